@@ -0,0 +1,19 @@
+#!/bin/sh
+set -e
+
+#
+# creates or updates Tiller resources, the kubectl command is passed in
+# the arguments, e.g. "apply -f" or "replace --force -f"
+#
+/usr/local/bin/kubectl "$@" /var/lib/gravity/resources/resources.yaml
+
+#
+# releases migrated to Helm 3 are recorded in the helm-release-backend
+# ConfigMap (see "gravity app migrate-releases"), keep Tiller stopped
+# for such clusters
+#
+backend=$(/usr/local/bin/kubectl get configmaps/helm-release-backend --namespace=kube-system \
+    --output=jsonpath='{.data.backend}' --ignore-not-found)
+if [ "$backend" = "helm3" ]; then
+    /usr/local/bin/kubectl scale deployments/tiller-deploy --namespace=kube-system --replicas=0
+fi
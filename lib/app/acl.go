@@ -235,6 +235,14 @@ func (r *ApplicationsACL) FetchChart(locator loc.Locator) (io.ReadCloser, error)
 	return r.applications.FetchChart(locator)
 }
 
+// FetchChartProvenance returns provenance file of the specified application chart.
+func (r *ApplicationsACL) FetchChartProvenance(locator loc.Locator) (io.Reader, error) {
+	if err := r.checkApp(locator, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.applications.FetchChartProvenance(locator)
+}
+
 // FetchIndexFile returns Helm chart repository index file data.
 func (r *ApplicationsACL) FetchIndexFile() (io.Reader, error) {
 	if err := r.check(defaults.SystemAccountOrg, teleservices.VerbRead); err != nil {
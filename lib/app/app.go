@@ -113,6 +113,9 @@ type Applications interface {
 	// FetchChart returns Helm chart package with the specified application.
 	FetchChart(loc.Locator) (io.ReadCloser, error)
 
+	// FetchChartProvenance returns provenance file of the specified application chart.
+	FetchChartProvenance(loc.Locator) (io.Reader, error)
+
 	// FetchIndexFile returns Helm chart repository index file data.
 	FetchIndexFile() (io.Reader, error)
 }
@@ -235,6 +238,8 @@ type HookRunRequest struct {
 	// which will be replaced with the ID of the effective service user during
 	// installation and when running application hooks.
 	ServiceUser storage.OSUser
+	// Recorder optionally persists the output of each hook attempt
+	Recorder HookRecorder `json:"-"`
 }
 
 // Check validates this request
@@ -272,6 +277,9 @@ type InstallerRequest struct {
 	CACert string `json:"ca_cert,omitempty"`
 	// EncryptionKey is encryption key to encrypt installer packages with
 	EncryptionKey string `json:"encryption_key,omitempty"`
+	// IntermediateRuntimes lists the intermediate runtimes to package
+	// with the installer for multi-hop upgrades
+	IntermediateRuntimes []loc.Locator `json:"intermediate_runtimes,omitempty"`
 }
 
 // Check validates this request
@@ -292,11 +300,12 @@ func (r InstallerRequest) ToRaw() (*InstallerRequestRaw, error) {
 		return nil, trace.Wrap(err)
 	}
 	return &InstallerRequestRaw{
-		Account:        r.Account,
-		Application:    r.Application,
-		TrustedCluster: json.RawMessage(bytes),
-		CACert:         r.CACert,
-		EncryptionKey:  r.EncryptionKey,
+		Account:              r.Account,
+		Application:          r.Application,
+		TrustedCluster:       json.RawMessage(bytes),
+		CACert:               r.CACert,
+		EncryptionKey:        r.EncryptionKey,
+		IntermediateRuntimes: r.IntermediateRuntimes,
 	}, nil
 }
 
@@ -313,6 +322,8 @@ type InstallerRequestRaw struct {
 	CACert string `json:"ca_cert,omitempty"`
 	// EncryptionKey is encryption key to encrypt installer packages with
 	EncryptionKey string `json:"encryption_key,omitempty"`
+	// IntermediateRuntimes lists the intermediate runtimes to package with the installer
+	IntermediateRuntimes []loc.Locator `json:"intermediate_runtimes,omitempty"`
 }
 
 // ToNative converts the request from API-friendly to its regular format
@@ -322,11 +333,12 @@ func (r InstallerRequestRaw) ToNative() (*InstallerRequest, error) {
 		return nil, trace.Wrap(err)
 	}
 	return &InstallerRequest{
-		Account:        r.Account,
-		Application:    r.Application,
-		TrustedCluster: cluster,
-		CACert:         r.CACert,
-		EncryptionKey:  r.EncryptionKey,
+		Account:              r.Account,
+		Application:          r.Application,
+		TrustedCluster:       cluster,
+		CACert:               r.CACert,
+		EncryptionKey:        r.EncryptionKey,
+		IntermediateRuntimes: r.IntermediateRuntimes,
 	}, nil
 }
 
@@ -373,6 +385,8 @@ type ImportRequest struct {
 	SetImages []loc.DockerImage `json:"set_images"`
 	// SetDeps defines a list of package dependencies that will be set to the specified version
 	SetDeps []loc.Locator `json:"set_deps"`
+	// Labels optionally specifies the labels to attach to the application package
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // DeleteRequest describes a request to delete an application
@@ -20,11 +20,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/gravitational/gravity/lib/app/hooks"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/trace"
@@ -41,8 +43,78 @@ func RunAppHook(ctx context.Context, apps Applications, req HookRunRequest) (*Ho
 }
 
 // StreamAppHook launches the specified hook and starts streaming its
-// output into the provided writer until the job completes
+// output into the provided writer until the job completes.
+//
+// A failed hook is retried as many times as configured in its spec.
+// If the request specifies a recorder, the output of each attempt is
+// recorded with it.
 func StreamAppHook(ctx context.Context, apps Applications, req HookRunRequest, wc io.WriteCloser) (*HookRef, error) {
+	defer wc.Close()
+	hook, err := CheckHasAppHook(apps, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if hook.Host != nil {
+		return nil, trace.BadParameter("%v hook of %v runs as a host script",
+			req.Hook, req.Application)
+	}
+	var ref *HookRef
+	err = retryHook(ctx, *hook, wc, req.Recorder, storage.HookAttempt{
+		Application: req.Application.String(),
+		Hook:        string(req.Hook),
+	}, func(w io.Writer, record *storage.HookAttempt) (err error) {
+		ref, err = streamAppHookAttempt(ctx, apps, req, w)
+		if ref != nil {
+			record.JobName = ref.Name
+		}
+		return trace.Wrap(err)
+	})
+	return ref, trace.Wrap(err)
+}
+
+// retryHook runs the hook attempts with run until an attempt succeeds or
+// the retries configured for the hook are exhausted.
+// Each attempt is recorded with the recorder, if set, using record as a template
+func retryHook(ctx context.Context, hook schema.Hook, w io.Writer, recorder HookRecorder, record storage.HookAttempt,
+	run func(w io.Writer, record *storage.HookAttempt) error) error {
+	backoff, err := hook.RetryBackoff()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for attempt := 1; ; attempt++ {
+		output := utils.NewTailBuffer(defaults.HookAttemptOutputMaxBytes)
+		record := record
+		record.Attempt = attempt
+		record.Started = time.Now().UTC()
+		err := run(io.MultiWriter(w, output), &record)
+		if recorder != nil {
+			record.Completed = time.Now().UTC()
+			record.Output = output.String()
+			if err != nil {
+				record.Error = trace.UserMessage(err)
+			}
+			if errRecord := recorder.RecordHookAttempt(record); errRecord != nil {
+				log.Warnf("Failed to record attempt %v of hook %v: %v.",
+					attempt, record.Hook, trace.DebugReport(errRecord))
+			}
+		}
+		if err == nil || attempt > hook.Retries || ctx.Err() != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Fprintf(w, "Hook %v failed (attempt %v of %v), retrying in %v.\n",
+			record.Hook, attempt, hook.Retries+1, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return trace.Wrap(err)
+		}
+		backoff *= 2
+	}
+}
+
+// streamAppHookAttempt runs a single attempt of the specified hook
+// streaming its output into the provided writer
+func streamAppHookAttempt(ctx context.Context, apps Applications, req HookRunRequest, w io.Writer) (*HookRef, error) {
 	ref, err := apps.StartAppHook(ctx, req)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -53,8 +125,7 @@ func StreamAppHook(ctx context.Context, apps Applications, req HookRunRequest, w
 
 	go func() {
 		defer localCancel()
-		err := apps.StreamAppHookLogs(ctx, *ref, wc)
-		wc.Close()
+		err := apps.StreamAppHookLogs(ctx, *ref, w)
 		if err != nil && !trace.IsEOF(err) {
 			log.Warnf("Failed to stream logs for hook %v: %v",
 				ref, trace.DebugReport(err))
@@ -84,6 +155,12 @@ func StreamAppHook(ctx context.Context, apps Applications, req HookRunRequest, w
 	return ref, trace.Wrap(err)
 }
 
+// HookRecorder persists application hook attempts
+type HookRecorder interface {
+	// RecordHookAttempt persists a single hook attempt
+	RecordHookAttempt(storage.HookAttempt) error
+}
+
 // CheckHasAppHook checks if the app has specified hook
 func CheckHasAppHook(apps Applications, req HookRunRequest) (*schema.Hook, error) {
 	app, err := apps.GetApp(req.Application)
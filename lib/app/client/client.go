@@ -390,6 +390,14 @@ func (c *Client) FetchChart(locator loc.Locator) (io.ReadCloser, error) {
 		locator.Name, locator.Version)), url.Values{})
 }
 
+// FetchChartProvenance returns provenance file of the specified application chart.
+//
+// GET charts/:name.prov
+func (c *Client) FetchChartProvenance(locator loc.Locator) (io.Reader, error) {
+	return c.getFile(c.Endpoint("charts", helmutils.ToProvenanceFilename(
+		locator.Name, locator.Version)), url.Values{})
+}
+
 // FetchIndexFile returns Helm chart repository index file data.
 //
 // GET charts/index.yaml
@@ -35,8 +35,12 @@ func GetDependencies(app *Application, apps Applications) (result *Dependencies,
 	}
 	result = &Dependencies{}
 	result.Packages = append(result.Packages, state.packages...)
+	// gravity and teleport packages for other architectures the image supports
+	result.Packages = append(result.Packages, app.Manifest.ArchDependencies(state.packages)...)
 	if state.runtimePackage != nil {
 		result.Packages = append(result.Packages, *state.runtimePackage)
+		// runtime packages for other architectures the image supports
+		result.Packages = append(result.Packages, app.Manifest.ArchPackages(*state.runtimePackage)...)
 	}
 	for _, locator := range state.apps {
 		if !locator.IsEqualTo(app.Package) {
@@ -88,9 +92,14 @@ type Dependencies struct {
 
 func getDependencies(app *Application, apps Applications, state *state) error {
 	log.Infof("Getting dependencies for %v.", app.Package)
+	var runtimePackages []loc.Locator
+	for _, runtimePackage := range app.Manifest.NodeProfiles.RuntimePackages() {
+		runtimePackages = append(runtimePackages, runtimePackage)
+		runtimePackages = append(runtimePackages, app.Manifest.ArchPackages(runtimePackage)...)
+	}
 	packageDeps := loc.Deduplicate(append(
 		app.Manifest.Dependencies.GetPackages(),
-		app.Manifest.NodeProfiles.RuntimePackages()...))
+		runtimePackages...))
 	for _, dependency := range packageDeps {
 		packageName := dependency.String()
 		if _, ok := state.visitedPackages[packageName]; !ok {
@@ -48,12 +48,20 @@ type ImageService interface {
 	// Returns the list of images synced
 	Sync(ctx context.Context, dir string, progress utils.Emitter) ([]TagSpec, error)
 
+	// SyncWithDigests synchronizes the contents of dir with this private docker registry
+	// like Sync and verifies that the images have the specified manifest digests
+	// keyed by image name and tag
+	SyncWithDigests(ctx context.Context, dir string, digests map[string]string, progress utils.Emitter) ([]TagSpec, error)
+
 	// Wrap translates the specified image name to point to the private registry.
 	Wrap(image string) string
 
 	// Unwrap translates the specified image name to point to the original repository
 	// if it's prefixed with this registry address - functional inverse of Wrap
 	Unwrap(image string) string
+
+	// HasImage returns true if the specified image is present in this private docker registry
+	HasImage(ctx context.Context, image string) (bool, error)
 }
 
 // DockerPuller defines an interface to pull images
@@ -0,0 +1,103 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+
+	"github.com/gravitational/trace"
+)
+
+// ImageDigests returns the manifest digests of the images in the specified
+// registry directory keyed by the image name and tag, e.g. repo:tag
+func ImageDigests(ctx context.Context, dir string) (map[string]string, error) {
+	store, err := openLocal(dir)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to open local directory %q as local registry", dir)
+	}
+	repos, err := ListRepos(ctx, store)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to list local repositories in %q", dir)
+	}
+	digests := make(map[string]string)
+	for _, name := range repos {
+		repo, err := store.Repository(ctx, name)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		tags := repo.Tags(ctx)
+		all, err := tags.All(ctx)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, tag := range all {
+			desc, err := tags.Get(ctx, tag)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			digests[TagSpec{Name: name, Version: tag}.String()] = desc.Digest.String()
+		}
+	}
+	return digests, nil
+}
+
+// PinImage replaces the tag of the specified image with its manifest digest.
+// The image registry, if any, is retained and ignored for the digest lookup.
+// Returns false if the image is not in digests
+func PinImage(image string, digests map[string]string) (string, bool) {
+	parsed, err := loc.ParseDockerImage(image)
+	if err != nil || strings.HasPrefix(parsed.Tag, "sha256:") {
+		return image, false
+	}
+	tag := TagSpec{Name: parsed.Repository, Version: parsed.Tag}
+	if tag.Version == "" {
+		tag.Version = defaultTag
+	}
+	digest, ok := digests[tag.String()]
+	if !ok {
+		return image, false
+	}
+	parsed.Tag = digest
+	return parsed.String(), true
+}
+
+// DigestLabels returns the package labels recording the specified image digests
+func DigestLabels(digests map[string]string) map[string]string {
+	if len(digests) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(digests))
+	for image, digest := range digests {
+		labels[pack.ImageDigestLabelPrefix+image] = digest
+	}
+	return labels
+}
+
+// DigestsFromLabels returns the image digests recorded in the specified package labels
+func DigestsFromLabels(labels map[string]string) map[string]string {
+	digests := make(map[string]string)
+	for key, digest := range labels {
+		if strings.HasPrefix(key, pack.ImageDigestLabelPrefix) {
+			digests[strings.TrimPrefix(key, pack.ImageDigestLabelPrefix)] = digest
+		}
+	}
+	return digests
+}
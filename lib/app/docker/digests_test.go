@@ -0,0 +1,95 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/docker/distribution/context"
+	. "gopkg.in/check.v1"
+)
+
+type DigestsSuite struct {
+	dir      string
+	registry *Registry
+}
+
+var _ = Suite(&DigestsSuite{})
+
+func (s *DigestsSuite) SetUpTest(c *C) {
+	s.dir = c.MkDir()
+	CreateTestImage(c, s.dir, "app", "1.0",
+		TestLayer(c, map[string]string{"etc/a": "a"}))
+	var err error
+	s.registry, err = NewRegistry(BasicConfiguration("127.0.0.1:0", c.MkDir()))
+	c.Assert(err, IsNil)
+	c.Assert(s.registry.Start(), IsNil)
+}
+
+func (s *DigestsSuite) TearDownTest(c *C) {
+	s.registry.Close()
+}
+
+func (s *DigestsSuite) TestPinsImages(c *C) {
+	digests, err := ImageDigests(context.Background(), s.dir)
+	c.Assert(err, IsNil)
+	c.Assert(digests, HasLen, 1)
+	digest := digests["app:1.0"]
+	c.Assert(digest, Matches, "sha256:[0-9a-f]{64}")
+
+	var testCases = []struct {
+		image    string
+		expected string
+		pinned   bool
+	}{
+		{image: "app:1.0", expected: "app@" + digest, pinned: true},
+		{image: "leader.telekube.local:5000/app:1.0", expected: "leader.telekube.local:5000/app@" + digest, pinned: true},
+		{image: "app:2.0", expected: "app:2.0"},
+		{image: "app@" + digest, expected: "app@" + digest},
+	}
+	for _, tc := range testCases {
+		pinned, ok := PinImage(tc.image, digests)
+		c.Assert(pinned, Equals, tc.expected, Commentf(tc.image))
+		c.Assert(ok, Equals, tc.pinned, Commentf(tc.image))
+	}
+	c.Assert(DigestsFromLabels(DigestLabels(digests)), DeepEquals, digests)
+}
+
+func (s *DigestsSuite) TestVerifiesDigestsOnSync(c *C) {
+	service, err := NewImageService(RegistryConnectionRequest{
+		RegistryAddress: s.registry.Addr(),
+	})
+	c.Assert(err, IsNil)
+
+	wrong := map[string]string{"app:1.0": "sha256:" + zeroes}
+	_, err = service.SyncWithDigests(context.Background(), s.dir, wrong, utils.NopEmitter())
+	c.Assert(err, ErrorMatches, "image app:1.0 has digest .*, expected .*")
+	exists, err := service.HasImage(context.Background(), "app:1.0")
+	c.Assert(err, IsNil)
+	c.Assert(exists, Equals, false, Commentf("image with unexpected digest should not be pushed"))
+
+	digests, err := ImageDigests(context.Background(), s.dir)
+	c.Assert(err, IsNil)
+	tags, err := service.SyncWithDigests(context.Background(), s.dir, digests, utils.NopEmitter())
+	c.Assert(err, IsNil)
+	c.Assert(tags, DeepEquals, []TagSpec{{Name: "app", Version: "1.0"}})
+	exists, err = service.HasImage(context.Background(), "app@"+digests["app:1.0"])
+	c.Assert(err, IsNil)
+	c.Assert(exists, Equals, true)
+}
+
+const zeroes = "0000000000000000000000000000000000000000000000000000000000000000"
@@ -39,6 +39,7 @@ import (
 
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/manifest/manifestlist"
 	"github.com/docker/distribution/registry/api/errcode"
 	registryclient "github.com/docker/distribution/registry/client"
 	registrystorage "github.com/docker/distribution/registry/storage"
@@ -46,6 +47,7 @@ import (
 	"github.com/docker/distribution/registry/storage/driver/filesystem"
 	"github.com/docker/libtrust"
 	"github.com/gravitational/trace"
+	"github.com/opencontainers/go-digest"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -137,7 +139,20 @@ type imageService struct {
 // dir is expected to be in docker registry 2.x format.
 //
 // Upon success, returns a list of images pushed to the registry.
-func (r *imageService) Sync(ctx context.Context, dir string, progress utils.Emitter) (installedTags []TagSpec, err error) {
+func (r *imageService) Sync(ctx context.Context, dir string, progress utils.Emitter) ([]TagSpec, error) {
+	return r.sync(ctx, dir, nil, progress)
+}
+
+// SyncWithDigests synchronizes the contents of the local directory specified with dir
+// with the contents of the remote registry like Sync and verifies that the images
+// have the manifest digests specified with digests keyed by image name and tag.
+//
+// Images are verified both before they are pushed and in the remote registry
+func (r *imageService) SyncWithDigests(ctx context.Context, dir string, digests map[string]string, progress utils.Emitter) ([]TagSpec, error) {
+	return r.sync(ctx, dir, digests, progress)
+}
+
+func (r *imageService) sync(ctx context.Context, dir string, digests map[string]string, progress utils.Emitter) (installedTags []TagSpec, err error) {
 	if err = r.connect(ctx); err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -181,6 +196,15 @@ func (r *imageService) Sync(ctx context.Context, dir string, progress utils.Emit
 			if err != nil {
 				return nil, trace.Wrap(err)
 			}
+			tagSpec := TagSpec{
+				Name:    localRepoName,
+				Version: tag,
+			}
+			expected, pinned := digests[tagSpec.String()]
+			if pinned && desc.Digest.String() != expected {
+				return nil, trace.BadParameter("image %v has digest %v, expected %v",
+					tagSpec, desc.Digest, expected)
+			}
 			localManifest, err := localManifests.Get(ctx, desc.Digest)
 			if err != nil {
 				return nil, trace.Wrap(err)
@@ -195,10 +219,6 @@ func (r *imageService) Sync(ctx context.Context, dir string, progress utils.Emit
 				}
 			}
 
-			tagSpec := TagSpec{
-				Name:    localRepoName,
-				Version: tag,
-			}
 			// remote registry either does not have this reference, or it is
 			// different from the local one
 			if remoteManifest == nil || !compareManifests(localManifest, remoteManifest) {
@@ -209,12 +229,31 @@ func (r *imageService) Sync(ctx context.Context, dir string, progress utils.Emit
 			} else {
 				progress.PrintStep("Image %s is up-to-date", tagSpec)
 			}
+			if pinned {
+				if err := verifyDigest(ctx, remoteTags, tagSpec, expected); err != nil {
+					return nil, trace.Wrap(err)
+				}
+			}
 			installedTags = append(installedTags, tagSpec)
 		}
 	}
 	return installedTags, nil
 }
 
+// verifyDigest verifies that the specified tag in the remote registry
+// has the expected manifest digest
+func verifyDigest(ctx context.Context, tags distribution.TagService, tag TagSpec, expected string) error {
+	desc, err := tags.Get(ctx, tag.Version)
+	if err != nil {
+		return trace.Wrap(err, "failed to verify digest of image %v", tag)
+	}
+	if desc.Digest.String() != expected {
+		return trace.BadParameter("image %v has digest %v in the registry, expected %v",
+			tag, desc.Digest, expected)
+	}
+	return nil
+}
+
 // Wrap translates the specified image to point to the private registry
 // this image service is managing if the image is not already pointing to it.
 func (r *imageService) Wrap(image string) string {
@@ -229,10 +268,49 @@ func (r *imageService) Wrap(image string) string {
 // Unwrap translates the specified image to point to the original repository
 // Its function is the inverse of Wrap.
 func (r *imageService) Unwrap(image string) (unwrapped string) {
-	unwrapped = TagFromString(image).String()
+	unwrapped = image
+	if !strings.Contains(image, "@") {
+		unwrapped = TagFromString(image).String()
+	}
 	return strings.TrimPrefix(unwrapped, fmt.Sprintf("%v/", r.RegistryAddress))
 }
 
+// HasImage returns true if the specified image is present in the registry.
+// The image registry, if any, is ignored.
+func (r *imageService) HasImage(ctx context.Context, image string) (bool, error) {
+	if err := r.connect(ctx); err != nil {
+		return false, trace.Wrap(err)
+	}
+	parsed, err := loc.ParseDockerImage(image)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	repo, err := r.remoteStore.Repository(ctx, parsed.Repository)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	if strings.HasPrefix(parsed.Tag, "sha256:") {
+		manifests, err := repo.Manifests(ctx)
+		if err != nil {
+			return false, trace.Wrap(err)
+		}
+		exists, err := manifests.Exists(ctx, digest.Digest(parsed.Tag))
+		return exists, trace.Wrap(err)
+	}
+	tag := parsed.Tag
+	if tag == "" {
+		tag = "latest"
+	}
+	_, err = repo.Tags(ctx).Get(ctx, tag)
+	if err != nil {
+		if _, ok := err.(distribution.ErrTagUnknown); ok || IsManifestUnknown(err) || isNameUnknown(err) {
+			return false, nil
+		}
+		return false, trace.Wrap(err)
+	}
+	return true, nil
+}
+
 func (r *imageService) connect(ctx context.Context) (err error) {
 	if r.remoteStore == nil {
 		r.remoteStore, err = ConnectRegistry(ctx, r.RegistryConnectionRequest)
@@ -448,6 +526,11 @@ func IsManifestUnknown(err error) bool {
 	return ("MANIFEST_UNKNOWN" == registryErrorCode(err))
 }
 
+// isNameUnknown returns true if the error indicates an unknown repository
+func isNameUnknown(err error) bool {
+	return registryErrorCode(err) == "NAME_UNKNOWN"
+}
+
 // registryErrorCode takes an error returned by registry client and tries
 // to recover the docker Registry API error code
 func registryErrorCode(err error) string {
@@ -476,12 +559,32 @@ func compareManifests(a, b distribution.Manifest) bool {
 
 // updateRepo takes a pair of local+remote repositories and makes the remote repo identical
 // to the local one.
+//
+// For a manifest list, the manifests of all its platforms are pushed before the list
 func (s *remoteStore) updateRepo(ctx context.Context, remote, local distribution.Repository, manifest distribution.Manifest, tag string) error {
 	s.Debugf("Pushing %[1]v --> %[2]v/%[1]v.", local.Named(), s.addr)
 	remoteManifests, err := remote.Manifests(ctx)
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if list, ok := manifest.(*manifestlist.DeserializedManifestList); ok {
+		localManifests, err := local.Manifests(ctx)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for _, desc := range list.Manifests {
+			platformManifest, err := localManifests.Get(ctx, desc.Digest)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			s.Debugf("Pushing manifest %v for %v/%v.", desc.Digest, desc.Platform.OS, desc.Platform.Architecture)
+			if err := s.updateRepo(ctx, remote, local, platformManifest, ""); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		_, err = remoteManifests.Put(ctx, manifest, distribution.WithTag(tag))
+		return trace.Wrap(err)
+	}
 	localBlobs := local.Blobs(ctx)
 	remoteBlobs := remote.Blobs(ctx)
 	// copy layers:
@@ -513,6 +616,10 @@ func (s *remoteStore) updateRepo(ctx context.Context, remote, local distribution
 		s.Debugf("Written %v bytes.", written)
 	}
 	s.Debugf("Updating manifest for %v.", local.Named())
-	_, err = remoteManifests.Put(ctx, manifest, distribution.WithTag(tag))
+	var options []distribution.ManifestServiceOption
+	if tag != "" {
+		options = append(options, distribution.WithTag(tag))
+	}
+	_, err = remoteManifests.Put(ctx, manifest, options...)
 	return trace.Wrap(err)
 }
@@ -0,0 +1,317 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// ImagePolicy restricts the images an application can vendor
+type ImagePolicy struct {
+	// Registries lists the registries the images can be pulled from
+	Registries []string `json:"registries,omitempty"`
+	// Repositories lists the fully-qualified repositories the images can
+	// be pulled from, e.g. docker.io/library/nginx. Supports * wildcards
+	Repositories []string `json:"repositories,omitempty"`
+	// ForbidLatest rejects the images tagged with latest or not tagged at all
+	ForbidLatest bool `json:"forbidLatest,omitempty"`
+	// RequireDigest rejects the images not pinned by digest
+	RequireDigest bool `json:"requireDigest,omitempty"`
+	// Vulnerabilities configures the vulnerability gate
+	Vulnerabilities *VulnerabilityGate `json:"vulnerabilities,omitempty"`
+}
+
+// VulnerabilityGate rejects the images with vulnerabilities found by a scanner
+type VulnerabilityGate struct {
+	// Reports lists the paths to the scanner reports in Trivy JSON format
+	Reports []string `json:"reports,omitempty"`
+	// Severity is the lowest severity of vulnerabilities that fails the build
+	Severity string `json:"severity,omitempty"`
+	// Ignore lists the IDs of vulnerabilities to ignore
+	Ignore []string `json:"ignore,omitempty"`
+	// AllowUnscanned allows the images without a scanner report.
+	// By default, such images violate the policy
+	AllowUnscanned bool `json:"allowUnscanned,omitempty"`
+
+	// vulnerabilities maps images to their vulnerabilities from the reports
+	vulnerabilities map[string][]Vulnerability
+}
+
+// Vulnerability is a vulnerability found in an image
+type Vulnerability struct {
+	// ID is the vulnerability ID, e.g. CVE-2019-1234
+	ID string `json:"VulnerabilityID"`
+	// Package is the name of the vulnerable package
+	Package string `json:"PkgName"`
+	// InstalledVersion is the installed version of the package
+	InstalledVersion string `json:"InstalledVersion"`
+	// FixedVersion is the version of the package with the fix
+	FixedVersion string `json:"FixedVersion"`
+	// Severity is the vulnerability severity
+	Severity string `json:"Severity"`
+}
+
+// String returns a textual representation of the vulnerability
+func (r Vulnerability) String() string {
+	out := fmt.Sprintf("%v (%v) in %v %v", r.ID, r.Severity, r.Package, r.InstalledVersion)
+	if r.FixedVersion != "" {
+		out += fmt.Sprintf(", fixed in %v", r.FixedVersion)
+	}
+	return out
+}
+
+// ReadImagePolicy reads the image policy from the file at the specified path.
+// Relative paths to the scanner reports are resolved against the policy file directory
+func ReadImagePolicy(filename string) (*ImagePolicy, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	var policy ImagePolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, trace.Wrap(err, "failed to parse image policy %v", filename)
+	}
+	if policy.Vulnerabilities != nil {
+		for i, report := range policy.Vulnerabilities.Reports {
+			if !filepath.IsAbs(report) {
+				policy.Vulnerabilities.Reports[i] = filepath.Join(filepath.Dir(filename), report)
+			}
+		}
+	}
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &policy, nil
+}
+
+// AddReports adds the specified scanner reports to the vulnerability gate
+func (r *ImagePolicy) AddReports(reports ...string) error {
+	if len(reports) == 0 {
+		return nil
+	}
+	if r.Vulnerabilities == nil {
+		r.Vulnerabilities = &VulnerabilityGate{}
+	}
+	r.Vulnerabilities.Reports = append(r.Vulnerabilities.Reports, reports...)
+	return trace.Wrap(r.CheckAndSetDefaults())
+}
+
+// CheckAndSetDefaults validates the policy, sets defaults and loads the scanner reports
+func (r *ImagePolicy) CheckAndSetDefaults() error {
+	for _, pattern := range r.Repositories {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return trace.BadParameter("invalid repository pattern %q", pattern)
+		}
+	}
+	if r.Vulnerabilities == nil {
+		return nil
+	}
+	gate := r.Vulnerabilities
+	if gate.Severity == "" {
+		gate.Severity = defaultSeverityThreshold
+	}
+	gate.Severity = strings.ToUpper(gate.Severity)
+	if _, ok := severities[gate.Severity]; !ok {
+		return trace.BadParameter("unknown severity %q, should be one of %v",
+			gate.Severity, severityNames)
+	}
+	gate.vulnerabilities = make(map[string][]Vulnerability)
+	for _, report := range gate.Reports {
+		if err := gate.readReport(report); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// Check validates the images against the policy.
+// The returned error summarizes the violations per image
+func (r ImagePolicy) Check(images []string) error {
+	violations, err := r.violations(images)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	var names []string
+	for image := range violations {
+		names = append(names, image)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%v image(s) violate the image policy:\n", len(names))
+	for _, image := range names {
+		fmt.Fprintf(&buf, "%v:\n", image)
+		for _, violation := range violations[image] {
+			fmt.Fprintf(&buf, "  - %v\n", violation)
+		}
+	}
+	return trace.BadParameter("%v", strings.TrimSpace(buf.String()))
+}
+
+// violations returns the policy violations of the specified images
+func (r ImagePolicy) violations(images []string) (map[string][]string, error) {
+	violations := make(map[string][]string)
+	for _, image := range images {
+		ref, err := ParseImageReference(image)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if !r.allowed(*ref) {
+			violations[image] = append(violations[image],
+				fmt.Sprintf("repository %v is not allowed", ref.Name()))
+		}
+		if r.ForbidLatest && ref.Digest == "" && ref.Tag == defaultTag {
+			violations[image] = append(violations[image], "uses the latest tag")
+		}
+		if r.RequireDigest && ref.Digest == "" {
+			violations[image] = append(violations[image], "is not pinned by digest")
+		}
+		if r.Vulnerabilities != nil {
+			if found := r.Vulnerabilities.check(*ref); len(found) != 0 {
+				violations[image] = append(violations[image], found...)
+			}
+		}
+	}
+	return violations, nil
+}
+
+// allowed returns true if the image is pulled from an allowed registry or repository
+func (r ImagePolicy) allowed(ref ImageReference) bool {
+	if len(r.Registries) == 0 && len(r.Repositories) == 0 {
+		return true
+	}
+	for _, registry := range r.Registries {
+		if normalizeRegistryHost(registry) == normalizeRegistryHost(ref.Domain) {
+			return true
+		}
+	}
+	for _, pattern := range r.Repositories {
+		if matched, _ := path.Match(pattern, ref.Name()); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns the violations for the vulnerabilities of the image at or
+// above the severity threshold. An image without a scanner report is
+// a violation unless unscanned images are allowed
+func (r VulnerabilityGate) check(ref ImageReference) (violations []string) {
+	vulnerabilities, ok := r.vulnerabilities[ref.String()]
+	if !ok {
+		if r.AllowUnscanned {
+			log.Warnf("No vulnerability scan results for %v.", ref)
+			return nil
+		}
+		return []string{"has no vulnerability scan report"}
+	}
+	threshold := severities[r.Severity]
+	for _, vulnerability := range vulnerabilities {
+		if severities[strings.ToUpper(vulnerability.Severity)] < threshold {
+			continue
+		}
+		if utils.StringInSlice(r.Ignore, vulnerability.ID) {
+			continue
+		}
+		violations = append(violations, vulnerability.String())
+	}
+	return violations
+}
+
+// readReport reads the vulnerabilities from the Trivy JSON report at the specified path
+func (r *VulnerabilityGate) readReport(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	var artifact string
+	var results []trivyResult
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		// reports before schema version 2 are lists of results with the
+		// image in the target of the first result, e.g. "nginx:1.17 (debian 10.2)"
+		if err := json.Unmarshal(data, &results); err != nil {
+			return trace.Wrap(err, "failed to parse scanner report %v", filename)
+		}
+		if len(results) != 0 {
+			artifact = strings.SplitN(results[0].Target, " (", 2)[0]
+		}
+	} else {
+		var report trivyReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return trace.Wrap(err, "failed to parse scanner report %v", filename)
+		}
+		artifact, results = report.ArtifactName, report.Results
+	}
+	if artifact == "" {
+		return trace.BadParameter("scanner report %v does not specify the image", filename)
+	}
+	ref, err := ParseImageReference(artifact)
+	if err != nil {
+		return trace.Wrap(err, "invalid image in scanner report %v", filename)
+	}
+	vulnerabilities := r.vulnerabilities[ref.String()]
+	for _, result := range results {
+		vulnerabilities = append(vulnerabilities, result.Vulnerabilities...)
+	}
+	r.vulnerabilities[ref.String()] = vulnerabilities
+	return nil
+}
+
+// trivyReport is the Trivy JSON report of schema version 2
+type trivyReport struct {
+	// ArtifactName is the scanned image
+	ArtifactName string `json:"ArtifactName"`
+	// Results lists the results per scan target
+	Results []trivyResult `json:"Results"`
+}
+
+// trivyResult lists the vulnerabilities of a scan target
+type trivyResult struct {
+	// Target is the scanned target
+	Target string `json:"Target"`
+	// Vulnerabilities lists the found vulnerabilities
+	Vulnerabilities []Vulnerability `json:"Vulnerabilities"`
+}
+
+// severities maps the vulnerability severities to their order
+var severities = map[string]int{
+	"UNKNOWN":  0,
+	"LOW":      1,
+	"MEDIUM":   2,
+	"HIGH":     3,
+	"CRITICAL": 4,
+}
+
+var severityNames = []string{"UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+// defaultSeverityThreshold is the default lowest severity that fails the build
+const defaultSeverityThreshold = "HIGH"
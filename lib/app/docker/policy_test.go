@@ -0,0 +1,113 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+type ImagePolicySuite struct{}
+
+var _ = Suite(&ImagePolicySuite{})
+
+func (s *ImagePolicySuite) TestReportsViolationsPerImage(c *C) {
+	dir := c.MkDir()
+	writeFile(c, filepath.Join(dir, "nginx.json"), `{
+  "SchemaVersion": 2,
+  "ArtifactName": "nginx:1.17",
+  "Results": [{"Target": "nginx:1.17 (debian 10.2)", "Vulnerabilities": [
+    {"VulnerabilityID": "CVE-2019-0001", "PkgName": "openssl", "InstalledVersion": "1.1.1c", "FixedVersion": "1.1.1d", "Severity": "CRITICAL"},
+    {"VulnerabilityID": "CVE-2019-0002", "PkgName": "libc", "InstalledVersion": "2.28", "Severity": "LOW"},
+    {"VulnerabilityID": "CVE-2019-0003", "PkgName": "curl", "InstalledVersion": "7.64", "Severity": "HIGH"}
+  ]}]
+}`)
+	writeFile(c, filepath.Join(dir, "app.json"), `[
+  {"Target": "quay.io/example/app:1.0 (alpine 3.10.2)", "Vulnerabilities": [
+    {"VulnerabilityID": "CVE-2019-0004", "PkgName": "musl", "InstalledVersion": "1.1.22", "Severity": "MEDIUM"}
+  ]}
+]`)
+	writeFile(c, filepath.Join(dir, "policy.yaml"), `
+registries: [quay.io]
+repositories: ["docker.io/library/*"]
+forbidLatest: true
+vulnerabilities:
+  reports: [nginx.json, app.json]
+  severity: high
+  ignore: [CVE-2019-0003]
+`)
+	policy, err := ReadImagePolicy(filepath.Join(dir, "policy.yaml"))
+	c.Assert(err, IsNil)
+
+	violations, err := policy.violations([]string{
+		"nginx:1.17",
+		"quay.io/example/app:1.0",
+		"quay.io/example/other",
+		"gcr.io/example/app:1.0",
+	})
+	c.Assert(err, IsNil)
+	c.Assert(violations, DeepEquals, map[string][]string{
+		"nginx:1.17": {
+			"CVE-2019-0001 (CRITICAL) in openssl 1.1.1c, fixed in 1.1.1d",
+		},
+		"quay.io/example/other": {
+			"uses the latest tag",
+			"has no vulnerability scan report",
+		},
+		"gcr.io/example/app:1.0": {
+			"repository gcr.io/example/app is not allowed",
+			"has no vulnerability scan report",
+		},
+	})
+
+	err = policy.Check([]string{"nginx:1.17", "quay.io/example/app:1.0"})
+	c.Assert(err, ErrorMatches, `(?s)1 image\(s\) violate the image policy:\nnginx:1.17:\n  - CVE-2019-0001.*`)
+}
+
+func (s *ImagePolicySuite) TestAllowsUnscannedImages(c *C) {
+	policy := ImagePolicy{Vulnerabilities: &VulnerabilityGate{AllowUnscanned: true}}
+	c.Assert(policy.CheckAndSetDefaults(), IsNil)
+	c.Assert(policy.Check([]string{"nginx:1.17"}), IsNil)
+
+	policy.Vulnerabilities.AllowUnscanned = false
+	c.Assert(policy.Check([]string{"nginx:1.17"}), ErrorMatches,
+		`(?s).*nginx:1.17:\n  - has no vulnerability scan report`)
+}
+
+func (s *ImagePolicySuite) TestRequiresDigest(c *C) {
+	policy := ImagePolicy{RequireDigest: true}
+	c.Assert(policy.CheckAndSetDefaults(), IsNil)
+	violations, err := policy.violations([]string{
+		"nginx:1.17",
+		"nginx@sha256:0e6c2a8a2fbcb3d8a9b4d4c4ebd6e9b5d0a5c1fbb7fd1f3e1c7c1d1b8a3e3b6f",
+	})
+	c.Assert(err, IsNil)
+	c.Assert(violations, DeepEquals, map[string][]string{
+		"nginx:1.17": {"is not pinned by digest"},
+	})
+}
+
+func (s *ImagePolicySuite) TestRejectsInvalidSeverity(c *C) {
+	policy := ImagePolicy{Vulnerabilities: &VulnerabilityGate{Severity: "severe"}}
+	c.Assert(policy.CheckAndSetDefaults(), ErrorMatches, `unknown severity "SEVERE".*`)
+}
+
+func writeFile(c *C, path, contents string) {
+	c.Assert(ioutil.WriteFile(path, []byte(contents), 0600), IsNil)
+}
@@ -0,0 +1,907 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"archive/tar"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/run"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema1"
+	"github.com/docker/distribution/manifest/schema2"
+	registryclient "github.com/docker/distribution/registry/client"
+	"github.com/docker/distribution/registry/client/auth"
+	"github.com/docker/distribution/registry/client/auth/challenge"
+	"github.com/docker/distribution/registry/client/transport"
+	dockerarchive "github.com/docker/docker/pkg/archive"
+	"github.com/gravitational/trace"
+	"github.com/opencontainers/go-digest"
+	log "github.com/sirupsen/logrus"
+)
+
+// RegistryPullerConfig defines the configuration of the registry puller
+type RegistryPullerConfig struct {
+	// DockerConfig is the path to the docker client configuration file
+	// with registry credentials.
+	// Defaults to ~/.docker/config.json if present
+	DockerConfig string
+	// Mirrors lists registry mirrors to try before the registry itself
+	Mirrors []RegistryMirror
+	// Rewrites lists rules to rewrite image references with before pulling
+	Rewrites []ImageRewrite
+	// InsecureRegistries lists registries that can be accessed
+	// over plain HTTP or without verifying their certificates
+	InsecureRegistries []string
+	// Parallel defines the number of layers to fetch in parallel
+	Parallel int
+	// Architectures lists the architectures to vendor the images for.
+	// Defaults to amd64
+	Architectures []string
+	// FieldLogger is used for logging
+	log.FieldLogger
+}
+
+// CheckAndSetDefaults validates the config and sets defaults
+func (r *RegistryPullerConfig) CheckAndSetDefaults() error {
+	if r.DockerConfig == "" {
+		r.DockerConfig = defaultDockerConfig()
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = log.WithField(trace.Component, "registry-puller")
+	}
+	if len(r.Architectures) == 0 {
+		r.Architectures = []string{defaults.Architecture}
+	}
+	return nil
+}
+
+// RegistryMirror defines a mirror of a docker registry
+type RegistryMirror struct {
+	// Registry is the address of the mirrored registry, e.g. docker.io
+	Registry string
+	// Mirror is the address of the mirror
+	Mirror string
+}
+
+// ParseRegistryMirror parses a registry mirror in the <registry>=<mirror> format
+func ParseRegistryMirror(spec string) (*RegistryMirror, error) {
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, trace.BadParameter("registry mirror %q should be in <registry>=<mirror> format", spec)
+	}
+	return &RegistryMirror{Registry: parts[0], Mirror: parts[1]}, nil
+}
+
+// ImageRewrite replaces the prefix of the matching image references
+type ImageRewrite struct {
+	// From is the prefix of the fully-qualified image reference to replace,
+	// e.g. docker.io/library/
+	From string
+	// To is the replacement prefix
+	To string
+}
+
+// ParseImageRewrite parses an image rewrite rule in the <prefix>=<replacement> format
+func ParseImageRewrite(spec string) (*ImageRewrite, error) {
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, trace.BadParameter("image rewrite %q should be in <prefix>=<replacement> format", spec)
+	}
+	return &ImageRewrite{From: parts[0], To: parts[1]}, nil
+}
+
+// NewRegistryPuller returns a new puller that fetches images
+// directly from docker registries without a docker daemon
+func NewRegistryPuller(config RegistryPullerConfig) (*RegistryPuller, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	credentials, err := readDockerConfig(config.DockerConfig)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &RegistryPuller{
+		RegistryPullerConfig: config,
+		credentials:          credentials,
+		hosts:                make(map[string]*registryHost),
+	}, nil
+}
+
+// RegistryPuller copies images from remote docker registries
+// into a local registry directory
+type RegistryPuller struct {
+	RegistryPullerConfig
+	credentials *dockerConfig
+	mu          sync.Mutex
+	// hosts caches the connections to registry hosts
+	hosts map[string]*registryHost
+}
+
+// PullRequest describes a request to pull images into a local registry directory
+type PullRequest struct {
+	// Dir is the local registry directory
+	Dir string
+	// Images maps the references to store the images under in the local
+	// registry to the references of the images to pull
+	Images map[string]string
+	// Parallel defines the number of images to pull in parallel
+	Parallel int
+	// Progress reports the pull progress
+	Progress utils.Progress
+}
+
+// Pull copies the images specified in req into the local registry directory
+func (r *RegistryPuller) Pull(ctx context.Context, req PullRequest) error {
+	if req.Progress == nil {
+		req.Progress = utils.NewNopProgress()
+	}
+	local, err := openLocal(req.Dir)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	targets := make([]string, 0, len(req.Images))
+	for target := range req.Images {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	group, groupCtx := run.WithContext(ctx, run.WithParallel(req.Parallel))
+	for _, target := range targets {
+		target, source := target, req.Images[target]
+		group.Go(groupCtx, func() error {
+			if err := r.pullImage(groupCtx, local, source, target, r.Architectures); err != nil {
+				return trace.Wrap(err, "failed to pull %v", source)
+			}
+			req.Progress.PrintSubStep("Vendored image %v", source)
+			return nil
+		})
+	}
+	return trace.Wrap(group.Wait())
+}
+
+// Unpack extracts the filesystem of the specified image into dir.
+// The file ownership is preserved so unpacking requires root privileges
+func (r *RegistryPuller) Unpack(ctx context.Context, image, dir string) error {
+	if os.Geteuid() != 0 {
+		return trace.AccessDenied("extracting the filesystem of image %v requires root "+
+			"privileges to preserve the file ownership", image)
+	}
+	return utils.WithTempDir(func(registryDir string) error {
+		local, err := openLocal(registryDir)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		ref, err := ParseImageReference(image)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		// the image filesystem is only unpacked for the default architecture
+		if err := r.pullImage(ctx, local, image, ref.Repository, []string{defaults.Architecture}); err != nil {
+			return trace.Wrap(err, "failed to pull %v", image)
+		}
+		repo, err := local.Repository(ctx, ref.Repository)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		desc, err := repo.Tags(ctx).Get(ctx, defaultTag)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		manifests, err := repo.Manifests(ctx)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		manifest, err := manifests.Get(ctx, desc.Digest)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		blobs := repo.Blobs(ctx)
+		for _, layer := range imageLayers(manifest) {
+			err := applyLayer(dir, func() (io.ReadCloser, error) {
+				return blobs.Open(ctx, layer)
+			})
+			if err != nil {
+				return trace.Wrap(err, "failed to apply layer %v of %v", layer, image)
+			}
+		}
+		return nil
+	}, "registry")
+}
+
+// ImageReference is a fully-qualified docker image reference
+type ImageReference struct {
+	// Domain is the registry domain
+	Domain string
+	// Repository is the repository path within the registry
+	Repository string
+	// Tag is the image tag
+	Tag string
+	// Digest is the image digest
+	Digest digest.Digest
+}
+
+// ParseImageReference parses the specified image into a fully-qualified reference,
+// images without a domain are attributed to docker.io and without tag or digest
+// are tagged with latest
+func ParseImageReference(image string) (*ImageReference, error) {
+	parsed, err := Parse(image)
+	if err != nil {
+		return nil, trace.Wrap(err, "invalid image reference %q", image)
+	}
+	named, ok := parsed.(Named)
+	if !ok {
+		return nil, trace.BadParameter("image reference %q has no name", image)
+	}
+	ref := &ImageReference{
+		Domain:     defaultDomain,
+		Repository: named.Name(),
+	}
+	// the first component is only treated as the registry domain if it
+	// looks like a host name, e.g. gravitational/debian-tall is on docker.io
+	parts := strings.SplitN(named.Name(), "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Domain, ref.Repository = parts[0], parts[1]
+	}
+	if ref.Domain == defaultDomain && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = officialRepoName + "/" + ref.Repository
+	}
+	if tagged, ok := parsed.(Tagged); ok {
+		ref.Tag = tagged.Tag()
+	}
+	if digested, ok := parsed.(Digested); ok {
+		ref.Digest = digested.Digest()
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+	return ref, nil
+}
+
+// Name returns the reference without tag or digest
+func (r ImageReference) Name() string {
+	return r.Domain + "/" + r.Repository
+}
+
+// String returns the reference as a string
+func (r ImageReference) String() string {
+	out := r.Name()
+	if r.Tag != "" {
+		out += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		out += "@" + r.Digest.String()
+	}
+	return out
+}
+
+// rewrite applies the first matching rewrite rule to the reference
+func (r *RegistryPuller) rewrite(image string) (*ImageReference, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, rule := range r.Rewrites {
+		if !strings.HasPrefix(ref.String(), rule.From) {
+			continue
+		}
+		rewritten := rule.To + strings.TrimPrefix(ref.String(), rule.From)
+		r.Debugf("Rewrote %v to %v.", image, rewritten)
+		return ParseImageReference(rewritten)
+	}
+	return ref, nil
+}
+
+// endpoints returns the hosts to pull the images of the specified domain from,
+// mirrors first
+func (r *RegistryPuller) endpoints(domain string) (hosts []string) {
+	for _, mirror := range r.Mirrors {
+		if mirror.Registry == domain {
+			hosts = append(hosts, mirror.Mirror)
+		}
+	}
+	if domain == defaultDomain {
+		return append(hosts, defaultRegistryHost)
+	}
+	return append(hosts, domain)
+}
+
+// pullImage copies the image specified with source into the local
+// registry under the target reference for the specified architectures
+func (r *RegistryPuller) pullImage(ctx context.Context, local *localStore, source, target string, architectures []string) error {
+	ref, err := r.rewrite(source)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var errors []error
+	for _, host := range r.endpoints(ref.Domain) {
+		logger := r.WithFields(log.Fields{"image": ref.String(), "host": host})
+		remote, err := r.repository(ctx, host, ref.Repository)
+		if err == nil {
+			err = r.copyImage(ctx, remote, local, *ref, target, architectures, logger)
+		}
+		if err == nil {
+			return nil
+		}
+		logger.WithError(err).Warn("Failed to pull image.")
+		errors = append(errors, trace.Wrap(err, "failed to pull from %v", host))
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// copyImage copies the image manifest and blobs from the remote repository.
+// A manifest list is reduced to the manifests of the specified architectures,
+// or resolved to the manifest of the architecture if there is only one
+func (r *RegistryPuller) copyImage(ctx context.Context, remote distribution.Repository, local *localStore, ref ImageReference, target string, architectures []string, logger log.FieldLogger) error {
+	manifests, err := remote.Manifests(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	manifest, err := getManifest(ctx, manifests, ref)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	targetName, targetTag := splitTarget(target)
+	localRepo, err := local.Repository(ctx, targetName)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	list, ok := manifest.(*manifestlist.DeserializedManifestList)
+	if !ok {
+		if err := checkImageArchitecture(ctx, remote.Blobs(ctx), manifest, ref, architectures); err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(r.copyManifest(ctx, remote, localRepo, manifest, targetTag, logger))
+	}
+	descs, err := platformManifests(list, ref, architectures)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, desc := range descs {
+		platformManifest, err := manifests.Get(ctx, desc.Digest)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		tag := ""
+		if len(descs) == 1 {
+			tag = targetTag
+		}
+		if err := r.copyManifest(ctx, remote, localRepo, platformManifest, tag, logger); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if len(descs) == 1 {
+		return nil
+	}
+	filtered, err := manifestlist.FromDescriptors(descs)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(putManifest(ctx, localRepo, filtered, targetTag))
+}
+
+// copyManifest copies the blobs referenced by the image manifest from the remote
+// repository and stores the manifest in the local repository
+func (r *RegistryPuller) copyManifest(ctx context.Context, remote, local distribution.Repository, manifest distribution.Manifest, tag string, logger log.FieldLogger) error {
+	remoteBlobs := remote.Blobs(ctx)
+	localBlobs := local.Blobs(ctx)
+	group, groupCtx := run.WithContext(ctx, run.WithParallel(r.Parallel))
+	for _, desc := range manifest.References() {
+		desc := desc
+		group.Go(groupCtx, func() error {
+			return trace.Wrap(copyBlob(groupCtx, remoteBlobs, localBlobs, desc, logger))
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(putManifest(ctx, local, manifest, tag))
+}
+
+// putManifest stores the manifest in the repository and tags it unless tag is empty
+func putManifest(ctx context.Context, repo distribution.Repository, manifest distribution.Manifest, tag string) error {
+	manifests, err := repo.Manifests(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	dgst, err := manifests.Put(ctx, manifest)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if tag == "" {
+		return nil
+	}
+	// the local storage does not tag the manifests on put
+	mediaType, _, err := manifest.Payload()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(repo.Tags(ctx).Tag(ctx, tag, distribution.Descriptor{
+		Digest:    dgst,
+		MediaType: mediaType,
+	}))
+}
+
+// getManifest fetches the manifest of the image with the specified reference
+func getManifest(ctx context.Context, manifests distribution.ManifestService, ref ImageReference) (distribution.Manifest, error) {
+	if ref.Digest != "" {
+		manifest, err := manifests.Get(ctx, ref.Digest)
+		return manifest, trace.Wrap(err)
+	}
+	manifest, err := manifests.Get(ctx, "", distribution.WithTag(ref.Tag))
+	return manifest, trace.Wrap(err)
+}
+
+// platformManifests returns the descriptors of the manifests for the specified
+// architectures from the manifest list
+func platformManifests(list *manifestlist.DeserializedManifestList, ref ImageReference, architectures []string) (descs []manifestlist.ManifestDescriptor, err error) {
+	for _, arch := range architectures {
+		var found bool
+		for _, desc := range list.Manifests {
+			if desc.Platform.OS == defaultPlatformOS && desc.Platform.Architecture == arch {
+				descs = append(descs, desc)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, trace.NotFound("image %v has no manifest for %v/%v",
+				ref, defaultPlatformOS, arch)
+		}
+	}
+	return descs, nil
+}
+
+// checkImageArchitecture makes sure that the single-platform image can run on
+// the specified architectures.
+// Only the schema2 images specify their architecture
+func checkImageArchitecture(ctx context.Context, blobs distribution.BlobStore, manifest distribution.Manifest, ref ImageReference, architectures []string) error {
+	m, ok := manifest.(*schema2.DeserializedManifest)
+	if !ok {
+		return nil
+	}
+	data, err := blobs.Get(ctx, m.Config.Digest)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var config struct {
+		Architecture string `json:"architecture"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return trace.Wrap(err, "failed to parse configuration of image %v", ref)
+	}
+	if config.Architecture == "" {
+		return nil
+	}
+	for _, arch := range architectures {
+		if arch != config.Architecture {
+			return trace.BadParameter("image %v is built for %v only and has no manifest for %v/%v",
+				ref, config.Architecture, defaultPlatformOS, arch)
+		}
+	}
+	return nil
+}
+
+// copyBlob copies the blob with the specified descriptor unless
+// the local repository already has it
+func copyBlob(ctx context.Context, remote, local distribution.BlobStore, desc distribution.Descriptor, logger log.FieldLogger) error {
+	if _, err := local.Stat(ctx, desc.Digest); err == nil {
+		logger.Debugf("Skipping layer %v.", desc.Digest)
+		return nil
+	}
+	reader, err := remote.Open(ctx, desc.Digest)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer reader.Close()
+	writer, err := local.Create(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer writer.Close()
+	logger.Debugf("Fetching layer %v.", desc.Digest)
+	if _, err := io.Copy(writer, reader); err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = writer.Commit(ctx, distribution.Descriptor{Digest: desc.Digest, MediaType: desc.MediaType})
+	return trace.Wrap(err)
+}
+
+// repository returns the remote repository with the specified name on the given host
+func (r *RegistryPuller) repository(ctx context.Context, host, name string) (distribution.Repository, error) {
+	registry, err := r.connect(host)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	named, err := parseNamed(name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	creds := r.credentials.forHost(host)
+	authorizer := auth.NewAuthorizer(registry.challenges,
+		auth.NewTokenHandler(registry.transport, creds, name, "pull"),
+		auth.NewBasicHandler(creds))
+	return registryclient.NewRepository(ctx, named, registry.addr,
+		transport.NewTransport(registry.transport, authorizer))
+}
+
+// connect pings the registry host and records its authentication challenges
+func (r *RegistryPuller) connect(host string) (*registryHost, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if registry, ok := r.hosts[host]; ok {
+		return registry, nil
+	}
+	insecure := utils.StringInSlice(r.InsecureRegistries, host)
+	schemes := []string{"https"}
+	if insecure {
+		schemes = append(schemes, "http")
+	}
+	var errors []error
+	for _, scheme := range schemes {
+		registry := &registryHost{
+			addr:       scheme + "://" + host,
+			transport:  newRegistryTransport(insecure),
+			challenges: challenge.NewSimpleManager(),
+		}
+		if err := registry.ping(); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		r.hosts[host] = registry
+		return registry, nil
+	}
+	return nil, trace.NewAggregate(errors...)
+}
+
+// registryHost is a connection to a docker registry host
+type registryHost struct {
+	addr       string
+	transport  *http.Transport
+	challenges challenge.Manager
+}
+
+func (r *registryHost) ping() error {
+	client := &http.Client{
+		Transport: r.transport,
+		Timeout:   registryPingTimeout,
+	}
+	resp, err := client.Get(r.addr + "/v2/")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	return trace.Wrap(r.challenges.AddResponse(resp))
+}
+
+func newRegistryTransport(insecure bool) *http.Transport {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		Dial: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).Dial,
+		TLSHandshakeTimeout: 30 * time.Second,
+	}
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return transport
+}
+
+// dockerConfig is the subset of the docker client configuration
+// file with registry credentials
+type dockerConfig struct {
+	// Auths maps registry addresses to their credentials
+	Auths map[string]dockerAuth `json:"auths"`
+}
+
+type dockerAuth struct {
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+// readDockerConfig reads registry credentials from the docker config file at path.
+// A missing file is not an error
+func readDockerConfig(path string) (*dockerConfig, error) {
+	config := &dockerConfig{}
+	if path == "" {
+		return config, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, trace.Wrap(err, "failed to parse docker config %v", path)
+	}
+	return config, nil
+}
+
+// forHost returns the credentials for the specified registry host
+func (r *dockerConfig) forHost(host string) *registryCredentials {
+	for addr, entry := range r.Auths {
+		if normalizeRegistryHost(addr) != normalizeRegistryHost(host) {
+			continue
+		}
+		creds := &registryCredentials{
+			username:     entry.Username,
+			password:     entry.Password,
+			refreshToken: entry.IdentityToken,
+		}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err == nil {
+				parts := strings.SplitN(string(decoded), ":", 2)
+				if len(parts) == 2 {
+					creds.username, creds.password = parts[0], parts[1]
+				}
+			}
+		}
+		return creds
+	}
+	return &registryCredentials{}
+}
+
+// normalizeRegistryHost strips the scheme and the path from the registry
+// address and maps the docker hub aliases to a single host
+func normalizeRegistryHost(addr string) string {
+	if u, err := url.Parse(addr); err == nil && u.Host != "" {
+		addr = u.Host
+	}
+	addr = strings.SplitN(addr, "/", 2)[0]
+	switch addr {
+	case defaultDomain, "index.docker.io", defaultRegistryHost:
+		return defaultDomain
+	}
+	return addr
+}
+
+// registryCredentials implements auth.CredentialStore for a single registry.
+// The same credentials are provided for the registry and its token service
+type registryCredentials struct {
+	sync.Mutex
+	username     string
+	password     string
+	refreshToken string
+}
+
+// Basic returns the username and password
+func (r *registryCredentials) Basic(*url.URL) (string, string) {
+	return r.username, r.password
+}
+
+// RefreshToken returns the identity token
+func (r *registryCredentials) RefreshToken(*url.URL, string) string {
+	r.Lock()
+	defer r.Unlock()
+	return r.refreshToken
+}
+
+// SetRefreshToken updates the identity token
+func (r *registryCredentials) SetRefreshToken(_ *url.URL, _, token string) {
+	r.Lock()
+	defer r.Unlock()
+	r.refreshToken = token
+}
+
+// imageLayers returns the layers of the image manifest from the base layer up
+func imageLayers(manifest distribution.Manifest) (layers []digest.Digest) {
+	switch m := manifest.(type) {
+	case *schema2.DeserializedManifest:
+		for _, layer := range m.Layers {
+			layers = append(layers, layer.Digest)
+		}
+	case *schema1.SignedManifest:
+		// schema1 lists the layers starting from the top one
+		for i := len(m.FSLayers) - 1; i >= 0; i-- {
+			layers = append(layers, m.FSLayers[i].BlobSum)
+		}
+	}
+	return layers
+}
+
+// applyLayer applies the image layer to dir.
+// The layer is read twice: the first pass validates the entries and removes
+// the files hidden by its whiteouts and the second pass extracts its contents.
+//
+// Entries that refer to a parent directory or resolve through a symbolic link
+// are rejected so the layer cannot modify files outside of dir.
+// The file ownership recorded in the layer is preserved
+func applyLayer(dir string, open func() (io.ReadCloser, error)) error {
+	// entries maps the paths extracted by this layer so far to their types
+	entries := make(map[string]byte)
+	err := forEachLayerEntry(open, func(hdr *tar.Header, _ io.Reader) error {
+		name, err := checkLayerPath(dir, hdr.Name, entries)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if hdr.Typeflag == tar.TypeLink {
+			if _, err := checkLayerPath(dir, hdr.Linkname, entries); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		base := filepath.Base(name)
+		parent := filepath.Join(dir, filepath.Dir(name))
+		switch {
+		case base == whiteoutOpaqueDir:
+			items, err := ioutil.ReadDir(parent)
+			if err != nil && !os.IsNotExist(err) {
+				return trace.ConvertSystemError(err)
+			}
+			for _, item := range items {
+				if err := os.RemoveAll(filepath.Join(parent, item.Name())); err != nil {
+					return trace.ConvertSystemError(err)
+				}
+			}
+		case strings.HasPrefix(base, whiteoutPrefix):
+			target := strings.TrimPrefix(base, whiteoutPrefix)
+			if err := os.RemoveAll(filepath.Join(parent, target)); err != nil {
+				return trace.ConvertSystemError(err)
+			}
+			delete(entries, filepath.Join(filepath.Dir(name), target))
+		default:
+			entries[name] = hdr.Typeflag
+		}
+		return nil
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	reader, writer := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(writer)
+		err := forEachLayerEntry(open, func(hdr *tar.Header, r io.Reader) error {
+			if strings.HasPrefix(filepath.Base(hdr.Name), whiteoutPrefix) {
+				return nil
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return trace.Wrap(err)
+			}
+			_, err := io.Copy(tw, r)
+			return trace.Wrap(err)
+		})
+		if err == nil {
+			err = tw.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+	defer reader.Close()
+	return trace.Wrap(dockerarchive.Untar(reader, dir, &dockerarchive.TarOptions{}))
+}
+
+// checkLayerPath returns the cleaned path of the layer entry relative to dir.
+// Returns an error if the path refers to a parent directory or if any of its
+// parent directories is a symbolic link, either in dir or among the entries
+// already extracted by the layer
+func checkLayerPath(dir, name string, entries map[string]byte) (string, error) {
+	for _, segment := range strings.Split(filepath.ToSlash(name), "/") {
+		if segment == ".." {
+			return "", trace.BadParameter("layer entry %q is outside of the image filesystem", name)
+		}
+	}
+	clean := strings.TrimPrefix(filepath.Clean("/"+name), "/")
+	var parent string
+	for _, segment := range strings.Split(filepath.Dir(clean), "/") {
+		if segment == "." || segment == "" {
+			continue
+		}
+		parent = filepath.Join(parent, segment)
+		if typ, ok := entries[parent]; ok {
+			if typ == tar.TypeSymlink {
+				return "", trace.BadParameter("layer entry %q resolves through symbolic link %v", name, parent)
+			}
+			continue
+		}
+		fi, err := os.Lstat(filepath.Join(dir, parent))
+		if err != nil && !os.IsNotExist(err) {
+			return "", trace.ConvertSystemError(err)
+		}
+		if err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			return "", trace.BadParameter("layer entry %q resolves through symbolic link %v", name, parent)
+		}
+	}
+	return clean, nil
+}
+
+// forEachLayerEntry invokes fn for every entry of the layer archive
+func forEachLayerEntry(open func() (io.ReadCloser, error), fn func(*tar.Header, io.Reader) error) error {
+	blob, err := open()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer blob.Close()
+	decompressed, err := dockerarchive.DecompressStream(blob)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer decompressed.Close()
+	tr := tar.NewReader(decompressed)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := fn(hdr, tr); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+}
+
+// splitTarget splits the local reference into name and tag or digest
+func splitTarget(target string) (name, tag string) {
+	if i := strings.Index(target, "@"); i != -1 {
+		return target[:i], ""
+	}
+	if i := strings.LastIndex(target, ":"); i != -1 && !strings.Contains(target[i:], "/") {
+		return target[:i], target[i+1:]
+	}
+	return target, defaultTag
+}
+
+// defaultDockerConfig returns the path to the docker client configuration file
+func defaultDockerConfig() string {
+	if dir := os.Getenv(constants.EnvDockerConfig); dir != "" {
+		return filepath.Join(dir, defaults.DockerConfigFile)
+	}
+	home := os.Getenv(constants.EnvHome)
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, defaults.DockerConfigDir, defaults.DockerConfigFile)
+}
+
+const (
+	// defaultTag is the tag of the images referenced without tag or digest
+	defaultTag = "latest"
+	// defaultRegistryHost is the host serving the docker hub registry API
+	defaultRegistryHost = "registry-1.docker.io"
+	// defaultPlatformOS is the OS of the images selected from manifest lists
+	defaultPlatformOS = "linux"
+	// whiteoutPrefix marks the files removed by an image layer
+	whiteoutPrefix = ".wh."
+	// whiteoutOpaqueDir marks the directory whose contents in the lower layers are hidden
+	whiteoutOpaqueDir = ".wh..wh..opq"
+	// registryPingTimeout is the timeout of the registry availability check
+	registryPingTimeout = 30 * time.Second
+)
@@ -0,0 +1,292 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+type RegistryPullerSuite struct {
+	registry *Registry
+}
+
+var _ = Suite(&RegistryPullerSuite{})
+
+func (s *RegistryPullerSuite) SetUpTest(c *C) {
+	dir := c.MkDir()
+	CreateTestImage(c, dir, "upstream/app", "1.0",
+		TestLayer(c, map[string]string{"etc/a": "a", "etc/b": "b"}),
+		TestLayer(c, map[string]string{"etc/.wh.a": "", "etc/c": "c"}))
+	createTestImageList(c, dir, "upstream/multiarch", "1.0", "amd64", "arm64", "ppc64le")
+	var err error
+	s.registry, err = NewRegistry(BasicConfiguration("127.0.0.1:0", dir))
+	c.Assert(err, IsNil)
+	c.Assert(s.registry.Start(), IsNil)
+}
+
+func (s *RegistryPullerSuite) TearDownTest(c *C) {
+	s.registry.Close()
+}
+
+func (s *RegistryPullerSuite) TestPullsImagesWithMirrorsAndRewrites(c *C) {
+	puller, err := NewRegistryPuller(RegistryPullerConfig{
+		Mirrors: []RegistryMirror{
+			{Registry: "mirrored.example.com", Mirror: s.registry.Addr()},
+		},
+		Rewrites: []ImageRewrite{
+			{From: "rewritten.example.com/", To: s.registry.Addr() + "/upstream/"},
+		},
+		InsecureRegistries: []string{s.registry.Addr()},
+		Parallel:           2,
+	})
+	c.Assert(err, IsNil)
+
+	dir := c.MkDir()
+	err = puller.Pull(context.Background(), PullRequest{
+		Dir: dir,
+		Images: map[string]string{
+			"upstream/app:1.0": "mirrored.example.com/upstream/app:1.0",
+			"app:1.0":          "rewritten.example.com/app:1.0",
+		},
+	})
+	c.Assert(err, IsNil)
+
+	local, err := openLocal(dir)
+	c.Assert(err, IsNil)
+	repos, err := ListRepos(context.Background(), local)
+	c.Assert(err, IsNil)
+	c.Assert(repos, DeepEquals, []string{"app", "upstream/app"})
+	for _, name := range repos {
+		repo, err := local.Repository(context.Background(), name)
+		c.Assert(err, IsNil)
+		tags, err := repo.Tags(context.Background()).All(context.Background())
+		c.Assert(err, IsNil)
+		c.Assert(tags, DeepEquals, []string{"1.0"})
+	}
+}
+
+func (s *RegistryPullerSuite) TestPullsImagesForArchitectures(c *C) {
+	puller, err := NewRegistryPuller(RegistryPullerConfig{
+		InsecureRegistries: []string{s.registry.Addr()},
+		Architectures:      []string{"amd64", "arm64"},
+	})
+	c.Assert(err, IsNil)
+
+	dir := c.MkDir()
+	err = puller.Pull(context.Background(), PullRequest{
+		Dir:    dir,
+		Images: map[string]string{"multiarch:1.0": s.registry.Addr() + "/upstream/multiarch:1.0"},
+	})
+	c.Assert(err, IsNil)
+
+	local, err := openLocal(dir)
+	c.Assert(err, IsNil)
+	repo, err := local.Repository(context.Background(), "multiarch")
+	c.Assert(err, IsNil)
+	desc, err := repo.Tags(context.Background()).Get(context.Background(), "1.0")
+	c.Assert(err, IsNil)
+	manifests, err := repo.Manifests(context.Background())
+	c.Assert(err, IsNil)
+	manifest, err := manifests.Get(context.Background(), desc.Digest)
+	c.Assert(err, IsNil)
+	list, ok := manifest.(*manifestlist.DeserializedManifestList)
+	c.Assert(ok, Equals, true, Commentf("expected a manifest list, got %T", manifest))
+	var architectures []string
+	for _, desc := range list.Manifests {
+		architectures = append(architectures, desc.Platform.Architecture)
+	}
+	c.Assert(architectures, DeepEquals, []string{"amd64", "arm64"})
+
+	// the manifest list is pushed to the cluster registry along with the platform manifests
+	service, err := NewImageService(RegistryConnectionRequest{
+		RegistryAddress: s.registry.Addr(),
+	})
+	c.Assert(err, IsNil)
+	_, err = service.Sync(context.Background(), dir, utils.NopEmitter())
+	c.Assert(err, IsNil)
+	for _, desc := range list.Manifests {
+		exists, err := service.HasImage(context.Background(), "multiarch@"+desc.Digest.String())
+		c.Assert(err, IsNil)
+		c.Assert(exists, Equals, true, Commentf(desc.Platform.Architecture))
+	}
+
+	err = puller.Pull(context.Background(), PullRequest{
+		Dir:    c.MkDir(),
+		Images: map[string]string{"app:1.0": s.registry.Addr() + "/upstream/app:1.0"},
+	})
+	c.Assert(trace.Unwrap(err), ErrorMatches, ".*is built for amd64 only and has no manifest for linux/arm64.*")
+}
+
+func (s *RegistryPullerSuite) TestUnpacksImageLayers(c *C) {
+	puller, err := NewRegistryPuller(RegistryPullerConfig{
+		InsecureRegistries: []string{s.registry.Addr()},
+	})
+	c.Assert(err, IsNil)
+
+	dir := c.MkDir()
+	err = puller.Unpack(context.Background(), s.registry.Addr()+"/upstream/app:1.0", dir)
+	c.Assert(err, IsNil)
+
+	_, err = os.Stat(filepath.Join(dir, "etc/a"))
+	c.Assert(os.IsNotExist(err), Equals, true, Commentf("expected etc/a to be removed by whiteout"))
+	for path, contents := range map[string]string{"etc/b": "b", "etc/c": "c"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, path))
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, contents)
+	}
+}
+
+func (s *RegistryPullerSuite) TestRejectsLayersOutsideImageFilesystem(c *C) {
+	outside := c.MkDir()
+	victim := filepath.Join(outside, "victim")
+	c.Assert(ioutil.WriteFile(victim, []byte("data"), 0644), IsNil)
+	dir := c.MkDir()
+	apply := func(headers ...tar.Header) error {
+		return applyLayer(dir, func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(rawLayer(c, headers...))), nil
+		})
+	}
+
+	err := apply(tar.Header{Name: "../../" + filepath.Base(outside) + "/.wh.victim", Typeflag: tar.TypeReg})
+	c.Assert(err, ErrorMatches, ".*outside of the image filesystem.*")
+
+	err = apply(tar.Header{Name: "etc", Typeflag: tar.TypeSymlink, Linkname: outside})
+	c.Assert(err, IsNil)
+	err = apply(tar.Header{Name: "etc/.wh.victim", Typeflag: tar.TypeReg})
+	c.Assert(err, ErrorMatches, ".*resolves through symbolic link etc.*")
+	err = apply(
+		tar.Header{Name: "lib", Typeflag: tar.TypeSymlink, Linkname: outside},
+		tar.Header{Name: "lib/victim", Typeflag: tar.TypeReg, Mode: 0644})
+	c.Assert(err, ErrorMatches, ".*resolves through symbolic link lib.*")
+	err = apply(tar.Header{Name: "link", Typeflag: tar.TypeLink, Linkname: "etc/victim"})
+	c.Assert(err, ErrorMatches, ".*resolves through symbolic link etc.*")
+
+	_, err = os.Stat(victim)
+	c.Assert(err, IsNil)
+}
+
+func (s *RegistryPullerSuite) TestPreservesFileOwnership(c *C) {
+	if os.Geteuid() != 0 {
+		c.Skip("preserving file ownership requires root")
+	}
+	dir := c.MkDir()
+	err := applyLayer(dir, func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(rawLayer(c, tar.Header{
+			Name:     "usr/bin/tool",
+			Typeflag: tar.TypeReg,
+			Mode:     04755,
+			Uid:      1000,
+			Gid:      1000,
+		}))), nil
+	})
+	c.Assert(err, IsNil)
+	fi, err := os.Lstat(filepath.Join(dir, "usr/bin/tool"))
+	c.Assert(err, IsNil)
+	c.Assert(fi.Mode()&os.ModeSetuid, Not(Equals), os.FileMode(0))
+	stat := fi.Sys().(*syscall.Stat_t)
+	c.Assert([]uint32{stat.Uid, stat.Gid}, DeepEquals, []uint32{1000, 1000})
+}
+
+func (s *RegistryPullerSuite) TestReadsCredentialsFromDockerConfig(c *C) {
+	path := filepath.Join(c.MkDir(), "config.json")
+	err := ioutil.WriteFile(path, []byte(`{"auths": {
+  "https://index.docker.io/v1/": {"auth": "aHViOnNlY3JldA=="},
+  "registry.example.com:5000": {"username": "user", "password": "pass"}
+}}`), 0600)
+	c.Assert(err, IsNil)
+	config, err := readDockerConfig(path)
+	c.Assert(err, IsNil)
+
+	username, password := config.forHost(defaultRegistryHost).Basic(nil)
+	c.Assert([]string{username, password}, DeepEquals, []string{"hub", "secret"})
+	username, password = config.forHost("registry.example.com:5000").Basic(nil)
+	c.Assert([]string{username, password}, DeepEquals, []string{"user", "pass"})
+	username, _ = config.forHost("other.example.com").Basic(nil)
+	c.Assert(username, Equals, "")
+}
+
+func (s *RegistryPullerSuite) TestParsesImageReferences(c *C) {
+	var testCases = []struct {
+		image    string
+		expected string
+	}{
+		{image: "nginx", expected: "docker.io/library/nginx:latest"},
+		{image: "gravitational/debian-tall:0.0.1", expected: "docker.io/gravitational/debian-tall:0.0.1"},
+		{image: "quay.io/coreos/etcd:v3.3", expected: "quay.io/coreos/etcd:v3.3"},
+		{image: "localhost:5000/app:1.0", expected: "localhost:5000/app:1.0"},
+	}
+	for _, tc := range testCases {
+		ref, err := ParseImageReference(tc.image)
+		c.Assert(err, IsNil)
+		c.Assert(ref.String(), Equals, tc.expected, Commentf(tc.image))
+	}
+}
+
+// createTestImageList writes a multi-architecture image into the registry directory
+// with a single layer per architecture
+func createTestImageList(c *C, dir, name, tag string, architectures ...string) {
+	ctx := context.Background()
+	store, err := openLocal(dir)
+	c.Assert(err, IsNil)
+	repo, err := store.Repository(ctx, name)
+	c.Assert(err, IsNil)
+	var descs []manifestlist.ManifestDescriptor
+	for _, arch := range architectures {
+		desc := putTestManifest(c, repo, arch, TestLayer(c, map[string]string{"etc/arch": arch}))
+		descs = append(descs, manifestlist.ManifestDescriptor{
+			Descriptor: desc,
+			Platform:   manifestlist.PlatformSpec{OS: "linux", Architecture: arch},
+		})
+	}
+	list, err := manifestlist.FromDescriptors(descs)
+	c.Assert(err, IsNil)
+	manifests, err := repo.Manifests(ctx)
+	c.Assert(err, IsNil)
+	dgst, err := manifests.Put(ctx, list)
+	c.Assert(err, IsNil)
+	err = repo.Tags(ctx).Tag(ctx, tag, distribution.Descriptor{
+		Digest:    dgst,
+		MediaType: manifestlist.MediaTypeManifestList,
+	})
+	c.Assert(err, IsNil)
+}
+
+// rawLayer returns an uncompressed layer archive with the specified empty entries
+func rawLayer(c *C, headers ...tar.Header) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range headers {
+		hdr := hdr
+		c.Assert(tw.WriteHeader(&hdr), IsNil)
+	}
+	c.Assert(tw.Close(), IsNil)
+	return buf.Bytes()
+}
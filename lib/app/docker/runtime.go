@@ -17,6 +17,7 @@ limitations under the License.
 package docker
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -38,18 +39,23 @@ import (
 // TranslateRuntimeImage translates the specified docker image
 // into a gravity package specified in req.
 func TranslateRuntimeImage(req TranslateImageRequest) error {
-	f, err := ioutil.TempFile("", "gravity-runtime")
-	if err != nil {
-		return trace.ConvertSystemError(err)
-	}
-	defer func() {
-		f.Close()
-		if errRemove := os.Remove(f.Name()); errRemove != nil {
-			log.Warnf("Failed to remove tarball %v: %v.",
-				f.Name(), errRemove)
-		}
-	}()
+	return translateRuntimeImage(req, func(rootfsDir string) error {
+		return exportImage(req, rootfsDir)
+	})
+}
 
+// TranslateRegistryRuntimeImage translates the specified docker image
+// into a gravity package specified in req pulling the image
+// directly from its registry with the provided puller
+func TranslateRegistryRuntimeImage(ctx context.Context, puller *RegistryPuller, req TranslateImageRequest) error {
+	return translateRuntimeImage(req, func(rootfsDir string) error {
+		return puller.Unpack(ctx, req.Image, rootfsDir)
+	})
+}
+
+// translateRuntimeImage creates the runtime package from the image
+// filesystem that unpack extracts into the specified directory
+func translateRuntimeImage(req TranslateImageRequest, unpack func(rootfsDir string) error) (err error) {
 	packageDir, err := ioutil.TempDir("", "runtime")
 	if err != nil {
 		return trace.ConvertSystemError(err)
@@ -65,6 +71,62 @@ func TranslateRuntimeImage(req TranslateImageRequest) error {
 		}
 	}()
 
+	log := log.WithFields(log.Fields{
+		"intermediate package directory": packageDir,
+		"package":                        req.Package,
+	})
+	log.Info("Translating docker image to the gravity package.")
+
+	rootfsDir := filepath.Join(packageDir, "rootfs")
+	if err := os.MkdirAll(rootfsDir, defaults.SharedDirMask); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if err := unpack(rootfsDir); err != nil {
+		return trace.Wrap(err)
+	}
+
+	if err := utils.CopyFile(
+		filepath.Join(packageDir, pack.ManifestFilename),
+		filepath.Join(rootfsDir, "/etc/planet", pack.ManifestFilename)); err != nil {
+		return trace.Wrap(err)
+	}
+
+	log.Info("Compressing intermediate package directory.")
+	reader, err := dockerarchive.Tar(packageDir, dockerarchive.Gzip)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = req.UpsertRepository(req.Package.Repository, time.Time{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	log.Info("Creating resulting package.")
+	_, err = req.UpsertPackage(req.Package, reader,
+		pack.WithLabels(pack.RuntimePackageLabels))
+	if err != nil && !trace.IsAlreadyExists(err) {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+// exportImage extracts the filesystem of the image into rootfsDir
+// by exporting a container created from the image
+func exportImage(req TranslateImageRequest, rootfsDir string) error {
+	f, err := ioutil.TempFile("", "gravity-runtime")
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer func() {
+		f.Close()
+		if errRemove := os.Remove(f.Name()); errRemove != nil {
+			log.Warnf("Failed to remove tarball %v: %v.",
+				f.Name(), errRemove)
+		}
+	}()
+
 	createOpts := dockerapi.CreateContainerOptions{
 		Name: fmt.Sprintf("planet-export-%v", utilrand.String(4)),
 		Config: &dockerapi.Config{
@@ -78,12 +140,9 @@ func TranslateRuntimeImage(req TranslateImageRequest) error {
 	}
 
 	log := log.WithFields(log.Fields{
-		"intermediate tarball":           f.Name(),
-		"intermediate package directory": packageDir,
-		"container ID":                   container.ID,
-		"package":                        req.Package,
+		"intermediate tarball": f.Name(),
+		"container ID":         container.ID,
 	})
-	log.Info("Translating docker image to the gravity package.")
 
 	defer func() {
 		log.Info("Removing container.")
@@ -113,48 +172,16 @@ func TranslateRuntimeImage(req TranslateImageRequest) error {
 			"failed to seek file")
 	}
 
-	rootfsDir := filepath.Join(packageDir, "rootfs")
-	if err := os.MkdirAll(rootfsDir, defaults.SharedDirMask); err != nil {
-		return trace.ConvertSystemError(err)
-	}
-	if err := dockerarchive.Untar(f, rootfsDir,
-		&dockerarchive.TarOptions{NoLchown: true}); err != nil {
-		return trace.Wrap(err)
-	}
-
-	if err := utils.CopyFile(
-		filepath.Join(packageDir, pack.ManifestFilename),
-		filepath.Join(rootfsDir, "/etc/planet", pack.ManifestFilename)); err != nil {
-		return trace.Wrap(err)
-	}
-
-	log.Info("Compressing intermediate package directory.")
-	reader, err := dockerarchive.Tar(packageDir, dockerarchive.Gzip)
-	if err != nil {
-		return trace.Wrap(err)
-	}
-
-	err = req.UpsertRepository(req.Package.Repository, time.Time{})
-	if err != nil {
-		return trace.Wrap(err)
-	}
-
-	log.Info("Creating resulting package.")
-	_, err = req.UpsertPackage(req.Package, reader,
-		pack.WithLabels(pack.RuntimePackageLabels))
-	if err != nil && !trace.IsAlreadyExists(err) {
-		return trace.Wrap(err)
-	}
-
-	return nil
+	return trace.Wrap(dockerarchive.Untar(f, rootfsDir,
+		&dockerarchive.TarOptions{NoLchown: true}))
 }
 
 // TranslateImageRequest describes a request to translate runtime docker
 // image to telekube package
 type TranslateImageRequest struct {
 	// Image defines the docker image to translate.
-	// The image must have been already pulled and available
-	// locally
+	// When translating with the docker daemon, the image must
+	// have been already pulled and available locally
 	Image string
 	// Package specifies the resulting telekube package
 	Package loc.Locator
@@ -0,0 +1,94 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/manifest/schema2"
+	"gopkg.in/check.v1"
+)
+
+// CreateTestImage writes an image with the specified layers into the registry directory
+func CreateTestImage(c *check.C, dir, name, tag string, layers ...[]byte) {
+	ctx := context.Background()
+	store, err := openLocal(dir)
+	c.Assert(err, check.IsNil)
+	repo, err := store.Repository(ctx, name)
+	c.Assert(err, check.IsNil)
+	desc := putTestManifest(c, repo, "amd64", layers...)
+	err = repo.Tags(ctx).Tag(ctx, tag, desc)
+	c.Assert(err, check.IsNil)
+}
+
+// putTestManifest writes the image manifest for the specified architecture
+// and returns its descriptor
+func putTestManifest(c *check.C, repo distribution.Repository, arch string, layers ...[]byte) distribution.Descriptor {
+	ctx := context.Background()
+	blobs := repo.Blobs(ctx)
+	config, err := blobs.Put(ctx, schema2.MediaTypeImageConfig,
+		[]byte(fmt.Sprintf(`{"architecture":%q,"os":"linux"}`, arch)))
+	c.Assert(err, check.IsNil)
+	m := schema2.Manifest{
+		Versioned: schema2.SchemaVersion,
+		Config:    config,
+	}
+	for _, layer := range layers {
+		desc, err := blobs.Put(ctx, schema2.MediaTypeLayer, layer)
+		c.Assert(err, check.IsNil)
+		m.Layers = append(m.Layers, desc)
+	}
+	deserialized, err := schema2.FromStruct(m)
+	c.Assert(err, check.IsNil)
+	manifests, err := repo.Manifests(ctx)
+	c.Assert(err, check.IsNil)
+	dgst, err := manifests.Put(ctx, deserialized)
+	c.Assert(err, check.IsNil)
+	_, payload, err := deserialized.Payload()
+	c.Assert(err, check.IsNil)
+	return distribution.Descriptor{
+		Digest:    dgst,
+		MediaType: schema2.MediaTypeManifest,
+		Size:      int64(len(payload)),
+	}
+}
+
+// TestLayer returns a compressed layer archive with the specified files
+func TestLayer(c *check.C, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for path, contents := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name:     path,
+			Mode:     0644,
+			Size:     int64(len(contents)),
+			Typeflag: tar.TypeReg,
+		})
+		c.Assert(err, check.IsNil)
+		_, err = tw.Write([]byte(contents))
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(tw.Close(), check.IsNil)
+	c.Assert(gz.Close(), check.IsNil)
+	return buf.Bytes()
+}
@@ -59,6 +59,10 @@ func (h *WebHandler) getIndexFile(w http.ResponseWriter, r *http.Request, p http
 
    If the name is "index.yaml", then repository index file is returned,
    see "getIndexFile" handler for details.
+
+   If the name has ".prov" extension, for example "alpine-0.1.0.tgz.prov",
+   then the chart provenance file is returned, see "fetchChartProvenance"
+   handler for details.
 */
 func (h *WebHandler) fetchChart(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *handlerContext) error {
 	name := p.ByName("name")
@@ -68,11 +72,10 @@ func (h *WebHandler) fetchChart(w http.ResponseWriter, r *http.Request, p httpro
 	if name == "index.yaml" {
 		return h.getIndexFile(w, r, p, context)
 	}
-	chartName, chartVersion, err := helmutils.ParseChartFilename(name)
-	if err != nil {
-		return trace.Wrap(err)
+	if helmutils.IsProvenanceFilename(name) {
+		return h.fetchChartProvenance(w, r, p, context)
 	}
-	locator, err := loc.NewLocator(defaults.SystemAccountOrg, chartName, chartVersion)
+	locator, err := chartLocator(name)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -88,3 +91,38 @@ func (h *WebHandler) fetchChart(w http.ResponseWriter, r *http.Request, p httpro
 	}
 	return nil
 }
+
+/* fetchChartProvenance returns provenance file for the specified application chart.
+
+   GET /charts/:name.prov
+   GET /app/v1/charts/:name.prov
+
+   Helm client downloads the provenance file alongside the chart archive
+   to verify its integrity and origin. Only signed applications have
+   provenance files.
+*/
+func (h *WebHandler) fetchChartProvenance(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *handlerContext) error {
+	locator, err := chartLocator(p.ByName("name"))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	reader, err := context.applications.FetchChartProvenance(*locator)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	w.Header().Set("Content-Type", "application/pgp-signature")
+	_, err = io.Copy(w, reader)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// chartLocator returns application locator for the provided chart filename
+func chartLocator(filename string) (*loc.Locator, error) {
+	chartName, chartVersion, err := helmutils.ParseChartFilename(filename)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return loc.NewLocator(defaults.SystemAccountOrg, chartName, chartVersion)
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/systeminfo"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/rigging"
 	teleutils "github.com/gravitational/teleport/lib/utils"
@@ -83,6 +84,11 @@ func configureMetadata(job *batchv1.Job, p Params) error {
 
 	job.ObjectMeta.Name = fmt.Sprintf("%v-%v", job.ObjectMeta.Name, suffix)
 
+	// label the job and its pods so they can be found by the garbage collector
+	labels := hookLabels(p)
+	job.ObjectMeta.Labels = utils.CombineLabels(job.ObjectMeta.Labels, labels)
+	job.Spec.Template.ObjectMeta.Labels = utils.CombineLabels(job.Spec.Template.ObjectMeta.Labels, labels)
+
 	// specify node selector so it runs on master but keep any existing selector labels
 	if job.Spec.Template.Spec.NodeSelector == nil {
 		job.Spec.Template.Spec.NodeSelector = make(map[string]string)
@@ -119,6 +125,14 @@ func configureMetadata(job *batchv1.Job, p Params) error {
 		*job.Spec.ActiveDeadlineSeconds = int64(time.Duration(
 			defaults.HookJobDeadline).Seconds())
 	}
+	// the deadline set in the hook spec overrides the one from the job spec
+	deadline, err := p.Hook.Deadline()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if deadline != 0 {
+		*job.Spec.ActiveDeadlineSeconds = int64(deadline.Seconds())
+	}
 	// deadline may have been overridden via hook request, if so, it takes precendence
 	if p.JobDeadline != 0 {
 		*job.Spec.ActiveDeadlineSeconds = int64(p.JobDeadline.Seconds())
@@ -132,6 +146,18 @@ func configureMetadata(job *batchv1.Job, p Params) error {
 	return nil
 }
 
+// hookLabels returns the labels identifying the hook job described with p
+func hookLabels(p Params) map[string]string {
+	labels := make(map[string]string)
+	if p.Hook != nil {
+		labels[HookLabel] = string(p.Hook.Type)
+	}
+	if p.Locator.Name != "" {
+		labels[HookAppLabel] = p.Locator.Name
+	}
+	return labels
+}
+
 // configureVolumes updates the job spec with required volumes so they are available
 // to init and hook containers
 func configureVolumes(job *batchv1.Job, p Params) {
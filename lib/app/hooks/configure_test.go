@@ -20,6 +20,8 @@ import (
 	"time"
 
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/schema"
 
 	"github.com/gravitational/rigging"
 	"gopkg.in/check.v1"
@@ -37,6 +39,8 @@ func (s *ConfigureSuite) TestConfigureMetadata(c *check.C) {
 	nodeSelector := map[string]string{"role": "master"}
 	deadline := time.Duration(10 * time.Second)
 	err := configureMetadata(job, Params{
+		Hook:         &schema.Hook{Type: schema.HookInstall},
+		Locator:      loc.MustParseLocator("example.com/app:0.0.1"),
 		NodeSelector: nodeSelector,
 		JobDeadline:  deadline,
 	})
@@ -47,4 +51,23 @@ func (s *ConfigureSuite) TestConfigureMetadata(c *check.C) {
 	c.Assert(job.Spec.Template.Spec.NodeSelector, check.DeepEquals, nodeSelector)
 	c.Assert(*job.Spec.ActiveDeadlineSeconds, check.Equals, int64(deadline.Seconds()))
 	c.Assert(job.Spec.Template.Spec.SecurityContext, check.DeepEquals, defaults.HookSecurityContext())
+	labels := map[string]string{HookLabel: string(schema.HookInstall), HookAppLabel: "app"}
+	c.Assert(job.ObjectMeta.Labels, check.DeepEquals, labels)
+	c.Assert(job.Spec.Template.ObjectMeta.Labels, check.DeepEquals, labels)
+}
+
+func (s *ConfigureSuite) TestHookDeadline(c *check.C) {
+	params := Params{
+		Hook:    &schema.Hook{Type: schema.HookInstall, ActiveDeadline: "5m"},
+		Locator: loc.MustParseLocator("example.com/app:0.0.1"),
+	}
+	job := &batchv1.Job{}
+	c.Assert(configureMetadata(job, params), check.IsNil)
+	c.Assert(*job.Spec.ActiveDeadlineSeconds, check.Equals, int64(300))
+
+	// the request deadline takes precedence over the hook one
+	params.JobDeadline = time.Minute
+	job = &batchv1.Job{}
+	c.Assert(configureMetadata(job, params), check.IsNil)
+	c.Assert(*job.Spec.ActiveDeadlineSeconds, check.Equals, int64(60))
 }
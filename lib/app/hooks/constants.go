@@ -80,6 +80,13 @@ const (
 	// that defines the name of the application package the hook originated from.
 	// This environment variable is made available to the hook job's init container
 	ApplicationPackageEnv = "APP_PACKAGE"
+
+	// HookLabel is the label with the hook type set on hook jobs and their pods
+	HookLabel = "gravitational.io/hook"
+
+	// HookAppLabel is the label with the name of the application
+	// set on hook jobs and their pods
+	HookAppLabel = "gravitational.io/hook-app"
 )
 
 // InitContainerImage is the image for the init container
@@ -0,0 +1,181 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+type HookSuite struct{}
+
+var _ = Suite(&HookSuite{})
+
+func (s *HookSuite) TestRetriesFailedHook(c *C) {
+	apps := &testHookApps{failures: 2}
+	recorder := &testHookRecorder{}
+	_, err := StreamAppHook(context.TODO(), apps, HookRunRequest{
+		Application: loc.MustParseLocator("example.com/app:0.0.1"),
+		Hook:        schema.HookInstall,
+		Recorder:    recorder,
+	}, utils.NopWriteCloser(ioutil.Discard))
+	c.Assert(err, IsNil)
+	c.Assert(apps.attempts, Equals, 3)
+	c.Assert(recorder.attempts, HasLen, 3)
+	c.Assert(recorder.attempts[0].Error, Equals, "attempt 1 failed")
+	c.Assert(recorder.attempts[0].Output, Equals, "output of attempt 1\n")
+	c.Assert(recorder.attempts[0].JobName, Equals, "install-1")
+	c.Assert(recorder.attempts[2].Attempt, Equals, 3)
+	c.Assert(recorder.attempts[2].Error, Equals, "")
+}
+
+func (s *HookSuite) TestFailsAfterRetries(c *C) {
+	apps := &testHookApps{failures: 5}
+	_, err := StreamAppHook(context.TODO(), apps, HookRunRequest{
+		Application: loc.MustParseLocator("example.com/app:0.0.1"),
+		Hook:        schema.HookInstall,
+	}, utils.NopWriteCloser(ioutil.Discard))
+	c.Assert(err, ErrorMatches, "attempt 3 failed")
+	c.Assert(apps.attempts, Equals, 3)
+}
+
+// testHookApps runs an install hook that fails the configured
+// number of times before succeeding
+type testHookApps struct {
+	Applications
+	failures int
+	attempts int
+}
+
+func (a *testHookApps) GetApp(locator loc.Locator) (*Application, error) {
+	return &Application{
+		Package: locator,
+		Manifest: schema.Manifest{
+			Hooks: &schema.Hooks{
+				Install: &schema.Hook{
+					Type:    schema.HookInstall,
+					Job:     "kind: Job",
+					Retries: 2,
+					Backoff: "1ms",
+				},
+			},
+		},
+	}, nil
+}
+
+func (a *testHookApps) StartAppHook(ctx context.Context, req HookRunRequest) (*HookRef, error) {
+	a.attempts++
+	return &HookRef{
+		Application: req.Application,
+		Hook:        req.Hook,
+		Name:        fmt.Sprintf("%v-%v", req.Hook, a.attempts),
+	}, nil
+}
+
+func (a *testHookApps) WaitAppHook(ctx context.Context, ref HookRef) error {
+	if a.attempts <= a.failures {
+		return trace.BadParameter("attempt %v failed", a.attempts)
+	}
+	return nil
+}
+
+func (a *testHookApps) StreamAppHookLogs(ctx context.Context, ref HookRef, out io.Writer) error {
+	_, err := fmt.Fprintf(out, "output of attempt %v\n", a.attempts)
+	return err
+}
+
+type testHookRecorder struct {
+	attempts []storage.HookAttempt
+}
+
+func (r *testHookRecorder) RecordHookAttempt(attempt storage.HookAttempt) error {
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func (s *HookSuite) TestRunsHostHookOnMatchingServers(c *C) {
+	apps := &testHostHookApps{hook: &schema.Hook{
+		Type:    schema.HookNodeAdded,
+		Retries: 1,
+		Backoff: "1ms",
+		Host: &schema.HostHook{
+			Script:   "echo ok",
+			Profiles: []string{"worker"},
+		},
+	}}
+	runner := &testHostRunner{failures: 1}
+	recorder := &testHookRecorder{}
+	err := RunHostHook(context.TODO(), apps, HostHookRequest{
+		Application: loc.MustParseLocator("example.com/app:0.0.1"),
+		Hook:        schema.HookNodeAdded,
+		Servers: []storage.Server{
+			{Hostname: "node-1", Role: "master"},
+			{Hostname: "node-2", Role: "worker"},
+		},
+		Runner:   runner,
+		Recorder: recorder,
+	}, ioutil.Discard)
+	c.Assert(err, IsNil)
+	c.Assert(runner.servers, DeepEquals, []string{"node-2", "node-2"})
+	c.Assert(runner.command, DeepEquals, []string{"/bin/sh", "-c", "echo ok"})
+	c.Assert(recorder.attempts, HasLen, 2)
+	c.Assert(recorder.attempts[0].Server, Equals, "node-2")
+	c.Assert(recorder.attempts[0].Error, Equals, "node-2 failed")
+	c.Assert(recorder.attempts[1].Output, Equals, "ok\n")
+}
+
+// testHostHookApps returns an application with the configured hook
+type testHostHookApps struct {
+	Applications
+	hook *schema.Hook
+}
+
+func (a *testHostHookApps) GetApp(locator loc.Locator) (*Application, error) {
+	return &Application{
+		Package: locator,
+		Manifest: schema.Manifest{
+			Hooks: &schema.Hooks{NodeAdded: a.hook},
+		},
+	}, nil
+}
+
+// testHostRunner fails the configured number of commands before succeeding
+type testHostRunner struct {
+	failures int
+	servers  []string
+	command  []string
+}
+
+func (r *testHostRunner) RunStream(ctx context.Context, server storage.Server, w io.Writer, args ...string) error {
+	r.servers = append(r.servers, server.Hostname)
+	r.command = args
+	if len(r.servers) <= r.failures {
+		return trace.BadParameter("%v failed", server.Hostname)
+	}
+	_, err := fmt.Fprintln(w, "ok")
+	return err
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/rpc"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// HostHookRequest describes a request to run an application hook
+// as a host script
+type HostHookRequest struct {
+	// Application is the application the hook belongs to
+	Application loc.Locator
+	// Hook is the hook type
+	Hook schema.HookType
+	// Servers lists the operation servers. The script runs on the servers
+	// that match the profiles of the hook
+	Servers []storage.Server
+	// Runner executes the script on the servers
+	Runner HostRunner
+	// Recorder optionally persists the hook attempts
+	Recorder HookRecorder
+}
+
+// HostRunner executes commands on cluster servers
+type HostRunner interface {
+	// RunStream executes the command on the specified server
+	// streaming its output into w
+	RunStream(ctx context.Context, server storage.Server, w io.Writer, args ...string) error
+}
+
+// IsHostHook returns true if the specified application hook runs as a host script
+func IsHostHook(apps Applications, locator loc.Locator, hook schema.HookType) (bool, error) {
+	spec, err := CheckHasAppHook(apps, HookRunRequest{Application: locator, Hook: hook})
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	return spec.Host != nil, nil
+}
+
+// RunHostHook runs the script of the application host hook on the request
+// servers matching the hook profiles one by one, streaming the output into w.
+//
+// A failed script is retried on the same server as many times as configured
+// in the hook spec.
+func RunHostHook(ctx context.Context, apps Applications, req HostHookRequest, w io.Writer) error {
+	hook, err := CheckHasAppHook(apps, HookRunRequest{Application: req.Application, Hook: req.Hook})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if hook.Host == nil {
+		return trace.BadParameter("%v hook of %v does not define a host script",
+			req.Hook, req.Application)
+	}
+	deadline, err := hook.Deadline()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	command := hook.Host.Command()
+	for _, server := range req.Servers {
+		if !hook.Host.Matches(server.Role) {
+			continue
+		}
+		fmt.Fprintf(w, "Executing %v hook on %v.\n", req.Hook, server.Hostname)
+		err := retryHook(ctx, *hook, w, req.Recorder, storage.HookAttempt{
+			Application: req.Application.String(),
+			Hook:        string(req.Hook),
+			Server:      server.Hostname,
+		}, func(w io.Writer, _ *storage.HookAttempt) error {
+			ctx := ctx
+			if deadline != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, deadline)
+				defer cancel()
+			}
+			return trace.Wrap(req.Runner.RunStream(ctx, server, w, command...))
+		})
+		if err != nil {
+			return trace.Wrap(err, "%v hook failed on %v", req.Hook, server.Hostname)
+		}
+	}
+	return nil
+}
+
+// NewAgentHostRunner returns a host runner that executes commands
+// with the RPC agents running on the servers
+func NewAgentHostRunner(agents rpc.AgentRepository, logger logrus.FieldLogger) HostRunner {
+	return &agentHostRunner{
+		agents:      agents,
+		FieldLogger: logger,
+	}
+}
+
+// RunStream executes the command on the specified server
+// streaming its output into w
+func (r *agentHostRunner) RunStream(ctx context.Context, server storage.Server, w io.Writer, args ...string) error {
+	agent, err := r.agents.GetClient(ctx, server.AdvertiseIP)
+	if err != nil {
+		return trace.Wrap(err, "failed to connect to the agent on %v", server.Hostname)
+	}
+	return trace.Wrap(agent.Command(ctx, r.FieldLogger, w, args...))
+}
+
+type agentHostRunner struct {
+	logrus.FieldLogger
+	agents rpc.AgentRepository
+}
@@ -174,13 +174,13 @@ func (r *applications) ExportApp(req appservice.ExportAppRequest) error {
 	_, err = r.withApp(req.Package, func(dir string, app *appservice.Application) error {
 		for _, dependency := range app.Manifest.Dependencies.Apps {
 			_, err := r.withApp(dependency.Locator, func(dir string, app *appservice.Application) error {
-				return r.exportApp(ctx, dir, imageService)
+				return r.exportApp(ctx, dir, app, imageService)
 			})
 			if err != nil {
 				return trace.Wrap(err)
 			}
 		}
-		err := r.exportApp(ctx, dir, imageService)
+		err := r.exportApp(ctx, dir, app, imageService)
 		return trace.Wrap(err)
 	})
 	return trace.Wrap(err)
@@ -217,7 +217,7 @@ func (r *applications) withApp(locator loc.Locator, process processAppFn) (*apps
 	return app, nil
 }
 
-func syncWithRegistry(ctx context.Context, registryDir string, imageService docker.ImageService, log log.FieldLogger) error {
+func syncWithRegistry(ctx context.Context, registryDir string, digests map[string]string, imageService docker.ImageService, log log.FieldLogger) error {
 	if ok, _ := utils.IsDirectory(registryDir); !ok {
 		log.Infof("No registry directory is present - skipping registry sync.")
 		return nil
@@ -229,7 +229,7 @@ func syncWithRegistry(ctx context.Context, registryDir string, imageService dock
 	if empty {
 		return trace.BadParameter("registry directory %v is empty", registryDir)
 	}
-	if _, err = imageService.Sync(ctx, registryDir, utils.NopEmitter()); err != nil {
+	if _, err = imageService.SyncWithDigests(ctx, registryDir, digests, utils.NopEmitter()); err != nil {
 		return trace.Wrap(err)
 	}
 	return nil
@@ -253,9 +253,10 @@ func (r *applications) runAppHook(ctx context.Context, req appservice.HookRunReq
 	return nil
 }
 
-func (r *applications) exportApp(ctx context.Context, dir string, imageService docker.ImageService) error {
+func (r *applications) exportApp(ctx context.Context, dir string, app *appservice.Application, imageService docker.ImageService) error {
 	dir = filepath.Join(dir, defaults.RegistryDir)
-	return syncWithRegistry(ctx, dir, imageService, r.FieldLogger)
+	digests := docker.DigestsFromLabels(app.PackageEnvelope.RuntimeLabels)
+	return syncWithRegistry(ctx, dir, digests, imageService, r.FieldLogger)
 }
 
 // uninstallApp calls "pre-uninstall" and "uninstall" hooks for the specified app
@@ -777,6 +778,11 @@ func (r *applications) FetchChart(locator loc.Locator) (io.ReadCloser, error) {
 	return r.Charts.FetchChart(locator)
 }
 
+// FetchChartProvenance returns provenance file of the specified application chart.
+func (r *applications) FetchChartProvenance(locator loc.Locator) (io.Reader, error) {
+	return r.Charts.FetchChartProvenance(locator)
+}
+
 // FetchIndexFile returns Helm chart repository index file data.
 func (r *applications) FetchIndexFile() (io.Reader, error) {
 	return r.Charts.GetIndexFile()
@@ -200,6 +200,16 @@ func pullMissingRemoteImage(image string, puller docker.DockerPuller, log log.Fi
 	return nil
 }
 
+// imageWithoutRegistry returns the specified image reference without the registry
+func imageWithoutRegistry(image string) (string, error) {
+	parsed, err := loc.ParseDockerImage(image)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	parsed.Registry = ""
+	return parsed.String(), nil
+}
+
 func tagImageWithoutRegistry(image string, docker docker.DockerInterface, log log.FieldLogger) error {
 	// tag the image without a registry
 	parsed, err := loc.ParseDockerImage(image)
@@ -80,8 +80,7 @@ func (r *applications) importAppWithContext(ctx *operationContext, request *app.
 
 	ctx.Infof("creating application package")
 
-	var labels map[string]string
-	_, err = r.createApp(*locator, packageBytes, manifestBytes, labels, request.Email, request.Force)
+	_, err = r.createApp(*locator, packageBytes, manifestBytes, request.Labels, request.Email, request.Force)
 	return trace.Wrap(err)
 }
 
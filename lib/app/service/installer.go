@@ -66,6 +66,10 @@ func (r *applications) getClusterInstaller(
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	err = pullIntermediateRuntimes(req.IntermediateRuntimes, apps, r, r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	binary, err := r.getGravityBinaryForApp(app)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -255,6 +259,39 @@ func pullDependencies(app *appservice.Application, localApps, remoteApps *applic
 	return nil
 }
 
+// pullIntermediateRuntimes pulls the specified runtime applications with their dependencies
+// to localApps skipping the packages that have already been pulled
+func pullIntermediateRuntimes(runtimes []loc.Locator, localApps, remoteApps *applications, log log.FieldLogger) error {
+	for _, runtime := range runtimes {
+		app, err := remoteApps.GetApp(runtime)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		dependencies, err := appservice.GetDependencies(app, remoteApps)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		var packages []loc.Locator
+		for _, locator := range dependencies.Packages {
+			_, err := localApps.Packages.ReadPackageEnvelope(locator)
+			if err == nil {
+				continue
+			}
+			if !trace.IsNotFound(err) {
+				return trace.Wrap(err)
+			}
+			packages = append(packages, locator)
+		}
+		if err := pullPackages(packages, localApps.Packages, remoteApps.Packages, log); err != nil {
+			return trace.Wrap(err)
+		}
+		if err := pullApplications(append(dependencies.Apps, runtime), localApps, remoteApps, log); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
 // pullPackages pulls package locators from remotePackages to localPackages
 func pullPackages(locators []loc.Locator, localPackages pack.PackageService, remotePackages pack.PackageService, log log.FieldLogger) error {
 	log.Infof("Pulling packages %v.", locators)
@@ -53,6 +53,10 @@ type PackagePullRequest struct {
 	Upsert bool
 	// MetadataOnly allows to pull only package metadata without body
 	MetadataOnly bool
+	// Arch optionally specifies the node architecture to pull the package for.
+	// The variant of the package for this architecture is pulled and stored
+	// under the original package name
+	Arch string
 }
 
 // CheckAndSetDefaults checks the package pull request and sets some defaults
@@ -178,13 +182,14 @@ func pullPackage(req PackagePullRequest) (*pack.PackageEnvelope, error) {
 		return nil, trace.AlreadyExists("package %v already exists", req.Package)
 	}
 
-	req.Infof("Pulling package %v.", req.Package)
+	srcPackage := schema.ArchPackage(req.Package, req.Arch)
+	req.Infof("Pulling package %v.", srcPackage)
 
 	reader := ioutil.NopCloser(utils.NopReader())
 	if req.MetadataOnly {
-		env, err = req.SrcPack.ReadPackageEnvelope(req.Package)
+		env, err = req.SrcPack.ReadPackageEnvelope(srcPackage)
 	} else {
-		env, reader, err = req.SrcPack.ReadPackage(req.Package)
+		env, reader, err = req.SrcPack.ReadPackage(srcPackage)
 	}
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -213,12 +218,15 @@ func pullPackage(req PackagePullRequest) (*pack.PackageEnvelope, error) {
 		}
 	}
 
+	// the architecture variant is stored under the requested package name
+	dstPackage := env.Locator
+	dstPackage.Name = req.Package.Name
 	if req.Upsert {
 		env, err = req.DstPack.UpsertPackage(
-			env.Locator, reader, pack.WithLabels(req.Labels))
+			dstPackage, reader, pack.WithLabels(req.Labels))
 	} else {
 		env, err = req.DstPack.CreatePackage(
-			env.Locator, reader, pack.WithLabels(req.Labels))
+			dstPackage, reader, pack.WithLabels(req.Labels))
 	}
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -356,9 +364,23 @@ func pullAppDeps(req AppPullRequest, manifest schema.Manifest, state *pullState)
 		}
 	}
 
+	deps := manifest.AllPackageDependencies()
+	if base != nil {
+		// pull the runtime packages of the base application for other
+		// architectures the application supports
+		baseApp, err := req.DstApp.GetApp(*base)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if runtimePackage, _ := baseApp.Manifest.DefaultRuntimePackage(); runtimePackage != nil {
+			deps = append(deps, manifest.ArchPackages(*runtimePackage)...)
+		}
+		deps = append(deps, manifest.ArchDependencies(baseApp.Manifest.Dependencies.GetPackages())...)
+	}
+
 	// pull dependent packages
 	group, ctx := run.WithContext(context.TODO(), run.WithParallel(req.Parallel))
-	for _, dep := range manifest.AllPackageDependencies() {
+	for _, dep := range loc.Deduplicate(deps) {
 		if state.pulled(dep) {
 			req.Infof("Package %v already pulled.", dep)
 			continue
@@ -18,6 +18,7 @@ package service
 
 import (
 	"bytes"
+	"io/ioutil"
 	"path/filepath"
 	"time"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/pack/localpack"
+	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/storage/keyval"
 
@@ -85,6 +87,30 @@ func (s *PullerSuite) TestPullPackage(c *C) {
 	c.Assert(trace.IsAlreadyExists(err), Equals, true)
 }
 
+func (s *PullerSuite) TestPullsPackageForArch(c *C) {
+	loc := loc.MustParseLocator("example.com/gravity:0.0.1")
+	_, err := s.srcPack.CreatePackage(loc, bytes.NewBuffer([]byte("amd64")))
+	c.Assert(err, IsNil)
+	_, err = s.srcPack.CreatePackage(schema.ArchPackage(loc, "arm64"), bytes.NewBuffer([]byte("arm64")))
+	c.Assert(err, IsNil)
+
+	env, err := PullPackage(PackagePullRequest{
+		SrcPack: s.srcPack,
+		DstPack: s.dstPack,
+		Package: loc,
+		Arch:    "arm64",
+	})
+	c.Assert(err, IsNil)
+	c.Assert(env.Locator, Equals, loc)
+
+	_, reader, err := s.dstPack.ReadPackage(loc)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "arm64")
+}
+
 func (s *PullerSuite) TestPullApp(c *C) {
 	s.pullApp(c, 0)
 }
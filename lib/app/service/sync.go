@@ -133,7 +133,8 @@ func SyncApp(ctx context.Context, req SyncRequest) error {
 
 	log.Infof("Syncing %v.", req.Package)
 
-	if _, err = req.ImageService.Sync(ctx, syncPath, req.Progress); err != nil {
+	digests := docker.DigestsFromLabels(application.PackageEnvelope.RuntimeLabels)
+	if _, err = req.ImageService.SyncWithDigests(ctx, syncPath, digests, req.Progress); err != nil {
 		return trace.Wrap(err)
 	}
 
@@ -60,20 +60,31 @@ type VendorerConfig struct {
 	RegistryURL string
 	// Packages is the pack service
 	Packages pack.PackageService
+	// RegistryPuller, if set, pulls images directly from their registries.
+	// The docker daemon is not used in this case
+	RegistryPuller *docker.RegistryPuller
 }
 
 // NewVendorer creates a new vendorer instance.
 func NewVendorer(conf VendorerConfig) (*vendorer, error) {
-	dockerClient, err := docker.NewClient(conf.DockerURL)
-	if err != nil {
-		return nil, trace.Wrap(err)
-	}
 	imageService, err := docker.NewImageService(docker.RegistryConnectionRequest{
 		RegistryAddress: conf.RegistryURL,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if conf.RegistryPuller != nil {
+		return &vendorer{
+			imageService:   imageService,
+			registryPuller: conf.RegistryPuller,
+			registryURL:    conf.RegistryURL,
+			packages:       conf.Packages,
+		}, nil
+	}
+	dockerClient, err := docker.NewClient(conf.DockerURL)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return NewVendorerFromClients(dockerClient, imageService, conf.RegistryURL, conf.Packages)
 }
 
@@ -132,6 +143,11 @@ type VendorRequest struct {
 	// ProgressReporter is a special writer, if set, vendorer will output user-friendly
 	// information during vendoring
 	ProgressReporter utils.Progress
+	// Policy optionally restricts the images that can be vendored
+	Policy *docker.ImagePolicy
+	// PinDigests specifies whether to rewrite the vendored images in resources
+	// and chart values to reference their manifest digests instead of tags
+	PinDigests bool
 }
 
 // vendorer is a helper struct that encapsulates all services needed to vendor/rewrite images in
@@ -140,8 +156,10 @@ type vendorer struct {
 	dockerClient docker.DockerInterface
 	imageService docker.ImageService
 	dockerPuller docker.DockerPuller
-	registryURL  string
-	packages     pack.PackageService
+	// registryPuller pulls images without the docker daemon if set
+	registryPuller *docker.RegistryPuller
+	registryURL    string
+	packages       pack.PackageService
 }
 
 // VendorTarball is the same as VendorDir but accepts a tarball stream and unpacks it before vendoring.
@@ -251,6 +269,17 @@ func (v *vendorer) VendorDir(ctx context.Context, unpackedDir string, req Vendor
 	imagesToPull := append(images, defaults.ContainerImage)
 	imagesToPull = append(imagesToPull, runtimeImages...)
 
+	// the default container image and the runtime images are vendored
+	// as well so they are subject to the policy
+	if req.Policy != nil {
+		if err := v.checkPolicy(*req.Policy, imagesToPull, req.ProgressReporter); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	// sources maps the images without registry to their original references
+	// for pulling them directly from the registries
+	sources := make(map[string]string)
 	group, groupCtx := run.WithContext(ctx, run.WithParallel(req.Parallel))
 	for _, image := range imagesToPull {
 		log := log.WithField("image", image)
@@ -258,6 +287,14 @@ func (v *vendorer) VendorDir(ctx context.Context, unpackedDir string, req Vendor
 			// image has already been vendored
 			continue
 		}
+		if v.registryPuller != nil {
+			name, err := imageWithoutRegistry(image)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			sources[name] = image
+			continue
+		}
 		image := image // create new variable for go routine below
 		group.Go(groupCtx, func() error {
 
@@ -280,7 +317,9 @@ func (v *vendorer) VendorDir(ctx context.Context, unpackedDir string, req Vendor
 	}
 
 	if req.VendorRuntime {
-		err = resourceFiles.RewriteManifest(v.translateRuntimeImages)
+		err = resourceFiles.RewriteManifest(func(m *schema.Manifest) error {
+			return v.translateRuntimeImages(ctx, m)
+		})
 		if err != nil {
 			return trace.Wrap(err)
 		}
@@ -292,12 +331,28 @@ func (v *vendorer) VendorDir(ctx context.Context, unpackedDir string, req Vendor
 
 	if ok, _ := utils.IsDirectory(filepath.Join(unpackedDir, defaults.RegistryDir)); ok {
 		log.Debug("Registry layers are present.")
-		return nil
+	} else {
+		err = v.exportImages(ctx, resourceFiles, chartImages, sources, unpackedDir, req)
+		if err != nil {
+			return trace.Wrap(err)
+		}
 	}
 
+	if req.PinDigests {
+		err = v.pinDigests(ctx, resourceFiles, chartResources, unpackedDir, req.ProgressReporter)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// exportImages pulls the images referenced in resources and charts and exports them
+// into the registry directory of the application package
+func (v *vendorer) exportImages(ctx context.Context, resourceFiles resources.ResourceFiles, chartImages []string, sources map[string]string, unpackedDir string, req VendorRequest) error {
 	// if the application package does not contain the dump of docker images of the referenced
 	// containers, pull all the necessary images, then export those images to disk
-	images, err = resourceFiles.Images()
+	images, err := resourceFiles.Images()
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -308,17 +363,75 @@ func (v *vendorer) VendorDir(ctx context.Context, unpackedDir string, req Vendor
 	}
 
 	log.Infof("No registry layers found, will pull and export images %q.", images)
-	if err = v.pullAndExportImages(ctx, teleutils.Deduplicate(images), unpackedDir, req.Parallel, req.ProgressReporter); err != nil {
+	if err = v.pullAndExportImages(ctx, teleutils.Deduplicate(images), sources, unpackedDir, req.Parallel, req.ProgressReporter); err != nil {
 		return trace.Wrap(err)
 	}
 
-	if err = v.pullAndExportImages(ctx, teleutils.Deduplicate(chartImages), unpackedDir, req.Parallel, req.ProgressReporter); err != nil {
+	if err = v.pullAndExportImages(ctx, teleutils.Deduplicate(chartImages), sources, unpackedDir, req.Parallel, req.ProgressReporter); err != nil {
 		return trace.Wrap(err)
 	}
 
 	return nil
 }
 
+// pinDigests rewrites the images in resources and chart values to reference
+// the manifest digests of the images exported into the registry directory
+func (v *vendorer) pinDigests(ctx context.Context, resourceFiles, chartResources resources.ResourceFiles, unpackedDir string, progress utils.Progress) error {
+	digests, err := docker.ImageDigests(ctx, filepath.Join(unpackedDir, defaults.RegistryDir))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	progress.PrintSubStep("Pinning %v vendored images to digests", len(digests))
+	err = resourceFiles.RewriteImages(func(image string) string {
+		pinned, ok := docker.PinImage(image, digests)
+		if !ok {
+			log.Warnf("No digest found for image %v.", image)
+		}
+		return pinned
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err = resourceFiles.Write(); err != nil {
+		return trace.Wrap(err)
+	}
+	for _, chart := range chartResources {
+		if err := pinChartValues(chart.Path(), digests); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// pinChartValues rewrites the images in the values file of the chart
+// in the specified directory to reference their manifest digests.
+//
+// Only the values that specify complete image references are rewritten,
+// images composed from several values in templates keep their tags
+func pinChartValues(chartDir string, digests map[string]string) error {
+	path := filepath.Join(chartDir, "values.yaml")
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return trace.ConvertSystemError(err)
+	}
+	data = chartImageValue.ReplaceAllFunc(data, func(match []byte) []byte {
+		groups := chartImageValue.FindSubmatch(match)
+		pinned, ok := docker.PinImage(string(groups[2]), digests)
+		if !ok {
+			return match
+		}
+		return []byte(string(groups[1]) + pinned + string(groups[3]))
+	})
+	return trace.ConvertSystemError(ioutil.WriteFile(path, data, defaults.SharedReadMask))
+}
+
+// chartImageValue matches the scalar values in a values file that
+// can be image references with a tag, e.g. "image: nginx:1.17"
+var chartImageValue = regexp.MustCompile(`(?m)((?::|-)[ \t]+["']?)([^\s"'#]+:[^\s"'#]+)(["']?(?:[ \t]+#.*)?)$`)
+
 // printResourceStatus prints a user-friendly status message about the provided
 // resource file which gives the user a high-level visibility into the process
 // of discovering images from resources
@@ -352,8 +465,11 @@ func printResourceStatus(resourceFile resources.ResourceFile, manifestPath strin
 
 // pullAndExportImages pulls the docker images of all referenced container images (if not yet
 // present locally), pushes them into an instance of a private docker registry and then
-// dumps the contents of this private registry into the specified directory
-func (v *vendorer) pullAndExportImages(ctx context.Context, images []string, exportDir string, parallel int, progress utils.Progress) error {
+// dumps the contents of this private registry into the specified directory.
+//
+// With the registry puller, the images are copied into the directory directly from
+// the registries, sources maps the images to their original references in this case
+func (v *vendorer) pullAndExportImages(ctx context.Context, images []string, sources map[string]string, exportDir string, parallel int, progress utils.Progress) error {
 	resourcesDir := filepath.Join(exportDir, "resources")
 	if err := os.MkdirAll(resourcesDir, defaults.PrivateDirMask); err != nil {
 		return trace.Wrap(trace.ConvertSystemError(err),
@@ -371,6 +487,10 @@ func (v *vendorer) pullAndExportImages(ctx context.Context, images []string, exp
 			"failed to create %q", layersDir)
 	}
 
+	if v.registryPuller != nil {
+		return trace.Wrap(v.pullImages(ctx, images, sources, layersDir, parallel, progress))
+	}
+
 	if err := exportLayers(ctx, exportDir, images, v.dockerClient,
 		log.WithField("export-directory", exportDir), parallel, progress); err != nil {
 		return trace.Wrap(err)
@@ -378,7 +498,50 @@ func (v *vendorer) pullAndExportImages(ctx context.Context, images []string, exp
 	return nil
 }
 
-func (v *vendorer) translateRuntimeImages(m *schema.Manifest) error {
+// checkPolicy validates the application images against the image policy.
+// Images that already refer to the local registry are not checked
+func (v *vendorer) checkPolicy(policy docker.ImagePolicy, images []string, progress utils.Progress) error {
+	var checked []string
+	for _, image := range teleutils.Deduplicate(images) {
+		if !strings.HasPrefix(image, v.registryURL) {
+			checked = append(checked, image)
+		}
+	}
+	progress.PrintSubStep("Checking %v images against the image policy", len(checked))
+	return trace.Wrap(policy.Check(checked))
+}
+
+// pullImages copies the specified images into the registry directory with the registry puller
+func (v *vendorer) pullImages(ctx context.Context, images []string, sources map[string]string, dir string, parallel int, progress utils.Progress) error {
+	req := docker.PullRequest{
+		Dir:      dir,
+		Images:   make(map[string]string),
+		Parallel: parallel,
+		Progress: progress,
+	}
+	for _, image := range images {
+		name, err := imageWithoutRegistry(image)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		source, ok := sources[name]
+		if !ok {
+			source = name
+		}
+		req.Images[name] = source
+	}
+	return trace.Wrap(v.registryPuller.Pull(ctx, req))
+}
+
+// translateRuntimeImage translates the runtime image into a package
+func (v *vendorer) translateRuntimeImage(ctx context.Context, req docker.TranslateImageRequest) error {
+	if v.registryPuller != nil {
+		return docker.TranslateRegistryRuntimeImage(ctx, v.registryPuller, req)
+	}
+	return docker.TranslateRuntimeImage(req)
+}
+
+func (v *vendorer) translateRuntimeImages(ctx context.Context, m *schema.Manifest) error {
 	if m.SystemOptions != nil && m.SystemOptions.BaseImage != "" {
 		_, tag, err := parseImageNameTag(m.SystemOptions.BaseImage)
 		if err != nil {
@@ -397,7 +560,7 @@ func (v *vendorer) translateRuntimeImages(m *schema.Manifest) error {
 			DockerInterface: v.dockerClient,
 			PackageService:  v.packages,
 		}
-		if err := docker.TranslateRuntimeImage(req); err != nil {
+		if err := v.translateRuntimeImage(ctx, req); err != nil {
 			return trace.Wrap(err)
 		}
 		if m.SystemOptions.Dependencies.Runtime == nil {
@@ -425,7 +588,7 @@ func (v *vendorer) translateRuntimeImages(m *schema.Manifest) error {
 				DockerInterface: v.dockerClient,
 				PackageService:  v.packages,
 			}
-			if err := docker.TranslateRuntimeImage(req); err != nil {
+			if err := v.translateRuntimeImage(ctx, req); err != nil {
 				return trace.Wrap(err)
 			}
 		}
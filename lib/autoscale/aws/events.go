@@ -166,6 +166,9 @@ func (a *Autoscaler) removeInstance(ctx context.Context, operator Operator, even
 			Servers:     []string{server.Hostname},
 			Force:       true,
 			NodeRemoved: true,
+			// the instance is already gone so there is no reason
+			// to wait for the maintenance window
+			IgnoreMaintenanceWindow: true,
 		})
 	if err != nil {
 		return trace.Wrap(err)
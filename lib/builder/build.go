@@ -34,7 +34,7 @@ import (
 
 // Build builds the standalone application installer using the provided builder
 func Build(ctx context.Context, builder *Builder) error {
-	err := checkBuildEnv()
+	err := checkBuildEnv(builder.RegistryPuller == nil)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -78,6 +78,12 @@ func Build(ctx context.Context, builder *Builder) error {
 			}
 			return trace.Wrap(err)
 		}
+		if len(builder.UpgradeFrom) != 0 {
+			err = builder.SyncIntermediateRuntimes(runtimeVersion)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+		}
 	}
 
 	builder.NextStep("Embedding application container images")
@@ -115,12 +121,15 @@ func Build(ctx context.Context, builder *Builder) error {
 }
 
 // checkBuildEnv makes sure that the environment "tele build" is invoked in is
-// suitable, for example, OS is supported and Docker is running
-func checkBuildEnv() error {
+// suitable, for example, OS is supported and Docker is running if requireDocker is set
+func checkBuildEnv(requireDocker bool) error {
 	if runtime.GOOS != "linux" {
 		return trace.BadParameter("tele build is not supported on %v, only "+
 			"Linux is supported", runtime.GOOS)
 	}
+	if !requireDocker {
+		return nil
+	}
 	client, err := docker.NewClient(constants.DockerEngineURL)
 	if err != nil {
 		return trace.Wrap(err)
@@ -25,13 +25,16 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/app/docker"
 	"github.com/gravitational/gravity/lib/app/service"
 	blobfs "github.com/gravitational/gravity/lib/blob/fs"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/helm"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/pack"
@@ -42,6 +45,7 @@ import (
 	"github.com/gravitational/gravity/lib/storage/keyval"
 	"github.com/gravitational/gravity/lib/utils"
 	"k8s.io/helm/pkg/chartutil"
+	"k8s.io/helm/pkg/provenance"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/docker/docker/pkg/archive"
@@ -87,6 +91,15 @@ type Config struct {
 	utils.Progress
 	// Silent suppresses all std output when set to true
 	Silent bool
+	// UpgradeFrom lists the installed runtime versions the cluster image
+	// should be able to upgrade from.
+	// Intermediate runtimes required for these upgrades are packaged with the image
+	UpgradeFrom []string
+	// Signatory optionally signs the application chart
+	Signatory *provenance.Signatory
+	// RegistryPuller, if set, vendors images directly from their registries
+	// without the docker daemon
+	RegistryPuller *docker.RegistryPuller
 }
 
 // CheckAndSetDefaults validates builder config and fills in defaults
@@ -115,6 +128,9 @@ func (c *Config) CheckAndSetDefaults() error {
 	if c.VendorReq.Parallel == 0 {
 		c.VendorReq.Parallel = runtime.NumCPU()
 	}
+	if c.RegistryPuller != nil && c.RegistryPuller.Parallel == 0 {
+		c.RegistryPuller.Parallel = c.VendorReq.Parallel
+	}
 	if c.Generator == nil {
 		c.Generator = &generator{}
 	}
@@ -158,7 +174,7 @@ func New(config Config) (*Builder, error) {
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
-		manifest, err = generateManifest(chart)
+		manifest, err = GenerateManifest(chart)
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
@@ -174,6 +190,13 @@ func New(config Config) (*Builder, error) {
 				trace.Unwrap(err)) // show original parsing error
 		}
 	}
+	err = checkArchitectures(*manifest, config.RegistryPuller)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if config.RegistryPuller != nil {
+		config.RegistryPuller.Architectures = manifest.Architectures()
+	}
 	b := &Builder{
 		Config:   config,
 		Manifest: *manifest,
@@ -204,6 +227,11 @@ type Builder struct {
 	Packages pack.PackageService
 	// Apps is the application service based on the layered package service
 	Apps app.Applications
+	// IntermediateRuntimes lists the intermediate runtimes to package with the image
+	IntermediateRuntimes []loc.Locator
+	// ImageDigests maps the vendored images to their manifest digests
+	// if the images are pinned to digests
+	ImageDigests map[string]string
 }
 
 // Locator returns locator of the application that's being built
@@ -305,9 +333,10 @@ func (b *Builder) Vendor(ctx context.Context, dir string) (io.ReadCloser, error)
 		}
 	}
 	vendorer, err := service.NewVendorer(service.VendorerConfig{
-		DockerURL:   constants.DockerEngineURL,
-		RegistryURL: constants.DockerRegistry,
-		Packages:    b.Packages,
+		DockerURL:      constants.DockerEngineURL,
+		RegistryURL:    constants.DockerRegistry,
+		Packages:       b.Packages,
+		RegistryPuller: b.RegistryPuller,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -319,9 +348,44 @@ func (b *Builder) Vendor(ctx context.Context, dir string) (io.ReadCloser, error)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if vendorReq.PinDigests {
+		b.ImageDigests, err = docker.ImageDigests(ctx, filepath.Join(dir, defaults.RegistryDir))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	if b.Signatory != nil {
+		err = b.signChart(dir)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
 	return archive.Tar(dir, archive.Uncompressed)
 }
 
+// signChart signs the vendored application chart and saves the
+// provenance file in the provided directory
+func (b *Builder) signChart(dir string) error {
+	chartDir := filepath.Join(dir, defaults.ResourcesDir)
+	if _, err := os.Stat(filepath.Join(chartDir, chartutil.ChartfileName)); err != nil {
+		if os.IsNotExist(err) {
+			return trace.BadParameter("only images with a Helm chart can be signed")
+		}
+		return trace.ConvertSystemError(err)
+	}
+	b.Info("Signing application chart.")
+	data, err := helm.SignChart(chartDir, b.Signatory)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, defaults.ChartProvenanceFile),
+		data, defaults.SharedReadMask)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return nil
+}
+
 // CreateApplication creates a Gravity application from the provided
 // data in the local database
 func (b *Builder) CreateApplication(data io.ReadCloser) (*app.Application, error) {
@@ -335,6 +399,7 @@ func (b *Builder) CreateApplication(data io.ReadCloser) (*app.Application, error
 		Source:    data,
 		ProgressC: progressC,
 		ErrorC:    errorC,
+		Labels:    docker.DigestLabels(b.ImageDigests),
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -349,6 +414,25 @@ func (b *Builder) CreateApplication(data io.ReadCloser) (*app.Application, error
 	return b.Apps.GetImportedApplication(*op)
 }
 
+// checkArchitectures makes sure the images can be vendored for all architectures
+// supported by the manifest
+func checkArchitectures(manifest schema.Manifest, puller *docker.RegistryPuller) error {
+	architectures := manifest.Architectures()
+	if len(architectures) == 1 && architectures[0] == defaults.Architecture {
+		return nil
+	}
+	if puller == nil {
+		return trace.BadParameter("building images for architectures %v requires "+
+			"pulling images directly from registries with --pull-from-registry",
+			strings.Join(architectures, ", "))
+	}
+	if len(manifest.RuntimeImages()) != 0 {
+		return trace.BadParameter("custom base images are only supported for %v images",
+			defaults.Architecture)
+	}
+	return nil
+}
+
 // GenerateInstaller generates an installer tarball for the specified
 // application and returns its data as a stream
 func (b *Builder) GenerateInstaller(application app.Application) (io.ReadCloser, error) {
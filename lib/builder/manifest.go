@@ -25,8 +25,8 @@ import (
 	"k8s.io/helm/pkg/proto/hapi/chart"
 )
 
-// generateManifest generates an application manifest for the provided Helm chart.
-func generateManifest(chart *chart.Chart) (*schema.Manifest, error) {
+// GenerateManifest generates an application manifest for the provided Helm chart.
+func GenerateManifest(chart *chart.Chart) (*schema.Manifest, error) {
 	return &schema.Manifest{
 		Header: schema.Header{
 			TypeMeta: metav1.TypeMeta{
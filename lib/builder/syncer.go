@@ -28,6 +28,7 @@ import (
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/coreos/go-semver/semver"
@@ -113,6 +114,20 @@ func (s *s3Syncer) Sync(builder *Builder, runtimeVersion *semver.Version) error
 	}, builder.Manifest)
 }
 
+// ListRuntimeVersions returns the versions of runtimes available in S3 bucket
+func (s *s3Syncer) ListRuntimeVersions() (versions []string, err error) {
+	apps, err := s.hub.List(false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, app := range apps {
+		if app.Name == defaults.TelekubePackage {
+			versions = append(versions, app.Version)
+		}
+	}
+	return versions, nil
+}
+
 // packSyncer synchronizes local package cache with pack/apps services
 type packSyncer struct {
 	pack pack.PackageService
@@ -153,3 +168,20 @@ func (s *packSyncer) Sync(builder *Builder, runtimeVersion *semver.Version) erro
 	}
 	return nil
 }
+
+// ListRuntimeVersions returns the versions of runtimes available in the apps service
+func (s *packSyncer) ListRuntimeVersions() (versions []string, err error) {
+	runtimes, err := s.apps.ListApps(app.ListAppsRequest{
+		Repository: defaults.SystemAccountOrg,
+		Type:       storage.AppRuntime,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, runtime := range runtimes {
+		if runtime.Package.Name == defaults.Runtime {
+			versions = append(versions, runtime.Package.Version)
+		}
+	}
+	return versions, nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/update"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/gravitational/trace"
+)
+
+// SyncIntermediateRuntimes computes the chains of intermediate runtimes
+// required to upgrade from each of the configured installed runtime versions
+// to the selected runtime and makes sure they are present in the local cache.
+//
+// The computed runtimes are packaged with the cluster image
+func (b *Builder) SyncIntermediateRuntimes(runtimeVersion *semver.Version) error {
+	apps, err := b.Env.AppServiceLocal(localenv.AppConfig{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	target, err := apps.GetApp(loc.Runtime.WithVersion(runtimeVersion))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	syncer, err := b.NewSyncer(b)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	versions, err := b.listRuntimeVersions(syncer, apps)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	getRuntime := func(version string) (*schema.Manifest, error) {
+		runtime, err := b.syncRuntime(syncer, apps, version)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &runtime.Manifest, nil
+	}
+	for _, from := range b.UpgradeFrom {
+		path, err := update.FindUpgradePath(update.UpgradePathConfig{
+			From:       from,
+			To:         &target.Manifest,
+			Versions:   versions,
+			GetRuntime: getRuntime,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if len(path) == 0 {
+			b.PrintSubStep("Base image %v can be upgraded to directly", from)
+			continue
+		}
+		b.PrintSubStep("Upgrade from base image %v requires intermediate base images %v", from, path)
+		for _, version := range path {
+			locator := loc.Runtime
+			locator.Version = version
+			b.IntermediateRuntimes = append(b.IntermediateRuntimes, locator)
+		}
+	}
+	b.IntermediateRuntimes = loc.Deduplicate(b.IntermediateRuntimes)
+	return nil
+}
+
+// runtimeLister is implemented by syncers that can list available runtime versions
+type runtimeLister interface {
+	// ListRuntimeVersions returns the versions of the available runtimes
+	ListRuntimeVersions() ([]string, error)
+}
+
+// listRuntimeVersions returns the versions of runtimes available either
+// in the local cache or in the repository of the specified syncer
+func (b *Builder) listRuntimeVersions(syncer Syncer, apps app.Applications) (versions []string, err error) {
+	cached, err := apps.ListApps(app.ListAppsRequest{
+		Repository: defaults.SystemAccountOrg,
+		Type:       storage.AppRuntime,
+	})
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	for _, runtime := range cached {
+		if runtime.Package.Name == defaults.Runtime {
+			versions = append(versions, runtime.Package.Version)
+		}
+	}
+	if lister, ok := syncer.(runtimeLister); ok {
+		available, err := lister.ListRuntimeVersions()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, version := range available {
+			if !utils.StringInSlice(versions, version) {
+				versions = append(versions, version)
+			}
+		}
+	}
+	return versions, nil
+}
+
+// syncRuntime makes sure the runtime with the specified version is
+// present in the local cache and returns it
+func (b *Builder) syncRuntime(syncer Syncer, apps app.Applications, version string) (*app.Application, error) {
+	runtimeVersion, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	locator := loc.Runtime.WithVersion(runtimeVersion)
+	runtime, err := apps.GetApp(locator)
+	if err == nil {
+		err = app.VerifyDependencies(runtime, apps, b.Env.Packages)
+		if err == nil {
+			return runtime, nil
+		}
+	}
+	if !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	b.Infof("Synchronizing intermediate runtime %v.", locator)
+	// Sync with a copy of the builder that has the intermediate runtime as the base
+	runtimeBuilder := *b
+	runtimeBuilder.Manifest = *b.Manifest.DeepCopy()
+	runtimeBuilder.Manifest.SetBase(locator)
+	if err := syncer.Sync(&runtimeBuilder, runtimeVersion); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return apps.GetApp(locator)
+}
@@ -0,0 +1,355 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/app/service"
+	"github.com/gravitational/gravity/lib/builder"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/docker/docker/pkg/archive"
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"k8s.io/helm/pkg/chartutil"
+	"k8s.io/helm/pkg/provenance"
+	"k8s.io/helm/pkg/repo"
+)
+
+// SyncerConfig is the helm repository syncer configuration.
+type SyncerConfig struct {
+	// Apps is the cluster application service charts are imported into.
+	Apps app.Applications
+	// Packages is the cluster package service.
+	Packages pack.PackageService
+	// Vendorer vendors chart images into application packages.
+	Vendorer service.Vendorer
+	// Client is the HTTP client used to download charts.
+	Client *http.Client
+	// Parallel is the number of images vendored in parallel.
+	Parallel int
+	// MaxDownloadSize limits the size of downloaded indexes and charts.
+	MaxDownloadSize int64
+	// FieldLogger is used for logging.
+	logrus.FieldLogger
+}
+
+// Check validates the syncer config and sets defaults.
+func (c *SyncerConfig) Check() error {
+	if c.Apps == nil {
+		return trace.BadParameter("missing Apps")
+	}
+	if c.Packages == nil {
+		return trace.BadParameter("missing Packages")
+	}
+	if c.Vendorer == nil {
+		return trace.BadParameter("missing Vendorer")
+	}
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: defaults.HelmRepositoryTimeout}
+	}
+	if c.MaxDownloadSize == 0 {
+		c.MaxDownloadSize = defaults.HelmRepositoryMaxDownloadSize
+	}
+	if c.FieldLogger == nil {
+		c.FieldLogger = logrus.WithField(trace.Component, "helm-sync")
+	}
+	return nil
+}
+
+// NewSyncer returns a new syncer that imports charts from external
+// helm repositories into the cluster catalog.
+func NewSyncer(config SyncerConfig) (*Syncer, error) {
+	if err := config.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Syncer{
+		SyncerConfig: config,
+	}, nil
+}
+
+// Syncer imports charts from external helm repositories into the cluster catalog.
+type Syncer struct {
+	// SyncerConfig is the syncer configuration.
+	SyncerConfig
+}
+
+// Sync imports the latest versions of the charts selected by the provided
+// repository and returns the status of the sync.
+//
+// Chart images are vendored so the imported applications can be installed
+// without access to the repository or the image registries.
+func (s *Syncer) Sync(ctx context.Context, repository storage.HelmRepository) storage.HelmRepositoryStatus {
+	status := storage.HelmRepositoryStatus{
+		LastSynced: time.Now().UTC(),
+	}
+	imported, err := s.sync(ctx, repository)
+	for _, locator := range imported {
+		status.Imported = append(status.Imported, locator.String())
+	}
+	if err != nil {
+		s.WithField("repository", repository.GetName()).Warnf("Sync failed: %v.",
+			trace.DebugReport(err))
+		status.Error = trace.UserMessage(err)
+	}
+	return status
+}
+
+func (s *Syncer) sync(ctx context.Context, repository storage.HelmRepository) (imported []loc.Locator, err error) {
+	index, err := s.fetchIndex(ctx, repository)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	err = s.Packages.UpsertRepository(defaults.SystemAccountOrg, time.Time{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var errors []error
+	for _, chart := range selectCharts(index, repository) {
+		locator, err := loc.NewLocator(defaults.SystemAccountOrg, chart.Name, chart.Version)
+		if err != nil {
+			errors = append(errors, trace.Wrap(err))
+			continue
+		}
+		_, err = s.Apps.GetApp(*locator)
+		if err == nil {
+			imported = append(imported, *locator)
+			continue
+		}
+		if !trace.IsNotFound(err) {
+			errors = append(errors, trace.Wrap(err))
+			continue
+		}
+		s.WithField("repository", repository.GetName()).Infof("Importing chart %v.", locator)
+		err = s.importChart(ctx, repository, chart)
+		if err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to import chart %v", locator))
+			continue
+		}
+		imported = append(imported, *locator)
+	}
+	return imported, trace.NewAggregate(errors...)
+}
+
+func (s *Syncer) fetchIndex(ctx context.Context, repository storage.HelmRepository) (*repo.IndexFile, error) {
+	indexURL := strings.TrimSuffix(repository.GetURL(), "/") + "/index.yaml"
+	data, err := s.download(ctx, repository, indexURL)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var index repo.IndexFile
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, trace.BadParameter("failed to parse repository index %v: %v", indexURL, err)
+	}
+	if index.APIVersion == "" {
+		return nil, trace.BadParameter("repository index %v has no API version", indexURL)
+	}
+	index.SortEntries()
+	return &index, nil
+}
+
+func (s *Syncer) importChart(ctx context.Context, repository storage.HelmRepository, chart *repo.ChartVersion) error {
+	if len(chart.URLs) == 0 {
+		return trace.NotFound("chart %v:%v has no download URLs", chart.Name, chart.Version)
+	}
+	chartURL, err := repo.ResolveReferenceURL(repository.GetURL(), chart.URLs[0])
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	data, err := s.download(ctx, repository, chartURL)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if chart.Digest != "" {
+		digest, err := provenance.Digest(bytes.NewReader(data))
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if digest != chart.Digest {
+			return trace.BadParameter("chart %v digest mismatch: expected %v, got %v",
+				chartURL, chart.Digest, digest)
+		}
+	}
+	dir, err := ioutil.TempDir("", "helm-sync")
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer os.RemoveAll(dir)
+	resourcesDir := filepath.Join(dir, defaults.ResourcesDir)
+	err = s.unpackChart(dir, resourcesDir, data)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	manifestPath := filepath.Join(resourcesDir, "app.yaml")
+	err = writeManifest(resourcesDir, manifestPath, repository)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = s.Vendorer.VendorDir(ctx, dir, service.VendorRequest{
+		PackageName:      chart.Name,
+		PackageVersion:   chart.Version,
+		ManifestPath:     manifestPath,
+		ResourcePatterns: []string{defaults.VendorPattern},
+		Parallel:         s.Parallel,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	reader, err := archive.Tar(dir, archive.Uncompressed)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer reader.Close()
+	return s.createApplication(reader)
+}
+
+// unpackChart extracts the chart archive into the resources directory
+func (s *Syncer) unpackChart(dir, resourcesDir string, data []byte) error {
+	chartsDir := filepath.Join(dir, "charts")
+	err := chartutil.Expand(chartsDir, bytes.NewReader(data))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	entries, err := ioutil.ReadDir(chartsDir)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if len(entries) != 1 || !entries[0].IsDir() {
+		return trace.BadParameter("chart archive should contain a single chart directory")
+	}
+	err = os.Rename(filepath.Join(chartsDir, entries[0].Name()), resourcesDir)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return trace.ConvertSystemError(os.Remove(chartsDir))
+}
+
+func (s *Syncer) createApplication(data io.Reader) error {
+	progressC := make(chan *app.ProgressEntry)
+	errorC := make(chan error, 1)
+	_, err := s.Apps.CreateImportOperation(&app.ImportRequest{
+		Source:    ioutil.NopCloser(data),
+		ProgressC: progressC,
+		ErrorC:    errorC,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// wait for the import to complete
+	for range progressC {
+	}
+	return trace.Wrap(<-errorC)
+}
+
+// download fetches the specified URL from the repository.
+//
+// The repository credentials are only sent to the repository host so they
+// do not leak to the hosts charts are referenced from.
+func (s *Syncer) download(ctx context.Context, repository storage.HelmRepository, rawURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if repository.GetUsername() != "" && sameHost(repository.GetURL(), req.URL) {
+		req.SetBasicAuth(repository.GetUsername(), repository.GetPassword())
+	}
+	resp, err := s.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, trace.ConnectionProblem(err, "failed to download %v", rawURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, trace.BadParameter("failed to download %v: %v", rawURL, resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, s.MaxDownloadSize+1))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if int64(len(data)) > s.MaxDownloadSize {
+		return nil, trace.LimitExceeded("%v exceeds the maximum download size of %v bytes",
+			rawURL, s.MaxDownloadSize)
+	}
+	return data, nil
+}
+
+// sameHost returns true if the provided URL points to the same
+// scheme and host as the repository URL
+func sameHost(repositoryURL string, u *url.URL) bool {
+	repository, err := url.Parse(repositoryURL)
+	if err != nil {
+		return false
+	}
+	return repository.Scheme == u.Scheme && repository.Host == u.Host
+}
+
+// writeManifest generates the application manifest for the chart in the
+// specified directory
+func writeManifest(chartDir, manifestPath string, repository storage.HelmRepository) error {
+	chart, err := chartutil.LoadDir(chartDir)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	manifest, err := builder.GenerateManifest(chart)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	manifest.Metadata.Labels[constants.HelmRepositoryLabel] = repository.GetName()
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.ConvertSystemError(ioutil.WriteFile(manifestPath, data, defaults.SharedReadMask))
+}
+
+// selectCharts returns the latest versions of the charts selected by the
+// repository, sorted by name.
+//
+// The index entries are expected to be sorted with the latest version first.
+func selectCharts(index *repo.IndexFile, repository storage.HelmRepository) (charts []*repo.ChartVersion) {
+	var names []string
+	for name := range index.Entries {
+		if repository.HasChart(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		versions := index.Entries[name]
+		if len(versions) > repository.GetMaxVersions() {
+			versions = versions[:repository.GetMaxVersions()]
+		}
+		charts = append(charts, versions...)
+	}
+	return charts
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalog
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/app/service"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops/opsservice"
+	"github.com/gravitational/gravity/lib/storage"
+
+	check "gopkg.in/check.v1"
+	"k8s.io/helm/pkg/chartutil"
+	"k8s.io/helm/pkg/repo"
+)
+
+type syncSuite struct {
+	services opsservice.TestServices
+	syncer   *Syncer
+	server   *httptest.Server
+	vendorer *testVendorer
+}
+
+var _ = check.Suite(&syncSuite{})
+
+func (s *syncSuite) SetUpTest(c *check.C) {
+	s.services = opsservice.SetupTestServices(c)
+	_, err := s.services.Backend.CreateAccount(storage.Account{
+		ID:  defaults.SystemAccountID,
+		Org: defaults.SystemAccountOrg,
+	})
+	c.Assert(err, check.IsNil)
+
+	repoDir := c.MkDir()
+	writeChart(c, repoDir, "nginx", "0.1.0")
+	writeChart(c, repoDir, "nginx", "0.2.0")
+	writeChart(c, repoDir, "redis", "1.0.0")
+	s.server = httptest.NewServer(http.FileServer(http.Dir(repoDir)))
+	index, err := repo.IndexDirectory(repoDir, s.server.URL)
+	c.Assert(err, check.IsNil)
+	c.Assert(index.WriteFile(filepath.Join(repoDir, "index.yaml"), 0644), check.IsNil)
+
+	s.vendorer = &testVendorer{}
+	s.syncer, err = NewSyncer(SyncerConfig{
+		Apps:     s.services.Apps,
+		Packages: s.services.Packages,
+		Vendorer: s.vendorer,
+	})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *syncSuite) TearDownTest(c *check.C) {
+	s.server.Close()
+}
+
+func (s *syncSuite) TestImportsLatestVersions(c *check.C) {
+	repository := s.repository(c, nil)
+	status := s.syncer.Sync(context.TODO(), repository)
+	c.Assert(status.Error, check.Equals, "")
+	c.Assert(status.Imported, check.DeepEquals, []string{
+		"gravitational.io/nginx:0.2.0",
+		"gravitational.io/redis:1.0.0",
+	})
+	c.Assert(s.vendorer.count, check.Equals, 2)
+
+	nginx, err := s.services.Apps.GetApp(loc.MustParseLocator("gravitational.io/nginx:0.2.0"))
+	c.Assert(err, check.IsNil)
+	c.Assert(nginx.Manifest.Metadata.Labels[constants.HelmRepositoryLabel], check.Equals, "test")
+	_, err = s.services.Apps.GetApp(loc.MustParseLocator("gravitational.io/nginx:0.1.0"))
+	c.Assert(err, check.NotNil)
+
+	// Already imported versions are skipped
+	status = s.syncer.Sync(context.TODO(), repository)
+	c.Assert(status.Error, check.Equals, "")
+	c.Assert(status.Imported, check.HasLen, 2)
+	c.Assert(s.vendorer.count, check.Equals, 2)
+}
+
+func (s *syncSuite) TestImportsSelectedCharts(c *check.C) {
+	status := s.syncer.Sync(context.TODO(), s.repository(c, []string{"nginx"}))
+	c.Assert(status.Error, check.Equals, "")
+	c.Assert(status.Imported, check.DeepEquals, []string{"gravitational.io/nginx:0.2.0"})
+}
+
+func (s *syncSuite) TestReportsUnreachableRepository(c *check.C) {
+	repository := s.repository(c, nil)
+	s.server.Close()
+	status := s.syncer.Sync(context.TODO(), repository)
+	c.Assert(status.Error, check.Matches, "failed to download .*index.yaml")
+	c.Assert(status.LastSynced.IsZero(), check.Equals, false)
+	c.Assert(status.Imported, check.HasLen, 0)
+}
+
+func (s *syncSuite) TestSendsCredentialsOnlyToRepositoryHost(c *check.C) {
+	var authorized []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); ok {
+			authorized = append(authorized, r.Host)
+		}
+	})
+	repositoryServer := httptest.NewServer(handler)
+	defer repositoryServer.Close()
+	chartServer := httptest.NewServer(handler)
+	defer chartServer.Close()
+
+	repository := storage.NewHelmRepository("test", storage.HelmRepositorySpecV1{
+		URL:      repositoryServer.URL,
+		Username: "user",
+		Password: "secret",
+	})
+	c.Assert(repository.CheckAndSetDefaults(), check.IsNil)
+	_, err := s.syncer.download(context.TODO(), repository, repositoryServer.URL+"/index.yaml")
+	c.Assert(err, check.IsNil)
+	_, err = s.syncer.download(context.TODO(), repository, chartServer.URL+"/nginx-0.2.0.tgz")
+	c.Assert(err, check.IsNil)
+	c.Assert(authorized, check.DeepEquals, []string{repositoryServer.Listener.Addr().String()})
+}
+
+func (s *syncSuite) TestLimitsDownloadSize(c *check.C) {
+	s.syncer.MaxDownloadSize = 16
+	status := s.syncer.Sync(context.TODO(), s.repository(c, nil))
+	c.Assert(status.Error, check.Matches, ".*index.yaml exceeds the maximum download size of 16 bytes")
+}
+
+func (s *syncSuite) repository(c *check.C, charts []string) storage.HelmRepository {
+	repository := storage.NewHelmRepository("test", storage.HelmRepositorySpecV1{
+		URL:    s.server.URL,
+		Charts: charts,
+	})
+	c.Assert(repository.CheckAndSetDefaults(), check.IsNil)
+	return repository
+}
+
+func writeChart(c *check.C, dir, name, version string) {
+	chartDir := filepath.Join(c.MkDir(), name)
+	c.Assert(os.MkdirAll(filepath.Join(chartDir, "templates"), 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(chartDir, "Chart.yaml"),
+		[]byte("name: "+name+"\nversion: "+version+"\n"), 0644), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(chartDir, "templates", "pod.yaml"),
+		[]byte("kind: Pod\n"), 0644), check.IsNil)
+	chart, err := chartutil.LoadDir(chartDir)
+	c.Assert(err, check.IsNil)
+	_, err = chartutil.Save(chart, dir)
+	c.Assert(err, check.IsNil)
+}
+
+// testVendorer counts vendored applications without pulling any images
+type testVendorer struct {
+	count int
+}
+
+func (v *testVendorer) VendorDir(ctx context.Context, dir string, req service.VendorRequest) error {
+	v.count++
+	return nil
+}
+
+func (v *testVendorer) VendorTarball(ctx context.Context, tarball io.ReadCloser, req service.VendorRequest) (string, error) {
+	return "", nil
+}
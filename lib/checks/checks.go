@@ -23,6 +23,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -89,6 +90,13 @@ func ValidateManifest(
 	failedProbes = append(failedProbes, failed...)
 
 	failedProbes = append(failedProbes, schema.ValidateKubelet(profile, manifest)...)
+
+	failed, err = RunDiskIOChecks(context.TODO(), profile, stateDir)
+	if err != nil {
+		errors = append(errors, trace.Wrap(err,
+			"error validating disk I/O requirements, see syslog for details"))
+	}
+	failedProbes = append(failedProbes, failed...)
 	return failedProbes, trace.NewAggregate(errors...)
 }
 
@@ -289,6 +297,9 @@ type checker struct {
 	servers  []Server
 	// requirements maps node profile to a set of requirements
 	requirements map[string]Requirements
+	// ClusterServers optionally lists the existing servers of the cluster
+	// the servers are joining
+	ClusterServers []storage.Server
 }
 
 // Features controls which tests the checker will run
@@ -413,6 +424,11 @@ func (r *checker) Run(ctx context.Context) error {
 		errors = append(errors, err)
 	}
 
+	err = checkArchitectures(r.servers, r.ClusterServers, r.manifest)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
 	err = checkTime(time.Now().UTC(), r.servers)
 	if err != nil {
 		errors = append(errors, err)
@@ -800,6 +816,50 @@ func checkSameOS(servers []Server) error {
 	return nil
 }
 
+// checkArchitectures makes sure the servers have the architectures supported
+// by the manifest and, unless the manifest allows mixed architectures, that
+// the servers and the existing cluster servers have the same architecture
+func checkArchitectures(servers []Server, clusterServers []storage.Server, manifest schema.Manifest) error {
+	archToNodes := make(map[string][]string)
+	var errors []error
+	for _, server := range servers {
+		arch := server.ServerInfo.GetArch()
+		if !utils.StringInSlice(manifest.Architectures(), arch) {
+			errors = append(errors, trace.BadParameter(
+				"server %v has architecture %v, supported architectures: %v",
+				server.ServerInfo.GetHostname(), arch, strings.Join(manifest.Architectures(), ", ")))
+		}
+		archToNodes[arch] = append(archToNodes[arch], fmt.Sprintf("%v (%v)",
+			server.ServerInfo.GetHostname(), server.AdvertiseAddr))
+	}
+	for _, server := range clusterServers {
+		arch := server.Arch
+		if arch == "" {
+			arch = defaults.Architecture
+		}
+		archToNodes[arch] = append(archToNodes[arch], fmt.Sprintf("%v (%v)",
+			server.Hostname, server.AdvertiseIP))
+	}
+
+	if len(archToNodes) > 1 && !manifest.AllowsMixedArchitectures() {
+		var formatted []string
+		for arch, nodes := range archToNodes {
+			formatted = append(formatted, fmt.Sprintf(
+				"%v: %v", arch, strings.Join(nodes, ", ")))
+		}
+		sort.Strings(formatted)
+		errors = append(errors, trace.BadParameter(
+			"servers have different architectures and the manifest does not "+
+				"allow mixed architectures:\n%v", strings.Join(formatted, "\n")))
+	}
+	if len(errors) != 0 {
+		return trace.NewAggregate(errors...)
+	}
+
+	log.Infof("Servers passed architecture check: %v.", archToNodes)
+	return nil
+}
+
 // checkTime checks if time it out of sync between servers
 func checkTime(currentTime time.Time, servers []Server) error {
 	// server can not be out of sync with itself
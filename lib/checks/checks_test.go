@@ -156,3 +156,37 @@ func (s *ChecksSuite) TestCheckSameOS(c *C) {
 	c.Assert(checkSameOS(infos[:2]), NotNil)
 	c.Assert(checkSameOS(infos[1:]), IsNil)
 }
+
+func (s *ChecksSuite) TestCheckArchitectures(c *C) {
+	server := func(hostname, arch string) Server {
+		return Server{
+			ServerInfo: ServerInfo{
+				System: storage.NewSystemInfo(storage.SystemSpecV2{
+					Hostname: hostname,
+					Arch:     arch,
+				}),
+			},
+		}
+	}
+	amd64 := server("node-1", "amd64")
+	arm64 := server("node-2", "arm64")
+	manifest := schema.Manifest{
+		SystemOptions: &schema.SystemOptions{
+			Architectures: []string{"amd64", "arm64"},
+		},
+	}
+
+	c.Assert(checkArchitectures([]Server{amd64, server("node-3", "")}, nil, schema.Manifest{}), IsNil)
+	c.Assert(checkArchitectures([]Server{arm64}, nil, schema.Manifest{}), ErrorMatches,
+		"server node-2 has architecture arm64, supported architectures: amd64")
+	c.Assert(checkArchitectures([]Server{arm64}, nil, manifest), IsNil)
+	c.Assert(checkArchitectures([]Server{amd64, arm64}, nil, manifest), ErrorMatches,
+		"(?s)servers have different architectures.*amd64: node-1.*arm64: node-2.*")
+	// nodes joining the existing cluster are checked against its servers
+	clusterServers := []storage.Server{{Hostname: "master", AdvertiseIP: "10.0.0.1"}}
+	c.Assert(checkArchitectures([]Server{arm64}, clusterServers, manifest), ErrorMatches,
+		"(?s)servers have different architectures.*amd64: master.*arm64: node-2.*")
+
+	manifest.SystemOptions.AllowMixedArchitectures = true
+	c.Assert(checkArchitectures([]Server{amd64, arm64}, clusterServers, manifest), IsNil)
+}
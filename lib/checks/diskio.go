@@ -0,0 +1,373 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gravitational/satellite/agent/health"
+	"github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// DiskIOCheckerID is the ID of the disk I/O performance checker
+const DiskIOCheckerID = "disk-io"
+
+// DiskIOTarget describes a directory to run the disk I/O benchmark against
+type DiskIOTarget struct {
+	// Name is a short name of the target, e.g. etcd
+	Name string
+	// Path is the directory to test.
+	// If the directory does not exist yet, the closest existing
+	// parent directory is tested instead
+	Path string
+	// MaxFsyncLatency is the maximum allowed 99th percentile fsync latency
+	MaxFsyncLatency time.Duration
+	// MinBytesPerSecond is the minimum required sequential write throughput
+	MinBytesPerSecond uint64
+}
+
+// String implements fmt.Stringer
+func (r DiskIOTarget) String() string {
+	return fmt.Sprintf("disk-io(name=%v, path=%v)", r.Name, r.Path)
+}
+
+// DiskIOCheckerData is the disk I/O checker payload attached to probes
+type DiskIOCheckerData struct {
+	// Name is the name of the tested target
+	Name string `json:"name"`
+	// Path is the tested directory
+	Path string `json:"path"`
+	// FsyncLatency is the measured 99th percentile fsync latency
+	FsyncLatency time.Duration `json:"fsync_latency"`
+	// MaxFsyncLatency is the configured fsync latency threshold
+	MaxFsyncLatency time.Duration `json:"max_fsync_latency,omitempty"`
+	// BytesPerSecond is the measured sequential write throughput
+	BytesPerSecond uint64 `json:"bytes_per_second"`
+	// MinBytesPerSecond is the configured throughput threshold
+	MinBytesPerSecond uint64 `json:"min_bytes_per_second,omitempty"`
+}
+
+// Failed returns true if the measurements do not satisfy the thresholds
+func (r DiskIOCheckerData) Failed() bool {
+	return r.latencyFailed() || r.throughputFailed()
+}
+
+// FailureMessage returns a message describing the failed thresholds
+func (r DiskIOCheckerData) FailureMessage() string {
+	var failures []string
+	if r.latencyFailed() {
+		failures = append(failures, fmt.Sprintf("fsync latency %v is higher than maximum of %v",
+			r.FsyncLatency, r.MaxFsyncLatency))
+	}
+	if r.throughputFailed() {
+		failures = append(failures, fmt.Sprintf("write throughput %v/s is lower than required %v/s",
+			humanize.Bytes(r.BytesPerSecond), humanize.Bytes(r.MinBytesPerSecond)))
+	}
+	return fmt.Sprintf("%v directory %v: %v", r.Name, r.Path, strings.Join(failures, ", "))
+}
+
+// SuccessMessage returns a message describing the measurements
+func (r DiskIOCheckerData) SuccessMessage() string {
+	return fmt.Sprintf("%v directory %v: fsync latency %v, write throughput %v/s",
+		r.Name, r.Path, r.FsyncLatency, humanize.Bytes(r.BytesPerSecond))
+}
+
+func (r DiskIOCheckerData) latencyFailed() bool {
+	return r.MaxFsyncLatency != 0 && r.FsyncLatency > r.MaxFsyncLatency
+}
+
+func (r DiskIOCheckerData) throughputFailed() bool {
+	return r.MinBytesPerSecond != 0 && r.BytesPerSecond < r.MinBytesPerSecond
+}
+
+// NewDiskIOChecker returns a checker that benchmarks fsync latency
+// and sequential write throughput of the specified directories
+func NewDiskIOChecker(targets ...DiskIOTarget) health.Checker {
+	return &diskIOChecker{
+		targets:   targets,
+		benchmark: benchmarkDir,
+	}
+}
+
+// Name returns the name of this checker
+func (r *diskIOChecker) Name() string {
+	return DiskIOCheckerID
+}
+
+// Check runs the disk I/O benchmark for each target and reports the results
+func (r *diskIOChecker) Check(ctx context.Context, reporter health.Reporter) {
+	for _, target := range r.targets {
+		reporter.Add(r.check(ctx, target))
+	}
+}
+
+func (r *diskIOChecker) check(ctx context.Context, target DiskIOTarget) *agentpb.Probe {
+	ctx, cancel := context.WithTimeout(ctx, defaults.DiskIOCheckTimeout)
+	defer cancel()
+	data, err := r.benchmark(ctx, target)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to benchmark %v.", target)
+		return &agentpb.Probe{
+			Checker: r.Name(),
+			Detail:  fmt.Sprintf("failed to benchmark %v directory %v", target.Name, target.Path),
+			Error:   trace.UserMessage(err),
+			Status:  agentpb.Probe_Failed,
+		}
+	}
+	probe := &agentpb.Probe{
+		Checker: r.Name(),
+		Status:  agentpb.Probe_Running,
+		Detail:  data.SuccessMessage(),
+	}
+	if data.Failed() {
+		probe.Status = agentpb.Probe_Failed
+		probe.Detail = data.FailureMessage()
+		probe.Error = "disk is too slow"
+	}
+	probe.CheckerData, err = json.Marshal(data)
+	if err != nil {
+		log.WithError(err).Warn("Failed to marshal checker data.")
+	}
+	log.Infof("Disk I/O check for %v: %v.", target, probe.Detail)
+	return probe
+}
+
+type diskIOChecker struct {
+	targets   []DiskIOTarget
+	benchmark func(context.Context, DiskIOTarget) (*DiskIOCheckerData, error)
+}
+
+// DiskIOTargets returns the list of disk I/O benchmark targets for the
+// specified node profile.
+//
+// The etcd and docker directories are always tested with the default thresholds.
+// The thresholds can be overridden per volume in the profile requirements:
+// the volume with the longest path that contains the target directory
+// contributes its thresholds
+func DiskIOTargets(profile schema.NodeProfile, stateDir string) ([]DiskIOTarget, error) {
+	targets := []DiskIOTarget{
+		{
+			Name:              "etcd",
+			Path:              filepath.Join(stateDir, defaults.PlanetDir, "etcd"),
+			MaxFsyncLatency:   defaults.DiskFsyncLatency,
+			MinBytesPerSecond: defaultTransferRate.BytesPerSecond(),
+		},
+		{
+			Name:              "docker",
+			Path:              filepath.Join(stateDir, defaults.PlanetDir, "docker"),
+			MaxFsyncLatency:   defaults.DiskFsyncLatency,
+			MinBytesPerSecond: defaultTransferRate.BytesPerSecond(),
+		},
+	}
+	for i, target := range targets {
+		volume := volumeForDir(profile.Requirements.Volumes, target.Path, stateDir)
+		if volume == nil {
+			continue
+		}
+		latency, err := volume.FsyncLatency()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if latency != 0 {
+			targets[i].MaxFsyncLatency = latency
+		}
+		if volume.MinTransferRate != 0 {
+			targets[i].MinBytesPerSecond = volume.MinTransferRate.BytesPerSecond()
+		}
+	}
+	return targets, nil
+}
+
+// RunDiskIOChecks runs the disk I/O benchmark for the specified node profile
+// on the local node.
+// Returns list of failed health probes.
+func RunDiskIOChecks(ctx context.Context, profile schema.NodeProfile, stateDir string) (failed []*agentpb.Probe, err error) {
+	targets, err := DiskIOTargets(profile, stateDir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var probes health.Probes
+	NewDiskIOChecker(targets...).Check(ctx, &probes)
+	return probes.GetFailed(), nil
+}
+
+// volumeForDir returns the volume with the longest path that contains dir
+// and sets any disk I/O thresholds. Returns nil if there is no such volume.
+//
+// The path of the volume for the default state directory is translated
+// to the actual state directory
+func volumeForDir(volumes []schema.Volume, dir, stateDir string) *schema.Volume {
+	var result *schema.Volume
+	var resultPath string
+	for i, volume := range volumes {
+		if volume.MaxFsyncLatency == "" && volume.MinTransferRate == 0 {
+			continue
+		}
+		path := volume.Path
+		if path == defaults.GravityDir {
+			path = stateDir
+		}
+		if !isSubdir(dir, path) {
+			continue
+		}
+		if result == nil || len(path) > len(resultPath) {
+			result = &volumes[i]
+			resultPath = path
+		}
+	}
+	return result
+}
+
+func isSubdir(dir, parent string) bool {
+	rel, err := filepath.Rel(filepath.Clean(parent), filepath.Clean(dir))
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, "../"))
+}
+
+// benchmarkDir measures fsync latency and sequential write throughput
+// of the specified target directory
+func benchmarkDir(ctx context.Context, target DiskIOTarget) (*DiskIOCheckerData, error) {
+	dir, err := existingParentDir(target.Path)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	latency, err := measureFsyncLatency(ctx, dir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	bps, err := measureThroughput(ctx, dir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &DiskIOCheckerData{
+		Name:              target.Name,
+		Path:              dir,
+		FsyncLatency:      latency,
+		MaxFsyncLatency:   target.MaxFsyncLatency,
+		BytesPerSecond:    bps,
+		MinBytesPerSecond: target.MinBytesPerSecond,
+	}, nil
+}
+
+// measureFsyncLatency performs a series of small synced writes in the
+// specified directory and returns the 99th percentile of the sync latency
+func measureFsyncLatency(ctx context.Context, dir string) (time.Duration, error) {
+	file, err := ioutil.TempFile(dir, "fsync")
+	if err != nil {
+		return 0, trace.ConvertSystemError(err)
+	}
+	defer removeFile(file)
+	buf := make([]byte, defaults.DiskFsyncBlockSize)
+	latencies := make([]time.Duration, 0, defaults.DiskFsyncSamples)
+	for i := 0; i < defaults.DiskFsyncSamples; i++ {
+		if ctx.Err() != nil {
+			return 0, trace.Wrap(ctx.Err())
+		}
+		if _, err := file.Write(buf); err != nil {
+			return 0, trace.ConvertSystemError(err)
+		}
+		start := time.Now()
+		if err := file.Sync(); err != nil {
+			return 0, trace.ConvertSystemError(err)
+		}
+		latencies = append(latencies, time.Since(start))
+	}
+	return percentile(latencies, 99), nil
+}
+
+// measureThroughput writes a file sequentially in the specified directory
+// and returns the write throughput in bytes per second
+func measureThroughput(ctx context.Context, dir string) (uint64, error) {
+	file, err := ioutil.TempFile(dir, "throughput")
+	if err != nil {
+		return 0, trace.ConvertSystemError(err)
+	}
+	defer removeFile(file)
+	buf := make([]byte, defaults.DiskThroughputBlockSize)
+	start := time.Now()
+	for i := 0; i < defaults.DiskThroughputBlocks; i++ {
+		if ctx.Err() != nil {
+			return 0, trace.Wrap(ctx.Err())
+		}
+		if _, err := file.Write(buf); err != nil {
+			return 0, trace.ConvertSystemError(err)
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return 0, trace.ConvertSystemError(err)
+	}
+	elapsed := time.Since(start).Seconds()
+	return uint64(float64(defaults.DiskThroughputBlockSize*defaults.DiskThroughputBlocks) / elapsed), nil
+}
+
+// percentile returns the p-th percentile of the specified samples
+func percentile(samples []time.Duration, p int) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	index := (len(sorted)*p+99)/100 - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
+
+// existingParentDir returns the closest existing directory for path
+func existingParentDir(path string) (string, error) {
+	dir := filepath.Clean(path)
+	for {
+		isDir, err := utils.IsDirectory(dir)
+		if err == nil && isDir {
+			return dir, nil
+		}
+		if err != nil && !trace.IsNotFound(err) {
+			return "", trace.Wrap(err)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", trace.NotFound("no existing parent directory for %v", path)
+		}
+		dir = parent
+	}
+}
+
+func removeFile(file *os.File) {
+	file.Close()
+	if err := os.Remove(file.Name()); err != nil {
+		log.WithError(err).Warnf("Failed to remove %v.", file.Name())
+	}
+}
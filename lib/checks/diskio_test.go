@@ -0,0 +1,145 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/satellite/agent/health"
+	"github.com/gravitational/satellite/agent/proto/agentpb"
+	. "gopkg.in/check.v1"
+)
+
+type DiskIOSuite struct{}
+
+var _ = Suite(&DiskIOSuite{})
+
+func (s *DiskIOSuite) TestTargetsUseVolumeThresholds(c *C) {
+	profile := schema.NodeProfile{
+		Requirements: schema.Requirements{
+			Volumes: []schema.Volume{
+				{
+					Path:            defaults.GravityDir,
+					MaxFsyncLatency: "20ms",
+				},
+				{
+					Path:            "/var/lib/data/planet/etcd",
+					MaxFsyncLatency: "5ms",
+				},
+				{
+					// No fsync latency threshold, the default is used
+					Path:            "/var/lib/data/planet/docker",
+					MinTransferRate: utils.MustParseTransferRate("50MB/s"),
+				},
+			},
+		},
+	}
+	targets, err := DiskIOTargets(profile, "/var/lib/data")
+	c.Assert(err, IsNil)
+	c.Assert(targets, DeepEquals, []DiskIOTarget{
+		{
+			Name:              "etcd",
+			Path:              "/var/lib/data/planet/etcd",
+			MaxFsyncLatency:   5 * time.Millisecond,
+			MinBytesPerSecond: defaultTransferRate.BytesPerSecond(),
+		},
+		{
+			Name:              "docker",
+			Path:              "/var/lib/data/planet/docker",
+			MaxFsyncLatency:   defaults.DiskFsyncLatency,
+			MinBytesPerSecond: 50 * 1000 * 1000,
+		},
+	})
+}
+
+func (s *DiskIOSuite) TestPicksVolumeByTranslatedPath(c *C) {
+	volumes := []schema.Volume{
+		{
+			// Translated to the shorter custom state directory
+			Path:            defaults.GravityDir,
+			MaxFsyncLatency: "20ms",
+		},
+		{
+			Path:            "/data/planet",
+			MaxFsyncLatency: "5ms",
+		},
+	}
+	volume := volumeForDir(volumes, "/data/planet/etcd", "/data")
+	c.Assert(volume, NotNil)
+	c.Assert(volume.Path, Equals, "/data/planet")
+}
+
+func (s *DiskIOSuite) TestReportsFailedThresholds(c *C) {
+	checker := &diskIOChecker{
+		targets: []DiskIOTarget{
+			{Name: "etcd", Path: "/etcd", MaxFsyncLatency: 10 * time.Millisecond},
+			{Name: "docker", Path: "/docker", MaxFsyncLatency: 30 * time.Millisecond},
+			{Name: "data", Path: "/data", MaxFsyncLatency: 10 * time.Millisecond, MinBytesPerSecond: 50 * 1000 * 1000},
+		},
+		benchmark: func(_ context.Context, target DiskIOTarget) (*DiskIOCheckerData, error) {
+			return &DiskIOCheckerData{
+				Name:              target.Name,
+				Path:              target.Path,
+				FsyncLatency:      20 * time.Millisecond,
+				MaxFsyncLatency:   target.MaxFsyncLatency,
+				BytesPerSecond:    20 * 1000 * 1000,
+				MinBytesPerSecond: target.MinBytesPerSecond,
+			}, nil
+		},
+	}
+	var probes health.Probes
+	checker.Check(context.TODO(), &probes)
+	c.Assert(probes, HasLen, 3)
+	c.Assert(probes[0].Status, Equals, agentpb.Probe_Failed)
+	c.Assert(probes[0].Detail, Equals,
+		"etcd directory /etcd: fsync latency 20ms is higher than maximum of 10ms")
+	c.Assert(probes[1].Status, Equals, agentpb.Probe_Running)
+	c.Assert(probes[1].Detail, Equals,
+		"docker directory /docker: fsync latency 20ms, write throughput 20MB/s")
+	c.Assert(probes[2].Status, Equals, agentpb.Probe_Failed)
+	c.Assert(probes[2].Detail, Equals,
+		"data directory /data: fsync latency 20ms is higher than maximum of 10ms, "+
+			"write throughput 20MB/s is lower than required 50MB/s")
+}
+
+func (s *DiskIOSuite) TestBenchmarksMissingDirectory(c *C) {
+	dir := c.MkDir()
+	data, err := benchmarkDir(context.TODO(), DiskIOTarget{
+		Name: "etcd",
+		Path: filepath.Join(dir, "planet", "etcd"),
+	})
+	c.Assert(err, IsNil)
+	c.Assert(data.Path, Equals, dir)
+	c.Assert(data.FsyncLatency > 0, Equals, true)
+	c.Assert(data.BytesPerSecond > 0, Equals, true)
+}
+
+func (s *DiskIOSuite) TestPercentile(c *C) {
+	var samples []time.Duration
+	for i := 100; i > 0; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	c.Assert(percentile(samples, 99), Equals, 99*time.Millisecond)
+	c.Assert(percentile(samples, 50), Equals, 50*time.Millisecond)
+	c.Assert(percentile(nil, 99), Equals, time.Duration(0))
+}
@@ -193,6 +193,10 @@ const (
 	// is in manual mode
 	ManualUpdateEnvVar = "MANUAL_UPDATE"
 
+	// DrainNodeEnvVar names the environment variable that specifies the name
+	// of the Kubernetes node for the node drain hooks
+	DrainNodeEnvVar = "GRAVITY_DRAIN_NODE"
+
 	// ServiceUserEnvVar names the environment variable that specifies the service user ID
 	ServiceUserEnvVar = "GRAVITY_SERVICE_USER"
 
@@ -328,6 +332,9 @@ const (
 	// EnvHome is home environment variable
 	EnvHome = "HOME"
 
+	// EnvDockerConfig is the environment variable with the docker client configuration directory
+	EnvDockerConfig = "DOCKER_CONFIG"
+
 	// EnvSudoUser is environment variable containing name of the user who invoked "sudo"
 	EnvSudoUser = "SUDO_USER"
 
@@ -570,6 +577,15 @@ const (
 	// ReportFilterKubernetes defines a report filter to fetch kubernetes diagnostics
 	ReportFilterKubernetes = "kubernetes"
 
+	// ReportFilterEtcd defines a report filter to fetch etcd diagnostics
+	ReportFilterEtcd = "etcd"
+
+	// ReportFilterPlanet defines a report filter to fetch planet diagnostics
+	ReportFilterPlanet = "planet"
+
+	// ReportFilterApp defines a report filter to fetch application diagnostics
+	ReportFilterApp = "app"
+
 	// RPCAgentUpgradeFunction requests deployed agents to run automatic upgrade operation on leader node
 	RPCAgentUpgradeFunction = "upgrade"
 
@@ -674,6 +690,8 @@ const (
 	HelmLabel = "helm"
 	// AppVersionLabel specifies version of an application in a Helm chart.
 	AppVersionLabel = "app-version"
+	// HelmRepositoryLabel specifies the helm repository an application was imported from.
+	HelmRepositoryLabel = "helm-repository"
 
 	// AnnotationKind contains image type, cluster or application.
 	AnnotationKind = "gravitational.io/kind"
@@ -692,6 +710,11 @@ const (
 	//
 	// Used in audit events.
 	ServiceStatusChecker = "@statuschecker"
+	// ServiceOperationScheduler is the name of the service that launches
+	// operations scheduled for the cluster maintenance window.
+	//
+	// Used in audit events.
+	ServiceOperationScheduler = "@scheduler"
 	// ServiceSystem is the identifier used as a "user" field for events
 	// that are triggered not by a human user but by a system process.
 	//
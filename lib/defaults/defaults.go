@@ -112,6 +112,23 @@ const (
 	// ResumeRetryInterval specifies the frequency of attempts to resume last operation
 	ResumeRetryInterval = 10 * time.Second
 
+	// CanaryCheckInterval specifies the frequency of canary node health checks during upgrade
+	CanaryCheckInterval = 10 * time.Second
+
+	// HealthGateTimeout is how long health gates are allowed to fail during upgrade
+	// before the operation is paused
+	HealthGateTimeout = 10 * time.Minute
+
+	// HealthGateInterval specifies the frequency of health gate checks during upgrade
+	HealthGateInterval = 10 * time.Second
+
+	// HealthGateRequestTimeout is the timeout of a single custom health check request
+	HealthGateRequestTimeout = 10 * time.Second
+
+	// RollbackHealthTimeout is how long to wait for the cluster to become
+	// healthy after an operation has been rolled back
+	RollbackHealthTimeout = 5 * time.Minute
+
 	// ResumeRetryAttempts specifies the total number of attempts to resume last operation
 	ResumeRetryAttempts = 20
 
@@ -147,6 +164,32 @@ const (
 	// HookJobDeadline sets the default limit on the hook job running time
 	HookJobDeadline = 20 * time.Minute
 
+	// HookRetryBackoff is the default delay before retrying a failed hook
+	HookRetryBackoff = 10 * time.Second
+
+	// HookAttemptOutputMaxBytes limits the hook attempt output persisted in
+	// the operation history, only the tail of a longer output is kept
+	HookAttemptOutputMaxBytes = 64 * 1024
+
+	// HostHookShell is the shell that runs host hook scripts
+	HostHookShell = "/bin/sh"
+
+	// GarbageCollectRetentionAge is the default age after which orphaned
+	// Kubernetes resources are removed by the garbage collector
+	GarbageCollectRetentionAge = 24 * time.Hour
+
+	// GarbageCollectHookJobs is the default number of completed jobs
+	// the garbage collector keeps for each application hook
+	GarbageCollectHookJobs = 3
+
+	// DumpHookTimeout is the default time budget for the application dump hook
+	// when collecting the cluster report
+	DumpHookTimeout = 5 * time.Minute
+
+	// MaxJournalFieldSize is the maximum size of a binary field value
+	// accepted when parsing journal exports in the cluster report
+	MaxJournalFieldSize = 64 * 1024 * 1024
+
 	// CertTTL is Teleport's SSH cert default TTL
 	CertTTL = 10 * time.Hour
 
@@ -390,6 +433,22 @@ const (
 	// ResourcesDir is the name of the directory where apps store their resources such as app manifest
 	ResourcesDir = "resources"
 
+	// GnuPGDir is the name of the GnuPG directory in the user's home
+	GnuPGDir = ".gnupg"
+
+	// SecretKeyringFile is the name of the GnuPG secret keyring file
+	SecretKeyringFile = "secring.gpg"
+
+	// DockerConfigDir is the name of the docker client configuration directory in the user's home
+	DockerConfigDir = ".docker"
+
+	// DockerConfigFile is the name of the docker client configuration file
+	DockerConfigFile = "config.json"
+
+	// ChartProvenanceFile is the name of the file in the application package
+	// with the provenance of the signed application chart
+	ChartProvenanceFile = "chart.prov"
+
 	// PlanetDir is the name of the planet directory
 	PlanetDir = "planet"
 
@@ -570,6 +629,28 @@ const (
 	// SiteStatusCheckInterval is how often local gravity site will invoke app status hook
 	SiteStatusCheckInterval = 1 * time.Minute
 
+	// OperationSchedulerInterval is how often local gravity site checks whether
+	// the maintenance window has opened for scheduled operations
+	OperationSchedulerInterval = 1 * time.Minute
+
+	// HelmRepositorySyncInterval is how often local gravity site checks
+	// whether configured helm repositories are due for sync
+	HelmRepositorySyncInterval = 1 * time.Minute
+
+	// HelmRepositoryTimeout is the timeout for downloading helm repository indexes and charts
+	HelmRepositoryTimeout = 5 * time.Minute
+
+	// HelmRepositoryMaxDownloadSize is the maximum size of a helm repository index or chart
+	HelmRepositoryMaxDownloadSize = 128 * 1024 * 1024
+
+	// RetentionInterval is how often local gravity site enforces
+	// the configured retention policies
+	RetentionInterval = 1 * time.Hour
+
+	// ScheduledOperationPollInterval is how often clients waiting for a scheduled
+	// operation to launch poll its state
+	ScheduledOperationPollInterval = 30 * time.Second
+
 	// OfflineCheckInterval is how often OpsCenter checks whether its sites are online/offline
 	OfflineCheckInterval = 10 * time.Second
 
@@ -771,6 +852,24 @@ const (
 	DiskCapacity = "5GB"
 	// DiskTransferRate is the minimum required disk speed for some default locations
 	DiskTransferRate = "10MB/s"
+	// DiskFsyncLatency is the maximum 99th percentile fsync latency
+	// allowed for the etcd and docker directories by default
+	DiskFsyncLatency = 10 * time.Millisecond
+	// DiskFsyncSamples is the number of synced writes performed
+	// to measure the fsync latency
+	DiskFsyncSamples = 200
+	// DiskFsyncBlockSize is the size of a single synced write.
+	// The value approximates the size of an etcd WAL entry
+	DiskFsyncBlockSize = 2300
+	// DiskThroughputBlockSize is the size of a single write in the
+	// sequential disk throughput test
+	DiskThroughputBlockSize = 1024 * 1024
+	// DiskThroughputBlocks is the number of writes in the sequential
+	// disk throughput test
+	DiskThroughputBlocks = 64
+	// DiskIOCheckTimeout specifies the maximum amount of time for the disk
+	// I/O benchmark on a single directory
+	DiskIOCheckTimeout = 30 * time.Second
 
 	// PingPongDuration is the duration of a ping-pong game agents play
 	PingPongDuration = 10 * time.Second
@@ -787,6 +886,9 @@ const (
 	// Runtime is the name of default runtime application
 	Runtime = "kubernetes"
 
+	// Architecture is the default CPU architecture of cluster nodes in GOARCH notation
+	Architecture = "amd64"
+
 	// UsedSecondFactorTokenTTL is the time we keep used second factor token
 	// to avoid reusing it on replay attacks
 	UsedSecondFactorTokenTTL = 30 * time.Second
@@ -91,33 +91,46 @@ func (p *Peer) ensureServiceUserAndBinary(ctx operationContext) error {
 
 // syncOperation synchronizes operation-related data to the local join backend
 func (p *Peer) syncOperation(ctx operationContext) error {
+	err := SyncOperation(ctx.Operator, p.JoinBackend, ctx.Cluster, ctx.Operation.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	p.Debug("Synchronized operation to the local backend.")
+	return nil
+}
+
+// SyncOperation synchronizes the specified expand operation along with its
+// cluster and plan to the provided local join backend.
+//
+// It is used by the nodes that join the cluster in the same operation
+// but do not execute its plan
+func SyncOperation(operator ops.Operator, backend storage.Backend, cluster ops.Site, key ops.SiteOperationKey) error {
 	// sync cluster
-	err := p.JoinBackend.DeleteSite(ctx.Cluster.Domain)
+	err := backend.DeleteSite(cluster.Domain)
 	if err != nil && !trace.IsNotFound(err) {
 		return trace.Wrap(err)
 	}
-	_, err = p.JoinBackend.CreateSite(ops.ConvertOpsSite(ctx.Cluster))
+	_, err = backend.CreateSite(ops.ConvertOpsSite(cluster))
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	// sync operation
-	operation, err := ctx.Operator.GetSiteOperation(ctx.Operation.Key())
+	operation, err := operator.GetSiteOperation(key)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	_, err = p.JoinBackend.CreateSiteOperation(storage.SiteOperation(*operation))
+	_, err = backend.CreateSiteOperation(storage.SiteOperation(*operation))
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	// sync operation plan
-	plan, err := ctx.Operator.GetOperationPlan(ctx.Operation.Key())
+	plan, err := operator.GetOperationPlan(key)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	_, err = p.JoinBackend.CreateOperationPlan(*plan)
+	_, err = backend.CreateOperationPlan(*plan)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	p.Debug("Synchronized operation to the local backend.")
 	return nil
 }
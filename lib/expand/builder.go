@@ -18,6 +18,7 @@ package expand
 
 import (
 	"fmt"
+	"path"
 
 	"github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/constants"
@@ -42,8 +43,11 @@ type planBuilder struct {
 	TeleportPackage loc.Locator
 	// PlanetPackage is the planet package to install
 	PlanetPackage loc.Locator
-	// JoiningNode is the node that's joining to the cluster
+	// JoiningNode is the node that's joining to the cluster.
+	// When several nodes are joining, it is the node executing the plan
 	JoiningNode storage.Server
+	// JoiningNodes is the list of all nodes joining in this operation
+	JoiningNodes storage.Servers
 	// ClusterNodes is the list of existing cluster nodes
 	ClusterNodes storage.Servers
 	// Peer is the IP:port of the cluster node this peer is joining to
@@ -58,6 +62,8 @@ type planBuilder struct {
 	ServiceUser storage.OSUser
 	// DNSConfig specifies the custom cluster DNS configuration
 	DNSConfig storage.DNSConfig
+	// ReplaceServer is the failed master node the joining node replaces
+	ReplaceServer *storage.Server
 }
 
 // AddConfigurePhase appends package configuration phase to the plan
@@ -167,7 +173,7 @@ func (b *planBuilder) AddStartAgentPhase(plan *storage.OperationPlan) {
 				OpsCenterURL: fmt.Sprintf("https://%v", b.Peer),
 			},
 		},
-		Requires: []string{SystemPhase},
+		Requires: fsm.RequireIfPresent(plan, SystemPhase),
 	})
 }
 
@@ -195,7 +201,37 @@ func (b *planBuilder) AddEtcdPhase(plan *storage.OperationPlan) {
 			ExecServer: &b.JoiningNode,
 			Master:     &b.Master,
 		},
-		Requires: fsm.RequireIfPresent(plan, SystemPhase, EtcdBackupPhase),
+		Requires: fsm.RequireIfPresent(plan, SystemPhase, EtcdBackupPhase, RemoveEtcdMemberPhase),
+	})
+}
+
+// AddRemoveEtcdMemberPhase appends phase that removes the etcd member
+// of the replaced master node
+func (b *planBuilder) AddRemoveEtcdMemberPhase(plan *storage.OperationPlan) {
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID: RemoveEtcdMemberPhase,
+		Description: fmt.Sprintf("Remove the failed node %v from the etcd cluster",
+			b.ReplaceServer.Hostname),
+		Data: &storage.OperationPhaseData{
+			Server:     b.ReplaceServer,
+			ExecServer: &b.JoiningNode,
+		},
+		Requires: fsm.RequireIfPresent(plan, SystemPhase),
+	})
+}
+
+// AddReplaceNodePhase appends phase that moves the labels and leadership
+// of the replaced master node to the joining node
+func (b *planBuilder) AddReplaceNodePhase(plan *storage.OperationPlan) {
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID: ReplaceNodePhase,
+		Description: fmt.Sprintf("Replace the failed node %v with the joining node",
+			b.ReplaceServer.Hostname),
+		Data: &storage.OperationPhaseData{
+			Server:     b.ReplaceServer,
+			ExecServer: &b.JoiningNode,
+		},
+		Requires: fsm.RequireIfPresent(plan, installphases.WaitPhase),
 	})
 }
 
@@ -237,7 +273,7 @@ func (b *planBuilder) AddStopAgentPhase(plan *storage.OperationPlan) {
 			ExecServer: &b.JoiningNode,
 			Server:     &b.Master,
 		},
-		Requires: []string{installphases.WaitPhase},
+		Requires: fsm.RequireIfPresent(plan, installphases.WaitPhase),
 	})
 }
 
@@ -272,6 +308,73 @@ func (b *planBuilder) AddElectPhase(plan *storage.OperationPlan) {
 	plan.Phases = append(plan.Phases, phase)
 }
 
+// AddNodesPhase appends the phase that joins multiple nodes to the plan.
+//
+// Each joining node gets a subphase with the same steps a single joining
+// node goes through. Masters are ordered first since their etcd members
+// are added one at a time
+func (b *planBuilder) AddNodesPhase(plan *storage.OperationPlan) {
+	var masters, nodes []storage.OperationPhase
+	for _, node := range b.JoiningNodes {
+		phase := b.nodePhase(node)
+		if node.IsMaster() {
+			masters = append(masters, phase)
+		} else {
+			nodes = append(nodes, phase)
+		}
+	}
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          NodesPhase,
+		Description: fmt.Sprintf("Join %v nodes to the cluster", len(b.JoiningNodes)),
+		Phases:      append(masters, nodes...),
+	})
+}
+
+// nodePhase returns the phase that joins the specified node
+func (b *planBuilder) nodePhase(node storage.Server) storage.OperationPhase {
+	builder := *b
+	builder.JoiningNode = node
+	nodePlan := &storage.OperationPlan{}
+	builder.AddBootstrapPhase(nodePlan)
+	builder.AddPullPhase(nodePlan)
+	if builder.Application.Manifest.HasHook(schema.HookNodeAdding) {
+		builder.AddPreHookPhase(nodePlan)
+	}
+	builder.AddSystemPhase(nodePlan)
+	if node.IsMaster() {
+		builder.AddEtcdPhase(nodePlan)
+	}
+	builder.AddWaitPhase(nodePlan)
+	if builder.Application.Manifest.HasHook(schema.HookNodeAdded) {
+		builder.AddPostHookPhase(nodePlan)
+	}
+	builder.AddElectPhase(nodePlan)
+	prefix := path.Join(NodesPhase, node.Hostname)
+	return storage.OperationPhase{
+		ID:          prefix,
+		Description: fmt.Sprintf("Join node %v", node.Hostname),
+		Phases:      prefixPhases(nodePlan, nodePlan.Phases, prefix),
+	}
+}
+
+// prefixPhases prepends the specified prefix to the IDs of the provided
+// phases and their requirements.
+// Requirements on phases outside of the plan are dropped
+func prefixPhases(plan *storage.OperationPlan, phases []storage.OperationPhase, prefix string) []storage.OperationPhase {
+	result := make([]storage.OperationPhase, 0, len(phases))
+	for _, phase := range phases {
+		phase.ID = prefix + phase.ID
+		var requires []string
+		for _, id := range fsm.RequireIfPresent(plan, phase.Requires...) {
+			requires = append(requires, prefix+id)
+		}
+		phase.Requires = requires
+		phase.Phases = prefixPhases(plan, phase.Phases, prefix)
+		result = append(result, phase)
+	}
+	return result
+}
+
 func (p *Peer) getPlanBuilder(ctx operationContext) (*planBuilder, error) {
 	application, err := ctx.Apps.GetApp(ctx.Cluster.App.Package)
 	if err != nil {
@@ -291,10 +394,6 @@ func (p *Peer) getPlanBuilder(ctx operationContext) (*planBuilder, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	planetPackage, err := application.Manifest.RuntimePackageForProfile(p.Role)
-	if err != nil {
-		return nil, trace.Wrap(err)
-	}
 	adminAgent, err := ctx.Operator.GetClusterAgent(ops.ClusterAgentRequest{
 		AccountID:   ctx.Operation.AccountID,
 		ClusterName: ctx.Operation.SiteDomain,
@@ -318,19 +417,41 @@ func (p *Peer) getPlanBuilder(ctx operationContext) (*planBuilder, error) {
 		return nil, trace.NotFound("operation does not have servers: %v",
 			operation)
 	}
+	joiningNode := operation.Servers[0]
+	if server := storage.Servers(operation.Servers).FindByIP(p.AdvertiseAddr); server != nil {
+		joiningNode = *server
+	}
+	planetPackage, err := application.Manifest.RuntimePackageForArch(p.Role, joiningNode.Arch)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// the replaced master is not considered a part of the cluster
+	var clusterNodes storage.Servers
+	replaceServer := operation.InstallExpand.ReplaceServer
+	for _, server := range ctx.Cluster.ClusterState.Servers {
+		if replaceServer == nil || server.Hostname != replaceServer.Hostname {
+			clusterNodes = append(clusterNodes, server)
+		}
+	}
+	masters := clusterNodes.Masters()
+	if len(masters) == 0 {
+		return nil, trace.NotFound("cluster does not have master nodes")
+	}
 	return &planBuilder{
 		Application:     *application,
 		Runtime:         *runtime,
 		TeleportPackage: *teleportPackage,
 		PlanetPackage:   *planetPackage,
-		JoiningNode:     operation.Servers[0],
-		ClusterNodes:    storage.Servers(ctx.Cluster.ClusterState.Servers),
+		JoiningNode:     joiningNode,
+		JoiningNodes:    storage.Servers(operation.Servers),
+		ClusterNodes:    clusterNodes,
 		Peer:            ctx.Peer,
-		Master:          storage.Servers(ctx.Cluster.ClusterState.Servers).Masters()[0],
+		Master:          masters[0],
 		AdminAgent:      *adminAgent,
 		RegularAgent:    *regularAgent,
 		ServiceUser:     ctx.Cluster.ServiceUser,
 		DNSConfig:       ctx.Cluster.DNSConfig,
+		ReplaceServer:   replaceServer,
 	}, nil
 }
 
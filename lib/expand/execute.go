@@ -0,0 +1,214 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expand
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/gravitational/gravity/lib/fsm"
+	installphases "github.com/gravitational/gravity/lib/install/phases"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// executePlan executes the operation plan.
+//
+// The nodes of the multi-node plan join concurrently, see executeNodes
+// for details
+func (p *Peer) executePlan(ctx context.Context, machine *fsm.FSM, opCtx operationContext) error {
+	plan, err := machine.GetPlan()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var nodesErr error
+	for _, phase := range plan.Phases {
+		if phase.ID == NodesPhase {
+			// nodes that failed to join do not prevent the rest of the
+			// plan from executing, see fsmEngine.Complete for how the
+			// operation is completed in this case
+			nodesErr = p.executeNodes(ctx, machine, opCtx, phase)
+			continue
+		}
+		err = machine.ExecutePhase(ctx, fsm.Params{
+			PhaseID:  phase.ID,
+			Progress: utils.NewNopProgress(),
+		})
+		if err != nil {
+			return trace.Wrap(err, "failed to execute phase %q", phase.ID)
+		}
+	}
+	return trace.Wrap(nodesErr)
+}
+
+// executeNodes joins the nodes of the specified nodes phase concurrently.
+//
+// Masters are added to the etcd cluster one at a time in the order of the
+// plan: the next member is only added after the previous master has come up.
+// A node that fails to join does not prevent other nodes from joining
+func (p *Peer) executeNodes(ctx context.Context, machine *fsm.FSM, opCtx operationContext, phase storage.OperationPhase) error {
+	etcd := newEtcdSequence(phase)
+	errorsCh := make(chan error, len(phase.Phases))
+	for _, nodePhase := range phase.Phases {
+		go func(nodePhase storage.OperationPhase) {
+			node := path.Base(nodePhase.ID)
+			err := executeNode(ctx, machine, nodePhase, etcd)
+			if err != nil {
+				p.Warnf("Node %v failed to join: %v.", node, trace.DebugReport(err))
+				err = trace.Wrap(err, "node %v failed to join", node)
+			}
+			p.reportNode(opCtx, node, err)
+			errorsCh <- err
+		}(nodePhase)
+	}
+	return utils.CollectErrors(ctx, errorsCh)
+}
+
+// executeNode executes the phases of the specified node phase in order
+func executeNode(ctx context.Context, machine *fsm.FSM, nodePhase storage.OperationPhase, etcd *etcdSequence) (err error) {
+	var turn bool
+	defer func() {
+		if turn {
+			etcd.release(err)
+		} else if err != nil {
+			etcd.skip(nodePhase.ID)
+		}
+	}()
+	for _, phase := range nodePhase.Phases {
+		id := nodePhaseID(phase.ID)
+		if id == EtcdPhase {
+			if err := etcd.acquire(nodePhase.ID); err != nil {
+				return trace.Wrap(err)
+			}
+			turn = true
+		}
+		err := machine.ExecutePhase(ctx, fsm.Params{
+			PhaseID:  phase.ID,
+			Progress: utils.NewNopProgress(),
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if turn && id == installphases.WaitPhase {
+			etcd.release(nil)
+			turn = false
+		}
+	}
+	return nil
+}
+
+// reportNode reports the outcome of joining the specified node
+func (p *Peer) reportNode(opCtx operationContext, node string, err error) {
+	message := fmt.Sprintf("%v: joined the cluster", node)
+	if err != nil {
+		message = fmt.Sprintf("%v: failed to join the cluster: %v", node, trace.UserMessage(err))
+	}
+	entry := ops.ProgressEntry{
+		SiteDomain:  opCtx.Operation.SiteDomain,
+		OperationID: opCtx.Operation.ID,
+		State:       ops.ProgressStateInProgress,
+		Message:     message,
+		Created:     time.Now().UTC(),
+	}
+	// keep the overall progress where it was
+	last, err := opCtx.Operator.GetSiteOperationProgress(opCtx.Operation.Key())
+	if err == nil {
+		entry.Completion = last.Completion
+		entry.Step = last.Step
+	}
+	if err := opCtx.Operator.CreateProgressEntry(opCtx.Operation.Key(), entry); err != nil {
+		p.Warnf("Failed to create progress entry %v: %v.", entry, trace.DebugReport(err))
+	}
+}
+
+// etcdSequence adds the etcd members of joining masters one at a time
+// in the order of the plan.
+//
+// The etcd configuration of each joining master expects the masters
+// before it in the plan to already be members of the etcd cluster
+type etcdSequence struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	// order lists the node phases of joining masters in the plan order
+	order []string
+	// next is the index of the master to add next
+	next int
+	// failed is set if a master failed to join. No more members are added
+	// after that since the following masters expect it in the etcd cluster
+	// and the etcd cluster may have lost its quorum
+	failed bool
+}
+
+// newEtcdSequence returns the etcd sequence for the masters of the specified
+// nodes phase
+func newEtcdSequence(phase storage.OperationPhase) *etcdSequence {
+	r := &etcdSequence{}
+	r.cond = sync.NewCond(&r.mu)
+	for _, nodePhase := range phase.Phases {
+		for _, subphase := range nodePhase.Phases {
+			if nodePhaseID(subphase.ID) == EtcdPhase {
+				r.order = append(r.order, nodePhase.ID)
+			}
+		}
+	}
+	return r
+}
+
+// acquire blocks until it is the turn of the master with the specified node phase
+func (r *etcdSequence) acquire(nodePhaseID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for !r.failed && r.next < len(r.order) && r.order[r.next] != nodePhaseID {
+		r.cond.Wait()
+	}
+	if r.failed {
+		return trace.CompareFailed("not adding etcd member since another " +
+			"joining master failed to join the cluster")
+	}
+	if r.next == len(r.order) {
+		return trace.NotFound("node phase %v does not add etcd member", nodePhaseID)
+	}
+	return nil
+}
+
+// release passes the turn to the next master
+func (r *etcdSequence) release(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.failed = true
+	}
+	r.next++
+	r.cond.Broadcast()
+}
+
+// skip is called when the node with the specified phase failed before
+// its turn. If it is a master, the following masters are not added
+func (r *etcdSequence) skip(nodePhaseID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if utils.StringInSlice(r.order[r.next:], nodePhaseID) {
+		r.failed = true
+		r.cond.Broadcast()
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expand
+
+import (
+	"sync"
+
+	installphases "github.com/gravitational/gravity/lib/install/phases"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	check "gopkg.in/check.v1"
+)
+
+type ExecuteSuite struct{}
+
+var _ = check.Suite(&ExecuteSuite{})
+
+func (s *ExecuteSuite) TestAddsEtcdMembersInPlanOrder(c *check.C) {
+	etcd := newEtcdSequence(nodesPhase(
+		nodePhase("master-1", true, ""),
+		nodePhase("node-1", false, ""),
+		nodePhase("master-2", true, ""),
+		nodePhase("master-3", true, ""),
+	))
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	for _, node := range []string{"master-3", "master-2", "master-1"} {
+		wg.Add(1)
+		go func(node string) {
+			defer wg.Done()
+			c.Assert(etcd.acquire(NodesPhase+"/"+node), check.IsNil)
+			mu.Lock()
+			order = append(order, node)
+			mu.Unlock()
+			etcd.release(nil)
+		}(node)
+	}
+	wg.Wait()
+	c.Assert(order, check.DeepEquals, []string{"master-1", "master-2", "master-3"})
+}
+
+func (s *ExecuteSuite) TestStopsAddingEtcdMembersAfterFailure(c *check.C) {
+	etcd := newEtcdSequence(nodesPhase(
+		nodePhase("master-1", true, ""),
+		nodePhase("master-2", true, ""),
+	))
+	// master-1 failed before its etcd member has been added
+	etcd.skip(NodesPhase + "/master-1")
+	err := etcd.acquire(NodesPhase + "/master-2")
+	c.Assert(trace.IsCompareFailed(err), check.Equals, true)
+}
+
+func (s *ExecuteSuite) TestFindsFailedNodes(c *check.C) {
+	completed := storage.OperationPhaseStateCompleted
+	failed := storage.OperationPhaseStateFailed
+	testCases := []struct {
+		comment string
+		nodes   storage.OperationPhase
+		failed  []string
+	}{
+		{
+			comment: "regular node failed",
+			nodes: nodesPhase(
+				nodePhase("master-1", true, completed),
+				nodePhase("node-1", false, failed),
+			),
+			failed: []string{"node-1"},
+		},
+		{
+			comment: "master failed before being added to etcd",
+			nodes: nodesPhase(
+				nodePhase("node-1", false, completed),
+				nodePhase("master-1", true, failed),
+			),
+			failed: []string{"master-1"},
+		},
+		{
+			comment: "master failed after being added to etcd",
+			nodes: nodesPhase(
+				nodePhase("node-1", false, completed),
+				withEtcdState(nodePhase("master-1", true, failed), failed),
+			),
+		},
+		{
+			comment: "no node joined",
+			nodes: nodesPhase(
+				nodePhase("node-1", false, failed),
+			),
+		},
+	}
+	for _, tc := range testCases {
+		plan := &storage.OperationPlan{Phases: []storage.OperationPhase{
+			{ID: installphases.ConfigurePhase, State: completed},
+			tc.nodes,
+		}}
+		c.Assert(failedNodes(plan), check.DeepEquals, tc.failed, check.Commentf(tc.comment))
+	}
+}
+
+func (s *ExecuteSuite) TestComparesAddressesNumerically(c *check.C) {
+	c.Assert(lessAddr("10.0.0.9", "10.0.0.10"), check.Equals, true)
+	c.Assert(lessAddr("10.0.0.10", "10.0.0.9"), check.Equals, false)
+	c.Assert(lessAddr("192.168.1.1", "10.0.0.1"), check.Equals, false)
+}
+
+func nodesPhase(phases ...storage.OperationPhase) storage.OperationPhase {
+	return storage.OperationPhase{ID: NodesPhase, Phases: phases}
+}
+
+// nodePhase returns the node phase of the multi-node plan. The state is
+// applied to the first subphase of the node, the other subphases are unstarted
+func nodePhase(node string, master bool, state string) storage.OperationPhase {
+	prefix := NodesPhase + "/" + node
+	ids := []string{installphases.BootstrapPhase, installphases.WaitPhase}
+	if master {
+		ids = []string{installphases.BootstrapPhase, EtcdPhase, installphases.WaitPhase}
+	}
+	phase := storage.OperationPhase{ID: prefix}
+	for _, id := range ids {
+		phase.Phases = append(phase.Phases, storage.OperationPhase{ID: prefix + id})
+	}
+	phase.Phases[0].State = state
+	if state == storage.OperationPhaseStateCompleted {
+		for i := range phase.Phases {
+			phase.Phases[i].State = state
+		}
+	}
+	return phase
+}
+
+func withEtcdState(phase storage.OperationPhase, state string) storage.OperationPhase {
+	for i, subphase := range phase.Phases {
+		if nodePhaseID(subphase.ID) == EtcdPhase {
+			phase.Phases[i].State = state
+		}
+	}
+	return phase
+}
@@ -19,6 +19,8 @@ package expand
 import (
 	"context"
 	"fmt"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/gravitational/gravity/lib/app"
@@ -185,7 +187,8 @@ func (e *fsmEngine) GetPlan() (*storage.OperationPlan, error) {
 // RunCommand executes the phase specified by params on the specified
 // server using the provided runner
 func (e *fsmEngine) RunCommand(ctx context.Context, runner fsm.RemoteRunner, node storage.Server, p fsm.Params) error {
-	args := []string{"join", "--phase", p.PhaseID, fmt.Sprintf("--force=%v", p.Force)}
+	args := []string{"join", "--phase", p.PhaseID, fmt.Sprintf("--force=%v", p.Force),
+		"--operation-id", e.OperationKey.OperationID}
 	if e.DebugMode {
 		args = append([]string{"--debug"}, args...)
 	}
@@ -203,6 +206,23 @@ func (e *fsmEngine) Complete(fsmErr error) error {
 	}
 	if fsm.IsCompleted(plan) {
 		err = ops.CompleteOperation(e.OperationKey, e.Operator)
+	} else if failed := failedNodes(plan); len(failed) != 0 {
+		// the nodes that have joined stay in the cluster while the failed
+		// nodes are removed from the cluster state
+		err = e.Operator.SetOperationState(e.OperationKey, ops.SetOperationStateRequest{
+			State:         ops.OperationStateCompleted,
+			FailedServers: failed,
+			Progress: &ops.ProgressEntry{
+				SiteDomain:  e.OperationKey.SiteDomain,
+				OperationID: e.OperationKey.OperationID,
+				Step:        constants.FinalStep,
+				Completion:  constants.Completed,
+				State:       ops.ProgressStateCompleted,
+				Message: fmt.Sprintf("Operation has completed, nodes %v failed to join the cluster",
+					strings.Join(failed, ", ")),
+				Created: time.Now().UTC(),
+			},
+		})
 	} else {
 		var message string
 		if fsmErr != nil {
@@ -220,6 +240,40 @@ func (e *fsmEngine) Complete(fsmErr error) error {
 	return nil
 }
 
+// failedNodes returns the nodes of the multi-node plan that failed to join
+// provided that the rest of the plan has completed.
+//
+// Returns nil if no node has joined, or if any of the failed nodes is
+// a master that might have been added to the etcd cluster, since such
+// a node has to be removed from the cluster explicitly
+func failedNodes(plan *storage.OperationPlan) (failed []string) {
+	var joined int
+	for _, phase := range plan.Phases {
+		if phase.ID != NodesPhase {
+			if !phase.IsCompleted() {
+				return nil
+			}
+			continue
+		}
+		for _, nodePhase := range phase.Phases {
+			if nodePhase.IsCompleted() {
+				joined++
+				continue
+			}
+			for _, subphase := range nodePhase.Phases {
+				if nodePhaseID(subphase.ID) == EtcdPhase && !subphase.IsUnstarted() {
+					return nil
+				}
+			}
+			failed = append(failed, path.Base(nodePhase.ID))
+		}
+	}
+	if joined == 0 {
+		return nil
+	}
+	return failed
+}
+
 // UpdateProgress reports operation progress to the cluster's operator
 func (e *fsmEngine) UpdateProgress(ctx context.Context, p fsm.Params) error {
 	plan, err := e.GetPlan()
@@ -230,13 +284,17 @@ func (e *fsmEngine) UpdateProgress(ctx context.Context, p fsm.Params) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	message := phase.Description
+	if node, _ := splitNodePhaseID(phase.ID); node != "" {
+		message = fmt.Sprintf("%v: %v", node, phase.Description)
+	}
 	entry := ops.ProgressEntry{
 		SiteDomain:  e.OperationKey.SiteDomain,
 		OperationID: e.OperationKey.OperationID,
 		Completion:  100 / len(fsm.FlattenPlan(plan)) * phase.Step,
 		Step:        phase.Step,
 		State:       ops.ProgressStateInProgress,
-		Message:     phase.Description,
+		Message:     message,
 		Created:     time.Now().UTC(),
 	}
 	err = e.Operator.CreateProgressEntry(e.OperationKey, entry)
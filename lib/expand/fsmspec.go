@@ -30,19 +30,20 @@ import (
 // FSMSpec returns a function that returns an appropriate phase executor
 func FSMSpec(config FSMConfig) fsm.FSMSpecFunc {
 	return func(p fsm.ExecutorParams, remote fsm.Remote) (fsm.PhaseExecutor, error) {
+		id := nodePhaseID(p.Phase.ID)
 		switch {
-		case strings.HasPrefix(p.Phase.ID, installphases.ConfigurePhase):
+		case strings.HasPrefix(id, installphases.ConfigurePhase):
 			return installphases.NewConfigure(p,
 				config.Operator)
 
-		case strings.HasPrefix(p.Phase.ID, installphases.BootstrapPhase):
+		case strings.HasPrefix(id, installphases.BootstrapPhase):
 			return installphases.NewBootstrap(p,
 				config.Operator,
 				config.Apps,
 				config.LocalBackend,
 				remote)
 
-		case strings.HasPrefix(p.Phase.ID, installphases.PullPhase):
+		case strings.HasPrefix(id, installphases.PullPhase):
 			return installphases.NewPull(p,
 				config.Operator,
 				config.Packages,
@@ -51,51 +52,61 @@ func FSMSpec(config FSMConfig) fsm.FSMSpecFunc {
 				config.LocalApps,
 				remote)
 
-		case strings.HasPrefix(p.Phase.ID, PreHookPhase):
+		case strings.HasPrefix(id, PreHookPhase):
 			return installphases.NewHook(p,
 				config.Operator,
 				config.Apps,
+				config.Runner,
 				schema.HookNodeAdding)
 
-		case strings.HasPrefix(p.Phase.ID, StartAgentPhase):
+		case strings.HasPrefix(id, StartAgentPhase):
 			return phases.NewAgentStart(p,
 				config.Operator)
 
-		case strings.HasPrefix(p.Phase.ID, StopAgentPhase):
+		case strings.HasPrefix(id, StopAgentPhase):
 			return phases.NewAgentStop(p,
 				config.Operator,
 				config.Packages)
 
-		case strings.HasPrefix(p.Phase.ID, EtcdBackupPhase):
+		case strings.HasPrefix(id, EtcdBackupPhase):
 			return phases.NewEtcdBackup(p,
 				config.Operator,
 				config.Runner)
 
-		case strings.HasPrefix(p.Phase.ID, EtcdPhase):
+		case strings.HasPrefix(id, RemoveEtcdMemberPhase):
+			return phases.NewRemoveEtcdMember(p,
+				config.Operator)
+
+		case strings.HasPrefix(id, ReplaceNodePhase):
+			return phases.NewReplaceNode(p,
+				config.Operator)
+
+		case strings.HasPrefix(id, EtcdPhase):
 			return phases.NewEtcd(p,
 				config.Operator,
 				config.Runner)
 
-		case strings.HasPrefix(p.Phase.ID, SystemPhase):
+		case strings.HasPrefix(id, SystemPhase):
 			return installphases.NewSystem(p,
 				config.Operator,
 				remote)
 
-		case strings.HasPrefix(p.Phase.ID, WaitPlanetPhase):
+		case strings.HasPrefix(id, WaitPlanetPhase):
 			return phases.NewWaitPlanet(p,
 				config.Operator)
 
-		case strings.HasPrefix(p.Phase.ID, WaitK8sPhase):
+		case strings.HasPrefix(id, WaitK8sPhase):
 			return phases.NewWaitK8s(p,
 				config.Operator)
 
-		case strings.HasPrefix(p.Phase.ID, PostHookPhase):
+		case strings.HasPrefix(id, PostHookPhase):
 			return installphases.NewHook(p,
 				config.Operator,
 				config.Apps,
+				config.Runner,
 				schema.HookNodeAdded)
 
-		case strings.HasPrefix(p.Phase.ID, ElectPhase):
+		case strings.HasPrefix(id, ElectPhase):
 			return phases.NewElect(p,
 				config.Operator)
 
@@ -105,6 +116,29 @@ func FSMSpec(config FSMConfig) fsm.FSMSpecFunc {
 	}
 }
 
+// nodePhaseID returns the ID of the specified phase of the multi-node plan
+// relative to its node phase, e.g. /nodes/node-1/system/planet becomes
+// /system/planet.
+// IDs of phases outside of the node phases are returned unchanged
+func nodePhaseID(phaseID string) string {
+	_, id := splitNodePhaseID(phaseID)
+	return id
+}
+
+// splitNodePhaseID splits the specified phase ID of the multi-node plan
+// into the name of the node and the phase ID relative to the node phase.
+// The node name is empty for phases outside of the node phases
+func splitNodePhaseID(phaseID string) (node, id string) {
+	if !strings.HasPrefix(phaseID, NodesPhase+"/") {
+		return "", phaseID
+	}
+	parts := strings.SplitN(strings.TrimPrefix(phaseID, NodesPhase+"/"), "/", 2)
+	if len(parts) != 2 {
+		return "", phaseID
+	}
+	return parts[0], "/" + parts[1]
+}
+
 const (
 	// PreHookPhase runs pre-expand application hook
 	PreHookPhase = "/preHook"
@@ -126,4 +160,11 @@ const (
 	StartAgentPhase = "/startAgent"
 	// StopAgentPhase stops RPC agent
 	StopAgentPhase = "/stopAgent"
+	// NodesPhase joins multiple nodes, one subphase per node
+	NodesPhase = "/nodes"
+	// RemoveEtcdMemberPhase removes the etcd member of the replaced master node
+	RemoveEtcdMemberPhase = "/removeEtcdMember"
+	// ReplaceNodePhase moves labels and leadership of the replaced master node
+	// to the joining node
+	ReplaceNodePhase = "/replaceNode"
 )
@@ -17,6 +17,7 @@ limitations under the License.
 package expand
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net"
@@ -93,7 +94,15 @@ type PeerConfig struct {
 	// Manual turns on manual plan execution
 	Manual bool
 	// OperationID is the ID of existing join operation created via UI
+	// or by another node joining in the same operation
 	OperationID string
+	// IgnoreMaintenanceWindow starts the join operation outside of the maintenance window
+	IgnoreMaintenanceWindow bool
+	// Nodes is the number of nodes joining in the operation created by this peer
+	Nodes int
+	// ReplaceServer is the hostname or IP address of the failed master node
+	// replaced by this peer
+	ReplaceServer string
 }
 
 // CheckAndSetDefaults checks the parameters and autodetects some defaults
@@ -194,6 +203,13 @@ func (p *Peer) dialSite(addr string) (*operationContext, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	var replaceServer *storage.Server
+	if p.ReplaceServer != "" {
+		replaceServer, err = p.setReplaceServerProfile(*cluster)
+		if err != nil {
+			return nil, utils.Abort(err)
+		}
+	}
 	err = p.checkAndSetServerProfile(cluster.App)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -208,7 +224,7 @@ func (p *Peer) dialSite(addr string) (*operationContext, error) {
 	}
 	var operation *ops.SiteOperation
 	if p.OperationID == "" {
-		operation, err = p.createExpandOperation(operator, *cluster)
+		operation, err = p.createExpandOperation(operator, *cluster, replaceServer)
 	} else {
 		operation, err = p.getExpandOperation(operator, *cluster)
 	}
@@ -234,27 +250,50 @@ func (p *Peer) dialSite(addr string) (*operationContext, error) {
 	}, nil
 }
 
-// createExpandOperation creates a new expand operation
-func (p *Peer) createExpandOperation(operator ops.Operator, cluster ops.Site) (*ops.SiteOperation, error) {
-	key, err := operator.CreateSiteExpandOperation(p.Context, ops.CreateSiteExpandOperationRequest{
-		AccountID:   cluster.AccountID,
-		SiteDomain:  cluster.Domain,
-		Provisioner: schema.ProvisionerOnPrem,
-		Servers:     map[string]int{p.Role: 1},
-	})
+// createExpandOperation creates a new expand operation.
+// If replaceServer is set, the operation replaces the specified failed master
+func (p *Peer) createExpandOperation(operator ops.Operator, cluster ops.Site, replaceServer *storage.Server) (*ops.SiteOperation, error) {
+	req := ops.CreateSiteExpandOperationRequest{
+		AccountID:               cluster.AccountID,
+		SiteDomain:              cluster.Domain,
+		Provisioner:             schema.ProvisionerOnPrem,
+		Servers:                 map[string]int{p.Role: utils.Max(p.Nodes, 1)},
+		IgnoreMaintenanceWindow: p.IgnoreMaintenanceWindow,
+	}
+	if replaceServer != nil {
+		req.ReplaceServer = replaceServer.Hostname
+	}
+	key, err := operator.CreateSiteExpandOperation(p.Context, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	operation, err := operator.GetSiteOperation(*key)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if operation.IsScheduled() && operation.Schedule != nil {
+		p.Silent.Printf("Maintenance window is closed, joining will start after %v.\n",
+			operation.Schedule.StartAfter.Format(constants.HumanDateFormat))
+		_, err = ops.WaitForScheduledOperation(p.Context, operator, *key)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
 	err = operator.SetOperationState(*key, ops.SetOperationStateRequest{
 		State: ops.OperationStateReady,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	operation, err := operator.GetSiteOperation(*key)
+	operation, err = operator.GetSiteOperation(*key)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if p.Nodes > 1 {
+		p.Silent.Printf("Waiting for %v more node(-s) to join, run the following command on each of them:\n"+
+			"gravity join %v --token=<token> --role=%v --operation-id=%v\n",
+			p.Nodes-1, p.Peers[0], p.Role, operation.ID)
+	}
 	return operation, nil
 }
 
@@ -329,6 +368,36 @@ func (p *Peer) checkAndSetServerProfile(app ops.Application) error {
 		"specified node role %q is not defined in the application manifest", p.Role))
 }
 
+// setReplaceServerProfile looks up the replaced master node in the cluster
+// and makes the peer join with its profile
+func (p *Peer) setReplaceServerProfile(cluster ops.Site) (*storage.Server, error) {
+	server, err := FindReplaceServer(cluster.ClusterState.Servers, p.ReplaceServer)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if p.Role != "" && p.Role != server.Role {
+		return nil, trace.BadParameter("node replacing %v should have role %q, got %q",
+			server.Hostname, server.Role, p.Role)
+	}
+	p.Role = server.Role
+	return server, nil
+}
+
+// FindReplaceServer returns the master server matching the provided
+// hostname or advertise address
+func FindReplaceServer(servers []storage.Server, node string) (*storage.Server, error) {
+	for _, server := range servers {
+		switch node {
+		case server.Hostname, server.AdvertiseIP, server.Nodename:
+			if !server.IsMaster() {
+				return nil, trace.BadParameter("node %v is not a master", node)
+			}
+			return &server, nil
+		}
+	}
+	return nil, trace.NotFound("node %v is not a cluster node", node)
+}
+
 // runLocalChecks makes sure node satisfies system requirements
 func (p *Peer) runLocalChecks(cluster ops.Site, installOperation ops.SiteOperation) error {
 	return checks.RunLocalChecks(checks.LocalChecksRequest{
@@ -606,7 +675,9 @@ func (p *Peer) waitForOperation(ctx operationContext) error {
 	}
 }
 
-func (p *Peer) waitForAgents(ctx operationContext) error {
+// waitForAgents blocks until agents of all nodes joining in the operation
+// have connected and returns true if this peer should execute the plan
+func (p *Peer) waitForAgents(ctx operationContext) (leader bool, err error) {
 	ticker := backoff.NewTicker(&backoff.ExponentialBackOff{
 		InitialInterval: time.Second,
 		Multiplier:      1.5,
@@ -620,10 +691,10 @@ func (p *Peer) waitForAgents(ctx operationContext) error {
 	for {
 		select {
 		case <-p.Context.Done():
-			return trace.Wrap(p.Context.Err())
+			return false, trace.Wrap(p.Context.Err())
 		case tm := <-ticker.C:
 			if tm.IsZero() {
-				return trace.ConnectionProblem(nil, "timed out waiting for agents to join")
+				return false, trace.ConnectionProblem(nil, "timed out waiting for agents to join")
 			}
 			report, err := ctx.Operator.GetSiteExpandOperationAgentReport(ctx.Operation.Key())
 			if err != nil {
@@ -639,6 +710,14 @@ func (p *Peer) waitForAgents(ctx operationContext) error {
 				log.Warningf("%v", err)
 				continue
 			}
+			if expected := op.InstallExpand.ServerCount(); len(report.Servers) < expected {
+				log.Debugf("%v out of %v agents have joined.", len(report.Servers), expected)
+				continue
+			}
+			if !p.isLeader(report.Servers) {
+				log.Info("Another joining node will start the operation.")
+				return false, nil
+			}
 			req, err := install.GetServers(*op, report.Servers)
 			if err != nil {
 				log.Warningf("%v", err)
@@ -646,14 +725,37 @@ func (p *Peer) waitForAgents(ctx operationContext) error {
 			}
 			err = ctx.Operator.UpdateExpandOperationState(ctx.Operation.Key(), *req)
 			if err != nil {
-				return trace.Wrap(err)
+				return false, trace.Wrap(err)
 			}
 			log.Infof("Installation can proceed! %v", report)
-			return nil
+			return true, nil
 		}
 	}
 }
 
+// isLeader returns true if this peer should execute the operation plan.
+// When several nodes join at once, the plan is executed by the node with
+// the smallest advertise address
+func (p *Peer) isLeader(servers []checks.ServerInfo) bool {
+	for _, server := range servers {
+		addr, _ := utils.SplitHostPort(server.AdvertiseAddr, "")
+		if lessAddr(addr, p.AdvertiseAddr) {
+			return false
+		}
+	}
+	return true
+}
+
+// lessAddr returns true if the address a is smaller than the address b.
+// IP addresses are compared numerically
+func lessAddr(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return a < b
+	}
+	return bytes.Compare(ipA.To16(), ipB.To16()) < 0
+}
+
 func (p *Peer) send(e install.Event) {
 	select {
 	case p.EventsC <- e:
@@ -734,10 +836,14 @@ func (p *Peer) startExpandOperation(ctx operationContext) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	err = p.waitForAgents(ctx)
+	leader, err := p.waitForAgents(ctx)
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if !leader {
+		p.sendMessage("Waiting for another joining node to execute the operation")
+		return nil
+	}
 	err = p.initOperationPlan(ctx)
 	if err != nil {
 		return trace.Wrap(err)
@@ -761,10 +867,10 @@ After all phases have completed successfully, complete the operation using "grav
 		return trace.Wrap(err)
 	}
 	go func() {
-		fsmErr := fsm.ExecutePlan(p.Context, utils.NewNopProgress(), false)
-		if err != nil {
+		fsmErr := p.executePlan(p.Context, fsm, ctx)
+		if fsmErr != nil {
 			p.Errorf("Failed to execute plan: %v.",
-				trace.DebugReport(err))
+				trace.DebugReport(fsmErr))
 		}
 		err := fsm.Complete(fsmErr)
 		if err != nil {
@@ -28,8 +28,6 @@ import (
 	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/ops"
 	rpcclient "github.com/gravitational/gravity/lib/rpc/client"
-	"github.com/gravitational/gravity/lib/schema"
-	"github.com/gravitational/gravity/lib/state"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/utils"
 
@@ -41,25 +39,11 @@ import (
 // NewEtcd returns executor that adds a new etcd member to the cluster
 func NewEtcd(p fsm.ExecutorParams, operator ops.Operator, runner fsm.AgentRepository) (*etcdExecutor, error) {
 	// create etcd client that's talking to members running on master nodes
-	var masters []storage.Server
-	for _, node := range p.Plan.Servers {
-		if node.ClusterRole == string(schema.ServiceRoleMaster) {
-			masters = append(masters, node)
-		}
-	}
-	var endpoints []string
-	for _, master := range masters {
-		endpoints = append(endpoints, fmt.Sprintf("https://%v:%v",
-			master.AdvertiseIP, defaults.EtcdAPIPort))
-	}
-	stateDir, err := state.GetStateDir()
+	config, err := etcdConfig(masterEndpoints(p.Plan.Servers))
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	etcdClient, err := clients.EtcdMembers(&clients.EtcdConfig{
-		Endpoints:  endpoints,
-		SecretsDir: state.SecretDir(stateDir),
-	})
+	etcdClient, err := clients.EtcdMembers(config)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -0,0 +1,398 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/clients"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/httplib"
+	kubeutils "github.com/gravitational/gravity/lib/kubernetes"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/state"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	etcd "github.com/coreos/etcd/client"
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NewRemoveEtcdMember returns executor that removes the etcd member
+// of the replaced master node
+func NewRemoveEtcdMember(p fsm.ExecutorParams, operator ops.Operator) (*removeEtcdMemberExecutor, error) {
+	config, err := etcdConfig(masterEndpoints(p.Plan.Servers))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	etcdClient, err := clients.EtcdMembers(config)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	logger := &fsm.Logger{
+		FieldLogger: logrus.WithFields(logrus.Fields{
+			constants.FieldPhase: p.Phase.ID,
+		}),
+		Key:      opKey(p.Plan),
+		Operator: operator,
+		Server:   p.Phase.Data.ExecServer,
+	}
+	return &removeEtcdMemberExecutor{
+		FieldLogger:    logger,
+		Etcd:           etcdClient,
+		Config:         *config,
+		ExecutorParams: p,
+	}, nil
+}
+
+type removeEtcdMemberExecutor struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// Etcd is client to the cluster's etcd members API
+	Etcd etcd.MembersAPI
+	// Config is the etcd client configuration
+	Config clients.EtcdConfig
+	// ExecutorParams is common executor params
+	fsm.ExecutorParams
+}
+
+// Execute removes the etcd member of the replaced node making sure
+// the etcd cluster has quorum before and after the removal
+func (p *removeEtcdMemberExecutor) Execute(ctx context.Context) error {
+	server := p.Phase.Data.Server
+	p.Progress.NextStep("Verifying etcd quorum")
+	members, err := p.Etcd.List(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = checkEtcdQuorum(ctx, p.Config, members)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	member, err := findEtcdMember(members, server.AdvertiseIP)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if member == nil {
+		p.Infof("Etcd member of %v has already been removed.", server.AdvertiseIP)
+		return nil
+	}
+	p.Progress.NextStep("Removing etcd member of %v", server.Hostname)
+	err = p.Etcd.Remove(ctx, member.ID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	p.Infof("Removed etcd member: %v.", member)
+	members, err = p.Etcd.List(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(checkEtcdQuorum(ctx, p.Config, members))
+}
+
+// Rollback is no-op for this phase since the member of the failed node
+// cannot be brought back
+func (*removeEtcdMemberExecutor) Rollback(ctx context.Context) error {
+	return nil
+}
+
+// PreCheck is no-op for this phase
+func (*removeEtcdMemberExecutor) PreCheck(ctx context.Context) error {
+	return nil
+}
+
+// PostCheck is no-op for this phase
+func (*removeEtcdMemberExecutor) PostCheck(ctx context.Context) error {
+	return nil
+}
+
+// NewReplaceNode returns executor that hands over the replaced master
+// node's labels and leadership to the joined node and deletes it from
+// the Kubernetes cluster
+func NewReplaceNode(p fsm.ExecutorParams, operator ops.Operator) (*replaceNodeExecutor, error) {
+	config, err := etcdConfig(append(masterEndpoints(p.Plan.Servers),
+		etcdEndpoint(*p.Phase.Data.ExecServer)))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	etcdClient, err := clients.Etcd(config)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	kubeClient, _, err := httplib.GetClusterKubeClient(p.Plan.DNSConfig.Addr())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	logger := &fsm.Logger{
+		FieldLogger: logrus.WithFields(logrus.Fields{
+			constants.FieldPhase: p.Phase.ID,
+		}),
+		Key:      opKey(p.Plan),
+		Operator: operator,
+		Server:   p.Phase.Data.ExecServer,
+	}
+	return &replaceNodeExecutor{
+		FieldLogger:    logger,
+		Etcd:           etcdClient,
+		Config:         *config,
+		Client:         kubeClient,
+		ExecutorParams: p,
+	}, nil
+}
+
+type replaceNodeExecutor struct {
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// Etcd is the etcd client
+	Etcd etcd.Client
+	// Config is the etcd client configuration
+	Config clients.EtcdConfig
+	// Client is Kubernetes client
+	Client *kubernetes.Clientset
+	// ExecutorParams is common executor params
+	fsm.ExecutorParams
+}
+
+// Execute replaces the failed master node with the joined node
+func (p *replaceNodeExecutor) Execute(ctx context.Context) error {
+	server, joined := *p.Phase.Data.Server, *p.Phase.Data.ExecServer
+	p.Progress.NextStep("Verifying etcd quorum")
+	members, err := etcd.NewMembersAPI(p.Etcd).List(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = checkEtcdQuorum(ctx, p.Config, members)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// the joined node may have taken over the address of the failed one
+	if server.AdvertiseIP != joined.AdvertiseIP {
+		p.Progress.NextStep("Moving leader-elected services off %v", server.Hostname)
+		err = p.moveLeader(ctx, server)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	p.Progress.NextStep("Moving labels of %v to %v", server.Hostname, joined.Hostname)
+	err = p.replaceKubeNode(ctx, server, joined)
+	return trace.Wrap(err)
+}
+
+// moveLeader removes the specified server from leader election and makes sure
+// another master takes over if the server is the current leader
+func (p *replaceNodeExecutor) moveLeader(ctx context.Context, server storage.Server) error {
+	keys := etcd.NewKeysAPI(p.Etcd)
+	electionKey := fmt.Sprintf("/planet/cluster/%v/election/%v", p.Plan.ClusterName, server.AdvertiseIP)
+	_, err := keys.Set(ctx, electionKey, "false", nil)
+	if err != nil {
+		return trace.Wrap(err, "failed to disable leader election on %v", server.AdvertiseIP)
+	}
+	leaderKey := fmt.Sprintf("/planet/cluster/%v/master", p.Plan.ClusterName)
+	_, err = keys.Delete(ctx, leaderKey, &etcd.DeleteOptions{PrevValue: server.AdvertiseIP})
+	if err != nil && !isEtcdError(err, etcd.ErrorCodeKeyNotFound, etcd.ErrorCodeTestFailed) {
+		return trace.Wrap(err, "failed to reset the leader")
+	}
+	err = utils.Retry(defaults.RetryInterval, defaults.RetryAttempts, func() error {
+		leaderAddr, err := utils.ResolveAddr(constants.APIServerDomainName, p.Plan.DNSConfig.Addr())
+		if err != nil {
+			return trace.Wrap(err, "failed to resolve current leader IP")
+		}
+		if leaderAddr == server.AdvertiseIP {
+			return utils.Continue("waiting for %v to step down as leader", leaderAddr)
+		}
+		return nil
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	p.Infof("Disabled leader election on %v.", server.AdvertiseIP)
+	return nil
+}
+
+// replaceKubeNode copies the labels of the failed node to the joined node and
+// deletes the failed node from Kubernetes so its pods are rescheduled
+func (p *replaceNodeExecutor) replaceKubeNode(ctx context.Context, server, joined storage.Server) error {
+	oldNode, err := kubeutils.GetNode(p.Client, server)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			p.Infof("Kubernetes node of %v has already been removed.", server.Hostname)
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	newNode, err := kubeutils.GetNode(p.Client, joined)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if oldNode.Name == newNode.Name {
+		return nil
+	}
+	labels := ReplacedNodeLabels(oldNode.Labels, newNode.Labels)
+	if len(labels) != 0 {
+		err = kubeutils.UpdateLabels(ctx, p.Client.Core().Nodes(), newNode.Name, labels)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		p.Infof("Copied labels %v to %v.", labels, newNode.Name)
+	}
+	err = p.Client.Core().Nodes().Delete(oldNode.Name, nil)
+	if err != nil {
+		err = rigging.ConvertError(err)
+		if !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+	}
+	p.Infof("Deleted Kubernetes node %v.", oldNode.Name)
+	return nil
+}
+
+// Rollback is no-op for this phase
+func (*replaceNodeExecutor) Rollback(ctx context.Context) error {
+	return nil
+}
+
+// PreCheck is no-op for this phase
+func (*replaceNodeExecutor) PreCheck(ctx context.Context) error {
+	return nil
+}
+
+// PostCheck is no-op for this phase
+func (*replaceNodeExecutor) PostCheck(ctx context.Context) error {
+	return nil
+}
+
+// ReplacedNodeLabels returns the labels of the replaced Kubernetes node
+// the replacing node does not have yet.
+// Labels maintained by Kubernetes itself are not copied
+func ReplacedNodeLabels(oldLabels, newLabels map[string]string) map[string]string {
+	labels := make(map[string]string)
+	for name, value := range oldLabels {
+		if _, ok := newLabels[name]; ok {
+			continue
+		}
+		if strings.Contains(name, "kubernetes.io/") && !strings.HasPrefix(name, "node-role.kubernetes.io/") {
+			continue
+		}
+		labels[name] = value
+	}
+	return labels
+}
+
+// EtcdQuorum returns the number of healthy members the etcd cluster
+// of the specified size needs to stay available
+func EtcdQuorum(members int) int {
+	return members/2 + 1
+}
+
+// checkEtcdQuorum makes sure that enough of the provided members are healthy
+// for the etcd cluster to have quorum
+func checkEtcdQuorum(ctx context.Context, config clients.EtcdConfig, members []etcd.Member) error {
+	var healthy int
+	for _, member := range members {
+		if isEtcdMemberHealthy(ctx, config, member) {
+			healthy++
+		}
+	}
+	if healthy < EtcdQuorum(len(members)) {
+		return trace.CompareFailed("etcd cluster does not have quorum: %v out of %v members are healthy",
+			healthy, len(members))
+	}
+	logrus.Infof("Etcd cluster has quorum: %v out of %v members are healthy.", healthy, len(members))
+	return nil
+}
+
+// isEtcdMemberHealthy returns true if the specified member is reachable
+// and sees the cluster leader
+func isEtcdMemberHealthy(ctx context.Context, config clients.EtcdConfig, member etcd.Member) bool {
+	// members that have not started yet do not advertise client URLs
+	if len(member.ClientURLs) == 0 {
+		return false
+	}
+	config.Endpoints = member.ClientURLs
+	client, err := clients.EtcdMembers(&config)
+	if err != nil {
+		logrus.Warnf("Failed to create etcd client for %v: %v.", member.ClientURLs, err)
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, defaults.DialTimeout)
+	defer cancel()
+	if _, err := client.Leader(ctx); err != nil {
+		logrus.Warnf("Etcd member %v is unhealthy: %v.", member.Name, err)
+		return false
+	}
+	return true
+}
+
+// findEtcdMember returns the etcd member with the specified peer address
+func findEtcdMember(members []etcd.Member, addr string) (*etcd.Member, error) {
+	for _, member := range members {
+		for _, peerURL := range member.PeerURLs {
+			hostname, err := utils.URLHostname(peerURL)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			if hostname == addr {
+				return &member, nil
+			}
+		}
+	}
+	return nil, trace.NotFound("etcd member with address %v not found", addr)
+}
+
+func isEtcdError(err error, codes ...int) bool {
+	if etcdErr, ok := trace.Unwrap(err).(etcd.Error); ok {
+		for _, code := range codes {
+			if etcdErr.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// etcdConfig returns configuration of the etcd client talking to the specified endpoints
+func etcdConfig(endpoints []string) (*clients.EtcdConfig, error) {
+	stateDir, err := state.GetStateDir()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &clients.EtcdConfig{
+		Endpoints:  endpoints,
+		SecretsDir: state.SecretDir(stateDir),
+	}, nil
+}
+
+// masterEndpoints returns etcd endpoints of the provided master servers
+func masterEndpoints(servers []storage.Server) (endpoints []string) {
+	for _, server := range servers {
+		if server.IsMaster() {
+			endpoints = append(endpoints, etcdEndpoint(server))
+		}
+	}
+	return endpoints
+}
+
+func etcdEndpoint(server storage.Server) string {
+	return fmt.Sprintf("https://%v:%v", server.AdvertiseIP, defaults.EtcdAPIPort)
+}
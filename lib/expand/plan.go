@@ -58,6 +58,14 @@ func (p *Peer) getOperationPlan(ctx operationContext) (*storage.OperationPlan, e
 		DNSConfig:     ctx.Cluster.DNSConfig,
 	}
 
+	if len(builder.JoiningNodes) > 1 {
+		return getMultiNodePlan(builder, plan), nil
+	}
+	return getSingleNodePlan(builder, plan), nil
+}
+
+// getSingleNodePlan returns the plan for the operation that joins a single node
+func getSingleNodePlan(builder *planBuilder, plan *storage.OperationPlan) *storage.OperationPlan {
 	// have cluster controller configure packages for the joining node
 	builder.AddConfigurePhase(plan)
 
@@ -75,6 +83,12 @@ func (p *Peer) getOperationPlan(ctx operationContext) (*storage.OperationPlan, e
 	// install teleport and planet services on the joining node
 	builder.AddSystemPhase(plan)
 
+	// when replacing a failed master, its etcd member is removed before the
+	// joining node is added so the etcd cluster does not lose quorum
+	if builder.ReplaceServer != nil {
+		builder.AddRemoveEtcdMemberPhase(plan)
+	}
+
 	// when adding a master node, add it to the existing etcd cluster as a full member
 	if builder.JoiningNode.IsMaster() {
 		// when adding a second master node, etcd cluster becomes unavailable
@@ -100,6 +114,12 @@ func (p *Peer) getOperationPlan(ctx operationContext) (*storage.OperationPlan, e
 		builder.AddStopAgentPhase(plan)
 	}
 
+	// hand over the labels and leadership of the replaced master to the
+	// joined node
+	if builder.ReplaceServer != nil {
+		builder.AddReplaceNodePhase(plan)
+	}
+
 	// run post-join hook if the application has it
 	if builder.Application.Manifest.HasHook(schema.HookNodeAdded) {
 		builder.AddPostHookPhase(plan)
@@ -110,5 +130,31 @@ func (p *Peer) getOperationPlan(ctx operationContext) (*storage.OperationPlan, e
 	builder.AddElectPhase(plan)
 
 	fillSteps(plan)
-	return plan, nil
+	return plan
+}
+
+// getMultiNodePlan returns the plan for the operation that joins several
+// nodes at once
+func getMultiNodePlan(builder *planBuilder, plan *storage.OperationPlan) *storage.OperationPlan {
+	// have cluster controller configure packages for all joining nodes
+	builder.AddConfigurePhase(plan)
+
+	// the recovery agent and etcd backup protect the single-master cluster
+	// while its etcd cluster grows, see getOperationPlan for details
+	withAgent := len(builder.JoiningNodes.Masters()) > 0 &&
+		len(builder.ClusterNodes.Masters()) == 1
+	if withAgent {
+		builder.AddStartAgentPhase(plan)
+		builder.AddEtcdBackupPhase(plan)
+	}
+
+	// join each node, see Peer.executePlan for how the nodes are scheduled
+	builder.AddNodesPhase(plan)
+
+	if withAgent {
+		builder.AddStopAgentPhase(plan)
+	}
+
+	fillSteps(plan)
+	return plan
 }
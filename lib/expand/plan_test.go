@@ -395,3 +395,193 @@ func (s *PlanSuite) verifyElectPhase(c *check.C, phase storage.OperationPhase) {
 		Requires: []string{installphases.WaitPhase},
 	}, phase)
 }
+
+func (s *PlanSuite) TestMultiNodePlan(c *check.C) {
+	app, err := s.services.Apps.GetApp(s.appPackage)
+	c.Assert(err, check.IsNil)
+	workerNode := storage.Server{
+		AdvertiseIP: "10.10.0.3",
+		Hostname:    "node-3",
+		Role:        "node",
+		ClusterRole: string(schema.ServiceRoleNode),
+	}
+	builder := &planBuilder{
+		Application:     *app,
+		TeleportPackage: *s.teleportPackage,
+		PlanetPackage:   *s.planetPackage,
+		JoiningNode:     workerNode,
+		JoiningNodes:    storage.Servers{workerNode, s.joiningNode},
+		ClusterNodes:    s.clusterNodes,
+		Peer:            fmt.Sprintf("%v:%v", s.masterNode.AdvertiseIP, defaults.GravitySiteNodePort),
+		Master:          s.masterNode,
+		AdminAgent:      *s.adminAgent,
+		RegularAgent:    *s.regularAgent,
+		ServiceUser:     s.serviceUser,
+	}
+	plan := getMultiNodePlan(builder, &storage.OperationPlan{})
+	c.Assert(phaseIDs(plan.Phases), check.DeepEquals, []string{
+		installphases.ConfigurePhase,
+		StartAgentPhase,
+		EtcdBackupPhase,
+		NodesPhase,
+		StopAgentPhase,
+	})
+
+	nodes := plan.Phases[3]
+	c.Assert(phaseIDs(nodes.Phases), check.DeepEquals, []string{
+		"/nodes/node-2", "/nodes/node-3",
+	}, check.Commentf("masters should be joined first"))
+
+	master := nodes.Phases[0]
+	c.Assert(phaseIDs(master.Phases), check.DeepEquals, []string{
+		"/nodes/node-2/bootstrap",
+		"/nodes/node-2/pull",
+		"/nodes/node-2/preHook",
+		"/nodes/node-2/system",
+		"/nodes/node-2/etcd",
+		"/nodes/node-2/wait",
+		"/nodes/node-2/postHook",
+		"/nodes/node-2/elect",
+	})
+	c.Assert(master.Phases[1].Requires, check.DeepEquals, []string{"/nodes/node-2/bootstrap"},
+		check.Commentf("requirements outside of the node phase should be dropped"))
+	c.Assert(master.Phases[4].Requires, check.DeepEquals, []string{"/nodes/node-2/system"})
+	c.Assert(master.Phases[5].Phases[0].ID, check.Equals, "/nodes/node-2/wait/planet")
+	c.Assert(master.Phases[5].Phases[0].Requires, check.DeepEquals,
+		[]string{"/nodes/node-2/system", "/nodes/node-2/etcd"})
+
+	worker := nodes.Phases[1]
+	c.Assert(phaseIDs(worker.Phases), check.DeepEquals, []string{
+		"/nodes/node-3/bootstrap",
+		"/nodes/node-3/pull",
+		"/nodes/node-3/preHook",
+		"/nodes/node-3/system",
+		"/nodes/node-3/wait",
+		"/nodes/node-3/postHook",
+		"/nodes/node-3/elect",
+	})
+	storage.DeepComparePhases(c, storage.OperationPhase{
+		ID: "/nodes/node-3/bootstrap",
+		Data: &storage.OperationPhaseData{
+			Server:      &workerNode,
+			ExecServer:  &workerNode,
+			Package:     &s.appPackage,
+			Agent:       s.regularAgent,
+			ServiceUser: &s.serviceUser,
+		},
+	}, worker.Phases[0])
+}
+
+func (s *PlanSuite) TestReplacePlan(c *check.C) {
+	app, err := s.services.Apps.GetApp(s.appPackage)
+	c.Assert(err, check.IsNil)
+	otherMaster := storage.Server{
+		AdvertiseIP: "10.10.0.3",
+		Hostname:    "node-3",
+		Role:        "node",
+		ClusterRole: string(schema.ServiceRoleMaster),
+	}
+	failedMaster := storage.Server{
+		AdvertiseIP: "10.10.0.4",
+		Hostname:    "node-4",
+		Role:        "node",
+		ClusterRole: string(schema.ServiceRoleMaster),
+	}
+	builder := &planBuilder{
+		Application:     *app,
+		TeleportPackage: *s.teleportPackage,
+		PlanetPackage:   *s.planetPackage,
+		JoiningNode:     s.joiningNode,
+		JoiningNodes:    storage.Servers{s.joiningNode},
+		ClusterNodes:    storage.Servers{s.masterNode, otherMaster},
+		Peer:            fmt.Sprintf("%v:%v", s.masterNode.AdvertiseIP, defaults.GravitySiteNodePort),
+		Master:          s.masterNode,
+		AdminAgent:      *s.adminAgent,
+		RegularAgent:    *s.regularAgent,
+		ServiceUser:     s.serviceUser,
+		ReplaceServer:   &failedMaster,
+	}
+	plan := getSingleNodePlan(builder, &storage.OperationPlan{})
+	c.Assert(phaseIDs(plan.Phases), check.DeepEquals, []string{
+		installphases.ConfigurePhase,
+		installphases.BootstrapPhase,
+		installphases.PullPhase,
+		PreHookPhase,
+		SystemPhase,
+		RemoveEtcdMemberPhase,
+		EtcdPhase,
+		installphases.WaitPhase,
+		ReplaceNodePhase,
+		PostHookPhase,
+		ElectPhase,
+	})
+	storage.DeepComparePhases(c, storage.OperationPhase{
+		ID:          RemoveEtcdMemberPhase,
+		Description: "Remove the failed node node-4 from the etcd cluster",
+		Data: &storage.OperationPhaseData{
+			Server:     &failedMaster,
+			ExecServer: &s.joiningNode,
+		},
+		Requires: []string{SystemPhase},
+	}, plan.Phases[5])
+	c.Assert(plan.Phases[6].Requires, check.DeepEquals, []string{SystemPhase, RemoveEtcdMemberPhase},
+		check.Commentf("joining node should be added to etcd after the failed member is removed"))
+	storage.DeepComparePhases(c, storage.OperationPhase{
+		ID:          ReplaceNodePhase,
+		Description: "Replace the failed node node-4 with the joining node",
+		Data: &storage.OperationPhaseData{
+			Server:     &failedMaster,
+			ExecServer: &s.joiningNode,
+		},
+		Requires: []string{installphases.WaitPhase},
+	}, plan.Phases[8])
+}
+
+func (s *PlanSuite) TestFindReplaceServer(c *check.C) {
+	workerNode := storage.Server{
+		AdvertiseIP: "10.10.0.3",
+		Hostname:    "node-3",
+		Role:        "node",
+		ClusterRole: string(schema.ServiceRoleNode),
+	}
+	servers := []storage.Server{s.masterNode, workerNode}
+
+	server, err := FindReplaceServer(servers, s.masterNode.AdvertiseIP)
+	c.Assert(err, check.IsNil)
+	c.Assert(*server, check.DeepEquals, s.masterNode)
+
+	server, err = FindReplaceServer(servers, s.masterNode.Hostname)
+	c.Assert(err, check.IsNil)
+	c.Assert(*server, check.DeepEquals, s.masterNode)
+
+	_, err = FindReplaceServer(servers, workerNode.Hostname)
+	c.Assert(trace.IsBadParameter(err), check.Equals, true, check.Commentf("only masters can be replaced"))
+
+	_, err = FindReplaceServer(servers, "node-5")
+	c.Assert(trace.IsNotFound(err), check.Equals, true)
+}
+
+func (s *PlanSuite) TestNodePhaseID(c *check.C) {
+	testCases := []struct {
+		phaseID string
+		node    string
+		id      string
+	}{
+		{phaseID: "/nodes/node-1/system/planet", node: "node-1", id: "/system/planet"},
+		{phaseID: "/nodes/node-1/etcd", node: "node-1", id: "/etcd"},
+		{phaseID: "/nodes/node-1", id: "/nodes/node-1"},
+		{phaseID: "/etcdBackup", id: "/etcdBackup"},
+	}
+	for _, tc := range testCases {
+		node, id := splitNodePhaseID(tc.phaseID)
+		c.Assert(node, check.Equals, tc.node, check.Commentf(tc.phaseID))
+		c.Assert(id, check.Equals, tc.id, check.Commentf(tc.phaseID))
+	}
+}
+
+func phaseIDs(phases []storage.OperationPhase) (ids []string) {
+	for _, phase := range phases {
+		ids = append(ids, phase.ID)
+	}
+	return ids
+}
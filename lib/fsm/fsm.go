@@ -75,6 +75,9 @@ type Params struct {
 	Force bool
 	// Progress is optional progress reporter
 	Progress utils.Progress
+	// Rollback is whether the phase is being rolled back as a part of the
+	// plan rollback. Such phases can be dispatched to remote servers
+	Rollback bool
 }
 
 // CheckAndSetDefaults makes sure all required parameters are set
@@ -241,6 +244,9 @@ func (f *FSM) RollbackPhase(ctx context.Context, p Params) error {
 			if err != nil {
 				return trace.Wrap(err)
 			}
+			if execWhere == CanRunRemotely && p.Rollback {
+				return trace.Wrap(f.rollbackPhaseRemotely(ctx, p, *phase, *execServer))
+			}
 			if execWhere != CanRunLocally {
 				return trace.BadParameter("rollback phase %v must be run from server %v", p.PhaseID, execServer.Hostname)
 			}
@@ -263,6 +269,28 @@ func (f *FSM) RollbackPhase(ctx context.Context, p Params) error {
 	return nil
 }
 
+// RollbackPlan rolls back all phases of the plan that have been started
+// in the reverse order of their execution
+func (f *FSM) RollbackPlan(ctx context.Context, progress utils.Progress, force bool) error {
+	plan, err := f.GetPlan()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, phase := range PhasesToRollback(plan) {
+		f.Debugf("Rolling back phase %q.", phase.ID)
+		err := f.RollbackPhase(ctx, Params{
+			PhaseID:  phase.ID,
+			Progress: progress,
+			Force:    force,
+			Rollback: true,
+		})
+		if err != nil {
+			return trace.Wrap(err, "failed to rollback phase %q", phase.ID)
+		}
+	}
+	return nil
+}
+
 // SetPreExec sets the hook that's called before phase execution
 func (f *FSM) SetPreExec(fn PhaseHookFn) {
 	f.preExecFn = fn
@@ -348,6 +376,22 @@ func (f *FSM) executePhaseRemotely(ctx context.Context, p Params, phase storage.
 	return f.RunCommand(ctx, f.Runner, server, p)
 }
 
+// rollbackPhaseRemotely rolls back the specified operation phase on the specified server
+func (f *FSM) rollbackPhaseRemotely(ctx context.Context, p Params, phase storage.OperationPhase, server storage.Server) error {
+	p.Progress.NextStep("Rolling back %q on remote node %v", phase.ID,
+		server.Hostname)
+	err := f.RunCommand(ctx, f.Runner, server, p)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// Record the state locally as well since etcd might not be available
+	// to synchronize the changes back to us
+	return trace.Wrap(f.ChangePhaseState(ctx, StateChange{
+		Phase: phase.ID,
+		State: storage.OperationPhaseStateRolledBack,
+	}))
+}
+
 // executePhaseLocally executes the specified operation phase on this server
 func (f *FSM) executePhaseLocally(ctx context.Context, p Params, phase storage.OperationPhase) error {
 	if !phase.HasSubphases() {
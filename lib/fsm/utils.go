@@ -17,6 +17,9 @@ limitations under the License.
 package fsm
 
 import (
+	"path"
+	"strings"
+
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
@@ -41,6 +44,80 @@ func CanRollback(plan *storage.OperationPlan, phaseID string) error {
 	return nil
 }
 
+// PhasesToRollback returns the minimal list of phases of the provided plan
+// that need to be rolled back, in the order they should be rolled back.
+//
+// Only phases without subphases that have been started and have not been
+// rolled back yet are returned. Phases that depend on other phases (either
+// directly or via their parents) are rolled back before their dependencies
+func PhasesToRollback(plan *storage.OperationPlan) (phases []storage.OperationPhase) {
+	order := executionOrder(plan)
+	for i := len(order) - 1; i >= 0; i-- {
+		phase := order[i]
+		if phase.IsUnstarted() || phase.IsRolledBack() {
+			continue
+		}
+		phases = append(phases, *phase)
+	}
+	return phases
+}
+
+// executionOrder returns the phases of the provided plan without subphases
+// sorted so that every phase follows the phases it depends on.
+// Otherwise, the order of the phases in the plan is preserved
+func executionOrder(plan *storage.OperationPlan) (order []*storage.OperationPhase) {
+	requires := make(map[string][]string)
+	var leaves []*storage.OperationPhase
+	for _, phase := range FlattenPlan(plan) {
+		requires[phase.ID] = phase.Requires
+		if !phase.HasSubphases() {
+			leaves = append(leaves, phase)
+		}
+	}
+	done := make([]bool, len(leaves))
+	// dependenciesDone returns true if all phases the specified phase
+	// or any of its parents require are done
+	dependenciesDone := func(phase *storage.OperationPhase) bool {
+		for id := phase.ID; id != path.Dir(id); id = path.Dir(id) {
+			for _, required := range requires[id] {
+				for i, leaf := range leaves {
+					if !done[i] && isSubphase(leaf.ID, required) {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+	for len(order) < len(leaves) {
+		next := -1
+		for i, leaf := range leaves {
+			if !done[i] && dependenciesDone(leaf) {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			// Dependencies cannot be satisfied, fall back to the order in the plan
+			for i := range leaves {
+				if !done[i] {
+					next = i
+					break
+				}
+			}
+		}
+		order = append(order, leaves[next])
+		done[next] = true
+	}
+	return order
+}
+
+// isSubphase returns true if the phase with the specified ID is
+// either the parent phase itself or one of its subphases
+func isSubphase(id, parent string) bool {
+	return id == parent || strings.HasPrefix(id, parent+"/")
+}
+
 // IsCompleted returns true if all phases of the provided plan are completed
 func IsCompleted(plan *storage.OperationPlan) bool {
 	for _, phase := range FlattenPlan(plan) {
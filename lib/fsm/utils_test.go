@@ -0,0 +1,87 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"testing"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	. "gopkg.in/check.v1"
+)
+
+func TestFSM(t *testing.T) { TestingT(t) }
+
+type UtilsSuite struct{}
+
+var _ = Suite(&UtilsSuite{})
+
+func (s *UtilsSuite) TestPhasesToRollback(c *C) {
+	plan := &storage.OperationPlan{
+		Phases: []storage.OperationPhase{
+			{
+				ID:    "/init",
+				State: storage.OperationPhaseStateCompleted,
+			},
+			{
+				ID:       "/masters",
+				Requires: []string{"/init"},
+				Phases: []storage.OperationPhase{
+					{
+						ID:    "/masters/node-1",
+						State: storage.OperationPhaseStateCompleted,
+					},
+					{
+						ID:       "/masters/node-2",
+						Requires: []string{"/masters/node-1"},
+						State:    storage.OperationPhaseStateFailed,
+					},
+				},
+			},
+			{
+				ID:       "/nodes",
+				Requires: []string{"/masters"},
+				Phases: []storage.OperationPhase{
+					{
+						ID:    "/nodes/node-3",
+						State: storage.OperationPhaseStateUnstarted,
+					},
+				},
+			},
+			{
+				// Declared before its dependency to verify the dependency order
+				ID:       "/app",
+				Requires: []string{"/config"},
+				State:    storage.OperationPhaseStateCompleted,
+			},
+			{
+				ID:    "/config",
+				State: storage.OperationPhaseStateRolledBack,
+			},
+		},
+	}
+	var ids []string
+	for _, phase := range PhasesToRollback(plan) {
+		ids = append(ids, phase.ID)
+	}
+	c.Assert(ids, DeepEquals, []string{
+		"/app",
+		"/masters/node-2",
+		"/masters/node-1",
+		"/init",
+	})
+}
@@ -27,14 +27,17 @@ import (
 	"github.com/gravitational/gravity/lib/utils"
 	helmutils "github.com/gravitational/gravity/lib/utils/helm"
 
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/helm/pkg/chartutil"
 	"k8s.io/helm/pkg/helm"
 	"k8s.io/helm/pkg/helm/portforwarder"
 	"k8s.io/helm/pkg/kube"
 	"k8s.io/helm/pkg/proto/hapi/release"
 
+	"github.com/gravitational/rigging"
 	"github.com/gravitational/trace"
 	"github.com/sirupsen/logrus"
 )
@@ -72,16 +75,119 @@ type client struct {
 type ClientConfig struct {
 	// DNSAddress is an optional in-cluster DNS address.
 	DNSAddress string
+	// Backend optionally specifies the release backend.
+	// If unspecified, the backend is detected from the cluster.
+	Backend ReleaseBackend
 	// TODO Add Helm TLS flags.
 }
 
+// ReleaseBackend defines the implementation that manages releases.
+type ReleaseBackend string
+
+const (
+	// Helm2 manages releases with Tiller.
+	Helm2 ReleaseBackend = "helm2"
+	// Helm3 manages releases without Tiller and stores them as Secrets.
+	Helm3 ReleaseBackend = "helm3"
+)
+
 // NewClient returns a new Helm client instance.
+//
+// Unless the backend has been specified explicitly, Helm 3 client is used
+// for clusters whose releases have been migrated and Helm 2 client otherwise.
 func NewClient(conf ClientConfig) (Client, error) {
 	kubeClient, kubeConfig, err := getKubeClient(conf.DNSAddress)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	tunnel, err := portforwarder.New("kube-system", kubeClient, kubeConfig)
+	backend := conf.Backend
+	if backend == "" {
+		backend, err = DetectBackend(kubeClient)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	switch backend {
+	case Helm2:
+		return newTillerClient(kubeClient, kubeConfig)
+	case Helm3:
+		return newHelm3Client(kubeClient, kubeConfig), nil
+	}
+	return nil, trace.BadParameter("unsupported release backend %q", backend)
+}
+
+// DetectBackend returns the release backend for the cluster.
+//
+// The release migration records the Helm 3 backend in a ConfigMap.
+// Clusters without the record manage releases with Tiller.
+func DetectBackend(client kubernetes.Interface) (ReleaseBackend, error) {
+	configMap, err := client.CoreV1().ConfigMaps(metav1.NamespaceSystem).Get(
+		backendConfigMap, metav1.GetOptions{})
+	err = rigging.ConvertError(err)
+	if err == nil {
+		return backendFromConfigMap(*configMap)
+	}
+	if !trace.IsNotFound(err) {
+		return "", trace.Wrap(err)
+	}
+	deployments, err := client.AppsV1().Deployments(metav1.NamespaceSystem).List(metav1.ListOptions{
+		LabelSelector: tillerSelector.String(),
+	})
+	if err != nil {
+		return "", trace.Wrap(rigging.ConvertError(err))
+	}
+	if len(deployments.Items) == 0 {
+		return "", trace.NotFound("Tiller is not deployed and releases have not " +
+			"been migrated to Helm 3, run 'gravity app migrate-releases' to switch " +
+			"the cluster to Helm 3")
+	}
+	return Helm2, nil
+}
+
+// recordBackend records the specified release backend for the cluster
+func recordBackend(client kubernetes.Interface, backend ReleaseBackend) error {
+	configMaps := client.CoreV1().ConfigMaps(metav1.NamespaceSystem)
+	configMap := newBackendConfigMap(backend)
+	_, err := configMaps.Create(configMap)
+	err = rigging.ConvertError(err)
+	if trace.IsAlreadyExists(err) {
+		_, err = configMaps.Update(configMap)
+		err = rigging.ConvertError(err)
+	}
+	return trace.Wrap(err)
+}
+
+// newBackendConfigMap returns the ConfigMap that records the specified release backend
+func newBackendConfigMap(backend ReleaseBackend) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backendConfigMap,
+			Namespace: metav1.NamespaceSystem,
+		},
+		Data: map[string]string{
+			backendKey: string(backend),
+		},
+	}
+}
+
+// backendFromConfigMap returns the release backend recorded in the specified ConfigMap
+func backendFromConfigMap(configMap v1.ConfigMap) (ReleaseBackend, error) {
+	backend := ReleaseBackend(configMap.Data[backendKey])
+	switch backend {
+	case Helm2, Helm3:
+		return backend, nil
+	}
+	return "", trace.BadParameter("unsupported release backend %q in %v/%v",
+		backend, configMap.Namespace, configMap.Name)
+}
+
+// newTillerClient returns a new Helm 2 client that talks to Tiller over a tunnel.
+func newTillerClient(kubeClient kubernetes.Interface, kubeConfig *rest.Config) (*client, error) {
+	tunnel, err := portforwarder.New(metav1.NamespaceSystem, kubeClient, kubeConfig)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -114,7 +220,7 @@ func (c *client) Install(p InstallParameters) (storage.Release, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	chart, err := chartutil.Load(p.Path)
+	chart, err := loadChart(p.Path)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -204,7 +310,7 @@ func (c *client) Upgrade(p UpgradeParameters) (storage.Release, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	_, err = chartutil.Load(p.Path)
+	_, err = loadChart(p.Path)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -332,5 +438,17 @@ var allStatuses = []release.Status_Code{
 	release.Status_PENDING_ROLLBACK,
 }
 
+// tillerSelector matches Tiller resources.
+var tillerSelector = labels.Set{"app": "helm", "name": "tiller"}
+
+const (
+	// backendConfigMap is the name of the ConfigMap in the kube-system
+	// namespace that records the release backend of the cluster.
+	// The tiller-app hooks read it as well
+	backendConfigMap = "helm-release-backend"
+	// backendKey is the ConfigMap key with the release backend
+	backendKey = "backend"
+)
+
 // maxHistory is how many history revisions are returned.
 const maxHistory = 256
@@ -0,0 +1,203 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/app/resources"
+	"github.com/gravitational/gravity/lib/storage"
+	helmutils "github.com/gravitational/gravity/lib/utils/helm"
+
+	"github.com/gravitational/trace"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/santhosh-tekuri/jsonschema"
+	"k8s.io/helm/pkg/chartutil"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+	"k8s.io/helm/pkg/renderutil"
+	"k8s.io/helm/pkg/timeconv"
+)
+
+// DryRunParameters defines parameters to render a release without deploying it.
+type DryRunParameters struct {
+	// Path is a chart path.
+	Path string
+	// Values is a list of YAML files with values.
+	Values []string
+	// Set is a list of values set on the CLI.
+	Set []string
+	// Name is the release name.
+	Name string
+	// Namespace is the release namespace.
+	Namespace string
+	// Release is the currently deployed release when rendering an upgrade.
+	Release storage.Release
+}
+
+// DryRunResult is the result of a release dry-run.
+type DryRunResult struct {
+	// Manifest is the rendered release manifest.
+	Manifest string
+	// Diff is the unified diff between the deployed and the rendered
+	// release manifests. Only set for upgrades.
+	Diff string
+	// Images lists the images referenced by the rendered manifest.
+	Images []string
+}
+
+// DryRun renders the chart with the provided values the same way it would
+// be rendered by install or upgrade.
+//
+// If the chart provides a values schema, the merged values are validated
+// against it.
+func DryRun(p DryRunParameters) (*DryRunResult, error) {
+	ch, err := loadChart(p.Path)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rawVals, err := helmutils.Vals(p.Values, p.Set, nil, nil, "", "", "")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	config := &chart.Config{Raw: string(rawVals)}
+	if err := ValidateValues(ch, config); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	options := chartutil.ReleaseOptions{
+		Name:      p.Name,
+		Namespace: p.Namespace,
+		Time:      timeconv.Now(),
+		Revision:  1,
+		IsInstall: p.Release == nil,
+		IsUpgrade: p.Release != nil,
+	}
+	if p.Release != nil {
+		options.Name = p.Release.GetName()
+		options.Namespace = p.Release.GetNamespace()
+		options.Revision = p.Release.GetRevision() + 1
+	}
+	rendered, err := renderutil.Render(ch, config, renderutil.Options{
+		ReleaseOptions: options,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	_, manifest, _, err := splitManifests(rendered)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	result := &DryRunResult{
+		Manifest: manifest,
+	}
+	result.Images, err = manifestImages(manifest)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if p.Release != nil {
+		result.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(p.Release.GetManifest()),
+			B:        difflib.SplitLines(manifest),
+			FromFile: fmt.Sprintf("%v (revision %v)", p.Release.GetName(), p.Release.GetRevision()),
+			ToFile:   fmt.Sprintf("%v (%v-%v)", p.Release.GetName(), ch.Metadata.Name, ch.Metadata.Version),
+			Context:  3,
+		})
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return result, nil
+}
+
+// ValidateValues validates the chart values merged with the provided
+// configuration against the chart values schema.
+//
+// Charts without a values schema are not validated.
+func ValidateValues(ch *chart.Chart, config *chart.Config) error {
+	var schema []byte
+	for _, file := range ch.Files {
+		if file.TypeUrl == valuesSchemaFilename {
+			schema = file.Value
+			break
+		}
+	}
+	if schema == nil {
+		return nil
+	}
+	values, err := chartutil.CoalesceValues(ch, config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft7
+	if err := compiler.AddResource(valuesSchemaFilename, bytes.NewReader(schema)); err != nil {
+		return trace.BadParameter("invalid values schema of chart %v: %v",
+			ch.Metadata.Name, err)
+	}
+	valuesSchema, err := compiler.Compile(valuesSchemaFilename)
+	if err != nil {
+		return trace.BadParameter("invalid values schema of chart %v: %v",
+			ch.Metadata.Name, err)
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = valuesSchema.Validate(bytes.NewReader(data))
+	if err == nil {
+		return nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return trace.BadParameter("failed to validate values of chart %v: %v",
+			ch.Metadata.Name, err)
+	}
+	var errors []string
+	for _, cause := range validationCauses(validationErr) {
+		errors = append(errors, fmt.Sprintf("- %v: %v", cause.InstancePtr, cause.Message))
+	}
+	return trace.BadParameter("values do not match the schema of chart %v:\n%v",
+		ch.Metadata.Name, strings.Join(errors, "\n"))
+}
+
+// validationCauses returns the innermost causes of the specified validation error
+func validationCauses(err *jsonschema.ValidationError) (causes []*jsonschema.ValidationError) {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+	for _, cause := range err.Causes {
+		causes = append(causes, validationCauses(cause)...)
+	}
+	return causes
+}
+
+// manifestImages returns the images referenced by resources in the manifest
+func manifestImages(manifest string) ([]string, error) {
+	if strings.TrimSpace(manifest) == "" {
+		return nil, nil
+	}
+	resource, err := resources.Decode(strings.NewReader(manifest))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return resources.Resources{*resource}.Images()
+}
+
+// valuesSchemaFilename is the name of the file with chart values schema
+const valuesSchemaFilename = "values.schema.json"
@@ -0,0 +1,123 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	check "gopkg.in/check.v1"
+	"k8s.io/helm/pkg/proto/hapi/release"
+	"k8s.io/helm/pkg/timeconv"
+)
+
+type DryRunSuite struct {
+	dir string
+}
+
+var _ = check.Suite(&DryRunSuite{})
+
+func (s *DryRunSuite) SetUpTest(c *check.C) {
+	s.dir = c.MkDir()
+	writeFile(c, s.dir, "Chart.yaml", "name: web\nversion: 0.0.2\n")
+	writeFile(c, s.dir, "values.yaml", "image: nginx:1.15\nreplicas: 1\n")
+	writeFile(c, s.dir, "values.schema.json", `{
+  "type": "object",
+  "properties": {
+    "replicas": {"type": "integer", "minimum": 1}
+  },
+  "required": ["replicas"]
+}`)
+	writeFile(c, s.dir, filepath.Join("templates", "deployment.yaml"), `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+spec:
+  replicas: {{ .Values.replicas }}
+  template:
+    spec:
+      containers:
+      - name: web
+        image: {{ .Values.image }}
+`)
+}
+
+func (s *DryRunSuite) TestRendersInstall(c *check.C) {
+	result, err := DryRun(DryRunParameters{
+		Path: s.dir,
+		Set:  []string{"replicas=3"},
+		Name: "web",
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(result.Manifest, check.Matches, "(?s).*replicas: 3.*")
+	c.Assert(result.Images, check.DeepEquals, []string{"nginx:1.15"})
+	c.Assert(result.Diff, check.Equals, "")
+}
+
+func (s *DryRunSuite) TestRejectsInvalidValues(c *check.C) {
+	_, err := DryRun(DryRunParameters{
+		Path: s.dir,
+		Set:  []string{"replicas=0"},
+		Name: "web",
+	})
+	c.Assert(err, check.ErrorMatches, "(?s)values do not match the schema of chart web:.*replicas.*")
+}
+
+func (s *DryRunSuite) TestRejectsV2Charts(c *check.C) {
+	writeFile(c, s.dir, "Chart.yaml", "apiVersion: v2\nname: web\nversion: 0.0.2\n")
+	_, err := DryRun(DryRunParameters{
+		Path: s.dir,
+		Name: "web",
+	})
+	c.Assert(err, check.ErrorMatches, `chart web has unsupported apiVersion "v2".*`)
+}
+
+func (s *DryRunSuite) TestDiffsUpgrade(c *check.C) {
+	deployed, err := DryRun(DryRunParameters{
+		Path: s.dir,
+		Name: "web",
+	})
+	c.Assert(err, check.IsNil)
+	current, err := storage.NewRelease(&release.Release{
+		Name:      "web",
+		Namespace: "default",
+		Version:   1,
+		Manifest:  deployed.Manifest,
+		Info: &release.Info{
+			Status:       &release.Status{Code: release.Status_DEPLOYED},
+			LastDeployed: timeconv.Now(),
+		},
+	})
+	c.Assert(err, check.IsNil)
+
+	result, err := DryRun(DryRunParameters{
+		Path:    s.dir,
+		Release: current,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(result.Diff, check.Equals, "")
+
+	result, err = DryRun(DryRunParameters{
+		Path:    s.dir,
+		Set:     []string{"image=nginx:1.16"},
+		Release: current,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(result.Diff, check.Matches, "(?s).*-        image: nginx:1.15\n\\+        image: nginx:1.16\n.*")
+	c.Assert(result.Images, check.DeepEquals, []string{"nginx:1.16"})
+}
@@ -0,0 +1,737 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+	helmutils "github.com/gravitational/gravity/lib/utils/helm"
+
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/helm/pkg/chartutil"
+	"k8s.io/helm/pkg/kube"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+	"k8s.io/helm/pkg/releaseutil"
+	"k8s.io/helm/pkg/renderutil"
+	"k8s.io/helm/pkg/timeconv"
+)
+
+// helm3Client is the Helm client that manages releases without Tiller.
+//
+// Releases are rendered locally and stored as Secrets in the release
+// namespace using the Helm 3 storage format.
+type helm3Client struct {
+	logrus.FieldLogger
+	// client is the Kubernetes client used to manage release Secrets
+	client kubernetes.Interface
+	// kube is the client used to apply release resources
+	kube *kube.Client
+}
+
+// newHelm3Client returns a new Helm 3 client for the specified cluster
+func newHelm3Client(client kubernetes.Interface, config *rest.Config) *helm3Client {
+	logger := logrus.WithField(trace.Component, "helm3")
+	kubeClient := kube.New(&restClientGetter{config: config})
+	kubeClient.Log = logger.Debugf
+	return &helm3Client{
+		FieldLogger: logger,
+		client:      client,
+		kube:        kubeClient,
+	}
+}
+
+// Install installs a Helm chart and returns release information.
+func (c *helm3Client) Install(p InstallParameters) (storage.Release, error) {
+	ch, err := loadChart(p.Path)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	name := p.Name
+	if name == "" {
+		name = fmt.Sprintf("%v-%v", ch.GetMetadata().GetName(), time.Now().Unix())
+	}
+	history, err := c.history(name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	version := 1
+	if len(history) != 0 {
+		last := history[0]
+		if last.Info.Status != statusUninstalled {
+			return nil, trace.AlreadyExists("release %v already exists", name)
+		}
+		version = last.Version + 1
+	}
+	namespace := p.Namespace
+	if namespace == "" {
+		namespace = defaults.Namespace
+	}
+	rel, err := c.render(ch, p.Values, p.Set, name, namespace, version, true)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rel.Info.Status = statusPendingInstall
+	rel.Info.Description = "Initial install underway"
+	if err := c.create(*rel); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	err = c.deploy(rel, eventPreInstall, eventPostInstall, func() error {
+		return c.kube.Create(namespace, strings.NewReader(rel.Manifest), timeoutSeconds, false)
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rel.Info.Description = "Install complete"
+	if err := c.update(*rel); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rel.toStorage(), nil
+}
+
+// loadChart loads the chart from the specified path.
+//
+// Charts are rendered with the Helm 2 engine which does not support
+// apiVersion v2 charts: their dependencies, crds directory and library
+// charts would be silently mishandled so such charts are rejected.
+func loadChart(path string) (*chart.Chart, error) {
+	ch, err := chartutil.Load(path)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if apiVersion := ch.GetMetadata().GetApiVersion(); apiVersion != "" && apiVersion != chartutil.ApiVersionV1 {
+		return nil, trace.BadParameter("chart %v has unsupported apiVersion %q, only %q charts are supported",
+			ch.GetMetadata().GetName(), apiVersion, chartutil.ApiVersionV1)
+	}
+	return ch, nil
+}
+
+// List returns list of releases matching provided parameters.
+func (c *helm3Client) List(p ListParameters) ([]storage.Release, error) {
+	var filter *regexp.Regexp
+	if p.Filter != "" {
+		var err error
+		if filter, err = regexp.Compile(p.Filter); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	releases, err := c.list(labels.Set{releaseLabelOwner: releaseOwnerHelm})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	latest := make(map[string]*releaseV3)
+	for _, rel := range releases {
+		if last, ok := latest[rel.Name]; !ok || last.Version < rel.Version {
+			latest[rel.Name] = rel
+		}
+	}
+	var names []string
+	for name := range latest {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result []storage.Release
+	for _, name := range names {
+		rel := latest[name]
+		if filter != nil && !filter.MatchString(name) {
+			continue
+		}
+		if !p.All && !utils.StringInSlice(listedStatuses, rel.Info.Status) {
+			continue
+		}
+		result = append(result, rel.toStorage())
+	}
+	return result, nil
+}
+
+// Get returns a single release with the specified name.
+func (c *helm3Client) Get(name string) (storage.Release, error) {
+	rel, err := c.last(name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rel.toStorage(), nil
+}
+
+// Upgrade upgrades a release.
+func (c *helm3Client) Upgrade(p UpgradeParameters) (storage.Release, error) {
+	current, err := c.last(p.Release)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ch, err := loadChart(p.Path)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rel, err := c.render(ch, p.Values, p.Set, current.Name, current.Namespace, current.Version+1, false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rel.Info.FirstDeployed = current.Info.FirstDeployed
+	rel.Info.Status = statusPendingUpgrade
+	rel.Info.Description = "Preparing upgrade"
+	if err := c.create(*rel); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	err = c.deploy(rel, eventPreUpgrade, eventPostUpgrade, func() error {
+		return c.kube.Update(rel.Namespace, strings.NewReader(current.Manifest),
+			strings.NewReader(rel.Manifest), false, false, timeoutSeconds, false)
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := c.supersede(*current); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rel.Info.Description = "Upgrade complete"
+	if err := c.update(*rel); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rel.toStorage(), nil
+}
+
+// Rollback rolls back a release to the specified version.
+func (c *helm3Client) Rollback(p RollbackParameters) (storage.Release, error) {
+	history, err := c.history(p.Release)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(history) == 0 {
+		return nil, trace.NotFound("release %v not found", p.Release)
+	}
+	current := history[0]
+	var target *releaseV3
+	for _, rel := range history {
+		if rel.Version == p.Revision {
+			target = rel
+			break
+		}
+	}
+	if target == nil {
+		return nil, trace.NotFound("release %v has no revision %v", p.Release, p.Revision)
+	}
+	now := time.Now().UTC()
+	rel := *target
+	rel.Version = current.Version + 1
+	rel.Info = &releaseInfoV3{
+		FirstDeployed: current.Info.FirstDeployed,
+		LastDeployed:  now,
+		Status:        statusPendingRollback,
+		Description:   fmt.Sprintf("Rollback to %v", p.Revision),
+		Notes:         target.Info.Notes,
+	}
+	if err := c.create(rel); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	err = c.deploy(&rel, eventPreRollback, eventPostRollback, func() error {
+		return c.kube.Update(rel.Namespace, strings.NewReader(current.Manifest),
+			strings.NewReader(rel.Manifest), false, false, timeoutSeconds, false)
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := c.supersede(*current); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := c.update(rel); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rel.toStorage(), nil
+}
+
+// Revisions returns revision history for a release with the provided name.
+func (c *helm3Client) Revisions(name string) ([]storage.Release, error) {
+	history, err := c.history(name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(history) == 0 {
+		return nil, trace.NotFound("release %v not found", name)
+	}
+	if len(history) > maxHistory {
+		history = history[:maxHistory]
+	}
+	releases := make([]storage.Release, 0, len(history))
+	for _, rel := range history {
+		releases = append(releases, rel.toStorage())
+	}
+	return releases, nil
+}
+
+// Uninstall uninstalls a release with the provided name.
+//
+// Like Helm 3, removes the release history as well.
+func (c *helm3Client) Uninstall(name string) (storage.Release, error) {
+	rel, err := c.last(name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rel.Info.Status = statusUninstalling
+	rel.Info.Description = "Deletion in progress"
+	if err := c.update(*rel); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	err = c.deploy(rel, eventPreDelete, eventPostDelete, func() error {
+		return c.kube.Delete(rel.Namespace, strings.NewReader(rel.Manifest))
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rel.Info.Status = statusUninstalled
+	rel.Info.Deleted = time.Now().UTC()
+	rel.Info.Description = "Uninstallation complete"
+	err = c.client.CoreV1().Secrets(rel.Namespace).DeleteCollection(nil, metav1.ListOptions{
+		LabelSelector: labels.Set{
+			releaseLabelOwner: releaseOwnerHelm,
+			releaseLabelName:  name,
+		}.String(),
+	})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	return rel.toStorage(), nil
+}
+
+// Close closes the Helm client.
+func (c *helm3Client) Close() error {
+	return nil
+}
+
+// render renders the specified chart into a new release revision
+func (c *helm3Client) render(ch *chart.Chart, valueFiles, setValues []string, name, namespace string, version int, isInstall bool) (*releaseV3, error) {
+	rawVals, err := helmutils.Vals(valueFiles, setValues, nil, nil, "", "", "")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	config := &chart.Config{Raw: string(rawVals)}
+	now := time.Now().UTC()
+	rendered, err := renderutil.Render(ch, config, renderutil.Options{
+		ReleaseOptions: chartutil.ReleaseOptions{
+			Name:      name,
+			Namespace: namespace,
+			Time:      timeconv.Timestamp(now),
+			Revision:  version,
+			IsInstall: isInstall,
+			IsUpgrade: !isInstall,
+		},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	hooks, manifest, notes, err := splitManifests(rendered)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	chart, err := convertChart(ch)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	values, err := readValues(config)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &releaseV3{
+		Name:      name,
+		Namespace: namespace,
+		Version:   version,
+		Chart:     chart,
+		Config:    values,
+		Manifest:  manifest,
+		Hooks:     hooks,
+		Info: &releaseInfoV3{
+			FirstDeployed: now,
+			LastDeployed:  now,
+			Notes:         notes,
+		},
+	}, nil
+}
+
+// deploy executes the specified action surrounded by the release hooks
+// for the given events and records the outcome in the release status
+func (c *helm3Client) deploy(rel *releaseV3, preEvent, postEvent string, action func() error) error {
+	err := c.runHooks(*rel, preEvent)
+	if err == nil {
+		err = action()
+	}
+	if err == nil {
+		err = c.runHooks(*rel, postEvent)
+	}
+	if err != nil {
+		rel.Info.Status = statusFailed
+		rel.Info.Description = fmt.Sprintf("Release %q failed: %v", rel.Name, err)
+		if errUpdate := c.update(*rel); errUpdate != nil {
+			c.WithError(errUpdate).Warn("Failed to update release status.")
+		}
+		return trace.Wrap(err)
+	}
+	rel.Info.Status = statusDeployed
+	return nil
+}
+
+// runHooks executes release hooks for the specified event in the order of their weight
+func (c *helm3Client) runHooks(rel releaseV3, event string) error {
+	var hooks []*hookV3
+	for _, hook := range rel.Hooks {
+		if hook.hasEvent(event) {
+			hooks = append(hooks, hook)
+		}
+	}
+	sort.SliceStable(hooks, func(i, j int) bool {
+		if hooks[i].Weight != hooks[j].Weight {
+			return hooks[i].Weight < hooks[j].Weight
+		}
+		return hooks[i].Name < hooks[j].Name
+	})
+	for _, hook := range hooks {
+		c.Infof("Executing %v hook %v/%v.", event, hook.Kind, hook.Name)
+		if hook.hasDeletePolicy(hookBeforeCreation) {
+			c.deleteHook(rel.Namespace, *hook)
+		}
+		err := c.kube.Create(rel.Namespace, strings.NewReader(hook.Manifest), timeoutSeconds, false)
+		if err == nil {
+			err = c.kube.WatchUntilReady(rel.Namespace, strings.NewReader(hook.Manifest), timeoutSeconds, false)
+		}
+		if err != nil {
+			if hook.hasDeletePolicy(hookFailed) {
+				c.deleteHook(rel.Namespace, *hook)
+			}
+			return trace.Wrap(err, "%v hook %v failed", event, hook.Name)
+		}
+		if hook.hasDeletePolicy(hookSucceeded) {
+			c.deleteHook(rel.Namespace, *hook)
+		}
+	}
+	return nil
+}
+
+func (c *helm3Client) deleteHook(namespace string, hook hookV3) {
+	err := c.kube.Delete(namespace, strings.NewReader(hook.Manifest))
+	if err != nil {
+		c.WithError(err).Warnf("Failed to delete hook %v.", hook.Name)
+	}
+}
+
+// last returns the most recent revision of the specified release
+func (c *helm3Client) last(name string) (*releaseV3, error) {
+	history, err := c.history(name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(history) == 0 || history[0].Info.Status == statusUninstalled {
+		return nil, trace.NotFound("release %v not found", name)
+	}
+	return history[0], nil
+}
+
+// history returns all revisions of the specified release, newest first
+func (c *helm3Client) history(name string) ([]*releaseV3, error) {
+	releases, err := c.list(labels.Set{
+		releaseLabelOwner: releaseOwnerHelm,
+		releaseLabelName:  name,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Version > releases[j].Version
+	})
+	return releases, nil
+}
+
+// list returns releases from all namespaces matching the specified labels
+func (c *helm3Client) list(set labels.Set) (releases []*releaseV3, err error) {
+	secrets, err := c.client.CoreV1().Secrets(metav1.NamespaceAll).List(metav1.ListOptions{
+		LabelSelector: set.String(),
+	})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	for _, secret := range secrets.Items {
+		rel, err := decodeRelease(string(secret.Data[releaseDataKey]))
+		if err != nil {
+			c.WithError(err).Warnf("Failed to decode release %v/%v.", secret.Namespace, secret.Name)
+			continue
+		}
+		if rel.Info == nil {
+			rel.Info = &releaseInfoV3{}
+		}
+		releases = append(releases, rel)
+	}
+	return releases, nil
+}
+
+func (c *helm3Client) create(rel releaseV3) error {
+	secret, err := newReleaseSecret(rel)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = c.client.CoreV1().Secrets(rel.Namespace).Create(secret)
+	return trace.Wrap(rigging.ConvertError(err))
+}
+
+func (c *helm3Client) update(rel releaseV3) error {
+	secret, err := newReleaseSecret(rel)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = c.client.CoreV1().Secrets(rel.Namespace).Update(secret)
+	return trace.Wrap(rigging.ConvertError(err))
+}
+
+func (c *helm3Client) supersede(rel releaseV3) error {
+	rel.Info.Status = statusSuperseded
+	return trace.Wrap(c.update(rel))
+}
+
+// splitManifests splits the rendered chart templates into hooks and the release
+// manifest with resources sorted in the installation order.
+// Returns the rendered chart notes as well
+func splitManifests(templates map[string]string) (hooks []*hookV3, manifest string, notes string, err error) {
+	var paths []string
+	for path := range templates {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	type resource struct {
+		kind    string
+		content string
+	}
+	var resources []resource
+	for _, path := range paths {
+		content := templates[path]
+		filename := filepath.Base(path)
+		if filename == notesFilename {
+			notes = content
+			continue
+		}
+		if strings.HasPrefix(filename, "_") {
+			continue
+		}
+		docs := releaseutil.SplitManifests(content)
+		var keys []string
+		for key := range docs {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return manifestIndex(keys[i]) < manifestIndex(keys[j])
+		})
+		for _, key := range keys {
+			doc := docs[key]
+			var head releaseutil.SimpleHead
+			if err := yaml.Unmarshal([]byte(doc), &head); err != nil {
+				return nil, "", "", trace.Wrap(err, "failed to parse %v", path)
+			}
+			if head.Kind == "" {
+				// Skip empty documents, e.g. templates disabled by a condition
+				continue
+			}
+			var annotations map[string]string
+			var name string
+			if head.Metadata != nil {
+				annotations = head.Metadata.Annotations
+				name = head.Metadata.Name
+			}
+			events, ok := annotations[hookAnnotation]
+			if !ok {
+				resources = append(resources, resource{
+					kind:    head.Kind,
+					content: fmt.Sprintf("---\n# Source: %v\n%v\n", path, doc),
+				})
+				continue
+			}
+			hook := &hookV3{
+				Name:     name,
+				Kind:     head.Kind,
+				Path:     path,
+				Manifest: doc,
+				Events:   splitAnnotation(events),
+			}
+			if weight, ok := annotations[hookWeightAnnotation]; ok {
+				if hook.Weight, err = strconv.Atoi(strings.TrimSpace(weight)); err != nil {
+					return nil, "", "", trace.BadParameter("invalid weight %q of hook %v", weight, name)
+				}
+			}
+			hook.DeletePolicies = splitAnnotation(annotations[hookDeletePolicyAnnotation])
+			hooks = append(hooks, hook)
+		}
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return kindIndex(resources[i].kind) < kindIndex(resources[j].kind)
+	})
+	var buf bytes.Buffer
+	for _, resource := range resources {
+		buf.WriteString(resource.content)
+	}
+	return hooks, buf.String(), notes, nil
+}
+
+func splitAnnotation(value string) (result []string) {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// manifestIndex returns the index of the document as named by releaseutil.SplitManifests
+func manifestIndex(key string) int {
+	index, _ := strconv.Atoi(strings.TrimPrefix(key, "manifest-"))
+	return index
+}
+
+// kindIndex returns the position of the specified resource kind in the installation order
+func kindIndex(kind string) int {
+	for i, k := range installOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(installOrder)
+}
+
+// restClientGetter implements genericclioptions.RESTClientGetter for the specified config
+type restClientGetter struct {
+	config *rest.Config
+}
+
+// ToRESTConfig returns the client configuration
+func (r *restClientGetter) ToRESTConfig() (*rest.Config, error) {
+	return rest.CopyConfig(r.config), nil
+}
+
+// ToDiscoveryClient returns the memory-cached discovery client
+func (r *restClientGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	client, err := discovery.NewDiscoveryClientForConfig(r.config)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return cachedDiscovery{DiscoveryInterface: client}, nil
+}
+
+// ToRESTMapper returns the REST mapper based on API discovery
+func (r *restClientGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	client, err := r.ToDiscoveryClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return restmapper.NewDeferredDiscoveryRESTMapper(client), nil
+}
+
+// ToRawKubeConfigLoader returns the client configuration loader
+func (r *restClientGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	return clientcmd.NewDefaultClientConfig(*clientcmdapi.NewConfig(), &clientcmd.ConfigOverrides{
+		Context: clientcmdapi.Context{Namespace: defaults.Namespace},
+	})
+}
+
+// cachedDiscovery is the discovery client that does not cache
+type cachedDiscovery struct {
+	discovery.DiscoveryInterface
+}
+
+// Fresh returns true since the results are never cached
+func (cachedDiscovery) Fresh() bool { return true }
+
+// Invalidate is a no-op
+func (cachedDiscovery) Invalidate() {}
+
+var _ genericclioptions.RESTClientGetter = &restClientGetter{}
+
+// listedStatuses enumerates release statuses displayed by default.
+var listedStatuses = []string{
+	statusDeployed,
+	statusFailed,
+	statusUninstalling,
+	statusPendingInstall,
+	statusPendingUpgrade,
+	statusPendingRollback,
+}
+
+// installOrder defines the order in which resources are installed
+var installOrder = []string{
+	"Namespace",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"Secret",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"ServiceAccount",
+	"CustomResourceDefinition",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"Ingress",
+	"APIService",
+}
+
+const (
+	eventPreInstall   = "pre-install"
+	eventPostInstall  = "post-install"
+	eventPreDelete    = "pre-delete"
+	eventPostDelete   = "post-delete"
+	eventPreUpgrade   = "pre-upgrade"
+	eventPostUpgrade  = "post-upgrade"
+	eventPreRollback  = "pre-rollback"
+	eventPostRollback = "post-rollback"
+
+	hookAnnotation             = "helm.sh/hook"
+	hookWeightAnnotation       = "helm.sh/hook-weight"
+	hookDeletePolicyAnnotation = "helm.sh/hook-delete-policy"
+
+	notesFilename = "NOTES.txt"
+
+	// timeoutSeconds is the timeout for hooks to complete
+	timeoutSeconds = 300
+)
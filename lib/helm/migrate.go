@@ -0,0 +1,195 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"sort"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/helm/pkg/proto/hapi/release"
+)
+
+// MigrateParameters defines parameters for migrating Tiller releases.
+type MigrateParameters struct {
+	// DNSAddress is an optional in-cluster DNS address.
+	DNSAddress string
+	// DryRun only lists the releases to migrate.
+	DryRun bool
+	// Cleanup removes the Tiller release records after migration.
+	Cleanup bool
+}
+
+// MigrateReleases converts the releases stored by Tiller to the Helm 3 format.
+//
+// Releases that have been uninstalled are not migrated since Helm 3
+// does not keep the history of uninstalled releases. Revisions that
+// have already been migrated are skipped.
+// Once all releases have been converted, the Helm 3 backend is recorded
+// for the cluster and Tiller is scaled down. The Tiller release records
+// are only removed after that.
+// Returns the list of migrated release revisions.
+func MigrateReleases(p MigrateParameters) ([]storage.Release, error) {
+	client, _, err := getKubeClient(p.DNSAddress)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return migrateReleases(client, p)
+}
+
+func migrateReleases(client kubernetes.Interface, p MigrateParameters) (migrated []storage.Release, err error) {
+	logger := logrus.WithField(trace.Component, "helm:migrate")
+	configMaps, err := client.CoreV1().ConfigMaps(metav1.NamespaceSystem).List(metav1.ListOptions{
+		LabelSelector: labels.Set{"OWNER": releaseOwnerTiller}.String(),
+	})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	history, err := tillerHistory(configMaps.Items)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var converted []string
+	for _, revisions := range history {
+		if revisions[0].release.GetInfo().GetStatus().GetCode() == release.Status_DELETED {
+			logger.Infof("Skip uninstalled release %v.", revisions[0].release.GetName())
+			continue
+		}
+		for _, revision := range revisions {
+			rel, err := convertRelease(revision.release)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			migrated = append(migrated, rel.toStorage())
+			if p.DryRun {
+				continue
+			}
+			secret, err := newReleaseSecret(*rel)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			_, err = client.CoreV1().Secrets(rel.Namespace).Create(secret)
+			err = rigging.ConvertError(err)
+			if err != nil && !trace.IsAlreadyExists(err) {
+				return nil, trace.Wrap(err)
+			}
+			converted = append(converted, revision.configMap)
+		}
+	}
+	if p.DryRun {
+		return migrated, nil
+	}
+	// The backend has to be switched before the Tiller records are removed,
+	// otherwise the cluster stays on Helm 2 and the releases disappear
+	if err := recordBackend(client, Helm3); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := stopTiller(client); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !p.Cleanup {
+		return migrated, nil
+	}
+	for _, configMap := range converted {
+		err = client.CoreV1().ConfigMaps(metav1.NamespaceSystem).Delete(configMap, nil)
+		err = rigging.ConvertError(err)
+		if err != nil && !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return migrated, nil
+}
+
+// stopTiller scales down the Tiller deployments once the cluster
+// has been switched to the Helm 3 backend
+func stopTiller(client kubernetes.Interface) error {
+	deployments := client.AppsV1().Deployments(metav1.NamespaceSystem)
+	list, err := deployments.List(metav1.ListOptions{
+		LabelSelector: tillerSelector.String(),
+	})
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	for _, deployment := range list.Items {
+		if !tillerRunning([]appsv1.Deployment{deployment}) {
+			continue
+		}
+		var replicas int32
+		deployment.Spec.Replicas = &replicas
+		_, err = deployments.Update(&deployment)
+		if err != nil {
+			return trace.Wrap(rigging.ConvertError(err))
+		}
+		logrus.Infof("Scaled down %v/%v.", deployment.Namespace, deployment.Name)
+	}
+	return nil
+}
+
+// tillerRunning returns true if any of the specified Tiller deployments
+// has not been scaled down
+func tillerRunning(deployments []appsv1.Deployment) bool {
+	for _, deployment := range deployments {
+		if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// tillerHistory decodes the specified Tiller release records and returns
+// the revisions grouped by release, newest first
+func tillerHistory(configMaps []v1.ConfigMap) (history [][]tillerRevision, err error) {
+	releases := make(map[string][]tillerRevision)
+	for _, configMap := range configMaps {
+		rel, err := decodeTillerRelease(configMap.Data[releaseDataKey])
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to decode release %v", configMap.Name)
+		}
+		releases[rel.GetName()] = append(releases[rel.GetName()], tillerRevision{
+			release:   rel,
+			configMap: configMap.Name,
+		})
+	}
+	var names []string
+	for name := range releases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		revisions := releases[name]
+		sort.Slice(revisions, func(i, j int) bool {
+			return revisions[i].release.GetVersion() > revisions[j].release.GetVersion()
+		})
+		history = append(history, revisions)
+	}
+	return history, nil
+}
+
+// tillerRevision is a release revision stored by Tiller
+type tillerRevision struct {
+	release *release.Release
+	// configMap is the name of the ConfigMap with the release record
+	configMap string
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	helmutils "github.com/gravitational/gravity/lib/utils/helm"
+
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/openpgp"
+	"k8s.io/helm/pkg/chartutil"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+	"k8s.io/helm/pkg/provenance"
+)
+
+// NewSignatory returns a chart signatory that uses the key with the
+// specified name from the provided secret keyring.
+//
+// If the key is encrypted, the passphrase is read from passphraseFile.
+func NewSignatory(keyring, name, passphraseFile string) (*provenance.Signatory, error) {
+	signatory, err := provenance.NewFromKeyring(keyring, name)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to read keyring %v", keyring)
+	}
+	if signatory.Entity == nil {
+		return nil, trace.NotFound("no key %q found in keyring %v", name, keyring)
+	}
+	if signatory.Entity.PrivateKey == nil {
+		return nil, trace.BadParameter("key %q in keyring %v has no private key", name, keyring)
+	}
+	if !signatory.Entity.PrivateKey.Encrypted {
+		return signatory, nil
+	}
+	if passphraseFile == "" {
+		return nil, trace.BadParameter("key %q is encrypted, provide a passphrase file", name)
+	}
+	passphrase, err := ioutil.ReadFile(passphraseFile)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	err = signatory.DecryptKey(func(string) ([]byte, error) {
+		return []byte(strings.TrimSpace(string(passphrase))), nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to decrypt key %q", name)
+	}
+	return signatory, nil
+}
+
+// SignChart signs the chart in the specified directory and returns
+// the contents of the provenance file.
+//
+// The signature covers the chart archive produced by SaveChart so
+// it stays valid when the chart is repackaged from the same directory.
+func SignChart(dir string, signatory *provenance.Signatory) ([]byte, error) {
+	chartDir, err := ioutil.TempDir("", "chart")
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer os.RemoveAll(chartDir)
+	chartPath, err := saveChartDir(dir, chartDir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	signature, err := signatory.ClearSign(chartPath)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to sign chart")
+	}
+	return []byte(signature), nil
+}
+
+// VerifyChart verifies the chart in the specified directory against the
+// provenance data using the provided keyring of trusted keys.
+func VerifyChart(dir string, provenanceData []byte, keyring openpgp.EntityList) (*provenance.Verification, error) {
+	chartDir, err := ioutil.TempDir("", "chart")
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer os.RemoveAll(chartDir)
+	chartPath, err := saveChartDir(dir, chartDir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	provenancePath := chartPath + ".prov"
+	err = ioutil.WriteFile(provenancePath, provenanceData, 0644)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	signatory := &provenance.Signatory{KeyRing: keyring}
+	verification, err := signatory.Verify(chartPath, provenancePath)
+	if err != nil {
+		return nil, trace.BadParameter("chart verification failed: %v", err)
+	}
+	return verification, nil
+}
+
+// SaveChart writes the provided chart as a compressed archive into the
+// specified directory and returns the path to the archive.
+//
+// Unlike chartutil.Save, the archive is reproducible: saving the same
+// chart always produces the same bytes.
+func SaveChart(c *chart.Chart, outDir string) (string, error) {
+	if c.Metadata == nil {
+		return "", trace.BadParameter("no Chart.yaml data")
+	}
+	chartPath := filepath.Join(outDir, helmutils.ToChartFilename(
+		c.Metadata.Name, c.Metadata.Version))
+	f, err := os.Create(chartPath)
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	zipper := gzip.NewWriter(f)
+	zipper.Header.Comment = "Helm"
+	writer := tar.NewWriter(zipper)
+	if err := writeChart(writer, c, ""); err != nil {
+		return "", trace.Wrap(err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", trace.Wrap(err)
+	}
+	if err := zipper.Close(); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return chartPath, nil
+}
+
+func saveChartDir(dir, outDir string) (string, error) {
+	chart, err := chartutil.LoadDir(dir)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return SaveChart(chart, outDir)
+}
+
+func writeChart(w *tar.Writer, c *chart.Chart, prefix string) error {
+	base := path.Join(prefix, c.Metadata.Name)
+	data, err := yaml.Marshal(c.Metadata)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := writeChartFile(w, path.Join(base, chartutil.ChartfileName), data); err != nil {
+		return trace.Wrap(err)
+	}
+	if c.Values != nil && len(c.Values.Raw) > 0 {
+		err := writeChartFile(w, path.Join(base, chartutil.ValuesfileName), []byte(c.Values.Raw))
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	for _, template := range c.Templates {
+		if err := writeChartFile(w, path.Join(base, template.Name), template.Data); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	for _, file := range c.Files {
+		if err := writeChartFile(w, path.Join(base, file.TypeUrl), file.Value); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	for _, dependency := range c.Dependencies {
+		if err := writeChart(w, dependency, path.Join(base, "charts")); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func writeChartFile(w *tar.Writer, name string, data []byte) error {
+	err := w.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: chartModTime,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = w.Write(data)
+	return trace.Wrap(err)
+}
+
+// chartModTime is the modification time of all files in chart archives
+var chartModTime = time.Unix(0, 0)
@@ -0,0 +1,105 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/openpgp"
+	check "gopkg.in/check.v1"
+	"k8s.io/helm/pkg/chartutil"
+	"k8s.io/helm/pkg/provenance"
+)
+
+type ProvenanceSuite struct {
+	dir    string
+	signer *openpgp.Entity
+}
+
+var _ = check.Suite(&ProvenanceSuite{})
+
+func (s *ProvenanceSuite) SetUpSuite(c *check.C) {
+	var err error
+	s.signer, err = openpgp.NewEntity("Test Signer", "", "signer@example.com", nil)
+	c.Assert(err, check.IsNil)
+}
+
+func (s *ProvenanceSuite) SetUpTest(c *check.C) {
+	s.dir = c.MkDir()
+	writeFile(c, s.dir, "Chart.yaml", "name: web\nversion: 0.0.1\n")
+	writeFile(c, s.dir, "values.yaml", "replicas: 1\n")
+	writeFile(c, s.dir, "templates/deployment.yaml", "kind: Deployment\n")
+}
+
+func (s *ProvenanceSuite) TestSavesReproducibleArchives(c *check.C) {
+	chart, err := chartutil.LoadDir(s.dir)
+	c.Assert(err, check.IsNil)
+
+	first, err := SaveChart(chart, c.MkDir())
+	c.Assert(err, check.IsNil)
+	second, err := SaveChart(chart, c.MkDir())
+	c.Assert(err, check.IsNil)
+	c.Assert(filepath.Base(first), check.Equals, "web-0.0.1.tgz")
+
+	firstDigest, err := provenance.DigestFile(first)
+	c.Assert(err, check.IsNil)
+	secondDigest, err := provenance.DigestFile(second)
+	c.Assert(err, check.IsNil)
+	c.Assert(firstDigest, check.Equals, secondDigest)
+
+	loaded, err := chartutil.Load(first)
+	c.Assert(err, check.IsNil)
+	c.Assert(loaded.Metadata.Name, check.Equals, "web")
+	c.Assert(loaded.Templates, check.HasLen, 1)
+}
+
+func (s *ProvenanceSuite) TestVerifiesSignedChart(c *check.C) {
+	data, err := SignChart(s.dir, &provenance.Signatory{Entity: s.signer})
+	c.Assert(err, check.IsNil)
+
+	verification, err := VerifyChart(s.dir, data, openpgp.EntityList{s.signer})
+	c.Assert(err, check.IsNil)
+	c.Assert(verification.FileName, check.Equals, "web-0.0.1.tgz")
+	c.Assert(verification.SignedBy.PrimaryKey.KeyId, check.Equals, s.signer.PrimaryKey.KeyId)
+}
+
+func (s *ProvenanceSuite) TestRejectsModifiedChart(c *check.C) {
+	data, err := SignChart(s.dir, &provenance.Signatory{Entity: s.signer})
+	c.Assert(err, check.IsNil)
+
+	writeFile(c, s.dir, "templates/deployment.yaml", "kind: DaemonSet\n")
+	_, err = VerifyChart(s.dir, data, openpgp.EntityList{s.signer})
+	c.Assert(err, check.ErrorMatches, "chart verification failed: .*")
+}
+
+func (s *ProvenanceSuite) TestRejectsUntrustedSigner(c *check.C) {
+	data, err := SignChart(s.dir, &provenance.Signatory{Entity: s.signer})
+	c.Assert(err, check.IsNil)
+
+	other, err := openpgp.NewEntity("Other Signer", "", "other@example.com", nil)
+	c.Assert(err, check.IsNil)
+	_, err = VerifyChart(s.dir, data, openpgp.EntityList{other})
+	c.Assert(err, check.ErrorMatches, "chart verification failed: .*")
+}
+
+func writeFile(c *check.C, dir, path, data string) {
+	path = filepath.Join(dir, path)
+	c.Assert(os.MkdirAll(filepath.Dir(path), 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(path, []byte(data), 0644), check.IsNil)
+}
@@ -0,0 +1,471 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/helm/pkg/chartutil"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+	"k8s.io/helm/pkg/proto/hapi/release"
+	"k8s.io/helm/pkg/timeconv"
+)
+
+// releaseV3 is the release record in the format used by Helm 3.
+//
+// Helm 3 stores each release revision as a Secret in the release
+// namespace with the gzipped JSON representation of this record.
+type releaseV3 struct {
+	// Name is the release name
+	Name string `json:"name,omitempty"`
+	// Info provides information about the release
+	Info *releaseInfoV3 `json:"info,omitempty"`
+	// Chart is the chart that was released
+	Chart *chartV3 `json:"chart,omitempty"`
+	// Config is the set of values supplied to override chart values
+	Config map[string]interface{} `json:"config,omitempty"`
+	// Manifest is the rendered release manifest without hooks
+	Manifest string `json:"manifest,omitempty"`
+	// Hooks lists release hooks
+	Hooks []*hookV3 `json:"hooks,omitempty"`
+	// Version is the release revision
+	Version int `json:"version,omitempty"`
+	// Namespace is the namespace the release is deployed to
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// releaseInfoV3 describes the release status
+type releaseInfoV3 struct {
+	// FirstDeployed is when the release was first deployed
+	FirstDeployed time.Time `json:"first_deployed,omitempty"`
+	// LastDeployed is when the release was last deployed
+	LastDeployed time.Time `json:"last_deployed,omitempty"`
+	// Deleted tracks when this release was deleted
+	Deleted time.Time `json:"deleted"`
+	// Description is a human-friendly description of the release
+	Description string `json:"description,omitempty"`
+	// Status is the release status
+	Status string `json:"status,omitempty"`
+	// Notes is the rendered chart notes
+	Notes string `json:"notes,omitempty"`
+}
+
+// chartV3 is the chart as recorded in a Helm 3 release
+type chartV3 struct {
+	// Metadata is the chart metadata
+	Metadata *chartMetadataV3 `json:"metadata"`
+	// Templates lists chart templates
+	Templates []*fileV3 `json:"templates"`
+	// Values are the default chart values
+	Values map[string]interface{} `json:"values"`
+	// Files lists miscellaneous chart files
+	Files []*fileV3 `json:"files"`
+}
+
+// chartMetadataV3 is the chart metadata as recorded in a Helm 3 release
+type chartMetadataV3 struct {
+	Name        string   `json:"name,omitempty"`
+	Home        string   `json:"home,omitempty"`
+	Sources     []string `json:"sources,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	Icon        string   `json:"icon,omitempty"`
+	APIVersion  string   `json:"apiVersion,omitempty"`
+	AppVersion  string   `json:"appVersion,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	KubeVersion string   `json:"kubeVersion,omitempty"`
+}
+
+// fileV3 is a named chart file
+type fileV3 struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+// hookV3 is the release hook as recorded in a Helm 3 release
+type hookV3 struct {
+	// Name is the name of the hook resource
+	Name string `json:"name,omitempty"`
+	// Kind is the kind of the hook resource
+	Kind string `json:"kind,omitempty"`
+	// Path is the chart-relative path to the template
+	Path string `json:"path,omitempty"`
+	// Manifest is the manifest of the hook resource
+	Manifest string `json:"manifest,omitempty"`
+	// Events lists events that trigger the hook
+	Events []string `json:"events,omitempty"`
+	// Weight defines the order of hook execution
+	Weight int `json:"weight,omitempty"`
+	// DeletePolicies lists the policies to delete the hook resource
+	DeletePolicies []string `json:"delete_policies,omitempty"`
+}
+
+// hasEvent returns true if the hook is triggered by the specified event
+func (r hookV3) hasEvent(event string) bool {
+	for _, e := range r.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDeletePolicy returns true if the hook has the specified delete policy
+func (r hookV3) hasDeletePolicy(policy string) bool {
+	for _, p := range r.DeletePolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// toStorage returns the release resource for this release.
+//
+// Release statuses are reported using the Helm 2 status codes
+// so the release views do not depend on the release backend
+func (r releaseV3) toStorage() storage.Release {
+	var metadata chartMetadataV3
+	if r.Chart != nil && r.Chart.Metadata != nil {
+		metadata = *r.Chart.Metadata
+	}
+	var info releaseInfoV3
+	if r.Info != nil {
+		info = *r.Info
+	}
+	return &storage.ReleaseV1{
+		Kind:    storage.KindRelease,
+		Version: services.V1,
+		Metadata: services.Metadata{
+			Name:        r.Name,
+			Description: metadata.Description,
+		},
+		Spec: storage.ReleaseSpecV1{
+			ChartName:    metadata.Name,
+			ChartVersion: metadata.Version,
+			AppVersion:   metadata.AppVersion,
+			Namespace:    r.Namespace,
+		},
+		Status: storage.ReleaseStatusV1{
+			Status:   statusToV2(info.Status),
+			Revision: r.Version,
+			Updated:  time.Unix(info.LastDeployed.Unix(), 0),
+		},
+		Manifest: r.Manifest,
+	}
+}
+
+// newReleaseSecret returns the Secret that stores the specified release
+func newReleaseSecret(r releaseV3) (*v1.Secret, error) {
+	data, err := encodeRelease(r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var status string
+	if r.Info != nil {
+		status = r.Info.Status
+	}
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      releaseSecretName(r.Name, r.Version),
+			Namespace: r.Namespace,
+			Labels: map[string]string{
+				releaseLabelName:    r.Name,
+				releaseLabelOwner:   releaseOwnerHelm,
+				releaseLabelStatus:  status,
+				releaseLabelVersion: strconv.Itoa(r.Version),
+			},
+		},
+		Type: releaseSecretType,
+		Data: map[string][]byte{releaseDataKey: []byte(data)},
+	}, nil
+}
+
+// releaseSecretName returns the name of the Secret for the specified release revision
+func releaseSecretName(name string, version int) string {
+	return fmt.Sprintf("%v.%v.v%v", releaseSecretPrefix, name, version)
+}
+
+// encodeRelease encodes the release as base64 encoded gzipped JSON
+func encodeRelease(r releaseV3) (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", trace.Wrap(err)
+	}
+	if err := w.Close(); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decodeRelease decodes the release encoded with encodeRelease
+func decodeRelease(data string) (*releaseV3, error) {
+	bytes, err := decompress(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var r releaseV3
+	if err := json.Unmarshal(bytes, &r); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &r, nil
+}
+
+// decodeTillerRelease decodes the release stored by Tiller
+func decodeTillerRelease(data string) (*release.Release, error) {
+	bytes, err := decompress(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var r release.Release
+	if err := proto.Unmarshal(bytes, &r); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &r, nil
+}
+
+// decompress decodes the base64 encoded and optionally gzipped data
+func decompress(data string) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !bytes.HasPrefix(decoded, gzipMagic) {
+		return decoded, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(decoded))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer r.Close()
+	decompressed, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return decompressed, nil
+}
+
+// convertRelease converts the Tiller release to the Helm 3 format
+func convertRelease(r *release.Release) (*releaseV3, error) {
+	config, err := readValues(r.GetConfig())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	chart, err := convertChart(r.GetChart())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var hooks []*hookV3
+	for _, hook := range r.GetHooks() {
+		hooks = append(hooks, convertHook(hook))
+	}
+	info := r.GetInfo()
+	return &releaseV3{
+		Name: r.GetName(),
+		Info: &releaseInfoV3{
+			FirstDeployed: timeconv.Time(info.GetFirstDeployed()),
+			LastDeployed:  timeconv.Time(info.GetLastDeployed()),
+			Deleted:       timestampTime(info.GetDeleted()),
+			Description:   info.GetDescription(),
+			Status:        statusToV3(info.GetStatus().GetCode()),
+			Notes:         info.GetStatus().GetNotes(),
+		},
+		Chart:     chart,
+		Config:    config,
+		Manifest:  r.GetManifest(),
+		Hooks:     hooks,
+		Version:   int(r.GetVersion()),
+		Namespace: r.GetNamespace(),
+	}, nil
+}
+
+// convertChart converts the chart to the Helm 3 format
+func convertChart(c *chart.Chart) (*chartV3, error) {
+	values, err := readValues(c.GetValues())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	md := c.GetMetadata()
+	result := &chartV3{
+		Metadata: &chartMetadataV3{
+			Name:        md.GetName(),
+			Home:        md.GetHome(),
+			Sources:     md.GetSources(),
+			Version:     md.GetVersion(),
+			Description: md.GetDescription(),
+			Keywords:    md.GetKeywords(),
+			Icon:        md.GetIcon(),
+			APIVersion:  md.GetApiVersion(),
+			AppVersion:  md.GetAppVersion(),
+			Deprecated:  md.GetDeprecated(),
+			KubeVersion: md.GetKubeVersion(),
+		},
+		Values: values,
+	}
+	for _, template := range c.GetTemplates() {
+		result.Templates = append(result.Templates, &fileV3{
+			Name: template.GetName(),
+			Data: template.GetData(),
+		})
+	}
+	for _, file := range c.GetFiles() {
+		result.Files = append(result.Files, &fileV3{
+			Name: file.GetTypeUrl(),
+			Data: file.GetValue(),
+		})
+	}
+	return result, nil
+}
+
+// convertHook converts the release hook to the Helm 3 format
+func convertHook(h *release.Hook) *hookV3 {
+	hook := &hookV3{
+		Name:     h.GetName(),
+		Kind:     h.GetKind(),
+		Path:     h.GetPath(),
+		Manifest: h.GetManifest(),
+		Weight:   int(h.GetWeight()),
+	}
+	for _, event := range h.GetEvents() {
+		// PRE_INSTALL -> pre-install
+		hook.Events = append(hook.Events,
+			strings.Replace(strings.ToLower(event.String()), "_", "-", -1))
+	}
+	for _, policy := range h.GetDeletePolicies() {
+		hook.DeletePolicies = append(hook.DeletePolicies, deletePoliciesV3[policy])
+	}
+	return hook
+}
+
+// readValues parses the values from the specified config
+func readValues(config *chart.Config) (map[string]interface{}, error) {
+	if config == nil || config.Raw == "" {
+		return nil, nil
+	}
+	values, err := chartutil.ReadValues([]byte(config.Raw))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return values, nil
+}
+
+// timestampTime converts the optional timestamp to time
+func timestampTime(ts *timestamp.Timestamp) time.Time {
+	if ts.GetSeconds() == 0 && ts.GetNanos() == 0 {
+		return time.Time{}
+	}
+	return timeconv.Time(ts)
+}
+
+// statusToV3 returns the Helm 3 status for the specified Helm 2 status code
+func statusToV3(code release.Status_Code) string {
+	for v3, v2 := range statusesV3 {
+		if v2 == code {
+			return v3
+		}
+	}
+	return statusUnknown
+}
+
+// statusToV2 returns the Helm 2 status name for the specified Helm 3 status
+func statusToV2(status string) string {
+	code, ok := statusesV3[status]
+	if !ok {
+		return release.Status_UNKNOWN.String()
+	}
+	return code.String()
+}
+
+// statusesV3 maps Helm 3 release statuses to Helm 2 status codes
+var statusesV3 = map[string]release.Status_Code{
+	statusUnknown:         release.Status_UNKNOWN,
+	statusDeployed:        release.Status_DEPLOYED,
+	statusUninstalled:     release.Status_DELETED,
+	statusSuperseded:      release.Status_SUPERSEDED,
+	statusFailed:          release.Status_FAILED,
+	statusUninstalling:    release.Status_DELETING,
+	statusPendingInstall:  release.Status_PENDING_INSTALL,
+	statusPendingUpgrade:  release.Status_PENDING_UPGRADE,
+	statusPendingRollback: release.Status_PENDING_ROLLBACK,
+}
+
+// deletePoliciesV3 maps Helm 2 hook delete policies to Helm 3
+var deletePoliciesV3 = map[release.Hook_DeletePolicy]string{
+	release.Hook_SUCCEEDED:            hookSucceeded,
+	release.Hook_FAILED:               hookFailed,
+	release.Hook_BEFORE_HOOK_CREATION: hookBeforeCreation,
+}
+
+const (
+	statusUnknown         = "unknown"
+	statusDeployed        = "deployed"
+	statusUninstalled     = "uninstalled"
+	statusSuperseded      = "superseded"
+	statusFailed          = "failed"
+	statusUninstalling    = "uninstalling"
+	statusPendingInstall  = "pending-install"
+	statusPendingUpgrade  = "pending-upgrade"
+	statusPendingRollback = "pending-rollback"
+
+	hookSucceeded      = "hook-succeeded"
+	hookFailed         = "hook-failed"
+	hookBeforeCreation = "before-hook-creation"
+
+	// releaseSecretPrefix is the name prefix of release Secrets
+	releaseSecretPrefix = "sh.helm.release.v1"
+	// releaseSecretType is the type of release Secrets
+	releaseSecretType = v1.SecretType("helm.sh/release.v1")
+	// releaseDataKey is the key of the release record in the release Secret
+	releaseDataKey = "release"
+
+	releaseLabelName    = "name"
+	releaseLabelOwner   = "owner"
+	releaseLabelStatus  = "status"
+	releaseLabelVersion = "version"
+	// releaseOwnerHelm is the owner label value of Helm 3 release Secrets
+	releaseOwnerHelm = "helm"
+	// releaseOwnerTiller is the owner label value of Tiller release ConfigMaps
+	releaseOwnerTiller = "TILLER"
+)
+
+// gzipMagic is the header of gzip compressed data
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
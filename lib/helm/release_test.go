@@ -0,0 +1,243 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/golang/protobuf/proto"
+	check "gopkg.in/check.v1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+	"k8s.io/helm/pkg/proto/hapi/release"
+	"k8s.io/helm/pkg/timeconv"
+)
+
+type ReleaseSuite struct{}
+
+var _ = check.Suite(&ReleaseSuite{})
+
+func (s *ReleaseSuite) TestConvertsTillerRelease(c *check.C) {
+	deployed := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	tillerRelease := newTillerRelease("web", 2, release.Status_DEPLOYED, deployed)
+
+	rel, err := convertRelease(tillerRelease)
+	c.Assert(err, check.IsNil)
+	c.Assert(rel.Name, check.Equals, "web")
+	c.Assert(rel.Namespace, check.Equals, "default")
+	c.Assert(rel.Version, check.Equals, 2)
+	c.Assert(rel.Info.Status, check.Equals, statusDeployed)
+	c.Assert(rel.Config, check.DeepEquals, map[string]interface{}{"replicas": float64(2)})
+	c.Assert(rel.Chart.Values, check.DeepEquals, map[string]interface{}{"replicas": float64(1)})
+	c.Assert(rel.Hooks, check.DeepEquals, []*hookV3{{
+		Name:           "web-init",
+		Kind:           "Job",
+		Path:           "web/templates/init.yaml",
+		Manifest:       "kind: Job",
+		Events:         []string{eventPreInstall, eventPreUpgrade},
+		Weight:         1,
+		DeletePolicies: []string{hookSucceeded},
+	}})
+
+	// release views stay the same regardless of the backend
+	expected, err := storage.NewRelease(tillerRelease)
+	c.Assert(err, check.IsNil)
+	c.Assert(rel.toStorage(), check.DeepEquals, expected)
+}
+
+func (s *ReleaseSuite) TestEncodesRelease(c *check.C) {
+	rel, err := convertRelease(newTillerRelease("web", 3, release.Status_FAILED, time.Now()))
+	c.Assert(err, check.IsNil)
+
+	secret, err := newReleaseSecret(*rel)
+	c.Assert(err, check.IsNil)
+	c.Assert(secret.Name, check.Equals, "sh.helm.release.v1.web.v3")
+	c.Assert(secret.Namespace, check.Equals, "default")
+	c.Assert(secret.Type, check.Equals, releaseSecretType)
+	c.Assert(secret.Labels, check.DeepEquals, map[string]string{
+		"name":    "web",
+		"owner":   "helm",
+		"status":  "failed",
+		"version": "3",
+	})
+
+	decoded, err := decodeRelease(string(secret.Data[releaseDataKey]))
+	c.Assert(err, check.IsNil)
+	c.Assert(decoded.toStorage(), check.DeepEquals, rel.toStorage())
+	c.Assert(decoded.Manifest, check.Equals, rel.Manifest)
+}
+
+func (s *ReleaseSuite) TestGroupsTillerHistory(c *check.C) {
+	now := time.Now()
+	configMaps := []v1.ConfigMap{
+		newTillerConfigMap(c, newTillerRelease("web", 1, release.Status_SUPERSEDED, now)),
+		newTillerConfigMap(c, newTillerRelease("db", 1, release.Status_DELETED, now)),
+		newTillerConfigMap(c, newTillerRelease("web", 2, release.Status_DEPLOYED, now)),
+	}
+	history, err := tillerHistory(configMaps)
+	c.Assert(err, check.IsNil)
+	var revisions [][]string
+	for _, releases := range history {
+		var names []string
+		for _, revision := range releases {
+			names = append(names, revision.configMap)
+		}
+		revisions = append(revisions, names)
+	}
+	c.Assert(revisions, check.DeepEquals, [][]string{
+		{"db.v1"},
+		{"web.v2", "web.v1"},
+	})
+}
+
+func (s *ReleaseSuite) TestDetectsStoppedTiller(c *check.C) {
+	running := int32(1)
+	stopped := int32(0)
+	c.Assert(tillerRunning(nil), check.Equals, false)
+	c.Assert(tillerRunning([]appsv1.Deployment{{}}), check.Equals, true)
+	c.Assert(tillerRunning([]appsv1.Deployment{
+		{Spec: appsv1.DeploymentSpec{Replicas: &running}},
+	}), check.Equals, true)
+	c.Assert(tillerRunning([]appsv1.Deployment{
+		{Spec: appsv1.DeploymentSpec{Replicas: &stopped}},
+	}), check.Equals, false)
+}
+
+func (s *ReleaseSuite) TestReadsRecordedBackend(c *check.C) {
+	backend, err := backendFromConfigMap(*newBackendConfigMap(Helm3))
+	c.Assert(err, check.IsNil)
+	c.Assert(backend, check.Equals, Helm3)
+	_, err = backendFromConfigMap(v1.ConfigMap{Data: map[string]string{backendKey: "helm4"}})
+	c.Assert(err, check.ErrorMatches, `unsupported release backend "helm4".*`)
+}
+
+func (s *ReleaseSuite) TestSplitsManifests(c *check.C) {
+	hooks, manifest, notes, err := splitManifests(map[string]string{
+		"web/templates/NOTES.txt":    "Thank you",
+		"web/templates/_helpers.tpl": "",
+		"web/templates/service.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: web`,
+		"web/templates/resources.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web`,
+		"web/templates/hook.yaml": `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: web-init
+  annotations:
+    helm.sh/hook: pre-install, pre-upgrade
+    helm.sh/hook-weight: "-1"
+    helm.sh/hook-delete-policy: hook-succeeded`,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(notes, check.Equals, "Thank you")
+	c.Assert(manifest, check.Equals, `---
+# Source: web/templates/resources.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: web/templates/resources.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`)
+	c.Assert(hooks, check.HasLen, 1)
+	c.Assert(hooks[0].Name, check.Equals, "web-init")
+	c.Assert(hooks[0].Events, check.DeepEquals, []string{eventPreInstall, eventPreUpgrade})
+	c.Assert(hooks[0].Weight, check.Equals, -1)
+	c.Assert(hooks[0].DeletePolicies, check.DeepEquals, []string{hookSucceeded})
+}
+
+func newTillerRelease(name string, version int32, status release.Status_Code, deployed time.Time) *release.Release {
+	return &release.Release{
+		Name:      name,
+		Namespace: "default",
+		Version:   version,
+		Manifest:  "kind: Deployment",
+		Config:    &chart.Config{Raw: "replicas: 2"},
+		Chart: &chart.Chart{
+			Metadata: &chart.Metadata{
+				Name:        name,
+				Version:     "0.0.1",
+				AppVersion:  "1.0",
+				Description: "Test chart",
+			},
+			Templates: []*chart.Template{
+				{Name: "templates/deployment.yaml", Data: []byte("kind: Deployment")},
+			},
+			Values: &chart.Config{Raw: "replicas: 1"},
+		},
+		Info: &release.Info{
+			Status:        &release.Status{Code: status},
+			FirstDeployed: timeconv.Timestamp(deployed),
+			LastDeployed:  timeconv.Timestamp(deployed),
+		},
+		Hooks: []*release.Hook{{
+			Name:           name + "-init",
+			Kind:           "Job",
+			Path:           name + "/templates/init.yaml",
+			Manifest:       "kind: Job",
+			Events:         []release.Hook_Event{release.Hook_PRE_INSTALL, release.Hook_PRE_UPGRADE},
+			Weight:         1,
+			DeletePolicies: []release.Hook_DeletePolicy{release.Hook_SUCCEEDED},
+		}},
+	}
+}
+
+func newTillerConfigMap(c *check.C, rel *release.Release) v1.ConfigMap {
+	data, err := proto.Marshal(rel)
+	c.Assert(err, check.IsNil)
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err = w.Write(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(w.Close(), check.IsNil)
+	return v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v.v%v", rel.Name, rel.Version),
+			Namespace: metav1.NamespaceSystem,
+		},
+		Data: map[string]string{
+			releaseDataKey: base64.StdEncoding.EncodeToString(buf.Bytes()),
+		},
+	}
+}
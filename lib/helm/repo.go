@@ -17,6 +17,7 @@ limitations under the License.
 package helm
 
 import (
+	"archive/tar"
 	"bytes"
 	"fmt"
 	"io"
@@ -24,6 +25,7 @@ import (
 	"os"
 	"path/filepath"
 
+	gravityarchive "github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
@@ -47,6 +49,8 @@ import (
 type Repository interface {
 	// FetchChart returns the specified application as a Helm chart tarball.
 	FetchChart(loc.Locator) (io.ReadCloser, error)
+	// FetchChartProvenance returns the provenance file of the specified application.
+	FetchChartProvenance(loc.Locator) (io.Reader, error)
 	// GetIndexFile returns the chart repository index file.
 	GetIndexFile() (io.Reader, error)
 	// AddToIndex adds the specified application to the repository index.
@@ -109,7 +113,7 @@ func (r *clusterRepository) FetchChart(locator loc.Locator) (io.ReadCloser, erro
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	path, err := chartutil.Save(chart, chartDir)
+	path, err := SaveChart(chart, chartDir)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -125,6 +129,43 @@ func (r *clusterRepository) FetchChart(locator loc.Locator) (io.ReadCloser, erro
 	}, nil
 }
 
+// FetchChartProvenance returns the provenance file of the specified application.
+//
+// The provenance file is generated when the application image is signed
+// during build and is stored alongside the chart resources.
+func (r *clusterRepository) FetchChartProvenance(locator loc.Locator) (io.Reader, error) {
+	_, reader, err := r.Packages.ReadPackage(locator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer reader.Close()
+	decompressed, err := archive.DecompressStream(reader)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer decompressed.Close()
+	var data []byte
+	err = gravityarchive.TarGlob(tar.NewReader(decompressed), ".", []string{defaults.ChartProvenanceFile},
+		func(match string, file io.Reader) error {
+			if match != defaults.ChartProvenanceFile {
+				return nil
+			}
+			content, err := ioutil.ReadAll(file)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			data = content
+			return gravityarchive.Abort
+		})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if data == nil {
+		return nil, trace.NotFound("application %v is not signed", locator)
+	}
+	return bytes.NewReader(data), nil
+}
+
 // GetIndexFile returns the chart repository index file.
 func (r *clusterRepository) GetIndexFile() (io.Reader, error) {
 	indexFile, err := r.Backend.GetIndexFile()
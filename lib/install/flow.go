@@ -576,6 +576,7 @@ func GetServers(op ops.SiteOperation, servers []checks.ServerInfo) (*ops.Operati
 			Hostname:    serverInfo.GetHostname(),
 			Role:        serverInfo.Role,
 			OSInfo:      serverInfo.GetOS(),
+			Arch:        serverInfo.GetArch(),
 			Mounts:      mounts,
 			User:        serverInfo.GetUser(),
 			Provisioner: op.Provisioner,
@@ -74,6 +74,8 @@ type FSMConfig struct {
 	Spec fsm.FSMSpecFunc
 	// Credentials is the credentials for gRPC agents
 	Credentials credentials.TransportCredentials
+	// Runner is optional runner to use when running remote commands
+	Runner fsm.AgentRepository
 	// Insecure allows to turn off cert validation in dev mode
 	Insecure bool
 	// UserLogFile is the user-friendly install log file
@@ -111,15 +113,18 @@ func (c *FSMConfig) CheckAndSetDefaults() (err error) {
 	if c.LocalBackend == nil {
 		return trace.BadParameter("missing LocalBackend")
 	}
-	if c.Spec == nil {
-		c.Spec = FSMSpec(*c)
-	}
 	if c.Credentials == nil {
 		c.Credentials, err = rpc.ClientCredentials(defaults.RPCAgentSecretsDir)
 		if err != nil {
 			return trace.Wrap(err)
 		}
 	}
+	if c.Runner == nil {
+		c.Runner = fsm.NewAgentRunner(c.Credentials)
+	}
+	if c.Spec == nil {
+		c.Spec = FSMSpec(*c)
+	}
 	return nil
 }
 
@@ -146,10 +151,9 @@ func NewFSM(config FSMConfig) (*fsm.FSM, error) {
 		FieldLogger: logger,
 		operation:   op,
 	}
-	runner := fsm.NewAgentRunner(config.Credentials)
 	fsm, err := fsm.New(fsm.Config{
 		Engine:   engine,
-		Runner:   runner,
+		Runner:   config.Runner,
 		Insecure: config.Insecure,
 		Logger:   logger,
 	})
@@ -57,6 +57,13 @@ func FSMSpec(config FSMConfig) fsm.FSMSpecFunc {
 				config.Apps,
 				config.LocalApps, remote)
 
+		case p.Phase.ID == phases.ProvisionHookPhase:
+			return phases.NewHook(p,
+				config.Operator,
+				config.Apps,
+				config.Runner,
+				schema.HookClusterProvision)
+
 		case strings.HasPrefix(p.Phase.ID, phases.MastersPhase), strings.HasPrefix(p.Phase.ID, phases.NodesPhase):
 			return phases.NewSystem(p,
 				config.Operator, remote)
@@ -119,6 +126,7 @@ func FSMSpec(config FSMConfig) fsm.FSMSpecFunc {
 			return phases.NewHook(p,
 				config.Operator,
 				config.LocalApps,
+				nil,
 				schema.HookNetworkInstall)
 
 		case strings.HasPrefix(p.Phase.ID, phases.GravityResourcesPhase):
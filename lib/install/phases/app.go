@@ -25,6 +25,7 @@ import (
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/rpc"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/systeminfo"
@@ -36,11 +37,12 @@ import (
 
 // NewApp returns executor that runs install and post-install hooks
 func NewApp(p fsm.ExecutorParams, operator ops.Operator, apps app.Applications) (*hookExecutor, error) {
-	return NewHook(p, operator, apps, schema.HookInstall, schema.HookInstalled)
+	return NewHook(p, operator, apps, nil, schema.HookInstall, schema.HookInstalled)
 }
 
-// NewHook returns executor that runs specified application hooks
-func NewHook(p fsm.ExecutorParams, operator ops.Operator, apps app.Applications, hooks ...schema.HookType) (*hookExecutor, error) {
+// NewHook returns executor that runs specified application hooks.
+// agents is used to run the hooks defined as host scripts
+func NewHook(p fsm.ExecutorParams, operator ops.Operator, apps app.Applications, agents rpc.AgentRepository, hooks ...schema.HookType) (*hookExecutor, error) {
 	if p.Phase.Data == nil || p.Phase.Data.ServiceUser == nil {
 		return nil, trace.BadParameter("service user is required")
 	}
@@ -62,6 +64,7 @@ func NewHook(p fsm.ExecutorParams, operator ops.Operator, apps app.Applications,
 		FieldLogger:    logger,
 		Operator:       operator,
 		Apps:           apps,
+		Agents:         agents,
 		ExecutorParams: p,
 		Hooks:          hooks,
 		ServiceUser:    *serviceUser,
@@ -75,6 +78,8 @@ type hookExecutor struct {
 	Operator ops.Operator
 	// Apps is the app service that runs the hook
 	Apps app.Applications
+	// Agents provides access to the RPC agents that run host hooks
+	Agents rpc.AgentRepository
 	// ServiceUser is the user used for services and system storage
 	ServiceUser systeminfo.User
 	// Hooks is hook names to be executed
@@ -108,8 +113,13 @@ func (p *hookExecutor) runHooks(ctx context.Context, hooks ...schema.HookType) e
 		if hook == schema.HookNetworkInstall {
 			req.HostNetwork = true
 		}
+		req.Recorder = ops.HookRecorder{
+			Operator: p.Operator,
+			Key:      p.Key(),
+			PhaseID:  p.Phase.ID,
+		}
 
-		_, err := app.CheckHasAppHook(p.Apps, req)
+		spec, err := app.CheckHasAppHook(p.Apps, req)
 		if err != nil {
 			if trace.IsNotFound(err) {
 				p.Debugf("Application %v does not have %v hook.",
@@ -130,7 +140,11 @@ func (p *hookExecutor) runHooks(ctx context.Context, hooks ...schema.HookType) e
 					trace.DebugReport(err))
 			}
 		}()
-		_, err = app.StreamAppHook(ctx, p.Apps, req, writer)
+		if spec.Host != nil {
+			err = p.runHostHook(ctx, req, writer)
+		} else {
+			_, err = app.StreamAppHook(ctx, p.Apps, req, writer)
+		}
 		if err != nil {
 			return trace.Wrap(err, "%v %s hook failed", locator, hook)
 		}
@@ -144,6 +158,26 @@ func (p *hookExecutor) runHooks(ctx context.Context, hooks ...schema.HookType) e
 	return nil
 }
 
+// runHostHook runs the specified host hook on the servers of the operation,
+// or on the server of the phase if the phase has one
+func (p *hookExecutor) runHostHook(ctx context.Context, req app.HookRunRequest, w io.WriteCloser) error {
+	defer w.Close()
+	if p.Agents == nil {
+		return trace.BadParameter("%v hook cannot run as a host script in this phase", req.Hook)
+	}
+	servers := p.Plan.Servers
+	if p.Phase.Data.ExecServer != nil {
+		servers = []storage.Server{*p.Phase.Data.ExecServer}
+	}
+	return app.RunHostHook(ctx, p.Apps, app.HostHookRequest{
+		Application: req.Application,
+		Hook:        req.Hook,
+		Servers:     servers,
+		Runner:      app.NewAgentHostRunner(p.Agents, p.FieldLogger),
+		Recorder:    req.Recorder,
+	}, w)
+}
+
 // Rollback is no-op for this phase
 func (*hookExecutor) Rollback(ctx context.Context) error {
 	return nil
@@ -29,6 +29,9 @@ const (
 	BootstrapPhase = "/bootstrap"
 	// PullPhase is a phase that pulls configured packages
 	PullPhase = "/pull"
+	// ProvisionHookPhase is a phase that runs the application's
+	// clusterProvision hook script on the nodes
+	ProvisionHookPhase = "/provision-hook"
 	// MastersPhase is a phase that installs system software on master nodes
 	MastersPhase = "/masters"
 	// NodesPhase is a phase that installs system software on regular nodes
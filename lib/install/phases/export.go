@@ -151,7 +151,12 @@ func (p *exportExecutor) exportApp(ctx context.Context, locator loc.Locator) err
 		locator.Name, locator.Version)
 	p.Infof("Exporting application %v:%v to local registry.",
 		locator.Name, locator.Version)
-	_, err := p.ImageService.Sync(ctx, p.registryPath(locator), utils.NopEmitter())
+	envelope, err := p.Packages.ReadPackageEnvelope(locator)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	digests := docker.DigestsFromLabels(envelope.RuntimeLabels)
+	_, err = p.ImageService.SyncWithDigests(ctx, p.registryPath(locator), digests, utils.NopEmitter())
 	return trace.Wrap(err)
 }
 
@@ -56,7 +56,8 @@ func NewPull(p fsm.ExecutorParams, operator ops.Operator, wizardPack, localPack
 		return nil, trace.Wrap(err)
 	}
 
-	runtimePackage, err := app.Manifest.RuntimePackageForProfile(p.Phase.Data.Server.Role)
+	runtimePackage, err := app.Manifest.RuntimePackageForArch(
+		p.Phase.Data.Server.Role, p.Phase.Data.Server.Arch)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -111,6 +112,10 @@ func (p *pullExecutor) Execute(ctx context.Context) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	err = p.pullArchPackages()
+	if err != nil {
+		return trace.Wrap(err)
+	}
 	err = p.pullConfiguredPackages()
 	if err != nil {
 		return trace.Wrap(err)
@@ -156,6 +161,41 @@ func (p *pullExecutor) pullUserApplication() error {
 	return nil
 }
 
+// pullArchPackages replaces the gravity and teleport packages pulled with
+// the application with their variants for the architecture of this node
+func (p *pullExecutor) pullArchPackages() error {
+	arch := p.Phase.Data.Server.Arch
+	if arch == "" || arch == defaults.Architecture {
+		return nil
+	}
+	var locators []loc.Locator
+	err := pack.ForeachPackage(p.LocalPackages,
+		func(e pack.PackageEnvelope) error {
+			if schema.IsArchDependency(e.Locator) {
+				locators = append(locators, e.Locator)
+			}
+			return nil
+		})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, locator := range locators {
+		p.Infof("Pulling package %v for %v.", locator, arch)
+		_, err := service.PullPackage(service.PackagePullRequest{
+			FieldLogger: p.FieldLogger,
+			SrcPack:     p.WizardPackages,
+			DstPack:     p.LocalPackages,
+			Package:     locator,
+			Arch:        arch,
+			Upsert:      true,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
 // applyPackageLabels adds labels to system packages in order for update
 // to properly detect an installed version
 func (p *pullExecutor) applyPackageLabels() error {
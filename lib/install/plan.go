@@ -19,6 +19,8 @@ package install
 import (
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/install/phases"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
@@ -89,6 +91,14 @@ func (i *Installer) GetOperationPlan(cluster ops.Site, op ops.SiteOperation) (*s
 	// pull configured packages on each node
 	builder.AddPullPhase(plan)
 
+	// run the application's host provisioning script on the nodes: the
+	// script runs right away on the hosts or, if it runs inside planet,
+	// once planet has been installed on all nodes
+	provisionHook := hostProvisionHook(cluster.App.Manifest)
+	if provisionHook != nil && provisionHook.Context != schema.HostHookContextPlanet {
+		builder.AddProvisionHookPhase(plan, phases.BootstrapPhase)
+	}
+
 	// install system software on master nodes
 	if err := builder.AddMastersPhase(plan); err != nil {
 		return nil, trace.Wrap(err)
@@ -101,6 +111,11 @@ func (i *Installer) GetOperationPlan(cluster ops.Site, op ops.SiteOperation) (*s
 		}
 	}
 
+	if provisionHook != nil && provisionHook.Context == schema.HostHookContextPlanet {
+		builder.AddProvisionHookPhase(plan, fsm.RequireIfPresent(plan,
+			phases.MastersPhase, phases.NodesPhase)...)
+	}
+
 	// perform post system install tasks such as waiting for planet
 	// to start up, creating RBAC resources, etc.
 	builder.AddWaitPhase(plan)
@@ -145,3 +160,13 @@ func (i *Installer) GetOperationPlan(cluster ops.Site, op ops.SiteOperation) (*s
 
 	return plan, nil
 }
+
+// hostProvisionHook returns the script of the application's clusterProvision
+// hook if the hook runs as a host script
+func hostProvisionHook(manifest schema.Manifest) *schema.HostHook {
+	hook, err := schema.HookFromString(schema.HookClusterProvision, manifest)
+	if err != nil {
+		return nil
+	}
+	return hook.Host
+}
@@ -175,11 +175,26 @@ func (b *PlanBuilder) AddPullPhase(plan *storage.OperationPlan) {
 	})
 }
 
+// AddProvisionHookPhase appends the phase that runs the application's
+// clusterProvision host hook on the nodes to the provided plan
+func (b *PlanBuilder) AddProvisionHookPhase(plan *storage.OperationPlan, requires ...string) {
+	plan.Phases = append(plan.Phases, storage.OperationPhase{
+		ID:          phases.ProvisionHookPhase,
+		Description: fmt.Sprintf("Execute the application's %v hook on the nodes", schema.HookClusterProvision),
+		Data: &storage.OperationPhaseData{
+			Package:     &b.Application.Package,
+			ServiceUser: &b.ServiceUser,
+		},
+		Requires: requires,
+		Step:     3,
+	})
+}
+
 // AddMastersPhase appends master nodes system installation phase to the provided plan
 func (b *PlanBuilder) AddMastersPhase(plan *storage.OperationPlan) error {
 	var masterPhases []storage.OperationPhase
 	for i, node := range b.Masters {
-		planetPackage, err := b.Application.Manifest.RuntimePackageForProfile(node.Role)
+		planetPackage, err := b.Application.Manifest.RuntimePackageForArch(node.Role, node.Arch)
 		if err != nil {
 			return trace.Wrap(err)
 		}
@@ -233,7 +248,7 @@ func (b *PlanBuilder) AddMastersPhase(plan *storage.OperationPlan) error {
 func (b *PlanBuilder) AddNodesPhase(plan *storage.OperationPlan) error {
 	var nodePhases []storage.OperationPhase
 	for i, node := range b.Nodes {
-		planetPackage, err := b.Application.Manifest.RuntimePackageForProfile(node.Role)
+		planetPackage, err := b.Application.Manifest.RuntimePackageForArch(node.Role, node.Arch)
 		if err != nil {
 			return trace.Wrap(err)
 		}
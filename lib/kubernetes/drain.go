@@ -18,9 +18,12 @@ package kubernetes
 
 import (
 	"context"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/cenkalti/backoff"
@@ -32,6 +35,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -88,20 +92,57 @@ func (d *drain) evictPods(ctx context.Context, pods []v1.Pod, policyGroupVersion
 		}(pod, errCh)
 	}
 
-	return trace.Wrap(utils.CollectErrors(ctx, errCh))
+	err := utils.CollectErrors(ctx, errCh)
+	if err != nil {
+		if blocking := d.blockingPods(); len(blocking) != 0 {
+			return trace.LimitExceeded("eviction of pods %v is blocked by pod disruption budgets: %v",
+				blocking, err)
+		}
+		return trace.Wrap(err)
+	}
+	return nil
 }
 
+// evictPodAndWait evicts the specified pod and waits for it to terminate.
+// If the drain policy specifies a force delete timeout, the pod is deleted
+// without grace period if it could not be evicted in time
 func (d *drain) evictPodAndWait(ctx context.Context, pod v1.Pod, policyGroupVersion string) error {
+	if d.policy.ForceDeleteTimeout == 0 {
+		return trace.Wrap(d.evictAndWait(ctx, pod, policyGroupVersion))
+	}
+	evictCtx, cancel := context.WithTimeout(ctx, d.policy.ForceDeleteTimeout)
+	defer cancel()
+	err := d.evictAndWait(evictCtx, pod, policyGroupVersion)
+	if err == nil {
+		return nil
+	}
+	if evictCtx.Err() == nil || ctx.Err() != nil {
+		return trace.Wrap(err)
+	}
+	log.WithFields(podFields(pod)).Warnf("Pod has not been evicted in %v, force deleting.",
+		d.policy.ForceDeleteTimeout)
+	return trace.Wrap(d.forceDeletePod(ctx, pod))
+}
+
+func (d *drain) evictAndWait(ctx context.Context, pod v1.Pod, policyGroupVersion string) error {
 	b := backoff.NewExponentialBackOff()
+	if d.policy.DisruptionBudgetTimeout != 0 {
+		b.MaxElapsedTime = d.policy.DisruptionBudgetTimeout
+	}
 	err := utils.RetryWithInterval(ctx, b,
 		func() error {
 			err := d.evictPod(pod, policyGroupVersion)
 			if err == nil {
+				d.setBlocking(pod, false)
 				return nil
 			}
 			if errors.IsNotFound(trace.Unwrap(err)) {
+				d.setBlocking(pod, false)
 				return nil
 			} else if errors.IsTooManyRequests(trace.Unwrap(err)) {
+				// Eviction API responds with 429 if the eviction
+				// would violate the pod's disruption budget
+				d.setBlocking(pod, true)
 				return trace.Retry(err, "too many requests")
 			}
 			return &backoff.PermanentError{Err: rigging.ConvertError(err)}
@@ -112,7 +153,7 @@ func (d *drain) evictPodAndWait(ctx context.Context, pod v1.Pod, policyGroupVers
 
 	// Set the timeout before pod termination based on how long we expect kubernetes to take
 	// with a safe margin of error
-	waitDuration := terminationWaitPeriod(pod)
+	waitDuration := terminationWaitPeriod(pod, d.gracePeriodSecondsFor(pod))
 	ctx, cancel := context.WithTimeout(ctx, waitDuration)
 	defer cancel()
 
@@ -137,17 +178,36 @@ func (d *drain) deletePods(ctx context.Context, pods []v1.Pod) error {
 
 func (d *drain) deletePod(pod v1.Pod) error {
 	options := &metav1.DeleteOptions{}
-	if d.gracePeriodSeconds >= 0 {
-		options.GracePeriodSeconds = utils.Int64Ptr(d.gracePeriodSeconds)
+	if gracePeriodSeconds := d.gracePeriodSecondsFor(pod); gracePeriodSeconds >= 0 {
+		options.GracePeriodSeconds = utils.Int64Ptr(gracePeriodSeconds)
 	}
 	// not using rigging.ConvertError on purpose to keep the original error
 	return trace.Wrap(d.client.Core().Pods(pod.Namespace).Delete(pod.Name, options))
 }
 
+// forceDeletePod deletes the specified pod without grace period
+// and waits for it to disappear
+func (d *drain) forceDeletePod(ctx context.Context, pod v1.Pod) error {
+	err := d.client.Core().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{
+		GracePeriodSeconds: utils.Int64Ptr(0),
+	})
+	if err != nil && !errors.IsNotFound(err) {
+		return rigging.ConvertError(err)
+	}
+	d.setBlocking(pod, false)
+	ctx, cancel := context.WithTimeout(ctx, defaults.TerminationWaitTimeout)
+	defer cancel()
+	_, err = waitForDelete(ctx, d.client.CoreV1(), []v1.Pod{pod}, usingEviction(false))
+	if err != nil {
+		return trace.Wrap(err, "error waiting for pod %v to terminate", formatPod(pod))
+	}
+	return nil
+}
+
 func (d *drain) evictPod(pod v1.Pod, policyGroupVersion string) error {
 	options := &metav1.DeleteOptions{}
-	if d.gracePeriodSeconds >= 0 {
-		options.GracePeriodSeconds = utils.Int64Ptr(d.gracePeriodSeconds)
+	if gracePeriodSeconds := d.gracePeriodSecondsFor(pod); gracePeriodSeconds >= 0 {
+		options.GracePeriodSeconds = utils.Int64Ptr(gracePeriodSeconds)
 	}
 	eviction := &policy.Eviction{
 		TypeMeta: metav1.TypeMeta{
@@ -165,7 +225,7 @@ func (d *drain) evictPod(pod v1.Pod, policyGroupVersion string) error {
 }
 
 // getPodsForDeletion returns all the pods to delete.
-// DaemonSet pods are always filtered out
+// DaemonSet pods and pods excluded by the drain policy are filtered out
 func (d *drain) getPodsForDeletion() (pods []v1.Pod, err error) {
 	podList, err := d.client.Core().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
 		FieldSelector: fields.SelectorFromSet(fields.Set{"spec.nodeName": d.nodeName}).String()})
@@ -190,6 +250,15 @@ func (d *drain) getPodsForDeletion() (pods []v1.Pod, err error) {
 }
 
 func (d *drain) canDeletePod(pod v1.Pod) (remove bool, err error) {
+	if d.skipSelector != nil && d.skipSelector.Matches(labels.Set(pod.Labels)) {
+		log.WithFields(podFields(pod)).Debug("Skip pod matching skip selector.")
+		return false, nil
+	}
+	if d.skipLocalStorageSelector != nil && hasLocalStorage(pod) &&
+		d.skipLocalStorageSelector.Matches(labels.Set(pod.Labels)) {
+		log.WithFields(podFields(pod)).Debug("Skip pod with local storage matching skip selector.")
+		return false, nil
+	}
 	// Note that we return false in cases where the pod is DaemonSet managed,
 	// regardless of flags.  We never delete them, the only question is whether
 	// their presence constitutes an error.
@@ -210,6 +279,59 @@ func (d *drain) canDeletePod(pod v1.Pod) (remove bool, err error) {
 	return true, nil
 }
 
+// gracePeriodSecondsFor returns the grace period for evicting the specified pod.
+// -1 means default grace period defined for the pod is used
+func (d *drain) gracePeriodSecondsFor(pod v1.Pod) int64 {
+	if period := d.policy.GracePeriodFor(pod.Namespace); period >= 0 {
+		return int64(period / time.Second)
+	}
+	return d.gracePeriodSeconds
+}
+
+// setBlocking marks the specified pod as blocked (or no longer blocked)
+// by its disruption budget and reports the pods that block the drain
+// if the set has changed
+func (d *drain) setBlocking(pod v1.Pod, blocking bool) {
+	d.mu.Lock()
+	name := formatPod(pod)
+	_, exists := d.blocking[name]
+	if exists == blocking {
+		d.mu.Unlock()
+		return
+	}
+	if blocking {
+		d.blocking[name] = struct{}{}
+	} else {
+		delete(d.blocking, name)
+	}
+	d.mu.Unlock()
+	if d.progress != nil {
+		d.progress(d.blockingPods())
+	}
+}
+
+// blockingPods returns the sorted list of pods whose eviction
+// is currently blocked by disruption budgets
+func (d *drain) blockingPods() (pods []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for name := range d.blocking {
+		pods = append(pods, name)
+	}
+	sort.Strings(pods)
+	return pods
+}
+
+// hasLocalStorage returns true if the specified pod uses emptyDir volumes
+func hasLocalStorage(pod v1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.EmptyDir != nil {
+			return true
+		}
+	}
+	return false
+}
+
 type drain struct {
 	client   *kubernetes.Clientset
 	nodeName string
@@ -219,6 +341,18 @@ type drain struct {
 	// timeout sets the timeout for the operation.
 	// zero value means no timeout
 	timeout time.Duration
+	// policy defines how pods are evicted
+	policy storage.DrainPolicy
+	// skipSelector matches pods that should not be evicted
+	skipSelector labels.Selector
+	// skipLocalStorageSelector matches pods with local storage that should not be evicted
+	skipLocalStorageSelector labels.Selector
+	// progress is optionally invoked with the list of pods blocking the drain
+	progress func(blocking []string)
+
+	mu sync.Mutex
+	// blocking is the set of pods whose eviction is blocked by disruption budgets
+	blocking map[string]struct{}
 }
 
 // queryEvictionPolicyGroupVersion uses Discovery API to find out if the server supports eviction subresource.
@@ -279,9 +413,12 @@ func waitForDelete(ctx context.Context, client corev1.CoreV1Interface, pods []v1
 }
 
 // terminationWaitPeriod calculates the amount of time we should wait for a pod to terminate,
-// Based on the TerminationGracePeriod plus some amount of time for Kubernetes to force terminate
-// the pod.
-func terminationWaitPeriod(pod v1.Pod) time.Duration {
+// Based on the TerminationGracePeriod (or the specified override, if not negative) plus some
+// amount of time for Kubernetes to force terminate the pod.
+func terminationWaitPeriod(pod v1.Pod, gracePeriodSeconds int64) time.Duration {
+	if gracePeriodSeconds >= 0 {
+		return time.Duration(gracePeriodSeconds)*time.Second + defaults.TerminationWaitTimeout
+	}
 	if pod.Spec.TerminationGracePeriodSeconds == nil {
 		return v1.DefaultTerminationGracePeriodSeconds*time.Second + defaults.TerminationWaitTimeout
 	}
@@ -0,0 +1,157 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	. "gopkg.in/check.v1"
+)
+
+type DrainSuite struct{}
+
+var _ = Suite(&DrainSuite{})
+
+func (s *DrainSuite) TestFiltersPods(c *C) {
+	selector, err := labels.Parse("drain=skip")
+	c.Assert(err, IsNil)
+	localStorageSelector, err := labels.Parse("app=cache")
+	c.Assert(err, IsNil)
+	d := &drain{
+		skipSelector:             selector,
+		skipLocalStorageSelector: localStorageSelector,
+	}
+	owner := []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "app"}}
+	testCases := []struct {
+		pod     v1.Pod
+		remove  bool
+		comment string
+	}{
+		{
+			pod:     v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app", OwnerReferences: owner}},
+			remove:  true,
+			comment: "regular pod",
+		},
+		{
+			pod:     v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "orphan"}},
+			remove:  true,
+			comment: "pod without controller",
+		},
+		{
+			pod: v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:            "agent",
+				OwnerReferences: []metav1.OwnerReference{{Kind: rigging.KindDaemonSet, Name: "agent"}},
+			}},
+			comment: "DaemonSet pod",
+		},
+		{
+			pod: v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:            "pinned",
+				Labels:          map[string]string{"drain": "skip"},
+				OwnerReferences: owner,
+			}},
+			comment: "pod matching skip selector",
+		},
+		{
+			pod: v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "cache",
+					Labels:          map[string]string{"app": "cache"},
+					OwnerReferences: owner,
+				},
+				Spec: v1.PodSpec{Volumes: []v1.Volume{{
+					Name:         "scratch",
+					VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+				}}},
+			},
+			comment: "pod with local storage matching local storage skip selector",
+		},
+		{
+			pod: v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "scratch", OwnerReferences: owner},
+				Spec: v1.PodSpec{Volumes: []v1.Volume{{
+					Name:         "scratch",
+					VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+				}}},
+			},
+			remove:  true,
+			comment: "pod with local storage not matching local storage skip selector",
+		},
+		{
+			pod: v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:            "app",
+				Labels:          map[string]string{"app": "cache"},
+				OwnerReferences: owner,
+			}},
+			remove:  true,
+			comment: "pod without local storage matching local storage skip selector",
+		},
+	}
+	for _, tc := range testCases {
+		remove, err := d.canDeletePod(tc.pod)
+		c.Assert(err, IsNil)
+		c.Assert(remove, Equals, tc.remove, Commentf(tc.comment))
+	}
+}
+
+func (s *DrainSuite) TestGracePeriods(c *C) {
+	d := &drain{
+		gracePeriodSeconds: defaults.ResourceGracePeriod,
+		policy: storage.DrainPolicy{
+			NamespaceGracePeriods: map[string]time.Duration{"db": 5 * time.Minute},
+		},
+	}
+	pod := func(namespace string) v1.Pod {
+		return v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: namespace}}
+	}
+	c.Assert(d.gracePeriodSecondsFor(pod("db")), Equals, int64(300))
+	c.Assert(d.gracePeriodSecondsFor(pod("default")), Equals, int64(defaults.ResourceGracePeriod))
+
+	d.policy.GracePeriod = 10 * time.Second
+	c.Assert(d.gracePeriodSecondsFor(pod("default")), Equals, int64(10))
+	c.Assert(terminationWaitPeriod(pod("default"), 10), Equals, 10*time.Second+defaults.TerminationWaitTimeout)
+}
+
+func (s *DrainSuite) TestReportsBlockingPods(c *C) {
+	var reports [][]string
+	d := &drain{
+		blocking: make(map[string]struct{}),
+		progress: func(pods []string) {
+			reports = append(reports, pods)
+		},
+	}
+	pod := func(name string) v1.Pod {
+		return v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+	}
+	d.setBlocking(pod("b"), true)
+	d.setBlocking(pod("a"), true)
+	d.setBlocking(pod("a"), true)
+	d.setBlocking(pod("b"), false)
+	c.Assert(reports, DeepEquals, [][]string{
+		{"default/b"},
+		{"default/a", "default/b"},
+		{"default/a"},
+	})
+}
@@ -29,26 +29,66 @@ import (
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
 // Drain safely drains the specified node and uses Eviction API if supported on the api server.
 func Drain(ctx context.Context, client *kubernetes.Clientset, nodeName string) error {
-	err := SetUnschedulable(ctx, client.CoreV1().Nodes(), nodeName, true)
+	return trace.Wrap(DrainNode(ctx, DrainConfig{
+		Client:   client,
+		NodeName: nodeName,
+	}))
+}
+
+// DrainNode safely drains the node according to the specified configuration
+func DrainNode(ctx context.Context, config DrainConfig) error {
+	if err := config.Policy.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	err := SetUnschedulable(ctx, config.Client.CoreV1().Nodes(), config.NodeName, true)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
-	d := drain{
-		client:             client,
-		nodeName:           nodeName,
+	d := &drain{
+		client:             config.Client,
+		nodeName:           config.NodeName,
 		gracePeriodSeconds: defaults.ResourceGracePeriod,
+		policy:             config.Policy,
+		progress:           config.Progress,
+		blocking:           make(map[string]struct{}),
+	}
+	if config.Policy.SkipSelector != "" {
+		d.skipSelector, err = labels.Parse(config.Policy.SkipSelector)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if config.Policy.SkipLocalStorageSelector != "" {
+		d.skipLocalStorageSelector, err = labels.Parse(config.Policy.SkipLocalStorageSelector)
+		if err != nil {
+			return trace.Wrap(err)
+		}
 	}
 	err = d.drainPods(ctx)
 	return trace.Wrap(err)
 }
 
+// DrainConfig defines the configuration of a node drain
+type DrainConfig struct {
+	// Client is the Kubernetes API client
+	Client *kubernetes.Clientset
+	// NodeName is the name of the node to drain
+	NodeName string
+	// Policy defines how pods are evicted from the node
+	Policy storage.DrainPolicy
+	// Progress is optionally invoked with the list of pods
+	// whose eviction is blocked by pod disruption budgets
+	Progress func(blocking []string)
+}
+
 // SetUnschedulable marks the specified node as unschedulable depending on the value of the specified flag.
 // Retries the operation internally on update conflicts.
 func SetUnschedulable(ctx context.Context, client corev1.NodeInterface, nodeName string, unschedulable bool) error {
@@ -35,9 +35,11 @@ import (
 // agentService is the access point to the agent cluster for running remote
 // commands.
 // manifest specifies the application manifest with requirements.
+// clusterServers optionally lists the existing cluster servers when
+// the servers are joining the cluster.
 func CheckServers(ctx context.Context, opKey SiteOperationKey,
-	infos checks.ServerInfos, servers []storage.Server, agentService AgentService,
-	manifest schema.Manifest) error {
+	infos checks.ServerInfos, servers []storage.Server, clusterServers []storage.Server,
+	agentService AgentService, manifest schema.Manifest) error {
 	nodes, err := mergeServers(infos, servers)
 	if err != nil {
 		return trace.Wrap(err)
@@ -53,6 +55,7 @@ func CheckServers(ctx context.Context, opKey SiteOperationKey,
 	}
 	c.TestBandwidth = true
 	c.TestDockerDevice = true
+	c.ClusterServers = clusterServers
 	return trace.Wrap(c.Run(ctx))
 }
 
@@ -137,8 +137,13 @@ const (
 	OperationUpdateConfigInProgress = "update_config_in_progress"
 
 	// common operation states
-	OperationStateCompleted = "completed"
-	OperationStateFailed    = "failed"
+	OperationStateCompleted  = "completed"
+	OperationStateFailed     = "failed"
+	OperationStateRolledBack = "rolled_back"
+
+	// OperationStateScheduled indicates that the operation has been created
+	// outside of the maintenance window and waits for the window to open
+	OperationStateScheduled = "scheduled"
 
 	// Teleport node labels
 	// AdvertiseIP defines a label with advertise IP address
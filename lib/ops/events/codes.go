@@ -229,6 +229,36 @@ var (
 		Name: AuthGatewayUpdatedEvent,
 		Code: AuthGatewayUpdatedCode,
 	}
+	// MaintenanceWindowCreated is emitted when cluster maintenance window is created/updated.
+	MaintenanceWindowCreated = events.Event{
+		Name: MaintenanceWindowCreatedEvent,
+		Code: MaintenanceWindowCreatedCode,
+	}
+	// MaintenanceWindowDeleted is emitted when cluster maintenance window is deleted.
+	MaintenanceWindowDeleted = events.Event{
+		Name: MaintenanceWindowDeletedEvent,
+		Code: MaintenanceWindowDeletedCode,
+	}
+	// ChartSigningPolicyCreated is emitted when chart signing policy is created/updated.
+	ChartSigningPolicyCreated = events.Event{
+		Name: ChartSigningPolicyCreatedEvent,
+		Code: ChartSigningPolicyCreatedCode,
+	}
+	// ChartSigningPolicyDeleted is emitted when chart signing policy is deleted.
+	ChartSigningPolicyDeleted = events.Event{
+		Name: ChartSigningPolicyDeletedEvent,
+		Code: ChartSigningPolicyDeletedCode,
+	}
+	// HelmRepositoryCreated is emitted when helm repository is created/updated.
+	HelmRepositoryCreated = events.Event{
+		Name: HelmRepositoryCreatedEvent,
+		Code: HelmRepositoryCreatedCode,
+	}
+	// HelmRepositoryDeleted is emitted when helm repository is deleted.
+	HelmRepositoryDeleted = events.Event{
+		Name: HelmRepositoryDeletedEvent,
+		Code: HelmRepositoryDeletedCode,
+	}
 	// UserInviteCreated is emitted when a user invite is created.
 	UserInviteCreated = events.Event{
 		Name: InviteCreatedEvent,
@@ -368,6 +398,18 @@ const (
 	AuthGatewayUpdatedCode = "G1009I"
 	// UserInviteCreatedCode is the user invite created event code.
 	UserInviteCreatedCode = "G1010I"
+	// MaintenanceWindowCreatedCode is the maintenance window updated event code.
+	MaintenanceWindowCreatedCode = "G1011I"
+	// MaintenanceWindowDeletedCode is the maintenance window deleted event code.
+	MaintenanceWindowDeletedCode = "G2011I"
+	// ChartSigningPolicyCreatedCode is the chart signing policy updated event code.
+	ChartSigningPolicyCreatedCode = "G1012I"
+	// ChartSigningPolicyDeletedCode is the chart signing policy deleted event code.
+	ChartSigningPolicyDeletedCode = "G2012I"
+	// HelmRepositoryCreatedCode is the helm repository updated event code.
+	HelmRepositoryCreatedCode = "G1013I"
+	// HelmRepositoryDeletedCode is the helm repository deleted event code.
+	HelmRepositoryDeletedCode = "G2013I"
 	// ClusterUnhealthyCode is the cluster goes unhealthy event code.
 	ClusterUnhealthyCode = "G3000W"
 	// ClusterHealthyCode is the cluster goes healthy event code.
@@ -437,6 +479,18 @@ const (
 	AuthGatewayUpdatedEvent = "authgateway.updated"
 	// InviteCreatedEvent fires when a new user invitation is generated.
 	InviteCreatedEvent = "invite.created"
+	// MaintenanceWindowCreatedEvent fires when maintenance window is created/updated.
+	MaintenanceWindowCreatedEvent = "maintenancewindow.created"
+	// MaintenanceWindowDeletedEvent fires when maintenance window is deleted.
+	MaintenanceWindowDeletedEvent = "maintenancewindow.deleted"
+	// ChartSigningPolicyCreatedEvent fires when chart signing policy is created/updated.
+	ChartSigningPolicyCreatedEvent = "chartsigningpolicy.created"
+	// ChartSigningPolicyDeletedEvent fires when chart signing policy is deleted.
+	ChartSigningPolicyDeletedEvent = "chartsigningpolicy.deleted"
+	// HelmRepositoryCreatedEvent fires when helm repository is created/updated.
+	HelmRepositoryCreatedEvent = "helmrepository.created"
+	// HelmRepositoryDeletedEvent fires when helm repository is deleted.
+	HelmRepositoryDeletedEvent = "helmrepository.deleted"
 
 	// ClusterDegradedEvent fires when cluster health check fails.
 	ClusterDegradedEvent = "cluster.degraded"
@@ -579,11 +579,11 @@ func (o *OperatorACL) GetSiteOperationCrashReport(key SiteOperationKey) (io.Read
 	return o.operator.GetSiteOperationCrashReport(key)
 }
 
-func (o *OperatorACL) GetSiteReport(key SiteKey) (io.ReadCloser, error) {
-	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbRead); err != nil {
+func (o *OperatorACL) GetSiteReport(req SiteReportRequest) (io.ReadCloser, error) {
+	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbRead); err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return o.operator.GetSiteReport(key)
+	return o.operator.GetSiteReport(req)
 }
 
 func (o *OperatorACL) ValidateDomainName(domainName string) error {
@@ -657,6 +657,22 @@ func (o *OperatorACL) GetOperationPlan(key SiteOperationKey) (*storage.Operation
 	return o.operator.GetOperationPlan(key)
 }
 
+// CreateHookAttempt records an application hook attempt of the operation
+func (o *OperatorACL) CreateHookAttempt(key SiteOperationKey, attempt storage.HookAttempt) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.CreateHookAttempt(key, attempt)
+}
+
+// GetHookAttempts returns application hook attempts of the operation
+func (o *OperatorACL) GetHookAttempts(key SiteOperationKey) ([]storage.HookAttempt, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetHookAttempts(key)
+}
+
 // Configure packages configures packages for the specified operation
 func (o *OperatorACL) ConfigurePackages(req ConfigurePackagesRequest) error {
 	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
@@ -1047,6 +1063,98 @@ func (o *OperatorACL) GetAuthGateway(key SiteKey) (storage.AuthGateway, error) {
 	return o.operator.GetAuthGateway(key)
 }
 
+// GetMaintenanceWindow returns the cluster maintenance window
+func (o *OperatorACL) GetMaintenanceWindow(key SiteKey) (storage.MaintenanceWindow, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindMaintenanceWindow, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetMaintenanceWindow(key)
+}
+
+// UpsertMaintenanceWindow creates or updates the cluster maintenance window
+func (o *OperatorACL) UpsertMaintenanceWindow(ctx context.Context, key SiteKey, window storage.MaintenanceWindow) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindMaintenanceWindow, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertMaintenanceWindow(ctx, key, window)
+}
+
+// DeleteMaintenanceWindow deletes the cluster maintenance window
+func (o *OperatorACL) DeleteMaintenanceWindow(ctx context.Context, key SiteKey) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindMaintenanceWindow, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteMaintenanceWindow(ctx, key)
+}
+
+// GetChartSigningPolicy returns the cluster chart signing policy
+func (o *OperatorACL) GetChartSigningPolicy(key SiteKey) (storage.ChartSigningPolicy, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindChartSigningPolicy, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetChartSigningPolicy(key)
+}
+
+// UpsertChartSigningPolicy creates or updates the cluster chart signing policy
+func (o *OperatorACL) UpsertChartSigningPolicy(ctx context.Context, key SiteKey, policy storage.ChartSigningPolicy) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindChartSigningPolicy, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertChartSigningPolicy(ctx, key, policy)
+}
+
+// DeleteChartSigningPolicy deletes the cluster chart signing policy
+func (o *OperatorACL) DeleteChartSigningPolicy(ctx context.Context, key SiteKey) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindChartSigningPolicy, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteChartSigningPolicy(ctx, key)
+}
+
+// GetHelmRepositories returns all configured helm repositories
+//
+// Returned repositories exclude the password unless withSecrets is true.
+func (o *OperatorACL) GetHelmRepositories(key SiteKey, withSecrets bool) ([]storage.HelmRepository, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindHelmRepository, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetHelmRepositories(key, withSecrets)
+}
+
+// GetHelmRepository returns the helm repository with the specified name
+//
+// Returned repository excludes the password unless withSecrets is true.
+func (o *OperatorACL) GetHelmRepository(key SiteKey, name string, withSecrets bool) (storage.HelmRepository, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindHelmRepository, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetHelmRepository(key, name, withSecrets)
+}
+
+// UpsertHelmRepository creates or updates the helm repository
+func (o *OperatorACL) UpsertHelmRepository(ctx context.Context, key SiteKey, repository storage.HelmRepository) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindHelmRepository, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.UpsertHelmRepository(ctx, key, repository)
+}
+
+// DeleteHelmRepository deletes the helm repository with the specified name
+func (o *OperatorACL) DeleteHelmRepository(ctx context.Context, key SiteKey, name string) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindHelmRepository, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteHelmRepository(ctx, key, name)
+}
+
+// LaunchScheduledOperations launches operations waiting for the maintenance window
+func (o *OperatorACL) LaunchScheduledOperations(ctx context.Context, key SiteKey) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.LaunchScheduledOperations(ctx, key)
+}
+
 // ListReleases returns all currently installed application releases in a cluster.
 func (o *OperatorACL) ListReleases(key SiteKey) ([]storage.Release, error) {
 	// TODO: Ideally this method would filter out releases a user does not
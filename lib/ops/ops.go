@@ -32,6 +32,7 @@ import (
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/ops/monitoring"
 	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/report"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/storage/clusterconfig"
@@ -130,6 +131,9 @@ type Operator interface {
 	RuntimeEnvironment
 	ClusterConfiguration
 	Audit
+	MaintenanceWindows
+	ChartSigningPolicies
+	HelmRepositories
 }
 
 // Accounts represents a collection of accounts in the portal
@@ -413,7 +417,7 @@ type Sites interface {
 	CompleteFinalInstallStep(CompleteFinalInstallStepRequest) error
 
 	// GetSiteReport returns a tarball that contains all debugging information gathered for the site
-	GetSiteReport(SiteKey) (io.ReadCloser, error)
+	GetSiteReport(SiteReportRequest) (io.ReadCloser, error)
 
 	// SignTLSKey signs X509 Public Key with X509 certificate authority of this site
 	SignTLSKey(TLSSignRequest) (*TLSSignResponse, error)
@@ -422,6 +426,33 @@ type Sites interface {
 	SignSSHKey(SSHSignRequest) (*SSHSignResponse, error)
 }
 
+// SiteReportRequest is a request to collect the cluster diagnostics report
+type SiteReportRequest struct {
+	// SiteKey identifies the cluster
+	SiteKey `json:"site_key"`
+	// Redaction specifies how to redact sensitive data in the report
+	Redaction report.RedactionConfig `json:"redaction"`
+	// TimeRange limits the collected logs to the specified period
+	TimeRange report.TimeRange `json:"time_range"`
+	// Collectors lists the collector groups to run.
+	// All collectors are run if unspecified
+	Collectors []string `json:"collectors,omitempty"`
+	// Nodes lists hostnames or addresses of the nodes to collect
+	// diagnostics from. Diagnostics are collected from all nodes if unspecified
+	Nodes []string `json:"nodes,omitempty"`
+	// DryRun requests the listing of what would be collected
+	// instead of the report
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// Check validates this request
+func (r SiteReportRequest) Check() error {
+	if err := report.CheckFilters(r.Collectors); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(r.TimeRange.Check())
+}
+
 // TLSSignRequest is a request to sign x509 PublicKey with site's local certificate authority
 type TLSSignRequest struct {
 	// AccountID is account id
@@ -820,6 +851,12 @@ type Operations interface {
 
 	// GetOperationPlan returns plan for the specified operation
 	GetOperationPlan(SiteOperationKey) (*storage.OperationPlan, error)
+
+	// CreateHookAttempt records an application hook attempt of the operation
+	CreateHookAttempt(SiteOperationKey, storage.HookAttempt) error
+
+	// GetHookAttempts returns application hook attempts of the operation
+	GetHookAttempts(SiteOperationKey) ([]storage.HookAttempt, error)
 }
 
 // LogEntry represents a single log line for an operation
@@ -1058,9 +1095,15 @@ func (s *SiteOperation) GetVars() storage.OperationVariables {
 	return storage.OperationVariables{}
 }
 
-// IsFailed returns whether operation is failed
+// IsFailed returns whether operation is failed.
+// Operations that have been rolled back are also considered failed
 func (s *SiteOperation) IsFailed() bool {
-	return s.State == OperationStateFailed
+	return s.State == OperationStateFailed || s.IsRolledBack()
+}
+
+// IsRolledBack returns whether operation has been rolled back
+func (s *SiteOperation) IsRolledBack() bool {
+	return s.State == OperationStateRolledBack
 }
 
 // IsCompleted returns whether the operation has completed successfully
@@ -1070,7 +1113,12 @@ func (s *SiteOperation) IsCompleted() bool {
 
 // IsFinished returns true if the operation has finished (succeeded or failed)
 func (s *SiteOperation) IsFinished() bool {
-	return s.State == OperationStateCompleted || s.State == OperationStateFailed
+	return s.State == OperationStateCompleted || s.IsFailed()
+}
+
+// IsScheduled returns true if the operation waits for the maintenance window to open
+func (s *SiteOperation) IsScheduled() bool {
+	return s.State == OperationStateScheduled
 }
 
 // IsAWS returns true if the operation has AWS provisioner
@@ -1245,6 +1293,12 @@ type CreateSiteExpandOperationRequest struct {
 	Servers map[string]int `json:"servers"`
 	// Provisioner to use for this operation
 	Provisioner string `json:"provisioner"`
+	// IgnoreMaintenanceWindow starts the operation right away even if
+	// the cluster maintenance window is closed
+	IgnoreMaintenanceWindow bool `json:"ignore_maintenance_window,omitempty"`
+	// ReplaceServer is the hostname of the failed master node the joining
+	// node replaces
+	ReplaceServer string `json:"replace_server,omitempty"`
 }
 
 // CheckAndSetDefaults makes sure the request is correct and fills in some unset
@@ -1262,6 +1316,9 @@ func (r *CreateSiteExpandOperationRequest) CheckAndSetDefaults() error {
 	if r.Provisioner == schema.ProvisionerAWSTerraform {
 		r.Variables.AWS.SetDefaults()
 	}
+	if r.ReplaceServer != "" && r.Provisioner != schema.ProvisionerOnPrem {
+		return trace.BadParameter("only on-prem nodes can be replaced")
+	}
 	return nil
 }
 
@@ -1283,6 +1340,11 @@ type CreateSiteShrinkOperationRequest struct {
 	// Used in cases where we recieve an event where the node is being terminated, but may
 	// not have disconnected from the cluster yet.
 	NodeRemoved bool `json:"node_removed"`
+	// IgnoreMaintenanceWindow starts the operation right away even if
+	// the cluster maintenance window is closed
+	IgnoreMaintenanceWindow bool `json:"ignore_maintenance_window,omitempty"`
+	// DrainPolicy optionally defines how the node is drained before removal
+	DrainPolicy *storage.DrainPolicy `json:"drain_policy,omitempty"`
 }
 
 // CheckAndSetDefaults makes sure the request is correct and fills in some unset
@@ -1300,6 +1362,11 @@ func (r *CreateSiteShrinkOperationRequest) CheckAndSetDefaults() error {
 	if len(r.Servers) != 1 {
 		return trace.BadParameter("can delete only one server at a time, got: %v", r.Servers)
 	}
+	if r.DrainPolicy != nil {
+		if err := r.DrainPolicy.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	return nil
 }
 
@@ -1314,6 +1381,13 @@ type CreateSiteAppUpdateOperationRequest struct {
 	App string `json:"package"`
 	// StartAgents specifies whether the operation will automatically start the update agents
 	StartAgents bool `json:"start_agents"`
+	// Strategy optionally defines how regular nodes are upgraded
+	Strategy *storage.UpdateStrategy `json:"strategy,omitempty"`
+	// DrainPolicy optionally defines how nodes are drained before upgrade
+	DrainPolicy *storage.DrainPolicy `json:"drain_policy,omitempty"`
+	// IgnoreMaintenanceWindow starts the operation right away even if
+	// the cluster maintenance window is closed
+	IgnoreMaintenanceWindow bool `json:"ignore_maintenance_window,omitempty"`
 }
 
 // Check validates this request
@@ -1334,6 +1408,9 @@ type CreateClusterGarbageCollectOperationRequest struct {
 	AccountID string `json:"account_id"`
 	// ClusterName is the name of the cluster
 	ClusterName string `json:"cluster_name"`
+	// IgnoreMaintenanceWindow starts the operation right away even if
+	// the cluster maintenance window is closed
+	IgnoreMaintenanceWindow bool `json:"ignore_maintenance_window,omitempty"`
 }
 
 // CreateUpdateEnvarsOperationRequest is a request
@@ -1352,6 +1429,9 @@ type CreateUpdateConfigOperationRequest struct {
 	ClusterKey SiteKey `json:"cluster_key"`
 	// Config specifies the new configuration as JSON-encoded payload
 	Config []byte `json:"config"`
+	// IgnoreMaintenanceWindow starts the operation right away even if
+	// the cluster maintenance window is closed
+	IgnoreMaintenanceWindow bool `json:"ignore_maintenance_window,omitempty"`
 }
 
 // UpdateClusterEnvironRequest is a request
@@ -1837,6 +1917,9 @@ type SetOperationStateRequest struct {
 	State string `json:"state"`
 	// Progress is an optional progress entry to create
 	Progress *ProgressEntry `json:"progress,omitempty"`
+	// FailedServers lists hostnames of servers that failed to join the cluster
+	// when the rest of the expand operation has completed
+	FailedServers []string `json:"failed_servers,omitempty"`
 }
 
 // LogForwarders defines the interface to manage log forwarders
@@ -1851,6 +1934,46 @@ type LogForwarders interface {
 	DeleteLogForwarder(ctx context.Context, key SiteKey, name string) error
 }
 
+// MaintenanceWindows defines the interface to manage the cluster maintenance window
+type MaintenanceWindows interface {
+	// GetMaintenanceWindow returns the cluster maintenance window
+	GetMaintenanceWindow(SiteKey) (storage.MaintenanceWindow, error)
+	// UpsertMaintenanceWindow creates or replaces the cluster maintenance window
+	UpsertMaintenanceWindow(context.Context, SiteKey, storage.MaintenanceWindow) error
+	// DeleteMaintenanceWindow deletes the cluster maintenance window
+	DeleteMaintenanceWindow(context.Context, SiteKey) error
+	// LaunchScheduledOperations launches the oldest scheduled operation
+	// if the maintenance window is open and the cluster can run it
+	LaunchScheduledOperations(context.Context, SiteKey) error
+}
+
+// ChartSigningPolicies defines the interface to manage the cluster chart signing policy
+type ChartSigningPolicies interface {
+	// GetChartSigningPolicy returns the cluster chart signing policy
+	GetChartSigningPolicy(SiteKey) (storage.ChartSigningPolicy, error)
+	// UpsertChartSigningPolicy creates or replaces the cluster chart signing policy
+	UpsertChartSigningPolicy(context.Context, SiteKey, storage.ChartSigningPolicy) error
+	// DeleteChartSigningPolicy deletes the cluster chart signing policy
+	DeleteChartSigningPolicy(context.Context, SiteKey) error
+}
+
+// HelmRepositories defines the interface to manage external chart repositories
+// imported into the cluster catalog
+type HelmRepositories interface {
+	// GetHelmRepositories returns all configured helm repositories
+	//
+	// Returned repositories exclude the password unless withSecrets is true.
+	GetHelmRepositories(key SiteKey, withSecrets bool) ([]storage.HelmRepository, error)
+	// GetHelmRepository returns the helm repository with the specified name
+	//
+	// Returned repository excludes the password unless withSecrets is true.
+	GetHelmRepository(key SiteKey, name string, withSecrets bool) (storage.HelmRepository, error)
+	// UpsertHelmRepository creates or updates the helm repository
+	UpsertHelmRepository(context.Context, SiteKey, storage.HelmRepository) error
+	// DeleteHelmRepository deletes the helm repository with the specified name
+	DeleteHelmRepository(ctx context.Context, key SiteKey, name string) error
+}
+
 // SMTP defines the interface to manage cluster SMTP configuration
 type SMTP interface {
 	// GetSMTPConfig returns the cluster SMTP configuration
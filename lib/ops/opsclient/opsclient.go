@@ -17,6 +17,7 @@ limitations under the License.
 package opsclient
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -684,12 +685,8 @@ func (c *Client) GetSiteOperationCrashReport(key ops.SiteOperationKey) (io.ReadC
 	return file.Body(), nil
 }
 
-func (c *Client) GetSiteReport(key ops.SiteKey) (io.ReadCloser, error) {
-	file, err := c.GetFile(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "report"), url.Values{})
-	if err != nil {
-		return nil, trace.Wrap(err)
-	}
-	return file.Body(), nil
+func (c *Client) GetSiteReport(req ops.SiteReportRequest) (io.ReadCloser, error) {
+	return c.PostJSONFile(c.Endpoint("accounts", req.AccountID, "sites", req.SiteDomain, "report"), req)
 }
 
 func (c *Client) UpsertRepository(repository string) error {
@@ -891,6 +888,33 @@ func (c *Client) GetOperationPlan(key ops.SiteOperationKey) (*storage.OperationP
 	return &plan, nil
 }
 
+// CreateHookAttempt records an application hook attempt of the operation
+func (c *Client) CreateHookAttempt(key ops.SiteOperationKey, attempt storage.HookAttempt) error {
+	_, err := c.PostJSON(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "operations", "common", key.OperationID, "hooks"),
+		attempt)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetHookAttempts returns application hook attempts of the operation
+func (c *Client) GetHookAttempts(key ops.SiteOperationKey) ([]storage.HookAttempt, error) {
+	out, err := c.Get(c.Endpoint(
+		"accounts", key.AccountID, "sites", key.SiteDomain, "operations", "common", key.OperationID, "hooks"),
+		url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var attempts []storage.HookAttempt
+	err = json.Unmarshal(out.Bytes(), &attempts)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return attempts, nil
+}
+
 // Configure packages configures packages for the specified install operation
 func (c *Client) ConfigurePackages(req ops.ConfigurePackagesRequest) error {
 	_, err := c.PostJSON(c.Endpoint(
@@ -1532,6 +1556,120 @@ func (c *Client) GetAuthGateway(key ops.SiteKey) (storage.AuthGateway, error) {
 	return storage.UnmarshalAuthGateway(response.Bytes())
 }
 
+// GetMaintenanceWindow returns the cluster maintenance window
+func (c *Client) GetMaintenanceWindow(key ops.SiteKey) (storage.MaintenanceWindow, error) {
+	response, err := c.Get(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "maintenancewindow"),
+		url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return storage.UnmarshalMaintenanceWindow(response.Bytes())
+}
+
+// UpsertMaintenanceWindow creates or updates the cluster maintenance window
+func (c *Client) UpsertMaintenanceWindow(ctx context.Context, key ops.SiteKey, window storage.MaintenanceWindow) error {
+	bytes, err := storage.MarshalMaintenanceWindow(window)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "maintenancewindow"),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeleteMaintenanceWindow deletes the cluster maintenance window
+func (c *Client) DeleteMaintenanceWindow(ctx context.Context, key ops.SiteKey) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "maintenancewindow"))
+	return trace.Wrap(err)
+}
+
+// GetChartSigningPolicy returns the cluster chart signing policy
+func (c *Client) GetChartSigningPolicy(key ops.SiteKey) (storage.ChartSigningPolicy, error) {
+	response, err := c.Get(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "chartsigningpolicy"),
+		url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return storage.UnmarshalChartSigningPolicy(response.Bytes())
+}
+
+// UpsertChartSigningPolicy creates or updates the cluster chart signing policy
+func (c *Client) UpsertChartSigningPolicy(ctx context.Context, key ops.SiteKey, policy storage.ChartSigningPolicy) error {
+	bytes, err := storage.MarshalChartSigningPolicy(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "chartsigningpolicy"),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeleteChartSigningPolicy deletes the cluster chart signing policy
+func (c *Client) DeleteChartSigningPolicy(ctx context.Context, key ops.SiteKey) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "chartsigningpolicy"))
+	return trace.Wrap(err)
+}
+
+// GetHelmRepositories returns all configured helm repositories
+//
+// Returned repositories exclude the password unless withSecrets is true.
+func (c *Client) GetHelmRepositories(key ops.SiteKey, withSecrets bool) ([]storage.HelmRepository, error) {
+	response, err := c.Get(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "helmrepositories"),
+		url.Values{constants.WithSecretsParam: []string{fmt.Sprintf("%t", withSecrets)}})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var items []json.RawMessage
+	if err = json.Unmarshal(response.Bytes(), &items); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	repositories := make([]storage.HelmRepository, len(items))
+	for i, item := range items {
+		repository, err := storage.UnmarshalHelmRepository(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		repositories[i] = repository
+	}
+	return repositories, nil
+}
+
+// GetHelmRepository returns the helm repository with the specified name
+//
+// Returned repository excludes the password unless withSecrets is true.
+func (c *Client) GetHelmRepository(key ops.SiteKey, name string, withSecrets bool) (storage.HelmRepository, error) {
+	response, err := c.Get(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "helmrepositories", name),
+		url.Values{constants.WithSecretsParam: []string{fmt.Sprintf("%t", withSecrets)}})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return storage.UnmarshalHelmRepository(response.Bytes())
+}
+
+// UpsertHelmRepository creates or updates the helm repository
+func (c *Client) UpsertHelmRepository(ctx context.Context, key ops.SiteKey, repository storage.HelmRepository) error {
+	bytes, err := storage.MarshalHelmRepository(repository)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = c.PutJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "helmrepositories", repository.GetName()),
+		&UpsertResourceRawReq{Resource: bytes})
+	return trace.Wrap(err)
+}
+
+// DeleteHelmRepository deletes the helm repository with the specified name
+func (c *Client) DeleteHelmRepository(ctx context.Context, key ops.SiteKey, name string) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "helmrepositories", name))
+	return trace.Wrap(err)
+}
+
+// LaunchScheduledOperations launches operations waiting for the maintenance window
+func (c *Client) LaunchScheduledOperations(ctx context.Context, key ops.SiteKey) error {
+	_, err := c.PostJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "operations", "scheduled", "launch"),
+		struct{}{})
+	return trace.Wrap(err)
+}
+
 // ListReleases returns all currently installed application releases in a cluster.
 func (c *Client) ListReleases(key ops.SiteKey) ([]storage.Release, error) {
 	response, err := c.Get(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "releases"),
@@ -1597,6 +1735,37 @@ func (c *Client) GetFile(endpoint string, params url.Values) (*roundtrip.FileRes
 	return re, nil
 }
 
+// PostJSONFile issues HTTP POST request to the server with the provided JSON data
+// and returns the response body as a stream
+func (c *Client) PostJSONFile(endpoint string, data interface{}) (io.ReadCloser, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.SetAuthHeader(req.Header)
+	re, err := c.HTTPClient().Do(req)
+	if err != nil {
+		if uerr, ok := err.(*url.Error); ok && uerr != nil && uerr.Err != nil {
+			return nil, trace.Wrap(uerr.Err)
+		}
+		return nil, trace.Wrap(err)
+	}
+	if re.StatusCode < 200 || re.StatusCode > 299 {
+		defer re.Body.Close()
+		bytes, err := ioutil.ReadAll(re.Body)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return nil, trace.ReadError(re.StatusCode, bytes)
+	}
+	return re.Body, nil
+}
+
 // Delete issues HTTP DELETE request to the server
 func (c *Client) Delete(endpoint string) (*roundtrip.Response, error) {
 	return telehttplib.ConvertResponse(c.Client.Delete(context.TODO(), endpoint))
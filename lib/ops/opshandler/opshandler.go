@@ -138,6 +138,7 @@ func NewWebHandler(cfg WebHandlerConfig) (*WebHandler, error) {
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain", h.needsAuth(h.getSite))
 	h.GET("/portal/v1/accounts/:account_id/sites", h.needsAuth(h.getSites))
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/report", h.needsAuth(h.getSiteReport))
+	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/report", h.needsAuth(h.getSiteReportWithRequest))
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/deactivate", h.needsAuth(h.deactivateSite))
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/activate", h.needsAuth(h.activateSite))
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/complete", h.needsAuth(h.completeFinalInstallStep))
@@ -215,6 +216,8 @@ func NewWebHandler(cfg WebHandlerConfig) (*WebHandler, error) {
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/plan/changelog", h.needsAuth(h.createOperationPlanChange))
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/plan", h.needsAuth(h.getOperationPlan))
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/plan/configure", h.needsAuth(h.configurePackages))
+	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/hooks", h.needsAuth(h.createHookAttempt))
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/hooks", h.needsAuth(h.getHookAttempts))
 
 	// log forwarders
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/logs/forwarders", h.needsAuth(h.getLogForwarders))
@@ -292,6 +295,34 @@ func NewWebHandler(cfg WebHandlerConfig) (*WebHandler, error) {
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/authgateway",
 		h.needsAuth(h.getAuthGateway))
 
+	// maintenance window
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/maintenancewindow",
+		h.needsAuth(h.getMaintenanceWindow))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/maintenancewindow",
+		h.needsAuth(h.upsertMaintenanceWindow))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/maintenancewindow",
+		h.needsAuth(h.deleteMaintenanceWindow))
+	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/operations/scheduled/launch",
+		h.needsAuth(h.launchScheduledOperations))
+
+	// chart signing policy
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/chartsigningpolicy",
+		h.needsAuth(h.getChartSigningPolicy))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/chartsigningpolicy",
+		h.needsAuth(h.upsertChartSigningPolicy))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/chartsigningpolicy",
+		h.needsAuth(h.deleteChartSigningPolicy))
+
+	// helm repositories
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/helmrepositories",
+		h.needsAuth(h.getHelmRepositories))
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/helmrepositories/:name",
+		h.needsAuth(h.getHelmRepository))
+	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/helmrepositories/:name",
+		h.needsAuth(h.upsertHelmRepository))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/helmrepositories/:name",
+		h.needsAuth(h.deleteHelmRepository))
+
 	// application releases
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/releases",
 		h.needsAuth(h.getReleases))
@@ -370,16 +401,20 @@ func (h *WebHandler) getApps(w http.ResponseWriter, r *http.Request, p httproute
 	return nil
 }
 
-/* getGravityBinary exports the cluster's gravity binary.
+/* getGravityBinary exports the cluster's gravity binary for the optional architecture.
 
-   GET /portal/v1/gravity
+   GET /portal/v1/gravity?arch=<arch>
 */
 func (h *WebHandler) getGravityBinary(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
 	cluster, err := ctx.Operator.GetLocalSite()
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	gravityPackage, err := cluster.App.Manifest.Dependencies.ByName(constants.GravityPackage)
+	arch := r.URL.Query().Get("arch")
+	if arch == "" {
+		arch = defaults.Architecture
+	}
+	gravityPackage, err := cluster.App.Manifest.DependencyForArch(constants.GravityPackage, arch)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -1005,7 +1040,27 @@ func (h *WebHandler) activateSite(w http.ResponseWriter, r *http.Request, p http
    GET /portal/v1/accounts/:account_id/sites/:site_domain/report
 */
 func (h *WebHandler) getSiteReport(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
-	report, err := context.Operator.GetSiteReport(siteKey(p))
+	return h.writeSiteReport(w, r, ops.SiteReportRequest{SiteKey: siteKey(p)}, context)
+}
+
+/* getSiteReportWithRequest returns a tarball with information about the site
+   collected according to the specified request
+
+   POST /portal/v1/accounts/:account_id/sites/:site_domain/report
+
+   Input: ops.SiteReportRequest
+*/
+func (h *WebHandler) getSiteReportWithRequest(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	var req ops.SiteReportRequest
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	req.SiteKey = siteKey(p)
+	return h.writeSiteReport(w, r, req, context)
+}
+
+func (h *WebHandler) writeSiteReport(w http.ResponseWriter, r *http.Request, req ops.SiteReportRequest, context *HandlerContext) error {
+	report, err := context.Operator.GetSiteReport(req)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -1532,6 +1587,41 @@ func (h *WebHandler) getOperationPlan(w http.ResponseWriter, r *http.Request, p
 	return nil
 }
 
+/* createHookAttempt records an application hook attempt of the operation
+
+   POST /portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/hooks
+
+   Success response: {"status": "ok", "message": "hook attempt created"}
+*/
+func (h *WebHandler) createHookAttempt(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	var attempt storage.HookAttempt
+	err := json.NewDecoder(r.Body).Decode(&attempt)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = context.Operator.CreateHookAttempt(siteOperationKey(p), attempt)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("hook attempt created"))
+	return nil
+}
+
+/* getHookAttempts returns application hook attempts of the operation
+
+   GET /portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/hooks
+
+   Success response: []storage.HookAttempt
+*/
+func (h *WebHandler) getHookAttempts(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	attempts, err := context.Operator.GetHookAttempts(siteOperationKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, attempts)
+	return nil
+}
+
 /* configurePackages configures install packages
 
    POST /portal/v1/accounts/:account_id/sites/:site_domain/operations/common/:operation_id/plan/configure
@@ -2148,6 +2238,239 @@ func (h *WebHandler) deleteSMTPConfig(w http.ResponseWriter, r *http.Request, p
 	return nil
 }
 
+/* getMaintenanceWindow returns the cluster maintenance window
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/maintenancewindow
+
+   Success Response:
+
+     storage.MaintenanceWindow
+*/
+func (h *WebHandler) getMaintenanceWindow(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	window, err := context.Operator.GetMaintenanceWindow(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	bytes, err := storage.MarshalMaintenanceWindow(window)
+	return rawMessage(w, bytes, err)
+}
+
+/* upsertMaintenanceWindow creates or updates the cluster maintenance window
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/maintenancewindow
+
+   Success Response:
+
+     {
+       "message": "maintenance window updated"
+     }
+*/
+func (h *WebHandler) upsertMaintenanceWindow(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	window, err := storage.UnmarshalMaintenanceWindow(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = context.Operator.UpsertMaintenanceWindow(r.Context(), siteKey(p), window)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("maintenance window updated"))
+	return nil
+}
+
+/* deleteMaintenanceWindow deletes the cluster maintenance window
+
+   DELETE /portal/v1/accounts/:account_id/sites/:site_domain/maintenancewindow
+
+   Success Response:
+
+     {
+       "message": "maintenance window deleted"
+     }
+*/
+func (h *WebHandler) deleteMaintenanceWindow(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	err := context.Operator.DeleteMaintenanceWindow(r.Context(), siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("maintenance window deleted"))
+	return nil
+}
+
+/* getChartSigningPolicy returns the cluster chart signing policy
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/chartsigningpolicy
+
+   Success Response:
+
+     storage.ChartSigningPolicy
+*/
+func (h *WebHandler) getChartSigningPolicy(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	policy, err := context.Operator.GetChartSigningPolicy(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	bytes, err := storage.MarshalChartSigningPolicy(policy)
+	return rawMessage(w, bytes, err)
+}
+
+/* upsertChartSigningPolicy creates or updates the cluster chart signing policy
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/chartsigningpolicy
+
+   Success Response:
+
+     {
+       "message": "chart signing policy updated"
+     }
+*/
+func (h *WebHandler) upsertChartSigningPolicy(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	policy, err := storage.UnmarshalChartSigningPolicy(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = context.Operator.UpsertChartSigningPolicy(r.Context(), siteKey(p), policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("chart signing policy updated"))
+	return nil
+}
+
+/* deleteChartSigningPolicy deletes the cluster chart signing policy
+
+   DELETE /portal/v1/accounts/:account_id/sites/:site_domain/chartsigningpolicy
+
+   Success Response:
+
+     {
+       "message": "chart signing policy deleted"
+     }
+*/
+func (h *WebHandler) deleteChartSigningPolicy(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	err := context.Operator.DeleteChartSigningPolicy(r.Context(), siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("chart signing policy deleted"))
+	return nil
+}
+
+/* getHelmRepositories returns all configured helm repositories
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/helmrepositories?with_secrets=<bool>
+
+   Success Response:
+
+     []storage.HelmRepository
+*/
+func (h *WebHandler) getHelmRepositories(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	withSecrets, _, err := telehttplib.ParseBool(r.URL.Query(), constants.WithSecretsParam)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	repositories, err := context.Operator.GetHelmRepositories(siteKey(p), withSecrets)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, repositories)
+	return nil
+}
+
+/* getHelmRepository returns the helm repository with the specified name
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/helmrepositories/:name?with_secrets=<bool>
+
+   Success Response:
+
+     storage.HelmRepository
+*/
+func (h *WebHandler) getHelmRepository(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	withSecrets, _, err := telehttplib.ParseBool(r.URL.Query(), constants.WithSecretsParam)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	repository, err := context.Operator.GetHelmRepository(siteKey(p), p.ByName("name"), withSecrets)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	bytes, err := storage.MarshalHelmRepository(repository)
+	return rawMessage(w, bytes, err)
+}
+
+/* upsertHelmRepository creates or updates the helm repository
+
+     PUT /portal/v1/accounts/:account_id/sites/:site_domain/helmrepositories/:name
+
+   Success Response:
+
+     {
+       "message": "helm repository updated"
+     }
+*/
+func (h *WebHandler) upsertHelmRepository(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	repository, err := storage.UnmarshalHelmRepository(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = context.Operator.UpsertHelmRepository(r.Context(), siteKey(p), repository)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("helm repository updated"))
+	return nil
+}
+
+/* deleteHelmRepository deletes the helm repository with the specified name
+
+   DELETE /portal/v1/accounts/:account_id/sites/:site_domain/helmrepositories/:name
+
+   Success Response:
+
+     {
+       "message": "helm repository deleted"
+     }
+*/
+func (h *WebHandler) deleteHelmRepository(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	err := context.Operator.DeleteHelmRepository(r.Context(), siteKey(p), p.ByName("name"))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("helm repository deleted"))
+	return nil
+}
+
+/* launchScheduledOperations launches operations waiting for the maintenance window
+
+     POST /portal/v1/accounts/:account_id/sites/:site_domain/operations/scheduled/launch
+
+   Success Response:
+
+     {
+       "message": "ok"
+     }
+*/
+func (h *WebHandler) launchScheduledOperations(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	err := context.Operator.LaunchScheduledOperations(r.Context(), siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("ok"))
+	return nil
+}
+
 /* getApplicationEndpoints returns application endpoints for a deployed cluster
 
      GET /portal/v1/accounts/:account_id/sites/:site_domain/endpoints
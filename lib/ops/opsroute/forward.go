@@ -425,12 +425,12 @@ func (r *Router) GetSiteOperationCrashReport(key ops.SiteOperationKey) (io.ReadC
 	return client.GetSiteOperationCrashReport(key)
 }
 
-func (r *Router) GetSiteReport(key ops.SiteKey) (io.ReadCloser, error) {
-	client, err := r.PickClient(key.SiteDomain)
+func (r *Router) GetSiteReport(req ops.SiteReportRequest) (io.ReadCloser, error) {
+	client, err := r.PickClient(req.SiteDomain)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return client.GetSiteReport(key)
+	return client.GetSiteReport(req)
 }
 
 // ValidateServers runs pre-installation checks
@@ -515,6 +515,24 @@ func (r *Router) GetOperationPlan(key ops.SiteOperationKey) (*storage.OperationP
 	return client.GetOperationPlan(key)
 }
 
+// CreateHookAttempt records an application hook attempt of the operation
+func (r *Router) CreateHookAttempt(key ops.SiteOperationKey, attempt storage.HookAttempt) error {
+	client, err := r.PickOperationClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.CreateHookAttempt(key, attempt)
+}
+
+// GetHookAttempts returns application hook attempts of the operation
+func (r *Router) GetHookAttempts(key ops.SiteOperationKey) ([]storage.HookAttempt, error) {
+	client, err := r.PickOperationClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetHookAttempts(key)
+}
+
 // Configure packages configures packages for the specified install operation
 func (r *Router) ConfigurePackages(req ops.ConfigurePackagesRequest) error {
 	client, err := r.PickOperationClient(req.SiteDomain)
@@ -621,6 +639,109 @@ func (r *Router) DeleteSMTPConfig(ctx context.Context, key ops.SiteKey) error {
 	return client.DeleteSMTPConfig(ctx, key)
 }
 
+// GetMaintenanceWindow returns the cluster maintenance window
+func (r *Router) GetMaintenanceWindow(key ops.SiteKey) (storage.MaintenanceWindow, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetMaintenanceWindow(key)
+}
+
+// UpsertMaintenanceWindow creates or updates the cluster maintenance window
+func (r *Router) UpsertMaintenanceWindow(ctx context.Context, key ops.SiteKey, window storage.MaintenanceWindow) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertMaintenanceWindow(ctx, key, window)
+}
+
+// DeleteMaintenanceWindow deletes the cluster maintenance window
+func (r *Router) DeleteMaintenanceWindow(ctx context.Context, key ops.SiteKey) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteMaintenanceWindow(ctx, key)
+}
+
+// GetChartSigningPolicy returns the cluster chart signing policy
+func (r *Router) GetChartSigningPolicy(key ops.SiteKey) (storage.ChartSigningPolicy, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetChartSigningPolicy(key)
+}
+
+// UpsertChartSigningPolicy creates or updates the cluster chart signing policy
+func (r *Router) UpsertChartSigningPolicy(ctx context.Context, key ops.SiteKey, policy storage.ChartSigningPolicy) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertChartSigningPolicy(ctx, key, policy)
+}
+
+// DeleteChartSigningPolicy deletes the cluster chart signing policy
+func (r *Router) DeleteChartSigningPolicy(ctx context.Context, key ops.SiteKey) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteChartSigningPolicy(ctx, key)
+}
+
+// GetHelmRepositories returns all configured helm repositories
+//
+// Returned repositories exclude the password unless withSecrets is true.
+func (r *Router) GetHelmRepositories(key ops.SiteKey, withSecrets bool) ([]storage.HelmRepository, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetHelmRepositories(key, withSecrets)
+}
+
+// GetHelmRepository returns the helm repository with the specified name
+//
+// Returned repository excludes the password unless withSecrets is true.
+func (r *Router) GetHelmRepository(key ops.SiteKey, name string, withSecrets bool) (storage.HelmRepository, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetHelmRepository(key, name, withSecrets)
+}
+
+// UpsertHelmRepository creates or updates the helm repository
+func (r *Router) UpsertHelmRepository(ctx context.Context, key ops.SiteKey, repository storage.HelmRepository) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertHelmRepository(ctx, key, repository)
+}
+
+// DeleteHelmRepository deletes the helm repository with the specified name
+func (r *Router) DeleteHelmRepository(ctx context.Context, key ops.SiteKey, name string) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteHelmRepository(ctx, key, name)
+}
+
+// LaunchScheduledOperations launches operations waiting for the maintenance window
+func (r *Router) LaunchScheduledOperations(ctx context.Context, key ops.SiteKey) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.LaunchScheduledOperations(ctx, key)
+}
+
 // GetAlerts returns a list of monitoring alerts
 func (r *Router) GetAlerts(key ops.SiteKey) ([]storage.Alert, error) {
 	client, err := r.RemoteClient(key.SiteDomain)
@@ -0,0 +1,60 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"context"
+
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/ops/events"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// GetChartSigningPolicy returns the cluster chart signing policy
+func (o *Operator) GetChartSigningPolicy(key ops.SiteKey) (storage.ChartSigningPolicy, error) {
+	policy, err := o.backend().GetChartSigningPolicy()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// UpsertChartSigningPolicy creates or updates the cluster chart signing policy
+func (o *Operator) UpsertChartSigningPolicy(ctx context.Context, key ops.SiteKey, policy storage.ChartSigningPolicy) error {
+	err := policy.CheckAndSetDefaults()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = o.backend().UpsertChartSigningPolicy(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	events.Emit(ctx, o, events.ChartSigningPolicyCreated)
+	return nil
+}
+
+// DeleteChartSigningPolicy deletes the cluster chart signing policy
+func (o *Operator) DeleteChartSigningPolicy(ctx context.Context, key ops.SiteKey) error {
+	err := o.backend().DeleteChartSigningPolicy()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	events.Emit(ctx, o, events.ChartSigningPolicyDeleted)
+	return nil
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
 
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
@@ -44,7 +45,16 @@ func (o *Operator) ValidateServers(req ops.ValidateServersRequest) error {
 		return trace.Wrap(err)
 	}
 
-	err = ops.CheckServers(context.TODO(), op.Key(), infos, req.Servers,
+	var clusterServers []storage.Server
+	if op.Type == ops.OperationExpand {
+		site, err := o.GetSite(req.SiteKey())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		clusterServers = site.ClusterState.Servers
+	}
+
+	err = ops.CheckServers(context.TODO(), op.Key(), infos, req.Servers, clusterServers,
 		cluster.agentService(), cluster.app.Manifest)
 	if err != nil {
 		return trace.Wrap(ops.FormatValidationError(err))
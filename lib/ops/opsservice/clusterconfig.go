@@ -155,7 +155,7 @@ func (s *site) createUpdateConfigOperation(ctx context.Context, req ops.CreateUp
 			Config:     req.Config,
 		},
 	}
-	key, err := s.getOperationGroup().createSiteOperation(op)
+	key, err := s.getOperationGroup().createSiteOperationInWindow(op, req.IgnoreMaintenanceWindow)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -94,6 +94,14 @@ func (o *Operator) ConfigurePackages(req ops.ConfigurePackagesRequest) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	// the replaced master is removed from the cluster state so the joining
+	// node can take over its hostname
+	if replaceServer := operation.InstallExpand.ReplaceServer; replaceServer != nil {
+		err = site.removeClusterStateServers([]string{replaceServer.Hostname})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	// cluster state servers need to be added before configuring packages so
 	// they make it into the site export package
 	err = site.addClusterStateServers(operation.Servers)
@@ -127,13 +135,25 @@ func (s *site) getEtcdConfig(ctx context.Context, opCtx *operationContext, serve
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	initialCluster := []string{opCtx.provisionedServers.InitialCluster(s.domainName)}
+	// joining masters are added to etcd one at a time, in the order of the
+	// operation servers (which is also the order of the plan), so each of
+	// them only expects the masters that have been added before it
+	joining := provisionedServers{server}
+	if server.IsMaster() {
+		joining = opCtx.provisionedServers.mastersUpTo(server)
+	}
+	initialCluster := []string{joining.InitialCluster(s.domainName)}
 	// add existing members
 	for _, member := range members {
 		address, err := utils.URLHostname(member.PeerURLs[0])
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
+		// the member of the replaced master is removed before the
+		// joining node starts its etcd
+		if replaceServer := opCtx.operation.InstallExpand.ReplaceServer; replaceServer != nil && replaceServer.AdvertiseIP == address {
+			continue
+		}
 		initialCluster = append(initialCluster, fmt.Sprintf("%s:%s",
 			member.Name, address))
 	}
@@ -192,7 +212,17 @@ func (s *site) configureExpandPackages(ctx context.Context, opCtx *operationCont
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	provisionedServer := opCtx.provisionedServers[0]
+	for _, server := range opCtx.provisionedServers {
+		err := s.configureExpandServer(ctx, opCtx, server, teleportMasterIPs)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// configureExpandServer configures packages for the specified joining server
+func (s *site) configureExpandServer(ctx context.Context, opCtx *operationContext, provisionedServer *ProvisionedServer, teleportMasterIPs []string) error {
 	etcdConfig, err := s.getEtcdConfig(ctx, opCtx, provisionedServer)
 	if err != nil {
 		return trace.Wrap(err)
@@ -201,7 +231,7 @@ func (s *site) configureExpandPackages(ctx context.Context, opCtx *operationCont
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	planetPackage, err := s.app.Manifest.RuntimePackage(provisionedServer.Profile)
+	planetPackage, err := s.app.Manifest.RuntimePackageForArch(provisionedServer.Role, provisionedServer.Arch)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -336,7 +366,7 @@ func (s *site) configurePackages(ctx *operationContext, req ops.ConfigurePackage
 			return trace.Wrap(err)
 		}
 
-		planetPackage, err := s.app.Manifest.RuntimePackage(master.Profile)
+		planetPackage, err := s.app.Manifest.RuntimePackageForArch(master.Role, master.Arch)
 		if err != nil {
 			return trace.Wrap(err)
 		}
@@ -414,7 +444,7 @@ func (s *site) configurePackages(ctx *operationContext, req ops.ConfigurePackage
 			return trace.Wrap(err)
 		}
 
-		planetPackage, err := s.app.Manifest.RuntimePackage(node.Profile)
+		planetPackage, err := s.app.Manifest.RuntimePackageForArch(node.Role, node.Arch)
 		if err != nil {
 			return trace.Wrap(err)
 		}
@@ -1464,7 +1494,7 @@ func (s *site) serverPackages(server *ProvisionedServer) ([]loc.Locator, error)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	planetPackage, err := s.app.Manifest.RuntimePackageForProfile(server.Role)
+	planetPackage, err := s.app.Manifest.RuntimePackageForArch(server.Role, server.Arch)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
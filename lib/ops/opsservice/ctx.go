@@ -17,6 +17,7 @@ limitations under the License.
 package opsservice
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"time"
@@ -51,6 +52,10 @@ type operationContext struct {
 	// serversToRemove is a list of servers to remove
 	// in shrink operation
 	serversToRemove []storage.Server
+	// ctx is canceled when the operation context is closed
+	ctx context.Context
+	// cancel cancels ctx
+	cancel context.CancelFunc
 }
 
 func (s *site) newOperationContext(operation ops.SiteOperation) (*operationContext, error) {
@@ -65,10 +70,13 @@ func (s *site) newOperationContext(operation ops.SiteOperation) (*operationConte
 		Hooks:     entry.Logger.Hooks,
 		Level:     entry.Logger.Level,
 	}
+	opCtx, cancel := context.WithCancel(context.Background())
 	ctx := &operationContext{
 		recorder:  recorder,
 		operation: operation,
 		Entry:     entry,
+		ctx:       opCtx,
+		cancel:    cancel,
 	}
 	return ctx, nil
 }
@@ -150,6 +158,7 @@ func (c *operationContext) WithFields(fields log.Fields) *log.Entry {
 
 // Close closes operation context resources, e.g. file handles
 func (c *operationContext) Close() error {
+	c.cancel()
 	return c.recorder.Close()
 }
 
@@ -22,6 +22,7 @@ import (
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
@@ -31,6 +32,20 @@ import (
 func (s *site) createExpandOperation(ctx context.Context, req ops.CreateSiteExpandOperationRequest) (*ops.SiteOperationKey, error) {
 	log.Debugf("createExpandOperation(%#v)", req)
 
+	var replaceServer *storage.Server
+	if req.ReplaceServer != "" {
+		cluster, err := s.service.GetSite(s.key)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		replaceServer, err = s.validateReplaceRequest(req, *cluster)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		// the joining node reuses the profile of the replaced node
+		req.Servers = map[string]int{replaceServer.Role: 1}
+	}
+
 	profiles := make(map[string]storage.ServerProfile)
 	for role, count := range req.Servers {
 		profile, err := s.app.Manifest.NodeProfiles.ByName(role)
@@ -47,14 +62,47 @@ func (s *site) createExpandOperation(ctx context.Context, req ops.CreateSiteExpa
 		}
 	}
 	return s.createInstallExpandOperation(ctx, createInstallExpandOperationRequest{
-		Type:        ops.OperationExpand,
-		State:       ops.OperationStateExpandInitiated,
-		Provisioner: req.Provisioner,
-		Vars:        req.Variables,
-		Profiles:    profiles,
+		Type:                    ops.OperationExpand,
+		State:                   ops.OperationStateExpandInitiated,
+		Provisioner:             req.Provisioner,
+		Vars:                    req.Variables,
+		Profiles:                profiles,
+		IgnoreMaintenanceWindow: req.IgnoreMaintenanceWindow,
+		ReplaceServer:           replaceServer,
 	})
 }
 
+// validateReplaceRequest returns the failed master node to replace
+func (s *site) validateReplaceRequest(req ops.CreateSiteExpandOperationRequest, cluster ops.Site) (*storage.Server, error) {
+	server, err := cluster.ClusterState.FindServer(req.ReplaceServer)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !server.IsMaster() {
+		return nil, trace.BadParameter(
+			"node %v is not a master, use 'gravity remove %v --force' to remove it",
+			server.Hostname, server.Hostname)
+	}
+	// etcd keeps its quorum without the failed member only if there are
+	// at least two healthy members left
+	masters := storage.Servers(cluster.ClusterState.Servers).Masters()
+	if len(masters) < 3 {
+		return nil, trace.BadParameter(
+			"cannot replace a master in a cluster with %v master node(-s), "+
+				"the etcd cluster needs at least 3 members to survive the loss of one",
+			len(masters))
+	}
+	servers, err := s.getAllTeleportServers()
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to query teleport servers")
+	}
+	if len(servers.getWithLabels(labels{ops.Hostname: server.Hostname})) != 0 {
+		return nil, trace.BadParameter(
+			"node %v is online, only failed nodes can be replaced", server.Hostname)
+	}
+	return server, nil
+}
+
 func (s *site) getSiteOperation(operationID string) (*ops.SiteOperation, error) {
 	op, err := s.backend().GetSiteOperation(s.key.SiteDomain, operationID)
 	if err != nil {
@@ -125,9 +173,11 @@ func (s *site) expandOperationStart(ctx *operationContext) error {
 
 func (s *site) validateExpand(op *ops.SiteOperation, req *ops.OperationUpdateRequest) error {
 	if op.Provisioner == schema.ProvisionerOnPrem {
-		if len(req.Servers) > 1 {
+		expected := op.InstallExpand.ServerCount()
+		if len(req.Servers) > expected {
 			return trace.BadParameter(
-				"can only add one node at a time, stop agents on %v extra node(-s)", len(req.Servers)-1)
+				"operation expects %v node(-s), stop agents on %v extra node(-s)",
+				expected, len(req.Servers)-expected)
 		} else if len(req.Servers) == 0 {
 			return trace.BadParameter(
 				"no servers provided, run agent command on the node you want to join")
@@ -152,6 +202,47 @@ func (s *site) validateExpand(op *ops.SiteOperation, req *ops.OperationUpdateReq
 		return trace.Wrap(err)
 	}
 
+	if op.InstallExpand.ReplaceServer != nil {
+		return trace.Wrap(validateReplace(*op.InstallExpand.ReplaceServer, req.Servers))
+	}
+
 	err = setClusterRoles(req.Servers, *s.app, len(masters))
 	return trace.Wrap(err)
 }
+
+// validateReplace makes sure the node joining in place of the specified
+// failed master takes over its role
+func validateReplace(replaceServer storage.Server, servers []storage.Server) error {
+	if len(servers) != 1 {
+		return trace.BadParameter("expected a single node to replace %v, got %v",
+			replaceServer.Hostname, len(servers))
+	}
+	if servers[0].Role != replaceServer.Role {
+		return trace.BadParameter("node replacing %v should have role %q, got %q",
+			replaceServer.Hostname, replaceServer.Role, servers[0].Role)
+	}
+	// the failed master may still be registered so the cluster role is
+	// not subject to the limit on the number of masters
+	servers[0].ClusterRole = string(schema.ServiceRoleMaster)
+	return nil
+}
+
+// removeFailedServers removes the servers that failed to join the cluster
+// from the cluster state once the rest of the expand operation has completed
+func (s *site) removeFailedServers(key ops.SiteOperationKey, req ops.SetOperationStateRequest) error {
+	operation, err := s.getSiteOperation(key.OperationID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if operation.Type != ops.OperationExpand || req.State != ops.OperationStateCompleted {
+		return trace.BadParameter("failed servers can only be specified when completing %v operation",
+			ops.OperationExpand)
+	}
+	for _, hostname := range req.FailedServers {
+		if !utils.StringInSlice(storage.Hostnames(operation.Servers), hostname) {
+			return trace.BadParameter("server %v is not part of %v", hostname, operation)
+		}
+	}
+	log.Infof("Removing servers %v that failed to join from the cluster state.", req.FailedServers)
+	return trace.Wrap(s.removeClusterStateServers(req.FailedServers))
+}
@@ -44,7 +44,7 @@ func (s *site) createGarbageCollectOperation(ctx context.Context, req ops.Create
 		State:      ops.OperationGarbageCollectInProgress,
 	}
 
-	key, err := s.getOperationGroup().createSiteOperation(op)
+	key, err := s.getOperationGroup().createSiteOperationInWindow(op, req.IgnoreMaintenanceWindow)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
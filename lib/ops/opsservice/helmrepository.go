@@ -0,0 +1,96 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"context"
+
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/ops/events"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// GetHelmRepositories returns all configured helm repositories
+//
+// Returned repositories exclude the password unless withSecrets is true.
+func (o *Operator) GetHelmRepositories(key ops.SiteKey, withSecrets bool) ([]storage.HelmRepository, error) {
+	repositories, err := o.backend().GetHelmRepositories()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !withSecrets {
+		for i, repository := range repositories {
+			repositories[i] = repository.WithoutSecrets()
+		}
+	}
+	return repositories, nil
+}
+
+// GetHelmRepository returns the helm repository with the specified name
+//
+// Returned repository excludes the password unless withSecrets is true.
+func (o *Operator) GetHelmRepository(key ops.SiteKey, name string, withSecrets bool) (storage.HelmRepository, error) {
+	repository, err := o.backend().GetHelmRepository(name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !withSecrets {
+		return repository.WithoutSecrets(), nil
+	}
+	return repository, nil
+}
+
+// UpsertHelmRepository creates or updates the helm repository.
+//
+// The sync status of an existing repository is preserved.
+func (o *Operator) UpsertHelmRepository(ctx context.Context, key ops.SiteKey, repository storage.HelmRepository) error {
+	err := repository.CheckAndSetDefaults()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	existing, err := o.backend().GetHelmRepository(repository.GetName())
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if existing != nil {
+		repository.SetStatus(existing.GetStatus())
+	}
+	err = o.backend().UpsertHelmRepository(repository)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	events.Emit(ctx, o, events.HelmRepositoryCreated, events.Fields{
+		events.FieldName: repository.GetName(),
+	})
+	return nil
+}
+
+// DeleteHelmRepository deletes the helm repository with the specified name.
+//
+// Applications already imported from the repository are kept.
+func (o *Operator) DeleteHelmRepository(ctx context.Context, key ops.SiteKey, name string) error {
+	err := o.backend().DeleteHelmRepository(name)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	events.Emit(ctx, o, events.HelmRepositoryDeleted, events.Fields{
+		events.FieldName: name,
+	})
+	return nil
+}
@@ -91,6 +91,11 @@ type createInstallExpandOperationRequest struct {
 	Provisioner string
 	Vars        storage.OperationVariables
 	Profiles    map[string]storage.ServerProfile
+	// IgnoreMaintenanceWindow allows the expand operation to start
+	// outside of the cluster maintenance window
+	IgnoreMaintenanceWindow bool
+	// ReplaceServer is the failed master node replaced by the expand operation
+	ReplaceServer *storage.Server
 }
 
 func (s *site) createInstallExpandOperation(context context.Context, req createInstallExpandOperationRequest) (*ops.SiteOperationKey, error) {
@@ -194,10 +199,11 @@ func (s *site) createInstallExpandOperation(context context.Context, req createI
 	}
 
 	op.InstallExpand = &storage.InstallExpandOperationState{
-		Vars:     variables,
-		Agents:   agents,
-		Profiles: profiles,
-		Package:  s.app.Package,
+		Vars:          variables,
+		Agents:        agents,
+		Profiles:      profiles,
+		Package:       s.app.Package,
+		ReplaceServer: req.ReplaceServer,
 	}
 
 	subnets, err := s.selectSubnets(*op)
@@ -207,7 +213,9 @@ func (s *site) createInstallExpandOperation(context context.Context, req createI
 	op.InstallExpand.Subnets = *subnets
 	ctx.Debugf("selected subnets: %v", subnets)
 
-	key, err := s.getOperationGroup().createSiteOperation(*op)
+	// only expand operations are subject to the maintenance window
+	ignoreWindow := req.IgnoreMaintenanceWindow || op.Type != ops.OperationExpand
+	key, err := s.getOperationGroup().createSiteOperationInWindow(*op, ignoreWindow)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -695,6 +703,7 @@ func (s *site) configureOnPremServers(ctx *operationContext, servers []storage.S
 		servers[i].Docker.Device = info.GetDevices().GetByName(dockerDevice)
 		servers[i].Docker.LVMSystemDirectory = info.GetLVMSystemDirectory()
 		servers[i].User = info.GetUser()
+		servers[i].Arch = info.GetArch()
 		servers[i].Provisioner = schema.ProvisionerOnPrem
 		servers[i].Created = time.Now().UTC()
 
@@ -824,12 +833,13 @@ func (s *site) installOperationStart(ctx *operationContext) error {
 	}
 
 	if isAWSProvisioner(op.Provisioner) {
-		if !s.app.Manifest.HasHook(schema.HookClusterProvision) {
-			return trace.BadParameter("%v hook is not defined",
+		hook, err := schema.HookFromString(schema.HookClusterProvision, s.app.Manifest)
+		if err != nil || hook.Host != nil {
+			return trace.BadParameter("%v job hook is not defined",
 				schema.HookClusterProvision)
 		}
 		ctx.Infof("Using cluster provisioning hook.")
-		err := s.runClusterProvisionHook(ctx)
+		err = s.runClusterProvisionHook(ctx)
 		if err != nil {
 			return trace.Wrap(err)
 		}
@@ -34,14 +34,24 @@ import (
 
 var (
 	// gravityTemplateSource is a bash script that downloads gravity binary
-	// from an Ops Center and installs it into /usr/bin
+	// for the machine architecture from an Ops Center and installs it into /usr/bin
 	gravityTemplateSource = `
 #!/bin/bash
 set -e
 
+case "$(uname -m)" in
+  x86_64) ARCH=amd64 ;;
+  aarch64|arm64) ARCH=arm64 ;;
+  *) ARCH="$(uname -m)" ;;
+esac
+case "$ARCH" in
+{{range $arch, $url := .gravity_urls}}  {{$arch}}) GRAVITY_URL="{{$url}}" ;;
+{{end}}  *) echo "$(date) [ERROR] Install agent is not available for architecture $ARCH"; exit 1 ;;
+esac
+
 CURL_OPTS="--retry 100 --retry-delay 0 --connect-timeout 10 --max-time 300 --tlsv1.2 --silent --show-error --http1.0"
-echo "$(date) [INFO] Downloading install agent..."
-curl $CURL_OPTS {{if .devmode}}-k{{end}} -H "Authorization: Bearer {{.ops_token}}" {{.gravity_url}} -o {{.gravity_bin_path}}
+echo "$(date) [INFO] Downloading install agent for $ARCH..."
+curl $CURL_OPTS {{if .devmode}}-k{{end}} -H "Authorization: Bearer {{.ops_token}}" "$GRAVITY_URL" -o {{.gravity_bin_path}}
 chmod 755 {{.gravity_bin_path}}
 
 echo "$(date) [INFO] Install agent will be using ${TMPDIR:-/tmp} for temporary files"
@@ -130,7 +140,7 @@ func (s *site) getInstallInstructions(token storage.ProvisioningToken, serverPro
 		"devmode":           s.shouldUseInsecure(),
 		"service_uid":       s.uid(),
 		"service_gid":       s.gid(),
-		"gravity_url":       s.packages().PackageDownloadURL(s.gravityPackage),
+		"gravity_urls":      s.gravityDownloadURLs(),
 		"advertise_addr":    params.Get(schema.AdvertiseAddr),
 		"install_token":     token.Token,
 		"cluster_name":      token.SiteDomain,
@@ -171,7 +181,7 @@ func (s *site) getJoinInstructions(token storage.ProvisioningToken, serverProfil
 		"devmode":           s.shouldUseInsecure(),
 		"service_uid":       s.uid(),
 		"service_gid":       s.gid(),
-		"gravity_url":       s.packages().PackageDownloadURL(s.gravityPackage),
+		"gravity_urls":      s.gravityDownloadURLs(),
 		"advertise_addr":    params.Get(schema.AdvertiseAddr),
 		"install_token":     token.Token,
 		"profile":           serverProfile,
@@ -192,3 +202,13 @@ func (s *site) getJoinInstructions(token storage.ProvisioningToken, serverProfil
 	}
 	return out.String(), nil
 }
+
+// gravityDownloadURLs returns the download URLs of the gravity binary
+// for each architecture supported by the cluster image
+func (s *site) gravityDownloadURLs() map[string]string {
+	urls := make(map[string]string)
+	for _, arch := range s.app.Manifest.Architectures() {
+		urls[arch] = s.packages().PackageDownloadURL(schema.ArchPackage(s.gravityPackage, arch))
+	}
+	return urls
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"context"
+
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/ops/events"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetMaintenanceWindow returns the cluster maintenance window
+func (o *Operator) GetMaintenanceWindow(key ops.SiteKey) (storage.MaintenanceWindow, error) {
+	window, err := o.backend().GetMaintenanceWindow()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return window, nil
+}
+
+// UpsertMaintenanceWindow creates or updates the cluster maintenance window
+func (o *Operator) UpsertMaintenanceWindow(ctx context.Context, key ops.SiteKey, window storage.MaintenanceWindow) error {
+	err := window.CheckAndSetDefaults()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = o.backend().UpsertMaintenanceWindow(window)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	events.Emit(ctx, o, events.MaintenanceWindowCreated)
+	return nil
+}
+
+// DeleteMaintenanceWindow deletes the cluster maintenance window
+func (o *Operator) DeleteMaintenanceWindow(ctx context.Context, key ops.SiteKey) error {
+	err := o.backend().DeleteMaintenanceWindow()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	events.Emit(ctx, o, events.MaintenanceWindowDeleted)
+	return nil
+}
+
+// LaunchScheduledOperations launches the oldest operation waiting for
+// the maintenance window if the window is currently open.
+//
+// Operations are launched one at a time: the next scheduled operation
+// is considered once the cluster is ready for it
+func (o *Operator) LaunchScheduledOperations(ctx context.Context, key ops.SiteKey) error {
+	operations, err := ops.GetScheduledOperations(key, o)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+
+	window, err := o.backend().GetMaintenanceWindow()
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if window != nil {
+		open, err := window.IsOpen(o.clock().UtcNow())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if !open {
+			return nil
+		}
+	}
+
+	site, err := o.openSite(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	logger := log.WithField("operation", operations[0].Key())
+	operation, err := site.getOperationGroup().launchScheduledOperation(operations[0].Key())
+	if err != nil {
+		if trace.IsCompareFailed(err) {
+			logger.WithError(err).Info("Scheduled operation cannot be launched yet.")
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	logger.Info("Launched scheduled operation.")
+
+	// shrink is the only operation driven by the cluster controller,
+	// others are driven by the clients waiting for the launch
+	if operation.Type == ops.OperationShrink {
+		err = site.executeOperation(operation.Key(), site.shrinkOperationStart)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/ops/events"
 	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/status"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/utils"
 
@@ -68,6 +69,143 @@ func (g *operationGroup) createSiteOperation(operation ops.SiteOperation) (*ops.
 	g.Lock()
 	defer g.Unlock()
 
+	return g.createSiteOperationLocked(operation)
+}
+
+// createSiteOperationInWindow creates the provided operation subject to the
+// cluster maintenance window.
+//
+// If the window is closed, the operation is created in the scheduled state
+// and is launched when the window opens, unless ignoreWindow is set
+func (g *operationGroup) createSiteOperationInWindow(operation ops.SiteOperation, ignoreWindow bool) (*ops.SiteOperationKey, error) {
+	g.Lock()
+	defer g.Unlock()
+
+	if !ignoreWindow {
+		schedule, err := g.getOperationSchedule(operation)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if schedule != nil {
+			return g.scheduleSiteOperation(operation, *schedule)
+		}
+	}
+
+	return g.createSiteOperationLocked(operation)
+}
+
+// getOperationSchedule returns the schedule for the provided operation if
+// the maintenance window is closed, or nil if the operation can start right away
+func (g *operationGroup) getOperationSchedule(operation ops.SiteOperation) (*storage.OperationSchedule, error) {
+	window, err := g.operator.backend().GetMaintenanceWindow()
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	now := g.operator.clock().UtcNow()
+	open, err := window.IsOpen(now)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if open {
+		return nil, nil
+	}
+	startAfter, err := window.NextOpen(now)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if startAfter.IsZero() {
+		return nil, trace.BadParameter("maintenance window never opens, check its " +
+			"schedule and blackout dates or override it to start the operation now")
+	}
+	return &storage.OperationSchedule{
+		State:      operation.State,
+		StartAfter: startAfter,
+	}, nil
+}
+
+// scheduleSiteOperation creates the provided operation in the scheduled state.
+// Scheduled operations do not affect the cluster state until launched
+func (g *operationGroup) scheduleSiteOperation(operation ops.SiteOperation, schedule storage.OperationSchedule) (*ops.SiteOperationKey, error) {
+	site, err := g.operator.openSite(g.siteKey)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	err = g.canCreateOperation(operation)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	operation.State = ops.OperationStateScheduled
+	operation.Schedule = &schedule
+	op, err := site.createSiteOperation(&operation)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	log.WithField("operation", op.String()).Infof(
+		"Maintenance window is closed, operation scheduled to start after %v.", schedule.StartAfter)
+	key := op.Key()
+	return &key, nil
+}
+
+// launchScheduledOperation moves the specified scheduled operation into the state
+// it has been created with if the checks allow it to start
+//
+// In case of failed checks returns trace.CompareFailed error to indicate that
+// the cluster is not in the appropriate state.
+func (g *operationGroup) launchScheduledOperation(key ops.SiteOperationKey) (*ops.SiteOperation, error) {
+	g.Lock()
+	defer g.Unlock()
+
+	operation, err := g.operator.GetSiteOperation(key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if !operation.IsScheduled() || operation.Schedule == nil {
+		return nil, trace.CompareFailed("operation %v is not scheduled", operation)
+	}
+
+	err = g.canCreateOperation(*operation)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	site, err := g.operator.openSite(g.siteKey)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	operation, err = site.setOperationState(key, operation.Schedule.State)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	state, err := operation.ClusterState()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	err = site.setSiteState(state)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	err = g.emitAuditEvent(context.TODO(), *operation)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return operation, nil
+}
+
+// createSiteOperationLocked creates the provided operation if the checks allow it to be created.
+// Must be called with the group lock held
+func (g *operationGroup) createSiteOperationLocked(operation ops.SiteOperation) (*ops.SiteOperationKey, error) {
 	err := g.canCreateOperation(operation)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -129,6 +267,11 @@ func (g *operationGroup) canCreateOperation(operation ops.SiteOperation) error {
 		return trace.Wrap(err)
 	}
 
+	err = g.checkScheduledOperations(operation)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
 	switch operation.Type {
 	case ops.OperationInstall, ops.OperationUninstall:
 		// no special checks for install/uninstall are needed
@@ -157,6 +300,29 @@ func (g *operationGroup) canCreateOperation(operation ops.SiteOperation) error {
 	return nil
 }
 
+// checkScheduledOperations makes sure no other operation is waiting for
+// the maintenance window.
+//
+// The plan of a scheduled operation is built and its agents are deployed
+// when it is scheduled, so the cluster must not change until it is launched.
+func (g *operationGroup) checkScheduledOperations(operation ops.SiteOperation) error {
+	switch operation.Type {
+	case ops.OperationInstall, ops.OperationUninstall:
+		return nil
+	}
+	scheduled, err := ops.GetScheduledOperations(g.siteKey, g.operator)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	for i := range scheduled {
+		if scheduled[i].ID != operation.ID {
+			return trace.CompareFailed("%v is scheduled for the maintenance window, "+
+				"cancel it or wait for it to complete", &scheduled[i])
+		}
+	}
+	return nil
+}
+
 // canCreateExpandOperation runs expand-specific checks
 //
 // In case of failed checks returns trace.CompareFailed error to indicate that
@@ -166,6 +332,12 @@ func (g *operationGroup) canCreateExpandOperation(site ops.Site, operation ops.S
 		return nil
 	}
 
+	// a failed master can be replaced in a cluster degraded because of it
+	if site.State == ops.SiteStateDegraded && operation.InstallExpand != nil &&
+		operation.InstallExpand.ReplaceServer != nil {
+		return trace.Wrap(g.canReplaceServer(site, *operation.InstallExpand.ReplaceServer))
+	}
+
 	operations, err := ops.GetActiveOperationsByType(g.siteKey, g.operator, ops.OperationExpand)
 	if err != nil && !trace.IsNotFound(err) {
 		return trace.Wrap(err)
@@ -216,6 +388,28 @@ func (g *operationGroup) canCreateExpandOperation(site ops.Site, operation ops.S
 	return nil
 }
 
+// canReplaceServer makes sure that the degraded cluster has no other problems
+// than the failed node being replaced
+func (g *operationGroup) canReplaceServer(site ops.Site, server storage.Server) error {
+	if site.Reason != storage.ReasonClusterDegraded {
+		return trace.CompareFailed("cannot replace node %v in %v cluster: %v",
+			server.Hostname, site.State, site.Reason.Description())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaults.AgentRequestTimeout)
+	defer cancel()
+	nodes, err := g.operator.cfg.GetNodeStatus(ctx, site.ClusterState.Servers)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, node := range nodes {
+		if node.Status != status.NodeHealthy && node.AdvertiseIP != server.AdvertiseIP {
+			return trace.CompareFailed("cannot replace node %v while node %v (%v) is %v",
+				server.Hostname, node.Hostname, node.AdvertiseIP, node.Status)
+		}
+	}
+	return nil
+}
+
 // compareAndSwapOperationState changes the operation state according to the provided spec
 //
 // In the case the operation moves to its final state, it also updates the cluster
@@ -240,6 +434,10 @@ func (g *operationGroup) compareAndSwapOperationState(swap swap) (*ops.SiteOpera
 			"operation %v is not in %v", operation, swap.expectedStates)
 	}
 
+	// scheduled operations have not affected the cluster state so there's
+	// nothing to update if one is cancelled
+	wasScheduled := operation.IsScheduled()
+
 	site, err := g.operator.openSite(g.siteKey)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -252,7 +450,7 @@ func (g *operationGroup) compareAndSwapOperationState(swap swap) (*ops.SiteOpera
 
 	// if we've just moved the operation to one of the final states (completed/failed),
 	// see if we also need to update the site state
-	if operation.IsFinished() {
+	if operation.IsFinished() && !wasScheduled {
 		err = g.emitAuditEvent(context.TODO(), *operation)
 		if err != nil {
 			return nil, trace.Wrap(err)
@@ -17,14 +17,20 @@ limitations under the License.
 package opsservice
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/ops/suite"
 	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/status"
 	"github.com/gravitational/gravity/lib/storage"
 
+	teleservices "github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+	"github.com/mailgun/timetools"
 	"gopkg.in/check.v1"
 )
 
@@ -287,6 +293,295 @@ func (s *OperationGroupSuite) TestClusterStateModifications(c *check.C) {
 	s.assertServerCount(c, 2)
 }
 
+// Makes sure operations created outside of the maintenance window are
+// scheduled and launched once the window opens
+func (s *OperationGroupSuite) TestScheduledOperation(c *check.C) {
+	group := s.operator.getOperationGroup(s.cluster.Key())
+	s.installCluster(c)
+
+	// Sundays 02:00-04:00 UTC
+	window := storage.NewMaintenanceWindow(storage.MaintenanceWindowSpecV1{
+		Windows: []storage.MaintenanceWindowSchedule{{
+			Schedule: "0 2 * * 0",
+			Duration: teleservices.NewDuration(2 * time.Hour),
+		}},
+	})
+	err := s.operator.UpsertMaintenanceWindow(context.TODO(), s.cluster.Key(), window)
+	c.Assert(err, check.IsNil)
+
+	// Tuesday, the window is closed
+	clock := &timetools.FreezedTime{CurrentTime: time.Date(2019, time.October, 1, 10, 0, 0, 0, time.UTC)}
+	s.operator.cfg.Clock = clock
+
+	key, err := group.createSiteOperationInWindow(ops.SiteOperation{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       ops.OperationGarbageCollect,
+		State:      ops.OperationGarbageCollectInProgress,
+	}, false)
+	c.Assert(err, check.IsNil)
+	s.assertOperationState(c, *key, ops.OperationStateScheduled)
+	s.assertClusterState(c, ops.SiteStateActive)
+
+	operation, err := s.operator.GetSiteOperation(*key)
+	c.Assert(err, check.IsNil)
+	c.Assert(operation.Schedule, check.DeepEquals, &storage.OperationSchedule{
+		State:      ops.OperationGarbageCollectInProgress,
+		StartAfter: time.Date(2019, time.October, 6, 2, 0, 0, 0, time.UTC),
+	})
+
+	// nothing is launched while the window is closed
+	err = s.operator.LaunchScheduledOperations(context.TODO(), s.cluster.Key())
+	c.Assert(err, check.IsNil)
+	s.assertOperationState(c, *key, ops.OperationStateScheduled)
+
+	clock.CurrentTime = time.Date(2019, time.October, 6, 2, 30, 0, 0, time.UTC)
+	err = s.operator.LaunchScheduledOperations(context.TODO(), s.cluster.Key())
+	c.Assert(err, check.IsNil)
+	s.assertOperationState(c, *key, ops.OperationGarbageCollectInProgress)
+	s.assertClusterState(c, ops.SiteStateGarbageCollecting)
+}
+
+// Makes sure no other operation can start while one is waiting for the
+// maintenance window
+func (s *OperationGroupSuite) TestRejectsOperationsWhileScheduled(c *check.C) {
+	group := s.operator.getOperationGroup(s.cluster.Key())
+	s.installCluster(c)
+
+	window := storage.NewMaintenanceWindow(storage.MaintenanceWindowSpecV1{
+		Windows: []storage.MaintenanceWindowSchedule{{
+			Schedule: "0 2 * * 0",
+			Duration: teleservices.NewDuration(2 * time.Hour),
+		}},
+	})
+	err := s.operator.UpsertMaintenanceWindow(context.TODO(), s.cluster.Key(), window)
+	c.Assert(err, check.IsNil)
+	clock := &timetools.FreezedTime{CurrentTime: time.Date(2019, time.October, 1, 10, 0, 0, 0, time.UTC)}
+	s.operator.cfg.Clock = clock
+
+	scheduled, err := group.createSiteOperationInWindow(ops.SiteOperation{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       ops.OperationUpdate,
+		State:      ops.OperationStateUpdateInProgress,
+	}, false)
+	c.Assert(err, check.IsNil)
+
+	// neither another scheduled operation nor an operation that ignores
+	// the window can be created
+	_, err = group.createSiteOperationInWindow(ops.SiteOperation{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       ops.OperationGarbageCollect,
+		State:      ops.OperationGarbageCollectInProgress,
+	}, false)
+	c.Assert(trace.IsCompareFailed(err), check.Equals, true, check.Commentf("%v", err))
+	_, err = group.createSiteOperationInWindow(ops.SiteOperation{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       ops.OperationGarbageCollect,
+		State:      ops.OperationGarbageCollectInProgress,
+	}, true)
+	c.Assert(trace.IsCompareFailed(err), check.Equals, true, check.Commentf("%v", err))
+	s.assertClusterState(c, ops.SiteStateActive)
+
+	// the scheduled operation itself is launched when the window opens
+	clock.CurrentTime = time.Date(2019, time.October, 6, 2, 30, 0, 0, time.UTC)
+	err = s.operator.LaunchScheduledOperations(context.TODO(), s.cluster.Key())
+	c.Assert(err, check.IsNil)
+	s.assertOperationState(c, *scheduled, ops.OperationStateUpdateInProgress)
+	s.assertClusterState(c, ops.SiteStateUpdating)
+}
+
+// Makes sure operations are not scheduled for a window that never opens
+func (s *OperationGroupSuite) TestRejectsWindowThatNeverOpens(c *check.C) {
+	group := s.operator.getOperationGroup(s.cluster.Key())
+	s.installCluster(c)
+
+	window := storage.NewMaintenanceWindow(storage.MaintenanceWindowSpecV1{
+		Windows: []storage.MaintenanceWindowSchedule{{
+			Schedule: "0 0 31 4 *",
+			Duration: teleservices.NewDuration(time.Hour),
+		}},
+	})
+	err := s.operator.UpsertMaintenanceWindow(context.TODO(), s.cluster.Key(), window)
+	c.Assert(err, check.IsNil)
+	s.operator.cfg.Clock = &timetools.FreezedTime{CurrentTime: time.Date(2019, time.October, 1, 10, 0, 0, 0, time.UTC)}
+
+	_, err = group.createSiteOperationInWindow(ops.SiteOperation{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       ops.OperationGarbageCollect,
+		State:      ops.OperationGarbageCollectInProgress,
+	}, false)
+	c.Assert(err, check.ErrorMatches, "maintenance window never opens.*")
+	s.assertClusterState(c, ops.SiteStateActive)
+}
+
+// Makes sure the window can be overridden and that cancelling a scheduled
+// operation does not affect the cluster state
+func (s *OperationGroupSuite) TestIgnoreMaintenanceWindow(c *check.C) {
+	group := s.operator.getOperationGroup(s.cluster.Key())
+	s.installCluster(c)
+
+	window := storage.NewMaintenanceWindow(storage.MaintenanceWindowSpecV1{
+		Windows: []storage.MaintenanceWindowSchedule{{
+			Schedule: "0 2 * * 0",
+			Duration: teleservices.NewDuration(2 * time.Hour),
+		}},
+	})
+	err := s.operator.UpsertMaintenanceWindow(context.TODO(), s.cluster.Key(), window)
+	c.Assert(err, check.IsNil)
+	s.operator.cfg.Clock = &timetools.FreezedTime{CurrentTime: time.Date(2019, time.October, 1, 10, 0, 0, 0, time.UTC)}
+
+	scheduled, err := group.createSiteOperationInWindow(ops.SiteOperation{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       ops.OperationGarbageCollect,
+		State:      ops.OperationGarbageCollectInProgress,
+	}, false)
+	c.Assert(err, check.IsNil)
+
+	_, err = group.compareAndSwapOperationState(swap{
+		key:        *scheduled,
+		newOpState: ops.OperationStateFailed,
+	})
+	c.Assert(err, check.IsNil)
+	s.assertClusterState(c, ops.SiteStateActive)
+
+	key, err := group.createSiteOperationInWindow(ops.SiteOperation{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       ops.OperationGarbageCollect,
+		State:      ops.OperationGarbageCollectInProgress,
+	}, true)
+	c.Assert(err, check.IsNil)
+	s.assertOperationState(c, *key, ops.OperationGarbageCollectInProgress)
+	s.assertClusterState(c, ops.SiteStateGarbageCollecting)
+}
+
+// Makes sure a failed master can be replaced in a cluster degraded because of it
+func (s *OperationGroupSuite) TestReplaceMasterInDegradedCluster(c *check.C) {
+	s.installCluster(c)
+	group := s.operator.getOperationGroup(s.cluster.Key())
+	var servers []storage.Server
+	for i := 0; i < 3; i++ {
+		servers = append(servers, storage.Server{
+			Hostname:    fmt.Sprintf("master-%v", i),
+			AdvertiseIP: fmt.Sprintf("10.0.0.%v", i),
+			ClusterRole: string(schema.ServiceRoleMaster),
+		})
+	}
+	c.Assert(group.addClusterStateServers(servers), check.IsNil)
+	err := s.operator.DeactivateSite(ops.DeactivateSiteRequest{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Reason:     storage.ReasonClusterDegraded,
+	})
+	c.Assert(err, check.IsNil)
+	s.assertClusterState(c, ops.SiteStateDegraded)
+
+	offline := map[string]bool{"10.0.0.0": true}
+	s.operator.cfg.GetNodeStatus = func(_ context.Context, servers []storage.Server) (nodes []status.ClusterServer, err error) {
+		for _, server := range servers {
+			node := status.ClusterServer{Hostname: server.Hostname, AdvertiseIP: server.AdvertiseIP, Status: status.NodeHealthy}
+			if offline[server.AdvertiseIP] {
+				node.Status = status.NodeOffline
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes, nil
+	}
+	expand := func(replaceServer *storage.Server) error {
+		_, err := group.createSiteOperation(ops.SiteOperation{
+			AccountID:  s.cluster.AccountID,
+			SiteDomain: s.cluster.Domain,
+			Type:       ops.OperationExpand,
+			State:      ops.OperationStateExpandInitiated,
+			InstallExpand: &storage.InstallExpandOperationState{
+				Profiles: map[string]storage.ServerProfile{
+					"node": {ServiceRole: string(schema.ServiceRoleMaster)},
+				},
+				ReplaceServer: replaceServer,
+			},
+			Servers: []storage.Server{{Hostname: "master-new", Role: "node"}},
+		})
+		return err
+	}
+
+	// a regular expand is not allowed
+	c.Assert(expand(nil), check.ErrorMatches, "cannot expand degraded cluster")
+	// other nodes are unhealthy as well
+	offline["10.0.0.1"] = true
+	c.Assert(expand(&servers[0]), check.ErrorMatches, "cannot replace node master-0 while node master-1 .* is offline")
+	// only the replaced node has failed
+	delete(offline, "10.0.0.1")
+	c.Assert(expand(&servers[0]), check.IsNil)
+	s.assertClusterState(c, ops.SiteStateExpanding)
+}
+
+// Makes sure servers that failed to join are removed from the cluster state
+// when the expand operation completes
+func (s *OperationGroupSuite) TestRemovesFailedServersOnExpand(c *check.C) {
+	s.installCluster(c)
+	group := s.operator.getOperationGroup(s.cluster.Key())
+	servers := []storage.Server{{Hostname: "node-1", Role: "node"}, {Hostname: "node-2", Role: "node"}}
+	c.Assert(group.addClusterStateServers(servers), check.IsNil)
+	key, err := group.createSiteOperation(ops.SiteOperation{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       ops.OperationExpand,
+		State:      ops.OperationStateExpandInitiated,
+		InstallExpand: &storage.InstallExpandOperationState{
+			Profiles: map[string]storage.ServerProfile{
+				"node": {ServiceRole: string(schema.ServiceRoleNode)},
+			},
+		},
+		Servers: servers,
+	})
+	c.Assert(err, check.IsNil)
+
+	err = s.operator.SetOperationState(*key, ops.SetOperationStateRequest{
+		State:         ops.OperationStateCompleted,
+		FailedServers: []string{"node-3"},
+	})
+	c.Assert(err, check.ErrorMatches, "server node-3 is not part of .*")
+	s.assertServerCount(c, 2)
+
+	err = s.operator.SetOperationState(*key, ops.SetOperationStateRequest{
+		State:         ops.OperationStateCompleted,
+		FailedServers: []string{"node-2"},
+	})
+	c.Assert(err, check.IsNil)
+	s.assertServerCount(c, 1)
+	s.assertOperationState(c, *key, ops.OperationStateCompleted)
+	s.assertClusterState(c, ops.SiteStateActive)
+}
+
+func (s *OperationGroupSuite) installCluster(c *check.C) {
+	group := s.operator.getOperationGroup(s.cluster.Key())
+	key, err := group.createSiteOperation(ops.SiteOperation{
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       ops.OperationInstall,
+		State:      ops.OperationStateInstallInitiated,
+	})
+	c.Assert(err, check.IsNil)
+	_, err = group.compareAndSwapOperationState(swap{
+		key:            *key,
+		expectedStates: []string{ops.OperationStateInstallInitiated},
+		newOpState:     ops.OperationStateCompleted,
+	})
+	c.Assert(err, check.IsNil)
+	s.assertClusterState(c, ops.SiteStateActive)
+}
+
+func (s *OperationGroupSuite) assertOperationState(c *check.C, key ops.SiteOperationKey, state string) {
+	operation, err := s.operator.GetSiteOperation(key)
+	c.Assert(err, check.IsNil)
+	c.Assert(operation.State, check.Equals, state)
+}
+
 func (s *OperationGroupSuite) assertClusterState(c *check.C, state string) {
 	cluster, err := s.operator.GetSite(s.cluster.Key())
 	c.Assert(err, check.IsNil)
@@ -54,3 +54,23 @@ func (o *Operator) GetOperationPlan(key ops.SiteOperationKey) (*storage.Operatio
 	}
 	return fsm.ResolvePlan(*plan, changelog), nil
 }
+
+// CreateHookAttempt records an application hook attempt of the operation
+func (o *Operator) CreateHookAttempt(key ops.SiteOperationKey, attempt storage.HookAttempt) error {
+	attempt.ClusterName = key.SiteDomain
+	attempt.OperationID = key.OperationID
+	_, err := o.backend().CreateHookAttempt(attempt)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetHookAttempts returns application hook attempts of the operation
+func (o *Operator) GetHookAttempts(key ops.SiteOperationKey) ([]storage.HookAttempt, error) {
+	attempts, err := o.backend().GetHookAttempts(key.SiteDomain, key.OperationID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return attempts, nil
+}
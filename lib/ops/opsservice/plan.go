@@ -64,6 +64,21 @@ func (p provisionedServers) Masters() []*ProvisionedServer {
 	return servers
 }
 
+// mastersUpTo returns a sub-list of this server list that contains servers
+// with master cluster role up to and including the specified server
+func (p provisionedServers) mastersUpTo(server *ProvisionedServer) (masters provisionedServers) {
+	for _, s := range p {
+		if !s.IsMaster() {
+			continue
+		}
+		masters = append(masters, s)
+		if s.AdvertiseIP == server.AdvertiseIP {
+			break
+		}
+	}
+	return masters
+}
+
 // MasterIPs returns a list of advertise IPs of master nodes.
 func (p provisionedServers) MasterIPs() (ips []string) {
 	for _, master := range p.Masters() {
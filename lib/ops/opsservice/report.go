@@ -25,23 +25,27 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/modules"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/report"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/utils"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
 )
 
-func (s *site) getSiteOperationCrashReport(op ops.SiteOperation) (io.ReadCloser, error) {
+func (s *site) getSiteOperationCrashReport(op ops.SiteOperation, req ops.SiteReportRequest) (io.ReadCloser, error) {
 	ctx, err := s.newOperationContext(op)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -63,10 +67,10 @@ func (s *site) getSiteOperationCrashReport(op ops.SiteOperation) (io.ReadCloser,
 	}
 
 	runner := s.agentRunner(ctx)
-	return s.getReport(runner, remoteServers, master)
+	return s.getReport(runner, remoteServers, master, req)
 }
 
-func (s *site) getSiteReport() (io.ReadCloser, error) {
+func (s *site) getSiteReport(req ops.SiteReportRequest) (io.ReadCloser, error) {
 	const noRetry = 1
 	servers, err := s.getTeleportServersWithTimeout(
 		nil,
@@ -93,48 +97,85 @@ func (s *site) getSiteReport() (io.ReadCloser, error) {
 		remoteServers = append(remoteServers, teleportServer)
 	}
 
-	return s.getReport(teleportRunner, remoteServers, master)
+	return s.getReport(teleportRunner, remoteServers, master, req)
 }
 
-func (s *site) getReport(runner remoteRunner, servers []remoteServer, master remoteServer) (io.ReadCloser, error) {
+func (s *site) getReport(runner remoteRunner, servers []remoteServer, master remoteServer, req ops.SiteReportRequest) (io.ReadCloser, error) {
+	if err := req.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	servers, err := filterReportServers(servers, req.Nodes)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if master != nil && !hasServer(servers, master) {
+		master = nil
+	}
+
+	if req.DryRun {
+		return s.getReportPlan(servers, master, req)
+	}
+
+	redactor, err := report.NewRedactor(req.Redaction)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	dir, err := ioutil.TempDir("", "report")
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	err = runCollectors(*s, dir, runner)
+	// all collected diagnostics pass through the redactor
+	reportWriter := report.NewRedactingWriter(report.NewFileWriter(dir), redactor)
+
+	err = runCollectors(*s, reportWriter, runner)
 	if err != nil {
 		// Intermediate steps in diagnostics collection are not fatal
 		// to collect all possible pieces in best-effort
 		log.Errorf("failed to run cluster collectors: %v", trace.DebugReport(err))
 	}
 
-	collectOperationsLogs(*s, dir)
+	if report.HasFilter(req.Collectors, constants.ReportFilterApp) {
+		// application dumps apply the per-file redaction hints on top of the redactor
+		s.collectAppDumps(report.NewFileWriter(dir), redactor)
+	}
+
+	collectOperationsLogs(*s, reportWriter, req.TimeRange)
 
 	if len(servers) > 0 {
-		// Use the first master server to collect kubernetes diagnostics
-		server := master
-		if server == nil {
-			server = servers[0]
-			log.Warningf("no master servers, collecting kubernetes diagnostics from %v", server)
+		if report.HasFilter(req.Collectors, constants.ReportFilterKubernetes) {
+			server := kubernetesReportServer(servers, master)
+			serverRunner := &serverRunner{server: server, runner: runner}
+			s.collectKubernetesInfo(getReportWriterForServer(reportWriter, server), serverRunner, req.TimeRange)
 		}
-		serverRunner := &serverRunner{server: server, runner: runner}
-		reportWriter := getReportWriterForServer(dir, server)
-		s.collectKubernetesInfo(reportWriter, serverRunner)
 
-		err = s.collectDebugInfoFromServers(dir, servers, runner)
-		if err != nil {
-			log.Errorf("failed to collect diagnostics from some nodes: %v", trace.DebugReport(err))
+		if filters := nodeReportFilters(req.Collectors); len(filters) != 0 {
+			err = s.collectDebugInfoFromServers(reportWriter, servers, runner, filters, req.TimeRange)
+			if err != nil {
+				log.Errorf("failed to collect diagnostics from some nodes: %v", trace.DebugReport(err))
+			}
 		}
 	}
 
+	err = redactor.WriteManifest(report.NewFileWriter(dir))
+	if err != nil {
+		log.Errorf("failed to write redaction manifest: %v", trace.DebugReport(err))
+	}
+
+	summary, err := getReportSummary(dir)
+	if err != nil {
+		log.Errorf("failed to generate report summary: %v", trace.DebugReport(err))
+	}
+
 	// use a pipe to avoid allocating a buffer
 	reader, writer := io.Pipe()
 	gzWriter := gzip.NewWriter(writer)
 
 	// writing w/o a reader will deadlock so write in a goroutine
 	go func() {
-		err := archive.CompressDirectory(dir, gzWriter)
+		err := archive.CompressDirectory(dir, gzWriter, summary...)
 		gzWriter.Close()
 		writer.CloseWithError(err)
 	}()
@@ -147,21 +188,138 @@ func (s *site) getReport(runner remoteRunner, servers []remoteServer, master rem
 	}, nil
 }
 
+// getReportSummary analyzes the diagnostics collected in dir and returns
+// the summary of detected problems as archive items to place at the
+// top of the report tarball
+func getReportSummary(dir string) ([]*archive.Item, error) {
+	summary, err := report.Analyze(dir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	items, err := summary.Items()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return items, nil
+}
+
+// getReportPlan returns the listing of diagnostics the report
+// for the specified request would collect
+func (s *site) getReportPlan(servers []remoteServer, master remoteServer, req ops.SiteReportRequest) (io.ReadCloser, error) {
+	var buf bytes.Buffer
+	if !req.TimeRange.IsZero() {
+		fmt.Fprintf(&buf, "Logs limited to %v\n\n", req.TimeRange)
+	}
+
+	fmt.Fprintln(&buf, "Cluster:")
+	fmt.Fprintf(&buf, "  %v\n", siteInfoFilename)
+	operations, err := s.service.GetSiteOperations(s.key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, op := range operations {
+		if req.TimeRange.Overlaps(op.Created, op.Updated) {
+			fmt.Fprintf(&buf, "  %v\n", fmt.Sprintf(opLogsFilename, op.Type, op.ID))
+		}
+	}
+	if report.HasFilter(req.Collectors, constants.ReportFilterApp) {
+		for _, application := range s.dumpHookApps() {
+			fmt.Fprintf(&buf, "  %v/%v/ (dump hook, time budget %v)\n", report.AppDumpDir,
+				application.Package.Name, dumpHookTimeout(application.Manifest.Hooks.Dump))
+		}
+	}
+
+	if len(servers) != 0 && report.HasFilter(req.Collectors, constants.ReportFilterKubernetes) {
+		server := kubernetesReportServer(servers, master)
+		fmt.Fprintf(&buf, "\nKubernetes (from node %v):\n", server.HostName())
+		fmt.Fprintf(&buf, "  %v-%v: nodes, pods, events, resources and container logs\n",
+			server.HostName(), kubernetesReportFilename)
+	}
+
+	if filters := nodeReportFilters(req.Collectors); len(filters) != 0 {
+		names := report.NodeCollectors(filters, req.TimeRange).Names()
+		if utils.StringInSlice(filters, constants.ReportFilterSystem) {
+			names = append(names, "gravity-packages.yaml")
+		}
+		for _, server := range servers {
+			fmt.Fprintf(&buf, "\nNode %v (%v), %v-%v:\n", server.HostName(), server.Address(),
+				server.HostName(), debugReportFilename)
+			for _, name := range names {
+				fmt.Fprintf(&buf, "  %v\n", name)
+			}
+		}
+	}
+	return ioutil.NopCloser(&buf), nil
+}
+
+// filterReportServers returns the servers matching the specified list of
+// node hostnames or addresses. All servers are returned if nodes is empty
+func filterReportServers(servers []remoteServer, nodes []string) ([]remoteServer, error) {
+	if len(nodes) == 0 {
+		return servers, nil
+	}
+	var result []remoteServer
+	for _, server := range servers {
+		host, _ := utils.SplitHostPort(server.Address(), "")
+		if utils.StringInSlice(nodes, server.HostName()) ||
+			utils.StringInSlice(nodes, server.Address()) ||
+			utils.StringInSlice(nodes, host) {
+			result = append(result, server)
+		}
+	}
+	if len(result) == 0 {
+		return nil, trace.NotFound("no nodes matching %v", nodes)
+	}
+	return result, nil
+}
+
+// hasServer returns true if the specified server is in the list of servers
+func hasServer(servers []remoteServer, server remoteServer) bool {
+	for _, s := range servers {
+		if s.Address() == server.Address() {
+			return true
+		}
+	}
+	return false
+}
+
+// kubernetesReportServer returns the server to collect kubernetes diagnostics from.
+// servers is expected to be non-empty
+func kubernetesReportServer(servers []remoteServer, master remoteServer) remoteServer {
+	// Use the first master server to collect kubernetes diagnostics
+	if master != nil {
+		return master
+	}
+	log.Warningf("no master servers, collecting kubernetes diagnostics from %v", servers[0])
+	return servers[0]
+}
+
+// nodeReportFilters returns the collector groups to run on every node
+// given the requested collector groups
+func nodeReportFilters(collectors []string) (filters []string) {
+	for _, filter := range report.NodeFilters {
+		if report.HasFilter(collectors, filter) {
+			filters = append(filters, filter)
+		}
+	}
+	return filters
+}
+
 // collectDebugInfoFromServers collects diagnostic information from servers
-// and stores each piece into a file in directory dir.
+// and stores each piece using the specified writer.
 // Files are named using the following pattern:
 //
 //   <server-name>-<resource>
 //
-func (s *site) collectDebugInfoFromServers(dir string, servers []remoteServer, runner remoteRunner) error {
+func (s *site) collectDebugInfoFromServers(w report.Writer, servers []remoteServer, runner remoteRunner, filters []string, timeRange report.TimeRange) error {
 	err := s.executeOnServers(context.TODO(), servers, func(c context.Context, server remoteServer) error {
 		log.Debugf("collectDebugInfo for %v", server)
 		r := &serverRunner{
 			server: server,
 			runner: runner,
 		}
-		reportWriter := getReportWriterForServer(dir, server)
-		err := s.collectDebugInfo(reportWriter, r)
+		reportWriter := getReportWriterForServer(w, server)
+		err := s.collectDebugInfo(reportWriter, r, filters, timeRange)
 		return trace.Wrap(err)
 	})
 	if err != nil {
@@ -170,38 +328,132 @@ func (s *site) collectDebugInfoFromServers(dir string, servers []remoteServer, r
 	return nil
 }
 
-func (s *site) collectDebugInfo(reportWriter report.Writer, runner *serverRunner) error {
-	w, err := reportWriter("debug-logs.tar")
+func (s *site) collectDebugInfo(reportWriter report.Writer, runner *serverRunner, filters []string, timeRange report.TimeRange) error {
+	w, err := reportWriter(debugReportFilename)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	defer w.Close()
 
-	err = runner.RunStream(w, s.gravityCommand("system", "report",
-		fmt.Sprintf("--filter=%v", constants.ReportFilterSystem),
-		"--compressed")...)
+	args := s.systemReportArgs(runner, filters, timeRange)
+	err = runner.RunStream(w, s.gravityCommand(args...)...)
 	if err != nil {
 		return trace.Wrap(err, "failed to collect diagnostics")
 	}
 	return nil
 }
 
-func (s *site) collectKubernetesInfo(reportWriter report.Writer, runner *serverRunner) error {
-	w, err := reportWriter("k8s-logs.tar")
+func (s *site) collectKubernetesInfo(reportWriter report.Writer, runner *serverRunner, timeRange report.TimeRange) error {
+	w, err := reportWriter(kubernetesReportFilename)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	defer w.Close()
 
-	err = runner.RunStream(w, s.gravityCommand("system", "report",
-		fmt.Sprintf("--filter=%v", constants.ReportFilterKubernetes), "--compressed")...)
+	args := s.systemReportArgs(runner, []string{constants.ReportFilterKubernetes}, timeRange)
+	err = runner.RunStream(w, s.gravityCommand(args...)...)
 	if err != nil {
 		return trace.Wrap(err, "failed to collect kubernetes diagnostics")
 	}
 	return nil
 }
 
-func runCollectors(site site, dir string, runner remoteRunner) error {
+// systemReportArgs returns the arguments of the system report command
+// collecting the specified collector groups on the node served by runner.
+//
+// The etcd and planet collector groups and the time bounds are only passed
+// to the gravity binaries that support them. Older binaries are asked for
+// the system collector group instead which includes etcd and planet
+// diagnostics, with logs collected for the entire time
+func (s *site) systemReportArgs(runner *serverRunner, filters []string, timeRange report.TimeRange) []string {
+	if requiresReportFilters(filters, timeRange) {
+		version, err := s.remoteGravityVersion(runner)
+		if err != nil {
+			log.Warnf("Failed to query gravity version on %v: %v.",
+				runner.server.HostName(), trace.DebugReport(err))
+		}
+		if err != nil || !supportsReportFilters(version, modules.Get().Version().Version) {
+			log.Warnf("Gravity %q on %v does not support collector groups or time bounds, "+
+				"collecting complete diagnostics.", version, runner.server.HostName())
+			filters = legacyReportFilters(filters)
+			timeRange = report.TimeRange{}
+		}
+	}
+	args := []string{"system", "report", "--compressed"}
+	for _, filter := range filters {
+		args = append(args, fmt.Sprintf("--filter=%v", filter))
+	}
+	return append(args, timeRange.Args()...)
+}
+
+// remoteGravityVersion returns the version of the gravity binary
+// on the node served by runner
+func (s *site) remoteGravityVersion(runner *serverRunner) (string, error) {
+	out, err := runner.Run(s.gravityCommand("version", "--output=json")...)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	var version modules.Version
+	if err := json.Unmarshal(out, &version); err != nil {
+		return "", trace.Wrap(err, "failed to parse gravity version %q", out)
+	}
+	return version.Version, nil
+}
+
+// requiresReportFilters returns true if the system report command for the
+// specified collector groups and time range relies on the flags older
+// gravity binaries do not support
+func requiresReportFilters(filters []string, timeRange report.TimeRange) bool {
+	if !timeRange.IsZero() {
+		return true
+	}
+	for _, filter := range filters {
+		if !utils.StringInSlice(legacyFilters, filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// supportsReportFilters returns true if the remote gravity binary supports
+// the etcd and planet collector groups and the time bounds of the system
+// report command, i.e. it is not older than the local binary
+func supportsReportFilters(remoteVersion, localVersion string) bool {
+	if remoteVersion == localVersion {
+		return true
+	}
+	remote, err := semver.NewVersion(remoteVersion)
+	if err != nil {
+		return false
+	}
+	local, err := semver.NewVersion(localVersion)
+	if err != nil {
+		return false
+	}
+	return !remote.LessThan(*local)
+}
+
+// legacyReportFilters maps the specified collector groups to the ones
+// supported by older gravity binaries
+func legacyReportFilters(filters []string) (result []string) {
+	for _, filter := range filters {
+		if !utils.StringInSlice(legacyFilters, filter) {
+			filter = constants.ReportFilterSystem
+		}
+		if !utils.StringInSlice(result, filter) {
+			result = append(result, filter)
+		}
+	}
+	return result
+}
+
+// legacyFilters lists the collector groups supported by older gravity binaries
+var legacyFilters = []string{
+	constants.ReportFilterSystem,
+	constants.ReportFilterKubernetes,
+}
+
+func runCollectors(site site, reportWriter report.Writer, runner remoteRunner) error {
 	storageSite, err := site.service.cfg.Backend.GetSite(site.domainName)
 	if err != nil {
 		return trace.Wrap(err)
@@ -209,9 +461,7 @@ func runCollectors(site site, dir string, runner remoteRunner) error {
 
 	collectors := []collectorFn{
 		collectSiteInfo(*storageSite),
-		collectDumpHook,
 	}
-	reportWriter := report.NewFileWriter(dir)
 
 	// collect information from all collectors
 	for _, collector := range collectors {
@@ -223,15 +473,16 @@ func runCollectors(site site, dir string, runner remoteRunner) error {
 	return nil
 }
 
-func collectOperationsLogs(site site, dir string) error {
+func collectOperationsLogs(site site, reportWriter report.Writer, timeRange report.TimeRange) error {
 	operations, err := site.service.GetSiteOperations(site.key)
 	if err != nil {
 		return trace.Wrap(err, "failed to get cluster operations")
 	}
 
-	reportWriter := report.NewFileWriter(dir)
-
 	for _, op := range operations {
+		if !timeRange.Overlaps(op.Created, op.Updated) {
+			continue
+		}
 		operation := ops.SiteOperation(op)
 		err = collectOperationLogs(site, operation, reportWriter)
 		if err != nil {
@@ -260,29 +511,83 @@ func collectSiteInfo(s storage.Site) collectorFn {
 	}
 }
 
-// collectDumpHook returns the output of the dump hook
-func collectDumpHook(reportWriter report.Writer, site site) error {
-	if !site.app.Manifest.HasHook(schema.HookDump) {
-		return nil
+// collectAppDumps runs the dump hooks of the cluster application and its
+// application dependencies and writes the results into the per-application
+// sections of the report.
+// A failed hook does not fail the report, instead the failure is recorded
+// in the hook status and flagged in the report summary
+func (s *site) collectAppDumps(w report.Writer, redactor *report.Redactor) {
+	for _, application := range s.dumpHookApps() {
+		err := s.collectAppDump(w, redactor, application)
+		if err != nil {
+			log.Errorf("failed to collect dump of %v: %v", application.Package, trace.DebugReport(err))
+		}
 	}
+}
 
-	w, err := reportWriter(dumpHookFilename)
-	if err != nil {
-		return trace.Wrap(err)
-	}
-	defer w.Close()
+func (s *site) collectAppDump(w report.Writer, redactor *report.Redactor, application app.Application) error {
+	timeout := dumpHookTimeout(application.Manifest.Hooks.Dump)
+	ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+	defer cancel()
 
-	_, out, err := app.RunAppHook(context.TODO(), site.appService, app.HookRunRequest{
-		Application: site.app.Package,
+	status := report.AppDumpStatus{
+		App:     application.Package.Name,
+		Started: time.Now().UTC(),
+		Timeout: timeout.String(),
+	}
+	_, out, err := app.RunAppHook(ctx, s.appService, app.HookRunRequest{
+		Application: application.Package,
 		Hook:        schema.HookDump,
-		ServiceUser: site.serviceUser(),
+		ServiceUser: s.serviceUser(),
+		Timeout:     timeout,
 	})
+	status.Duration = time.Since(status.Started).String()
 	if err != nil {
-		return trace.Wrap(err, string(out))
+		status.Failed = true
+		status.Error = trace.UserMessage(err)
+		if ctx.Err() == context.DeadlineExceeded {
+			status.Error = fmt.Sprintf("hook exceeded its time budget of %v", timeout)
+		}
 	}
 
-	_, err = io.Copy(w, bytes.NewReader(out))
-	return trace.Wrap(err)
+	dump, err := report.ParseAppDump(out)
+	if err != nil {
+		status.Failed = true
+		status.Error = strings.TrimSpace(fmt.Sprintf("%v %v", status.Error, trace.UserMessage(err)))
+		dump = &report.AppDump{Log: out}
+	}
+	return trace.Wrap(report.WriteAppDump(w, redactor, status, dump))
+}
+
+// dumpHookApps returns the cluster application and its application
+// dependencies that define the dump hook
+func (s *site) dumpHookApps() (apps []app.Application) {
+	locators := append([]loc.Locator{s.app.Package}, s.app.Manifest.Dependencies.GetApps()...)
+	for _, locator := range locators {
+		application, err := s.appService.GetApp(locator)
+		if err != nil {
+			log.Warnf("Failed to query application %v: %v.", locator, trace.DebugReport(err))
+			continue
+		}
+		if application.Manifest.HasHook(schema.HookDump) {
+			apps = append(apps, *application)
+		}
+	}
+	return apps
+}
+
+// dumpHookTimeout returns the time budget for the specified dump hook.
+// The budget is the active deadline of the hook job if specified
+// and defaults.DumpHookTimeout otherwise
+func dumpHookTimeout(hook *schema.Hook) time.Duration {
+	if hook == nil {
+		return defaults.DumpHookTimeout
+	}
+	job, err := hook.GetJob()
+	if err != nil || job.Spec.ActiveDeadlineSeconds == nil || *job.Spec.ActiveDeadlineSeconds <= 0 {
+		return defaults.DumpHookTimeout
+	}
+	return time.Duration(*job.Spec.ActiveDeadlineSeconds) * time.Second
 }
 
 // collectOperationLogs streams logs of the specified operation using the specified writer
@@ -305,19 +610,20 @@ func collectOperationLogs(site site, operation ops.SiteOperation, reportWriter r
 
 type collectorFn func(report.Writer, site) error
 
-func getReportWriterForServer(dir string, server remoteServer) report.Writer {
+func getReportWriterForServer(w report.Writer, server remoteServer) report.Writer {
 	return func(name string) (io.WriteCloser, error) {
-		fileName := filepath.Join(dir, fmt.Sprintf("%s-%s", server.HostName(), name))
-		return report.NewPendingFileWriter(fileName), nil
+		return w(fmt.Sprintf("%s-%s", server.HostName(), name))
 	}
 }
 
 const (
 	// siteInfoFilename is the name of the file with JSON-dumped site
 	siteInfoFilename = "site.json"
-	// dumpHookFilename is the name of the file with dump hook output
-	dumpHookFilename = "dump-hook"
 	// opLogsFilename defines the file pattern that stores operation log for a particular
 	// cluster operation
 	opLogsFilename = "%v.%v"
+	// debugReportFilename is the name of the tarball with node diagnostics
+	debugReportFilename = "debug-logs.tar"
+	// kubernetesReportFilename is the name of the tarball with kubernetes diagnostics
+	kubernetesReportFilename = "k8s-logs.tar"
 )
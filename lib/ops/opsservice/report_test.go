@@ -20,8 +20,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"time"
 
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/report"
 	"github.com/gravitational/gravity/lib/storage"
 
 	"gopkg.in/check.v1"
@@ -49,6 +51,21 @@ func (s *ReportSuite) TestClusterInfo(c *check.C) {
 	c.Assert(fromReport.License, check.Equals, "redacted")
 }
 
+func (s *ReportSuite) TestSystemReportFilters(c *check.C) {
+	timeRange := report.TimeRange{Since: time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)}
+	c.Assert(requiresReportFilters([]string{"system", "kubernetes"}, report.TimeRange{}), check.Equals, false)
+	c.Assert(requiresReportFilters([]string{"kubernetes"}, timeRange), check.Equals, true)
+	c.Assert(requiresReportFilters([]string{"system", "etcd"}, report.TimeRange{}), check.Equals, true)
+
+	c.Assert(legacyReportFilters([]string{"system", "etcd", "planet"}), check.DeepEquals, []string{"system"})
+	c.Assert(legacyReportFilters([]string{"planet", "kubernetes"}), check.DeepEquals, []string{"system", "kubernetes"})
+
+	c.Assert(supportsReportFilters("5.5.1", "5.5.1"), check.Equals, true)
+	c.Assert(supportsReportFilters("5.6.0", "5.5.1"), check.Equals, true)
+	c.Assert(supportsReportFilters("5.5.0", "5.5.1"), check.Equals, false)
+	c.Assert(supportsReportFilters("", "5.5.1"), check.Equals, false)
+}
+
 type nopCloser struct {
 	io.Writer
 }
@@ -0,0 +1,102 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"os"
+	"sort"
+
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/vacuum/prune/quota"
+
+	"github.com/gravitational/trace"
+)
+
+// PruneOperationHistory removes logs and progress entries of finished operations
+// of the specified cluster that are not retained by the given policy.
+//
+// Operations are grouped by type. Operation records and the last progress entry
+// of each operation are always kept so the operation history remains intact
+func (o *Operator) PruneOperationHistory(key ops.SiteKey, policy storage.QuotaPolicy) error {
+	if err := policy.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	operations, err := o.backend().GetSiteOperations(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var items []quota.Item
+	keys := make(map[string]ops.SiteOperationKey)
+	for i := range operations {
+		operation := (*ops.SiteOperation)(&operations[i])
+		if !operation.IsFinished() {
+			continue
+		}
+		operationKey := operation.Key()
+		var size int64
+		if fi, err := os.Stat(o.operationLogPath(operationKey)); err == nil {
+			size = fi.Size()
+		}
+		keys[operation.ID] = operationKey
+		items = append(items, quota.Item{
+			ID:      operation.ID,
+			Group:   operation.Type,
+			Created: operation.Created,
+			Size:    size,
+		})
+	}
+	// Operations are returned newest first so a stable sort
+	// preserves the order within each group
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Group < items[j].Group
+	})
+	_, expired := quota.Apply(policy, items, o.cfg.Clock.UtcNow())
+	for _, item := range expired {
+		if err := o.pruneOperation(keys[item.ID]); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if len(expired) != 0 {
+		o.Infof("Pruned history of %v operations of %v.", len(expired), key.SiteDomain)
+	}
+	return nil
+}
+
+// pruneOperation removes the log file and all but the last progress entry
+// of the specified operation
+func (o *Operator) pruneOperation(key ops.SiteOperationKey) error {
+	err := os.Remove(o.operationLogPath(key))
+	if err != nil && !os.IsNotExist(err) {
+		return trace.ConvertSystemError(err)
+	}
+	entries, err := o.backend().GetProgressEntries(key.SiteDomain, key.OperationID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	// Entries are sorted by creation time, keep the last one
+	for _, entry := range entries[:len(entries)-1] {
+		err := o.backend().DeleteProgressEntry(key.SiteDomain, key.OperationID, entry.ID)
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/ops/suite"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/pborman/uuid"
+	"gopkg.in/check.v1"
+)
+
+type RetentionSuite struct {
+	operator *Operator
+	cluster  *ops.Site
+}
+
+var _ = check.Suite(&RetentionSuite{})
+
+func (s *RetentionSuite) SetUpTest(c *check.C) {
+	services := SetupTestServices(c)
+	s.operator = services.Operator
+
+	suite := &suite.OpsSuite{}
+	app, err := suite.SetUpTestPackage(services.Apps, services.Packages, c)
+	c.Assert(err, check.IsNil)
+
+	account, err := s.operator.CreateAccount(ops.NewAccountRequest{
+		Org: "retention.test",
+	})
+	c.Assert(err, check.IsNil)
+
+	s.cluster, err = s.operator.CreateSite(ops.NewSiteRequest{
+		AccountID:  account.ID,
+		AppPackage: app.String(),
+		Provider:   schema.ProvisionerOnPrem,
+		DomainName: "retention.test",
+	})
+	c.Assert(err, check.IsNil)
+}
+
+// Makes sure history of the operations beyond the policy is pruned
+// while the operation records and their last progress entries are kept
+func (s *RetentionSuite) TestPrunesOperationHistory(c *check.C) {
+	now := time.Now().UTC()
+	older := s.createOperation(c, ops.OperationUpdate, ops.OperationStateCompleted, now.Add(-2*time.Hour))
+	newer := s.createOperation(c, ops.OperationUpdate, ops.OperationStateFailed, now.Add(-time.Hour))
+	running := s.createOperation(c, ops.OperationUpdate, ops.OperationStateUpdateInProgress, now.Add(-3*time.Hour))
+
+	err := s.operator.PruneOperationHistory(s.cluster.Key(), storage.QuotaPolicy{KeepLast: 1})
+	c.Assert(err, check.IsNil)
+
+	s.assertHistory(c, older, false, 1)
+	s.assertHistory(c, newer, true, 2)
+	s.assertHistory(c, running, true, 2)
+
+	_, err = s.operator.GetSiteOperation(older)
+	c.Assert(err, check.IsNil)
+}
+
+func (s *RetentionSuite) createOperation(c *check.C, typ, state string, created time.Time) ops.SiteOperationKey {
+	op, err := s.operator.backend().CreateSiteOperation(storage.SiteOperation{
+		ID:         uuid.New(),
+		AccountID:  s.cluster.AccountID,
+		SiteDomain: s.cluster.Domain,
+		Type:       typ,
+		State:      state,
+		Created:    created,
+	})
+	c.Assert(err, check.IsNil)
+	key := (*ops.SiteOperation)(op).Key()
+
+	for i := 0; i < 2; i++ {
+		_, err = s.operator.backend().CreateProgressEntry(storage.ProgressEntry{
+			SiteDomain:  key.SiteDomain,
+			OperationID: key.OperationID,
+			Created:     created.Add(time.Duration(i) * time.Minute),
+			Completion:  i * 100,
+			State:       state,
+		})
+		c.Assert(err, check.IsNil)
+	}
+
+	path := s.operator.operationLogPath(key)
+	c.Assert(os.MkdirAll(filepath.Dir(path), 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(path, []byte("log"), 0644), check.IsNil)
+	return key
+}
+
+func (s *RetentionSuite) assertHistory(c *check.C, key ops.SiteOperationKey, hasLog bool, numEntries int) {
+	_, err := os.Stat(s.operator.operationLogPath(key))
+	c.Assert(err == nil, check.Equals, hasLog)
+	entries, err := s.operator.backend().GetProgressEntries(key.SiteDomain, key.OperationID)
+	c.Assert(err, check.IsNil)
+	c.Assert(entries, check.HasLen, numEntries)
+}
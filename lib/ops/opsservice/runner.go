@@ -36,6 +36,9 @@ type remoteRunner interface {
 	Run(server remoteServer, args ...string) ([]byte, error)
 	// RunStream runs the provided command on the specified server and streams output to w
 	RunStream(server remoteServer, w io.Writer, args ...string) error
+	// RunStreamContext runs the provided command on the specified server and streams
+	// output to w. The command is aborted once the specified context expires
+	RunStreamContext(ctx context.Context, server remoteServer, w io.Writer, args ...string) error
 	// RunCmd runs the provided command on the specified server and logs
 	// its results into the operation context
 	RunCmd(operationContext, remoteServer, Command) ([]byte, error)
@@ -55,8 +58,13 @@ type teleportRunner struct {
 
 // RunStream runs the provided command on the specified server and streams output to w
 func (r *teleportRunner) RunStream(server remoteServer, w io.Writer, args ...string) error {
+	return r.RunStreamContext(context.TODO(), server, w, args...)
+}
+
+// RunStreamContext runs the provided command on the specified server and streams output to w
+func (r *teleportRunner) RunStreamContext(ctx context.Context, server remoteServer, w io.Writer, args ...string) error {
 	command := strings.Join(args, " ")
-	err := r.ExecuteCommand(context.TODO(), r.domainName, server.Address(), command, w)
+	err := r.ExecuteCommand(ctx, r.domainName, server.Address(), command, w)
 
 	entry := r.recorder.WithFields(log.Fields{
 		constants.FieldServer:             server.Address(),
@@ -98,7 +106,12 @@ type agentRunner struct {
 
 // RunStream runs the provided command on the specified server and streams output to w
 func (r *agentRunner) RunStream(server remoteServer, w io.Writer, args ...string) error {
-	err := r.AgentService.Exec(context.TODO(), r.ctx.key(), server.Address(), args, w)
+	return r.RunStreamContext(context.TODO(), server, w, args...)
+}
+
+// RunStreamContext runs the provided command on the specified server and streams output to w
+func (r *agentRunner) RunStreamContext(ctx context.Context, server remoteServer, w io.Writer, args ...string) error {
+	err := r.AgentService.Exec(ctx, r.ctx.key(), server.Address(), args, w)
 
 	entry := r.ctx.WithFields(log.Fields{
 		constants.FieldServer:             server.Address(),
@@ -160,6 +173,12 @@ func (r *serverRunner) RunStream(w io.Writer, args ...string) error {
 	return r.runner.RunStream(r.server, w, args...)
 }
 
+// RunStreamContext runs the provided command and streams output to w.
+// The command is aborted once the specified context expires
+func (r *serverRunner) RunStreamContext(ctx context.Context, w io.Writer, args ...string) error {
+	return r.runner.RunStreamContext(ctx, r.server, w, args...)
+}
+
 // Run runs the provided command
 func (r *serverRunner) Run(args ...string) ([]byte, error) {
 	return r.runner.Run(r.server, args...)
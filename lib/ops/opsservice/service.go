@@ -40,6 +40,7 @@ import (
 	"github.com/gravitational/gravity/lib/ops/opsclient"
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/status"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/users"
 	"github.com/gravitational/gravity/lib/utils"
@@ -136,6 +137,10 @@ type Config struct {
 
 	// GetHelmClient is a factory method for creating a Helm client.
 	GetHelmClient helm.GetClientFunc
+
+	// GetNodeStatus returns the status of the specified cluster nodes.
+	// Defaults to querying the planet agents
+	GetNodeStatus func(ctx context.Context, servers []storage.Server) ([]status.ClusterServer, error)
 }
 
 // Operator implements Operator interface
@@ -226,6 +231,9 @@ func (cfg *Config) CheckAndSetDefaults() error {
 	if cfg.GetHelmClient == nil {
 		cfg.GetHelmClient = helm.NewClient
 	}
+	if cfg.GetNodeStatus == nil {
+		cfg.GetNodeStatus = getNodeStatus
+	}
 	return nil
 }
 
@@ -254,6 +262,9 @@ func (cfg *Config) CheckRelaxed() error {
 	if cfg.GetHelmClient == nil {
 		cfg.GetHelmClient = helm.NewClient
 	}
+	if cfg.GetNodeStatus == nil {
+		cfg.GetNodeStatus = getNodeStatus
+	}
 	return nil
 }
 
@@ -271,6 +282,11 @@ func (o *Operator) siteDir(accountID, siteID string, additional ...string) strin
 	return filepath.Join(path...)
 }
 
+// operationLogPath returns the path to the log file of the specified operation
+func (o *Operator) operationLogPath(key ops.SiteOperationKey) string {
+	return o.siteDir(key.AccountID, key.SiteDomain, key.OperationID, fmt.Sprintf("%v.log", key.OperationID))
+}
+
 func (o *Operator) backend() storage.Backend {
 	return o.cfg.Backend
 }
@@ -1003,6 +1019,12 @@ func (o *Operator) SetOperationState(key ops.SiteOperationKey, req ops.SetOperat
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if len(req.FailedServers) != 0 {
+		err = site.removeFailedServers(key, req)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	// change the state without "compare" part just to take leverage of
 	// the operation group locking to ensure atomicity
 	_, err = site.compareAndSwapOperationState(swap{
@@ -1098,21 +1120,22 @@ func (o *Operator) GetSiteOperationCrashReport(key ops.SiteOperationKey) (io.Rea
 		return nil, trace.Wrap(err)
 	}
 
+	req := ops.SiteReportRequest{SiteKey: key.SiteKey()}
 	switch op.Type {
 	case ops.OperationInstall:
-		return site.getSiteOperationCrashReport(*op)
+		return site.getSiteOperationCrashReport(*op, req)
 	default:
-		return site.getSiteReport()
+		return site.getSiteReport(req)
 	}
 }
 
-func (o *Operator) GetSiteReport(key ops.SiteKey) (io.ReadCloser, error) {
-	site, err := o.openSite(key)
+func (o *Operator) GetSiteReport(req ops.SiteReportRequest) (io.ReadCloser, error) {
+	site, err := o.openSite(req.SiteKey)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	return site.getSiteReport()
+	return site.getSiteReport(req)
 }
 
 func (o *Operator) GetSiteOperationProgress(key ops.SiteOperationKey) (*ops.ProgressEntry, error) {
@@ -19,10 +19,14 @@ package opsservice
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
+	"github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/kubernetes"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
@@ -82,6 +86,7 @@ func (s *site) createShrinkOperation(context context.Context, req ops.CreateSite
 		Force:       req.Force,
 		Vars:        req.Variables,
 		NodeRemoved: req.NodeRemoved,
+		DrainPolicy: req.DrainPolicy,
 	}
 	op.Shrink.Vars.System.ClusterName = s.key.SiteDomain
 
@@ -99,11 +104,21 @@ func (s *site) createShrinkOperation(context context.Context, req ops.CreateSite
 		}
 	}
 
-	key, err := s.getOperationGroup().createSiteOperation(*op)
+	key, err := s.getOperationGroup().createSiteOperationInWindow(*op, req.IgnoreMaintenanceWindow)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
+	operation, err := s.getSiteOperation(key.OperationID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if operation.IsScheduled() {
+		// the operation will be started by the scheduler once the
+		// maintenance window opens
+		return key, nil
+	}
+
 	s.reportProgress(ctx, ops.ProgressEntry{
 		State:      ops.ProgressStateInProgress,
 		Completion: 0,
@@ -274,7 +289,7 @@ func (s *site) shrinkOperationStart(ctx *operationContext) (err error) {
 			Message:    "running pre-removal hooks",
 		})
 
-		if err = s.runHook(ctx, schema.HookNodeRemoving); err != nil {
+		if err = s.runNodeRemovingHook(ctx, *server, agentRunner); err != nil {
 			if !force {
 				return trace.Wrap(err, "failed to run %v hook", schema.HookNodeRemoving)
 			}
@@ -282,6 +297,21 @@ func (s *site) shrinkOperationStart(ctx *operationContext) (err error) {
 		}
 	}
 
+	if state.DrainPolicy != nil && online {
+		s.reportProgress(ctx, ops.ProgressEntry{
+			State:      ops.ProgressStateInProgress,
+			Completion: 25,
+			Message:    "draining the node",
+		})
+
+		if err = s.drainNode(ctx, *server, *state.DrainPolicy); err != nil {
+			if !force {
+				return trace.Wrap(err, "failed to drain the node")
+			}
+			ctx.Warningf("failed to drain the node, force continue: %v", trace.DebugReport(err))
+		}
+	}
+
 	s.reportProgress(ctx, ops.ProgressEntry{
 		State:      ops.ProgressStateInProgress,
 		Completion: 30,
@@ -572,6 +602,51 @@ func (s *site) deletePackages(server *ProvisionedServer) error {
 	return nil
 }
 
+// drainNode evicts pods from the node being removed according to the specified
+// policy and runs the node drain hooks if the application defines them
+func (s *site) drainNode(ctx *operationContext, server storage.Server, policy storage.DrainPolicy) error {
+	client, err := s.service.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env := map[string]string{constants.DrainNodeEnvVar: server.KubeNodeID()}
+	if s.app.Manifest.HasHook(schema.HookNodeDraining) {
+		err = s.runPackageHookWithEnv(ctx, s.app.Package, schema.HookNodeDraining, env)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	drainCtx, cancel := context.WithTimeout(ctx.ctx, defaults.DrainTimeout)
+	defer cancel()
+	err = kubernetes.DrainNode(drainCtx, kubernetes.DrainConfig{
+		Client:   client,
+		NodeName: server.KubeNodeID(),
+		Policy:   policy,
+		Progress: func(pods []string) {
+			message := "draining the node"
+			if len(pods) != 0 {
+				message = fmt.Sprintf("draining the node, waiting for pods blocked by disruption budgets: %v",
+					strings.Join(pods, ", "))
+			}
+			s.reportProgress(ctx, ops.ProgressEntry{
+				State:      ops.ProgressStateInProgress,
+				Completion: 25,
+				Message:    message,
+			})
+		},
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if s.app.Manifest.HasHook(schema.HookNodeDrained) {
+		err = s.runPackageHookWithEnv(ctx, s.app.Package, schema.HookNodeDrained, env)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
 // unlabelNode deletes server profile labels from k8s node
 func (s *site) unlabelNode(server storage.Server, runner *serverRunner) error {
 	role := server.Role
@@ -636,3 +711,46 @@ func (s *site) serfNodeLeave(runner *serverRunner) error {
 	})
 	return trace.Wrap(err)
 }
+
+// runNodeRemovingHook runs the application's preNodeRemove hook.
+// The hook defined as a host script runs on the node being removed
+// using its shrink agent, if the node is online
+func (s *site) runNodeRemovingHook(ctx *operationContext, server storage.Server, agentRunner *serverRunner) error {
+	isHost, err := app.IsHostHook(s.appService, s.app.Package, schema.HookNodeRemoving)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !isHost {
+		return trace.Wrap(s.runHook(ctx, schema.HookNodeRemoving))
+	}
+	if agentRunner == nil {
+		ctx.Warningf("Node %q is offline, skip %v hook.", server.Hostname, schema.HookNodeRemoving)
+		return nil
+	}
+	return trace.Wrap(app.RunHostHook(context.TODO(), s.appService, app.HostHookRequest{
+		Application: s.app.Package,
+		Hook:        schema.HookNodeRemoving,
+		Servers:     []storage.Server{server},
+		Runner:      hostRunner{agentRunner},
+		Recorder: ops.HookRecorder{
+			Operator: s.service,
+			Key:      ctx.key(),
+		},
+	}, ctx.recorder))
+}
+
+// hostRunner runs host hook scripts with the server runner
+type hostRunner struct {
+	*serverRunner
+}
+
+// RunStream executes the command on the server of the runner.
+// The command is aborted once the context expires which enforces
+// the hook deadline
+func (r hostRunner) RunStream(ctx context.Context, server storage.Server, w io.Writer, args ...string) error {
+	if server.AdvertiseIP != r.server.Address() {
+		return trace.BadParameter("runner for %v cannot run commands on %v",
+			r.server.Address(), server.AdvertiseIP)
+	}
+	return trace.Wrap(r.serverRunner.RunStreamContext(ctx, w, args...))
+}
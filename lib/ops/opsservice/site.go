@@ -120,7 +120,7 @@ func (s *site) String() string {
 }
 
 func (s *site) operationLogPath(key ops.SiteOperationKey) string {
-	return s.siteDir(key.OperationID, fmt.Sprintf("%v.log", key.OperationID))
+	return s.service.operationLogPath(key)
 }
 
 func (s *site) openFiles(filePaths ...string) ([]io.WriteCloser, error) {
@@ -102,6 +102,15 @@ func (s *site) checkPlanetStatus(ctx context.Context) error {
 	return nil
 }
 
+// getNodeStatus returns the status of the specified nodes as reported by planet agents
+func getNodeStatus(ctx context.Context, servers []storage.Server) ([]status.ClusterServer, error) {
+	planetStatus, err := status.FromPlanetAgent(ctx, servers)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return planetStatus.Nodes, nil
+}
+
 // checkStatusHook executes the application's status hook
 func (s *site) checkStatusHook(ctx context.Context) error {
 	if !s.app.Manifest.HasHook(schema.HookStatus) {
@@ -368,6 +368,8 @@ func (s *site) createUpdateOperation(context context.Context, req ops.CreateSite
 		Provisioner: installOperation.Provisioner,
 		Update: &storage.UpdateOperationState{
 			UpdatePackage: req.App,
+			Strategy:      req.Strategy,
+			DrainPolicy:   req.DrainPolicy,
 		},
 	}
 
@@ -377,7 +379,7 @@ func (s *site) createUpdateOperation(context context.Context, req ops.CreateSite
 	}
 	defer ctx.Close()
 
-	key, err := s.getOperationGroup().createSiteOperation(op)
+	key, err := s.getOperationGroup().createSiteOperationInWindow(op, req.IgnoreMaintenanceWindow)
 	if err != nil {
 		return nil, trace.Wrap(err, "failed to create update operation")
 	}
@@ -439,6 +441,16 @@ func (s *site) validateUpdateOperationRequest(req ops.CreateSiteAppUpdateOperati
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if req.Strategy != nil {
+		if err := req.Strategy.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if req.DrainPolicy != nil {
+		if err := req.DrainPolicy.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	// the new package must exist in the Ops Center
 	newEnvelope, err := s.packages().ReadPackageEnvelope(*updatePackage)
 	if err != nil {
@@ -480,6 +492,8 @@ func (s *site) startUpdateAgent(ctx context.Context, opCtx *operationContext, up
 		return trace.Wrap(err)
 	}
 	serverStateDir := stateServer.StateDir()
+	// the agent runs the gravity binary built for the master's architecture
+	agentPackage := schema.ArchPackage(*gravityPackage, stateServer.Arch)
 	agentExecPath := filepath.Join(state.GravityRPCAgentDir(serverStateDir), constants.GravityBin)
 	secretsHostDir := filepath.Join(state.GravityRPCAgentDir(serverStateDir), defaults.SecretsDir)
 	err = utils.NewSSHCommands(nodeClient.Client).
@@ -488,7 +502,7 @@ func (s *site) startUpdateAgent(ctx context.Context, opCtx *operationContext, up
 		C("mkdir -p %s", secretsHostDir).
 		C("%s package export --file-mask=%o %s %s --ops-url=%s --insecure --quiet",
 			constants.GravityBin, defaults.SharedExecutableMask,
-			gravityPackage.String(), agentExecPath, defaults.GravityServiceURL).
+			agentPackage.String(), agentExecPath, defaults.GravityServiceURL).
 		C("%s update init-plan", agentExecPath).
 		// distribute agents and upgrade process
 		C("%s agent deploy --leader=upgrade --node=sync-plan", agentExecPath).
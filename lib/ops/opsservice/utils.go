@@ -59,16 +59,26 @@ func GetMounts(m schema.Manifest, server storage.Server) ([]storage.Mount, error
 
 // runPackageHook invokes the specified hook for the application identified by the provided locator.
 func (s *site) runPackageHook(ctx *operationContext, locator loc.Locator, hook schema.HookType) error {
+	return s.runPackageHookWithEnv(ctx, locator, hook, nil)
+}
+
+// runPackageHookWithEnv invokes the specified hook for the application identified by the provided
+// locator and passes it the specified additional environment variables
+func (s *site) runPackageHookWithEnv(ctx *operationContext, locator loc.Locator, hook schema.HookType, env map[string]string) error {
 	var out []byte
 	var err error
 	if s.service.cfg.Local {
 		_, out, err = app.RunAppHook(context.TODO(), s.appService, app.HookRunRequest{
 			Application: locator,
 			Hook:        hook,
+			Env:         env,
 			ServiceUser: s.serviceUser(),
 		})
 	} else {
 		command := s.planetGravityCommand("app", "hook", locator.String(), hook.String())
+		for name, value := range env {
+			command = append(command, fmt.Sprintf("--env=%v=%v", name, value))
+		}
 		out, err = s.runOnMaster(ctx, command)
 	}
 	if err != nil {
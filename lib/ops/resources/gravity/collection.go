@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"time"
 
@@ -575,6 +576,172 @@ func (c *authGatewayCollection) ToMarshal() interface{} {
 	return c.item
 }
 
+type maintenanceWindowCollection struct {
+	item storage.MaintenanceWindow
+}
+
+// Resources returns the resources collection in the generic format
+func (c *maintenanceWindowCollection) Resources() ([]teleservices.UnknownResource, error) {
+	resource, err := utils.ToUnknownResource(c.item)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []teleservices.UnknownResource{*resource}, nil
+}
+
+// WriteText serializes maintenance window in human-friendly text format
+func (c *maintenanceWindowCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Parameter", "Value"})
+	fmt.Fprintf(t, "Timezone:\t%v\n", c.item.GetTimezone())
+	var windows []string
+	for _, window := range c.item.GetWindows() {
+		windows = append(windows, fmt.Sprintf("%q for %v",
+			window.Schedule, window.Duration.Value()))
+	}
+	fmt.Fprintf(t, "Windows:\t%v\n", formatList(windows))
+	fmt.Fprintf(t, "Blackout Dates:\t%v\n", formatList(c.item.GetBlackoutDates()))
+	open, err := c.item.IsOpen(time.Now())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if open {
+		fmt.Fprintf(t, "Status:\topen\n")
+	} else {
+		next, err := c.item.NextOpen(time.Now())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Fprintf(t, "Status:\tclosed, opens at %v\n", next.UTC().Format(constants.HumanDateFormat))
+	}
+	_, err = io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (c *maintenanceWindowCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(c, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (c *maintenanceWindowCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(c, w)
+}
+
+// ToMarshal returns object that should be marshaled.
+func (c *maintenanceWindowCollection) ToMarshal() interface{} {
+	return c.item
+}
+
+type chartSigningPolicyCollection struct {
+	item storage.ChartSigningPolicy
+}
+
+// Resources returns the resources collection in the generic format
+func (c *chartSigningPolicyCollection) Resources() ([]teleservices.UnknownResource, error) {
+	resource, err := utils.ToUnknownResource(c.item)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []teleservices.UnknownResource{*resource}, nil
+}
+
+// WriteText serializes chart signing policy in human-friendly text format
+func (c *chartSigningPolicyCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Trusted Key", "Identities", "Fingerprint"})
+	for _, key := range c.item.GetTrustedKeys() {
+		keyring, err := key.KeyRing()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for _, entity := range keyring {
+			var identities []string
+			for name := range entity.Identities {
+				identities = append(identities, name)
+			}
+			sort.Strings(identities)
+			fmt.Fprintf(t, "%v\t%v\t%X\n", key.Name, formatList(identities),
+				entity.PrimaryKey.Fingerprint)
+		}
+	}
+	_, err := fmt.Fprintf(w, "Mode: %v\n\n%v", c.item.GetMode(), t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (c *chartSigningPolicyCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(c, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (c *chartSigningPolicyCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(c, w)
+}
+
+// ToMarshal returns object that should be marshaled.
+func (c *chartSigningPolicyCollection) ToMarshal() interface{} {
+	return c.item
+}
+
+type helmRepositoryCollection []storage.HelmRepository
+
+// Resources returns the resources collection in the generic format
+func (c helmRepositoryCollection) Resources() (resources []teleservices.UnknownResource, err error) {
+	for _, item := range c {
+		resource, err := utils.ToUnknownResource(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		resources = append(resources, *resource)
+	}
+	return resources, nil
+}
+
+// WriteText serializes helm repositories in human-friendly text format
+func (c helmRepositoryCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Name", "URL", "Charts", "Last Synced", "Imported", "Error"})
+	for _, repository := range c {
+		status := repository.GetStatus()
+		lastSynced := "never"
+		if !status.LastSynced.IsZero() {
+			lastSynced = status.LastSynced.Format(constants.HumanDateFormat)
+		}
+		syncError := "-"
+		if status.Error != "" {
+			syncError = status.Error
+		}
+		charts := "all"
+		if len(repository.GetCharts()) != 0 {
+			charts = formatList(repository.GetCharts())
+		}
+		fmt.Fprintf(t, "%v\t%v\t%v\t%v\t%v\t%v\n", repository.GetName(),
+			repository.GetURL(), charts, lastSynced, len(status.Imported),
+			syncError)
+	}
+	_, err := io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (c helmRepositoryCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(c, w)
+}
+
+// WriteYAML serializes collection into YAML format
+func (c helmRepositoryCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(c, w)
+}
+
+// ToMarshal returns object that should be marshaled.
+func (c helmRepositoryCollection) ToMarshal() interface{} {
+	if len(c) == 1 {
+		return c[0]
+	}
+	return c
+}
+
 // WriteText serializes collection in human-friendly text format
 func (r envCollection) WriteText(w io.Writer) error {
 	t := goterm.NewTable(0, 10, 5, ' ', 0)
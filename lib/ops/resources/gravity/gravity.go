@@ -229,6 +229,36 @@ func (r *Resources) Create(ctx context.Context, req resources.CreateRequest) err
 			return trace.Wrap(err)
 		}
 		r.Println("Updated auth gateway configuration")
+	case storage.KindMaintenanceWindow:
+		window, err := storage.UnmarshalMaintenanceWindow(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertMaintenanceWindow(ctx, r.cluster.Key(), window)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Println("Updated cluster maintenance window")
+	case storage.KindChartSigningPolicy:
+		policy, err := storage.UnmarshalChartSigningPolicy(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertChartSigningPolicy(ctx, r.cluster.Key(), policy)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Println("Updated cluster chart signing policy")
+	case storage.KindHelmRepository:
+		repository, err := storage.UnmarshalHelmRepository(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertHelmRepository(ctx, r.cluster.Key(), repository)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Printf("Updated helm repository %q\n", repository.GetName())
 	case storage.KindRuntimeEnvironment, storage.KindClusterConfiguration:
 		err := r.ClusterOperationHandler.UpdateResource(req)
 		return trace.Wrap(err)
@@ -340,6 +370,31 @@ func (r *Resources) GetCollection(req resources.ListRequest) (resources.Collecti
 			return nil, trace.Wrap(err)
 		}
 		return &authGatewayCollection{gw}, nil
+	case storage.KindMaintenanceWindow:
+		window, err := r.Operator.GetMaintenanceWindow(r.cluster.Key())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &maintenanceWindowCollection{window}, nil
+	case storage.KindChartSigningPolicy:
+		policy, err := r.Operator.GetChartSigningPolicy(r.cluster.Key())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &chartSigningPolicyCollection{policy}, nil
+	case storage.KindHelmRepository:
+		if req.Name != "" {
+			repository, err := r.Operator.GetHelmRepository(r.cluster.Key(), req.Name, req.WithSecrets)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			return helmRepositoryCollection{repository}, nil
+		}
+		repositories, err := r.Operator.GetHelmRepositories(r.cluster.Key(), req.WithSecrets)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return helmRepositoryCollection(repositories), nil
 	case storage.KindSMTPConfig:
 		config, err := r.Operator.GetSMTPConfig(r.cluster.Key())
 		if err != nil {
@@ -451,6 +506,30 @@ func (r *Resources) Remove(ctx context.Context, req resources.RemoveRequest) err
 			return trace.Wrap(err)
 		}
 		r.Println("SMTP configuration has been deleted")
+	case storage.KindMaintenanceWindow:
+		if err := r.Operator.DeleteMaintenanceWindow(ctx, r.cluster.Key()); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Println("Maintenance window has been deleted")
+	case storage.KindChartSigningPolicy:
+		if err := r.Operator.DeleteChartSigningPolicy(ctx, r.cluster.Key()); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Println("Chart signing policy has been deleted")
+	case storage.KindHelmRepository:
+		if err := r.Operator.DeleteHelmRepository(ctx, r.cluster.Key(), req.Name); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Printf("Helm repository %q has been deleted\n", req.Name)
 	case storage.KindAlert:
 		if err := r.Operator.DeleteAlert(ctx, r.cluster.Key(), req.Name); err != nil {
 			if trace.IsNotFound(err) && req.Force {
@@ -511,6 +590,12 @@ func Validate(resource storage.UnknownResource) (err error) {
 		_, err = storage.UnmarshalAlertTarget(resource.Raw)
 	case storage.KindAuthGateway:
 		_, err = storage.UnmarshalAuthGateway(resource.Raw)
+	case storage.KindMaintenanceWindow:
+		_, err = storage.UnmarshalMaintenanceWindow(resource.Raw)
+	case storage.KindChartSigningPolicy:
+		_, err = storage.UnmarshalChartSigningPolicy(resource.Raw)
+	case storage.KindHelmRepository:
+		_, err = storage.UnmarshalHelmRepository(resource.Raw)
 	case storage.KindRuntimeEnvironment:
 		_, err = storage.UnmarshalEnvironmentVariables(resource.Raw)
 	case storage.KindClusterConfiguration:
@@ -88,6 +88,10 @@ type CreateRequest struct {
 	// Confirmed defines whether the operation has been explicitly approved.
 	// This attribute is operation-specific
 	Confirmed bool
+	// IgnoreMaintenanceWindow defines whether the operation can start outside
+	// of the cluster maintenance window.
+	// This attribute is operation-specific
+	IgnoreMaintenanceWindow bool
 }
 
 // Check validates the request
@@ -141,6 +145,10 @@ type RemoveRequest struct {
 	// Confirmed defines whether the operation has been explicitly approved.
 	// This attribute is operation-specific
 	Confirmed bool
+	// IgnoreMaintenanceWindow defines whether the operation can start outside
+	// of the cluster maintenance window.
+	// This attribute is operation-specific
+	IgnoreMaintenanceWindow bool
 }
 
 // Check validates the request
@@ -156,6 +164,8 @@ func (r *RemoveRequest) Check() error {
 	switch kind {
 	case storage.KindAlertTarget:
 	case storage.KindSMTPConfig:
+	case storage.KindMaintenanceWindow:
+	case storage.KindChartSigningPolicy:
 	case storage.KindRuntimeEnvironment:
 	case storage.KindClusterConfiguration:
 	default:
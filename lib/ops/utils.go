@@ -17,6 +17,7 @@ limitations under the License.
 package ops
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -162,7 +163,8 @@ func GetOperationWithProgress(opKey SiteOperationKey, operator Operator) (*SiteO
 	return operation, progress, nil
 }
 
-// GetActiveOperations returns a list of currently active cluster operations
+// GetActiveOperations returns a list of currently active cluster operations.
+// Operations scheduled for the maintenance window are not considered active
 func GetActiveOperations(key SiteKey, operator Operator) (active []SiteOperation, err error) {
 	all, err := operator.GetSiteOperations(key)
 	if err != nil {
@@ -170,7 +172,7 @@ func GetActiveOperations(key SiteKey, operator Operator) (active []SiteOperation
 	}
 	for _, op := range all {
 		operation := (*SiteOperation)(&op)
-		if !operation.IsFinished() {
+		if !operation.IsFinished() && !operation.IsScheduled() {
 			active = append(active, *operation)
 		}
 	}
@@ -195,6 +197,68 @@ func GetActiveOperationsByType(key SiteKey, operator Operator, opType string) (r
 	return result, nil
 }
 
+// GetScheduledOperations returns the list of cluster operations waiting
+// for the maintenance window to open, oldest first
+func GetScheduledOperations(key SiteKey, operator Operator) (scheduled []SiteOperation, err error) {
+	all, err := operator.GetSiteOperations(key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// backend returns operations in the last-to-first order
+	for i := len(all) - 1; i >= 0; i-- {
+		operation := SiteOperation(all[i])
+		if operation.IsScheduled() {
+			scheduled = append(scheduled, operation)
+		}
+	}
+	if len(scheduled) == 0 {
+		return nil, trace.NotFound("no scheduled operations for %v", key)
+	}
+	return scheduled, nil
+}
+
+// WaitForScheduledOperation blocks until the specified operation, if it has been
+// scheduled for the maintenance window, is launched.
+// Returns the operation in the state it has been launched into
+func WaitForScheduledOperation(ctx context.Context, operator Operator, key SiteOperationKey) (*SiteOperation, error) {
+	ticker := time.NewTicker(defaults.ScheduledOperationPollInterval)
+	defer ticker.Stop()
+	for {
+		operation, err := operator.GetSiteOperation(key)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if !operation.IsScheduled() {
+			if operation.IsFinished() {
+				return nil, trace.CompareFailed("scheduled operation %v has been cancelled", operation)
+			}
+			return operation, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, trace.Wrap(ctx.Err())
+		}
+	}
+}
+
+// CheckOperationLaunched returns an error if the specified operation
+// is still waiting for the maintenance window to open
+func CheckOperationLaunched(operator Operator, key SiteOperationKey) error {
+	operation, err := operator.GetSiteOperation(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !operation.IsScheduled() {
+		return nil
+	}
+	if operation.Schedule != nil {
+		return trace.BadParameter("operation %v is scheduled to start after %v when the maintenance window opens",
+			key.OperationID, operation.Schedule.StartAfter.Format(constants.HumanDateFormat))
+	}
+	return trace.BadParameter("operation %v is waiting for the maintenance window to open", key.OperationID)
+}
+
 // MatchOperation returns an operation that matches given match function.
 // Returns trace.NotFound if no operation matches
 func MatchOperation(siteKey SiteKey, operator Operator, match OperationMatcher) (op *SiteOperation, progress *ProgressEntry, err error) {
@@ -301,6 +365,27 @@ func FailOperation(key SiteOperationKey, operator OperationStateSetter, message
 	})
 }
 
+// RollbackOperation marks the specified operation as rolled back
+func RollbackOperation(key SiteOperationKey, operator OperationStateSetter, message string) error {
+	if message != "" {
+		message = fmt.Sprintf("Operation has been rolled back: %v", message)
+	} else {
+		message = "Operation has been rolled back"
+	}
+	return operator.SetOperationState(key, SetOperationStateRequest{
+		State: OperationStateRolledBack,
+		Progress: &ProgressEntry{
+			SiteDomain:  key.SiteDomain,
+			OperationID: key.OperationID,
+			Step:        constants.FinalStep,
+			Completion:  constants.Completed,
+			State:       ProgressStateFailed,
+			Message:     strings.TrimSpace(message),
+			Created:     time.Now().UTC(),
+		},
+	})
+}
+
 // OperationStateSetter defines an interface to set/update operation state
 type OperationStateSetter interface {
 	// SetOperationState updates state of the operation
@@ -316,6 +401,22 @@ func (r OperationStateFunc) SetOperationState(key SiteOperationKey, req SetOpera
 // OperationStateFunc is a function handler for setting the operation state
 type OperationStateFunc func(SiteOperationKey, SetOperationStateRequest) error
 
+// HookRecorder records application hook attempts of an operation phase
+type HookRecorder struct {
+	// Operator is the operator the attempts are recorded with
+	Operator Operator
+	// Key identifies the operation
+	Key SiteOperationKey
+	// PhaseID is the ID of the phase running the hook
+	PhaseID string
+}
+
+// RecordHookAttempt records the provided hook attempt with the operator
+func (r HookRecorder) RecordHookAttempt(attempt storage.HookAttempt) error {
+	attempt.PhaseID = r.PhaseID
+	return r.Operator.CreateHookAttempt(r.Key, attempt)
+}
+
 // VerifyLicense verifies the provided license
 func VerifyLicense(packages pack.PackageService, license string) error {
 	parsed, err := licenseapi.ParseLicense(license)
@@ -29,6 +29,9 @@ const (
 	AdvertiseIPLabel = "advertise-ip"
 	// OperationIDLabel contains ID of the operation the package was configured for
 	OperationIDLabel = "operation-id"
+	// ImageDigestLabelPrefix prefixes the labels recording the manifest digests
+	// of the images vendored in an application package, keyed by image name and tag
+	ImageDigestLabelPrefix = "image-digest/"
 
 	// PurposeCA marks the planet certificate authority package
 	PurposeCA = "ca"
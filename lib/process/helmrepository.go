@@ -0,0 +1,116 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/app/docker"
+	"github.com/gravitational/gravity/lib/app/service"
+	"github.com/gravitational/gravity/lib/catalog"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/pack"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// startHelmRepositorySyncer periodically imports charts from the configured
+// helm repositories into the cluster catalog
+func (p *Process) startHelmRepositorySyncer(ctx context.Context, apps app.Applications) error {
+	log := p.WithField(trace.Component, "helm-sync")
+	// gravity-site does not have access to a docker daemon so chart
+	// images are pulled directly from their registries
+	puller, err := docker.NewRegistryPuller(docker.RegistryPullerConfig{
+		Parallel:    runtime.NumCPU(),
+		FieldLogger: log,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	syncer, err := newHelmRepositorySyncer(apps, p.packages, puller, log)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	p.Info("Starting helm repository syncer.")
+	ticker := time.NewTicker(defaults.HelmRepositorySyncInterval)
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.syncHelmRepositories(ctx, syncer); err != nil {
+				p.Errorf("Failed to sync helm repositories: %v.",
+					trace.DebugReport(err))
+			}
+		case <-ctx.Done():
+			p.Info("Stopping helm repository syncer.")
+			ticker.Stop()
+			return nil
+		}
+	}
+}
+
+// newHelmRepositorySyncer returns a syncer that vendors chart images
+// with the specified registry puller
+func newHelmRepositorySyncer(apps app.Applications, packages pack.PackageService, puller *docker.RegistryPuller, log logrus.FieldLogger) (*catalog.Syncer, error) {
+	vendorer, err := service.NewVendorer(service.VendorerConfig{
+		RegistryURL:    constants.DockerRegistry,
+		Packages:       packages,
+		RegistryPuller: puller,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return catalog.NewSyncer(catalog.SyncerConfig{
+		Apps:        apps,
+		Packages:    packages,
+		Vendorer:    vendorer,
+		Parallel:    runtime.NumCPU(),
+		FieldLogger: log,
+	})
+}
+
+// syncHelmRepositories syncs the repositories that are due and records the
+// sync status of each
+func (p *Process) syncHelmRepositories(ctx context.Context, syncer *catalog.Syncer) error {
+	repositories, err := p.backend.GetHelmRepositories()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, repository := range repositories {
+		if !repository.IsSyncDue(time.Now().UTC()) {
+			continue
+		}
+		status := syncer.Sync(ctx, repository)
+		// The repository may have been updated or removed during the sync
+		repository, err = p.backend.GetHelmRepository(repository.GetName())
+		if err != nil {
+			if trace.IsNotFound(err) {
+				continue
+			}
+			return trace.Wrap(err)
+		}
+		repository.SetStatus(status)
+		if err := p.backend.UpsertHelmRepository(repository); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
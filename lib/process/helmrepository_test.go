@@ -0,0 +1,109 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/app/docker"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops/opsservice"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/check.v1"
+	"k8s.io/helm/pkg/chartutil"
+	"k8s.io/helm/pkg/repo"
+)
+
+type HelmRepositorySuite struct{}
+
+var _ = check.Suite(&HelmRepositorySuite{})
+
+// TestSyncsWithoutDockerDaemon verifies that chart images are vendored
+// straight from their registry
+func (s *HelmRepositorySuite) TestSyncsWithoutDockerDaemon(c *check.C) {
+	registryDir := c.MkDir()
+	docker.CreateTestImage(c, registryDir, "upstream/app", "1.0",
+		docker.TestLayer(c, map[string]string{"etc/app": "app"}))
+	// The chart install hooks run the default hook container image
+	docker.CreateTestImage(c, registryDir, "gravitational/debian-tall", "0.0.1",
+		docker.TestLayer(c, map[string]string{"bin/sh": "sh"}))
+	registry, err := docker.NewRegistry(docker.BasicConfiguration("127.0.0.1:0", registryDir))
+	c.Assert(err, check.IsNil)
+	c.Assert(registry.Start(), check.IsNil)
+	defer registry.Close()
+
+	repoDir := c.MkDir()
+	writeTestChart(c, repoDir, "app", "1.0.0", registry.Addr()+"/upstream/app:1.0")
+	server := httptest.NewServer(http.FileServer(http.Dir(repoDir)))
+	defer server.Close()
+	index, err := repo.IndexDirectory(repoDir, server.URL)
+	c.Assert(err, check.IsNil)
+	c.Assert(index.WriteFile(filepath.Join(repoDir, "index.yaml"), 0644), check.IsNil)
+
+	services := opsservice.SetupTestServices(c)
+	_, err = services.Backend.CreateAccount(storage.Account{
+		ID:  defaults.SystemAccountID,
+		Org: defaults.SystemAccountOrg,
+	})
+	c.Assert(err, check.IsNil)
+
+	log := logrus.WithField("test", "helm-sync")
+	puller, err := docker.NewRegistryPuller(docker.RegistryPullerConfig{
+		Mirrors: []docker.RegistryMirror{
+			{Registry: "quay.io", Mirror: registry.Addr()},
+		},
+		InsecureRegistries: []string{registry.Addr()},
+		FieldLogger:        log,
+	})
+	c.Assert(err, check.IsNil)
+	syncer, err := newHelmRepositorySyncer(services.Apps, services.Packages, puller, log)
+	c.Assert(err, check.IsNil)
+
+	repository := storage.NewHelmRepository("test", storage.HelmRepositorySpecV1{URL: server.URL})
+	c.Assert(repository.CheckAndSetDefaults(), check.IsNil)
+	status := syncer.Sync(context.TODO(), repository)
+	c.Assert(status.Error, check.Equals, "")
+	c.Assert(status.Imported, check.HasLen, 1)
+}
+
+// writeTestChart packages a chart with a pod that runs the specified image
+func writeTestChart(c *check.C, dir, name, version, image string) {
+	chartDir := filepath.Join(c.MkDir(), name)
+	c.Assert(os.MkdirAll(filepath.Join(chartDir, "templates"), 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(chartDir, "Chart.yaml"),
+		[]byte("name: "+name+"\nversion: "+version+"\n"), 0644), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(chartDir, "templates", "pod.yaml"),
+		[]byte(`apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  containers:
+  - name: app
+    image: `+image+"\n"), 0644), check.IsNil)
+	chart, err := chartutil.LoadDir(chartDir)
+	c.Assert(err, check.IsNil)
+	_, err = chartutil.Save(chart, dir)
+	c.Assert(err, check.IsNil)
+}
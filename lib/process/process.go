@@ -627,6 +627,36 @@ func (p *Process) startSiteStatusChecker(ctx context.Context) error {
 	}
 }
 
+// startOperationScheduler periodically launches operations that have been
+// scheduled for the cluster maintenance window
+func (p *Process) startOperationScheduler(ctx context.Context) error {
+	site, err := p.operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	p.Info("Starting operation scheduler.")
+	ticker := time.NewTicker(defaults.OperationSchedulerInterval)
+	localCtx := context.WithValue(ctx, constants.UserContext,
+		constants.ServiceOperationScheduler)
+	for {
+		select {
+		case <-ticker.C:
+			key := ops.SiteKey{
+				AccountID:  site.AccountID,
+				SiteDomain: site.Domain,
+			}
+			if err := p.operator.LaunchScheduledOperations(localCtx, key); err != nil {
+				p.Errorf("Failed to launch scheduled operations: %v.",
+					trace.DebugReport(err))
+			}
+		case <-ctx.Done():
+			p.Info("Stopping operation scheduler.")
+			ticker.Stop()
+			return nil
+		}
+	}
+}
+
 // startElection starts leader election process and watches the changes
 func (p *Process) startElection() error {
 	// elect gravity site leader - all other sites will remain
@@ -1253,6 +1283,21 @@ func (p *Process) initService(ctx context.Context) (err error) {
 	// site status checker executes status hook periodically
 	p.RegisterClusterService(p.startSiteStatusChecker)
 
+	// operation scheduler launches operations when the maintenance window opens
+	p.RegisterClusterService(p.startOperationScheduler)
+
+	// retention enforcer prunes application packages and operation history
+	if !p.cfg.Retention.IsEmpty() {
+		p.RegisterClusterService(func(ctx context.Context) error {
+			return p.startRetentionEnforcer(ctx, operator)
+		})
+	}
+
+	// helm repository syncer imports external charts into the cluster catalog
+	p.RegisterClusterService(func(ctx context.Context) error {
+		return p.startHelmRepositorySyncer(ctx, applications)
+	})
+
 	// a few services that are running only when gravity is started in
 	// local site mode
 	if p.inKubernetes() {
@@ -0,0 +1,109 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/ops/opsservice"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/vacuum/prune"
+	"github.com/gravitational/gravity/lib/vacuum/prune/pack"
+
+	"github.com/gravitational/trace"
+)
+
+// startRetentionEnforcer periodically applies the configured retention
+// policies to the application packages and the operation history
+func (p *Process) startRetentionEnforcer(ctx context.Context, operator *opsservice.Operator) error {
+	p.Info("Starting retention enforcer.")
+	ticker := time.NewTicker(p.cfg.Retention.Interval)
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.enforceRetention(ctx, operator); err != nil {
+				p.Errorf("Failed to enforce retention policies: %v.",
+					trace.DebugReport(err))
+			}
+		case <-ctx.Done():
+			p.Info("Stopping retention enforcer.")
+			ticker.Stop()
+			return nil
+		}
+	}
+}
+
+func (p *Process) enforceRetention(ctx context.Context, operator *opsservice.Operator) error {
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var clusters []ops.Site
+	accounts, err := operator.GetAccounts()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, account := range accounts {
+		accountClusters, err := operator.GetSites(account.ID)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		clusters = append(clusters, accountClusters...)
+	}
+	if policy := p.cfg.Retention.Operations; policy != nil {
+		for _, cluster := range clusters {
+			if err := operator.PruneOperationHistory(cluster.Key(), *policy); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+	}
+	if policy := p.cfg.Retention.Packages; policy != nil {
+		var apps []storage.Application
+		for _, remoteCluster := range clusters {
+			if remoteCluster.Key() == cluster.Key() {
+				continue
+			}
+			apps = append(apps, storage.Application{
+				Locator:  remoteCluster.App.Package,
+				Manifest: remoteCluster.App.Manifest,
+			})
+		}
+		pruner, err := pack.New(pack.Config{
+			App: &storage.Application{
+				Locator:  cluster.App.Package,
+				Manifest: cluster.App.Manifest,
+			},
+			Apps:      apps,
+			Packages:  p.packages,
+			Retention: policy,
+			Config: prune.Config{
+				FieldLogger: p.WithField(trace.Component, "gc:package"),
+				Silent:      localenv.Silent(true),
+			},
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := pruner.Prune(ctx); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
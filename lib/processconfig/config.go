@@ -23,6 +23,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
@@ -150,6 +151,9 @@ type Config struct {
 	// Charts is Helm chart repository configuration.
 	Charts ChartsConfig `yaml:"charts"`
 
+	// Retention specifies the periodic retention of packages and operation history
+	Retention RetentionConfig `yaml:"retention"`
+
 	// Users list allows to add registered users to the application
 	// e.g. application admins, what is handy for development purposes
 	Users Users `yaml:"users"`
@@ -220,6 +224,10 @@ func (cfg *Config) CheckAndSetDefaults() error {
 		return trace.Wrap(err)
 	}
 
+	if err := cfg.Retention.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+
 	return nil
 }
 
@@ -365,6 +373,40 @@ func (c *ChartsConfig) CheckAndSetDefaults() error {
 	return nil
 }
 
+// RetentionConfig defines the quota policies gravity-site enforces periodically
+type RetentionConfig struct {
+	// Interval specifies how often the policies are enforced
+	Interval time.Duration `yaml:"interval"`
+	// Packages specifies the policy for application packages
+	Packages *storage.QuotaPolicy `yaml:"packages"`
+	// Operations specifies the policy for operation logs and progress entries
+	Operations *storage.QuotaPolicy `yaml:"operations"`
+}
+
+// IsEmpty returns true if no retention policy has been configured
+func (c RetentionConfig) IsEmpty() bool {
+	return c.Packages == nil && c.Operations == nil
+}
+
+// CheckAndSetDefaults validates retention configuration and sets defaults
+func (c *RetentionConfig) CheckAndSetDefaults() error {
+	for _, policy := range []*storage.QuotaPolicy{c.Packages, c.Operations} {
+		if policy == nil {
+			continue
+		}
+		if err := policy.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if c.Interval < 0 {
+		return trace.BadParameter("retention interval cannot be negative")
+	}
+	if c.Interval == 0 {
+		c.Interval = defaults.RetentionInterval
+	}
+	return nil
+}
+
 // OpsCenterConfig provides settings for access and installation portal
 type OpsCenterConfig struct {
 	// SeedConfig defines optional configuration to apply on OpsCenter start
@@ -0,0 +1,380 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/ghodss/yaml"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+	v1 "k8s.io/api/core/v1"
+)
+
+// analyzer parses a specific collected output and detects problems
+type analyzer struct {
+	// name is the name of the collected output sans the optional node prefix
+	name string
+	// compressed specifies whether the output is gzip-compressed
+	compressed bool
+	// analyze parses the output and returns the list of detected problems
+	analyze func(io.Reader) ([]Problem, error)
+}
+
+// findAnalyzer returns the analyzer for the collected output with the specified path
+// or nil if there is no analyzer for this output.
+// The output can be named either exactly as the collector or be prefixed with
+// the name of the node it was collected on
+func findAnalyzer(path string) *analyzer {
+	base := filepath.Base(path)
+	for i, analyzer := range analyzers {
+		if base == analyzer.name || strings.HasSuffix(base, "-"+analyzer.name) {
+			return &analyzers[i]
+		}
+	}
+	return nil
+}
+
+var analyzers = []analyzer{
+	{name: "planet-status", analyze: analyzePlanetStatus},
+	{name: "etcdctl", analyze: analyzeEtcdHealth},
+	{name: "k8s-nodes", analyze: analyzeNodes},
+	{name: "k8s-podlist", analyze: analyzePods},
+	{name: "df", analyze: analyzeDiskUsage("Use%", "disk")},
+	{name: "df-inodes", analyze: analyzeDiskUsage("IUse%", "inode")},
+	{name: "gravity-system.log.gz", compressed: true, analyze: analyzeJournal},
+	{name: "planet-journal-export.log.gz", compressed: true, analyze: analyzeJournal},
+	{name: AppDumpStatusFilename, analyze: analyzeAppDumpStatus},
+}
+
+// analyzePlanetStatus reports degraded nodes and failed probes from
+// the JSON-formatted planet agent status
+func analyzePlanetStatus(r io.Reader) (problems []Problem, err error) {
+	var status pb.SystemStatus
+	if err := json.NewDecoder(r).Decode(&status); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if status.Status != pb.SystemStatus_Running {
+		problems = append(problems, Problem{
+			Severity:    SeverityCritical,
+			Description: fmt.Sprintf("Cluster status is %v.", strings.ToLower(status.Status.String())),
+		})
+	}
+	for _, node := range status.Nodes {
+		if node == nil {
+			continue
+		}
+		for _, probe := range node.Probes {
+			if probe == nil || probe.Status == pb.Probe_Running {
+				continue
+			}
+			detail := probe.Detail
+			if probe.Error != "" {
+				detail = fmt.Sprintf("%v (%v)", detail, probe.Error)
+			}
+			problems = append(problems, Problem{
+				Severity: SeverityCritical,
+				Description: fmt.Sprintf("Node %v: %v check failed: %v.",
+					node.Name, probe.Checker, strings.TrimSpace(detail)),
+			})
+		}
+	}
+	return problems, nil
+}
+
+// analyzeEtcdHealth reports unhealthy etcd members from the output
+// of the etcdctl cluster-health command
+func analyzeEtcdHealth(r io.Reader) (problems []Problem, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.Contains(line, "is unhealthy"), strings.Contains(line, "is unreachable"):
+			problems = append(problems, Problem{
+				Severity:    SeverityCritical,
+				Description: fmt.Sprintf("etcd: %v.", line),
+			})
+		case strings.HasPrefix(line, "cluster is degraded"), strings.HasPrefix(line, "cluster is unavailable"):
+			problems = append(problems, Problem{
+				Severity:    SeverityCritical,
+				Description: fmt.Sprintf("etcd %v.", line),
+			})
+		}
+	}
+	return problems, trace.Wrap(scanner.Err())
+}
+
+// analyzeNodes reports Kubernetes nodes that are not ready or are under
+// resource pressure from the YAML-formatted node list
+func analyzeNodes(r io.Reader) (problems []Problem, err error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var nodes v1.NodeList
+	if err := yaml.Unmarshal(data, &nodes); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, node := range nodes.Items {
+		if node.Spec.Unschedulable {
+			problems = append(problems, Problem{
+				Severity:    SeverityWarning,
+				Description: fmt.Sprintf("Kubernetes node %v is cordoned.", node.Name),
+			})
+		}
+		for _, condition := range node.Status.Conditions {
+			healthy := condition.Status == v1.ConditionFalse
+			if condition.Type == v1.NodeReady {
+				healthy = condition.Status == v1.ConditionTrue
+			}
+			if healthy {
+				continue
+			}
+			problems = append(problems, Problem{
+				Severity: SeverityCritical,
+				Description: fmt.Sprintf("Kubernetes node %v has condition %v=%v: %v.",
+					node.Name, condition.Type, condition.Status, condition.Message),
+			})
+		}
+	}
+	return problems, nil
+}
+
+// analyzePods reports pods that are not running or ready or are restarting frequently
+// from the tabular output of kubectl get pods --all-namespaces
+func analyzePods(r io.Reader) (problems []Problem, err error) {
+	scanner := bufio.NewScanner(r)
+	var columns map[string]int
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if columns == nil {
+			columns = make(map[string]int)
+			for i, field := range fields {
+				columns[field] = i
+			}
+			continue
+		}
+		get := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(fields) {
+				return ""
+			}
+			return fields[i]
+		}
+		name := fmt.Sprintf("%v/%v", get("NAMESPACE"), get("NAME"))
+		status := get("STATUS")
+		switch status {
+		case "Completed", "Succeeded":
+			continue
+		case "Running":
+			if ready := get("READY"); !isPodReady(ready) {
+				problems = append(problems, Problem{
+					Severity:    SeverityWarning,
+					Description: fmt.Sprintf("Pod %v is running but not ready (%v containers ready).", name, ready),
+				})
+			}
+		default:
+			problems = append(problems, Problem{
+				Severity:    SeverityCritical,
+				Description: fmt.Sprintf("Pod %v is %v.", name, status),
+			})
+		}
+		restarts, err := strconv.Atoi(get("RESTARTS"))
+		if err == nil && restarts >= podRestartsThreshold {
+			problems = append(problems, Problem{
+				Severity:    SeverityWarning,
+				Description: fmt.Sprintf("Pod %v has restarted %v times.", name, restarts),
+			})
+		}
+	}
+	return problems, trace.Wrap(scanner.Err())
+}
+
+// isPodReady returns true if all containers are ready given the value
+// of the READY column in the form ready/total
+func isPodReady(ready string) bool {
+	parts := strings.Split(ready, "/")
+	if len(parts) != 2 {
+		return true
+	}
+	return parts[0] == parts[1]
+}
+
+// analyzeDiskUsage returns an analyzer that reports filesystems with high usage
+// from the output of df using the specified usage column
+func analyzeDiskUsage(usageColumn, kind string) func(io.Reader) ([]Problem, error) {
+	return func(r io.Reader) (problems []Problem, err error) {
+		scanner := bufio.NewScanner(r)
+		usageIndex := -1
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if usageIndex < 0 {
+				for i, field := range fields {
+					if field == usageColumn {
+						usageIndex = i
+					}
+				}
+				continue
+			}
+			// Mount points are the last column
+			if usageIndex >= len(fields)-1 {
+				continue
+			}
+			usage, err := strconv.Atoi(strings.TrimSuffix(fields[usageIndex], "%"))
+			if err != nil {
+				continue
+			}
+			mountPoint := fields[len(fields)-1]
+			switch {
+			case usage >= diskUsageCriticalPercent:
+				problems = append(problems, Problem{
+					Severity:    SeverityCritical,
+					Description: fmt.Sprintf("Filesystem %v %v usage is %v%%.", mountPoint, kind, usage),
+				})
+			case usage >= diskUsageWarningPercent:
+				problems = append(problems, Problem{
+					Severity:    SeverityWarning,
+					Description: fmt.Sprintf("Filesystem %v %v usage is %v%%.", mountPoint, kind, usage),
+				})
+			}
+		}
+		return problems, trace.Wrap(scanner.Err())
+	}
+}
+
+// analyzeJournal reports error messages from the journal export stream.
+// See https://www.freedesktop.org/wiki/Software/systemd/export/ for
+// the description of the format
+func analyzeJournal(r io.Reader) (problems []Problem, err error) {
+	var errors []string
+	var count int
+	err = readJournalExport(r, func(entry map[string]string) {
+		priority, err := strconv.Atoi(entry["PRIORITY"])
+		if err != nil || priority > journalErrorPriority {
+			return
+		}
+		count++
+		if len(errors) < maxJournalErrors {
+			errors = append(errors, fmt.Sprintf("%v: %v",
+				entry["SYSLOG_IDENTIFIER"], strings.TrimSpace(entry["MESSAGE"])))
+		}
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	return []Problem{{
+		Severity: SeverityWarning,
+		Description: fmt.Sprintf("%v error messages in the journal, first %v:\n%v",
+			count, len(errors), strings.Join(errors, "\n")),
+	}}, nil
+}
+
+// analyzeAppDumpStatus reports the failed application dump hook
+func analyzeAppDumpStatus(r io.Reader) (problems []Problem, err error) {
+	var status AppDumpStatus
+	if err := json.NewDecoder(r).Decode(&status); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !status.Failed {
+		return nil, nil
+	}
+	return []Problem{{
+		Severity: SeverityWarning,
+		Description: fmt.Sprintf("Dump hook of application %v failed, the application diagnostics may be incomplete: %v",
+			status.App, status.Error),
+	}}, nil
+}
+
+// readJournalExport parses the journal export stream from r
+// and invokes handler for each entry
+func readJournalExport(r io.Reader, handler func(map[string]string)) error {
+	br := bufio.NewReader(r)
+	entry := make(map[string]string)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return trace.Wrap(err)
+		}
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		if len(line) == 0 {
+			if len(entry) != 0 {
+				handler(entry)
+				entry = make(map[string]string)
+			}
+			if err == io.EOF {
+				return nil
+			}
+			continue
+		}
+		if i := bytes.IndexByte(line, '='); i >= 0 {
+			entry[string(line[:i])] = string(line[i+1:])
+		} else {
+			// Binary field: the name is followed by the little-endian
+			// 64-bit size of the value, the value and a newline
+			var size uint64
+			if err := binary.Read(br, binary.LittleEndian, &size); err != nil {
+				return trace.Wrap(err)
+			}
+			if size > defaults.MaxJournalFieldSize {
+				return trace.BadParameter("journal field %q size %v exceeds the maximum of %v",
+					line, size, defaults.MaxJournalFieldSize)
+			}
+			value := make([]byte, size+1)
+			if _, err := io.ReadFull(br, value); err != nil {
+				return trace.Wrap(err)
+			}
+			entry[string(line)] = string(value[:size])
+		}
+		if err == io.EOF {
+			if len(entry) != 0 {
+				handler(entry)
+			}
+			return nil
+		}
+	}
+}
+
+const (
+	// podRestartsThreshold is the number of container restarts
+	// after which a pod is reported
+	podRestartsThreshold = 5
+	// diskUsageWarningPercent is the filesystem usage to report as a warning
+	diskUsageWarningPercent = 80
+	// diskUsageCriticalPercent is the filesystem usage to report as critical
+	diskUsageCriticalPercent = 90
+	// journalErrorPriority is the lowest syslog priority reported as an error
+	journalErrorPriority = 3
+	// maxJournalErrors is the maximum number of journal errors listed in the summary
+	maxJournalErrors = 10
+)
@@ -0,0 +1,321 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+)
+
+// ParseAppDump parses the output of the application dump hook.
+//
+// The hook can either output arbitrary text which is then saved to the
+// report as-is, or a structured dump: a (optionally gzipped) tarball encoded
+// in base64 between the AppDumpBegin and AppDumpEnd marker lines.
+// The tarball contains the AppDumpManifestFilename manifest that describes
+// the files in the dump. Hook output outside of the markers is treated as the hook log.
+func ParseAppDump(output []byte) (*AppDump, error) {
+	dump := &AppDump{}
+	var encoded bytes.Buffer
+	var inDump, hasDump bool
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(nil, len(output)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == AppDumpBegin:
+			inDump, hasDump = true, true
+		case strings.TrimSpace(line) == AppDumpEnd:
+			inDump = false
+		case inDump:
+			encoded.WriteString(strings.TrimSpace(line))
+		default:
+			dump.Log = append(dump.Log, line+"\n"...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !hasDump {
+		return dump, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded.String())
+	if err != nil {
+		return nil, trace.BadParameter("failed to decode application dump: %v", err)
+	}
+	if err := dump.readTarball(bytes.NewReader(data)); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return dump, nil
+}
+
+// AppDump is the parsed output of the application dump hook
+type AppDump struct {
+	// Manifest describes the files in the dump.
+	// Manifest is nil if the hook did not output a structured dump
+	Manifest *AppDumpManifest
+	// Files maps paths of the files in the dump to their contents
+	Files map[string][]byte
+	// Log is the hook output outside of the structured dump
+	Log []byte
+}
+
+// AppDumpManifest describes the files in the application dump
+type AppDumpManifest struct {
+	// Files lists the files in the dump
+	Files []AppDumpFile `json:"files"`
+}
+
+// AppDumpFile describes a single file in the application dump
+type AppDumpFile struct {
+	// Path is the path of the file in the dump tarball
+	Path string `json:"path"`
+	// Category groups related files in the report.
+	// Defaults to AppDumpDefaultCategory
+	Category string `json:"category,omitempty"`
+	// Description optionally describes the contents of the file
+	Description string `json:"description,omitempty"`
+	// Sensitive marks the file as too sensitive to be included in reports.
+	// Sensitive files are only listed in the dump status
+	Sensitive bool `json:"sensitive,omitempty"`
+	// Redact lists additional redaction rules for this file
+	Redact []RedactionRule `json:"redact,omitempty"`
+}
+
+// Check validates this file description
+func (r *AppDumpFile) Check() error {
+	if err := checkDumpPath(r.Path); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.Category == "" {
+		r.Category = AppDumpDefaultCategory
+	}
+	if !categoryRegexp.MatchString(r.Category) {
+		return trace.BadParameter("invalid category %q for dump file %q, only letters, numbers, '-' and '_' are allowed",
+			r.Category, r.Path)
+	}
+	for _, rule := range r.Redact {
+		if err := rule.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// AppDumpStatus describes the result of the application dump hook
+type AppDumpStatus struct {
+	// App is the application the dump hook was run for
+	App string `json:"app"`
+	// Started is the time the hook was started
+	Started time.Time `json:"started"`
+	// Duration is how long the hook has been running
+	Duration string `json:"duration"`
+	// Timeout is the time budget of the hook
+	Timeout string `json:"timeout"`
+	// Failed is true if the hook has failed or exceeded its time budget
+	Failed bool `json:"failed"`
+	// Error describes the hook failure
+	Error string `json:"error,omitempty"`
+	// Files lists the files collected by the hook
+	Files []AppDumpFile `json:"files,omitempty"`
+}
+
+// WriteAppDump writes the application dump hook results into the per-application
+// section of the report using the specified writer.
+//
+// Dump files are written as app/<app>/<category>/<path> and redacted
+// with the specified redactor and the redaction hints of each file.
+// The hook log and the dump status are written to the same section.
+// The hook status is recorded even if the hook has failed and dump is nil
+func WriteAppDump(w Writer, redactor *Redactor, status AppDumpStatus, dump *AppDump) error {
+	if err := checkDumpPath(status.App); err != nil {
+		return trace.Wrap(err)
+	}
+	var errors []error
+	prefix := path.Join(AppDumpDir, status.App)
+	if dump != nil {
+		if err := writeRedacted(w, redactor, path.Join(prefix, AppDumpLogFilename), dump.Log); err != nil {
+			errors = append(errors, err)
+		}
+		files, err := dump.writeFiles(w, redactor, prefix)
+		if err != nil {
+			errors = append(errors, err)
+		}
+		status.Files = files
+	}
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := writeRedacted(w, redactor, path.Join(prefix, AppDumpStatusFilename), data); err != nil {
+		errors = append(errors, err)
+	}
+	return trace.NewAggregate(errors...)
+}
+
+const (
+	// AppDumpBegin marks the beginning of the structured application dump in the hook output
+	AppDumpBegin = "-----BEGIN GRAVITY APP DUMP-----"
+	// AppDumpEnd marks the end of the structured application dump in the hook output
+	AppDumpEnd = "-----END GRAVITY APP DUMP-----"
+	// AppDumpManifestFilename is the name of the manifest in the application dump tarball
+	AppDumpManifestFilename = "manifest.yaml"
+	// AppDumpDefaultCategory is the category of dump files without an explicit category
+	AppDumpDefaultCategory = "general"
+	// AppDumpDir is the report directory with per-application sections
+	AppDumpDir = "app"
+	// AppDumpLogFilename is the name of the dump hook log in the application section
+	AppDumpLogFilename = "dump-hook.log"
+	// AppDumpStatusFilename is the name of the dump hook status in the application section
+	AppDumpStatusFilename = "dump-status.json"
+)
+
+// readTarball reads the dump files and the manifest from the
+// (optionally compressed) tarball r
+func (r *AppDump) readTarball(reader io.Reader) error {
+	reader, err := maybeGunzip(reader)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.Files = make(map[string][]byte)
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := checkDumpPath(header.Name); err != nil {
+			return trace.Wrap(err)
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Files[path.Clean(header.Name)] = data
+	}
+	data, ok := r.Files[AppDumpManifestFilename]
+	if !ok {
+		return trace.NotFound("application dump is missing %v", AppDumpManifestFilename)
+	}
+	delete(r.Files, AppDumpManifestFilename)
+	var manifest AppDumpManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return trace.Wrap(err, "failed to parse application dump manifest")
+	}
+	for i := range manifest.Files {
+		if err := manifest.Files[i].Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	r.Manifest = &manifest
+	return nil
+}
+
+// writeFiles writes the dump files into the application section with the specified
+// prefix and returns the descriptions of the files.
+// Files not described in the manifest are written with the default category
+func (r *AppDump) writeFiles(w Writer, redactor *Redactor, prefix string) (files []AppDumpFile, err error) {
+	if r.Manifest == nil {
+		return nil, nil
+	}
+	described := make(map[string]bool)
+	files = append(files, r.Manifest.Files...)
+	for _, file := range files {
+		described[path.Clean(file.Path)] = true
+	}
+	var undescribed []string
+	for name := range r.Files {
+		if !described[name] {
+			undescribed = append(undescribed, name)
+		}
+	}
+	sort.Strings(undescribed)
+	for _, name := range undescribed {
+		files = append(files, AppDumpFile{Path: name, Category: AppDumpDefaultCategory})
+	}
+	var errors []error
+	for _, file := range files {
+		data, ok := r.Files[path.Clean(file.Path)]
+		if !ok {
+			errors = append(errors, trace.NotFound("file %v is missing from the application dump", file.Path))
+			continue
+		}
+		if file.Sensitive {
+			continue
+		}
+		fileRedactor, err := redactor.WithRules(file.Redact)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		name := path.Join(prefix, file.Category, path.Clean(file.Path))
+		if !strings.HasPrefix(name, prefix+"/") {
+			errors = append(errors, trace.BadParameter("invalid dump file path %q", file.Path))
+			continue
+		}
+		if err := writeRedacted(w, fileRedactor, name, data); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return files, trace.NewAggregate(errors...)
+}
+
+// writeRedacted writes the data redacted with redactor using the specified writer
+func writeRedacted(w Writer, redactor *Redactor, name string, data []byte) error {
+	wc, err := NewRedactingWriter(w, redactor)(name)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = wc.Write(data)
+	return trace.NewAggregate(err, wc.Close())
+}
+
+// checkDumpPath returns an error if the specified path is absolute
+// or refers to a parent directory
+func checkDumpPath(name string) error {
+	if name == "" || path.IsAbs(name) {
+		return trace.BadParameter("invalid dump file path %q", name)
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return trace.BadParameter("invalid dump file path %q", name)
+		}
+	}
+	return nil
+}
+
+// categoryRegexp defines valid dump file categories
+var categoryRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
@@ -0,0 +1,161 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/archive"
+
+	. "gopkg.in/check.v1"
+)
+
+type AppDumpSuite struct{}
+
+var _ = Suite(&AppDumpSuite{})
+
+func (r *AppDumpSuite) TestWritesStructuredDump(c *C) {
+	tarball := archive.MustCreateMemArchive([]*archive.Item{
+		archive.ItemFromString(AppDumpManifestFilename, `files:
+- path: db/status.txt
+  category: database
+  redact:
+  - name: customer
+    pattern: "customer=([a-z]+)"
+- path: keys.txt
+  sensitive: true
+`),
+		archive.ItemFromString("db/status.txt", "replication ok, customer=acme, password=secret\n"),
+		archive.ItemFromString("keys.txt", "top secret\n"),
+		archive.ItemFromString("extra.txt", "more info\n"),
+	})
+	output := fmt.Sprintf("collecting dump\n%v\n%v\n%v\ndone\n",
+		AppDumpBegin, base64.StdEncoding.EncodeToString(tarball.Bytes()), AppDumpEnd)
+
+	dump, err := ParseAppDump([]byte(output))
+	c.Assert(err, IsNil)
+	c.Assert(string(dump.Log), Equals, "collecting dump\ndone\n")
+
+	redactor, err := NewRedactor(RedactionConfig{})
+	c.Assert(err, IsNil)
+	dir := c.MkDir()
+	err = WriteAppDump(NewFileWriter(dir), redactor, AppDumpStatus{App: "example"}, dump)
+	c.Assert(err, IsNil)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app", "example", "database", "db", "status.txt"))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "replication ok, customer=****, password=******\n")
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "app", "example", AppDumpDefaultCategory, "extra.txt"))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "more info\n")
+
+	_, err = ioutil.ReadFile(filepath.Join(dir, "app", "example", AppDumpDefaultCategory, "keys.txt"))
+	c.Assert(err, NotNil)
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "app", "example", AppDumpStatusFilename))
+	c.Assert(err, IsNil)
+	var status AppDumpStatus
+	c.Assert(json.Unmarshal(data, &status), IsNil)
+	c.Assert(status.Failed, Equals, false)
+	c.Assert(status.Files, HasLen, 3)
+	c.Assert(status.Files[1].Sensitive, Equals, true)
+
+	c.Assert(redactor.Manifest().Files, DeepEquals, []RedactedFile{{
+		File:       "app/example/database/db/status.txt",
+		Redactions: map[string]int{"customer": 1, "password": 1},
+	}})
+}
+
+func (r *AppDumpSuite) TestKeepsUnstructuredOutput(c *C) {
+	dump, err := ParseAppDump([]byte("some diagnostics\n"))
+	c.Assert(err, IsNil)
+	c.Assert(dump.Manifest, IsNil)
+
+	redactor, err := NewRedactor(RedactionConfig{})
+	c.Assert(err, IsNil)
+	dir := c.MkDir()
+	err = WriteAppDump(NewFileWriter(dir), redactor, AppDumpStatus{
+		App:    "example",
+		Failed: true,
+		Error:  "hook exceeded its time budget of 5m0s",
+	}, dump)
+	c.Assert(err, IsNil)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app", "example", AppDumpLogFilename))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "some diagnostics\n")
+
+	summary, err := Analyze(dir)
+	c.Assert(err, IsNil)
+	c.Assert(summary.Problems, DeepEquals, []Problem{{
+		Severity:    SeverityWarning,
+		Description: "Dump hook of application example failed, the application diagnostics may be incomplete: hook exceeded its time budget of 5m0s",
+		Source:      Source{File: "app/example/dump-status.json"},
+	}})
+}
+
+func (r *AppDumpSuite) TestRejectsInvalidManifest(c *C) {
+	for _, manifest := range []string{
+		"files:\n- path: ../escape.txt\n",
+		"files:\n- path: file.txt\n  category: a/b\n",
+	} {
+		tarball := archive.MustCreateMemArchive([]*archive.Item{
+			archive.ItemFromString(AppDumpManifestFilename, manifest),
+		})
+		output := fmt.Sprintf("%v\n%v\n%v\n",
+			AppDumpBegin, base64.StdEncoding.EncodeToString(tarball.Bytes()), AppDumpEnd)
+		_, err := ParseAppDump([]byte(output))
+		c.Assert(err, NotNil, Commentf(manifest))
+	}
+}
+
+func (r *AppDumpSuite) TestRejectsEntriesOutsideReport(c *C) {
+	for _, name := range []string{"../../../../escape.txt", "/etc/escape.txt", "db/../../escape.txt"} {
+		tarball := archive.MustCreateMemArchive([]*archive.Item{
+			archive.ItemFromString(AppDumpManifestFilename, "files: []\n"),
+			archive.ItemFromString(name, "escaped\n"),
+		})
+		output := fmt.Sprintf("%v\n%v\n%v\n",
+			AppDumpBegin, base64.StdEncoding.EncodeToString(tarball.Bytes()), AppDumpEnd)
+		_, err := ParseAppDump([]byte(output))
+		c.Assert(err, ErrorMatches, "invalid dump file path.*", Commentf(name))
+	}
+
+	_, err := NewFileWriter(c.MkDir())("../escape.txt")
+	c.Assert(err, NotNil)
+}
+
+func (r *AppDumpSuite) TestRedactsDumpStatus(c *C) {
+	redactor, err := NewRedactor(RedactionConfig{})
+	c.Assert(err, IsNil)
+	dir := c.MkDir()
+	err = WriteAppDump(NewFileWriter(dir), redactor, AppDumpStatus{
+		App:    "example",
+		Failed: true,
+		Error:  "failed to connect with password=secret",
+	}, nil)
+	c.Assert(err, IsNil)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app", "example", AppDumpStatusFilename))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Not(Matches), "(?s).*secret.*")
+}
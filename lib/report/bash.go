@@ -71,6 +71,11 @@ func (r bashHistoryCollector) Collect(ctx context.Context, reportWriter Writer,
 	return nil
 }
 
+// Name returns the name pattern of the collected bash histories
+func (r bashHistoryCollector) Name() string {
+	return "bash_history-<user>"
+}
+
 type bashHistoryCollector struct{}
 
 const bashHistoryFileName = ".bash_history"
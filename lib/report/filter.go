@@ -0,0 +1,176 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// AllFilters lists all known collector groups
+var AllFilters = []string{
+	constants.ReportFilterSystem,
+	constants.ReportFilterKubernetes,
+	constants.ReportFilterEtcd,
+	constants.ReportFilterPlanet,
+	constants.ReportFilterApp,
+}
+
+// NodeFilters lists collector groups that run on every node
+var NodeFilters = []string{
+	constants.ReportFilterSystem,
+	constants.ReportFilterEtcd,
+	constants.ReportFilterPlanet,
+}
+
+// CheckFilters validates the specified list of collector groups
+func CheckFilters(filters []string) error {
+	for _, filter := range filters {
+		if !utils.StringInSlice(AllFilters, filter) {
+			return trace.BadParameter("unknown collector group %q, supported are: %v",
+				filter, AllFilters)
+		}
+	}
+	return nil
+}
+
+// HasFilter returns true if the collector group filter is selected
+// in the specified list of filters.
+// An empty list selects all collector groups
+func HasFilter(filters []string, filter string) bool {
+	return len(filters) == 0 || utils.StringInSlice(filters, filter)
+}
+
+// NodeCollectors returns collectors for the specified node collector groups.
+// Logs are limited to the specified time range
+func NodeCollectors(filters []string, timeRange TimeRange) Collectors {
+	var collectors Collectors
+	if HasFilter(filters, constants.ReportFilterSystem) {
+		collectors = append(collectors, SystemInfo(timeRange)...)
+	}
+	if HasFilter(filters, constants.ReportFilterEtcd) {
+		collectors = append(collectors, EtcdInfo(timeRange)...)
+	}
+	if HasFilter(filters, constants.ReportFilterPlanet) {
+		collectors = append(collectors, PlanetInfo(timeRange)...)
+	}
+	return collectors
+}
+
+// ParseTime parses the specified value either as a duration
+// relative to now (e.g. 2h) or as an RFC3339 timestamp
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		if duration < 0 {
+			return time.Time{}, trace.BadParameter("duration %v should be positive", value)
+		}
+		return now.Add(-duration), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, trace.BadParameter(
+			"expected a duration (e.g. 2h) or an RFC3339 timestamp (e.g. 2019-01-02T15:04:05Z), got %q", value)
+	}
+	return t, nil
+}
+
+// Check validates this time range
+func (r TimeRange) Check() error {
+	if !r.Since.IsZero() && !r.Until.IsZero() && !r.Until.After(r.Since) {
+		return trace.BadParameter("until (%v) should be after since (%v)",
+			r.Until.Format(time.RFC3339), r.Since.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// IsZero returns true if this time range is not bounded
+func (r TimeRange) IsZero() bool {
+	return r.Since.IsZero() && r.Until.IsZero()
+}
+
+// Overlaps returns true if the period between start and end
+// overlaps with this time range
+func (r TimeRange) Overlaps(start, end time.Time) bool {
+	if !r.Since.IsZero() && end.Before(r.Since) {
+		return false
+	}
+	if !r.Until.IsZero() && start.After(r.Until) {
+		return false
+	}
+	return true
+}
+
+// Args returns this time range as command line flags
+// of the system report command
+func (r TimeRange) Args() (args []string) {
+	if !r.Since.IsZero() {
+		args = append(args, fmt.Sprintf("--since=%v", r.Since.UTC().Format(time.RFC3339)))
+	}
+	if !r.Until.IsZero() {
+		args = append(args, fmt.Sprintf("--until=%v", r.Until.UTC().Format(time.RFC3339)))
+	}
+	return args
+}
+
+// String returns a textual representation of this time range
+func (r TimeRange) String() string {
+	format := func(t time.Time) string {
+		if t.IsZero() {
+			return "*"
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%v - %v", format(r.Since), format(r.Until))
+}
+
+// JournalArgs returns the journalctl flags to limit the journal
+// to this time range
+func (r TimeRange) JournalArgs() (args []string) {
+	if !r.Since.IsZero() {
+		args = append(args, "--since", fmt.Sprintf("@%v", r.Since.Unix()))
+	}
+	if !r.Until.IsZero() {
+		args = append(args, "--until", fmt.Sprintf("@%v", r.Until.Unix()))
+	}
+	return args
+}
+
+// kubectlLogsArgs returns the kubectl logs flags to limit the logs to this
+// time range. kubectl only supports the lower bound
+func (r TimeRange) kubectlLogsArgs() []string {
+	if r.Since.IsZero() {
+		return nil
+	}
+	return []string{fmt.Sprintf("--since-time=%v", r.Since.UTC().Format(time.RFC3339))}
+}
+
+// TimeRange limits the period of time logs are collected for.
+// Either bound can be left unspecified
+type TimeRange struct {
+	// Since is the lower bound of the time range
+	Since time.Time `json:"since,omitempty"`
+	// Until is the upper bound of the time range
+	Until time.Time `json:"until,omitempty"`
+}
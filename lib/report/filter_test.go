@@ -0,0 +1,81 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+
+	. "gopkg.in/check.v1"
+)
+
+type FilterSuite struct{}
+
+var _ = Suite(&FilterSuite{})
+
+func (r *FilterSuite) TestParsesTime(c *C) {
+	now := time.Date(2019, 1, 2, 15, 0, 0, 0, time.UTC)
+	t, err := ParseTime("2h", now)
+	c.Assert(err, IsNil)
+	c.Assert(t, Equals, time.Date(2019, 1, 2, 13, 0, 0, 0, time.UTC))
+
+	t, err = ParseTime("2019-01-01T10:00:00Z", now)
+	c.Assert(err, IsNil)
+	c.Assert(t, Equals, time.Date(2019, 1, 1, 10, 0, 0, 0, time.UTC))
+
+	t, err = ParseTime("", now)
+	c.Assert(err, IsNil)
+	c.Assert(t.IsZero(), Equals, true)
+
+	_, err = ParseTime("yesterday", now)
+	c.Assert(err, NotNil)
+}
+
+func (r *FilterSuite) TestTimeRange(c *C) {
+	since := time.Date(2019, 1, 2, 13, 0, 0, 0, time.UTC)
+	until := time.Date(2019, 1, 2, 15, 0, 0, 0, time.UTC)
+	timeRange := TimeRange{Since: since, Until: until}
+	c.Assert(timeRange.Check(), IsNil)
+	c.Assert(TimeRange{Since: until, Until: since}.Check(), NotNil)
+
+	c.Assert(timeRange.Args(), DeepEquals, []string{
+		"--since=2019-01-02T13:00:00Z",
+		"--until=2019-01-02T15:00:00Z",
+	})
+	c.Assert(timeRange.JournalArgs(), DeepEquals, []string{
+		"--since", "@1546434000",
+		"--until", "@1546441200",
+	})
+	c.Assert(TimeRange{}.Args(), HasLen, 0)
+
+	c.Assert(timeRange.Overlaps(since.Add(-time.Hour), since.Add(time.Minute)), Equals, true)
+	c.Assert(timeRange.Overlaps(since.Add(-time.Hour), since.Add(-time.Minute)), Equals, false)
+	c.Assert(timeRange.Overlaps(until.Add(time.Minute), until.Add(time.Hour)), Equals, false)
+	c.Assert(TimeRange{}.Overlaps(since, until), Equals, true)
+}
+
+func (r *FilterSuite) TestSelectsCollectors(c *C) {
+	c.Assert(CheckFilters([]string{constants.ReportFilterEtcd, constants.ReportFilterApp}), IsNil)
+	c.Assert(CheckFilters([]string{"unknown"}), NotNil)
+
+	names := NodeCollectors([]string{constants.ReportFilterEtcd}, TimeRange{}).Names()
+	c.Assert(names, DeepEquals, []string{"etcdctl", "etcdctl-members", "etcd-journal.log"})
+
+	names = NodeCollectors(nil, TimeRange{}).Names()
+	c.Assert(names, HasLen, len(SystemInfo(TimeRange{}))+len(EtcdInfo(TimeRange{}))+len(PlanetInfo(TimeRange{})))
+}
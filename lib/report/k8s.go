@@ -30,8 +30,8 @@ import (
 )
 
 // KubernetesInfo returns a list of collectors to fetch kubernetes-related
-// diagnostics.
-func KubernetesInfo(ctx context.Context, runner utils.CommandRunner) Collectors {
+// diagnostics. Container logs are limited to the specified time range.
+func KubernetesInfo(ctx context.Context, runner utils.CommandRunner, timeRange TimeRange) Collectors {
 	runner = planetContextRunner{runner}
 	// general kubernetes info
 	commands := Collectors{
@@ -73,10 +73,10 @@ func KubernetesInfo(ctx context.Context, runner utils.CommandRunner) Collectors
 			}
 			for _, container := range containers {
 				name := fmt.Sprintf("k8s-logs-%v-%v-%v", namespace, pod, container)
-				commands = append(commands, Cmd(name, utils.PlanetCommand(kubectl.Command("logs", pod,
+				args := append([]string{"logs", pod,
 					"--namespace", namespace,
-					fmt.Sprintf("-c=%v", container)))...),
-				)
+					fmt.Sprintf("-c=%v", container)}, timeRange.kubectlLogsArgs()...)
+				commands = append(commands, Cmd(name, utils.PlanetCommand(kubectl.Command(args...))...))
 			}
 		}
 	}
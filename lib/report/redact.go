@@ -0,0 +1,560 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+)
+
+// RedactionConfig defines how sensitive data is redacted in reports
+type RedactionConfig struct {
+	// Rules lists additional redaction rules
+	Rules []RedactionRule `json:"rules,omitempty"`
+	// RedactIPs specifies whether IPv4 addresses are redacted
+	RedactIPs bool `json:"redact_ips,omitempty"`
+}
+
+// RedactionRule defines a single redaction rule
+type RedactionRule struct {
+	// Name is the rule name as recorded in the redaction manifest
+	Name string `json:"name"`
+	// Pattern is the regular expression matching the sensitive data.
+	// If the expression has capturing groups, only the last group
+	// of each match is redacted, otherwise the whole match is
+	Pattern string `json:"pattern"`
+}
+
+// Check validates this rule
+func (r RedactionRule) Check() error {
+	if r.Name == "" {
+		return trace.BadParameter("redaction rule name is required")
+	}
+	if r.Pattern == "" {
+		return trace.BadParameter("redaction rule %q pattern is required", r.Name)
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return trace.BadParameter("invalid redaction rule %q pattern: %v", r.Name, err)
+	}
+	return nil
+}
+
+// ParseRedactionRules parses the list of redaction rules in YAML or JSON format
+func ParseRedactionRules(data []byte) ([]RedactionRule, error) {
+	var rules []RedactionRule
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, rule := range rules {
+		if err := rule.Check(); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return rules, nil
+}
+
+// NewRedactor creates a new redactor with the built-in rules
+// and the rules from the specified configuration
+func NewRedactor(config RedactionConfig) (*Redactor, error) {
+	rules := append([]RedactionRule{}, builtinRules...)
+	rules = append(rules, config.Rules...)
+	if config.RedactIPs {
+		rules = append(rules, ipRule)
+	}
+	redactor := &Redactor{
+		mu:       &sync.Mutex{},
+		manifest: make(map[string]map[string]int),
+	}
+	if err := redactor.addRules(rules); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return redactor, nil
+}
+
+// WithRules returns a new redactor that applies the specified rules
+// in addition to the rules of this redactor.
+// Redactions made by the new redactor are recorded in the manifest of this redactor
+func (r *Redactor) WithRules(rules []RedactionRule) (*Redactor, error) {
+	redactor := &Redactor{
+		rules:    append([]compiledRule{}, r.rules...),
+		mu:       r.mu,
+		manifest: r.manifest,
+	}
+	if err := redactor.addRules(rules); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return redactor, nil
+}
+
+// Redactor masks sensitive data in the collected diagnostics
+// and keeps track of what has been redacted
+type Redactor struct {
+	rules    []compiledRule
+	mu       *sync.Mutex
+	manifest map[string]map[string]int
+}
+
+func (r *Redactor) addRules(rules []RedactionRule) error {
+	for _, rule := range rules {
+		if err := rule.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+		r.rules = append(r.rules, compiledRule{
+			RedactionRule: rule,
+			re:            regexp.MustCompile(rule.Pattern),
+		})
+	}
+	return nil
+}
+
+// NewRedactingWriter returns a Writer that redacts all data
+// written with w using the specified redactor.
+//
+// Redacted data is masked in-place so the length of the data is preserved.
+// Compressed outputs and tarballs are unpacked and redacted entry by entry
+func NewRedactingWriter(w Writer, redactor *Redactor) Writer {
+	return func(name string) (io.WriteCloser, error) {
+		wc, err := w(name)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if isArchive(name) {
+			return newPipeRedactor(wc, func(r io.Reader, w io.Writer) error {
+				return redactor.redactArchiveOrCompressed(name, r, w)
+			}), nil
+		}
+		return &lineRedactor{
+			name:     name,
+			w:        wc,
+			redactor: redactor,
+		}, nil
+	}
+}
+
+// Manifest returns the redaction manifest
+func (r *Redactor) Manifest() RedactionManifest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	manifest := RedactionManifest{}
+	for _, rule := range r.rules {
+		manifest.Rules = append(manifest.Rules, rule.RedactionRule)
+	}
+	for file, counts := range r.manifest {
+		item := RedactedFile{File: file, Redactions: make(map[string]int)}
+		for rule, count := range counts {
+			item.Redactions[rule] = count
+		}
+		manifest.Files = append(manifest.Files, item)
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].File < manifest.Files[j].File
+	})
+	return manifest
+}
+
+// WriteManifest writes the redaction manifest using the specified writer
+func (r *Redactor) WriteManifest(w Writer) error {
+	wc, err := w(RedactionManifestFilename)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer wc.Close()
+	data, err := json.MarshalIndent(r.Manifest(), "", "  ")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = wc.Write(data)
+	return trace.Wrap(err)
+}
+
+// RedactionManifest describes what has been redacted in a report
+type RedactionManifest struct {
+	// Rules lists the redaction rules in effect
+	Rules []RedactionRule `json:"rules"`
+	// Files lists files with redacted data
+	Files []RedactedFile `json:"files"`
+}
+
+// RedactedFile describes redactions in a single file
+type RedactedFile struct {
+	// File is the name of the file
+	File string `json:"file"`
+	// Redactions maps rule name to the number of redactions
+	Redactions map[string]int `json:"redactions"`
+}
+
+// RedactionManifestFilename is the name of the redaction manifest in the report
+const RedactionManifestFilename = "redaction-manifest.json"
+
+const (
+	// maxLineSize is the maximum size of a line buffered for redaction.
+	// Longer lines are redacted in overlapping chunks
+	maxLineSize = 1024 * 1024
+	// redactionOverlap is the size of the tail of a chunk of an over-long
+	// line that is redacted again with the next chunk.
+	// Secrets spanning chunk boundaries are redacted as long as they
+	// are shorter than the overlap
+	redactionOverlap = 64 * 1024
+)
+
+// redactLine masks sensitive data in the specified line in-place.
+// state tracks multi-line secrets (private keys) across lines
+func (r *Redactor) redactLine(name string, line []byte, state *redactionState) {
+	if state.inPrivateKey {
+		if privateKeyEnd.Match(line) {
+			state.inPrivateKey = false
+			return
+		}
+		mask(line)
+		return
+	}
+	if begin := privateKeyBegin.FindIndex(line); begin != nil {
+		r.record(name, privateKeyRuleName)
+		end := privateKeyEnd.FindIndex(line[begin[1]:])
+		if end == nil {
+			state.inPrivateKey = true
+			return
+		}
+		// Private key on a single line, e.g. in JSON
+		mask(line[begin[1] : begin[1]+end[0]])
+		return
+	}
+	for _, rule := range r.rules {
+		matches := rule.re.FindAllSubmatchIndex(line, -1)
+		for _, match := range matches {
+			start, end := match[0], match[1]
+			if len(match) > 2 {
+				// Only redact the last capturing group
+				start, end = match[len(match)-2], match[len(match)-1]
+			}
+			if start < 0 || start == end || isMasked(line[start:end]) {
+				// Skip empty matches and matches already redacted by other rules
+				continue
+			}
+			mask(line[start:end])
+			r.record(name, rule.Name)
+		}
+	}
+}
+
+func (r *Redactor) record(name, rule string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts, ok := r.manifest[name]
+	if !ok {
+		counts = make(map[string]int)
+		r.manifest[name] = counts
+	}
+	counts[rule]++
+}
+
+// redactStream redacts data from r line by line and writes it to w
+func (r *Redactor) redactStream(name string, src io.Reader, w io.Writer) error {
+	redactor := &lineRedactor{
+		name:     name,
+		w:        nopWriteCloser{w},
+		redactor: r,
+	}
+	if _, err := io.Copy(redactor, src); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(redactor.Close())
+}
+
+// redactArchiveOrCompressed redacts the tarball or the gzip-compressed
+// stream from src depending on the specified name
+func (r *Redactor) redactArchiveOrCompressed(name string, src io.Reader, w io.Writer) error {
+	if strings.HasSuffix(name, ".tar") || strings.HasSuffix(name, ".tar.gz") {
+		return r.redactArchive(name, src, w)
+	}
+	return r.redactCompressed(name, src, w)
+}
+
+// redactCompressed redacts the gzip-compressed stream from src
+func (r *Redactor) redactCompressed(name string, src io.Reader, w io.Writer) error {
+	zr, err := gzip.NewReader(src)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer zr.Close()
+	zw := gzip.NewWriter(w)
+	if err := r.redactStream(name, zr, zw); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(zw.Close())
+}
+
+// redactArchive redacts the tarball from src entry by entry.
+// The tarball is optionally gzip-compressed
+func (r *Redactor) redactArchive(name string, src io.Reader, w io.Writer) error {
+	src, err := maybeGunzip(src)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	compressed := false
+	if zr, ok := src.(*gzip.Reader); ok {
+		compressed = true
+		defer zr.Close()
+	}
+	out := w
+	var zw *gzip.Writer
+	if compressed {
+		zw = gzip.NewWriter(w)
+		out = zw
+	}
+	tr := tar.NewReader(src)
+	tw := tar.NewWriter(out)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		entryName := name + ":" + header.Name
+		if header.Typeflag == tar.TypeReg && isArchive(header.Name) {
+			err = r.redactEntry(entryName, header, tr, tw)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			continue
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return trace.Wrap(err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := r.redactStream(entryName, tr, tw); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return trace.Wrap(err)
+	}
+	if zw != nil {
+		return trace.Wrap(zw.Close())
+	}
+	return nil
+}
+
+// redactEntry redacts the nested archive or compressed entry from tr.
+// As redacting these changes the size of the entry, the result is
+// buffered in a temporary file before it is written to tw
+func (r *Redactor) redactEntry(name string, header *tar.Header, tr io.Reader, tw *tar.Writer) error {
+	tmp, err := ioutil.TempFile("", "redact")
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+	if err := r.redactArchiveOrCompressed(name, tr, tmp); err != nil {
+		return trace.Wrap(err)
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	header.Size = size
+	if err := tw.WriteHeader(header); err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = io.Copy(tw, tmp)
+	return trace.Wrap(err)
+}
+
+// Write redacts complete lines and forwards them to the underlying writer.
+// It implements io.Writer
+func (r *lineRedactor) Write(data []byte) (n int, err error) {
+	r.buf.Write(data)
+	for {
+		i := bytes.IndexByte(r.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := r.buf.Next(i + 1)
+		r.redactor.redactLine(r.name, line, &r.state)
+		if _, err := r.w.Write(line); err != nil {
+			return 0, err
+		}
+	}
+	if r.buf.Len() >= maxLineSize {
+		// Do not buffer lines without limit. Redact the line so far but hold
+		// back its tail so that secrets spanning the flushed chunk boundary
+		// are matched again once the line continues
+		line := r.buf.Bytes()
+		r.redactor.redactLine(r.name, line, &r.state)
+		if _, err := r.w.Write(r.buf.Next(len(line) - redactionOverlap)); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// Close redacts and forwards the remaining data and closes the underlying writer.
+// It implements io.Closer
+func (r *lineRedactor) Close() error {
+	var errors []error
+	if r.buf.Len() != 0 {
+		line := r.buf.Bytes()
+		r.redactor.redactLine(r.name, line, &r.state)
+		if _, err := r.w.Write(line); err != nil {
+			errors = append(errors, err)
+		}
+		r.buf.Reset()
+	}
+	if err := r.w.Close(); err != nil {
+		errors = append(errors, err)
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// lineRedactor redacts text data line by line
+type lineRedactor struct {
+	name     string
+	w        io.WriteCloser
+	redactor *Redactor
+	buf      bytes.Buffer
+	state    redactionState
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close is a no-op.
+// It implements io.Closer
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// newPipeRedactor returns a writer that feeds the written data to
+// redact in a separate goroutine which writes the results to w
+func newPipeRedactor(w io.WriteCloser, redact func(io.Reader, io.Writer) error) *pipeRedactor {
+	reader, writer := io.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		err := redact(reader, w)
+		// Drain the rest of the input so the writes do not block
+		io.Copy(ioutil.Discard, reader)
+		reader.CloseWithError(err)
+		errCh <- err
+	}()
+	return &pipeRedactor{PipeWriter: writer, w: w, errCh: errCh}
+}
+
+// Close waits for the redaction to complete and closes the underlying writer.
+// It implements io.Closer
+func (r *pipeRedactor) Close() error {
+	r.PipeWriter.Close()
+	err := <-r.errCh
+	return trace.NewAggregate(err, r.w.Close())
+}
+
+type pipeRedactor struct {
+	*io.PipeWriter
+	w     io.WriteCloser
+	errCh chan error
+}
+
+type redactionState struct {
+	inPrivateKey bool
+}
+
+type compiledRule struct {
+	RedactionRule
+	re *regexp.Regexp
+}
+
+// isArchive returns true if the file with the specified name
+// is a (possibly compressed) tarball or a compressed file
+func isArchive(name string) bool {
+	return strings.HasSuffix(name, ".tar") || strings.HasSuffix(name, ".gz")
+}
+
+// isMasked returns true if the data has already been masked
+func isMasked(data []byte) bool {
+	for _, b := range data {
+		if b != '*' {
+			return false
+		}
+	}
+	return true
+}
+
+// mask replaces the data in-place with the mask character preserving
+// the line structure
+func mask(data []byte) {
+	for i, b := range data {
+		if b != '\n' && b != '\r' {
+			data[i] = '*'
+		}
+	}
+}
+
+var builtinRules = []RedactionRule{
+	{
+		Name:    "join-token",
+		Pattern: `(?i)(?:--token[= ]|join[_-]?token["']?\s*[:=]\s*["']?)([^\s"',]+)`,
+	},
+	{
+		Name:    "bearer-token",
+		Pattern: `(?i)bearer\s+([A-Za-z0-9\-._~+/]+=*)`,
+	},
+	{
+		Name:    "api-key",
+		Pattern: `(?i)(?:api[_-]?key|access[_-]?key(?:[_-]?id)?|secret[_-]?key|client[_-]?secret)["']?\s*[:=]\s*["']?([^\s"',]+)`,
+	},
+	{
+		Name:    "aws-access-key",
+		Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	},
+	{
+		Name:    "password",
+		Pattern: `(?i)\b(?:[a-z0-9]+[_-])?(?:password|passwd)["']?\s*[:=]\s*["']?([^\s"',]+)`,
+	},
+}
+
+var ipRule = RedactionRule{
+	Name:    "ip-address",
+	Pattern: `\b(?:(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\b`,
+}
+
+const privateKeyRuleName = "private-key"
+
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z0-9 ]*PRIVATE KEY-----`)
+)
@@ -22,6 +22,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/utils"
@@ -29,10 +30,15 @@ import (
 	"github.com/gravitational/trace"
 )
 
-// NewFileWriter creates a Writer that writes to a file
+// NewFileWriter creates a Writer that writes to a file.
+// The files cannot be written outside of the specified directory
 func NewFileWriter(dir string) Writer {
 	return func(name string) (io.WriteCloser, error) {
 		fileName := filepath.Join(dir, name)
+		rel, err := filepath.Rel(dir, fileName)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, trace.BadParameter("file %v is outside of the report directory", name)
+		}
 		return NewPendingFileWriter(fileName), nil
 	}
 }
@@ -51,7 +57,10 @@ func (r *pendingWriter) Write(data []byte) (n int, err error) {
 		return 0, nil
 	}
 	if r.file == nil {
-		var err error
+		err := os.MkdirAll(filepath.Dir(r.path), defaults.SharedDirMask)
+		if err != nil {
+			return 0, err
+		}
 		r.file, err = os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC,
 			defaults.SharedReadWriteMask)
 		if err != nil {
@@ -105,6 +114,22 @@ func (r Collectors) Collect(ctx context.Context, reportWriter Writer, runner uti
 	return trace.NewAggregate(errors...)
 }
 
+// Names returns the names of outputs of this list of Collectors
+func (r Collectors) Names() (names []string) {
+	for _, collector := range r {
+		if named, ok := collector.(namedCollector); ok {
+			names = append(names, named.Name())
+		}
+	}
+	return names
+}
+
+// namedCollector is a Collector that knows the name of its output
+type namedCollector interface {
+	// Name returns the name of the collector output
+	Name() string
+}
+
 // Cmd creates a new Command with the given name and command line
 func Cmd(name string, args ...string) Command {
 	cmd := args[0]
@@ -126,6 +151,11 @@ type Command struct {
 	args []string
 }
 
+// Name returns the name of the output of this Command
+func (r Command) Name() string {
+	return r.name
+}
+
 // Collect implements Collector for this Command
 func (r Command) Collect(ctx context.Context, reportWriter Writer, runner utils.CommandRunner) error {
 	w, err := reportWriter(r.name)
@@ -144,6 +174,11 @@ func Script(name, script string) ScriptCollector {
 	return ScriptCollector{name: name, script: script}
 }
 
+// Name returns the name of the output of this script
+func (r ScriptCollector) Name() string {
+	return r.name
+}
+
 // Collect implements Collector using a bash script
 func (r ScriptCollector) Collect(ctx context.Context, reportWriter Writer, runner utils.CommandRunner) error {
 	args := []string{"/bin/bash", "-c", r.script}
@@ -0,0 +1,294 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/gravitational/gravity/lib/archive"
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// Analyze walks the report directory dir and runs the known analyzers
+// on the collected outputs, including the outputs inside the nested
+// node archives.
+// Returns the summary of detected problems
+func Analyze(dir string) (*Summary, error) {
+	summary := &Summary{Created: time.Now().UTC()}
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		defer f.Close()
+		problems, err := analyzeFile(Source{File: name}, f)
+		if err != nil {
+			log.WithError(err).Warnf("Failed to analyze %v.", name)
+			return nil
+		}
+		summary.Problems = append(summary.Problems, problems...)
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sort.SliceStable(summary.Problems, func(i, j int) bool {
+		return summary.Problems[i].Severity < summary.Problems[j].Severity
+	})
+	return summary, nil
+}
+
+// Items returns the rendered summary as archive items
+func (r Summary) Items() ([]*archive.Item, error) {
+	var markdown, html bytes.Buffer
+	if err := r.WriteMarkdown(&markdown); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := r.WriteHTML(&html); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []*archive.Item{
+		archive.ItemFromStringMode(SummaryMarkdownFilename, markdown.String(), defaults.SharedReadMask),
+		archive.ItemFromStringMode(SummaryHTMLFilename, html.String(), defaults.SharedReadMask),
+	}, nil
+}
+
+// WriteMarkdown writes the summary in markdown format to w
+func (r Summary) WriteMarkdown(w io.Writer) error {
+	return trace.Wrap(markdownTemplate.Execute(w, r))
+}
+
+// WriteHTML writes the summary in HTML format to w
+func (r Summary) WriteHTML(w io.Writer) error {
+	return trace.Wrap(htmlTemplate.Execute(w, r))
+}
+
+// Summary describes the results of the report analysis
+type Summary struct {
+	// Created is the time the summary was created
+	Created time.Time
+	// Problems lists all detected problems
+	Problems []Problem
+}
+
+// Problem describes a single problem detected in the report
+type Problem struct {
+	// Severity is the problem severity
+	Severity Severity
+	// Description describes the problem
+	Description string
+	// Source references the collected output the problem was detected in
+	Source Source
+}
+
+// Source references a collected output
+type Source struct {
+	// File is the path of the file relative to the report root
+	File string
+	// Entry optionally names the entry inside the nested archive File
+	Entry string
+}
+
+// String returns a textual representation of this source
+func (r Source) String() string {
+	if r.Entry == "" {
+		return r.File
+	}
+	return fmt.Sprintf("%v:%v", r.File, r.Entry)
+}
+
+// Severity defines the problem severity
+type Severity int
+
+// String returns a textual representation of this severity
+func (r Severity) String() string {
+	switch r {
+	case SeverityCritical:
+		return "critical"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// SeverityCritical defines a problem that likely affects cluster operation
+	SeverityCritical Severity = iota
+	// SeverityWarning defines a problem that might require attention
+	SeverityWarning
+)
+
+const (
+	// SummaryMarkdownFilename is the name of the report summary in markdown format
+	SummaryMarkdownFilename = "summary.md"
+	// SummaryHTMLFilename is the name of the report summary in HTML format
+	SummaryHTMLFilename = "summary.html"
+)
+
+// analyzeFile runs the analyzer matching the specified source on the
+// contents of r. Nested node archives are analyzed entry by entry
+func analyzeFile(source Source, r io.Reader) ([]Problem, error) {
+	if strings.HasSuffix(source.File, ".tar") {
+		return analyzeArchive(source, r)
+	}
+	analyzer := findAnalyzer(source.File)
+	if analyzer == nil {
+		return nil, nil
+	}
+	return analyzeWith(analyzer, source, r)
+}
+
+// analyzeArchive runs the analyzers on the entries of the (optionally compressed)
+// archive read from r
+func analyzeArchive(source Source, r io.Reader) (problems []Problem, err error) {
+	r, err = maybeGunzip(r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return problems, trace.Wrap(err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		analyzer := findAnalyzer(header.Name)
+		if analyzer == nil {
+			continue
+		}
+		entrySource := Source{File: source.File, Entry: header.Name}
+		entryProblems, err := analyzeWith(analyzer, entrySource, tr)
+		if err != nil {
+			log.WithError(err).Warnf("Failed to analyze %v.", entrySource)
+			continue
+		}
+		problems = append(problems, entryProblems...)
+	}
+	return problems, nil
+}
+
+func analyzeWith(analyzer *analyzer, source Source, r io.Reader) ([]Problem, error) {
+	var err error
+	if analyzer.compressed {
+		r, err = maybeGunzip(r)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	problems, err := analyzer.analyze(r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for i := range problems {
+		problems[i].Source = source
+	}
+	return problems, nil
+}
+
+// maybeGunzip returns a reader that decompresses r if it starts with the gzip header
+func maybeGunzip(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, trace.Wrap(err)
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return zr, nil
+	}
+	return br, nil
+}
+
+var markdownTemplate = texttemplate.Must(texttemplate.New("markdown").Funcs(texttemplate.FuncMap{
+	// cell formats the value as a single-line markdown table cell
+	"cell": func(s string) string {
+		return strings.Replace(strings.Join(strings.Fields(s), " "), "|", `\|`, -1)
+	},
+}).Parse(
+	`# Diagnostic report summary
+
+Generated on {{.Created.Format "2006-01-02 15:04:05 UTC"}}.
+{{if not .Problems}}
+No problems detected.
+{{else}}
+| Severity | Problem | Source |
+|----------|---------|--------|
+{{range .Problems}}| {{.Severity}} | {{cell .Description}} | [{{.Source.File}}]({{.Source.File}}){{with .Source.Entry}} ({{.}}){{end}} |
+{{end}}{{end}}`))
+
+var htmlTemplate = template.Must(template.New("html").Parse(
+	`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Diagnostic report summary</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; white-space: pre-wrap; }
+.critical { color: #c00; }
+.warning { color: #c60; }
+</style>
+</head>
+<body>
+<h1>Diagnostic report summary</h1>
+<p>Generated on {{.Created.Format "2006-01-02 15:04:05 UTC"}}.</p>
+{{if not .Problems}}<p>No problems detected.</p>{{else}}<table>
+<tr><th>Severity</th><th>Problem</th><th>Source</th></tr>
+{{range .Problems}}<tr>
+<td class="{{.Severity}}">{{.Severity}}</td>
+<td>{{.Description}}</td>
+<td><a href="{{.Source.File}}">{{.Source.File}}</a>{{with .Source.Entry}} ({{.}}){{end}}</td>
+</tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,115 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/archive"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+type SummarySuite struct{}
+
+var _ = Suite(&SummarySuite{})
+
+func (r *SummarySuite) TestAnalyzesPods(c *C) {
+	const output = `NAMESPACE     NAME        READY   STATUS             RESTARTS   AGE
+kube-system   coredns-1   1/1     Running            0          1d
+kube-system   bandwagon   0/1     Running            0          1d
+default       app-1       0/1     CrashLoopBackOff   12         1d
+default       hook-1      0/1     Completed          0          1d
+`
+	problems, err := analyzePods(strings.NewReader(output))
+	c.Assert(err, IsNil)
+	c.Assert(problems, DeepEquals, []Problem{
+		{Severity: SeverityWarning, Description: "Pod kube-system/bandwagon is running but not ready (0/1 containers ready)."},
+		{Severity: SeverityCritical, Description: "Pod default/app-1 is CrashLoopBackOff."},
+		{Severity: SeverityWarning, Description: "Pod default/app-1 has restarted 12 times."},
+	})
+}
+
+func (r *SummarySuite) TestAnalyzesDiskUsage(c *C) {
+	const output = `Filesystem     Type      Size  Used Avail Use% Mounted on
+/dev/sda1      ext4       40G   38G  2.0G  95% /
+/dev/sdb1      xfs       100G   82G   18G  82% /var/lib/gravity
+tmpfs          tmpfs     3.9G     0  3.9G   0% /dev/shm
+`
+	problems, err := analyzeDiskUsage("Use%", "disk")(strings.NewReader(output))
+	c.Assert(err, IsNil)
+	c.Assert(problems, DeepEquals, []Problem{
+		{Severity: SeverityCritical, Description: "Filesystem / disk usage is 95%."},
+		{Severity: SeverityWarning, Description: "Filesystem /var/lib/gravity disk usage is 82%."},
+	})
+}
+
+func (r *SummarySuite) TestAnalyzesJournal(c *C) {
+	var buf bytes.Buffer
+	buf.WriteString("MESSAGE=all good\nPRIORITY=6\nSYSLOG_IDENTIFIER=gravity\n\n")
+	buf.WriteString("PRIORITY=3\nSYSLOG_IDENTIFIER=kubelet\nMESSAGE\n")
+	// binary field
+	buf.Write([]byte{4, 0, 0, 0, 0, 0, 0, 0})
+	buf.WriteString("boom\n\n")
+	problems, err := analyzeJournal(&buf)
+	c.Assert(err, IsNil)
+	c.Assert(problems, DeepEquals, []Problem{
+		{Severity: SeverityWarning, Description: "1 error messages in the journal, first 1:\nkubelet: boom"},
+	})
+}
+
+func (r *SummarySuite) TestRejectsOversizedJournalFields(c *C) {
+	var buf bytes.Buffer
+	buf.WriteString("MESSAGE\n")
+	buf.Write([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	buf.WriteString("boom\n\n")
+	_, err := analyzeJournal(&buf)
+	c.Assert(trace.IsBadParameter(err), Equals, true, Commentf("%v", err))
+}
+
+func (r *SummarySuite) TestAnalyzesNestedArchives(c *C) {
+	dir := c.MkDir()
+	nested := archive.MustCreateMemArchive([]*archive.Item{
+		archive.ItemFromString("etcdctl", "member 1 is unhealthy: got unhealthy result from https://10.0.0.1:2379\ncluster is degraded\n"),
+		archive.ItemFromString("iptables", "unrelated"),
+	})
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, err := zw.Write(nested.Bytes())
+	c.Assert(err, IsNil)
+	c.Assert(zw.Close(), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "node-1-debug-logs.tar"), compressed.Bytes(), 0644), IsNil)
+
+	summary, err := Analyze(dir)
+	c.Assert(err, IsNil)
+	c.Assert(summary.Problems, HasLen, 2)
+	c.Assert(summary.Problems[0].Source, DeepEquals, Source{File: "node-1-debug-logs.tar", Entry: "etcdctl"})
+
+	var markdown bytes.Buffer
+	c.Assert(summary.WriteMarkdown(&markdown), IsNil)
+	c.Assert(markdown.String(), Matches,
+		"(?s).*\\| critical \\| etcd cluster is degraded. \\| \\[node-1-debug-logs.tar\\]\\(node-1-debug-logs.tar\\) \\(etcdctl\\) \\|.*")
+
+	var html bytes.Buffer
+	c.Assert(summary.WriteHTML(&html), IsNil)
+	c.Assert(html.String(), Matches, `(?s).*<a href="node-1-debug-logs.tar">node-1-debug-logs.tar</a> \(etcdctl\).*`)
+}
@@ -25,23 +25,43 @@ import (
 	"github.com/gravitational/gravity/lib/utils"
 )
 
-// SystemInfo returns a list of collectors to fetch various bits of system information
-func SystemInfo() Collectors {
+// SystemInfo returns a list of collectors to fetch various bits of system information.
+// Journal logs are limited to the specified time range
+func SystemInfo(timeRange TimeRange) Collectors {
 	var collectors Collectors
 	add := func(additional ...Collector) {
 		collectors = append(collectors, additional...)
 	}
 
 	add(basicSystemInfo()...)
-	add(planetServices()...)
-	add(syslogExportLogs())
+	add(syslogExportLogs(timeRange))
 	add(systemFileLogs()...)
-	add(planetLogs()...)
 	add(bashHistoryCollector{})
 
 	return collectors
 }
 
+// EtcdInfo returns a list of collectors to fetch etcd diagnostics.
+// etcd logs are limited to the specified time range
+func EtcdInfo(timeRange TimeRange) Collectors {
+	return Collectors{
+		// etcd cluster health
+		Cmd("etcdctl", utils.PlanetCommandArgs("/usr/bin/etcdctl", "cluster-health")...),
+		Cmd("etcdctl-members", utils.PlanetCommandArgs("/usr/bin/etcdctl", "member", "list")...),
+		Cmd("etcd-journal.log", utils.PlanetCommandArgs(append([]string{
+			"/bin/journalctl", "--no-pager", "--unit", "etcd"}, timeRange.JournalArgs()...)...)...),
+	}
+}
+
+// PlanetInfo returns a list of collectors to fetch planet diagnostics.
+// Journal logs are limited to the specified time range
+func PlanetInfo(timeRange TimeRange) Collectors {
+	var collectors Collectors
+	collectors = append(collectors, planetServices()...)
+	collectors = append(collectors, planetLogs(timeRange)...)
+	return collectors
+}
+
 func basicSystemInfo() Collectors {
 	return Collectors{
 		// networking
@@ -71,8 +91,6 @@ func basicSystemInfo() Collectors {
 
 func planetServices() Collectors {
 	return Collectors{
-		// etcd cluster health
-		Cmd("etcdctl", utils.PlanetCommandArgs("/usr/bin/etcdctl", "cluster-health")...),
 		Cmd("planet-status", utils.PlanetCommandArgs("/usr/bin/planet", "status")...),
 		// status of systemd units
 		Cmd("systemctl", utils.PlanetCommandArgs("/bin/systemctl", "status")...),
@@ -81,20 +99,20 @@ func planetServices() Collectors {
 
 // syslogExportLogs fetches logs for gravity binary invocations
 // (including installation logs)
-func syslogExportLogs() Collector {
+func syslogExportLogs(timeRange TimeRange) Collector {
 	const script = `
 #!/bin/bash
 /bin/journalctl --no-pager --output=export %v | /bin/gzip -f`
 	syslogID := func(id string) string {
 		return fmt.Sprintf("SYSLOG_IDENTIFIER=%v", id)
 	}
-	matches := []string{
+	args := append(timeRange.JournalArgs(),
 		syslogID("./gravity"),
 		syslogID("gravity"),
 		syslogID(defaults.GravityBin),
-	}
+	)
 
-	return Script("gravity-system.log.gz", fmt.Sprintf(script, strings.Join(matches, " ")))
+	return Script("gravity-system.log.gz", fmt.Sprintf(script, strings.Join(args, " ")))
 }
 
 // systemFileLogs fetches gravity platform-related logs
@@ -109,7 +127,7 @@ cat %v 2> /dev/null || true`
 }
 
 // planetLogs fetches planet syslog messages as well as the fresh journal entries
-func planetLogs() Collectors {
+func planetLogs(timeRange TimeRange) Collectors {
 	return Collectors{
 		// Fetch planet syslog messages as a tarball
 		Script("planet-logs.tar.gz", tarball(defaults.InGravity("planet/log/messages*"))),
@@ -118,6 +136,6 @@ func planetLogs() Collectors {
 		//
 		// $ cat ./node-1-planet-journal-export.log | /lib/systemd/systemd-journal-remote -o ./journal/system.journal -
 		Self("planet-journal-export.log.gz",
-			"system", "export-runtime-journal"),
+			append([]string{"system", "export-runtime-journal"}, timeRange.Args()...)...),
 	}
 }
@@ -130,10 +130,12 @@ func DeployAgents(ctx context.Context, req DeployAgentsRequest) error {
 			return trace.Wrap(err)
 		}
 		serverStateDir := stateServer.StateDir()
+		// the agent runs the gravity binary built for the server's architecture
+		gravityPackage := schema.ArchPackage(req.GravityPackage, stateServer.Arch)
 
 		go func(node, nodeStateDir string, leader bool) {
 			err := trace.Wrap(deployAgentOnNode(ctx, req, node, nodeStateDir,
-				leader, req.SecretsPackage.String()))
+				leader, gravityPackage.String(), req.SecretsPackage.String()))
 			if err != nil {
 				logrus.WithError(err).WithField("node", node).Warnf("Failed to deploy agent.")
 			}
@@ -182,7 +184,7 @@ func NewDeployServer(node storage.Server) DeployServer {
 	}
 }
 
-func deployAgentOnNode(ctx context.Context, req DeployAgentsRequest, node, nodeStateDir string, leader bool, secretsPackage string) error {
+func deployAgentOnNode(ctx context.Context, req DeployAgentsRequest, node, nodeStateDir string, leader bool, gravityPackage, secretsPackage string) error {
 	nodeClient, err := req.Proxy.ConnectToNode(ctx, node, defaults.SSHUser, false)
 	if err != nil {
 		return trace.Wrap(err, node)
@@ -215,7 +217,7 @@ func deployAgentOnNode(ctx context.Context, req DeployAgentsRequest, node, nodeS
 		IgnoreError("/usr/bin/systemctl stop %s", defaults.GravityRPCAgentServiceName).
 		WithRetries("%s enter -- --notty %s -- package export --file-mask=%o %s %s --ops-url=%s --insecure",
 			constants.GravityBin, defaults.GravityBin, defaults.SharedExecutableMask,
-			req.GravityPackage, gravityPlanetPath, defaults.GravityServiceURL).
+			gravityPackage, gravityPlanetPath, defaults.GravityServiceURL).
 		C(runCmd).
 		WithLogger(req.WithField("node", node)).
 		Run(ctx)
@@ -395,6 +395,15 @@ func (in *Header) DeepCopy() *Header {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Hook) DeepCopyInto(out *Hook) {
 	*out = *in
+	if in.Host != nil {
+		in, out := &in.Host, &out.Host
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(HostHook)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -417,7 +426,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.ClusterDeprovision != nil {
@@ -426,7 +435,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NodesProvision != nil {
@@ -435,7 +444,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NodesDeprovision != nil {
@@ -444,7 +453,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Install != nil {
@@ -453,7 +462,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Installed != nil {
@@ -462,7 +471,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Uninstall != nil {
@@ -471,7 +480,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Uninstalling != nil {
@@ -480,7 +489,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NodeAdding != nil {
@@ -489,7 +498,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NodeAdded != nil {
@@ -498,7 +507,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NodeRemoving != nil {
@@ -507,7 +516,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NodeRemoved != nil {
@@ -516,7 +525,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.BeforeUpdate != nil {
@@ -525,7 +534,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Updating != nil {
@@ -534,7 +543,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Updated != nil {
@@ -543,7 +552,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Rollback != nil {
@@ -552,7 +561,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.RolledBack != nil {
@@ -561,7 +570,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Status != nil {
@@ -570,7 +579,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Info != nil {
@@ -579,7 +588,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.LicenseUpdated != nil {
@@ -588,7 +597,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Start != nil {
@@ -597,7 +606,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Stop != nil {
@@ -606,7 +615,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Dump != nil {
@@ -615,7 +624,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Backup != nil {
@@ -624,7 +633,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Restore != nil {
@@ -633,7 +642,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 
@@ -643,7 +652,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NetworkUpdate != nil {
@@ -652,7 +661,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.NetworkRollback != nil {
@@ -661,7 +670,7 @@ func (in *Hooks) DeepCopyInto(out *Hooks) {
 			*out = nil
 		} else {
 			*out = new(Hook)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	return
@@ -677,6 +686,27 @@ func (in *Hooks) DeepCopy() *Hooks {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostHook) DeepCopyInto(out *HostHook) {
+	*out = *in
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostHook.
+func (in *HostHook) DeepCopy() *HostHook {
+	if in == nil {
+		return nil
+	}
+	out := new(HostHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IAMPolicy) DeepCopyInto(out *IAMPolicy) {
 	*out = *in
@@ -1192,6 +1222,11 @@ func (in *SystemOptions) DeepCopyInto(out *SystemOptions) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -18,6 +18,10 @@ package schema
 
 import (
 	"reflect"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/trace"
 
@@ -51,6 +55,10 @@ type Hooks struct {
 	NodeRemoving *Hook `json:"preNodeRemove,omitempty"`
 	// NodeRemoved is called after shrink
 	NodeRemoved *Hook `json:"postNodeRemove,omitempty"`
+	// NodeDraining is called before a node is drained
+	NodeDraining *Hook `json:"preNodeDrain,omitempty"`
+	// NodeDrained is called after a node has been drained
+	NodeDrained *Hook `json:"postNodeDrain,omitempty"`
 	// BeforeUpdate is executed before the application is updated
 	BeforeUpdate *Hook `json:"preUpdate,omitempty"`
 	// Updating performs application update
@@ -105,11 +113,139 @@ type Hook struct {
 	Type HookType `json:"type,omitempty"`
 	// Job is a URL of (file:// or http://) or a literal value of a k8s job
 	Job string `json:"job,omitempty"`
+	// Retries is the number of times the hook is retried after a failure
+	Retries int `json:"retries,omitempty"`
+	// Backoff is the delay before the first retry, doubled with each next retry
+	Backoff string `json:"backoff,omitempty"`
+	// ActiveDeadline limits the running time of a single hook attempt
+	ActiveDeadline string `json:"activeDeadline,omitempty"`
+	// Host is a script run directly on cluster nodes instead of the job
+	Host *HostHook `json:"host,omitempty"`
+}
+
+// HostHook defines a hook that runs as a shell script on cluster nodes
+type HostHook struct {
+	// Script is the shell script to run
+	Script string `json:"script"`
+	// Context is where the script runs, either host or planet.
+	// Defaults to host
+	Context HostHookContext `json:"context,omitempty"`
+	// Profiles lists the node profiles the script runs on.
+	// The script runs on all nodes if empty
+	Profiles []string `json:"profiles,omitempty"`
+}
+
+// Matches returns true if the script runs on nodes with the specified profile
+func (h HostHook) Matches(profile string) bool {
+	return len(h.Profiles) == 0 || utils.StringInSlice(h.Profiles, profile)
+}
+
+// Command returns the command line that runs the script on a node
+func (h HostHook) Command() []string {
+	if h.Context == HostHookContextPlanet {
+		return utils.PlanetEnterCommand(defaults.HostHookShell, "-c", h.Script)
+	}
+	return []string{defaults.HostHookShell, "-c", h.Script}
+}
+
+// HostHookContext defines where the host hook script runs
+type HostHookContext string
+
+const (
+	// HostHookContextHost runs the script on the node itself
+	HostHookContextHost HostHookContext = "host"
+	// HostHookContextPlanet runs the script inside the planet container
+	HostHookContextPlanet HostHookContext = "planet"
+)
+
+// HostHooks lists the hooks that can run as host scripts
+var HostHooks = []HookType{
+	HookClusterProvision,
+	HookNodeAdding,
+	HookNodeAdded,
+	HookNodeRemoving,
+	HookBeforeUpdate,
+}
+
+// checkHost validates the host script of the hook of the specified type
+func (h Hook) checkHost(hookType HookType, profiles NodeProfiles) error {
+	if !hookType.SupportsHost() {
+		return trace.BadParameter("hook %v cannot run as a host script, supported hooks are %v",
+			hookType, HostHooks)
+	}
+	if h.Job != "" {
+		return trace.BadParameter("hook %v should specify either a job or a host script, not both",
+			hookType)
+	}
+	switch h.Host.Context {
+	case "", HostHookContextHost:
+	case HostHookContextPlanet:
+		if hookType == HookNodeAdding {
+			return trace.BadParameter("hook %v runs before planet is started on the joining node "+
+				"and can only use the %v context", hookType, HostHookContextHost)
+		}
+	default:
+		return trace.BadParameter("hook %v host script context should be either %v or %v, got %q",
+			hookType, HostHookContextHost, HostHookContextPlanet, h.Host.Context)
+	}
+	if h.Host.Script == "" {
+		return trace.BadParameter("hook %v host script is empty", hookType)
+	}
+	for _, profile := range h.Host.Profiles {
+		if _, err := profiles.ByName(profile); err != nil {
+			return trace.BadParameter("hook %v host script refers to unknown node profile %q",
+				hookType, profile)
+		}
+	}
+	return nil
+}
+
+// Check validates the hook retry and deadline settings
+func (h Hook) Check() error {
+	if h.Retries < 0 {
+		return trace.BadParameter("hook %v retries should be >= 0, got %v", h.Type, h.Retries)
+	}
+	if _, err := h.RetryBackoff(); err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := h.Deadline(); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// RetryBackoff returns the delay before the first retry of the hook
+func (h Hook) RetryBackoff() (time.Duration, error) {
+	if h.Backoff == "" {
+		return defaults.HookRetryBackoff, nil
+	}
+	return parseHookDuration(h.Type, "backoff", h.Backoff)
+}
+
+// Deadline returns the running time limit of a single hook attempt.
+// Returns 0 if the hook does not set one
+func (h Hook) Deadline() (time.Duration, error) {
+	if h.ActiveDeadline == "" {
+		return 0, nil
+	}
+	return parseHookDuration(h.Type, "activeDeadline", h.ActiveDeadline)
+}
+
+func parseHookDuration(hook HookType, field, value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, trace.BadParameter("hook %v %v %q is not in valid format, expected '30s'",
+			hook, field, value)
+	}
+	if duration <= 0 {
+		return 0, trace.BadParameter("hook %v %v should be positive, got %v", hook, field, duration)
+	}
+	return duration, nil
 }
 
 // Empty determines if the hook set is empty
 func (h Hook) Empty() bool {
-	return h.Job == ""
+	return h.Job == "" && h.Host == nil
 }
 
 // GetJob parses the hook's string with job spec and returns a job object
@@ -172,6 +308,10 @@ const (
 	HookNodeRemoving HookType = "preNodeRemove"
 	// HookNodeRemoved defines the post shrink hook
 	HookNodeRemoved HookType = "postNodeRemove"
+	// HookNodeDraining defines the hook that runs before a node is drained
+	HookNodeDraining HookType = "preNodeDrain"
+	// HookNodeDrained defines the hook that runs after a node has been drained
+	HookNodeDrained HookType = "postNodeDrain"
 	// HookStatus defines the application status hook
 	HookStatus HookType = "status"
 	// HookInfo defines the application service info hook
@@ -204,6 +344,16 @@ func (h HookType) String() string {
 	return string(h)
 }
 
+// SupportsHost returns true if the hook can run as a host script
+func (h HookType) SupportsHost() bool {
+	for _, hook := range HostHooks {
+		if hook == h {
+			return true
+		}
+	}
+	return false
+}
+
 // AllHooks obtains the list of all hook types
 func AllHooks() []HookType {
 	return []HookType{
@@ -224,6 +374,8 @@ func AllHooks() []HookType {
 		HookNodeAdded,
 		HookNodeRemoving,
 		HookNodeRemoved,
+		HookNodeDraining,
+		HookNodeDrained,
 		HookStatus,
 		HookInfo,
 		HookLicenseUpdated,
@@ -280,6 +432,10 @@ func HookFromString(hookType HookType, manifest Manifest) (*Hook, error) {
 		hook = manifest.Hooks.NodeRemoving
 	case HookNodeRemoved:
 		hook = manifest.Hooks.NodeRemoved
+	case HookNodeDraining:
+		hook = manifest.Hooks.NodeDraining
+	case HookNodeDrained:
+		hook = manifest.Hooks.NodeDrained
 	case HookStatus:
 		hook = manifest.Hooks.Status
 	case HookInfo:
@@ -18,6 +18,7 @@ package schema
 
 import (
 	"reflect"
+	"time"
 
 	. "gopkg.in/check.v1"
 	batchv1 "k8s.io/api/batch/v1"
@@ -135,3 +136,129 @@ hooks:
 	c.Assert(err, IsNil)
 	c.Assert(installJob, DeepEquals, job)
 }
+
+func (r *HooksSuite) TestParsesRetrySettings(c *C) {
+	manifest, err := ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: test
+  resourceVersion: 0.0.1
+hooks:
+  install:
+    retries: 3
+    backoff: 30s
+    activeDeadline: 10m
+    job: "kind: Job"`))
+	c.Assert(err, IsNil)
+	hook := manifest.Hooks.Install
+	c.Assert(hook.Retries, Equals, 3)
+	backoff, err := hook.RetryBackoff()
+	c.Assert(err, IsNil)
+	c.Assert(backoff, Equals, 30*time.Second)
+	deadline, err := hook.Deadline()
+	c.Assert(err, IsNil)
+	c.Assert(deadline, Equals, 10*time.Minute)
+
+	_, err = ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: test
+  resourceVersion: 0.0.1
+hooks:
+  install:
+    activeDeadline: soon
+    job: "kind: Job"`))
+	c.Assert(err, ErrorMatches, `.*hook install activeDeadline "soon" is not in valid format.*`)
+}
+
+func (r *HooksSuite) TestParsesHostHook(c *C) {
+	manifest, err := ParseManifestYAML([]byte(hostHookManifest(`
+  preNodeAdd:
+    host:
+      script: "sysctl -w vm.max_map_count=262144"
+      profiles: [worker]`)))
+	c.Assert(err, IsNil)
+	hook, err := HookFromString(HookNodeAdding, *manifest)
+	c.Assert(err, IsNil)
+	c.Assert(hook.Host.Matches("worker"), Equals, true)
+	c.Assert(hook.Host.Matches("master"), Equals, false)
+	c.Assert(hook.Host.Command(), DeepEquals, []string{"/bin/sh", "-c", "sysctl -w vm.max_map_count=262144"})
+
+	manifest, err = ParseManifestYAML([]byte(hostHookManifest(`
+  preUpdate:
+    host:
+      script: "etcdctl cluster-health"
+      context: planet`)))
+	c.Assert(err, IsNil)
+	hook, err = HookFromString(HookBeforeUpdate, *manifest)
+	c.Assert(err, IsNil)
+	c.Assert(hook.Host.Matches("master"), Equals, true)
+	c.Assert(hook.Host.Command()[1:], DeepEquals, []string{"planet", "enter", "--", "--notty", "/bin/sh", "--", "-c", "etcdctl cluster-health"})
+}
+
+func (r *HooksSuite) TestRejectsInvalidHostHooks(c *C) {
+	testCases := []struct {
+		hooks   string
+		error   string
+		comment string
+	}{
+		{
+			hooks: `
+  preNodeAdd:
+    job: "kind: Job"
+    host:
+      script: "true"`,
+			error:   ".*hook preNodeAdd should specify either a job or a host script, not both.*",
+			comment: "job and host script are mutually exclusive",
+		},
+		{
+			hooks: `
+  preNodeAdd:
+    host:
+      script: "true"
+      context: planet`,
+			error:   ".*hook preNodeAdd runs before planet is started.*",
+			comment: "pre-join hook cannot run in planet",
+		},
+		{
+			hooks: `
+  postNodeAdd:
+    host:
+      script: "true"
+      profiles: [db]`,
+			error:   `.*hook postNodeAdd host script refers to unknown node profile "db".*`,
+			comment: "unknown node profile",
+		},
+		{
+			hooks: `
+  postNodeAdd:
+    host:
+      script: ""`,
+			error:   ".*hook postNodeAdd host script is empty.*",
+			comment: "empty script",
+		},
+	}
+	for _, tc := range testCases {
+		_, err := ParseManifestYAML([]byte(hostHookManifest(tc.hooks)))
+		c.Assert(err, ErrorMatches, tc.error, Commentf(tc.comment))
+	}
+}
+
+func hostHookManifest(hooks string) string {
+	return `apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: test
+  resourceVersion: 0.0.1
+installer:
+  flavors:
+    items:
+    - name: one
+      nodes:
+      - profile: master
+        count: 1
+nodeProfiles:
+- name: master
+- name: worker
+hooks:` + hooks
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/utils"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/gravitational/trace"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -341,6 +342,186 @@ func (m Manifest) DefaultRuntimePackage() (*loc.Locator, error) {
 	return &m.SystemOptions.Dependencies.Runtime.Locator, nil
 }
 
+// RuntimePackageForArch returns the planet package for the specified profile
+// and node architecture
+func (m Manifest) RuntimePackageForArch(profileName, arch string) (*loc.Locator, error) {
+	runtimePackage, err := m.RuntimePackageForProfile(profileName)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := m.checkArchitecture(arch); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	archPackage := ArchPackage(*runtimePackage, arch)
+	return &archPackage, nil
+}
+
+// DependencyForArch returns the package dependency with the specified name
+// built for the specified node architecture
+func (m Manifest) DependencyForArch(name, arch string) (*loc.Locator, error) {
+	locator, err := m.Dependencies.ByName(name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := m.checkArchitecture(arch); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	archPackage := ArchPackage(*locator, arch)
+	return &archPackage, nil
+}
+
+// Architectures returns the CPU architectures of the nodes this image
+// can be installed on
+func (m Manifest) Architectures() []string {
+	if m.SystemOptions == nil || len(m.SystemOptions.Architectures) == 0 {
+		return []string{defaults.Architecture}
+	}
+	return m.SystemOptions.Architectures
+}
+
+// AllowsMixedArchitectures returns true if the cluster can have nodes
+// of different architectures
+func (m Manifest) AllowsMixedArchitectures() bool {
+	return m.SystemOptions != nil && m.SystemOptions.AllowMixedArchitectures
+}
+
+// ArchPackages returns the variants of the specified package for the
+// non-default architectures supported by this image
+func (m Manifest) ArchPackages(locator loc.Locator) (packages []loc.Locator) {
+	for _, arch := range m.Architectures() {
+		if arch != defaults.Architecture {
+			packages = append(packages, ArchPackage(locator, arch))
+		}
+	}
+	return packages
+}
+
+// ArchDependencies returns the variants of the gravity and teleport packages
+// from the specified list for the non-default architectures supported by this image
+func (m Manifest) ArchDependencies(packages []loc.Locator) (deps []loc.Locator) {
+	for _, locator := range packages {
+		if IsArchDependency(locator) {
+			deps = append(deps, m.ArchPackages(locator)...)
+		}
+	}
+	return deps
+}
+
+// checkArchitecture returns an error if this image does not support
+// the specified architecture
+func (m Manifest) checkArchitecture(arch string) error {
+	if arch == "" || utils.StringInSlice(m.Architectures(), arch) {
+		return nil
+	}
+	return trace.BadParameter("architecture %v is not supported, supported architectures: %v",
+		arch, strings.Join(m.Architectures(), ", "))
+}
+
+// ArchPackage returns the variant of the specified package for the specified
+// architecture. The runtime, gravity and teleport packages for architectures
+// other than amd64 have the architecture appended to their name,
+// e.g. gravitational.io/planet-arm64:6.0.0
+func ArchPackage(locator loc.Locator, arch string) loc.Locator {
+	if arch == "" || arch == defaults.Architecture {
+		return locator
+	}
+	locator.Name = fmt.Sprintf("%v-%v", locator.Name, arch)
+	return locator
+}
+
+// IsArchDependency returns true if the specified package is built
+// for each architecture of the image
+func IsArchDependency(locator loc.Locator) bool {
+	return utils.StringInSlice(archDependencies, locator.Name)
+}
+
+// archDependencies lists the names of the binary package dependencies
+// that are built for each architecture of the image
+var archDependencies = []string{constants.GravityPackage, constants.TeleportPackage}
+
+// SupportsUpgradeFrom returns true if this image can be upgraded directly
+// from the runtime with the specified version.
+// Images without an upgrade constraint support upgrades from any version
+func (m Manifest) SupportsUpgradeFrom(version string) (bool, error) {
+	if m.SystemOptions == nil || m.SystemOptions.UpgradeFrom == "" {
+		return true, nil
+	}
+	constraint, err := parseUpgradeConstraint(m.SystemOptions.UpgradeFrom)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	ver, err := semver.NewVersion(version)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	return constraint.check(*ver), nil
+}
+
+// parseUpgradeConstraint parses the specified upgrade version constraint
+// given as a comma-separated list of comparisons, e.g. ">=5.2.0, <5.5.0"
+func parseUpgradeConstraint(constraint string) (upgradeConstraint, error) {
+	var result upgradeConstraint
+	for _, term := range strings.Split(constraint, ",") {
+		term = strings.TrimSpace(term)
+		op := "="
+		for _, prefix := range comparisonOperators {
+			if strings.HasPrefix(term, prefix) {
+				op = prefix
+				term = strings.TrimSpace(strings.TrimPrefix(term, prefix))
+				break
+			}
+		}
+		ver, err := semver.NewVersion(term)
+		if err != nil {
+			return nil, trace.BadParameter("invalid upgrade constraint %q: %v", constraint, err)
+		}
+		result = append(result, versionComparison{op: op, version: *ver})
+	}
+	return result, nil
+}
+
+// check returns true if the specified version satisfies all comparisons
+// of this constraint
+func (r upgradeConstraint) check(ver semver.Version) bool {
+	for _, comparison := range r {
+		if !comparison.check(ver) {
+			return false
+		}
+	}
+	return true
+}
+
+// check returns true if the specified version satisfies this comparison
+func (r versionComparison) check(ver semver.Version) bool {
+	switch r.op {
+	case ">=":
+		return !ver.LessThan(r.version)
+	case ">":
+		return r.version.LessThan(ver)
+	case "<=":
+		return !r.version.LessThan(ver)
+	case "<":
+		return ver.LessThan(r.version)
+	case "!=":
+		return !ver.Equal(r.version)
+	default:
+		return ver.Equal(r.version)
+	}
+}
+
+// upgradeConstraint is a list of version comparisons that all have to hold
+type upgradeConstraint []versionComparison
+
+// versionComparison compares versions to the version with the operator
+type versionComparison struct {
+	op      string
+	version semver.Version
+}
+
+// comparisonOperators lists the supported version comparison operators.
+// Longer operators go first so they are matched before their prefixes
+var comparisonOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
 // RuntimeImages returns the list of all runtime images.
 func (m Manifest) RuntimeImages() (images []string) {
 	if m.SystemOptions != nil && m.SystemOptions.BaseImage != "" {
@@ -360,6 +541,12 @@ func (m Manifest) AllPackageDependencies() (deps []loc.Locator) {
 		deps = append(deps, m.SystemOptions.Dependencies.Runtime.Locator)
 	}
 	deps = append(deps, m.NodeProfiles.RuntimePackages()...)
+	var archDeps []loc.Locator
+	for _, runtimePackage := range deps {
+		archDeps = append(archDeps, m.ArchPackages(runtimePackage)...)
+	}
+	deps = append(deps, archDeps...)
+	deps = append(deps, m.ArchDependencies(m.Dependencies.GetPackages())...)
 	return loc.Deduplicate(append(m.Dependencies.GetPackages(), deps...))
 }
 
@@ -795,6 +982,9 @@ type Volume struct {
 	SkipIfMissing *bool `json:"skipIfMissing,omitempty"`
 	// MinTransferRate is required disk speed
 	MinTransferRate utils.TransferRate `json:"minTransferRate,omitempty"`
+	// MaxFsyncLatency is the maximum allowed 99th percentile fsync latency
+	// for the volume, e.g. "10ms"
+	MaxFsyncLatency string `json:"maxFsyncLatency,omitempty"`
 	// Hidden applies to mounts and means that the mount is not shown to a user in installer UI
 	Hidden bool `json:"hidden,omitempty"`
 	// UID sets UID for a volume path on the host
@@ -826,6 +1016,12 @@ func (v *Volume) CheckAndSetDefaults() error {
 			return trace.Wrap(err)
 		}
 	}
+	if v.MaxFsyncLatency != "" {
+		_, err := v.FsyncLatency()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	if utils.BoolValue(v.SkipIfMissing) {
 		// Turn off automatic directory creation for optimistic mounts
 		v.CreateIfMissing = utils.BoolPtr(false)
@@ -833,6 +1029,23 @@ func (v *Volume) CheckAndSetDefaults() error {
 	return nil
 }
 
+// FsyncLatency parses the maximum fsync latency requirement.
+// Returns 0 if the requirement has not been set
+func (v Volume) FsyncLatency() (time.Duration, error) {
+	if v.MaxFsyncLatency == "" {
+		return 0, nil
+	}
+	latency, err := time.ParseDuration(v.MaxFsyncLatency)
+	if err != nil {
+		return 0, trace.BadParameter("volume fsync latency %q is not in valid format, expected '10ms'",
+			v.MaxFsyncLatency)
+	}
+	if latency <= 0 {
+		return 0, trace.BadParameter("volume fsync latency should be positive, got %v", latency)
+	}
+	return latency, nil
+}
+
 // FileMode parses mode from octal string representation
 // and returns os.FileMode instead
 func (v Volume) FileMode() (os.FileMode, error) {
@@ -980,6 +1193,15 @@ type SystemOptions struct {
 	BaseImage string `json:"baseImage,omitempty"`
 	// Dependencies defines additional package dependencies
 	Dependencies SystemDependencies `json:"dependencies"`
+	// UpgradeFrom is the semantic version constraint of the runtime versions
+	// this image can be upgraded from directly, for example ">=5.2.0, <5.5.0".
+	// Upgrades from versions outside of the range require intermediate runtimes
+	UpgradeFrom string `json:"upgradeFrom,omitempty"`
+	// Architectures lists the CPU architectures of the nodes the image can be
+	// installed on. Defaults to amd64
+	Architectures []string `json:"architectures,omitempty"`
+	// AllowMixedArchitectures allows clusters with nodes of different architectures
+	AllowMixedArchitectures bool `json:"allowMixedArchitectures,omitempty"`
 }
 
 // Runtime describes the application runtime
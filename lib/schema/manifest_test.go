@@ -25,6 +25,7 @@ import (
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/utils"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/gravitational/trace"
 	. "gopkg.in/check.v1"
 	"k8s.io/api/core/v1"
@@ -362,6 +363,98 @@ metadata:
 	c.Assert(err, NotNil)
 }
 
+func (s *ManifestSuite) TestUpgradeFrom(c *C) {
+	manifest, err := ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Runtime
+metadata:
+  name: kubernetes
+  resourceVersion: 5.5.0
+systemOptions:
+  upgradeFrom: ">=5.2.0, <5.5.0"`))
+	c.Assert(err, IsNil)
+	for version, supported := range map[string]bool{
+		"5.2.0":  true,
+		"5.4.10": true,
+		"5.0.35": false,
+		"5.5.0":  false,
+	} {
+		ok, err := manifest.SupportsUpgradeFrom(version)
+		c.Assert(err, IsNil)
+		c.Assert(ok, Equals, supported, Commentf(version))
+	}
+
+	_, err = ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Runtime
+metadata:
+  name: kubernetes
+  resourceVersion: 5.5.0
+systemOptions:
+  upgradeFrom: "not a constraint"`))
+	c.Assert(err, NotNil)
+
+	constraint, err := parseUpgradeConstraint("5.4.1")
+	c.Assert(err, IsNil)
+	c.Assert(constraint.check(*semver.New("5.4.1")), Equals, true)
+	c.Assert(constraint.check(*semver.New("5.4.2")), Equals, false)
+
+	constraint, err = parseUpgradeConstraint(">5.2.0, != 5.3.0, <=5.4.0")
+	c.Assert(err, IsNil)
+	c.Assert(constraint.check(*semver.New("5.2.0")), Equals, false)
+	c.Assert(constraint.check(*semver.New("5.3.0")), Equals, false)
+	c.Assert(constraint.check(*semver.New("5.4.0")), Equals, true)
+}
+
+func (s *ManifestSuite) TestArchitectures(c *C) {
+	manifest, err := ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Runtime
+metadata:
+  name: kubernetes
+  resourceVersion: 0.0.1
+dependencies:
+  packages:
+    - gravitational.io/gravity:0.0.1
+nodeProfiles:
+  - name: node
+systemOptions:
+  dependencies:
+    runtimePackage: gravitational.io/planet:0.0.1
+  architectures: [amd64, arm64]`))
+	c.Assert(err, IsNil)
+	c.Assert(manifest.Architectures(), DeepEquals, []string{"amd64", "arm64"})
+	c.Assert(manifest.AllowsMixedArchitectures(), Equals, false)
+	for arch, expected := range map[string]string{
+		"":      "gravitational.io/planet:0.0.1",
+		"amd64": "gravitational.io/planet:0.0.1",
+		"arm64": "gravitational.io/planet-arm64:0.0.1",
+	} {
+		runtimePackage, err := manifest.RuntimePackageForArch("node", arch)
+		c.Assert(err, IsNil)
+		c.Assert(runtimePackage.String(), Equals, expected, Commentf(arch))
+	}
+	_, err = manifest.RuntimePackageForArch("node", "ppc64le")
+	c.Assert(err, ErrorMatches, "architecture ppc64le is not supported.*")
+	gravityPackage, err := manifest.DependencyForArch(constants.GravityPackage, "arm64")
+	c.Assert(err, IsNil)
+	c.Assert(gravityPackage.String(), Equals, "gravitational.io/gravity-arm64:0.0.1")
+	_, err = manifest.DependencyForArch(constants.GravityPackage, "ppc64le")
+	c.Assert(err, ErrorMatches, "architecture ppc64le is not supported.*")
+	c.Assert(manifest.AllPackageDependencies(), DeepEquals, []loc.Locator{
+		loc.MustParseLocator("gravitational.io/gravity:0.0.1"),
+		loc.MustParseLocator("gravitational.io/planet:0.0.1"),
+		loc.MustParseLocator("gravitational.io/planet-arm64:0.0.1"),
+		loc.MustParseLocator("gravitational.io/gravity-arm64:0.0.1"),
+	})
+
+	_, err = ParseManifestYAML([]byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: app
+  resourceVersion: 0.0.1
+systemOptions:
+  architectures: [s390x]`))
+	c.Assert(err, NotNil)
+}
+
 func (s *ManifestSuite) TestInvalidProfileInFlavor(c *C) {
 	bytes := []byte(`apiVersion: bundle.gravitational.io/v2
 kind: Bundle
@@ -91,6 +91,22 @@ func CheckAndSetDefaults(manifest *Manifest) error {
 		errors = append(errors, trace.Wrap(err))
 	}
 
+	if manifest.SystemOptions != nil && manifest.SystemOptions.UpgradeFrom != "" {
+		_, err = parseUpgradeConstraint(manifest.SystemOptions.UpgradeFrom)
+		if err != nil {
+			errors = append(errors, trace.Wrap(err))
+		}
+	}
+
+	for _, profile := range manifest.NodeProfiles {
+		if profile.SystemOptions != nil && (len(profile.SystemOptions.Architectures) != 0 ||
+			profile.SystemOptions.AllowMixedArchitectures) {
+			errors = append(errors, trace.BadParameter(
+				"node profile %q: architectures can only be set in the global systemOptions",
+				profile.Name))
+		}
+	}
+
 	// the rest of the checks apply only to user apps
 	// TODO Do specific checks for Cluster VS Application
 	switch manifest.Kind {
@@ -131,14 +147,16 @@ func CheckAndSetDefaults(manifest *Manifest) error {
 		}
 	}
 
-	if manifest.Hooks != nil && (manifest.Hooks.ClusterProvision != nil ||
+	// a clusterProvision host script prepares the nodes and does not
+	// provision the infrastructure
+	if manifest.Hooks != nil && ((manifest.Hooks.ClusterProvision != nil && manifest.Hooks.ClusterProvision.Host == nil) ||
 		manifest.Hooks.ClusterDeprovision != nil ||
 		manifest.Hooks.NodesProvision != nil ||
 		manifest.Hooks.NodesDeprovision != nil) {
 
-		if manifest.Hooks.ClusterProvision == nil {
+		if manifest.Hooks.ClusterProvision == nil || manifest.Hooks.ClusterProvision.Host != nil {
 			errors = append(errors,
-				trace.BadParameter("specify clusterProvision hook when using custom provisioning"))
+				trace.BadParameter("specify clusterProvision job hook when using custom provisioning"))
 		}
 		if manifest.Hooks.ClusterDeprovision == nil {
 			errors = append(errors,
@@ -154,6 +172,23 @@ func CheckAndSetDefaults(manifest *Manifest) error {
 		}
 	}
 
+	if manifest.Hooks != nil {
+		for _, hook := range manifest.Hooks.AllHooks() {
+			if err := hook.Check(); err != nil {
+				errors = append(errors, trace.Wrap(err))
+			}
+		}
+		for _, hookType := range AllHooks() {
+			hook, err := HookFromString(hookType, *manifest)
+			if err != nil || hook.Host == nil {
+				continue
+			}
+			if err := hook.checkHost(hookType, manifest.NodeProfiles); err != nil {
+				errors = append(errors, trace.Wrap(err))
+			}
+		}
+	}
+
 	for i, nodeProfile := range manifest.NodeProfiles {
 		for j := range nodeProfile.Requirements.Volumes {
 			if err := manifest.NodeProfiles[i].Requirements.Volumes[j].CheckAndSetDefaults(); err != nil {
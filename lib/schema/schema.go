@@ -240,6 +240,7 @@ const manifestSchema = `
                         "createIfMissing": {"type": "boolean", "default": true},
                         "skipIfMissing": {"type": "boolean", "default": false},
                         "minTransferRate": {"type": "string"},
+                        "maxFsyncLatency": {"type": "string"},
                         "hidden": {"type": "boolean"},
                         "recursive": {"type": "boolean"},
                         "mode": {"type": "string"},
@@ -336,7 +337,20 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "clusterProvision"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"},
+                "host": {
+                  "type": "object",
+                  "additionalProperties": false,
+                  "required": ["script"],
+                  "properties": {
+                    "script": {"type": "string"},
+                    "context": {"type": "string", "enum": ["host", "planet"]},
+                    "profiles": {"type": "array", "items": {"type": "string"}}
+                  }
+                }
               }
             },
             "clusterDeprovision": {
@@ -344,7 +358,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "clusterDeprovision"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "nodesProvision": {
@@ -352,7 +369,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "nodesProvision"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "nodesDeprovision": {
@@ -360,7 +380,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "nodesDeprovision"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "install": {
@@ -368,7 +391,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "install"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "postInstall": {
@@ -376,7 +402,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "postInstall"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "uninstall": {
@@ -384,7 +413,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "uninstall"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "preUninstall": {
@@ -392,7 +424,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "preUninstall"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "preNodeAdd": {
@@ -400,7 +435,20 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "preNodeAdd"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"},
+                "host": {
+                  "type": "object",
+                  "additionalProperties": false,
+                  "required": ["script"],
+                  "properties": {
+                    "script": {"type": "string"},
+                    "context": {"type": "string", "enum": ["host", "planet"]},
+                    "profiles": {"type": "array", "items": {"type": "string"}}
+                  }
+                }
               }
             },
             "postNodeAdd": {
@@ -408,7 +456,20 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "postNodeAdd"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"},
+                "host": {
+                  "type": "object",
+                  "additionalProperties": false,
+                  "required": ["script"],
+                  "properties": {
+                    "script": {"type": "string"},
+                    "context": {"type": "string", "enum": ["host", "planet"]},
+                    "profiles": {"type": "array", "items": {"type": "string"}}
+                  }
+                }
               }
             },
             "preNodeRemove": {
@@ -416,7 +477,20 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "preNodeRemove"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"},
+                "host": {
+                  "type": "object",
+                  "additionalProperties": false,
+                  "required": ["script"],
+                  "properties": {
+                    "script": {"type": "string"},
+                    "context": {"type": "string", "enum": ["host", "planet"]},
+                    "profiles": {"type": "array", "items": {"type": "string"}}
+                  }
+                }
               }
             },
             "postNodeRemove": {
@@ -424,7 +498,32 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "postNodeRemove"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
+              }
+            },
+            "preNodeDrain": {
+              "type": "object",
+              "additionalProperties": false,
+              "properties": {
+                "type": {"type": "string", "default": "preNodeDrain"},
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
+              }
+            },
+            "postNodeDrain": {
+              "type": "object",
+              "additionalProperties": false,
+              "properties": {
+                "type": {"type": "string", "default": "postNodeDrain"},
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "preUpdate": {
@@ -432,7 +531,20 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "preUpdate"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"},
+                "host": {
+                  "type": "object",
+                  "additionalProperties": false,
+                  "required": ["script"],
+                  "properties": {
+                    "script": {"type": "string"},
+                    "context": {"type": "string", "enum": ["host", "planet"]},
+                    "profiles": {"type": "array", "items": {"type": "string"}}
+                  }
+                }
               }
             },
             "update": {
@@ -440,7 +552,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "update"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "postUpdate": {
@@ -448,7 +563,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "postUpdate"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "rollback": {
@@ -456,7 +574,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "rollback"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "postRollback": {
@@ -464,7 +585,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "postRollback"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "status": {
@@ -472,7 +596,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "status"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "info": {
@@ -480,7 +607,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "info"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "licenseUpdated": {
@@ -488,7 +618,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "licenseUpdated"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "start": {
@@ -496,7 +629,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "start"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "stop": {
@@ -504,7 +640,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "stop"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "dump": {
@@ -512,7 +651,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "dump"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "backup": {
@@ -520,7 +662,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "backup"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "restore": {
@@ -528,7 +673,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "restore"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "networkInstall": {
@@ -536,7 +684,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "networkInstall"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "networkUpdate": {
@@ -544,7 +695,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "networkUpdate"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             },
             "networkRollback": {
@@ -552,7 +706,10 @@ const manifestSchema = `
               "additionalProperties": false,
               "properties": {
                 "type": {"type": "string", "default": "networkRollback"},
-                "job": {"type": "string"}
+                "job": {"type": "string"},
+                "retries": {"type": "integer", "minimum": 0},
+                "backoff": {"type": "string"},
+                "activeDeadline": {"type": "string"}
               }
             }
           }
@@ -686,7 +843,13 @@ const manifestSchema = `
           "properties": {
             "runtimePackage": {"type": "string"}
           }
-        }
+        },
+        "upgradeFrom": {"type": "string"},
+        "architectures": {
+          "type": "array",
+          "items": {"enum": ["amd64", "arm64"]}
+        },
+        "allowMixedArchitectures": {"type": "boolean"}
       }
     },
     "externalService": {
@@ -0,0 +1,87 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+
+	"github.com/gravitational/gravity/lib/checks"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/state"
+	"github.com/gravitational/gravity/lib/systeminfo"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+)
+
+// addDiskIOProbes benchmarks disk I/O of the etcd and docker directories
+// of the local node and reports the failed probes in the node status.
+//
+// The benchmark needs the directories on host so it is skipped inside planet
+func addDiskIOProbes(ctx context.Context, cluster ops.Site, agent *Agent) error {
+	if utils.CheckInPlanet() {
+		return nil
+	}
+	node, err := localNode(agent.Nodes)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	profile, err := cluster.App.Manifest.NodeProfiles.ByName(node.Profile)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	stateDir, err := state.GetStateDir()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	targets, err := checks.DiskIOTargets(*profile, stateDir)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var probes health.Probes
+	checks.NewDiskIOChecker(targets...).Check(ctx, &probes)
+	addFailedProbes(node, probes.GetFailed())
+	return nil
+}
+
+// addFailedProbes adds the specified failed probes to the node status
+func addFailedProbes(node *ClusterServer, failed []*pb.Probe) {
+	for _, probe := range failed {
+		node.FailedProbes = append(node.FailedProbes, probeErrorDetail(*probe))
+	}
+	if len(node.FailedProbes) != 0 && node.Status == NodeHealthy {
+		node.Status = NodeDegraded
+	}
+}
+
+// localNode returns the status of the node with one of the local IP addresses
+func localNode(nodes []ClusterServer) (*ClusterServer, error) {
+	ifaces, err := systeminfo.NetworkInterfaces()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for i, node := range nodes {
+		for _, iface := range ifaces {
+			if iface.IPv4 == node.AdvertiseIP {
+				return &nodes[i], nil
+			}
+		}
+	}
+	return nil, trace.NotFound("local node is not part of the cluster")
+}
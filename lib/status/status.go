@@ -127,6 +127,10 @@ func FromCluster(ctx context.Context, operator ops.Operator, cluster ops.Site, o
 		return status, trace.Wrap(err, "failed to collect system status from agents")
 	}
 
+	if err := addDiskIOProbes(ctx, cluster, status.Agent); err != nil {
+		logrus.WithError(err).Warn("Failed to run disk I/O checks.")
+	}
+
 	status.State = cluster.State
 	return status, nil
 }
@@ -352,7 +356,7 @@ type ClusterServer struct {
 }
 
 func (r ClusterOperation) isFailed() bool {
-	return r.State == ops.OperationStateFailed
+	return r.State == ops.OperationStateFailed || r.State == ops.OperationStateRolledBack
 }
 
 func fromOperationAndProgress(operation ops.SiteOperation, progress ops.ProgressEntry) *ClusterOperation {
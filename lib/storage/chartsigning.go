@@ -0,0 +1,271 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	teledefaults "github.com/gravitational/teleport/lib/defaults"
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"golang.org/x/crypto/openpgp"
+)
+
+// ChartSigningPolicy defines a resource that controls verification
+// of application chart signatures
+type ChartSigningPolicy interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults validates the resource and fills in some defaults
+	CheckAndSetDefaults() error
+	// GetMode returns the policy mode
+	GetMode() string
+	// IsEnforced returns true if unverified charts must be rejected
+	IsEnforced() bool
+	// GetTrustedKeys returns the list of trusted keys
+	GetTrustedKeys() []TrustedKey
+	// GetKeyRing returns the trusted keys as a keyring
+	GetKeyRing() (openpgp.EntityList, error)
+}
+
+// NewChartSigningPolicy creates a new chart signing policy resource from the provided spec
+func NewChartSigningPolicy(spec ChartSigningPolicySpecV1) ChartSigningPolicy {
+	return &ChartSigningPolicyV1{
+		Kind:    KindChartSigningPolicy,
+		Version: teleservices.V1,
+		Metadata: teleservices.Metadata{
+			Name:      KindChartSigningPolicy,
+			Namespace: teledefaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// ChartSigningPolicyV1 defines the chart signing policy resource
+type ChartSigningPolicyV1 struct {
+	// Kind is the resource kind
+	Kind string `json:"kind"`
+	// Version is the resource version
+	Version string `json:"version"`
+	// Metadata is the resource metadata
+	Metadata teleservices.Metadata `json:"metadata"`
+	// Spec is the resource specification
+	Spec ChartSigningPolicySpecV1 `json:"spec"`
+}
+
+// ChartSigningPolicySpecV1 defines the chart signing policy resource specification
+type ChartSigningPolicySpecV1 struct {
+	// Mode defines what happens when a chart cannot be verified.
+	// Either "warn" or "enforce", defaults to "warn"
+	Mode string `json:"mode,omitempty"`
+	// TrustedKeys lists public keys charts can be signed with
+	TrustedKeys []TrustedKey `json:"trusted_keys"`
+}
+
+// TrustedKey is a public key trusted to sign application charts
+type TrustedKey struct {
+	// Name is the key name
+	Name string `json:"name"`
+	// PublicKey is the ASCII-armored PGP public key
+	PublicKey string `json:"public_key"`
+}
+
+// GetMode returns the policy mode
+func (p *ChartSigningPolicyV1) GetMode() string {
+	return p.Spec.Mode
+}
+
+// IsEnforced returns true if unverified charts must be rejected
+func (p *ChartSigningPolicyV1) IsEnforced() bool {
+	return p.Spec.Mode == ChartSigningEnforce
+}
+
+// GetTrustedKeys returns the list of trusted keys
+func (p *ChartSigningPolicyV1) GetTrustedKeys() []TrustedKey {
+	return p.Spec.TrustedKeys
+}
+
+// GetKeyRing returns the trusted keys as a keyring
+func (p *ChartSigningPolicyV1) GetKeyRing() (keyring openpgp.EntityList, err error) {
+	for _, key := range p.Spec.TrustedKeys {
+		entities, err := key.KeyRing()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		keyring = append(keyring, entities...)
+	}
+	return keyring, nil
+}
+
+// CheckAndSetDefaults validates the resource and fills in some defaults
+func (p *ChartSigningPolicyV1) CheckAndSetDefaults() error {
+	if p.Metadata.Name == "" {
+		p.Metadata.Name = KindChartSigningPolicy
+	}
+	if err := p.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if p.Spec.Mode == "" {
+		p.Spec.Mode = ChartSigningWarn
+	}
+	switch p.Spec.Mode {
+	case ChartSigningWarn, ChartSigningEnforce:
+	default:
+		return trace.BadParameter("unsupported mode %q, supported are: %v, %v",
+			p.Spec.Mode, ChartSigningWarn, ChartSigningEnforce)
+	}
+	if len(p.Spec.TrustedKeys) == 0 {
+		return trace.BadParameter("at least one trusted key is required")
+	}
+	names := make(map[string]struct{})
+	for _, key := range p.Spec.TrustedKeys {
+		if key.Name == "" {
+			return trace.BadParameter("trusted key name cannot be empty")
+		}
+		if _, ok := names[key.Name]; ok {
+			return trace.BadParameter("duplicate trusted key %q", key.Name)
+		}
+		names[key.Name] = struct{}{}
+		if _, err := key.KeyRing(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// GetName returns the resource name
+func (p *ChartSigningPolicyV1) GetName() string {
+	return p.Metadata.Name
+}
+
+// SetName sets the resource name
+func (p *ChartSigningPolicyV1) SetName(name string) {
+	p.Metadata.Name = name
+}
+
+// GetMetadata returns the resource metadata
+func (p *ChartSigningPolicyV1) GetMetadata() teleservices.Metadata {
+	return p.Metadata
+}
+
+// SetExpiry sets the resource expiration time
+func (p *ChartSigningPolicyV1) SetExpiry(expires time.Time) {
+	p.Metadata.SetExpiry(expires)
+}
+
+// Expiry returns the resource expiration time
+func (p *ChartSigningPolicyV1) Expiry() time.Time {
+	return p.Metadata.Expiry()
+}
+
+// SetTTL sets the resource TTL
+func (p *ChartSigningPolicyV1) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	p.Metadata.SetTTL(clock, ttl)
+}
+
+// String returns the object's string representation
+func (p ChartSigningPolicyV1) String() string {
+	var names []string
+	for _, key := range p.Spec.TrustedKeys {
+		names = append(names, key.Name)
+	}
+	return fmt.Sprintf("ChartSigningPolicy(Mode=%v, TrustedKeys=%v)",
+		p.Spec.Mode, names)
+}
+
+// KeyRing returns the entities defined by the key
+func (k TrustedKey) KeyRing() (openpgp.EntityList, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(k.PublicKey))
+	if err != nil {
+		return nil, trace.BadParameter("failed to parse trusted key %q: %v", k.Name, err)
+	}
+	return entities, nil
+}
+
+// UnmarshalChartSigningPolicy unmarshals chart signing policy resource from JSON or YAML
+func UnmarshalChartSigningPolicy(data []byte) (ChartSigningPolicy, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty input")
+	}
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var header teleservices.ResourceHeader
+	if err := json.Unmarshal(jsonData, &header); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch header.Version {
+	case teleservices.V1:
+		var policy ChartSigningPolicyV1
+		err := teleutils.UnmarshalWithSchema(GetChartSigningPolicySchema(), &policy, jsonData)
+		if err != nil {
+			return nil, trace.BadParameter(err.Error())
+		}
+		if err := policy.CheckAndSetDefaults(); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &policy, nil
+	}
+	return nil, trace.BadParameter("%v resource version %q is not supported",
+		KindChartSigningPolicy, header.Version)
+}
+
+// MarshalChartSigningPolicy marshals chart signing policy resource to JSON
+func MarshalChartSigningPolicy(policy ChartSigningPolicy, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(policy)
+}
+
+// GetChartSigningPolicySchema returns the full chart signing policy resource schema
+func GetChartSigningPolicySchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, MetadataSchema,
+		ChartSigningPolicySpecV1Schema, "")
+}
+
+// ChartSigningPolicySpecV1Schema defines the chart signing policy spec schema
+const ChartSigningPolicySpecV1Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["trusted_keys"],
+  "properties": {
+    "mode": {"type": "string"},
+    "trusted_keys": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["name", "public_key"],
+        "properties": {
+          "name": {"type": "string"},
+          "public_key": {"type": "string"}
+        }
+      }
+    }
+  }
+}`
+
+const (
+	// ChartSigningWarn allows unverified charts to be installed with a warning
+	ChartSigningWarn = "warn"
+	// ChartSigningEnforce rejects unverified charts
+	ChartSigningEnforce = "enforce"
+)
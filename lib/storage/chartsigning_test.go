@@ -0,0 +1,92 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	check "gopkg.in/check.v1"
+)
+
+type ChartSigningPolicySuite struct {
+	entity    *openpgp.Entity
+	publicKey string
+}
+
+var _ = check.Suite(&ChartSigningPolicySuite{})
+
+func (s *ChartSigningPolicySuite) SetUpSuite(c *check.C) {
+	var err error
+	s.entity, err = openpgp.NewEntity("Test Signer", "", "signer@example.com", nil)
+	c.Assert(err, check.IsNil)
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(s.entity.Serialize(w), check.IsNil)
+	c.Assert(w.Close(), check.IsNil)
+	s.publicKey = buf.String()
+}
+
+func (s *ChartSigningPolicySuite) TestResourceParsing(c *check.C) {
+	policy, err := UnmarshalChartSigningPolicy(s.policy(c, "", s.publicKey))
+	c.Assert(err, check.IsNil)
+	c.Assert(policy.GetName(), check.Equals, KindChartSigningPolicy)
+	c.Assert(policy.GetMode(), check.Equals, ChartSigningWarn)
+	c.Assert(policy.IsEnforced(), check.Equals, false)
+
+	keyring, err := policy.GetKeyRing()
+	c.Assert(err, check.IsNil)
+	c.Assert(keyring, check.HasLen, 1)
+	c.Assert(keyring[0].PrimaryKey.KeyId, check.Equals, s.entity.PrimaryKey.KeyId)
+
+	policy, err = UnmarshalChartSigningPolicy(s.policy(c, ChartSigningEnforce, s.publicKey))
+	c.Assert(err, check.IsNil)
+	c.Assert(policy.IsEnforced(), check.Equals, true)
+}
+
+func (s *ChartSigningPolicySuite) TestValidatesResource(c *check.C) {
+	_, err := UnmarshalChartSigningPolicy(s.policy(c, "audit", s.publicKey))
+	c.Assert(err, check.ErrorMatches, `unsupported mode "audit".*`)
+
+	_, err = UnmarshalChartSigningPolicy(s.policy(c, "", "not a key"))
+	c.Assert(err, check.ErrorMatches, `failed to parse trusted key "release".*`)
+
+	_, err = UnmarshalChartSigningPolicy([]byte(`kind: chartsigningpolicy
+version: v1
+spec:
+  trusted_keys: []`))
+	c.Assert(err, check.ErrorMatches, "at least one trusted key is required")
+}
+
+func (s *ChartSigningPolicySuite) policy(c *check.C, mode, publicKey string) []byte {
+	key, err := json.Marshal(publicKey)
+	c.Assert(err, check.IsNil)
+	spec := `kind: chartsigningpolicy
+version: v1
+spec:
+  trusted_keys:
+  - name: release
+    public_key: ` + string(key)
+	if mode != "" {
+		spec = strings.Replace(spec, "spec:\n", "spec:\n  mode: "+mode+"\n", 1)
+	}
+	return []byte(spec)
+}
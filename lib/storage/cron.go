@@ -0,0 +1,185 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// cronSchedule is a parsed cron expression in the standard 5-field format:
+//
+//   minute hour day-of-month month day-of-week
+//
+// Each field accepts '*', single values, ranges (1-5), steps (*/15, 1-10/2, 5/15)
+// and comma-separated lists of those. A step after a single value applies
+// from that value up to the maximum of the field
+type cronSchedule struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// domAny and dowAny are true when the respective day fields are '*'
+	domAny bool
+	dowAny bool
+}
+
+// cronField describes the range of values of a cron expression field
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	// 7 is an alias for Sunday
+	{name: "day of week", min: 0, max: 7},
+}
+
+// parseCronSchedule parses the specified cron expression
+func parseCronSchedule(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, trace.BadParameter("expected %v fields in schedule %q, got %v",
+			len(cronFields), expr, len(fields))
+	}
+	var bits [5]uint64
+	for i, field := range fields {
+		var err error
+		bits[i], err = parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, trace.Wrap(err, "invalid schedule %q", expr)
+		}
+	}
+	// Fold Sunday as 7 into 0
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+	return &cronSchedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, spec cronField) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		hasStep := false
+		if i := strings.Index(part, "/"); i != -1 {
+			hasStep = true
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, trace.BadParameter("invalid step in %v field %q", spec.name, field)
+			}
+			part = part[:i]
+		}
+		low, high := spec.min, spec.max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, trace.BadParameter("invalid %v field %q", spec.name, field)
+			}
+			if high, err = strconv.Atoi(bounds[1]); err != nil {
+				return 0, trace.BadParameter("invalid %v field %q", spec.name, field)
+			}
+		default:
+			if low, err = strconv.Atoi(part); err != nil {
+				return 0, trace.BadParameter("invalid %v field %q", spec.name, field)
+			}
+			if !hasStep {
+				high = low
+			}
+		}
+		if low < spec.min || high > spec.max || low > high {
+			return 0, trace.BadParameter("%v field %q is out of range [%v-%v]",
+				spec.name, field, spec.min, spec.max)
+		}
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+// next returns the earliest time not before the specified time (truncated
+// to the minute) that matches the schedule.
+// Returns zero time if there's no match within the search horizon
+func (s cronSchedule) next(t time.Time) time.Time {
+	if t.Truncate(time.Minute) != t {
+		t = t.Truncate(time.Minute).Add(time.Minute)
+	}
+	horizon := t.AddDate(cronSearchHorizonYears, 0, 0)
+	for t.Before(horizon) {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchesDay returns true if the day of the specified time matches the schedule.
+// As with cron, if both day of month and day of week are restricted,
+// the day matches if either of them matches
+func (s cronSchedule) matchesDay(t time.Time) bool {
+	dom := has(s.dom, t.Day())
+	dow := has(s.dow, int(t.Weekday()))
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+func has(bits uint64, value int) bool {
+	return bits&(1<<uint(value)) != 0
+}
+
+// cronSearchHorizonYears limits the search for the next schedule match
+const cronSearchHorizonYears = 5
@@ -0,0 +1,313 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gravitational/gravity/lib/utils"
+
+	teledefaults "github.com/gravitational/teleport/lib/defaults"
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// HelmRepository defines an external chart repository that is
+// periodically imported into the cluster catalog
+type HelmRepository interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults validates the resource and fills in some defaults
+	CheckAndSetDefaults() error
+	// GetURL returns the repository URL
+	GetURL() string
+	// GetUsername returns the username for repository basic auth
+	GetUsername() string
+	// GetPassword returns the password for repository basic auth
+	GetPassword() string
+	// GetCharts returns the names of charts to import, empty means all
+	GetCharts() []string
+	// HasChart returns true if the chart with the specified name should be imported
+	HasChart(name string) bool
+	// GetMaxVersions returns the number of latest versions imported per chart
+	GetMaxVersions() int
+	// GetSyncInterval returns how often the repository is synced
+	GetSyncInterval() time.Duration
+	// IsSyncDue returns true if the repository should be synced at the specified time
+	IsSyncDue(now time.Time) bool
+	// GetStatus returns the repository sync status
+	GetStatus() HelmRepositoryStatus
+	// SetStatus sets the repository sync status
+	SetStatus(HelmRepositoryStatus)
+	// WithoutSecrets returns a copy of the repository without the password
+	WithoutSecrets() HelmRepository
+}
+
+// NewHelmRepository creates a new helm repository resource from the provided spec
+func NewHelmRepository(name string, spec HelmRepositorySpecV1) HelmRepository {
+	return &HelmRepositoryV1{
+		Kind:    KindHelmRepository,
+		Version: teleservices.V1,
+		Metadata: teleservices.Metadata{
+			Name:      name,
+			Namespace: teledefaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// HelmRepositoryV1 defines the helm repository resource
+type HelmRepositoryV1 struct {
+	// Kind is the resource kind
+	Kind string `json:"kind"`
+	// Version is the resource version
+	Version string `json:"version"`
+	// Metadata is the resource metadata
+	Metadata teleservices.Metadata `json:"metadata"`
+	// Spec is the resource specification
+	Spec HelmRepositorySpecV1 `json:"spec"`
+	// Status is the repository sync status
+	Status HelmRepositoryStatus `json:"status,omitempty"`
+}
+
+// HelmRepositorySpecV1 defines the helm repository resource specification
+type HelmRepositorySpecV1 struct {
+	// URL is the repository URL, the index is expected at <url>/index.yaml
+	URL string `json:"url"`
+	// Username is the optional username for basic auth
+	Username string `json:"username,omitempty"`
+	// Password is the optional password for basic auth
+	Password string `json:"password,omitempty"`
+	// Charts lists names of charts to import, all charts are imported if empty
+	Charts []string `json:"charts,omitempty"`
+	// MaxVersions is the number of latest versions imported per chart
+	MaxVersions int `json:"max_versions,omitempty"`
+	// SyncInterval defines how often the repository is synced
+	SyncInterval teleservices.Duration `json:"sync_interval,omitempty"`
+}
+
+// HelmRepositoryStatus describes the result of the last repository sync
+type HelmRepositoryStatus struct {
+	// LastSynced is the time of the last sync attempt
+	LastSynced time.Time `json:"last_synced,omitempty"`
+	// Error is the error of the last sync attempt, if any
+	Error string `json:"error,omitempty"`
+	// Imported lists applications imported from the repository
+	Imported []string `json:"imported,omitempty"`
+}
+
+// GetURL returns the repository URL
+func (r *HelmRepositoryV1) GetURL() string {
+	return r.Spec.URL
+}
+
+// GetUsername returns the username for repository basic auth
+func (r *HelmRepositoryV1) GetUsername() string {
+	return r.Spec.Username
+}
+
+// GetPassword returns the password for repository basic auth
+func (r *HelmRepositoryV1) GetPassword() string {
+	return r.Spec.Password
+}
+
+// GetCharts returns the names of charts to import, empty means all
+func (r *HelmRepositoryV1) GetCharts() []string {
+	return r.Spec.Charts
+}
+
+// HasChart returns true if the chart with the specified name should be imported
+func (r *HelmRepositoryV1) HasChart(name string) bool {
+	return len(r.Spec.Charts) == 0 || utils.StringInSlice(r.Spec.Charts, name)
+}
+
+// GetMaxVersions returns the number of latest versions imported per chart
+func (r *HelmRepositoryV1) GetMaxVersions() int {
+	return r.Spec.MaxVersions
+}
+
+// GetSyncInterval returns how often the repository is synced
+func (r *HelmRepositoryV1) GetSyncInterval() time.Duration {
+	return r.Spec.SyncInterval.Value()
+}
+
+// IsSyncDue returns true if the repository should be synced at the specified time
+func (r *HelmRepositoryV1) IsSyncDue(now time.Time) bool {
+	return r.Status.LastSynced.IsZero() ||
+		!now.Before(r.Status.LastSynced.Add(r.GetSyncInterval()))
+}
+
+// GetStatus returns the repository sync status
+func (r *HelmRepositoryV1) GetStatus() HelmRepositoryStatus {
+	return r.Status
+}
+
+// SetStatus sets the repository sync status
+func (r *HelmRepositoryV1) SetStatus(status HelmRepositoryStatus) {
+	r.Status = status
+}
+
+// WithoutSecrets returns a copy of the repository without the password
+func (r *HelmRepositoryV1) WithoutSecrets() HelmRepository {
+	if r.Spec.Password == "" {
+		return r
+	}
+	out := *r
+	out.Spec.Password = ""
+	return &out
+}
+
+// CheckAndSetDefaults validates the resource and fills in some defaults
+func (r *HelmRepositoryV1) CheckAndSetDefaults() error {
+	if r.Metadata.Name == "" {
+		return trace.BadParameter("repository name cannot be empty")
+	}
+	if err := r.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.Spec.URL == "" {
+		return trace.BadParameter("repository URL cannot be empty")
+	}
+	u, err := url.Parse(r.Spec.URL)
+	if err != nil {
+		return trace.BadParameter("invalid repository URL %q: %v", r.Spec.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return trace.BadParameter("repository URL %q must be http or https", r.Spec.URL)
+	}
+	if r.Spec.MaxVersions < 0 {
+		return trace.BadParameter("max_versions cannot be negative")
+	}
+	if r.Spec.MaxVersions == 0 {
+		r.Spec.MaxVersions = 1
+	}
+	if r.Spec.SyncInterval.Value() < 0 {
+		return trace.BadParameter("sync_interval cannot be negative")
+	}
+	if r.Spec.SyncInterval.Value() == 0 {
+		r.Spec.SyncInterval = teleservices.NewDuration(defaultHelmRepositorySyncInterval)
+	}
+	return nil
+}
+
+// GetName returns the resource name
+func (r *HelmRepositoryV1) GetName() string {
+	return r.Metadata.Name
+}
+
+// SetName sets the resource name
+func (r *HelmRepositoryV1) SetName(name string) {
+	r.Metadata.Name = name
+}
+
+// GetMetadata returns the resource metadata
+func (r *HelmRepositoryV1) GetMetadata() teleservices.Metadata {
+	return r.Metadata
+}
+
+// SetExpiry sets the resource expiration time
+func (r *HelmRepositoryV1) SetExpiry(expires time.Time) {
+	r.Metadata.SetExpiry(expires)
+}
+
+// Expiry returns the resource expiration time
+func (r *HelmRepositoryV1) Expiry() time.Time {
+	return r.Metadata.Expiry()
+}
+
+// SetTTL sets the resource TTL
+func (r *HelmRepositoryV1) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	r.Metadata.SetTTL(clock, ttl)
+}
+
+// String returns the object's string representation
+func (r HelmRepositoryV1) String() string {
+	return fmt.Sprintf("HelmRepository(Name=%v, URL=%v, Charts=%v, MaxVersions=%v)",
+		r.Metadata.Name, r.Spec.URL, r.Spec.Charts, r.Spec.MaxVersions)
+}
+
+// UnmarshalHelmRepository unmarshals helm repository resource from JSON or YAML
+func UnmarshalHelmRepository(data []byte) (HelmRepository, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty input")
+	}
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var header teleservices.ResourceHeader
+	if err := json.Unmarshal(jsonData, &header); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch header.Version {
+	case teleservices.V1:
+		var repository HelmRepositoryV1
+		err := teleutils.UnmarshalWithSchema(GetHelmRepositorySchema(), &repository, jsonData)
+		if err != nil {
+			return nil, trace.BadParameter(err.Error())
+		}
+		if err := repository.CheckAndSetDefaults(); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &repository, nil
+	}
+	return nil, trace.BadParameter("%v resource version %q is not supported",
+		KindHelmRepository, header.Version)
+}
+
+// MarshalHelmRepository marshals helm repository resource to JSON
+func MarshalHelmRepository(repository HelmRepository, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(repository)
+}
+
+// GetHelmRepositorySchema returns the full helm repository resource schema
+func GetHelmRepositorySchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, MetadataSchema,
+		HelmRepositoryV1Schema, "")
+}
+
+// HelmRepositoryV1Schema defines the helm repository spec and status schema
+const HelmRepositoryV1Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["url"],
+  "properties": {
+    "url": {"type": "string"},
+    "username": {"type": "string"},
+    "password": {"type": "string"},
+    "charts": {"type": "array", "items": {"type": "string"}},
+    "max_versions": {"type": "integer"},
+    "sync_interval": {"type": "string"}
+  }
+},
+"status": {
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "last_synced": {"type": "string"},
+    "error": {"type": "string"},
+    "imported": {"type": "array", "items": {"type": "string"}}
+  }
+}`
+
+// defaultHelmRepositorySyncInterval is how often a repository is synced by default
+const defaultHelmRepositorySyncInterval = 24 * time.Hour
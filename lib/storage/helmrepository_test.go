@@ -0,0 +1,128 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	check "gopkg.in/check.v1"
+)
+
+type HelmRepositorySuite struct{}
+
+var _ = check.Suite(&HelmRepositorySuite{})
+
+func (s *HelmRepositorySuite) TestResourceParsing(c *check.C) {
+	repository, err := UnmarshalHelmRepository([]byte(`kind: helmrepository
+version: v1
+metadata:
+  name: stable
+spec:
+  url: https://charts.example.com/stable
+  charts: [nginx, redis]`))
+	c.Assert(err, check.IsNil)
+	c.Assert(repository.GetName(), check.Equals, "stable")
+	c.Assert(repository.GetURL(), check.Equals, "https://charts.example.com/stable")
+	c.Assert(repository.GetMaxVersions(), check.Equals, 1)
+	c.Assert(repository.GetSyncInterval(), check.Equals, defaultHelmRepositorySyncInterval)
+	c.Assert(repository.HasChart("nginx"), check.Equals, true)
+	c.Assert(repository.HasChart("kafka"), check.Equals, false)
+
+	repository, err = UnmarshalHelmRepository([]byte(`kind: helmrepository
+version: v1
+metadata:
+  name: internal
+spec:
+  url: http://charts.local
+  username: user
+  password: secret
+  max_versions: 3
+  sync_interval: 1h`))
+	c.Assert(err, check.IsNil)
+	c.Assert(repository.GetUsername(), check.Equals, "user")
+	c.Assert(repository.GetPassword(), check.Equals, "secret")
+	c.Assert(repository.GetMaxVersions(), check.Equals, 3)
+	c.Assert(repository.GetSyncInterval(), check.Equals, time.Hour)
+	c.Assert(repository.HasChart("kafka"), check.Equals, true)
+}
+
+func (s *HelmRepositorySuite) TestValidatesResource(c *check.C) {
+	testCases := []struct {
+		spec    string
+		comment string
+		err     string
+	}{
+		{
+			spec:    `url: ftp://charts.example.com`,
+			comment: "unsupported scheme",
+			err:     `repository URL "ftp://charts.example.com" must be http or https`,
+		},
+		{
+			spec:    `url: https://charts.example.com, max_versions: -1`,
+			comment: "negative max versions",
+			err:     "max_versions cannot be negative",
+		},
+		{
+			spec:    `charts: [nginx]`,
+			comment: "missing URL",
+			err:     ".*url is required.*",
+		},
+	}
+	for _, tc := range testCases {
+		_, err := UnmarshalHelmRepository([]byte(`kind: helmrepository
+version: v1
+metadata:
+  name: stable
+spec: {` + tc.spec + `}`))
+		c.Assert(err, check.ErrorMatches, tc.err, check.Commentf(tc.comment))
+	}
+}
+
+func (s *HelmRepositorySuite) TestPreservesStatus(c *check.C) {
+	repository := NewHelmRepository("stable", HelmRepositorySpecV1{
+		URL: "https://charts.example.com/stable",
+	})
+	c.Assert(repository.CheckAndSetDefaults(), check.IsNil)
+	now := time.Date(2019, time.March, 1, 12, 0, 0, 0, time.UTC)
+	c.Assert(repository.IsSyncDue(now), check.Equals, true)
+
+	repository.SetStatus(HelmRepositoryStatus{
+		LastSynced: now,
+		Imported:   []string{"gravitational.io/nginx:0.2.0"},
+	})
+	data, err := MarshalHelmRepository(repository)
+	c.Assert(err, check.IsNil)
+	repository, err = UnmarshalHelmRepository(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(repository.GetStatus().LastSynced.Equal(now), check.Equals, true)
+	c.Assert(repository.GetStatus().Imported, check.DeepEquals, []string{"gravitational.io/nginx:0.2.0"})
+	c.Assert(repository.IsSyncDue(now.Add(time.Hour)), check.Equals, false)
+	c.Assert(repository.IsSyncDue(now.Add(defaultHelmRepositorySyncInterval)), check.Equals, true)
+}
+
+func (s *HelmRepositorySuite) TestRemovesSecrets(c *check.C) {
+	repository := NewHelmRepository("internal", HelmRepositorySpecV1{
+		URL:      "https://charts.example.com",
+		Username: "user",
+		Password: "secret",
+	})
+	withoutSecrets := repository.WithoutSecrets()
+	c.Assert(withoutSecrets.GetUsername(), check.Equals, "user")
+	c.Assert(withoutSecrets.GetPassword(), check.Equals, "")
+	// the original resource is not modified
+	c.Assert(repository.GetPassword(), check.Equals, "secret")
+}
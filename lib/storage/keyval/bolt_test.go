@@ -149,6 +149,10 @@ func (s *BSuite) TestOperationsCRUD(c *C) {
 	s.suite.OperationsCRUD(c)
 }
 
+func (s *BSuite) TestHookAttemptsCRUD(c *C) {
+	s.suite.HookAttemptsCRUD(c)
+}
+
 func (s *BSuite) TestCreatesApplication(c *C) {
 	s.suite.CreatesApplication(c)
 }
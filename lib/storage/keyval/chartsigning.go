@@ -0,0 +1,60 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyval
+
+import (
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// GetChartSigningPolicy returns the cluster chart signing policy
+func (b *backend) GetChartSigningPolicy() (storage.ChartSigningPolicy, error) {
+	data, err := b.getValBytes(b.key(chartSigningPolicyP, valP))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("chart signing policy not found")
+		}
+		return nil, trace.Wrap(err)
+	}
+	return storage.UnmarshalChartSigningPolicy(data)
+}
+
+// UpsertChartSigningPolicy creates or replaces the cluster chart signing policy
+func (b *backend) UpsertChartSigningPolicy(policy storage.ChartSigningPolicy) error {
+	data, err := storage.MarshalChartSigningPolicy(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = b.upsertValBytes(b.key(chartSigningPolicyP, valP), data, forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// DeleteChartSigningPolicy deletes the cluster chart signing policy
+func (b *backend) DeleteChartSigningPolicy() error {
+	err := b.deleteKey(b.key(chartSigningPolicyP, valP))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("chart signing policy not found")
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
@@ -37,6 +37,7 @@ const (
 	operationsP                 = "ops"
 	appOperationsP              = "appops"
 	changelogP                  = "changelog"
+	hookAttemptsP               = "hookattempts"
 	activeOperationsP           = "activeops"
 	repositoriesP               = "repos"
 	packagesP                   = "packages"
@@ -77,6 +78,9 @@ const (
 	dnsP                        = "dns"
 	chartsP                     = "charts"
 	indexP                      = "index"
+	maintenanceWindowP          = "maintenancewindow"
+	chartSigningPolicyP         = "chartsigningpolicy"
+	helmRepositoriesP           = "helmrepositories"
 
 	// AllCollectionIDs identifies a collection without a specification (an ID)
 	AllCollectionIDs = "__all__"
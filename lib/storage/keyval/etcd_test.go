@@ -173,6 +173,10 @@ func (s *ESuite) TestOperationsCRUD(c *C) {
 	s.suite.OperationsCRUD(c)
 }
 
+func (s *ESuite) TestHookAttemptsCRUD(c *C) {
+	s.suite.HookAttemptsCRUD(c)
+}
+
 func (s *ESuite) TestCreatesApplication(c *C) {
 	s.suite.CreatesApplication(c)
 }
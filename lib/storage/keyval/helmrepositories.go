@@ -0,0 +1,77 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyval
+
+import (
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// GetHelmRepositories returns all configured helm repositories
+func (b *backend) GetHelmRepositories() ([]storage.HelmRepository, error) {
+	names, err := b.getKeys(b.key(helmRepositoriesP))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var repositories []storage.HelmRepository
+	for _, name := range names {
+		repository, err := b.GetHelmRepository(name)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		repositories = append(repositories, repository)
+	}
+	return repositories, nil
+}
+
+// GetHelmRepository returns the helm repository with the specified name
+func (b *backend) GetHelmRepository(name string) (storage.HelmRepository, error) {
+	data, err := b.getValBytes(b.key(helmRepositoriesP, name))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("helm repository %q not found", name)
+		}
+		return nil, trace.Wrap(err)
+	}
+	return storage.UnmarshalHelmRepository(data)
+}
+
+// UpsertHelmRepository creates or replaces the helm repository
+func (b *backend) UpsertHelmRepository(repository storage.HelmRepository) error {
+	data, err := storage.MarshalHelmRepository(repository)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = b.upsertValBytes(b.key(helmRepositoriesP, repository.GetName()), data, forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// DeleteHelmRepository deletes the helm repository with the specified name
+func (b *backend) DeleteHelmRepository(name string) error {
+	err := b.deleteKey(b.key(helmRepositoriesP, name))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("helm repository %q not found", name)
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
@@ -204,6 +204,44 @@ func (b *backend) GetOperationPlanChangelog(clusterName, operationID string) (st
 	return storage.PlanChangelog(out), nil
 }
 
+// CreateHookAttempt records a single execution attempt of an application hook
+func (b *backend) CreateHookAttempt(attempt storage.HookAttempt) (*storage.HookAttempt, error) {
+	if attempt.ID == "" {
+		attempt.ID = uuid.New()
+	}
+	err := b.upsertVal(b.key(sitesP, attempt.ClusterName, operationsP,
+		attempt.OperationID, hookAttemptsP, attempt.ID, valP), attempt, forever)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &attempt, nil
+}
+
+// GetHookAttempts returns all hook attempts recorded for an operation
+// sorted by start time
+func (b *backend) GetHookAttempts(clusterName, operationID string) ([]storage.HookAttempt, error) {
+	ids, err := b.getKeys(b.key(sitesP, clusterName, operationsP, operationID, hookAttemptsP))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var out []storage.HookAttempt
+	for _, id := range ids {
+		var attempt storage.HookAttempt
+		err = b.getVal(b.key(sitesP, clusterName, operationsP, operationID,
+			hookAttemptsP, id, valP), &attempt)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		utils.UTC(&attempt.Started)
+		utils.UTC(&attempt.Completed)
+		out = append(out, attempt)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Started.Before(out[j].Started)
+	})
+	return out, nil
+}
+
 // CreateAppOperation creates a new application operation
 func (b *backend) CreateAppOperation(op storage.AppOperation) (*storage.AppOperation, error) {
 	err := op.Check()
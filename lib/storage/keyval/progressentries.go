@@ -17,6 +17,8 @@ limitations under the License.
 package keyval
 
 import (
+	"sort"
+
 	"github.com/gravitational/gravity/lib/storage"
 
 	"github.com/gravitational/trace"
@@ -75,6 +77,55 @@ func (b *backend) GetLastProgressEntry(siteDomain, operationID string) (*storage
 	return p, nil
 }
 
+func (b *backend) GetProgressEntries(siteDomain, operationID string) ([]storage.ProgressEntry, error) {
+	if siteDomain == "" {
+		return nil, trace.BadParameter("missing site domain")
+	}
+	if operationID == "" {
+		return nil, trace.BadParameter("missing operation id")
+	}
+	ids, err := b.getKeys(b.key(sitesP, siteDomain, operationsP, operationID, progressP))
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	var out []storage.ProgressEntry
+	for _, id := range ids {
+		var e storage.ProgressEntry
+		err := b.getVal(b.key(sitesP, siteDomain, operationsP, operationID, progressP, id), &e)
+		if err != nil {
+			if trace.IsNotFound(err) {
+				continue
+			}
+			return nil, trace.Wrap(err)
+		}
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Created.Before(out[j].Created)
+	})
+	return out, nil
+}
+
+func (b *backend) DeleteProgressEntry(siteDomain, operationID, id string) error {
+	if siteDomain == "" {
+		return trace.BadParameter("missing site domain")
+	}
+	if operationID == "" {
+		return trace.BadParameter("missing operation id")
+	}
+	if id == "" {
+		return trace.BadParameter("missing progress entry id")
+	}
+	err := b.deleteKey(b.key(sitesP, siteDomain, operationsP, operationID, progressP, id))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("progress entry %v not found", id)
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
 func (b *backend) CreateAppProgressEntry(p storage.AppProgressEntry) (*storage.AppProgressEntry, error) {
 	err := p.Check()
 	if err != nil {
@@ -0,0 +1,366 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	teledefaults "github.com/gravitational/teleport/lib/defaults"
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// MaintenanceWindow defines a resource that restricts the time when
+// disruptive cluster operations are allowed to run
+type MaintenanceWindow interface {
+	// Resource provides common resource methods
+	teleservices.Resource
+	// CheckAndSetDefaults validates the resource and fills in some defaults
+	CheckAndSetDefaults() error
+	// GetTimezone returns the name of the timezone the schedule is defined in
+	GetTimezone() string
+	// GetWindows returns the list of recurring windows
+	GetWindows() []MaintenanceWindowSchedule
+	// GetBlackoutDates returns the list of dates when no window opens
+	GetBlackoutDates() []string
+	// IsOpen returns true if the specified time falls within a window
+	IsOpen(time.Time) (bool, error)
+	// NextOpen returns the earliest time not before the specified time
+	// that falls within a window.
+	// Returns zero time if no window opens within the search horizon
+	NextOpen(time.Time) (time.Time, error)
+}
+
+// NewMaintenanceWindow creates a new maintenance window resource from the provided spec
+func NewMaintenanceWindow(spec MaintenanceWindowSpecV1) MaintenanceWindow {
+	return &MaintenanceWindowV1{
+		Kind:    KindMaintenanceWindow,
+		Version: teleservices.V1,
+		Metadata: teleservices.Metadata{
+			Name:      KindMaintenanceWindow,
+			Namespace: teledefaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// MaintenanceWindowV1 defines the maintenance window resource
+type MaintenanceWindowV1 struct {
+	// Kind is the resource kind
+	Kind string `json:"kind"`
+	// Version is the resource version
+	Version string `json:"version"`
+	// Metadata is the resource metadata
+	Metadata teleservices.Metadata `json:"metadata"`
+	// Spec is the resource specification
+	Spec MaintenanceWindowSpecV1 `json:"spec"`
+}
+
+// MaintenanceWindowSpecV1 defines the maintenance window resource specification
+type MaintenanceWindowSpecV1 struct {
+	// Timezone is the name of the IANA timezone the windows are defined in.
+	// Defaults to UTC
+	Timezone string `json:"timezone,omitempty"`
+	// Windows lists the recurring windows
+	Windows []MaintenanceWindowSchedule `json:"windows"`
+	// BlackoutDates lists dates (YYYY-MM-DD) when no window opens
+	BlackoutDates []string `json:"blackout_dates,omitempty"`
+}
+
+// MaintenanceWindowSchedule defines a single recurring window
+type MaintenanceWindowSchedule struct {
+	// Schedule is a cron expression that defines when the window opens
+	Schedule string `json:"schedule"`
+	// Duration defines how long the window stays open
+	Duration teleservices.Duration `json:"duration"`
+}
+
+// GetTimezone returns the name of the timezone the schedule is defined in
+func (w *MaintenanceWindowV1) GetTimezone() string {
+	return w.Spec.Timezone
+}
+
+// GetWindows returns the list of recurring windows
+func (w *MaintenanceWindowV1) GetWindows() []MaintenanceWindowSchedule {
+	return w.Spec.Windows
+}
+
+// GetBlackoutDates returns the list of dates when no window opens
+func (w *MaintenanceWindowV1) GetBlackoutDates() []string {
+	return w.Spec.BlackoutDates
+}
+
+// IsOpen returns true if the specified time falls within a window
+func (w *MaintenanceWindowV1) IsOpen(now time.Time) (bool, error) {
+	schedule, err := w.parse()
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	return schedule.isOpen(now), nil
+}
+
+// NextOpen returns the earliest time not before the specified time
+// that falls within a window.
+// Returns zero time if no window opens within the search horizon
+func (w *MaintenanceWindowV1) NextOpen(now time.Time) (time.Time, error) {
+	schedule, err := w.parse()
+	if err != nil {
+		return time.Time{}, trace.Wrap(err)
+	}
+	return schedule.nextOpen(now), nil
+}
+
+// CheckAndSetDefaults validates the resource and fills in some defaults
+func (w *MaintenanceWindowV1) CheckAndSetDefaults() error {
+	if w.Metadata.Name == "" {
+		w.Metadata.Name = KindMaintenanceWindow
+	}
+	if err := w.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if w.Spec.Timezone == "" {
+		w.Spec.Timezone = time.UTC.String()
+	}
+	if len(w.Spec.Windows) == 0 {
+		return trace.BadParameter("at least one window is required")
+	}
+	for _, window := range w.Spec.Windows {
+		if window.Duration.Value() <= 0 {
+			return trace.BadParameter("window %q must have a positive duration",
+				window.Schedule)
+		}
+		if window.Duration.Value() > maxMaintenanceWindowDuration {
+			return trace.BadParameter("window %q cannot be longer than %v",
+				window.Schedule, maxMaintenanceWindowDuration)
+		}
+	}
+	_, err := w.parse()
+	return trace.Wrap(err)
+}
+
+// GetName returns the resource name
+func (w *MaintenanceWindowV1) GetName() string {
+	return w.Metadata.Name
+}
+
+// SetName sets the resource name
+func (w *MaintenanceWindowV1) SetName(name string) {
+	w.Metadata.Name = name
+}
+
+// GetMetadata returns the resource metadata
+func (w *MaintenanceWindowV1) GetMetadata() teleservices.Metadata {
+	return w.Metadata
+}
+
+// SetExpiry sets the resource expiration time
+func (w *MaintenanceWindowV1) SetExpiry(expires time.Time) {
+	w.Metadata.SetExpiry(expires)
+}
+
+// Expiry returns the resource expiration time
+func (w *MaintenanceWindowV1) Expiry() time.Time {
+	return w.Metadata.Expiry()
+}
+
+// SetTTL sets the resource TTL
+func (w *MaintenanceWindowV1) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	w.Metadata.SetTTL(clock, ttl)
+}
+
+// String returns the object's string representation
+func (w MaintenanceWindowV1) String() string {
+	return fmt.Sprintf("MaintenanceWindow(Timezone=%v, Windows=%v, BlackoutDates=%v)",
+		w.Spec.Timezone, w.Spec.Windows, w.Spec.BlackoutDates)
+}
+
+// String returns the window's string representation
+func (w MaintenanceWindowSchedule) String() string {
+	return fmt.Sprintf("%q for %v", w.Schedule, w.Duration.Value())
+}
+
+func (w *MaintenanceWindowV1) parse() (*maintenanceSchedule, error) {
+	timezone := w.Spec.Timezone
+	if timezone == "" {
+		timezone = time.UTC.String()
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, trace.BadParameter("invalid timezone %q: %v", timezone, err)
+	}
+	schedule := &maintenanceSchedule{
+		location:  location,
+		blackouts: make(map[string]struct{}),
+	}
+	for _, window := range w.Spec.Windows {
+		cron, err := parseCronSchedule(window.Schedule)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		schedule.windows = append(schedule.windows, scheduledWindow{
+			schedule: *cron,
+			duration: window.Duration.Value(),
+		})
+	}
+	for _, date := range w.Spec.BlackoutDates {
+		parsed, err := time.ParseInLocation(blackoutDateFormat, date, location)
+		if err != nil {
+			return nil, trace.BadParameter("invalid blackout date %q, expected YYYY-MM-DD", date)
+		}
+		schedule.blackouts[parsed.Format(blackoutDateFormat)] = struct{}{}
+	}
+	return schedule, nil
+}
+
+// maintenanceSchedule is the parsed form of the maintenance window resource
+type maintenanceSchedule struct {
+	location  *time.Location
+	windows   []scheduledWindow
+	blackouts map[string]struct{}
+}
+
+type scheduledWindow struct {
+	schedule cronSchedule
+	duration time.Duration
+}
+
+// isOpen returns true if the specified time is within one of the windows
+// and does not fall on a blackout date
+func (s maintenanceSchedule) isOpen(t time.Time) bool {
+	t = t.In(s.location)
+	if s.isBlackout(t) {
+		return false
+	}
+	for _, window := range s.windows {
+		// The window is open if it started after t-duration and not after t
+		start := window.schedule.next(t.Add(-window.duration).Add(time.Nanosecond))
+		if !start.IsZero() && !start.After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextOpen returns the earliest time not before the specified time
+// at which a window is open
+func (s maintenanceSchedule) nextOpen(t time.Time) time.Time {
+	t = t.In(s.location)
+	// Each iteration either skips a blackout day or advances to the next
+	// window start so the number of iterations is bounded
+	for i := 0; i < maxMaintenanceScheduleIterations; i++ {
+		if s.isOpen(t) {
+			return t
+		}
+		if s.isBlackout(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		var next time.Time
+		for _, window := range s.windows {
+			start := window.schedule.next(t.Truncate(time.Minute).Add(time.Minute))
+			if !start.IsZero() && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+		if next.IsZero() {
+			return next
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+func (s maintenanceSchedule) isBlackout(t time.Time) bool {
+	_, ok := s.blackouts[t.In(s.location).Format(blackoutDateFormat)]
+	return ok
+}
+
+// UnmarshalMaintenanceWindow unmarshals maintenance window resource from JSON or YAML
+func UnmarshalMaintenanceWindow(data []byte) (MaintenanceWindow, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("empty input")
+	}
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var header teleservices.ResourceHeader
+	if err := json.Unmarshal(jsonData, &header); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch header.Version {
+	case teleservices.V1:
+		var window MaintenanceWindowV1
+		err := teleutils.UnmarshalWithSchema(GetMaintenanceWindowSchema(), &window, jsonData)
+		if err != nil {
+			return nil, trace.BadParameter(err.Error())
+		}
+		if err := window.CheckAndSetDefaults(); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &window, nil
+	}
+	return nil, trace.BadParameter("%v resource version %q is not supported",
+		KindMaintenanceWindow, header.Version)
+}
+
+// MarshalMaintenanceWindow marshals maintenance window resource to JSON
+func MarshalMaintenanceWindow(window MaintenanceWindow, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(window)
+}
+
+// GetMaintenanceWindowSchema returns the full maintenance window resource schema
+func GetMaintenanceWindowSchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, MetadataSchema,
+		MaintenanceWindowSpecV1Schema, "")
+}
+
+// MaintenanceWindowSpecV1Schema defines the maintenance window spec schema
+const MaintenanceWindowSpecV1Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["windows"],
+  "properties": {
+    "timezone": {"type": "string"},
+    "windows": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["schedule", "duration"],
+        "properties": {
+          "schedule": {"type": "string"},
+          "duration": {"type": "string"}
+        }
+      }
+    },
+    "blackout_dates": {"type": "array", "items": {"type": "string"}}
+  }
+}`
+
+const (
+	// blackoutDateFormat is the format of blackout dates
+	blackoutDateFormat = "2006-01-02"
+	// maxMaintenanceWindowDuration is the maximum duration of a single window
+	maxMaintenanceWindowDuration = 7 * 24 * time.Hour
+	// maxMaintenanceScheduleIterations limits the search for the next open window
+	maxMaintenanceScheduleIterations = 10000
+)
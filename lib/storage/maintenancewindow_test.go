@@ -0,0 +1,225 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/compare"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	check "gopkg.in/check.v1"
+)
+
+type MaintenanceWindowSuite struct{}
+
+var _ = check.Suite(&MaintenanceWindowSuite{})
+
+func (s *MaintenanceWindowSuite) TestResourceParsing(c *check.C) {
+	spec := `kind: maintenancewindow
+version: v1
+spec:
+  timezone: America/New_York
+  windows:
+  - schedule: "0 2 * * 6"
+    duration: 4h
+  blackout_dates: ["2019-10-12"]
+`
+	window, err := UnmarshalMaintenanceWindow([]byte(spec))
+	c.Assert(err, check.IsNil)
+	c.Assert(window, compare.DeepEquals, NewMaintenanceWindow(MaintenanceWindowSpecV1{
+		Timezone: "America/New_York",
+		Windows: []MaintenanceWindowSchedule{
+			{
+				Schedule: "0 2 * * 6",
+				Duration: teleservices.NewDuration(4 * time.Hour),
+			},
+		},
+		BlackoutDates: []string{"2019-10-12"},
+	}))
+}
+
+func (s *MaintenanceWindowSuite) TestValidation(c *check.C) {
+	testCases := []struct {
+		spec    MaintenanceWindowSpecV1
+		comment string
+	}{
+		{
+			spec:    MaintenanceWindowSpecV1{},
+			comment: "no windows",
+		},
+		{
+			spec: MaintenanceWindowSpecV1{
+				Windows: []MaintenanceWindowSchedule{{Schedule: "0 2 * * *"}},
+			},
+			comment: "zero duration",
+		},
+		{
+			spec: MaintenanceWindowSpecV1{
+				Windows: []MaintenanceWindowSchedule{{
+					Schedule: "0 2 * * *",
+					Duration: teleservices.NewDuration(8 * 24 * time.Hour),
+				}},
+			},
+			comment: "duration too long",
+		},
+		{
+			spec: MaintenanceWindowSpecV1{
+				Timezone: "Mars/Olympus_Mons",
+				Windows:  []MaintenanceWindowSchedule{newSchedule("0 2 * * *", time.Hour)},
+			},
+			comment: "invalid timezone",
+		},
+		{
+			spec: MaintenanceWindowSpecV1{
+				Windows:       []MaintenanceWindowSchedule{newSchedule("0 2 * * *", time.Hour)},
+				BlackoutDates: []string{"12/25/2019"},
+			},
+			comment: "invalid blackout date",
+		},
+		{
+			spec: MaintenanceWindowSpecV1{
+				Windows: []MaintenanceWindowSchedule{newSchedule("0 2 * *", time.Hour)},
+			},
+			comment: "invalid schedule",
+		},
+	}
+	for _, tc := range testCases {
+		err := NewMaintenanceWindow(tc.spec).CheckAndSetDefaults()
+		c.Assert(err, check.NotNil, check.Commentf(tc.comment))
+	}
+}
+
+func (s *MaintenanceWindowSuite) TestCronSchedule(c *check.C) {
+	from := time.Date(2019, time.October, 1, 10, 30, 15, 0, time.UTC) // Tuesday
+	testCases := []struct {
+		expr    string
+		next    time.Time
+		comment string
+	}{
+		{
+			expr:    "* * * * *",
+			next:    time.Date(2019, time.October, 1, 10, 31, 0, 0, time.UTC),
+			comment: "rounds up to the next minute",
+		},
+		{
+			expr:    "*/20 * * * *",
+			next:    time.Date(2019, time.October, 1, 10, 40, 0, 0, time.UTC),
+			comment: "step",
+		},
+		{
+			expr:    "20/30 * * * *",
+			next:    time.Date(2019, time.October, 1, 10, 50, 0, 0, time.UTC),
+			comment: "value with step runs up to the maximum",
+		},
+		{
+			expr:    "0 9-17/4 * * *",
+			next:    time.Date(2019, time.October, 1, 13, 0, 0, 0, time.UTC),
+			comment: "range with step",
+		},
+		{
+			expr:    "0 0 * * 7",
+			next:    time.Date(2019, time.October, 6, 0, 0, 0, 0, time.UTC),
+			comment: "7 is Sunday",
+		},
+		{
+			expr:    "0 0 15 * 5",
+			next:    time.Date(2019, time.October, 4, 0, 0, 0, 0, time.UTC),
+			comment: "either day of month or day of week",
+		},
+		{
+			expr:    "0 0 29 2 *",
+			next:    time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC),
+			comment: "leap day",
+		},
+		{
+			expr:    "0 0 31 4 *",
+			comment: "never matches",
+		},
+	}
+	for _, tc := range testCases {
+		schedule, err := parseCronSchedule(tc.expr)
+		c.Assert(err, check.IsNil, check.Commentf(tc.comment))
+		c.Assert(schedule.next(from), check.DeepEquals, tc.next, check.Commentf(tc.comment))
+	}
+
+	for _, expr := range []string{"60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := parseCronSchedule(expr)
+		c.Assert(err, check.NotNil, check.Commentf(expr))
+	}
+}
+
+func (s *MaintenanceWindowSuite) TestWindows(c *check.C) {
+	// Saturdays 02:00-06:00 in New York which is 06:00-10:00 UTC in October
+	window := NewMaintenanceWindow(MaintenanceWindowSpecV1{
+		Timezone:      "America/New_York",
+		Windows:       []MaintenanceWindowSchedule{newSchedule("0 2 * * 6", 4*time.Hour)},
+		BlackoutDates: []string{"2019-10-12"},
+	})
+	c.Assert(window.CheckAndSetDefaults(), check.IsNil)
+
+	testCases := []struct {
+		now      time.Time
+		open     bool
+		nextOpen time.Time
+		comment  string
+	}{
+		{
+			now:      time.Date(2019, time.October, 5, 7, 30, 0, 0, time.UTC),
+			open:     true,
+			nextOpen: time.Date(2019, time.October, 5, 7, 30, 0, 0, time.UTC),
+			comment:  "inside the window",
+		},
+		{
+			now:      time.Date(2019, time.October, 5, 6, 0, 0, 0, time.UTC),
+			open:     true,
+			nextOpen: time.Date(2019, time.October, 5, 6, 0, 0, 0, time.UTC),
+			comment:  "window start",
+		},
+		{
+			now:      time.Date(2019, time.October, 5, 5, 59, 30, 0, time.UTC),
+			nextOpen: time.Date(2019, time.October, 5, 6, 0, 0, 0, time.UTC),
+			comment:  "right before the window",
+		},
+		{
+			now:      time.Date(2019, time.October, 5, 10, 0, 0, 0, time.UTC),
+			nextOpen: time.Date(2019, time.October, 19, 6, 0, 0, 0, time.UTC),
+			comment:  "window end, next window falls on a blackout date",
+		},
+		{
+			now:      time.Date(2019, time.October, 12, 7, 0, 0, 0, time.UTC),
+			nextOpen: time.Date(2019, time.October, 19, 6, 0, 0, 0, time.UTC),
+			comment:  "blackout date",
+		},
+	}
+	for _, tc := range testCases {
+		open, err := window.IsOpen(tc.now)
+		c.Assert(err, check.IsNil)
+		c.Assert(open, check.Equals, tc.open, check.Commentf(tc.comment))
+		next, err := window.NextOpen(tc.now)
+		c.Assert(err, check.IsNil)
+		c.Assert(next.Equal(tc.nextOpen), check.Equals, true,
+			check.Commentf("%v: expected %v, got %v", tc.comment, tc.nextOpen, next.UTC()))
+	}
+}
+
+func newSchedule(schedule string, duration time.Duration) MaintenanceWindowSchedule {
+	return MaintenanceWindowSchedule{
+		Schedule: schedule,
+		Duration: teleservices.NewDuration(duration),
+	}
+}
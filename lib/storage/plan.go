@@ -139,6 +139,50 @@ type ElectionChange struct {
 type GarbageCollectOperationData struct {
 	// RemoteApps lists remote applications known to cluster
 	RemoteApps []Application `json:"remote_apps,omitempty" yaml:"remote_apps,omitempty"`
+	// Retention specifies the retention policy for orphaned Kubernetes resources
+	Retention *RetentionPolicy `json:"retention,omitempty" yaml:"retention,omitempty"`
+}
+
+// QuotaPolicy defines which items of a versioned series (e.g. application
+// packages or operation logs) are kept by the garbage collector.
+// Items still in use are always kept
+type QuotaPolicy struct {
+	// KeepLast specifies the number of most recent items to keep in each series
+	KeepLast int `json:"keep_last,omitempty" yaml:"keep_last,omitempty"`
+	// KeepFor specifies the duration for which new items are kept
+	KeepFor time.Duration `json:"keep_for,omitempty" yaml:"keep_for,omitempty"`
+	// MaxSize caps the total size of the kept items.
+	// Oldest items are removed first when the cap is exceeded
+	MaxSize utils.Capacity `json:"max_size,omitempty" yaml:"max_size,omitempty"`
+}
+
+// Check validates this policy
+func (r QuotaPolicy) Check() error {
+	if r.KeepLast < 0 {
+		return trace.BadParameter("number of items to keep cannot be negative")
+	}
+	if r.KeepFor < 0 {
+		return trace.BadParameter("retention duration cannot be negative")
+	}
+	return nil
+}
+
+// IsEmpty returns true if this policy does not define any constraints
+func (r QuotaPolicy) IsEmpty() bool {
+	return r == QuotaPolicy{}
+}
+
+// RetentionPolicy defines which orphaned Kubernetes resources are kept
+// by the garbage collector
+type RetentionPolicy struct {
+	// MaxAge specifies the age after which an orphaned resource is removed
+	MaxAge time.Duration `json:"max_age,omitempty" yaml:"max_age,omitempty"`
+	// HookJobs specifies the number of most recent completed jobs to keep
+	// for each application hook
+	HookJobs int `json:"hook_jobs,omitempty" yaml:"hook_jobs,omitempty"`
+	// PruneReleaseResources enables removal of the resources left behind by
+	// uninstalled releases. Otherwise such resources are only reported
+	PruneReleaseResources bool `json:"prune_release_resources,omitempty" yaml:"prune_release_resources,omitempty"`
 }
 
 // UpdateOperationData describes configuration for update operations
@@ -147,6 +191,23 @@ type UpdateOperationData struct {
 	// The list might be a subset of all cluster servers in case
 	// the operation only operates on a specific part
 	Servers []UpdateServer `json:"updates,omitempty"`
+	// Strategy specifies the update strategy for phases that verify node health
+	Strategy *UpdateStrategy `json:"strategy,omitempty"`
+	// DrainPolicy specifies the policy for phases that drain nodes
+	DrainPolicy *DrainPolicy `json:"drain_policy,omitempty"`
+	// Hops lists the intermediate runtime updates of a multi-hop upgrade
+	Hops []UpdateHop `json:"hops,omitempty"`
+	// ChangesetID optionally specifies the ID of the system package changeset.
+	// Defaults to the operation ID
+	ChangesetID string `json:"changeset_id,omitempty"`
+}
+
+// UpdateHop describes an upgrade to an intermediate runtime
+type UpdateHop struct {
+	// Runtime identifies the intermediate runtime application
+	Runtime loc.Locator `json:"runtime"`
+	// Servers lists the server updates to the intermediate runtime
+	Servers []UpdateServer `json:"servers"`
 }
 
 // UpdateServer describes an intent to update runtime/teleport configuration
@@ -234,6 +295,37 @@ type PlanChange struct {
 	Error *trace.RawTrace `json:"error"`
 }
 
+// HookAttempt records a single execution attempt of an application hook
+// run by an operation
+type HookAttempt struct {
+	// ID is the attempt ID
+	ID string `json:"id"`
+	// ClusterName is the name of the cluster for the operation
+	ClusterName string `json:"cluster_name"`
+	// OperationID is the ID of the operation that ran the hook
+	OperationID string `json:"operation_id"`
+	// PhaseID is the ID of the plan phase that ran the hook
+	PhaseID string `json:"phase_id,omitempty"`
+	// Application is the application the hook belongs to
+	Application string `json:"application"`
+	// Hook is the hook type
+	Hook string `json:"hook"`
+	// Attempt is the attempt number, starting with 1
+	Attempt int `json:"attempt"`
+	// JobName is the name of the hook job
+	JobName string `json:"job_name,omitempty"`
+	// Server is the hostname of the server that ran the host hook script
+	Server string `json:"server,omitempty"`
+	// Started is when the attempt started
+	Started time.Time `json:"started"`
+	// Completed is when the attempt completed
+	Completed time.Time `json:"completed"`
+	// Error is the attempt failure, empty if the attempt succeeded
+	Error string `json:"error,omitempty"`
+	// Output is the hook output, truncated to the latest output if too long
+	Output string `json:"output"`
+}
+
 // PlanChangelog is a list of plan state changes
 type PlanChangelog []PlanChange
 
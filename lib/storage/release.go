@@ -53,6 +53,8 @@ type Release interface {
 	GetUpdated() time.Time
 	// GetLocator returns locator of the corresponding application package.
 	GetLocator() loc.Locator
+	// GetManifest returns the rendered manifest of the release.
+	GetManifest() string
 }
 
 // NewRelease creates a new release resource from the provided Helm release.
@@ -76,6 +78,7 @@ func NewRelease(release *release.Release) (Release, error) {
 			Revision: int(release.GetVersion()),
 			Updated:  time.Unix(release.GetInfo().GetLastDeployed().Seconds, 0),
 		},
+		Manifest: release.GetManifest(),
 	}, nil
 }
 
@@ -91,6 +94,8 @@ type ReleaseV1 struct {
 	Spec ReleaseSpecV1 `json:"spec"`
 	// Status provides runtime information about release.
 	Status ReleaseStatusV1 `json:"status"`
+	// Manifest is the rendered release manifest, it is not serialized.
+	Manifest string `json:"-"`
 }
 
 // ReleaseSpecV1 defines release resource spec.
@@ -168,6 +173,11 @@ func (r *ReleaseV1) GetLocator() loc.Locator {
 	}
 }
 
+// GetManifest returns the rendered manifest of the release.
+func (r *ReleaseV1) GetManifest() string {
+	return r.Manifest
+}
+
 // GetName returns the resource name.
 func (r *ReleaseV1) GetName() string {
 	return r.Metadata.Name
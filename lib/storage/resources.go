@@ -73,6 +73,12 @@ const (
 	KindRelease = "release"
 	// KindInvite defines the user invite token.
 	KindInvite = "invite"
+	// KindMaintenanceWindow defines the resource that restricts when cluster operations can run
+	KindMaintenanceWindow = "maintenancewindow"
+	// KindChartSigningPolicy defines the resource that controls verification of application charts
+	KindChartSigningPolicy = "chartsigningpolicy"
+	// KindHelmRepository defines an external chart repository imported into the cluster catalog
+	KindHelmRepository = "helmrepository"
 )
 
 // CanonicalKind translates the specified kind to canonical form.
@@ -105,6 +111,12 @@ func CanonicalKind(kind string) string {
 		return KindClusterConfiguration
 	case KindAuthGateway, "gw":
 		return KindAuthGateway
+	case KindMaintenanceWindow, "maintenancewindows", "mw":
+		return KindMaintenanceWindow
+	case KindChartSigningPolicy, "chartsigning", "signingpolicy":
+		return KindChartSigningPolicy
+	case KindHelmRepository, "helmrepositories", "helmrepo", "helmrepos":
+		return KindHelmRepository
 	}
 	return kind
 }
@@ -171,6 +183,9 @@ var SupportedGravityResources = []string{
 	KindAuthGateway,
 	KindRuntimeEnvironment,
 	KindClusterConfiguration,
+	KindMaintenanceWindow,
+	KindChartSigningPolicy,
+	KindHelmRepository,
 }
 
 // SupportedGravityResourcesToRemove is a list of resources supported by
@@ -186,6 +201,9 @@ var SupportedGravityResourcesToRemove = []string{
 	KindTLSKeyPair,
 	KindRuntimeEnvironment,
 	KindClusterConfiguration,
+	KindMaintenanceWindow,
+	KindChartSigningPolicy,
+	KindHelmRepository,
 }
 
 // MetadataSchema is a copy of teleport/lib/services.MetadataSchema but with
@@ -26,6 +26,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -45,6 +46,7 @@ import (
 	"github.com/gravitational/trace"
 	"github.com/jonboulle/clockwork"
 	"github.com/tstranex/u2f"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // Accounts collection modifies and updates account entries,
@@ -439,6 +441,18 @@ type SiteOperation struct {
 	UpdateEnviron *UpdateEnvarsOperationState `json:"update_environ,omitempty"`
 	// UpdateConfig defines the state of the cluster configuration update operation
 	UpdateConfig *UpdateConfigOperationState `json:"update_config,omitempty"`
+	// Schedule is set when the operation has been deferred until
+	// the cluster maintenance window opens
+	Schedule *OperationSchedule `json:"schedule,omitempty"`
+}
+
+// OperationSchedule describes an operation deferred until the maintenance window opens
+type OperationSchedule struct {
+	// State is the state the operation moves into when launched
+	State string `json:"state"`
+	// StartAfter is the time the maintenance window is expected to open.
+	// Zero if no window is expected to open
+	StartAfter time.Time `json:"start_after,omitempty"`
 }
 
 func (s *SiteOperation) Check() error {
@@ -488,6 +502,10 @@ type SiteOperations interface {
 	CreateOperationPlanChange(PlanChange) (*PlanChange, error)
 	// GetOperationPlanChangelog returns all state transition entries for a plan
 	GetOperationPlanChangelog(clusterName, operationID string) (PlanChangelog, error)
+	// CreateHookAttempt records a single execution attempt of an application hook
+	CreateHookAttempt(HookAttempt) (*HookAttempt, error)
+	// GetHookAttempts returns all hook attempts recorded for an operation
+	GetHookAttempts(clusterName, operationID string) ([]HookAttempt, error)
 }
 
 // Reason details the reason a site is in a particular state
@@ -848,6 +866,10 @@ type ProgressEntries interface {
 	CreateProgressEntry(p ProgressEntry) (*ProgressEntry, error)
 	// GetLastProgressEntry gets a progress entry for this site
 	GetLastProgressEntry(siteDomain, operationID string) (*ProgressEntry, error)
+	// GetProgressEntries returns all progress entries of the specified operation
+	GetProgressEntries(siteDomain, operationID string) ([]ProgressEntry, error)
+	// DeleteProgressEntry deletes the specified progress entry
+	DeleteProgressEntry(siteDomain, operationID, id string) error
 }
 
 // Package is any named and versioned blob with an optional manifest
@@ -1477,6 +1499,9 @@ type Backend interface {
 	PackageChangesets
 	Links
 	ClusterImport
+	MaintenanceWindows
+	ChartSigningPolicies
+	HelmRepositories
 	LegacyRoles
 	SystemMetadata
 	Charts
@@ -1551,6 +1576,9 @@ type Server struct {
 	Provisioner string `json:"provisioner"`
 	// OSInfo identifies the host operating system
 	OSInfo OSInfo `json:"os"`
+	// Arch is the CPU architecture of the server in GOARCH notation.
+	// Empty for servers that joined before the architecture was recorded
+	Arch string `json:"arch,omitempty"`
 	// Mounts lists mount configurations for a server profile instance
 	Mounts []Mount `json:"mounts"`
 	// SystemState defines the system configuration for gravity - location
@@ -1766,6 +1794,23 @@ type InstallExpandOperationState struct {
 	Vars OperationVariables `json:"vars"`
 	// Package is the application being installed
 	Package loc.Locator `json:"package"`
+	// ReplaceServer is the failed master node replaced by the expand operation
+	ReplaceServer *Server `json:"replace_server,omitempty"`
+}
+
+// ServerCount returns the total number of servers requested by the operation.
+// An operation always expects at least one server
+func (s *InstallExpandOperationState) ServerCount() int {
+	var count int
+	if s != nil {
+		for _, profile := range s.Profiles {
+			count += profile.Request.Count
+		}
+	}
+	if count == 0 {
+		return 1
+	}
+	return count
 }
 
 // OperationVariables is operation-specific set of variables
@@ -1984,6 +2029,8 @@ type ShrinkOperationState struct {
 	// Used in cases where we recieve an event where the node is being terminated, but may
 	// not have disconnected from the cluster yet.
 	NodeRemoved bool `json:"node_removed"`
+	// DrainPolicy optionally defines how the node is drained before removal
+	DrainPolicy *DrainPolicy `json:"drain_policy,omitempty"`
 }
 
 // UpdateOperationState describes the state of the update operation.
@@ -2000,6 +2047,188 @@ type UpdateOperationState struct {
 	ServerUpdates []ServerUpdate `json:"server_updates,omitempty"`
 	// Manual specifies whether this update operation was created in manual mode
 	Manual bool `json:"manual"`
+	// Strategy optionally defines how regular nodes are upgraded
+	Strategy *UpdateStrategy `json:"strategy,omitempty"`
+	// DrainPolicy optionally defines how nodes are drained before upgrade
+	DrainPolicy *DrainPolicy `json:"drain_policy,omitempty"`
+}
+
+// UpdateStrategy defines the order in which regular nodes are upgraded.
+//
+// Nodes matching the canary selector are upgraded first, one at a time,
+// and are then verified to stay healthy for the canary pause before
+// the remaining nodes are upgraded in batches
+type UpdateStrategy struct {
+	// CanarySelector is the Kubernetes label selector of the canary nodes
+	CanarySelector string `json:"canary_selector,omitempty"`
+	// CanaryPause is how long canary nodes should stay healthy
+	// before the remaining nodes are upgraded
+	CanaryPause time.Duration `json:"canary_pause,omitempty"`
+	// BatchSize is the number of nodes upgraded concurrently
+	BatchSize int `json:"batch_size,omitempty"`
+	// MaxUnavailable is the maximum number of nodes that can be unavailable
+	// at the same time during the upgrade. It caps the batch size.
+	// Rolling updates of the runtime environment and cluster configuration
+	// do not use the strategy as they update one node at a time
+	MaxUnavailable int `json:"max_unavailable,omitempty"`
+	// HealthGates optionally configures health checks run after each node is upgraded
+	HealthGates *HealthGates `json:"health_gates,omitempty"`
+	// AutoRollback is whether the operation should be automatically
+	// rolled back when a phase fails
+	AutoRollback bool `json:"auto_rollback,omitempty"`
+}
+
+// HealthGates configures health checks that should pass after a node
+// has been upgraded before the upgrade continues with the next node.
+//
+// Besides the custom checks, the gates require planet agents to report
+// the cluster as running, all pods on the upgraded node to be ready
+// and the application status hook to succeed
+type HealthGates struct {
+	// Timeout is how long the checks are allowed to fail
+	// before the operation is paused
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// HTTPChecks lists URLs that should respond with a successful status code
+	HTTPChecks []string `json:"http_checks,omitempty"`
+	// PrometheusURL is the address of the Prometheus server to run the queries against
+	PrometheusURL string `json:"prometheus_url,omitempty"`
+	// PrometheusQueries lists Prometheus queries that should return no results,
+	// for example, firing critical alerts
+	PrometheusQueries []string `json:"prometheus_queries,omitempty"`
+}
+
+// Check validates these health gates
+func (r HealthGates) Check() error {
+	if r.Timeout < 0 {
+		return trace.BadParameter("health gate timeout should not be negative")
+	}
+	for _, check := range r.HTTPChecks {
+		if _, err := url.ParseRequestURI(check); err != nil {
+			return trace.BadParameter("invalid health check URL %q: %v", check, err)
+		}
+	}
+	if len(r.PrometheusQueries) != 0 && r.PrometheusURL == "" {
+		return trace.BadParameter("Prometheus queries require a Prometheus URL")
+	}
+	if r.PrometheusURL != "" {
+		if _, err := url.ParseRequestURI(r.PrometheusURL); err != nil {
+			return trace.BadParameter("invalid Prometheus URL %q: %v", r.PrometheusURL, err)
+		}
+	}
+	return nil
+}
+
+// Check validates this update strategy
+func (r UpdateStrategy) Check() error {
+	if r.CanaryPause < 0 {
+		return trace.BadParameter("canary pause should not be negative")
+	}
+	if r.BatchSize < 0 {
+		return trace.BadParameter("batch size should not be negative")
+	}
+	if r.MaxUnavailable < 0 {
+		return trace.BadParameter("max unavailable should not be negative")
+	}
+	if r.CanarySelector == "" && r.CanaryPause != 0 {
+		return trace.BadParameter("canary pause requires a canary selector")
+	}
+	if r.HealthGates != nil {
+		if err := r.HealthGates.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// IsDefaultOrder returns true if this strategy does not change
+// the default order in which regular nodes are upgraded
+func (r UpdateStrategy) IsDefaultOrder() bool {
+	return r.CanarySelector == "" && r.BatchSize == 0 && r.MaxUnavailable == 0
+}
+
+// EffectiveBatchSize returns the number of nodes to upgrade concurrently
+// with the max unavailable limit applied
+func (r UpdateStrategy) EffectiveBatchSize() int {
+	size := r.BatchSize
+	if size == 0 {
+		size = 1
+	}
+	if r.MaxUnavailable != 0 && size > r.MaxUnavailable {
+		size = r.MaxUnavailable
+	}
+	return size
+}
+
+// DrainPolicy defines how pods are evicted from a node before
+// the node is upgraded or removed from the cluster.
+//
+// DaemonSet pods are never evicted
+type DrainPolicy struct {
+	// GracePeriod overrides the termination grace period of evicted pods
+	GracePeriod time.Duration `json:"grace_period,omitempty"`
+	// NamespaceGracePeriods overrides the termination grace period
+	// of pods in the specific namespaces
+	NamespaceGracePeriods map[string]time.Duration `json:"namespace_grace_periods,omitempty"`
+	// DisruptionBudgetTimeout is how long evictions blocked by pod disruption
+	// budgets are retried before the drain fails
+	DisruptionBudgetTimeout time.Duration `json:"disruption_budget_timeout,omitempty"`
+	// ForceDeleteTimeout is how long to wait for pods to be evicted before
+	// deleting them without grace period, bypassing pod disruption budgets.
+	// Pods are never force deleted if unset
+	ForceDeleteTimeout time.Duration `json:"force_delete_timeout,omitempty"`
+	// SkipSelector is the Kubernetes label selector of pods that are not evicted
+	SkipSelector string `json:"skip_selector,omitempty"`
+	// SkipLocalStorageSelector is the Kubernetes label selector of pods using
+	// local storage (emptyDir volumes) that are not evicted
+	SkipLocalStorageSelector string `json:"skip_local_storage_selector,omitempty"`
+}
+
+// Check validates this drain policy
+func (r DrainPolicy) Check() error {
+	if r.GracePeriod < 0 {
+		return trace.BadParameter("drain grace period should not be negative")
+	}
+	for namespace, period := range r.NamespaceGracePeriods {
+		if period < 0 {
+			return trace.BadParameter("drain grace period for namespace %q should not be negative",
+				namespace)
+		}
+	}
+	if r.DisruptionBudgetTimeout < 0 {
+		return trace.BadParameter("disruption budget timeout should not be negative")
+	}
+	if r.ForceDeleteTimeout < 0 {
+		return trace.BadParameter("force delete timeout should not be negative")
+	}
+	if r.ForceDeleteTimeout != 0 && r.DisruptionBudgetTimeout != 0 &&
+		r.ForceDeleteTimeout > r.DisruptionBudgetTimeout {
+		return trace.BadParameter("force delete timeout should not exceed disruption budget timeout")
+	}
+	if r.SkipSelector != "" {
+		if _, err := labels.Parse(r.SkipSelector); err != nil {
+			return trace.BadParameter("invalid skip selector %q: %v", r.SkipSelector, err)
+		}
+	}
+	if r.SkipLocalStorageSelector != "" {
+		if _, err := labels.Parse(r.SkipLocalStorageSelector); err != nil {
+			return trace.BadParameter("invalid local storage skip selector %q: %v",
+				r.SkipLocalStorageSelector, err)
+		}
+	}
+	return nil
+}
+
+// GracePeriodFor returns the termination grace period for pods
+// in the specified namespace or -1 if the pod's own grace period
+// should be used
+func (r DrainPolicy) GracePeriodFor(namespace string) time.Duration {
+	if period, ok := r.NamespaceGracePeriods[namespace]; ok {
+		return period
+	}
+	if r.GracePeriod != 0 {
+		return r.GracePeriod
+	}
+	return -1
 }
 
 // UpdateEnvarsOperationState describes the state of the operation to update cluster environment variables.
@@ -2088,6 +2317,38 @@ type ClusterConfiguration interface {
 	UpsertClusterConfig(teleservices.ClusterConfig) error
 }
 
+// MaintenanceWindows stores the cluster maintenance window in the DB
+type MaintenanceWindows interface {
+	// GetMaintenanceWindow returns the cluster maintenance window
+	GetMaintenanceWindow() (MaintenanceWindow, error)
+	// UpsertMaintenanceWindow creates or replaces the cluster maintenance window
+	UpsertMaintenanceWindow(MaintenanceWindow) error
+	// DeleteMaintenanceWindow deletes the cluster maintenance window
+	DeleteMaintenanceWindow() error
+}
+
+// ChartSigningPolicies stores the cluster chart signing policy in the DB
+type ChartSigningPolicies interface {
+	// GetChartSigningPolicy returns the cluster chart signing policy
+	GetChartSigningPolicy() (ChartSigningPolicy, error)
+	// UpsertChartSigningPolicy creates or replaces the cluster chart signing policy
+	UpsertChartSigningPolicy(ChartSigningPolicy) error
+	// DeleteChartSigningPolicy deletes the cluster chart signing policy
+	DeleteChartSigningPolicy() error
+}
+
+// HelmRepositories stores external chart repositories in the DB
+type HelmRepositories interface {
+	// GetHelmRepositories returns all configured helm repositories
+	GetHelmRepositories() ([]HelmRepository, error)
+	// GetHelmRepository returns the helm repository with the specified name
+	GetHelmRepository(name string) (HelmRepository, error)
+	// UpsertHelmRepository creates or replaces the helm repository
+	UpsertHelmRepository(HelmRepository) error
+	// DeleteHelmRepository deletes the helm repository with the specified name
+	DeleteHelmRepository(name string) error
+}
+
 // CloudConfig represents additional cloud provider-specific configuration
 type CloudConfig struct {
 	// GCENodeTags lists additional node tags on GCE
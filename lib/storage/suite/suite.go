@@ -897,6 +897,19 @@ func (s *StorageSuite) ProgressEntriesCRUD(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(*ope2, DeepEquals, pe2)
 
+	entries, err := s.Backend.GetProgressEntries(sa.Domain, op.ID)
+	c.Assert(err, IsNil)
+	c.Assert(entries, DeepEquals, []storage.ProgressEntry{pe1, pe2})
+
+	err = s.Backend.DeleteProgressEntry(sa.Domain, op.ID, pe1.ID)
+	c.Assert(err, IsNil)
+	err = s.Backend.DeleteProgressEntry(sa.Domain, op.ID, pe1.ID)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%#v", err))
+
+	entries, err = s.Backend.GetProgressEntries(sa.Domain, op.ID)
+	c.Assert(err, IsNil)
+	c.Assert(entries, DeepEquals, []storage.ProgressEntry{pe2})
+
 	// Create for non existent site should fail
 	_, err = s.Backend.CreateProgressEntry(storage.ProgressEntry{
 		SiteDomain:  "nothere.com",
@@ -992,6 +1005,45 @@ func (s *StorageSuite) OperationsCRUD(c *C) {
 	})
 }
 
+func (s *StorageSuite) HookAttemptsCRUD(c *C) {
+	now := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	failed := storage.HookAttempt{
+		ClusterName: "example.com",
+		OperationID: "op1",
+		PhaseID:     "/app/app",
+		Application: "example.com/app:0.0.1",
+		Hook:        "install",
+		Attempt:     1,
+		Started:     now,
+		Completed:   now.Add(time.Minute),
+		Error:       "job failed",
+		Output:      "install failed",
+	}
+	out, err := s.Backend.CreateHookAttempt(failed)
+	c.Assert(err, IsNil)
+	c.Assert(out.ID, Not(Equals), "")
+	failed.ID = out.ID
+
+	succeeded := failed
+	succeeded.ID = ""
+	succeeded.Attempt = 2
+	succeeded.Started = now.Add(2 * time.Minute)
+	succeeded.Completed = now.Add(3 * time.Minute)
+	succeeded.Error = ""
+	succeeded.Output = "install completed"
+	out, err = s.Backend.CreateHookAttempt(succeeded)
+	c.Assert(err, IsNil)
+	succeeded.ID = out.ID
+
+	attempts, err := s.Backend.GetHookAttempts("example.com", "op1")
+	c.Assert(err, IsNil)
+	c.Assert(attempts, DeepEquals, []storage.HookAttempt{failed, succeeded})
+
+	attempts, err = s.Backend.GetHookAttempts("example.com", "op2")
+	c.Assert(err, IsNil)
+	c.Assert(attempts, HasLen, 0)
+}
+
 func (s *StorageSuite) LoginEntriesCRUD(c *C) {
 	// Create
 	entry := storage.LoginEntry{
@@ -72,6 +72,8 @@ type System interface {
 	GetLVMSystemDirectory() string
 	// GetUser returns the information about the user the agent is running under
 	GetUser() OSUser
+	// GetArch returns the CPU architecture of the system in GOARCH notation
+	GetArch() string
 }
 
 // UnmarshalSystemInfo unmarshals system info from JSON specified with data
@@ -180,6 +182,15 @@ func (r *SystemV2) GetUser() OSUser {
 	return r.Spec.User
 }
 
+// GetArch returns the CPU architecture of the system in GOARCH notation.
+// Agents that do not report the architecture run on amd64
+func (r *SystemV2) GetArch() string {
+	if r.Spec.Arch == "" {
+		return defaults.Architecture
+	}
+	return r.Spec.Arch
+}
+
 // SystemV2 describes a system
 type SystemV2 struct {
 	// Kind is resource kind, "systeminfo"
@@ -223,6 +234,8 @@ type SystemSpecV2 struct {
 	LVMSystemDirectory string `json:"lvm_system_dir"`
 	// User specifies the agent's user identity
 	User OSUser `json:"user"`
+	// Arch specifies the CPU architecture in GOARCH notation
+	Arch string `json:"arch,omitempty"`
 }
 
 // String returns a textual representation of this system info
@@ -231,12 +244,13 @@ func (r SystemV2) String() string {
 	for name, iface := range r.Spec.NetworkInterfaces {
 		ifaces = append(ifaces, fmt.Sprintf("%v=%v", name, iface.IPv4))
 	}
-	return fmt.Sprintf("sysinfo(hostname=%v, interfaces=%v, cpus=%v, ramMB=%v, OS=%v, user=%v, lvm_dir=%v)",
+	return fmt.Sprintf("sysinfo(hostname=%v, interfaces=%v, cpus=%v, ramMB=%v, OS=%v, arch=%v, user=%v, lvm_dir=%v)",
 		r.Spec.Hostname,
 		strings.Join(ifaces, ","),
 		r.Spec.NumCPU,
 		r.Spec.Memory.Total/1000/1000,
 		r.Spec.OS,
+		r.GetArch(),
 		r.Spec.User,
 		r.Spec.LVMSystemDirectory,
 	)
@@ -355,6 +369,7 @@ const SystemSpecV2Schema = `{
       }
     },
     "lvm_system_dir": {"type": "string"},
+    "arch": {"type": "string"},
     "user": {
       "type": "object",
       "required": ["name", "uid", "gid"],
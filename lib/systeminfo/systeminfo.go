@@ -19,8 +19,10 @@ package systeminfo
 import (
 	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gravitational/gravity/lib/devicemapper"
 	"github.com/gravitational/gravity/lib/storage"
@@ -146,6 +148,11 @@ func collect() (*storage.SystemSpecV2, error) {
 	}
 	info.OS = storage.OSInfo(*osInfo)
 
+	info.Arch, err = queryArch()
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to query machine architecture")
+	}
+
 	info.Processes, err = queryProcesses()
 	if err != nil {
 		return nil, trace.Wrap(err, "failed to obtain running processes info")
@@ -218,6 +225,34 @@ func queryCPUs() (cpus uint, err error) {
 	return uint(len(cpuList.List)), nil
 }
 
+// queryArch returns the architecture of the machine in GOARCH notation.
+// The architecture of the machine is queried instead of the one the binary
+// has been built for since the binary might run under emulation
+func queryArch() (string, error) {
+	out, err := exec.Command("uname", "-m").Output()
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return machineToArch(strings.TrimSpace(string(out))), nil
+}
+
+// machineToArch converts the machine hardware name as reported by uname
+// to GOARCH notation
+func machineToArch(machine string) string {
+	switch machine {
+	case "x86_64":
+		return "amd64"
+	case "aarch64", "arm64":
+		return "arm64"
+	case "i386", "i686":
+		return "386"
+	case "armv6l", "armv7l":
+		return "arm"
+	default:
+		return machine
+	}
+}
+
 func queryProcesses() (result []storage.Process, err error) {
 	processes, err := ps.Processes()
 	if err != nil {
@@ -0,0 +1,37 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package systeminfo
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type SystemInfoSuite struct{}
+
+var _ = Suite(&SystemInfoSuite{})
+
+func (r *SystemInfoSuite) TestConvertsMachineToArch(c *C) {
+	for machine, arch := range map[string]string{
+		"x86_64":  "amd64",
+		"aarch64": "arm64",
+		"armv7l":  "arm",
+		"i686":    "386",
+		"ppc64le": "ppc64le",
+	} {
+		c.Assert(machineToArch(machine), Equals, arch, Commentf(machine))
+	}
+}
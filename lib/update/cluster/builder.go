@@ -29,6 +29,7 @@ import (
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/update"
 	libphase "github.com/gravitational/gravity/lib/update/cluster/phases"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/gravitational/rigging"
@@ -49,12 +50,24 @@ func (r phaseBuilder) init(leadMaster storage.Server) *update.Phase {
 			InstalledPackage: &r.installedApp.Package,
 			Update: &storage.UpdateOperationData{
 				Servers: r.servers,
+				Hops:    r.updateHops(),
 			},
 		},
 	})
 	return &phase
 }
 
+// updateHops returns the intermediate runtime updates of this plan
+func (r phaseBuilder) updateHops() (hops []storage.UpdateHop) {
+	for _, hop := range r.hops {
+		hops = append(hops, storage.UpdateHop{
+			Runtime: hop.runtime.Package,
+			Servers: hop.servers,
+		})
+	}
+	return hops
+}
+
 func (r phaseBuilder) checks() *update.Phase {
 	phase := update.RootPhase(update.Phase{
 		ID:          "checks",
@@ -296,15 +309,90 @@ func (r phaseBuilder) nodes(leadMaster storage.UpdateServer, nodes []storage.Upd
 		Description: "Update regular nodes",
 	})
 
-	for i, server := range nodes {
-		node := r.node(server.Server, &root, "Update system software on node %q")
-		node.AddSequential(r.commonNode(nodes[i], leadMaster, supportsTaints,
-			waitsForEndpoints(true))...)
-		root.AddParallel(node)
+	if r.strategy.IsDefaultOrder() {
+		for i, server := range nodes {
+			node := r.node(server.Server, &root, "Update system software on node %q")
+			node.AddSequential(r.commonNode(nodes[i], leadMaster, supportsTaints,
+				waitsForEndpoints(true))...)
+			root.AddParallel(node)
+		}
+		return &root
+	}
+
+	canaries, others := splitCanaryNodes(nodes, r.canaryNodes)
+	if len(canaries) != 0 {
+		canary := update.Phase{
+			ID:          root.ChildLiteral("canary"),
+			Description: "Update canary nodes",
+		}
+		for i, server := range canaries {
+			node := r.node(server.Server, &canary, "Update system software on canary node %q")
+			node.AddSequential(r.commonNode(canaries[i], leadMaster, supportsTaints,
+				waitsForEndpoints(true))...)
+			canary.AddSequential(node)
+		}
+		strategy := r.strategy
+		description := "Verify canary nodes are healthy"
+		if strategy.CanaryPause != 0 {
+			description = fmt.Sprintf("Verify canary nodes stay healthy for %v", strategy.CanaryPause)
+		}
+		verify := update.Phase{
+			ID:          root.ChildLiteral("verify-canary"),
+			Executor:    verifyCanary,
+			Description: description,
+			Data: &storage.OperationPhaseData{
+				ExecServer: &leadMaster.Server,
+				Update: &storage.UpdateOperationData{
+					Servers:  canaries,
+					Strategy: &strategy,
+				},
+			},
+		}
+		root.AddSequential(canary, verify)
+	}
+
+	for i, batch := range splitBatches(others, r.strategy.EffectiveBatchSize()) {
+		phase := update.Phase{
+			ID:          root.ChildLiteral(fmt.Sprintf("batch-%v", i+1)),
+			Description: fmt.Sprintf("Update batch %v of regular nodes", i+1),
+			Parallel:    len(batch) > 1,
+		}
+		for j, server := range batch {
+			node := r.node(server.Server, &phase, "Update system software on node %q")
+			node.AddSequential(r.commonNode(batch[j], leadMaster, supportsTaints,
+				waitsForEndpoints(true))...)
+			phase.AddParallel(node)
+		}
+		root.AddSequential(phase)
 	}
 	return &root
 }
 
+// splitCanaryNodes splits the specified list of servers into canaries
+// matching the given Kubernetes node names and the rest
+func splitCanaryNodes(servers []storage.UpdateServer, canaryNodes []string) (canaries, others []storage.UpdateServer) {
+	for _, server := range servers {
+		if utils.StringInSlice(canaryNodes, server.KubeNodeID()) {
+			canaries = append(canaries, server)
+		} else {
+			others = append(others, server)
+		}
+	}
+	return canaries, others
+}
+
+// splitBatches splits the specified list of servers into batches of the given size
+func splitBatches(servers []storage.UpdateServer, size int) (batches [][]storage.UpdateServer) {
+	for len(servers) > size {
+		batches = append(batches, servers[:size])
+		servers = servers[size:]
+	}
+	if len(servers) != 0 {
+		batches = append(batches, servers)
+	}
+	return batches
+}
+
 func (r phaseBuilder) etcdPlan(
 	leadMaster storage.Server,
 	otherMasters []storage.Server,
@@ -478,15 +566,22 @@ func (r phaseBuilder) node(server storage.Server, parent update.ParentPhase, for
 // commonNode returns a list of operations required for any node role to upgrade its system software
 func (r phaseBuilder) commonNode(server, leadMaster storage.UpdateServer, supportsTaints bool,
 	waitsForEndpoints waitsForEndpoints) []update.Phase {
+	drain := update.Phase{
+		ID:          "drain",
+		Executor:    drainNode,
+		Description: fmt.Sprintf("Drain node %q", server.Hostname),
+		Data: &storage.OperationPhaseData{
+			Server:     &server.Server,
+			ExecServer: &leadMaster.Server,
+			Package:    &r.installedApp.Package,
+		}}
+	if r.drainPolicy != nil {
+		drain.Data.Update = &storage.UpdateOperationData{
+			DrainPolicy: r.drainPolicy,
+		}
+	}
 	phases := []update.Phase{
-		{
-			ID:          "drain",
-			Executor:    drainNode,
-			Description: fmt.Sprintf("Drain node %q", server.Hostname),
-			Data: &storage.OperationPhaseData{
-				Server:     &server.Server,
-				ExecServer: &leadMaster.Server,
-			}},
+		drain,
 		{
 			ID:          "system-upgrade",
 			Executor:    updateSystem,
@@ -494,7 +589,8 @@ func (r phaseBuilder) commonNode(server, leadMaster storage.UpdateServer, suppor
 			Data: &storage.OperationPhaseData{
 				ExecServer: &server.Server,
 				Update: &storage.UpdateOperationData{
-					Servers: []storage.UpdateServer{server},
+					Servers:     []storage.UpdateServer{server},
+					ChangesetID: r.changesetID,
 				},
 			}},
 	}
@@ -536,6 +632,21 @@ func (r phaseBuilder) commonNode(server, leadMaster storage.UpdateServer, suppor
 				ExecServer: &leadMaster.Server,
 			}})
 	}
+	if r.strategy.HealthGates != nil {
+		strategy := r.strategy
+		phases = append(phases, update.Phase{
+			ID:          "health",
+			Executor:    healthGate,
+			Description: fmt.Sprintf("Verify cluster health after updating node %q", server.Hostname),
+			Data: &storage.OperationPhaseData{
+				Server:     &server.Server,
+				ExecServer: &leadMaster.Server,
+				Package:    &r.installedApp.Package,
+				Update: &storage.UpdateOperationData{
+					Strategy: &strategy,
+				},
+			}})
+	}
 	return phases
 }
 
@@ -17,6 +17,8 @@ limitations under the License.
 package cluster
 
 import (
+	"time"
+
 	"github.com/gravitational/gravity/lib/app"
 	apptest "github.com/gravitational/gravity/lib/app/service/test"
 	"github.com/gravitational/gravity/lib/archive"
@@ -120,6 +122,160 @@ func (s *PlanSuite) TestPlanWithRuntimeUpdate(c *check.C) {
 	c.Assert(*obtainedPlan, compare.DeepEquals, plan)
 }
 
+func (s *PlanSuite) TestPlanWithIntermediateRuntime(c *check.C) {
+	params := newTestPlan(c, params{
+		installedRuntime:         loc.MustParseLocator("gravitational.io/runtime:1.0.0"),
+		installedApp:             loc.MustParseLocator("gravitational.io/app:1.0.0"),
+		updateRuntime:            loc.MustParseLocator("gravitational.io/runtime:2.0.0"),
+		updateApp:                loc.MustParseLocator("gravitational.io/app:2.0.0"),
+		installedRuntimeManifest: installedRuntimeManifest,
+		installedAppManifest:     installedAppManifest,
+		updateRuntimeManifest:    updateRuntimeManifest,
+		updateAppManifest:        updateAppManifest,
+	})
+	hopRuntime := app.Application{
+		Package:  loc.MustParseLocator("gravitational.io/runtime:1.5.0"),
+		Manifest: schema.MustParseManifestYAML([]byte(hopRuntimeManifest)),
+		PackageEnvelope: pack.PackageEnvelope{
+			Manifest: []byte(hopRuntimeManifest),
+		},
+	}
+	hopServers, err := hopUpdates(testOperator, ops.SiteOperationKey{}, hopRuntime,
+		params.updateApp.Manifest, params.servers)
+	c.Assert(err, check.IsNil)
+	params.hops = []upgradeHop{{runtime: hopRuntime, servers: hopServers}}
+
+	plan, err := newOperationPlan(params)
+	c.Assert(err, check.IsNil)
+
+	phases := make(map[string]storage.OperationPhase)
+	var ids []string
+	for _, phase := range plan.Phases {
+		phases[phase.ID] = phase
+		ids = append(ids, phase.ID)
+	}
+	c.Assert(ids, check.DeepEquals, []string{
+		"/init", "/checks", "/pre-update", "/bootstrap", "/hop-1.5.0", "/masters", "/nodes",
+		"/etcd", "/migration", "/config", "/runtime", "/app", "/gc",
+	})
+	c.Assert(phases["/hop-1.5.0"].Requires, check.DeepEquals, []string{"/checks", "/bootstrap", "/pre-update"})
+	c.Assert(phases["/masters"].Requires, check.DeepEquals, []string{"/hop-1.5.0"})
+
+	hop := phases["/hop-1.5.0"]
+	ids = nil
+	for _, phase := range hop.Phases {
+		ids = append(ids, phase.ID)
+	}
+	c.Assert(ids, check.DeepEquals, []string{
+		"/hop-1.5.0/masters", "/hop-1.5.0/nodes", "/hop-1.5.0/etcd", "/hop-1.5.0/runtime",
+	})
+	c.Assert(hop.Phases[1].Requires, check.DeepEquals, []string{"/hop-1.5.0/masters"})
+	systemUpgrade := hop.Phases[0].Phases[0].Phases[3]
+	c.Assert(systemUpgrade.ID, check.Equals, "/hop-1.5.0/masters/node-1/system-upgrade")
+	c.Assert(systemUpgrade.Data.Update.ChangesetID, check.Equals, "123-1.5.0")
+	c.Assert(systemUpgrade.Data.Update.Servers[0].Runtime.Update.Package, check.Equals,
+		loc.MustParseLocator("gravitational.io/planet:1.5.0"))
+	c.Assert(plan.Phases[0].Data.Update.Hops, check.DeepEquals, []storage.UpdateHop{
+		{Runtime: hopRuntime.Package, Servers: hopServers},
+	})
+}
+
+func (s *PlanSuite) TestNodesWithUpdateStrategy(c *check.C) {
+	var nodes []storage.UpdateServer
+	for _, name := range []string{"node-1", "node-2", "node-3", "node-4", "node-5"} {
+		nodes = append(nodes, storage.UpdateServer{
+			Server: storage.Server{Hostname: name, Nodename: name, AdvertiseIP: name},
+		})
+	}
+	leadMaster := storage.UpdateServer{Server: storage.Server{Hostname: "master"}}
+	builder := phaseBuilder{planConfig: planConfig{
+		strategy: storage.UpdateStrategy{
+			CanarySelector: "upgrade=canary",
+			BatchSize:      3,
+			MaxUnavailable: 2,
+		},
+		canaryNodes: []string{"node-2"},
+	}}
+
+	phase := builder.nodes(leadMaster, nodes, false)
+	var obtained []storage.OperationPhase
+	for _, phase := range phase.Phases {
+		var children []storage.OperationPhase
+		for _, child := range phase.Phases {
+			children = append(children, storage.OperationPhase{ID: child.ID})
+		}
+		obtained = append(obtained, storage.OperationPhase{
+			ID:       phase.ID,
+			Executor: phase.Executor,
+			Phases:   children,
+			Requires: phase.Requires,
+			Parallel: phase.Parallel,
+		})
+	}
+	c.Assert(obtained, compare.DeepEquals, []storage.OperationPhase{
+		{
+			ID:     "/nodes/canary",
+			Phases: []storage.OperationPhase{{ID: "/nodes/canary/node-2"}},
+		},
+		{
+			ID:       "/nodes/verify-canary",
+			Executor: verifyCanary,
+			Requires: []string{"/nodes/canary"},
+		},
+		{
+			ID:       "/nodes/batch-1",
+			Phases:   []storage.OperationPhase{{ID: "/nodes/batch-1/node-1"}, {ID: "/nodes/batch-1/node-3"}},
+			Requires: []string{"/nodes/verify-canary"},
+			Parallel: true,
+		},
+		{
+			ID:       "/nodes/batch-2",
+			Phases:   []storage.OperationPhase{{ID: "/nodes/batch-2/node-4"}, {ID: "/nodes/batch-2/node-5"}},
+			Requires: []string{"/nodes/batch-1"},
+			Parallel: true,
+		},
+	})
+}
+
+func (s *PlanSuite) TestNodeWithHealthGates(c *check.C) {
+	server := storage.UpdateServer{Server: storage.Server{Hostname: "node-1"}}
+	leadMaster := storage.UpdateServer{Server: storage.Server{Hostname: "master"}}
+	builder := phaseBuilder{planConfig: planConfig{
+		strategy: storage.UpdateStrategy{
+			HealthGates: &storage.HealthGates{HTTPChecks: []string{"http://localhost/healthz"}},
+		},
+	}}
+
+	phase := builder.nodes(leadMaster, []storage.UpdateServer{server}, false)
+	c.Assert(phase.Phases, check.HasLen, 1)
+	var ids []string
+	for _, phase := range phase.Phases[0].Phases {
+		ids = append(ids, phase.ID)
+	}
+	c.Assert(ids, check.DeepEquals, []string{"drain", "system-upgrade", "uncordon", "endpoints", "health"})
+	health := phase.Phases[0].Phases[4]
+	c.Assert(health.Executor, check.Equals, healthGate)
+	c.Assert(health.Data.Update.Strategy.HealthGates, check.DeepEquals, builder.strategy.HealthGates)
+}
+
+func (s *PlanSuite) TestNodeWithDrainPolicy(c *check.C) {
+	server := storage.UpdateServer{Server: storage.Server{Hostname: "node-1"}}
+	leadMaster := storage.UpdateServer{Server: storage.Server{Hostname: "master"}}
+	builder := phaseBuilder{planConfig: planConfig{
+		installedApp: app.Application{Package: loc.MustParseLocator("gravitational.io/app:1.0.0")},
+		drainPolicy: &storage.DrainPolicy{
+			DisruptionBudgetTimeout:  10 * time.Minute,
+			SkipLocalStorageSelector: "app=cache",
+		},
+	}}
+
+	phase := builder.nodes(leadMaster, []storage.UpdateServer{server}, false)
+	drain := phase.Phases[0].Phases[0]
+	c.Assert(drain.Executor, check.Equals, drainNode)
+	c.Assert(*drain.Data.Package, check.DeepEquals, builder.installedApp.Package)
+	c.Assert(drain.Data.Update.DrainPolicy, check.DeepEquals, builder.drainPolicy)
+}
+
 func (s *PlanSuite) TestPlanWithoutRuntimeUpdate(c *check.C) {
 	// setup
 	runtimeLoc1 := loc.MustParseLocator("gravitational.io/runtime:1.0.0")
@@ -476,3 +632,20 @@ systemOptions:
   dependencies:
     runtimePackage: gravitational.io/planet:2.0.0
 `
+
+const hopRuntimeManifest = `apiVersion: bundle.gravitational.io/v2
+kind: Runtime
+metadata:
+  name: runtime
+  resourceVersion: 1.5.0
+dependencies:
+  packages:
+    - gravitational.io/gravity:1.5.0
+  apps:
+    - gravitational.io/runtime-dep-1:1.0.0
+    - gravitational.io/runtime-dep-2:1.5.0
+    - gravitational.io/rbac-app:1.0.0
+systemOptions:
+  dependencies:
+    runtimePackage: gravitational.io/planet:1.5.0
+`
@@ -156,10 +156,14 @@ func (f *engine) GetExecutor(p fsm.ExecutorParams, remote fsm.Remote) (fsm.Phase
 	return f.Spec(p, remote)
 }
 
-// RunCommand executes the phase specified by params on the specified server
+// RunCommand executes or rolls back the phase specified by params on the specified server
 // using the provided runner
 func (f *engine) RunCommand(ctx context.Context, runner fsm.RemoteRunner, server storage.Server, p fsm.Params) error {
-	args := []string{"plan", "execute",
+	command := "execute"
+	if p.Rollback {
+		command = "rollback"
+	}
+	args := []string{"plan", command,
 		"--phase", p.PhaseID,
 		"--operation-id", f.plan.OperationID,
 	}
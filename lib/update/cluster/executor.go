@@ -53,6 +53,10 @@ const (
 	uncordonNode = "uncordon_node"
 	// endpoints is the phase to wait for system service endpoints
 	endpoints = "endpoints"
+	// verifyCanary is the phase to verify the health of the upgraded canary nodes
+	verifyCanary = "verify_canary"
+	// healthGate is the phase to verify the cluster health after a node has been upgraded
+	healthGate = "health_gate"
 	// config is the phase that updates system configuration
 	config = "config"
 	// kubeletPermissions is the phase to add kubelet permissions
@@ -119,7 +123,7 @@ func fsmSpec(c Config) fsm.FSMSpecFunc {
 				c.LocalBackend, c.ClusterPackages, c.HostLocalPackages,
 				logger)
 		case preUpdate:
-			return libphase.NewUpdatePhaseBeforeApp(p, c.Apps, c.Client, logger)
+			return libphase.NewUpdatePhaseBeforeApp(p, c.Operator, c.Apps, c.Client, c.Runner, logger)
 		case updateApp:
 			return libphase.NewUpdatePhaseApp(p, c.Operator, c.Apps, c.Client, logger)
 		case electionStatus:
@@ -129,11 +133,15 @@ func fsmSpec(c Config) fsm.FSMSpecFunc {
 		case untaintNode:
 			return libphase.NewPhaseUntaint(p, c.Client, logger)
 		case drainNode:
-			return libphase.NewPhaseDrain(p, c.Client, logger)
+			return libphase.NewPhaseDrain(p, c.Operator, c.Apps, c.Client, logger)
 		case uncordonNode:
 			return libphase.NewPhaseUncordon(p, c.Client, logger)
 		case endpoints:
 			return libphase.NewPhaseEndpoints(p, c.Client, logger)
+		case verifyCanary:
+			return libphase.NewPhaseVerifyCanary(p, c.Client, logger)
+		case healthGate:
+			return libphase.NewPhaseHealthGate(p, c.Operator, c.Apps, c.Client, logger)
 		case config:
 			return libphase.NewUpdatePhaseConfig(p, c.Operator, c.ClusterPackages, c.HostLocalPackages, remote, logger)
 		case kubeletPermissions:
@@ -0,0 +1,228 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/update"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// upgradeHop describes an upgrade to an intermediate runtime
+type upgradeHop struct {
+	// runtime is the intermediate runtime application
+	runtime app.Application
+	// servers lists the server updates to the intermediate runtime
+	servers []storage.UpdateServer
+}
+
+// getUpgradeHops computes the chain of intermediate runtimes required to upgrade
+// from the installed to the update runtime.
+// Intermediate runtimes are looked up in the cluster application service.
+// Returns an empty list if the update runtime can be upgraded to directly
+func getUpgradeHops(
+	apps app.Applications,
+	operator packageRotator,
+	operation ops.SiteOperationKey,
+	installedRuntime, updateRuntime app.Application,
+	updateManifest schema.Manifest,
+	updates []storage.UpdateServer,
+) (hops []upgradeHop, err error) {
+	supported, err := updateRuntime.Manifest.SupportsUpgradeFrom(installedRuntime.Package.Version)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if supported {
+		return nil, nil
+	}
+	runtimes, err := apps.ListApps(app.ListAppsRequest{
+		Repository: updateRuntime.Package.Repository,
+		Type:       storage.AppRuntime,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	byVersion := make(map[string]app.Application)
+	var versions []string
+	for _, runtime := range runtimes {
+		if runtime.Package.Name != updateRuntime.Package.Name {
+			continue
+		}
+		byVersion[runtime.Package.Version] = runtime
+		versions = append(versions, runtime.Package.Version)
+	}
+	path, err := update.FindUpgradePath(update.UpgradePathConfig{
+		From:     installedRuntime.Package.Version,
+		To:       &updateRuntime.Manifest,
+		Versions: versions,
+		GetRuntime: func(version string) (*schema.Manifest, error) {
+			runtime, ok := byVersion[version]
+			if !ok {
+				return nil, trace.NotFound("runtime %v not found", version)
+			}
+			return &runtime.Manifest, nil
+		},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	log.WithField("path", path).Info("Upgrade requires intermediate runtimes.")
+	for _, version := range path {
+		runtime := byVersion[version]
+		servers, err := hopUpdates(operator, operation, runtime, updateManifest, updates)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		hops = append(hops, upgradeHop{
+			runtime: runtime,
+			servers: servers,
+		})
+		updates = servers
+	}
+	return hops, nil
+}
+
+// hopUpdates computes the server updates to the specified intermediate runtime.
+// previous lists the server updates of the previous step
+func hopUpdates(
+	operator packageRotator,
+	operation ops.SiteOperationKey,
+	runtime app.Application,
+	updateManifest schema.Manifest,
+	previous []storage.UpdateServer,
+) (updates []storage.UpdateServer, err error) {
+	runtimePackage, err := runtime.Manifest.DefaultRuntimePackage()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, server := range previous {
+		installed := server.Runtime.Installed
+		if server.Runtime.Update != nil {
+			installed = server.Runtime.Update.Package
+		}
+		updateServer := storage.UpdateServer{
+			Server: server.Server,
+			Runtime: storage.RuntimePackage{
+				Installed:      installed,
+				SecretsPackage: server.Runtime.SecretsPackage,
+			},
+			Teleport: storage.TeleportPackage{
+				Installed: server.Teleport.Installed,
+			},
+		}
+		if !installed.IsEqualTo(*runtimePackage) {
+			configUpdate, err := operator.RotatePlanetConfig(ops.RotatePlanetConfigRequest{
+				Key:            operation,
+				Server:         server.Server,
+				Manifest:       updateManifest,
+				RuntimePackage: *runtimePackage,
+				DryRun:         true,
+			})
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			updateServer.Runtime.Update = &storage.RuntimeUpdate{
+				Package:       *runtimePackage,
+				ConfigPackage: configUpdate.Locator,
+			}
+		}
+		updates = append(updates, updateServer)
+	}
+	return updates, nil
+}
+
+// newHopPhase returns a new phase that upgrades the cluster from the installed
+// to the specified intermediate runtime
+func newHopPhase(p planConfig, hop upgradeHop, installedRuntime app.Application, supportsTaints bool) (*update.Phase, error) {
+	p.servers = hop.servers
+	p.installedRuntime = installedRuntime
+	p.updateRuntime = hop.runtime
+	p.changesetID = fmt.Sprintf("%v-%v", p.operation.ID, hop.runtime.Package.Version)
+	masters, nodes := update.SplitServers(p.servers)
+	if len(masters) == 0 {
+		return nil, trace.NotFound("no master servers found")
+	}
+	leadMaster := masters[0]
+	builder := phaseBuilder{planConfig: p}
+
+	root := update.RootPhase(update.Phase{
+		ID:          fmt.Sprintf("hop-%v", hop.runtime.Package.Version),
+		Description: fmt.Sprintf("Upgrade to intermediate runtime %v", hop.runtime.Package.Version),
+	})
+	mastersPhase := *builder.masters(leadMaster, masters[1:], supportsTaints)
+	nodesPhase := *builder.nodes(leadMaster, nodes, supportsTaints).Require(mastersPhase)
+	phases := []update.Phase{mastersPhase}
+	if len(nodesPhase.Phases) > 0 {
+		phases = append(phases, nodesPhase)
+	}
+
+	updateEtcd, currentVersion, desiredVersion, err := p.shouldUpdateEtcd(p)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if updateEtcd {
+		phases = append(phases, *builder.etcdPlan(leadMaster.Server, servers(masters[1:]...), servers(nodes...),
+			currentVersion, desiredVersion))
+	}
+
+	runtimeUpdates, err := getRuntimeUpdates(p)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(runtimeUpdates) > 0 {
+		phases = append(phases, *builder.runtime(runtimeUpdates).Require(mastersPhase))
+	}
+
+	for _, phase := range phases {
+		root.Add(update.Phase(nestPhase(root.ID, storage.OperationPhase(phase))))
+	}
+	return &root, nil
+}
+
+// nestPhase moves the specified phase with all its sub-phases under the parent
+// with the given ID by rewriting absolute phase IDs and requirements
+func nestPhase(parentID string, phase storage.OperationPhase) storage.OperationPhase {
+	if path.IsAbs(phase.ID) {
+		phase.ID = path.Join(parentID, phase.ID)
+	}
+	if phase.Requires != nil {
+		requires := make([]string, 0, len(phase.Requires))
+		for _, req := range phase.Requires {
+			if path.IsAbs(req) {
+				req = path.Join(parentID, req)
+			}
+			requires = append(requires, req)
+		}
+		phase.Requires = requires
+	}
+	if phase.Phases != nil {
+		phases := make([]storage.OperationPhase, 0, len(phase.Phases))
+		for _, sub := range phase.Phases {
+			phases = append(phases, nestPhase(parentID, sub))
+		}
+		phase.Phases = phases
+	}
+	return phase
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/rpc"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/utils"
@@ -69,6 +70,7 @@ func NewUpdatePhaseApp(
 			Package:        *p.Phase.Data.Package,
 			Servers:        p.Plan.Servers,
 			ServiceUser:    cluster.ServiceUser,
+			Recorder:       newHookRecorder(p, operator),
 		}}, nil
 }
 
@@ -122,11 +124,14 @@ type updatePhaseBeforeApp struct {
 	phaseApp
 }
 
-// NewUpdatePhaseBeforeApp returns a new executor for running application pre-update hook
+// NewUpdatePhaseBeforeApp returns a new executor for running application pre-update hook.
+// agents is used to run the hook if it is defined as a host script
 func NewUpdatePhaseBeforeApp(
 	p fsm.ExecutorParams,
+	operator ops.Operator,
 	apps app.Applications,
 	client *kubernetes.Clientset,
+	agents rpc.AgentRepository,
 	logger log.FieldLogger,
 ) (*updatePhaseBeforeApp, error) {
 	if p.Phase.Data.Package == nil {
@@ -140,6 +145,8 @@ func NewUpdatePhaseBeforeApp(
 			GravityPackage: p.Plan.GravityPackage,
 			Package:        *p.Phase.Data.Package,
 			Servers:        p.Plan.Servers,
+			Agents:         agents,
+			Recorder:       newHookRecorder(p, operator),
 		}}, nil
 }
 
@@ -170,6 +177,10 @@ type phaseApp struct {
 	Servers []storage.Server
 	// ServiceUser is the user used for services and system storage
 	ServiceUser storage.OSUser
+	// Recorder records the hook attempts with the operation
+	Recorder app.HookRecorder
+	// Agents provides access to the RPC agents that run host hooks
+	Agents rpc.AgentRepository
 	log.FieldLogger
 }
 
@@ -194,8 +205,9 @@ func (p *phaseApp) runHooks(ctx context.Context, hooks ...schema.HookType) error
 				constants.ManualUpdateEnvVar: "true",
 			},
 			ServiceUser: p.ServiceUser,
+			Recorder:    p.Recorder,
 		}
-		_, err := app.CheckHasAppHook(p.Apps, req)
+		spec, err := app.CheckHasAppHook(p.Apps, req)
 		if err != nil {
 			if trace.IsNotFound(err) {
 				p.Debugf("%v does not have %v hook.", p.Package, hook)
@@ -207,7 +219,11 @@ func (p *phaseApp) runHooks(ctx context.Context, hooks ...schema.HookType) error
 		reader, writer := io.Pipe()
 		defer writer.Close()
 		go streamHook(hook, reader, p.FieldLogger)
-		_, err = app.StreamAppHook(ctx, p.Apps, req, writer)
+		if spec.Host != nil {
+			err = p.runHostHook(ctx, req, writer)
+		} else {
+			_, err = app.StreamAppHook(ctx, p.Apps, req, writer)
+		}
 		if err != nil {
 			return trace.Wrap(err, "%v(%v) hook failed", p.Package, hook)
 		}
@@ -215,6 +231,30 @@ func (p *phaseApp) runHooks(ctx context.Context, hooks ...schema.HookType) error
 	return nil
 }
 
+// runHostHook runs the specified host hook on the cluster servers
+func (p *phaseApp) runHostHook(ctx context.Context, req app.HookRunRequest, w io.WriteCloser) error {
+	defer w.Close()
+	if p.Agents == nil {
+		return trace.BadParameter("%v hook cannot run as a host script in this phase", req.Hook)
+	}
+	return app.RunHostHook(ctx, p.Apps, app.HostHookRequest{
+		Application: req.Application,
+		Hook:        req.Hook,
+		Servers:     p.Servers,
+		Runner:      app.NewAgentHostRunner(p.Agents, p.FieldLogger),
+		Recorder:    req.Recorder,
+	}, w)
+}
+
+// newHookRecorder returns a recorder for the hook attempts of the specified phase
+func newHookRecorder(p fsm.ExecutorParams, operator ops.Operator) ops.HookRecorder {
+	return ops.HookRecorder{
+		Operator: operator,
+		Key:      fsm.OperationKey(p.Plan),
+		PhaseID:  p.Phase.ID,
+	}
+}
+
 func streamHook(hook schema.HookType, reader io.ReadCloser, logger log.FieldLogger) {
 	defer reader.Close()
 	scanner := bufio.NewScanner(reader)
@@ -28,6 +28,7 @@ import (
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/state"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/utils"
@@ -39,11 +40,11 @@ import (
 // updatePhaseBootstrap is the executor for the update bootstrap phase.
 //
 // Bootstrapping entails a few activities executed on each server:
-//  - exporting a copy of the new gravity binary into the auxiliary location
-//	which is then used for update-related tasks
-//  - ensuring that all system directories exist and have proper permissions
-//  - pulling system updates
-//  - synchronizing the remote operation plan with the local backend
+//   - exporting a copy of the new gravity binary into the auxiliary location
+//     which is then used for update-related tasks
+//   - ensuring that all system directories exist and have proper permissions
+//   - pulling system updates
+//   - synchronizing the remote operation plan with the local backend
 type updatePhaseBootstrap struct {
 	// Packages is the cluster package service
 	Packages pack.PackageService
@@ -236,10 +237,15 @@ func (p *updatePhaseBootstrap) pullSystemUpdates() error {
 	}
 	for _, update := range updates {
 		p.Infof("Pulling package update: %v.", update)
+		var arch string
+		if schema.IsArchDependency(update) {
+			arch = p.Server.Arch
+		}
 		_, err := appservice.PullPackage(appservice.PackagePullRequest{
 			SrcPack: p.Packages,
 			DstPack: p.LocalPackages,
 			Package: update,
+			Arch:    arch,
 		})
 		if err != nil && !trace.IsAlreadyExists(err) {
 			return trace.Wrap(err)
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeapi "k8s.io/client-go/kubernetes"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// phaseVerifyCanary defines the operation of verifying that the upgraded
+// canary nodes stay healthy before the rest of the nodes are upgraded
+type phaseVerifyCanary struct {
+	log.FieldLogger
+	// Client specifies the kubernetes API client
+	Client *kubeapi.Clientset
+	// Servers is the list of canary servers
+	Servers []storage.Server
+	// Pause is how long the canary nodes should stay healthy
+	Pause time.Duration
+	// clusterServers is the list of all cluster servers
+	clusterServers []storage.Server
+}
+
+// NewPhaseVerifyCanary returns a new executor for verifying canary nodes
+func NewPhaseVerifyCanary(p fsm.ExecutorParams, client *kubeapi.Clientset, logger log.FieldLogger) (*phaseVerifyCanary, error) {
+	if p.Phase.Data == nil || p.Phase.Data.Update == nil || len(p.Phase.Data.Update.Servers) == 0 {
+		return nil, trace.NotFound("no canary servers specified for phase %q", p.Phase.ID)
+	}
+	if client == nil {
+		return nil, trace.BadParameter("phase %q must be run from a master node (requires kubernetes client)", p.Phase.ID)
+	}
+	var servers []storage.Server
+	for _, server := range p.Phase.Data.Update.Servers {
+		servers = append(servers, server.Server)
+	}
+	var pause time.Duration
+	if p.Phase.Data.Update.Strategy != nil {
+		pause = p.Phase.Data.Update.Strategy.CanaryPause
+	}
+	return &phaseVerifyCanary{
+		FieldLogger:    logger,
+		Client:         client,
+		Servers:        servers,
+		Pause:          pause,
+		clusterServers: p.Plan.Servers,
+	}, nil
+}
+
+// Execute verifies that the canary nodes stay ready and schedulable
+// for the duration of the canary pause
+func (p *phaseVerifyCanary) Execute(ctx context.Context) error {
+	p.Infof("Verify canary nodes stay healthy for %v.", p.Pause)
+	deadline := time.Now().Add(p.Pause)
+	ticker := time.NewTicker(defaults.CanaryCheckInterval)
+	defer ticker.Stop()
+	for {
+		for _, server := range p.Servers {
+			err := checkNodeHealthy(p.Client.CoreV1().Nodes(), server.KubeNodeID())
+			if err != nil {
+				return trace.Wrap(err, "canary node %v failed verification, "+
+					"fix the node and resume the operation or roll it back", server.Hostname)
+			}
+		}
+		if !time.Now().Before(deadline) {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return trace.Wrap(ctx.Err())
+		}
+	}
+}
+
+// Rollback is a no-op for this phase
+func (p *phaseVerifyCanary) Rollback(context.Context) error {
+	return nil
+}
+
+// PreCheck makes sure the phase is being executed on the correct server
+func (p *phaseVerifyCanary) PreCheck(context.Context) error {
+	return trace.Wrap(fsm.CheckMasterServer(p.clusterServers))
+}
+
+// PostCheck is no-op for this phase
+func (p *phaseVerifyCanary) PostCheck(context.Context) error {
+	return nil
+}
+
+// checkNodeHealthy returns an error if the specified node is not ready
+// or is not schedulable
+func checkNodeHealthy(client corev1.NodeInterface, name string) error {
+	node, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	if node.Spec.Unschedulable {
+		return trace.BadParameter("node %v is unschedulable", name)
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type != v1.NodeReady {
+			continue
+		}
+		if condition.Status != v1.ConditionTrue {
+			return trace.BadParameter("node %v is not ready: %v", name, condition.Message)
+		}
+		return nil
+	}
+	return trace.NotFound("node %v does not report readiness", name)
+}
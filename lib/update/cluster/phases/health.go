@@ -0,0 +1,323 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/httplib"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/status"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	kubeapi "k8s.io/client-go/kubernetes"
+)
+
+// phaseHealthGate defines the operation of verifying the cluster health
+// after a node has been upgraded
+type phaseHealthGate struct {
+	log.FieldLogger
+	// Client specifies the kubernetes API client
+	Client *kubeapi.Clientset
+	// Apps is the cluster application service
+	Apps app.Applications
+	// Server is the upgraded server
+	Server storage.Server
+	// Servers is the list of all cluster servers
+	Servers []storage.Server
+	// Package is the installed application package
+	Package loc.Locator
+	// ServiceUser is the user to run the status hook as
+	ServiceUser storage.OSUser
+	// Gates configures the health checks
+	Gates storage.HealthGates
+	// hasStatusHook is true if the application has a status hook
+	hasStatusHook bool
+	// httpClient is the client for custom HTTP checks and Prometheus queries
+	httpClient *http.Client
+}
+
+// NewPhaseHealthGate returns a new executor for verifying the cluster health
+func NewPhaseHealthGate(
+	p fsm.ExecutorParams,
+	operator ops.Operator,
+	apps app.Applications,
+	client *kubeapi.Clientset,
+	logger log.FieldLogger,
+) (*phaseHealthGate, error) {
+	data := p.Phase.Data
+	if data == nil || data.Server == nil {
+		return nil, trace.NotFound("no server specified for phase %q", p.Phase.ID)
+	}
+	if data.Package == nil {
+		return nil, trace.NotFound("no package specified for phase %q", p.Phase.ID)
+	}
+	if data.Update == nil || data.Update.Strategy == nil || data.Update.Strategy.HealthGates == nil {
+		return nil, trace.NotFound("no health gates specified for phase %q", p.Phase.ID)
+	}
+	if client == nil {
+		return nil, trace.BadParameter("phase %q must be run from a master node (requires kubernetes client)", p.Phase.ID)
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	application, err := apps.GetApp(*data.Package)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	gates := *data.Update.Strategy.HealthGates
+	if gates.Timeout == 0 {
+		gates.Timeout = defaults.HealthGateTimeout
+	}
+	return &phaseHealthGate{
+		FieldLogger:   logger,
+		Client:        client,
+		Apps:          apps,
+		Server:        *data.Server,
+		Servers:       p.Plan.Servers,
+		Package:       *data.Package,
+		ServiceUser:   cluster.ServiceUser,
+		Gates:         gates,
+		hasStatusHook: application.Manifest.HasHook(schema.HookStatus),
+		httpClient:    httplib.GetClient(false, httplib.WithTimeout(defaults.HealthGateRequestTimeout)),
+	}, nil
+}
+
+// Execute runs the health checks until they pass.
+// If the checks keep failing for longer than the gate timeout,
+// the phase fails with the last failure reason
+func (p *phaseHealthGate) Execute(ctx context.Context) error {
+	p.Infof("Verify cluster health after updating %v.", p.Server.Hostname)
+	deadline := time.Now().Add(p.Gates.Timeout)
+	ticker := time.NewTicker(defaults.HealthGateInterval)
+	defer ticker.Stop()
+	for {
+		err := p.check(ctx)
+		if err == nil {
+			return nil
+		}
+		p.Warnf("Health gate failed: %v.", err)
+		if !time.Now().Before(deadline) {
+			return trace.Wrap(err, "health gate for node %v has been failing for %v, "+
+				"fix the issue and resume the operation or roll it back",
+				p.Server.Hostname, p.Gates.Timeout)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return trace.Wrap(err)
+		}
+	}
+}
+
+// Rollback is a no-op for this phase
+func (p *phaseHealthGate) Rollback(context.Context) error {
+	return nil
+}
+
+// PreCheck makes sure the phase is being executed on the correct server
+func (p *phaseHealthGate) PreCheck(context.Context) error {
+	return trace.Wrap(fsm.CheckMasterServer(p.Servers))
+}
+
+// PostCheck is no-op for this phase
+func (p *phaseHealthGate) PostCheck(context.Context) error {
+	return nil
+}
+
+// check runs all health checks and returns the first failure
+func (p *phaseHealthGate) check(ctx context.Context) error {
+	if err := p.checkPlanet(ctx); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := p.checkPods(); err != nil {
+		return trace.Wrap(err)
+	}
+	for _, check := range p.Gates.HTTPChecks {
+		if err := p.checkHTTP(ctx, check); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	for _, query := range p.Gates.PrometheusQueries {
+		if err := p.checkPrometheus(ctx, query); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return trace.Wrap(p.checkStatusHook(ctx))
+}
+
+// checkPlanet verifies that planet agents report the cluster as running
+// and the upgraded node as healthy
+func (p *phaseHealthGate) checkPlanet(ctx context.Context) error {
+	agent, err := status.FromPlanetAgent(ctx, p.Servers)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if agent.GetSystemStatus() != agentpb.SystemStatus_Running {
+		return trace.BadParameter("cluster is not healthy: system status is %v", agent.SystemStatus)
+	}
+	for _, node := range agent.Nodes {
+		if node.AdvertiseIP != p.Server.AdvertiseIP {
+			continue
+		}
+		if node.Status != status.NodeHealthy {
+			return trace.BadParameter("node %v is %v: %v", p.Server.Hostname,
+				node.Status, strings.Join(node.FailedProbes, ", "))
+		}
+	}
+	return nil
+}
+
+// checkPods verifies that all pods scheduled on the upgraded node are ready
+func (p *phaseHealthGate) checkPods() error {
+	pods, err := p.Client.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", p.Server.KubeNodeID()).String(),
+	})
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	var notReady []string
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		if !isPodReady(pod) {
+			notReady = append(notReady, fmt.Sprintf("%v/%v", pod.Namespace, pod.Name))
+		}
+	}
+	if len(notReady) != 0 {
+		return trace.BadParameter("pods on node %v are not ready: %v",
+			p.Server.Hostname, strings.Join(notReady, ", "))
+	}
+	return nil
+}
+
+// checkHTTP verifies that the specified URL responds with a successful status code
+func (p *phaseHealthGate) checkHTTP(ctx context.Context, check string) error {
+	req, err := http.NewRequest(http.MethodGet, check, nil)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	resp, err := p.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return trace.Wrap(err, "health check %v failed", check)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return trace.BadParameter("health check %v failed: %v", check, resp.Status)
+	}
+	return nil
+}
+
+// checkPrometheus verifies that the specified Prometheus query returns no results
+func (p *phaseHealthGate) checkPrometheus(ctx context.Context, query string) error {
+	endpoint := fmt.Sprintf("%v/api/v1/query?%v", strings.TrimSuffix(p.Gates.PrometheusURL, "/"),
+		url.Values{"query": []string{query}}.Encode())
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	resp, err := p.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return trace.Wrap(err, "failed to run Prometheus query %q", query)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var result prometheusResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return trace.Wrap(err, "failed to parse Prometheus response: %s", body)
+	}
+	if result.Status != "success" {
+		return trace.BadParameter("Prometheus query %q failed: %v", query, result.Error)
+	}
+	if len(result.Data.Result) != 0 {
+		return trace.BadParameter("Prometheus query %q returned %v result(s)",
+			query, len(result.Data.Result))
+	}
+	return nil
+}
+
+// checkStatusHook runs the application status hook if the application has one
+func (p *phaseHealthGate) checkStatusHook(ctx context.Context) error {
+	if !p.hasStatusHook {
+		return nil
+	}
+	ref, out, err := app.RunAppHook(ctx, p.Apps, app.HookRunRequest{
+		Application: p.Package,
+		Hook:        schema.HookStatus,
+		ServiceUser: p.ServiceUser,
+	})
+	if ref != nil {
+		err := p.Apps.DeleteAppHookJob(ctx, app.DeleteAppHookJobRequest{
+			HookRef: *ref,
+			Cascade: true,
+		})
+		if err != nil {
+			p.Warnf("Failed to delete status hook %v: %v.", ref, trace.DebugReport(err))
+		}
+	}
+	if err != nil {
+		return trace.Wrap(err, "status hook failed: %s", out)
+	}
+	return nil
+}
+
+// isPodReady returns true if the specified pod has the ready condition
+func isPodReady(pod v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// prometheusResponse is the response of the Prometheus query API
+type prometheusResponse struct {
+	// Status is the status of the query
+	Status string `json:"status"`
+	// Error describes the query failure
+	Error string `json:"error,omitempty"`
+	// Data is the query result
+	Data struct {
+		// Result is the list of the returned series
+		Result []json.RawMessage `json:"result"`
+	} `json:"data"`
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	log "github.com/sirupsen/logrus"
+	. "gopkg.in/check.v1"
+)
+
+func TestPhases(t *testing.T) { TestingT(t) }
+
+type HealthGateSuite struct{}
+
+var _ = Suite(&HealthGateSuite{})
+
+func (_ *HealthGateSuite) TestRunsCustomChecks(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			w.WriteHeader(http.StatusOK)
+		case "/api/v1/query":
+			if r.URL.Query().Get("query") == `ALERTS{alertstate="firing"}` {
+				fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[0,"1"]}]}}`)
+				return
+			}
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	gate := &phaseHealthGate{
+		FieldLogger: log.StandardLogger(),
+		Gates:       storage.HealthGates{PrometheusURL: server.URL},
+		httpClient:  server.Client(),
+	}
+	ctx := context.TODO()
+	c.Assert(gate.checkHTTP(ctx, server.URL+"/healthz"), IsNil)
+	c.Assert(gate.checkHTTP(ctx, server.URL+"/unavailable"), NotNil)
+	c.Assert(gate.checkPrometheus(ctx, `up == 0`), IsNil)
+	c.Assert(gate.checkPrometheus(ctx, `ALERTS{alertstate="firing"}`), NotNil)
+}
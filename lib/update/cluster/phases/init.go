@@ -41,6 +41,7 @@ import (
 // updatePhaseInit is the update init phase which performs the following:
 //   - generate new secrets
 //   - generate new planet container configuration where necessary
+//     (including the configuration for intermediate runtimes)
 //   - verifies that the admin agent user exists
 //   - updates the cluster with service user details
 //   - cleans up state left from previous versions
@@ -61,6 +62,8 @@ type updatePhaseInit struct {
 	Operation ops.SiteOperation
 	// Servers is the list of local cluster servers
 	Servers []storage.UpdateServer
+	// Hops lists the intermediate runtime updates
+	Hops []storage.UpdateHop
 	// FieldLogger is used for logging
 	log.FieldLogger
 	// updateManifest specifies the manifest of the update application
@@ -138,6 +141,7 @@ func NewUpdatePhaseInit(
 		Cluster:               *cluster,
 		Operation:             *operation,
 		Servers:               p.Phase.Data.Update.Servers,
+		Hops:                  p.Phase.Data.Update.Hops,
 		FieldLogger:           logger,
 		updateManifest:        app.Manifest,
 		installedApp:          *installedApp,
@@ -197,6 +201,17 @@ func (p *updatePhaseInit) Execute(context.Context) error {
 			}
 		}
 	}
+	for _, hop := range p.Hops {
+		for _, server := range hop.Servers {
+			if server.Runtime.Update == nil {
+				continue
+			}
+			if err := p.rotatePlanetConfig(server); err != nil {
+				return trace.Wrap(err, "failed to rotate planet configuration for %v (runtime %v)",
+					server, hop.Runtime)
+			}
+		}
+	}
 	return nil
 }
 
@@ -18,14 +18,23 @@ package phases
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/kubernetes"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/update"
+	"github.com/gravitational/gravity/lib/utils"
 
+	"github.com/cenkalti/backoff"
 	"github.com/gravitational/rigging"
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
@@ -105,28 +114,143 @@ func (p *phaseUntaint) Rollback(context.Context) error {
 // phaseDrain defines the operation of draining a node
 type phaseDrain struct {
 	kubernetesOperation
+	// Operator is the cluster operator service
+	Operator ops.Operator
+	// Apps is the cluster application service
+	Apps app.Applications
+	// Package is the installed application package that can define
+	// the node drain hooks
+	Package *loc.Locator
+	// ServiceUser is the user to run the drain hooks as
+	ServiceUser storage.OSUser
+	// Policy defines how pods are evicted from the node
+	Policy storage.DrainPolicy
+	// key identifies the update operation
+	key ops.SiteOperationKey
+	// step is the step of this phase used for progress reporting
+	step int
+	// completion is the completion percentage of this phase
+	completion int
 }
 
 // NewPhaseDrain returns a new executor for draining a node
-func NewPhaseDrain(p fsm.ExecutorParams, client *kubeapi.Clientset, logger log.FieldLogger) (*phaseDrain, error) {
+func NewPhaseDrain(
+	p fsm.ExecutorParams,
+	operator ops.Operator,
+	apps app.Applications,
+	client *kubeapi.Clientset,
+	logger log.FieldLogger,
+) (*phaseDrain, error) {
 	op, err := newKubernetesOperation(p, client, logger)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var policy storage.DrainPolicy
+	if p.Phase.Data.Update != nil && p.Phase.Data.Update.DrainPolicy != nil {
+		policy = *p.Phase.Data.Update.DrainPolicy
+	}
 	return &phaseDrain{
 		kubernetesOperation: *op,
+		Operator:            operator,
+		Apps:                apps,
+		Package:             p.Phase.Data.Package,
+		ServiceUser:         cluster.ServiceUser,
+		Policy:              policy,
+		key:                 p.Key(),
+		step:                p.Phase.Step,
+		completion:          100 / utils.Max(len(p.Plan.Phases), 1) * p.Phase.Step,
 	}, nil
 }
 
 // Execute drains the specified node
 func (p *phaseDrain) Execute(ctx context.Context) error {
+	if err := p.runHook(ctx, schema.HookNodeDraining); err != nil {
+		return trace.Wrap(err)
+	}
 	p.Infof("Drain %v.", p.Server)
 	ctx, cancel := context.WithTimeout(ctx, defaults.DrainTimeout)
 	defer cancel()
 	err := update.Retry(ctx, func() error {
-		return trace.Wrap(drain(ctx, p.Client, p.Server.KubeNodeID()))
+		err := kubernetes.DrainNode(ctx, kubernetes.DrainConfig{
+			Client:   p.Client,
+			NodeName: p.Server.KubeNodeID(),
+			Policy:   p.Policy,
+			Progress: p.reportBlocking,
+		})
+		if trace.IsLimitExceeded(err) {
+			// Do not retry evictions blocked by disruption budgets
+			// beyond the timeout specified with the drain policy
+			return &backoff.PermanentError{Err: err}
+		}
+		return trace.Wrap(err)
 	}, defaults.DrainErrorTimeout)
-	return trace.Wrap(err)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(p.runHook(ctx, schema.HookNodeDrained))
+}
+
+// reportBlocking creates a progress entry with the pods blocking the drain
+func (p *phaseDrain) reportBlocking(pods []string) {
+	message := fmt.Sprintf("Drain node %q", p.Server.Hostname)
+	if len(pods) != 0 {
+		message = fmt.Sprintf("Drain node %q: waiting for pods blocked by disruption budgets: %v",
+			p.Server.Hostname, strings.Join(pods, ", "))
+		p.Info(message)
+	}
+	err := p.Operator.CreateProgressEntry(p.key, ops.ProgressEntry{
+		SiteDomain:  p.key.SiteDomain,
+		OperationID: p.key.OperationID,
+		Completion:  p.completion,
+		Step:        p.step,
+		State:       ops.ProgressStateInProgress,
+		Message:     message,
+		Created:     time.Now().UTC(),
+	})
+	if err != nil {
+		p.Warnf("Failed to create progress entry: %v.", err)
+	}
+}
+
+// runHook runs the specified node drain hook if the application defines it
+func (p *phaseDrain) runHook(ctx context.Context, hook schema.HookType) error {
+	if p.Package == nil {
+		return nil
+	}
+	req := app.HookRunRequest{
+		Application: *p.Package,
+		Hook:        hook,
+		Env: map[string]string{
+			constants.DrainNodeEnvVar: p.Server.KubeNodeID(),
+		},
+		ServiceUser: p.ServiceUser,
+	}
+	_, err := app.CheckHasAppHook(p.Apps, req)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	p.Infof("Execute %v(%v) hook for %v.", p.Package, hook, p.Server.Hostname)
+	ref, out, err := app.RunAppHook(ctx, p.Apps, req)
+	if ref != nil {
+		err := p.Apps.DeleteAppHookJob(ctx, app.DeleteAppHookJobRequest{
+			HookRef: *ref,
+			Cascade: true,
+		})
+		if err != nil {
+			p.Warnf("Failed to delete %v hook %v: %v.", hook, ref, trace.DebugReport(err))
+		}
+	}
+	if err != nil {
+		return trace.Wrap(err, "%v hook failed: %s", hook, out)
+	}
+	return nil
 }
 
 // Rollback reverts the effect of drain by uncordoning the node
@@ -288,11 +412,6 @@ func taint(ctx context.Context, client corev1.NodeInterface, node string, add ad
 	return nil
 }
 
-func drain(ctx context.Context, client *kubeapi.Clientset, node string) error {
-	err := kubernetes.Drain(ctx, client, node)
-	return trace.Wrap(err)
-}
-
 func uncordon(ctx context.Context, client corev1.NodeInterface, node string) error {
 	err := kubernetes.SetUnschedulable(ctx, client, node, false)
 	return trace.Wrap(err)
@@ -124,6 +124,15 @@ func NewOperationPlan(config PlanConfig) (*storage.OperationPlan, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	var strategy storage.UpdateStrategy
+	if config.Operation.Update.Strategy != nil {
+		strategy = *config.Operation.Update.Strategy
+	}
+	canaryNodes, err := getCanaryNodes(strategy, config.Client.CoreV1().Nodes())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	updateCoreDNS, err := shouldUpdateCoreDNS(config.Client)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -181,6 +190,23 @@ func NewOperationPlan(config PlanConfig) (*storage.OperationPlan, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	hops, err := getUpgradeHops(config.Apps, config.Operator,
+		(*ops.SiteOperation)(config.Operation).Key(),
+		*installedRuntime, *updateRuntime, updateApp.Manifest, updates)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(hops) != 0 {
+		// The update runtime is installed on top of the last intermediate runtime
+		lastHop := hops[len(hops)-1]
+		for i := range updates {
+			updates[i].Runtime.Installed = lastHop.servers[i].Runtime.Installed
+			if lastHop.servers[i].Runtime.Update != nil {
+				updates[i].Runtime.Installed = lastHop.servers[i].Runtime.Update.Package
+			}
+		}
+	}
+
 	gravityPackage, err := updateRuntime.Manifest.Dependencies.ByName(constants.GravityPackage)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -211,6 +237,10 @@ func NewOperationPlan(config PlanConfig) (*storage.OperationPlan, error) {
 		dnsConfig:         config.DNSConfig,
 		updateDNSAppEarly: updateDNSAppEarly,
 		roles:             roles,
+		strategy:          strategy,
+		drainPolicy:       config.Operation.Update.DrainPolicy,
+		canaryNodes:       canaryNodes,
+		hops:              hops,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -287,6 +317,17 @@ type planConfig struct {
 	updateDNSAppEarly bool
 	// roles is the existing cluster roles
 	roles []teleservices.Role
+	// strategy defines the order in which regular nodes are upgraded
+	strategy storage.UpdateStrategy
+	// drainPolicy optionally defines how nodes are drained
+	drainPolicy *storage.DrainPolicy
+	// canaryNodes lists names of the Kubernetes nodes selected as canaries
+	canaryNodes []string
+	// hops lists the intermediate runtimes to upgrade to before the update runtime
+	hops []upgradeHop
+	// changesetID optionally specifies the ID of the system package changesets.
+	// Set for intermediate runtime upgrades
+	changesetID string
 }
 
 func newOperationPlan(p planConfig) (*storage.OperationPlan, error) {
@@ -316,24 +357,29 @@ func newOperationPlan(p planConfig) (*storage.OperationPlan, error) {
 		log.Debugf("No support for taints/tolerations for %v.", installedGravityPackage)
 	}
 
+	var hopPhases []update.Phase
+	installedRuntime := p.installedRuntime
+	for _, hop := range p.hops {
+		hopPhase, err := newHopPhase(p, hop, installedRuntime, supportsTaints)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		hopPhases = append(hopPhases, *hopPhase)
+		installedRuntime = hop.runtime
+	}
+	// The rest of the plan upgrades from the last intermediate runtime
+	p.installedRuntime = installedRuntime
+
 	mastersPhase := *builder.masters(leadMaster, masters[1:], supportsTaints).
 		Require(checksPhase, bootstrapPhase, preUpdatePhase)
 	nodesPhase := *builder.nodes(leadMaster, nodes, supportsTaints).
 		Require(mastersPhase)
 
-	allRuntimeUpdates, err := app.GetUpdatedDependencies(p.installedRuntime, p.updateRuntime)
-	if err != nil && !trace.IsNotFound(err) {
+	runtimeUpdates, err := getRuntimeUpdates(p)
+	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	// some system apps may need to be skipped depending on the manifest settings
-	runtimeUpdates := allRuntimeUpdates[:0]
-	for _, locator := range allRuntimeUpdates {
-		if !schema.ShouldSkipApp(p.updateApp.Manifest, locator) {
-			runtimeUpdates = append(runtimeUpdates, locator)
-		}
-	}
-
 	appUpdates, err := app.GetUpdatedDependencies(p.installedApp, p.updateApp)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -364,7 +410,18 @@ func newOperationPlan(p planConfig) (*storage.OperationPlan, error) {
 			}
 		}
 
-		root.Add(bootstrapPhase, mastersPhase)
+		root.Add(bootstrapPhase)
+		if len(hopPhases) != 0 {
+			// Intermediate runtimes are upgraded to one after another before the master nodes
+			hopPhases[0].Requires = mastersPhase.Requires
+			for i := 1; i < len(hopPhases); i++ {
+				hopPhases[i].Require(hopPhases[i-1])
+			}
+			mastersPhase.Requires = nil
+			mastersPhase.Require(hopPhases[len(hopPhases)-1])
+			root.Add(hopPhases...)
+		}
+		root.Add(mastersPhase)
 		if len(nodesPhase.Phases) > 0 {
 			root.Add(nodesPhase)
 		}
@@ -400,6 +457,24 @@ func newOperationPlan(p planConfig) (*storage.OperationPlan, error) {
 	return &plan, nil
 }
 
+// getRuntimeUpdates returns the list of system applications to update
+// from the installed to the update runtime
+func getRuntimeUpdates(p planConfig) ([]loc.Locator, error) {
+	allRuntimeUpdates, err := app.GetUpdatedDependencies(p.installedRuntime, p.updateRuntime)
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+
+	// some system apps may need to be skipped depending on the manifest settings
+	runtimeUpdates := allRuntimeUpdates[:0]
+	for _, locator := range allRuntimeUpdates {
+		if !schema.ShouldSkipApp(p.updateApp.Manifest, locator) {
+			runtimeUpdates = append(runtimeUpdates, locator)
+		}
+	}
+	return runtimeUpdates, nil
+}
+
 // configUpdates computes the configuration updates for the specified list of servers
 func configUpdates(
 	installed, update schema.Manifest,
@@ -446,7 +521,7 @@ func configUpdates(
 			return nil, trace.Wrap(err)
 		}
 		if needsPlanetUpdate {
-			updateRuntime, err := update.RuntimePackageForProfile(server.Role)
+			updateRuntime, err := update.RuntimePackageForArch(server.Role, server.Arch)
 			if err != nil {
 				return nil, trace.Wrap(err)
 			}
@@ -484,6 +559,25 @@ func configUpdates(
 	return updates, nil
 }
 
+// getCanaryNodes returns names of the Kubernetes nodes matching
+// the canary selector of the specified update strategy
+func getCanaryNodes(strategy storage.UpdateStrategy, client corev1.NodeInterface) (names []string, err error) {
+	if strategy.CanarySelector == "" {
+		return nil, nil
+	}
+	nodes, err := client.List(metav1.ListOptions{LabelSelector: strategy.CanarySelector})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	for _, node := range nodes.Items {
+		names = append(names, node.Name)
+	}
+	if len(names) == 0 {
+		return nil, trace.NotFound("no nodes match canary selector %q", strategy.CanarySelector)
+	}
+	return names, nil
+}
+
 func checkAndSetServerDefaults(servers []storage.Server, client corev1.NodeInterface) ([]storage.Server, error) {
 	nodes, err := utils.GetNodes(client)
 	if err != nil {
@@ -160,10 +160,14 @@ func (r *Engine) ChangePhaseState(ctx context.Context, change fsm.StateChange) e
 	return nil
 }
 
-// RunCommand executes the phase specified by params on the specified server
+// RunCommand executes or rolls back the phase specified by params on the specified server
 // using the provided runner
 func (r *Engine) RunCommand(ctx context.Context, runner fsm.RemoteRunner, server storage.Server, params fsm.Params) error {
-	args := []string{"plan", "execute",
+	command := "execute"
+	if params.Rollback {
+		command = "rollback"
+	}
+	args := []string{"plan", command,
 		"--phase", params.PhaseID,
 		"--operation-id", r.Operation.ID,
 	}
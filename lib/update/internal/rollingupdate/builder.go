@@ -22,7 +22,7 @@ func RuntimeConfigUpdates(
 ) (updates []storage.UpdateServer, err error) {
 	updates = make([]storage.UpdateServer, 0, len(servers))
 	for _, server := range servers {
-		runtimePackage, err := manifest.RuntimePackageForProfile(server.Role)
+		runtimePackage, err := manifest.RuntimePackageForArch(server.Role, server.Arch)
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
@@ -102,7 +102,9 @@ func (r Builder) Masters(servers []storage.UpdateServer, rootText, nodeTextForma
 	return &root
 }
 
-// Nodes returns a new phase to execute a rolling update of the specified list of regular servers
+// Nodes returns a new phase to execute a rolling update of the specified list of regular servers.
+// Servers are updated one at a time so at most one node is unavailable which satisfies
+// any storage.UpdateStrategy.MaxUnavailable limit
 func (r Builder) Nodes(servers []storage.UpdateServer, master storage.Server, rootText, nodeTextFormat string) *update.Phase {
 	root := update.RootPhase(update.Phase{
 		ID:          "nodes",
@@ -0,0 +1,178 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package update
+
+import (
+	"sort"
+
+	"github.com/gravitational/gravity/lib/schema"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/gravitational/trace"
+)
+
+// UpgradePathConfig describes the input of the upgrade path planner
+type UpgradePathConfig struct {
+	// From is the version of the installed runtime
+	From string
+	// To is the manifest of the target runtime
+	To *schema.Manifest
+	// Versions lists the versions of the available intermediate runtimes
+	Versions []string
+	// GetRuntime returns the manifest of the runtime with the specified version
+	GetRuntime func(version string) (*schema.Manifest, error)
+}
+
+// CheckAndSetDefaults validates the configuration
+func (r *UpgradePathConfig) CheckAndSetDefaults() error {
+	if r.From == "" {
+		return trace.BadParameter("installed runtime version is required")
+	}
+	if r.To == nil {
+		return trace.BadParameter("target runtime manifest is required")
+	}
+	if r.GetRuntime == nil {
+		return trace.BadParameter("GetRuntime is required")
+	}
+	return nil
+}
+
+// FindUpgradePath computes the chain of intermediate runtimes required
+// to upgrade from the installed runtime to the target runtime.
+//
+// Each runtime declares the range of runtime versions it can be upgraded
+// from directly (see schema.SystemOptions.UpgradeFrom).
+// The planner prefers the shortest path and, for the same length,
+// the highest intermediate versions.
+// Returns the intermediate versions in upgrade order - the list is nil
+// if the target runtime can be upgraded to directly
+func FindUpgradePath(config UpgradePathConfig) ([]string, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	from, err := semver.NewVersion(config.From)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	to, err := semver.NewVersion(config.To.Metadata.ResourceVersion)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var versions []semver.Version
+	for _, version := range config.Versions {
+		ver, err := semver.NewVersion(version)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if from.LessThan(*ver) && ver.LessThan(*to) {
+			versions = append(versions, *ver)
+		}
+	}
+	// Higher versions first
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[j].LessThan(versions[i])
+	})
+	planner := pathPlanner{
+		config:    config,
+		versions:  versions,
+		manifests: make(map[string]*schema.Manifest),
+		paths:     make(map[string][]string),
+	}
+	path, err := planner.find(config.To, *to)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if path == nil {
+		return nil, trace.NotFound("no upgrade path from %v to %v, "+
+			"make sure the intermediate runtimes are available", config.From, to)
+	}
+	if len(path) == 1 {
+		return nil, nil
+	}
+	return path[:len(path)-1], nil
+}
+
+type pathPlanner struct {
+	config UpgradePathConfig
+	// versions lists candidate intermediate versions in descending order
+	versions []semver.Version
+	// manifests caches runtime manifests by version
+	manifests map[string]*schema.Manifest
+	// paths caches computed paths by target version.
+	// A nil path means the target cannot be reached
+	paths map[string][]string
+}
+
+// find returns the shortest upgrade path to the runtime with the specified
+// manifest and version. The path ends with the target version
+func (r *pathPlanner) find(manifest *schema.Manifest, version semver.Version) ([]string, error) {
+	if path, ok := r.paths[version.String()]; ok {
+		return path, nil
+	}
+	// Mark as unreachable while the search is in progress
+	r.paths[version.String()] = nil
+	supported, err := manifest.SupportsUpgradeFrom(r.config.From)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if supported {
+		r.paths[version.String()] = []string{version.String()}
+		return r.paths[version.String()], nil
+	}
+	var shortest []string
+	for _, candidate := range r.versions {
+		if !candidate.LessThan(version) {
+			continue
+		}
+		supported, err := manifest.SupportsUpgradeFrom(candidate.String())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if !supported {
+			continue
+		}
+		candidateManifest, err := r.getRuntime(candidate.String())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		path, err := r.find(candidateManifest, candidate)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if path != nil && (shortest == nil || len(path) < len(shortest)) {
+			shortest = path
+		}
+	}
+	if shortest == nil {
+		return nil, nil
+	}
+	path := append(append([]string{}, shortest...), version.String())
+	r.paths[version.String()] = path
+	return path, nil
+}
+
+func (r *pathPlanner) getRuntime(version string) (*schema.Manifest, error) {
+	if manifest, ok := r.manifests[version]; ok {
+		return manifest, nil
+	}
+	manifest, err := r.config.GetRuntime(version)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	r.manifests[version] = manifest
+	return manifest, nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package update
+
+import (
+	"testing"
+
+	"github.com/gravitational/gravity/lib/schema"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+func TestUpdate(t *testing.T) { TestingT(t) }
+
+type PathSuite struct{}
+
+var _ = Suite(&PathSuite{})
+
+func (s *PathSuite) TestFindsUpgradePath(c *C) {
+	runtimes := map[string]*schema.Manifest{
+		"5.2.0": newRuntime("5.2.0", ">=5.0.0, <5.2.0"),
+		"5.3.0": newRuntime("5.3.0", ">=5.2.0, <5.3.0"),
+		"5.4.0": newRuntime("5.4.0", ">=5.2.0, <5.4.0"),
+		"5.5.0": newRuntime("5.5.0", ">=5.4.0, <5.5.0"),
+	}
+	getRuntime := func(version string) (*schema.Manifest, error) {
+		manifest, ok := runtimes[version]
+		if !ok {
+			return nil, trace.NotFound("runtime %v not found", version)
+		}
+		return manifest, nil
+	}
+	var testCases = []struct {
+		comment string
+		from    string
+		path    []string
+		err     bool
+	}{
+		{
+			comment: "direct upgrade",
+			from:    "5.4.2",
+			path:    nil,
+		},
+		{
+			comment: "skips intermediate versions where possible",
+			from:    "5.2.1",
+			path:    []string{"5.4.0"},
+		},
+		{
+			comment: "multiple hops",
+			from:    "5.0.35",
+			path:    []string{"5.2.0", "5.4.0"},
+		},
+		{
+			comment: "no path",
+			from:    "4.68.0",
+			err:     true,
+		},
+	}
+	for _, tc := range testCases {
+		comment := Commentf(tc.comment)
+		path, err := FindUpgradePath(UpgradePathConfig{
+			From:       tc.from,
+			To:         runtimes["5.5.0"],
+			Versions:   []string{"5.2.0", "5.3.0", "5.4.0", "5.5.0"},
+			GetRuntime: getRuntime,
+		})
+		if tc.err {
+			c.Assert(trace.IsNotFound(err), Equals, true, comment)
+			continue
+		}
+		c.Assert(err, IsNil, comment)
+		c.Assert(path, DeepEquals, tc.path, comment)
+	}
+}
+
+func newRuntime(version, upgradeFrom string) *schema.Manifest {
+	return &schema.Manifest{
+		Metadata: schema.Metadata{
+			Name:            "kubernetes",
+			ResourceVersion: version,
+		},
+		SystemOptions: &schema.SystemOptions{
+			UpgradeFrom: upgradeFrom,
+		},
+	}
+}
@@ -23,15 +23,18 @@ import (
 	"time"
 
 	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/rpc"
+	"github.com/gravitational/gravity/lib/status"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/utils"
 
+	"github.com/gravitational/satellite/agent/proto/agentpb"
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
 )
@@ -55,6 +58,9 @@ func NewUpdater(ctx context.Context, config Config, machine *fsm.FSM) (*Updater,
 
 // Run executes the operation plan to completion
 func (r *Updater) Run(ctx context.Context, force bool) (err error) {
+	if err := r.waitForMaintenanceWindow(ctx); err != nil {
+		return trace.Wrap(err)
+	}
 	errCh := make(chan error, 1)
 	go func() {
 		errCh <- r.executePlan(ctx, force)
@@ -80,12 +86,34 @@ L:
 	return trace.Wrap(err)
 }
 
+// waitForMaintenanceWindow blocks until the operation is launched if it
+// has been scheduled for the cluster maintenance window
+func (r *Updater) waitForMaintenanceWindow(ctx context.Context) error {
+	if r.Operation == nil || !r.Operation.IsScheduled() {
+		return nil
+	}
+	if r.Operation.Schedule != nil {
+		r.Silent.Printf("Maintenance window is closed, operation will start after %v.\n",
+			r.Operation.Schedule.StartAfter.Format(constants.HumanDateFormat))
+	}
+	operation, err := ops.WaitForScheduledOperation(ctx, r.Operator, r.Operation.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.Operation = operation
+	return nil
+}
+
 // RunPhase runs the specified phase.
 func (r *Updater) RunPhase(ctx context.Context, phase string, phaseTimeout time.Duration, force bool) error {
 	if phase == fsm.RootPhase {
 		return trace.Wrap(r.Run(ctx, force))
 	}
 
+	if err := ops.CheckOperationLaunched(r.Operator, r.Operation.Key()); err != nil {
+		return trace.Wrap(err)
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, phaseTimeout)
 	defer cancel()
 
@@ -114,6 +142,23 @@ func (r *Updater) RollbackPhase(ctx context.Context, phase string, phaseTimeout
 	}))
 }
 
+// RollbackPlan rolls back all phases of the operation plan that have been
+// started, verifies that the cluster is healthy and marks the operation
+// as rolled back
+func (r *Updater) RollbackPlan(ctx context.Context, timeout time.Duration, force bool) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	progress := utils.NewProgress(ctx, fmt.Sprintf("Rolling back %v", formatOperation(*r.Operation)), -1, false)
+	defer progress.Stop()
+
+	if err := r.rollbackPlan(ctx, progress, force); err != nil {
+		return trace.Wrap(err)
+	}
+	r.shutdownAgents(ctx)
+	return nil
+}
+
 // Complete completes the active operation
 func (r *Updater) Complete(fsmErr error) error {
 	if fsmErr == nil {
@@ -147,6 +192,9 @@ func (r *Updater) executePlan(ctx context.Context, force bool) error {
 	planErr := r.machine.ExecutePlan(ctx, progress, force)
 	if planErr != nil {
 		r.Warnf("Failed to execute plan: %v.", trace.DebugReport(planErr))
+		if r.autoRollback() {
+			return trace.Wrap(r.autoRollbackPlan(ctx, progress, planErr))
+		}
 	}
 
 	err := r.machine.Complete(planErr)
@@ -159,14 +207,77 @@ func (r *Updater) executePlan(ctx context.Context, force bool) error {
 		return trace.Wrap(err)
 	}
 
+	r.shutdownAgents(ctx)
+	return nil
+}
+
+// autoRollback returns true if the operation should be rolled back
+// automatically on failure
+func (r *Updater) autoRollback() bool {
+	return r.Operation.Update != nil && r.Operation.Update.Strategy != nil &&
+		r.Operation.Update.Strategy.AutoRollback
+}
+
+// autoRollbackPlan rolls back the operation after the plan has failed with planErr
+func (r *Updater) autoRollbackPlan(ctx context.Context, progress utils.Progress, planErr error) error {
+	r.Info("Rolling back the operation automatically.")
+	progress.NextStep("Operation failed, rolling back")
+	err := r.rollbackPlan(ctx, progress, false)
+	if err != nil {
+		r.Warnf("Failed to roll back operation: %v.", trace.DebugReport(err))
+		if errComplete := r.machine.Complete(planErr); errComplete != nil {
+			r.Warnf("Failed to mark operation failed: %v.", trace.DebugReport(errComplete))
+		}
+		return trace.NewAggregate(planErr, err)
+	}
+	r.shutdownAgents(ctx)
+	return trace.Wrap(planErr, "operation has been rolled back")
+}
+
+// rollbackPlan rolls back the plan and marks the operation as rolled back
+// once the cluster is healthy
+func (r *Updater) rollbackPlan(ctx context.Context, progress utils.Progress, force bool) error {
+	if err := r.machine.RollbackPlan(ctx, progress, force); err != nil {
+		return trace.Wrap(err)
+	}
+	progress.NextStep("Verifying cluster health")
+	if err := r.waitForHealthy(ctx); err != nil {
+		return trace.Wrap(err)
+	}
+	key := r.Operation.Key()
+	err := ops.RollbackOperation(key, fsm.OperationStateSetter(key, r.Operator, r.LocalBackend), "")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = r.Operator.ActivateSite(ops.ActivateSiteRequest{
+		AccountID:  key.AccountID,
+		SiteDomain: key.SiteDomain,
+	})
+	return trace.Wrap(err)
+}
+
+// waitForHealthy blocks until planet agents report the cluster as running
+func (r *Updater) waitForHealthy(ctx context.Context) error {
+	return utils.RetryFor(ctx, defaults.RollbackHealthTimeout, func() error {
+		agent, err := status.FromPlanetAgent(ctx, r.servers)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if agent.GetSystemStatus() != agentpb.SystemStatus_Running {
+			return trace.BadParameter("cluster is not healthy: system status is %v", agent.SystemStatus)
+		}
+		return nil
+	})
+}
+
+func (r *Updater) shutdownAgents(ctx context.Context) {
 	var addrs []string
 	for _, server := range r.servers {
 		addrs = append(addrs, server.AdvertiseIP)
 	}
-	if errShutdown := rpc.ShutdownAgents(ctx, addrs, r.FieldLogger, r.Runner); errShutdown != nil {
-		r.Warnf("Failed to shutdown agents: %v.", trace.DebugReport(errShutdown))
+	if err := rpc.ShutdownAgents(ctx, addrs, r.FieldLogger, r.Runner); err != nil {
+		r.Warnf("Failed to shutdown agents: %v.", trace.DebugReport(err))
 	}
-	return nil
 }
 
 func (r *Updater) updateProgress(lastProgress *ops.ProgressEntry) *ops.ProgressEntry {
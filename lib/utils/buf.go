@@ -19,6 +19,7 @@ package utils
 import (
 	"bytes"
 	"io"
+	"sync"
 )
 
 // NewSyncBuffer returns new in memory buffer
@@ -70,3 +71,36 @@ func (b *SyncBuffer) Close() error {
 	}
 	return err2
 }
+
+// NewTailBuffer returns a new in memory buffer that keeps
+// only the last max bytes written to it
+func NewTailBuffer(max int) *TailBuffer {
+	return &TailBuffer{max: max}
+}
+
+// TailBuffer is in memory bytes buffer that is safe for
+// concurrent writes and keeps only the tail of the written data
+type TailBuffer struct {
+	sync.Mutex
+	buf []byte
+	max int
+}
+
+// Write appends data to the buffer discarding the oldest data
+// once the buffer exceeds its capacity
+func (b *TailBuffer) Write(data []byte) (n int, err error) {
+	b.Lock()
+	defer b.Unlock()
+	b.buf = append(b.buf, data...)
+	if len(b.buf) > b.max {
+		b.buf = append([]byte(nil), b.buf[len(b.buf)-b.max:]...)
+	}
+	return len(data), nil
+}
+
+// String returns contents of the buffer
+func (b *TailBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return string(b.buf)
+}
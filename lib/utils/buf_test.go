@@ -0,0 +1,37 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"io"
+
+	"gopkg.in/check.v1"
+)
+
+type BufSuite struct{}
+
+var _ = check.Suite(&BufSuite{})
+
+func (s *BufSuite) TestTailBufferKeepsTail(c *check.C) {
+	buf := NewTailBuffer(8)
+	io.WriteString(buf, "hello")
+	c.Assert(buf.String(), check.Equals, "hello")
+	n, err := io.WriteString(buf, ", world")
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 7)
+	c.Assert(buf.String(), check.Equals, "o, world")
+}
@@ -55,7 +55,8 @@ func hasVar(name []string, vals map[string]interface{}) bool {
 // ParseChartFilename returns chart name and version from the provided chart
 // package filename generated by ToChartFilename function below.
 func ParseChartFilename(filename string) (name, version string, err error) {
-	parts := strings.Split(strings.TrimSuffix(filename, ".tgz"), "-")
+	parts := strings.Split(strings.TrimSuffix(strings.TrimSuffix(
+		filename, provenanceExtension), ".tgz"), "-")
 	if len(parts) < 2 {
 		return "", "", trace.BadParameter("bad chart filename: %v", filename)
 	}
@@ -67,6 +68,17 @@ func ToChartFilename(name, version string) string {
 	return fmt.Sprintf("%v-%v.tgz", name, version)
 }
 
+// ToProvenanceFilename returns a chart provenance filename for the provided name/version.
+func ToProvenanceFilename(name, version string) string {
+	return ToChartFilename(name, version) + provenanceExtension
+}
+
+// IsProvenanceFilename returns true if the provided filename is a chart
+// provenance filename generated by ToProvenanceFilename function above.
+func IsProvenanceFilename(filename string) bool {
+	return strings.HasSuffix(filename, provenanceExtension)
+}
+
 // CopyIndexFile returns a deep copy of the provided index file.
 func CopyIndexFile(indexFile repo.IndexFile) *repo.IndexFile {
 	newIndex := &repo.IndexFile{
@@ -84,3 +96,6 @@ func CopyIndexFile(indexFile repo.IndexFile) *repo.IndexFile {
 	}
 	return newIndex
 }
+
+// provenanceExtension is the extension of chart provenance files
+const provenanceExtension = ".prov"
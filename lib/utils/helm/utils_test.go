@@ -103,6 +103,14 @@ func (s *helmUtilsSuite) TestChartFilename(c *check.C) {
 	c.Assert(name, check.Equals, "nginx-ingress")
 	c.Assert(version, check.Equals, "1.0.0")
 
+	filename = ToProvenanceFilename("nginx-ingress", "1.0.0")
+	c.Assert(filename, check.Equals, "nginx-ingress-1.0.0.tgz.prov")
+	c.Assert(IsProvenanceFilename(filename), check.Equals, true)
+	name, version, err = ParseChartFilename(filename)
+	c.Assert(err, check.IsNil)
+	c.Assert(name, check.Equals, "nginx-ingress")
+	c.Assert(version, check.Equals, "1.0.0")
+
 	_, _, err = ParseChartFilename("nginx.tgz")
 	c.Assert(err, check.NotNil)
 }
@@ -67,6 +67,31 @@ func (c *Capacity) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// UnmarshalYAML unmarshals capacity from a human friendly form
+func (c *Capacity) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var capacity string
+	if err := unmarshal(&capacity); err != nil {
+		return trace.Wrap(err)
+	}
+	bytes, err := humanize.ParseBytes(capacity)
+	if err != nil {
+		return trace.Wrap(err, "could not parse %q as bytes", capacity)
+	}
+	*c = Capacity(bytes)
+	return nil
+}
+
+// Set parses capacity from a human friendly form.
+// It allows capacity to be used as a command line flag value
+func (c *Capacity) Set(value string) error {
+	bytes, err := humanize.ParseBytes(value)
+	if err != nil {
+		return trace.Wrap(err, "could not parse %q as bytes", value)
+	}
+	*c = Capacity(bytes)
+	return nil
+}
+
 // MustParseCapacity parses the provided string as capacity or panics
 func MustParseCapacity(data string) Capacity {
 	bytes, err := humanize.ParseBytes(data)
@@ -30,18 +30,19 @@ import (
 
 // NewOperationPlan returns a new plan for the specified operation
 // and the given set of servers
-func NewOperationPlan(operation ops.SiteOperation, servers []storage.Server, remoteApps []storage.Application) (*storage.OperationPlan, error) {
+func NewOperationPlan(operation ops.SiteOperation, servers []storage.Server, remoteApps []storage.Application, retention storage.RetentionPolicy) (*storage.OperationPlan, error) {
 	masters, _ := libfsm.SplitServers(servers)
 	if len(masters) == 0 {
 		return nil, trace.NotFound("no master servers found in cluster state")
 	}
 
-	builder := phaseBuilder{remoteApps: remoteApps}
+	builder := phaseBuilder{remoteApps: remoteApps, retention: retention}
 
 	registry := *builder.registry(masters)
 	packages := *builder.packages(servers)
 	journals := *builder.journals(servers)
-	phases := phases{registry, packages, journals}
+	kubernetes := builder.kubernetes()
+	phases := phases{registry, packages, journals, kubernetes}
 
 	plan := &storage.OperationPlan{
 		OperationID:   operation.ID,
@@ -116,6 +117,19 @@ func (r phaseBuilder) journals(servers []storage.Server) *phase {
 	return &root
 }
 
+func (r phaseBuilder) kubernetes() phase {
+	retention := r.retention
+	return root(phase{
+		ID:          libphase.Kubernetes,
+		Description: "Prune orphaned Kubernetes resources",
+		Data: &storage.OperationPhaseData{
+			GarbageCollect: &storage.GarbageCollectOperationData{
+				Retention: &retention,
+			},
+		},
+	})
+}
+
 func (r phaseBuilder) node(server storage.Server, parent phase, format string) phase {
 	return phase{
 		ID:          parent.ChildLiteral(server.Hostname),
@@ -125,6 +139,7 @@ func (r phaseBuilder) node(server storage.Server, parent phase, format string) p
 
 type phaseBuilder struct {
 	remoteApps []storage.Application
+	retention  storage.RetentionPolicy
 }
 
 // AddSequential will append sub-phases which depend one upon another
@@ -18,6 +18,7 @@ package fsm
 
 import (
 	"testing"
+	"time"
 
 	"github.com/gravitational/gravity/lib/compare"
 	"github.com/gravitational/gravity/lib/loc"
@@ -50,7 +51,9 @@ func (S) TestSingleNodePlan(c *C) {
 		},
 	}
 
-	plan, err := NewOperationPlan(operation, servers, remoteApps)
+	retention := storage.RetentionPolicy{MaxAge: time.Hour, HookJobs: 1}
+
+	plan, err := NewOperationPlan(operation, servers, remoteApps, retention)
 	c.Assert(err, IsNil)
 	c.Assert(plan, compare.DeepEquals, &storage.OperationPlan{
 		OperationID:   operation.ID,
@@ -107,6 +110,15 @@ func (S) TestSingleNodePlan(c *C) {
 					},
 				},
 			},
+			{
+				ID:          "/kubernetes",
+				Description: "Prune orphaned Kubernetes resources",
+				Data: &storage.OperationPhaseData{
+					GarbageCollect: &storage.GarbageCollectOperationData{
+						Retention: &retention,
+					},
+				},
+			},
 		},
 	})
 }
@@ -129,7 +141,9 @@ func (S) TestMultiNodePlan(c *C) {
 		},
 	}
 
-	plan, err := NewOperationPlan(operation, servers, remoteApps)
+	retention := storage.RetentionPolicy{MaxAge: time.Hour, HookJobs: 1}
+
+	plan, err := NewOperationPlan(operation, servers, remoteApps, retention)
 	c.Assert(err, IsNil)
 	c.Assert(plan, compare.DeepEquals, &storage.OperationPlan{
 		OperationID:   operation.ID,
@@ -222,6 +236,15 @@ func (S) TestMultiNodePlan(c *C) {
 					},
 				},
 			},
+			{
+				ID:          "/kubernetes",
+				Description: "Prune orphaned Kubernetes resources",
+				Data: &storage.OperationPhaseData{
+					GarbageCollect: &storage.GarbageCollectOperationData{
+						Retention: &retention,
+					},
+				},
+			},
 		},
 	})
 }
@@ -260,6 +260,12 @@ func configToExecutor(config Config) libfsm.FSMSpecFunc {
 				config.Packages,
 				config.Silent, logger)
 
+		case params.Phase.ID == libphase.Kubernetes:
+			return libphase.NewKubernetes(
+				params,
+				config.Operator,
+				config.Silent, logger)
+
 		default:
 			return nil, trace.BadParameter("unknown phase %q", params.Phase.ID)
 		}
@@ -0,0 +1,109 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"context"
+
+	libfsm "github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/httplib"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/vacuum/prune"
+	"github.com/gravitational/gravity/lib/vacuum/prune/kubernetes"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+)
+
+// NewKubernetes creates a new executor that removes orphaned Kubernetes resources
+func NewKubernetes(
+	params libfsm.ExecutorParams,
+	operator ops.Operator,
+	silent localenv.Silent,
+	logger log.FieldLogger,
+) (*kubernetesExecutor, error) {
+	var retention storage.RetentionPolicy
+	if params.Phase.Data != nil && params.Phase.Data.GarbageCollect != nil &&
+		params.Phase.Data.GarbageCollect.Retention != nil {
+		retention = *params.Phase.Data.GarbageCollect.Retention
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	releases, err := operator.ListReleases(cluster.Key())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	client, config, err := httplib.GetClusterKubeClient(cluster.DNSConfig.Addr())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	extensionsClient, err := apiextensions.NewForConfig(config)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	pruner, err := kubernetes.New(kubernetes.Config{
+		Client:           client,
+		ExtensionsClient: extensionsClient,
+		Releases:         kubernetes.ReleaseNames(releases),
+		Retention:        retention,
+		Config: prune.Config{
+			Silent:      silent,
+			FieldLogger: logger,
+		},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &kubernetesExecutor{
+		FieldLogger: logger,
+		Pruner:      pruner,
+	}, nil
+}
+
+// Execute executes phase
+func (r *kubernetesExecutor) Execute(ctx context.Context) error {
+	err := r.Prune(ctx)
+	return trace.Wrap(err)
+}
+
+// PreCheck is a no-op
+func (r *kubernetesExecutor) PreCheck(context.Context) error {
+	return nil
+}
+
+// PostCheck is a no-op
+func (r *kubernetesExecutor) PostCheck(context.Context) error {
+	return nil
+}
+
+// Rollback is a no-op
+func (r *kubernetesExecutor) Rollback(context.Context) error {
+	return nil
+}
+
+type kubernetesExecutor struct {
+	// FieldLogger is the logger the executor uses
+	log.FieldLogger
+	// Pruner is the actual clean up implementation
+	prune.Pruner
+}
@@ -26,4 +26,6 @@ const (
 	ClusterPackages = "/packages/cluster"
 	// Registry is the phase to remove unused docker images
 	Registry = "/registry"
+	// Kubernetes is the phase to remove orphaned Kubernetes resources
+	Kubernetes = "/kubernetes"
 )
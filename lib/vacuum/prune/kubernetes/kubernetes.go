@@ -0,0 +1,491 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/app/hooks"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+	"github.com/gravitational/gravity/lib/vacuum/prune"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// New creates a new pruner of orphaned Kubernetes resources
+func New(config Config) (*cleanup, error) {
+	if err := config.checkAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	releases := make(map[string]struct{}, len(config.Releases))
+	for _, release := range config.Releases {
+		releases[release] = struct{}{}
+	}
+	return &cleanup{
+		Config:   config,
+		releases: releases,
+	}, nil
+}
+
+func (r *Config) checkAndSetDefaults() error {
+	if r.Client == nil {
+		return trace.BadParameter("kubernetes client is required")
+	}
+	if r.ExtensionsClient == nil {
+		return trace.BadParameter("kubernetes extensions client is required")
+	}
+	if r.Retention.MaxAge < 0 {
+		return trace.BadParameter("retention age cannot be negative")
+	}
+	if r.Retention.HookJobs < 0 {
+		return trace.BadParameter("number of hook jobs to keep cannot be negative")
+	}
+	if r.Retention.MaxAge == 0 {
+		r.Retention.MaxAge = defaults.GarbageCollectRetentionAge
+	}
+	if r.Clock == nil {
+		r.Clock = clockwork.NewRealClock()
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = log.WithField(trace.Component, "gc:kubernetes")
+	}
+	return nil
+}
+
+// Config describes configuration for the cleaner of orphaned Kubernetes resources
+type Config struct {
+	// Config specifies the common pruner configuration
+	prune.Config
+	// Client specifies the Kubernetes API client
+	Client kubernetes.Interface
+	// ExtensionsClient specifies the client for custom resource definitions
+	ExtensionsClient apiextensions.Interface
+	// Releases lists the names of the releases installed in the cluster.
+	// Resources of any other release are considered orphaned
+	Releases []string
+	// Retention specifies the retention policy
+	Retention storage.RetentionPolicy
+	// Clock specifies the time source
+	Clock clockwork.Clock
+}
+
+// ReleaseNames returns the names of the specified releases
+func ReleaseNames(releases []storage.Release) (names []string) {
+	for _, release := range releases {
+		names = append(names, release.GetName())
+	}
+	return names
+}
+
+// Prune removes completed hook jobs and pods not retained by the policy.
+// Resources left behind by releases that are no longer installed are only
+// removed if the policy enables it
+func (r *cleanup) Prune(context.Context) error {
+	if err := r.pruneHookJobs(); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := r.pruneHookPods(); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := r.pruneReleaseResources(); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+func (r *cleanup) pruneHookJobs() error {
+	jobs, err := r.Client.BatchV1().Jobs(metav1.NamespaceAll).List(metav1.ListOptions{
+		LabelSelector: hooks.HookLabel,
+	})
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	propagation := metav1.DeletePropagationBackground
+	for _, job := range hookJobsToPrune(jobs.Items, r.Retention, r.Clock.Now()) {
+		r.PrintStep("Removing %v hook job %v/%v of application %q.",
+			job.Labels[hooks.HookLabel], job.Namespace, job.Name, job.Labels[hooks.HookAppLabel])
+		if r.DryRun {
+			continue
+		}
+		err := r.Client.BatchV1().Jobs(job.Namespace).Delete(job.Name, &metav1.DeleteOptions{
+			PropagationPolicy: &propagation,
+		})
+		if err = rigging.ConvertError(err); err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// pruneHookPods removes completed hook pods which have outlived their jobs,
+// e.g. when the job has been deleted without cascading
+func (r *cleanup) pruneHookPods() error {
+	pods, err := r.Client.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		LabelSelector: hooks.HookLabel,
+	})
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	for _, pod := range pods.Items {
+		if !isPodFinished(pod) || !r.isExpired(pod.ObjectMeta) {
+			continue
+		}
+		if len(pod.OwnerReferences) != 0 {
+			continue
+		}
+		r.PrintStep("Removing orphaned hook pod %v/%v.", pod.Namespace, pod.Name)
+		if r.DryRun {
+			continue
+		}
+		err := r.Client.CoreV1().Pods(pod.Namespace).Delete(pod.Name, nil)
+		if err = rigging.ConvertError(err); err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (r *cleanup) pruneReleaseResources() error {
+	// Resources are removed in this order so that namespaces go last
+	collectors := []func(selector string) ([]resource, error){
+		r.persistentVolumeClaims,
+		r.configMaps,
+		r.persistentVolumes,
+		r.customResourceDefinitions,
+		r.namespaces,
+	}
+	seen := make(map[types.UID]struct{})
+	for _, collect := range collectors {
+		for _, selector := range releaseSelectors {
+			resources, err := collect(selector.String())
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			for _, resource := range resources {
+				if _, ok := seen[resource.UID]; ok {
+					continue
+				}
+				seen[resource.UID] = struct{}{}
+				release, orphaned := r.isOrphaned(resource.ObjectMeta)
+				if !orphaned {
+					continue
+				}
+				if !r.Retention.PruneReleaseResources {
+					r.PrintStep("Found %v of uninstalled release %q, use --prune-release-resources to remove it.",
+						resource, release)
+					continue
+				}
+				r.PrintStep("Removing %v of release %q.", resource, release)
+				if r.DryRun {
+					continue
+				}
+				err := rigging.ConvertError(resource.delete())
+				if err != nil && !trace.IsNotFound(err) {
+					return trace.Wrap(err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (r *cleanup) persistentVolumeClaims(selector string) (result []resource, err error) {
+	claims, err := r.Client.CoreV1().PersistentVolumeClaims(metav1.NamespaceAll).List(
+		metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	if len(claims.Items) == 0 {
+		return nil, nil
+	}
+	used, err := r.claimsInUse()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, claim := range claims.Items {
+		if utils.StringInSlice(used, objectKey(claim.Namespace, claim.Name)) {
+			continue
+		}
+		claim := claim
+		result = append(result, resource{
+			kind:       "persistent volume claim",
+			ObjectMeta: claim.ObjectMeta,
+			delete: func() error {
+				return r.Client.CoreV1().PersistentVolumeClaims(claim.Namespace).Delete(claim.Name, nil)
+			},
+		})
+	}
+	return result, nil
+}
+
+func (r *cleanup) configMaps(selector string) (result []resource, err error) {
+	configMaps, err := r.Client.CoreV1().ConfigMaps(metav1.NamespaceAll).List(
+		metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	for _, configMap := range configMaps.Items {
+		configMap := configMap
+		result = append(result, resource{
+			kind:       "config map",
+			ObjectMeta: configMap.ObjectMeta,
+			delete: func() error {
+				return r.Client.CoreV1().ConfigMaps(configMap.Namespace).Delete(configMap.Name, nil)
+			},
+		})
+	}
+	return result, nil
+}
+
+func (r *cleanup) persistentVolumes(selector string) (result []resource, err error) {
+	volumes, err := r.Client.CoreV1().PersistentVolumes().List(
+		metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	for _, volume := range volumes.Items {
+		if volume.Status.Phase == v1.VolumeBound {
+			continue
+		}
+		volume := volume
+		result = append(result, resource{
+			kind:       "persistent volume",
+			ObjectMeta: volume.ObjectMeta,
+			delete: func() error {
+				return r.Client.CoreV1().PersistentVolumes().Delete(volume.Name, nil)
+			},
+		})
+	}
+	return result, nil
+}
+
+func (r *cleanup) customResourceDefinitions(selector string) (result []resource, err error) {
+	crds, err := r.ExtensionsClient.ApiextensionsV1beta1().CustomResourceDefinitions().List(
+		metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	for _, crd := range crds.Items {
+		crd := crd
+		result = append(result, resource{
+			kind:       "custom resource definition",
+			ObjectMeta: crd.ObjectMeta,
+			delete: func() error {
+				return r.ExtensionsClient.ApiextensionsV1beta1().CustomResourceDefinitions().Delete(crd.Name, nil)
+			},
+		})
+	}
+	return result, nil
+}
+
+func (r *cleanup) namespaces(selector string) (result []resource, err error) {
+	namespaces, err := r.Client.CoreV1().Namespaces().List(
+		metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	for _, namespace := range namespaces.Items {
+		if utils.StringInSlice(systemNamespaces, namespace.Name) {
+			continue
+		}
+		namespace := namespace
+		result = append(result, resource{
+			kind:       "namespace",
+			ObjectMeta: namespace.ObjectMeta,
+			delete: func() error {
+				return r.Client.CoreV1().Namespaces().Delete(namespace.Name, nil)
+			},
+		})
+	}
+	return result, nil
+}
+
+// claimsInUse returns the persistent volume claims referenced by existing pods
+func (r *cleanup) claimsInUse() (claims []string, err error) {
+	pods, err := r.Client.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, trace.Wrap(rigging.ConvertError(err))
+	}
+	for _, pod := range pods.Items {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				claims = append(claims, objectKey(pod.Namespace, volume.PersistentVolumeClaim.ClaimName))
+			}
+		}
+	}
+	return claims, nil
+}
+
+// isOrphaned returns the name of the release the resource belongs to
+// and whether the resource has been left behind by this release
+// for longer than the retention policy allows.
+// Resources helm has been asked to keep are never considered orphaned
+func (r *cleanup) isOrphaned(meta metav1.ObjectMeta) (release string, orphaned bool) {
+	release = releaseName(meta.Labels)
+	if release == "" {
+		return "", false
+	}
+	if _, ok := r.releases[release]; ok {
+		return release, false
+	}
+	if meta.Annotations[resourcePolicyAnnotation] == resourcePolicyKeep {
+		return release, false
+	}
+	return release, r.isExpired(meta)
+}
+
+func (r *cleanup) isExpired(meta metav1.ObjectMeta) bool {
+	return r.Clock.Now().Sub(meta.CreationTimestamp.Time) >= r.Retention.MaxAge
+}
+
+// hookJobsToPrune returns the completed hook jobs that are not retained
+// by the specified policy
+func hookJobsToPrune(jobs []batchv1.Job, policy storage.RetentionPolicy, now time.Time) (result []batchv1.Job) {
+	groups := make(map[hookKey][]batchv1.Job)
+	for _, job := range jobs {
+		if !isJobFinished(job) {
+			continue
+		}
+		key := hookKey{app: job.Labels[hooks.HookAppLabel], hook: job.Labels[hooks.HookLabel]}
+		groups[key] = append(groups[key], job)
+	}
+	for _, group := range groups {
+		sort.Slice(group, func(i, j int) bool {
+			return group[j].CreationTimestamp.Before(&group[i].CreationTimestamp)
+		})
+		for i, job := range group {
+			if i < policy.HookJobs || now.Sub(job.CreationTimestamp.Time) < policy.MaxAge {
+				continue
+			}
+			result = append(result, job)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return objectKey(result[i].Namespace, result[i].Name) < objectKey(result[j].Namespace, result[j].Name)
+	})
+	return result
+}
+
+func isJobFinished(job batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) &&
+			condition.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func isPodFinished(pod v1.Pod) bool {
+	return pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
+}
+
+// releaseName returns the name of the release from the specified resource labels
+// or an empty string if the resource does not belong to a release
+func releaseName(labels map[string]string) string {
+	for _, selector := range releaseSelectors {
+		if utils.StringInSlice(releaseManagers, labels[selector.managedByLabel]) && labels[selector.releaseLabel] != "" {
+			return labels[selector.releaseLabel]
+		}
+	}
+	return ""
+}
+
+func objectKey(namespace, name string) string {
+	return fmt.Sprintf("%v/%v", namespace, name)
+}
+
+func (r resource) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%v %q", r.kind, r.Name)
+	}
+	return fmt.Sprintf("%v %q", r.kind, objectKey(r.Namespace, r.Name))
+}
+
+func (r releaseSelector) String() string {
+	return fmt.Sprintf("%v in (%v),%v", r.managedByLabel, strings.Join(releaseManagers, ","), r.releaseLabel)
+}
+
+// releaseSelector describes the labels charts use to associate resources
+// with a release
+type releaseSelector struct {
+	// managedByLabel is the label with the name of the release manager
+	managedByLabel string
+	// releaseLabel is the label with the name of the release
+	releaseLabel string
+}
+
+// releaseSelectors lists the common label conventions for release resources
+var releaseSelectors = []releaseSelector{
+	{managedByLabel: "heritage", releaseLabel: "release"},
+	{managedByLabel: "app.kubernetes.io/managed-by", releaseLabel: "app.kubernetes.io/instance"},
+}
+
+// releaseManagers lists the values of the manager label on resources
+// created by helm 2 (Tiller) and helm 3
+var releaseManagers = []string{"Tiller", "Helm"}
+
+const (
+	// resourcePolicyAnnotation is the helm annotation with the resource policy
+	resourcePolicyAnnotation = "helm.sh/resource-policy"
+	// resourcePolicyKeep is the policy of resources helm keeps after the release is removed
+	resourcePolicyKeep = "keep"
+)
+
+// systemNamespaces lists namespaces that are never removed
+var systemNamespaces = []string{
+	metav1.NamespaceDefault,
+	metav1.NamespaceSystem,
+	metav1.NamespacePublic,
+}
+
+// resource describes a Kubernetes resource to remove
+type resource struct {
+	// kind is the human-readable resource kind
+	kind string
+	// ObjectMeta is the resource metadata
+	metav1.ObjectMeta
+	// delete removes the resource
+	delete func() error
+}
+
+type hookKey struct {
+	app  string
+	hook string
+}
+
+type cleanup struct {
+	// Config specifies the pruner configuration
+	Config
+	// releases is the set of installed release names
+	releases map[string]struct{}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/app/hooks"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/jonboulle/clockwork"
+	. "gopkg.in/check.v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+func TestKubernetes(t *testing.T) { TestingT(t) }
+
+type S struct {
+	clock clockwork.FakeClock
+}
+
+var _ = Suite(&S{})
+
+func (s *S) SetUpTest(c *C) {
+	s.clock = clockwork.NewFakeClockAt(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func (s *S) TestPrunesHookJobsBeyondRetention(c *C) {
+	jobs := []batchv1.Job{
+		s.newJob("install-1", "app", "install", 4*time.Hour, batchv1.JobComplete),
+		s.newJob("install-2", "app", "install", 3*time.Hour, batchv1.JobFailed),
+		s.newJob("install-3", "app", "install", 2*time.Hour, batchv1.JobComplete),
+		// still running
+		s.newJob("install-4", "app", "install", 5*time.Hour, ""),
+		// not old enough
+		s.newJob("update-1", "app", "update", 30*time.Minute, batchv1.JobComplete),
+		s.newJob("update-2", "app", "update", 10*time.Minute, batchv1.JobComplete),
+		// different application
+		s.newJob("install-5", "other", "install", 3*time.Hour, batchv1.JobComplete),
+	}
+	policy := storage.RetentionPolicy{MaxAge: time.Hour, HookJobs: 1}
+
+	var names []string
+	for _, job := range hookJobsToPrune(jobs, policy, s.clock.Now()) {
+		names = append(names, job.Name)
+	}
+	c.Assert(names, DeepEquals, []string{"install-1", "install-2"})
+}
+
+func (s *S) TestDetectsOrphanedReleaseResources(c *C) {
+	pruner, err := New(Config{
+		Client:           fakeClient{},
+		ExtensionsClient: fakeExtensionsClient{},
+		Releases:         []string{"active"},
+		Retention:        storage.RetentionPolicy{MaxAge: time.Hour},
+		Clock:            s.clock,
+	})
+	c.Assert(err, IsNil)
+
+	var testCases = []struct {
+		meta     metav1.ObjectMeta
+		release  string
+		orphaned bool
+		comment  string
+	}{
+		{
+			meta:     s.newMeta(2*time.Hour, map[string]string{"heritage": "Tiller", "release": "removed"}),
+			release:  "removed",
+			orphaned: true,
+			comment:  "resource of a removed release",
+		},
+		{
+			meta: s.newMeta(2*time.Hour, map[string]string{
+				"app.kubernetes.io/managed-by": "Tiller",
+				"app.kubernetes.io/instance":   "removed",
+			}),
+			release:  "removed",
+			orphaned: true,
+			comment:  "resource of a removed release with recommended labels",
+		},
+		{
+			meta:     s.newMeta(2*time.Hour, map[string]string{"heritage": "Tiller", "release": "active"}),
+			release:  "active",
+			orphaned: false,
+			comment:  "resource of an active release",
+		},
+		{
+			meta:     s.newMeta(10*time.Minute, map[string]string{"heritage": "Tiller", "release": "removed"}),
+			release:  "removed",
+			orphaned: false,
+			comment:  "resource within retention age",
+		},
+		{
+			meta:     s.newMeta(2*time.Hour, map[string]string{"release": "removed"}),
+			orphaned: false,
+			comment:  "resource not managed by helm",
+		},
+		{
+			meta: s.newMeta(2*time.Hour, map[string]string{
+				"app.kubernetes.io/managed-by": "Helm",
+				"app.kubernetes.io/instance":   "removed",
+			}),
+			release:  "removed",
+			orphaned: true,
+			comment:  "resource of a removed helm 3 release",
+		},
+		{
+			meta: func() metav1.ObjectMeta {
+				meta := s.newMeta(2*time.Hour, map[string]string{"heritage": "Tiller", "release": "removed"})
+				meta.Annotations = map[string]string{"helm.sh/resource-policy": "keep"}
+				return meta
+			}(),
+			release:  "removed",
+			orphaned: false,
+			comment:  "resource kept by helm after the release has been removed",
+		},
+	}
+	for _, tc := range testCases {
+		comment := Commentf(tc.comment)
+		release, orphaned := pruner.isOrphaned(tc.meta)
+		c.Assert(release, Equals, tc.release, comment)
+		c.Assert(orphaned, Equals, tc.orphaned, comment)
+	}
+}
+
+func (s *S) newJob(name, app, hook string, age time.Duration, condition batchv1.JobConditionType) batchv1.Job {
+	job := batchv1.Job{
+		ObjectMeta: s.newMeta(age, map[string]string{
+			hooks.HookLabel:    hook,
+			hooks.HookAppLabel: app,
+		}),
+	}
+	job.Name = name
+	job.Namespace = metav1.NamespaceSystem
+	if condition != "" {
+		job.Status.Conditions = []batchv1.JobCondition{
+			{Type: condition, Status: v1.ConditionTrue},
+		}
+	}
+	return job
+}
+
+func (s *S) newMeta(age time.Duration, labels map[string]string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		CreationTimestamp: metav1.NewTime(s.clock.Now().Add(-age)),
+		Labels:            labels,
+	}
+}
+
+type fakeClient struct {
+	kubernetes.Interface
+}
+
+type fakeExtensionsClient struct {
+	apiextensions.Interface
+}
+
+func (s *S) TestSelectsResourcesOfAllReleaseManagers(c *C) {
+	c.Assert(releaseSelectors[0].String(), Equals, "heritage in (Tiller,Helm),release")
+	selector, err := labels.Parse(releaseSelectors[1].String())
+	c.Assert(err, IsNil)
+	c.Assert(selector.Matches(labels.Set{
+		"app.kubernetes.io/managed-by": "Helm",
+		"app.kubernetes.io/instance":   "app",
+	}), Equals, true)
+}
@@ -24,11 +24,14 @@ import (
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/vacuum/prune"
+	"github.com/gravitational/gravity/lib/vacuum/prune/quota"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -60,6 +63,14 @@ func (r *Config) checkAndSetDefaults() error {
 	if r.Packages == nil {
 		return trace.BadParameter("package service is required")
 	}
+	if r.Retention != nil {
+		if err := r.Retention.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if r.Clock == nil {
+		r.Clock = clockwork.NewRealClock()
+	}
 	if r.FieldLogger == nil {
 		r.FieldLogger = log.WithField(trace.Component, "gc:package")
 	}
@@ -79,6 +90,12 @@ type Config struct {
 	Apps []storage.Application
 	// Packages specifies the package service to prune
 	Packages packageService
+	// Retention specifies the optional quota policy for application packages.
+	// Application versions retained by the policy are kept along with
+	// their dependencies, expired versions are removed from all repositories
+	Retention *storage.QuotaPolicy
+	// Clock specifies the time source
+	Clock clockwork.Clock
 }
 
 // packageService defines the subset of package APIs as required for pruning
@@ -100,6 +117,12 @@ func (r *cleanup) Prune(context.Context) error {
 		return trace.Wrap(err)
 	}
 
+	if r.Retention != nil {
+		if err := r.applyRetention(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
 	state, err := r.build(required)
 	if err != nil {
 		return trace.Wrap(err)
@@ -126,24 +149,8 @@ func (r *cleanup) Prune(context.Context) error {
 // Returns the map of package locator -> descriptor for packages that are not
 // eligible for removal
 func (r *cleanup) mark() (required packageMap, err error) {
-	dependencies := append(r.App.Manifest.AllPackageDependencies(),
-		r.App.Manifest.Dependencies.GetApps()...)
-	dependencies = append(dependencies, r.App.Locator)
-	if base := r.App.Manifest.Base(); base != nil {
-		dependencies = append(dependencies, *base)
-	}
-
 	required = make(packageMap)
-	for _, app := range r.Apps {
-		dependencies = append(dependencies, app.Manifest.AllPackageDependencies()...)
-		dependencies = append(dependencies, app.Manifest.Dependencies.GetApps()...)
-		dependencies = append(dependencies, app.Locator)
-		if base := app.Manifest.Base(); base != nil {
-			dependencies = append(dependencies, *base)
-		}
-	}
-
-	for _, dependency := range dependencies {
+	for _, dependency := range r.dependencies() {
 		semver, err := dependency.SemVer()
 		if err != nil {
 			return nil, trace.Wrap(err)
@@ -158,6 +165,66 @@ func (r *cleanup) mark() (required packageMap, err error) {
 	return required, nil
 }
 
+// dependencies returns the packages the cluster application and
+// the applications of remote clusters depend on
+func (r *cleanup) dependencies() (dependencies []loc.Locator) {
+	dependencies = appDependencies(r.App.Locator, r.App.Manifest)
+	for _, app := range r.Apps {
+		dependencies = append(dependencies, appDependencies(app.Locator, app.Manifest)...)
+	}
+	return dependencies
+}
+
+// applyRetention computes the sets of application packages retained
+// and expired by the retention policy
+func (r *cleanup) applyRetention() error {
+	repositories, err := r.Packages.GetRepositories()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	envelopes := make(map[string]pack.PackageEnvelope)
+	var all []pack.PackageEnvelope
+	for _, repository := range repositories {
+		packages, err := r.Packages.GetPackages(repository)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for _, envelope := range packages {
+			envelopes[envelope.Locator.String()] = envelope
+		}
+		all = append(all, packages...)
+	}
+
+	items, err := quota.PackageItems(all, r.dependencies())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	retained, expired := quota.Apply(*r.Retention, items, r.Clock.Now())
+
+	r.retained = make(map[loc.Locator]struct{})
+	for _, item := range retained {
+		envelope := envelopes[item.ID]
+		r.PrintStep("Retain application package %v.", envelope.Locator)
+		r.retained[envelope.Locator] = struct{}{}
+		if len(envelope.Manifest) == 0 {
+			continue
+		}
+		manifest, err := schema.ParseManifestYAMLNoValidate(envelope.Manifest)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for _, dependency := range appDependencies(envelope.Locator, *manifest) {
+			r.retained[dependency] = struct{}{}
+		}
+	}
+
+	r.expired = make(map[loc.Locator]struct{})
+	for _, item := range expired {
+		r.expired[envelopes[item.ID].Locator] = struct{}{}
+	}
+	return nil
+}
+
 // build builds a package tree to be able to track package dependencies
 // and prune packages in proper order
 func (r *cleanup) build(required packageMap) (state map[loc.Locator]statePackage, err error) {
@@ -237,6 +304,16 @@ func (r *cleanup) deletePackage(item statePackage) error {
 func (r *cleanup) shouldDeletePackage(pkg existingPackage, required packageMap) (delete bool, err error) {
 	log := r.WithField("package", pkg.Locator)
 
+	if _, retained := r.retained[pkg.Locator]; retained {
+		log.Debug("Will not delete a package retained by the policy.")
+		return false, nil
+	}
+
+	if _, expired := r.expired[pkg.Locator]; expired {
+		log.Debug("Will delete an application package expired by the policy.")
+		return true, nil
+	}
+
 	if existingVersion, exists := required[pkg.Locator.ZeroVersion()]; exists {
 		if existingVersion.Compare(pkg.Version) > 0 {
 			log.Debug("Will delete an obsolete package.")
@@ -378,6 +455,17 @@ func isRPCUpdateCredentialsPackage(envelope pack.PackageEnvelope) bool {
 	return envelope.Locator.ZeroVersion().IsEqualTo(pattern)
 }
 
+// appDependencies returns the application package with the specified manifest
+// along with its direct package and application dependencies
+func appDependencies(app loc.Locator, manifest schema.Manifest) []loc.Locator {
+	dependencies := append(manifest.AllPackageDependencies(), manifest.Dependencies.GetApps()...)
+	dependencies = append(dependencies, app)
+	if base := manifest.Base(); base != nil {
+		dependencies = append(dependencies, *base)
+	}
+	return dependencies
+}
+
 type packageMap map[loc.Locator]existingPackage
 
 type existingPackage struct {
@@ -389,6 +477,10 @@ type cleanup struct {
 	Config
 	// runtimeVersion specifies the version of gravity
 	runtimeVersion semver.Version
+	// retained lists packages kept by the retention policy
+	retained map[loc.Locator]struct{}
+	// expired lists application packages expired by the retention policy
+	expired map[loc.Locator]struct{}
 }
 
 func (r statePackage) String() string {
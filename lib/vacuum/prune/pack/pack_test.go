@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gravitational/gravity/lib/compare"
 	"github.com/gravitational/gravity/lib/defaults"
@@ -31,6 +32,7 @@ import (
 	"github.com/gravitational/gravity/lib/vacuum/prune"
 
 	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
 	log "github.com/sirupsen/logrus"
 	. "gopkg.in/check.v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -431,6 +433,57 @@ func (*S) TestDoesnotPruneUnrelatedPackages(c *C) {
 	c.Assert(byLocator(allPackages), compare.SortedSliceEquals, byLocator(expected))
 }
 
+func (*S) TestRetainsApplicationVersions(c *C) {
+	// setup
+	runtimePackage := newPackage("gravitational.io/planet:0.0.1", pack.PurposeLabel, pack.PurposeRuntime)
+	app := newAppPackage("gravitational.io/app:0.0.1", storage.AppUser)
+	runtimeApp := newAppPackage("gravitational.io/runtime:0.0.1", storage.AppRuntime)
+	dependencies := testPackages{
+		newPackage("gravitational.io/foo:0.0.2"),
+	}
+	a, dependencies := newApp(app, runtimeApp, runtimePackage, dependencies...)
+
+	now := time.Date(2019, 1, 10, 0, 0, 0, 0, time.UTC)
+	published := func(envelope packageEnvelope, age time.Duration) packageEnvelope {
+		envelope.Created = now.Add(-age)
+		return envelope
+	}
+	v1 := published(newAppPackage("example.com/opscenter-app:1.0.0", storage.AppUser), 72*time.Hour)
+	v2 := published(newAppPackage("example.com/opscenter-app:2.0.0", storage.AppUser), 48*time.Hour)
+	v2.Manifest = []byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: opscenter-app
+  resourceVersion: 2.0.0
+dependencies:
+  packages:
+  - gravitational.io/foo:0.0.1
+`)
+	v3 := published(newAppPackage("example.com/opscenter-app:3.0.0", storage.AppUser), 24*time.Hour)
+	allPackages := append(testPackages(dependencies),
+		v1, newPackage("example.com/opscenter-app-resources:1.0.0"),
+		v2, newPackage("gravitational.io/foo:0.0.1"),
+		v3,
+	)
+
+	// exercise
+	p, err := New(Config{
+		App:       a,
+		Packages:  &allPackages,
+		Retention: &storage.QuotaPolicy{KeepLast: 2},
+		Clock:     clockwork.NewFakeClockAt(now),
+	})
+	c.Assert(err, IsNil)
+
+	err = p.Prune(context.TODO())
+	c.Assert(err, IsNil)
+
+	// verify
+	expected := append(dependencies, v2, newPackage("gravitational.io/foo:0.0.1"), v3)
+	c.Assert(byLocator(allPackages), compare.SortedSliceEquals, byLocator(expected),
+		Commentf("Should keep the last two application versions with dependencies"))
+}
+
 func newApp(app, runtimeApp, runtimePackage packageEnvelope, dependencies ...packageEnvelope) (*storage.Application, []packageEnvelope) {
 	m := schema.Manifest{
 		Header: schema.Header{
@@ -0,0 +1,146 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package quota implements the selection of items retained
+// by a storage.QuotaPolicy
+package quota
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/gravitational/trace"
+)
+
+// Apply splits the specified items into retained and expired sets
+// according to the given policy.
+//
+// Items in each group are expected to be ordered from newest to oldest.
+// An item is retained if it is in use, is one of the policy.KeepLast first items
+// of its group or is younger than policy.KeepFor. If neither KeepLast nor KeepFor
+// is set, all items are retained subject to the size cap.
+// If the total size of the retained items exceeds policy.MaxSize,
+// retained items that are not in use are expired, oldest first,
+// until the size fits
+func Apply(policy storage.QuotaPolicy, items []Item, now time.Time) (retained, expired []Item) {
+	limited := policy.KeepLast != 0 || policy.KeepFor != 0
+	positions := make(map[string]int)
+	var totalSize int64
+	for _, item := range items {
+		position := positions[item.Group]
+		positions[item.Group] = position + 1
+		switch {
+		case item.InUse, !limited,
+			position < policy.KeepLast,
+			policy.KeepFor != 0 && now.Sub(item.Created) < policy.KeepFor:
+			retained = append(retained, item)
+			totalSize += item.Size
+		default:
+			expired = append(expired, item)
+		}
+	}
+	if policy.MaxSize == 0 || totalSize <= int64(policy.MaxSize.Bytes()) {
+		return retained, expired
+	}
+	byAge := make([]Item, len(retained))
+	copy(byAge, retained)
+	sort.SliceStable(byAge, func(i, j int) bool {
+		return byAge[i].Created.Before(byAge[j].Created)
+	})
+	trimmed := make(map[string]struct{})
+	for _, item := range byAge {
+		if totalSize <= int64(policy.MaxSize.Bytes()) {
+			break
+		}
+		if item.InUse {
+			continue
+		}
+		trimmed[item.ID] = struct{}{}
+		totalSize -= item.Size
+		expired = append(expired, item)
+	}
+	var result []Item
+	for _, item := range retained {
+		if _, ok := trimmed[item.ID]; !ok {
+			result = append(result, item)
+		}
+	}
+	return result, expired
+}
+
+// PackageItems returns the application packages from the specified list
+// as items grouped by package repository and name, newest version first.
+// Packages from the inUse list are marked as in use
+func PackageItems(envelopes []pack.PackageEnvelope, inUse []loc.Locator) ([]Item, error) {
+	type appPackage struct {
+		pack.PackageEnvelope
+		version semver.Version
+	}
+	var packages []appPackage
+	for _, envelope := range envelopes {
+		if envelope.Type == "" {
+			continue
+		}
+		version, err := envelope.Locator.SemVer()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		packages = append(packages, appPackage{PackageEnvelope: envelope, version: *version})
+	}
+	sort.SliceStable(packages, func(i, j int) bool {
+		groupI, groupJ := packages[i].Locator.ZeroVersion().String(), packages[j].Locator.ZeroVersion().String()
+		if groupI != groupJ {
+			return groupI < groupJ
+		}
+		return packages[j].version.LessThan(packages[i].version)
+	})
+	used := make(map[loc.Locator]struct{}, len(inUse))
+	for _, locator := range inUse {
+		used[locator] = struct{}{}
+	}
+	items := make([]Item, 0, len(packages))
+	for _, pkg := range packages {
+		_, isUsed := used[pkg.Locator]
+		items = append(items, Item{
+			ID:      pkg.Locator.String(),
+			Group:   pkg.Locator.ZeroVersion().String(),
+			Created: pkg.Created,
+			Size:    pkg.SizeBytes,
+			InUse:   isUsed,
+		})
+	}
+	return items, nil
+}
+
+// Item describes a single item subject to a quota policy
+type Item struct {
+	// ID uniquely identifies the item
+	ID string
+	// Group identifies the series this item belongs to,
+	// e.g. a package repository and name
+	Group string
+	// Created specifies the item creation time
+	Created time.Time
+	// Size specifies the item size in bytes
+	Size int64
+	// InUse specifies whether the item is still in use and cannot be removed
+	InUse bool
+}
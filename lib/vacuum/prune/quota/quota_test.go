@@ -0,0 +1,109 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/storage"
+
+	. "gopkg.in/check.v1"
+)
+
+func TestQuota(t *testing.T) { TestingT(t) }
+
+type S struct{}
+
+var _ = Suite(&S{})
+
+func (S) TestAppliesPolicy(c *C) {
+	now := time.Date(2019, 1, 10, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	items := []Item{
+		{ID: "app:3", Group: "app", Created: now.Add(-1 * day), Size: 10},
+		{ID: "app:2", Group: "app", Created: now.Add(-5 * day), Size: 10},
+		{ID: "app:1", Group: "app", Created: now.Add(-9 * day), Size: 10, InUse: true},
+		{ID: "other:2", Group: "other", Created: now.Add(-6 * day), Size: 10},
+		{ID: "other:1", Group: "other", Created: now.Add(-7 * day), Size: 10},
+	}
+
+	var testCases = []struct {
+		policy   storage.QuotaPolicy
+		retained []string
+		expired  []string
+		comment  string
+	}{
+		{
+			policy:   storage.QuotaPolicy{KeepLast: 1},
+			retained: []string{"app:3", "app:1", "other:2"},
+			expired:  []string{"app:2", "other:1"},
+			comment:  "keeps the last version of each group and versions in use",
+		},
+		{
+			policy:   storage.QuotaPolicy{KeepFor: 6*day + time.Hour},
+			retained: []string{"app:3", "app:2", "app:1", "other:2"},
+			expired:  []string{"other:1"},
+			comment:  "keeps recent versions",
+		},
+		{
+			policy:   storage.QuotaPolicy{MaxSize: 25},
+			retained: []string{"app:3", "app:1"},
+			expired:  []string{"other:1", "other:2", "app:2"},
+			comment:  "removes oldest versions not in use to fit the size cap",
+		},
+		{
+			policy:   storage.QuotaPolicy{KeepLast: 2, MaxSize: 30},
+			retained: []string{"app:3", "app:2", "app:1"},
+			expired:  []string{"other:1", "other:2"},
+			comment:  "applies the size cap to the retained versions",
+		},
+	}
+	for _, tc := range testCases {
+		comment := Commentf(tc.comment)
+		retained, expired := Apply(tc.policy, items, now)
+		c.Assert(ids(retained), DeepEquals, tc.retained, comment)
+		c.Assert(ids(expired), DeepEquals, tc.expired, comment)
+	}
+}
+
+func (S) TestOrdersPackagesByVersion(c *C) {
+	envelopes := []pack.PackageEnvelope{
+		{Locator: loc.MustParseLocator("example.com/app:1.0.0"), Type: string(storage.AppUser)},
+		{Locator: loc.MustParseLocator("example.com/app:1.10.0"), Type: string(storage.AppUser)},
+		{Locator: loc.MustParseLocator("example.com/app:1.2.0"), Type: string(storage.AppUser)},
+		{Locator: loc.MustParseLocator("example.com/app-resources:1.2.0")},
+		{Locator: loc.MustParseLocator("example.com/another:0.0.1"), Type: string(storage.AppUser)},
+	}
+	items, err := PackageItems(envelopes, []loc.Locator{loc.MustParseLocator("example.com/app:1.0.0")})
+	c.Assert(err, IsNil)
+	c.Assert(items, DeepEquals, []Item{
+		{ID: "example.com/another:0.0.1", Group: "example.com/another:0.0.0"},
+		{ID: "example.com/app:1.10.0", Group: "example.com/app:0.0.0"},
+		{ID: "example.com/app:1.2.0", Group: "example.com/app:0.0.0"},
+		{ID: "example.com/app:1.0.0", Group: "example.com/app:0.0.0", InUse: true},
+	})
+}
+
+func ids(items []Item) (result []string) {
+	for _, item := range items {
+		result = append(result, item.ID)
+	}
+	return result
+}
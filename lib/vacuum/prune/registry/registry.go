@@ -19,6 +19,7 @@ package registry
 import (
 	"context"
 	"strings"
+	"time"
 
 	apps "github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/app/docker"
@@ -27,9 +28,11 @@ import (
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/state"
+	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/systemservice"
 	"github.com/gravitational/gravity/lib/utils"
 	"github.com/gravitational/gravity/lib/vacuum/prune"
+	"github.com/gravitational/gravity/lib/vacuum/prune/quota"
 
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
@@ -77,6 +80,9 @@ type Config struct {
 	Apps apps.Applications
 	// ImageService specifies the docker image service
 	ImageService docker.ImageService
+	// Retention specifies the optional quota policy for the cluster application.
+	// Images of the application versions retained by the policy are kept in the registry
+	Retention *storage.QuotaPolicy
 }
 
 // Prune removes unused docker images.
@@ -122,23 +128,65 @@ func (r *cleanup) Prune(ctx context.Context) (err error) {
 		}
 	}
 
-	r.PrintStep("Sync application state with registry")
-	if r.DryRun {
-		return nil
+	apps := []loc.Locator{*r.App}
+	if r.Retention != nil {
+		retained, err := r.retainedApps()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		apps = append(apps, retained...)
 	}
-	err = appservice.SyncApp(ctx, appservice.SyncRequest{
-		PackService:  r.Packages,
-		AppService:   r.Apps,
-		ImageService: r.ImageService,
-		Package:      *r.App,
-	})
-	if err != nil {
-		return trace.Wrap(err)
+
+	for _, app := range apps {
+		r.PrintStep("Sync application %v state with registry", app)
+		if r.DryRun {
+			continue
+		}
+		err = appservice.SyncApp(ctx, appservice.SyncRequest{
+			PackService:  r.Packages,
+			AppService:   r.Apps,
+			ImageService: r.ImageService,
+			Package:      app,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
 	}
 
 	return nil
 }
 
+// retainedApps returns the previous versions of the cluster application
+// retained by the retention policy
+func (r *cleanup) retainedApps() (apps []loc.Locator, err error) {
+	envelopes, err := r.Packages.GetPackages(r.App.Repository)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var versions []pack.PackageEnvelope
+	for _, envelope := range envelopes {
+		if envelope.Locator.Name == r.App.Name {
+			versions = append(versions, envelope)
+		}
+	}
+	items, err := quota.PackageItems(versions, []loc.Locator{*r.App})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	retained, _ := quota.Apply(*r.Retention, items, time.Now())
+	for _, item := range retained {
+		if item.InUse {
+			continue
+		}
+		app, err := loc.ParseLocator(item.ID)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		apps = append(apps, *app)
+	}
+	return apps, nil
+}
+
 func (r *cleanup) registryStart(ctx context.Context) error {
 	out, err := r.serviceStart(ctx)
 	if err != nil {
@@ -23,6 +23,7 @@ import (
 
 	"github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
 	libfsm "github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/ops"
@@ -49,6 +50,10 @@ func New(config Config) (*Collector, error) {
 
 // Run runs the garbage collection.
 func (r *Collector) Run(ctx context.Context, force bool) error {
+	if err := r.waitForMaintenanceWindow(ctx); err != nil {
+		return trace.Wrap(err)
+	}
+
 	machine, err := r.init()
 	if err != nil {
 		return trace.Wrap(err)
@@ -79,12 +84,34 @@ L:
 	return trace.Wrap(err)
 }
 
+// waitForMaintenanceWindow blocks until the operation is launched if it
+// has been scheduled for the cluster maintenance window
+func (r *Collector) waitForMaintenanceWindow(ctx context.Context) error {
+	if !r.Operation.IsScheduled() {
+		return nil
+	}
+	if r.Operation.Schedule != nil {
+		r.Silent.Printf("Maintenance window is closed, operation will start after %v.\n",
+			r.Operation.Schedule.StartAfter.Format(constants.HumanDateFormat))
+	}
+	operation, err := ops.WaitForScheduledOperation(ctx, r.Operator, r.Operation.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.Operation = operation
+	return nil
+}
+
 // RunPhase runs the specified garbage collection phase.
 func (r *Collector) RunPhase(ctx context.Context, phase string, phaseTimeout time.Duration, force bool) error {
 	if phase == libfsm.RootPhase {
 		return trace.Wrap(r.Run(ctx, force))
 	}
 
+	if err := ops.CheckOperationLaunched(r.Operator, r.Operation.Key()); err != nil {
+		return trace.Wrap(err)
+	}
+
 	machine, err := r.init()
 	if err != nil {
 		return trace.Wrap(err)
@@ -198,6 +225,12 @@ func (r *Config) checkAndSetDefaults() error {
 	if len(r.Servers) == 0 {
 		return trace.BadParameter("at least a single server is required")
 	}
+	if r.Retention == (storage.RetentionPolicy{}) {
+		r.Retention = storage.RetentionPolicy{
+			MaxAge:   defaults.GarbageCollectRetentionAge,
+			HookJobs: defaults.GarbageCollectHookJobs,
+		}
+	}
 	if r.FieldLogger == nil {
 		r.FieldLogger = log.WithField(trace.Component, "gc:collector")
 	}
@@ -228,6 +261,8 @@ type Config struct {
 	Runner libfsm.AgentRepository
 	// RuntimePath is the path to the runtime container's rootfs
 	RuntimePath string
+	// Retention specifies the retention policy for orphaned Kubernetes resources
+	Retention storage.RetentionPolicy
 	// FieldLogger is the logger to use
 	log.FieldLogger
 	// Silent controls whether the process outputs messages to stdout
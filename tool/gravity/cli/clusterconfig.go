@@ -18,6 +18,7 @@ package cli
 
 import (
 	"context"
+	"time"
 
 	"github.com/gravitational/gravity/lib/fsm"
 	libfsm "github.com/gravitational/gravity/lib/fsm"
@@ -33,12 +34,12 @@ import (
 )
 
 // resetConfig executes the loop to reset cluster configuration to defaults
-func resetConfig(ctx context.Context, localEnv, updateEnv *localenv.LocalEnvironment, manual, confirmed bool) error {
+func resetConfig(ctx context.Context, localEnv, updateEnv *localenv.LocalEnvironment, manual, confirmed, ignoreWindow bool) error {
 	config := libclusterconfig.NewEmpty()
-	return trace.Wrap(updateConfig(ctx, localEnv, updateEnv, config, manual, confirmed))
+	return trace.Wrap(updateConfig(ctx, localEnv, updateEnv, config, manual, confirmed, ignoreWindow))
 }
 
-func updateConfig(ctx context.Context, localEnv, updateEnv *localenv.LocalEnvironment, config libclusterconfig.Interface, manual, confirmed bool) error {
+func updateConfig(ctx context.Context, localEnv, updateEnv *localenv.LocalEnvironment, config libclusterconfig.Interface, manual, confirmed, ignoreWindow bool) error {
 	if err := validateCloudConfig(localEnv, config); err != nil {
 		return trace.Wrap(err)
 	}
@@ -57,7 +58,7 @@ func updateConfig(ctx context.Context, localEnv, updateEnv *localenv.LocalEnviro
 			return nil
 		}
 	}
-	updater, err := newConfigUpdater(ctx, localEnv, updateEnv, config)
+	updater, err := newConfigUpdater(ctx, localEnv, updateEnv, config, ignoreWindow)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -70,14 +71,15 @@ func updateConfig(ctx context.Context, localEnv, updateEnv *localenv.LocalEnviro
 	return nil
 }
 
-func newConfigUpdater(ctx context.Context, localEnv, updateEnv *localenv.LocalEnvironment, config libclusterconfig.Interface) (*update.Updater, error) {
+func newConfigUpdater(ctx context.Context, localEnv, updateEnv *localenv.LocalEnvironment, config libclusterconfig.Interface, ignoreWindow bool) (*update.Updater, error) {
 	configBytes, err := libclusterconfig.Marshal(config)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 	init := configInitializer{
-		resource: configBytes,
-		config:   config,
+		resource:     configBytes,
+		config:       config,
+		ignoreWindow: ignoreWindow,
 	}
 	return newUpdater(ctx, localEnv, updateEnv, init)
 }
@@ -102,6 +104,15 @@ func rollbackConfigPhase(env, updateEnv *localenv.LocalEnvironment, params Phase
 	return trace.Wrap(err)
 }
 
+func rollbackConfigPlan(env, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation, timeout time.Duration, force bool) error {
+	updater, err := getConfigUpdater(env, updateEnv, operation)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer updater.Close()
+	return trace.Wrap(updater.RollbackPlan(context.TODO(), timeout, force))
+}
+
 func completeConfigPlan(env, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation) error {
 	updater, err := getConfigUpdater(env, updateEnv, operation)
 	if err != nil {
@@ -155,8 +166,9 @@ func (r configInitializer) validatePreconditions(*localenv.LocalEnvironment, ops
 func (r configInitializer) newOperation(operator ops.Operator, cluster ops.Site) (*ops.SiteOperationKey, error) {
 	key, err := operator.CreateUpdateConfigOperation(context.TODO(),
 		ops.CreateUpdateConfigOperationRequest{
-			ClusterKey: cluster.Key(),
-			Config:     r.resource,
+			ClusterKey:              cluster.Key(),
+			Config:                  r.resource,
+			IgnoreMaintenanceWindow: r.ignoreWindow,
 		},
 	)
 	if err != nil {
@@ -221,6 +233,8 @@ func (configInitializer) updateDeployRequest(req deployAgentsRequest) deployAgen
 type configInitializer struct {
 	resource []byte
 	config   libclusterconfig.Interface
+	// ignoreWindow starts the operation outside of the maintenance window
+	ignoreWindow bool
 }
 
 func validateCloudConfig(localEnv *localenv.LocalEnvironment, config libclusterconfig.Interface) error {
@@ -18,6 +18,7 @@ package cli
 
 import (
 	"context"
+	"time"
 
 	"github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/constants"
@@ -59,9 +60,12 @@ func updateTrigger(
 	updateEnv *localenv.LocalEnvironment,
 	updatePackage string,
 	manual, block, noValidateVersion bool,
+	strategy *storage.UpdateStrategy,
+	drainPolicy *storage.DrainPolicy,
+	ignoreWindow bool,
 ) error {
 	ctx := context.TODO()
-	updater, err := newClusterUpdater(ctx, localEnv, updateEnv, updatePackage, manual, block, noValidateVersion)
+	updater, err := newClusterUpdater(ctx, localEnv, updateEnv, updatePackage, manual, block, noValidateVersion, strategy, drainPolicy, ignoreWindow)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -83,11 +87,17 @@ func newClusterUpdater(
 	localEnv, updateEnv *localenv.LocalEnvironment,
 	updatePackage string,
 	manual, block, noValidateVersion bool,
+	strategy *storage.UpdateStrategy,
+	drainPolicy *storage.DrainPolicy,
+	ignoreWindow bool,
 ) (updater, error) {
 	unattended := !manual && !block
 	init := &clusterInitializer{
 		updatePackage: updatePackage,
 		unattended:    unattended,
+		strategy:      strategy,
+		drainPolicy:   drainPolicy,
+		ignoreWindow:  ignoreWindow,
 	}
 	updater, err := newUpdater(ctx, localEnv, updateEnv, init)
 	if err != nil {
@@ -122,6 +132,15 @@ func rollbackUpdatePhase(env, updateEnv *localenv.LocalEnvironment, params Phase
 	return trace.Wrap(err)
 }
 
+func rollbackUpdatePlan(env, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation, timeout time.Duration, force bool) error {
+	updater, err := getClusterUpdater(env, updateEnv, operation, true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer updater.Close()
+	return trace.Wrap(updater.RollbackPlan(context.TODO(), timeout, force))
+}
+
 func completeUpdatePlan(env, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation) error {
 	updater, err := getClusterUpdater(env, updateEnv, operation, true)
 	if err != nil {
@@ -174,6 +193,11 @@ func getClusterUpdater(localEnv, updateEnv *localenv.LocalEnvironment, operation
 }
 
 func (r *clusterInitializer) validatePreconditions(localEnv *localenv.LocalEnvironment, operator ops.Operator, cluster ops.Site) error {
+	if r.strategy != nil {
+		if err := r.strategy.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	updateApp, err := checkForUpdate(localEnv, operator, cluster.App.Package, r.updatePackage)
 	if err != nil {
 		return trace.Wrap(err)
@@ -188,9 +212,12 @@ func (r *clusterInitializer) validatePreconditions(localEnv *localenv.LocalEnvir
 
 func (r clusterInitializer) newOperation(operator ops.Operator, cluster ops.Site) (*ops.SiteOperationKey, error) {
 	return operator.CreateSiteAppUpdateOperation(context.TODO(), ops.CreateSiteAppUpdateOperationRequest{
-		AccountID:  cluster.AccountID,
-		SiteDomain: cluster.Domain,
-		App:        r.updateLoc.String(),
+		AccountID:               cluster.AccountID,
+		SiteDomain:              cluster.Domain,
+		App:                     r.updateLoc.String(),
+		Strategy:                r.strategy,
+		DrainPolicy:             r.drainPolicy,
+		IgnoreMaintenanceWindow: r.ignoreWindow,
 	})
 }
 
@@ -249,6 +276,60 @@ type clusterInitializer struct {
 	updateLoc     loc.Locator
 	updatePackage string
 	unattended    bool
+	// strategy optionally defines how regular nodes are upgraded
+	strategy *storage.UpdateStrategy
+	// drainPolicy optionally defines how nodes are drained
+	drainPolicy *storage.DrainPolicy
+	// ignoreWindow starts the operation outside of the maintenance window
+	ignoreWindow bool
+}
+
+// strategy returns the update strategy for this configuration
+// or nil if no strategy has been requested
+func (r updateStrategyConfig) strategy() *storage.UpdateStrategy {
+	strategy := storage.UpdateStrategy{
+		CanarySelector: r.canarySelector,
+		CanaryPause:    r.canaryPause,
+		BatchSize:      r.batchSize,
+		MaxUnavailable: r.maxUnavailable,
+		AutoRollback:   r.autoRollback,
+	}
+	if r.healthGates || len(r.healthChecks) != 0 || len(r.prometheusQueries) != 0 {
+		strategy.HealthGates = &storage.HealthGates{
+			Timeout:           r.healthTimeout,
+			HTTPChecks:        r.healthChecks,
+			PrometheusURL:     r.prometheusURL,
+			PrometheusQueries: r.prometheusQueries,
+		}
+	}
+	if strategy == (storage.UpdateStrategy{}) {
+		return nil
+	}
+	return &strategy
+}
+
+// updateStrategyConfig defines the command line parameters of the update strategy
+type updateStrategyConfig struct {
+	// canarySelector is the label selector of the canary nodes
+	canarySelector string
+	// canaryPause is how long canary nodes should stay healthy
+	canaryPause time.Duration
+	// batchSize is the number of regular nodes to upgrade concurrently
+	batchSize int
+	// maxUnavailable is the maximum number of nodes unavailable at the same time
+	maxUnavailable int
+	// healthGates enables health checks after each node is upgraded
+	healthGates bool
+	// healthTimeout is how long health checks can fail
+	healthTimeout time.Duration
+	// healthChecks lists URLs of custom HTTP health checks
+	healthChecks []string
+	// prometheusURL is the address of the Prometheus server
+	prometheusURL string
+	// prometheusQueries lists Prometheus queries that should return no results
+	prometheusQueries []string
+	// autoRollback enables automatic rollback of the failed operation
+	autoRollback bool
 }
 
 const (
@@ -23,6 +23,7 @@ import (
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/configure"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -62,6 +63,8 @@ type Application struct {
 	JoinCmd JoinCmd
 	// AutoJoinCmd uses cloud provider info to join existing cluster
 	AutoJoinCmd AutoJoinCmd
+	// ReplaceCmd joins the node in place of a failed master node
+	ReplaceCmd ReplaceCmd
 	// LeaveCmd removes the current node from the cluster
 	LeaveCmd LeaveCmd
 	// RemoveCmd removes the specified node from the cluster
@@ -94,6 +97,8 @@ type Application struct {
 	UpdateSystemCmd UpdateSystemCmd
 	// UpgradeCmd launches app upgrade
 	UpgradeCmd UpgradeCmd
+	// RollbackCmd rolls back a failed operation
+	RollbackCmd RollbackCmd
 	// StatusCmd displays cluster status
 	StatusCmd StatusCmd
 	// StatusResetCmd resets the cluster to active state
@@ -118,6 +123,8 @@ type Application struct {
 	AppUninstallCmd AppUninstallCmd
 	// AppHistoryCmd displays revision history for a release
 	AppHistoryCmd AppHistoryCmd
+	// AppMigrateReleasesCmd converts Tiller releases to Helm 3 format
+	AppMigrateReleasesCmd AppMigrateReleasesCmd
 	// AppSyncCmd synchronizes an application image with a cluster
 	AppSyncCmd AppSyncCmd
 	// AppSearchCmd searches for applications.
@@ -288,6 +295,8 @@ type Application struct {
 	SystemGCPackageCmd SystemGCPackageCmd
 	// SystemGCRegistryCmd removes unused docker images
 	SystemGCRegistryCmd SystemGCRegistryCmd
+	// SystemGCKubernetesCmd removes orphaned Kubernetes resources
+	SystemGCKubernetesCmd SystemGCKubernetesCmd
 	// GarbageCollectCmd prunes unused resources (package/journal files/docker images)
 	// in the cluster
 	GarbageCollectCmd GarbageCollectCmd
@@ -428,7 +437,37 @@ type JoinCmd struct {
 	// Force forces phase execution
 	Force *bool
 	// OperationID is the ID of the operation created via UI
+	// or by another node joining in the same operation
 	OperationID *string
+	// IgnoreMaintenanceWindow starts the operation outside of the cluster maintenance window
+	IgnoreMaintenanceWindow *bool
+	// Nodes is the number of nodes joining in the operation
+	Nodes *int
+}
+
+// ReplaceCmd joins the node to the cluster in place of a failed master node
+type ReplaceCmd struct {
+	*kingpin.CmdClause
+	// Node is the failed master node to replace
+	Node *string
+	// PeerAddr is the cluster address
+	PeerAddr *string
+	// Token is join token
+	Token *string
+	// AdvertiseAddr is local node advertise IP address
+	AdvertiseAddr *string
+	// DockerDevice is device to use for Docker data
+	DockerDevice *string
+	// SystemDevice is device to use for system data
+	SystemDevice *string
+	// ServerAddr is RPC server address
+	ServerAddr *string
+	// Mounts is additional app mounts
+	Mounts *configure.KeyVal
+	// CloudProvider turns on cloud provider integration
+	CloudProvider *string
+	// IgnoreMaintenanceWindow starts the operation outside of the cluster maintenance window
+	IgnoreMaintenanceWindow *bool
 }
 
 // AutoJoinCmd uses cloud provider info to join existing cluster
@@ -464,6 +503,27 @@ type RemoveCmd struct {
 	Force *bool
 	// Confirm suppresses confirmation prompt
 	Confirm *bool
+	// IgnoreMaintenanceWindow starts the operation outside of the cluster maintenance window
+	IgnoreMaintenanceWindow *bool
+	// Drain defines the node drain policy flags
+	Drain DrainFlags
+}
+
+// DrainFlags defines the node drain policy flags of the commands that drain nodes
+type DrainFlags struct {
+	// GracePeriod overrides the termination grace period of evicted pods
+	GracePeriod *time.Duration
+	// NamespaceGracePeriods overrides the grace period of pods in specific namespaces
+	NamespaceGracePeriods *map[string]string
+	// DisruptionBudgetTimeout is how long to retry evictions blocked by disruption budgets
+	DisruptionBudgetTimeout *time.Duration
+	// ForceDeleteTimeout is how long to wait before deleting pods without grace period
+	ForceDeleteTimeout *time.Duration
+	// SkipSelector is the label selector of pods to leave on the node
+	SkipSelector *string
+	// SkipLocalStorageSelector is the label selector of pods with local storage
+	// to leave on the node
+	SkipLocalStorageSelector *string
 }
 
 // PlanCmd manages an operation plan
@@ -480,6 +540,8 @@ type PlanDisplayCmd struct {
 	*kingpin.CmdClause
 	// Output is output format
 	Output *constants.Format
+	// Hooks displays the application hook attempts of the operation
+	Hooks *bool
 }
 
 // PlanExecuteCmd executes a phase of an active operation
@@ -566,6 +628,30 @@ type UpdateTriggerCmd struct {
 	Block *bool
 	// SkipVersionCheck suppresses version mismatch errors
 	SkipVersionCheck *bool
+	// CanarySelector is the label selector of the canary nodes
+	CanarySelector *string
+	// CanaryPause is how long canary nodes should stay healthy before upgrading the rest
+	CanaryPause *time.Duration
+	// BatchSize is the number of regular nodes to upgrade concurrently
+	BatchSize *int
+	// MaxUnavailable is the maximum number of nodes unavailable at the same time
+	MaxUnavailable *int
+	// HealthGates enables health checks after each node is upgraded
+	HealthGates *bool
+	// HealthTimeout is how long health checks can fail before the operation is paused
+	HealthTimeout *time.Duration
+	// HealthChecks lists URLs of custom HTTP health checks
+	HealthChecks *[]string
+	// PrometheusURL is the address of the Prometheus server for health queries
+	PrometheusURL *string
+	// PrometheusQueries lists Prometheus queries that should return no results
+	PrometheusQueries *[]string
+	// AutoRollback rolls back the operation automatically if a phase fails
+	AutoRollback *bool
+	// Drain defines the node drain policy flags
+	Drain DrainFlags
+	// IgnoreMaintenanceWindow starts the operation outside of the cluster maintenance window
+	IgnoreMaintenanceWindow *bool
 }
 
 // UpdateUploadCmd uploads new app version to local cluster
@@ -620,6 +706,41 @@ type UpgradeCmd struct {
 	Resume *bool
 	// SkipVersionCheck suppresses version mismatch errors
 	SkipVersionCheck *bool
+	// CanarySelector is the label selector of the canary nodes
+	CanarySelector *string
+	// CanaryPause is how long canary nodes should stay healthy before upgrading the rest
+	CanaryPause *time.Duration
+	// BatchSize is the number of regular nodes to upgrade concurrently
+	BatchSize *int
+	// MaxUnavailable is the maximum number of nodes unavailable at the same time
+	MaxUnavailable *int
+	// HealthGates enables health checks after each node is upgraded
+	HealthGates *bool
+	// HealthTimeout is how long health checks can fail before the operation is paused
+	HealthTimeout *time.Duration
+	// HealthChecks lists URLs of custom HTTP health checks
+	HealthChecks *[]string
+	// PrometheusURL is the address of the Prometheus server for health queries
+	PrometheusURL *string
+	// PrometheusQueries lists Prometheus queries that should return no results
+	PrometheusQueries *[]string
+	// AutoRollback rolls back the operation automatically if a phase fails
+	AutoRollback *bool
+	// Drain defines the node drain policy flags
+	Drain DrainFlags
+	// IgnoreMaintenanceWindow starts the operation outside of the cluster maintenance window
+	IgnoreMaintenanceWindow *bool
+}
+
+// RollbackCmd rolls back a failed operation
+type RollbackCmd struct {
+	*kingpin.CmdClause
+	// OperationID is the ID of the operation to roll back
+	OperationID *string
+	// Force forces rollback of the phases that cannot be rolled back otherwise
+	Force *bool
+	// Timeout is the rollback timeout
+	Timeout *time.Duration
 }
 
 // StatusCmd displays cluster status
@@ -701,6 +822,8 @@ type AppInstallCmd struct {
 	RegistryCert *string
 	// RegistryKey is a registry client private key path.
 	RegistryKey *string
+	// DryRun renders and validates the release without installing it.
+	DryRun *bool
 }
 
 // AppListCmd shows all application releases.
@@ -729,6 +852,8 @@ type AppUpgradeCmd struct {
 	RegistryCert *string
 	// RegistryKey is a registry client private key path.
 	RegistryKey *string
+	// DryRun renders the upgrade and displays its diff without upgrading.
+	DryRun *bool
 }
 
 // AppRollbackCmd rolls back a release.
@@ -754,6 +879,15 @@ type AppHistoryCmd struct {
 	Release *string
 }
 
+// AppMigrateReleasesCmd converts releases stored by Tiller to Helm 3 format.
+type AppMigrateReleasesCmd struct {
+	*kingpin.CmdClause
+	// DryRun only lists the releases to migrate.
+	DryRun *bool
+	// Cleanup removes Tiller release records after migration.
+	Cleanup *bool
+}
+
 // AppSyncCmd synchronizes an application image with a cluster.
 type AppSyncCmd struct {
 	*kingpin.CmdClause
@@ -1205,6 +1339,20 @@ type ReportCmd struct {
 	*kingpin.CmdClause
 	// FilePath is the report tarball path
 	FilePath *string
+	// RedactIPs specifies whether to redact IP addresses in the report
+	RedactIPs *bool
+	// RedactionRules is the path to the file with additional redaction rules
+	RedactionRules *string
+	// Since limits the collected logs to entries newer than this time
+	Since *string
+	// Until limits the collected logs to entries older than this time
+	Until *string
+	// Collectors lists the collector groups to run
+	Collectors *[]string
+	// Nodes lists the nodes to collect diagnostics from
+	Nodes *[]string
+	// DryRun lists what would be collected without collecting
+	DryRun *bool
 }
 
 // SiteCmd combines cluster related subcommands
@@ -1451,6 +1599,10 @@ type SystemReportCmd struct {
 	Filter *[]string
 	// Compressed allows to gzip the tarball
 	Compressed *bool
+	// Since limits the collected logs to entries newer than this time
+	Since *string
+	// Until limits the collected logs to entries older than this time
+	Until *string
 }
 
 // SystemStateDirCmd shows local state directory
@@ -1499,11 +1651,19 @@ type SystemExportRuntimeJournalCmd struct {
 	*kingpin.CmdClause
 	// OutputFile specifies the path of the resulting tarball
 	OutputFile *string
+	// Since limits the exported journal to entries newer than this time
+	Since *string
+	// Until limits the exported journal to entries older than this time
+	Until *string
 }
 
 // SystemStreamRuntimeJournalCmd streams contents of the runtime journal
 type SystemStreamRuntimeJournalCmd struct {
 	*kingpin.CmdClause
+	// Since limits the journal to entries newer than this time
+	Since *string
+	// Until limits the journal to entries older than this time
+	Until *string
 }
 
 // SystemGCJournalCmd manages cleanup of journal files
@@ -1525,6 +1685,12 @@ type SystemGCPackageCmd struct {
 	DryRun *bool
 	// Cluster specifies whether to prune cluster packages
 	Cluster *bool
+	// KeepLast specifies the number of application versions to keep
+	KeepLast *int
+	// KeepFor specifies the age of application versions to keep
+	KeepFor *time.Duration
+	// MaxSize specifies the total size cap of application versions
+	MaxSize *utils.Capacity
 }
 
 // SystemGCRegistryCmd removes unused docker images
@@ -1537,6 +1703,26 @@ type SystemGCRegistryCmd struct {
 	// DryRun displays the images to be removed
 	// without actually removing anything
 	DryRun *bool
+	// KeepLast specifies the number of application versions to keep images for
+	KeepLast *int
+	// KeepFor specifies the age of application versions to keep images for
+	KeepFor *time.Duration
+	// MaxSize specifies the total size cap of application versions to keep images for
+	MaxSize *utils.Capacity
+}
+
+// SystemGCKubernetesCmd removes orphaned Kubernetes resources
+type SystemGCKubernetesCmd struct {
+	*kingpin.CmdClause
+	// DryRun displays the resources to be removed
+	// without actually removing anything
+	DryRun *bool
+	// RetentionAge specifies the age after which orphaned resources are removed
+	RetentionAge *time.Duration
+	// KeepHookJobs specifies the number of completed jobs to keep for each hook
+	KeepHookJobs *int
+	// PruneReleaseResources enables removal of resources of uninstalled releases
+	PruneReleaseResources *bool
 }
 
 // GarbageCollectCmd prunes unused cluster resources
@@ -1547,6 +1733,14 @@ type GarbageCollectCmd struct {
 	// Confirmed is whether the user has confirmed the removal of custom docker
 	// images
 	Confirmed *bool
+	// IgnoreMaintenanceWindow starts the operation outside of the cluster maintenance window
+	IgnoreMaintenanceWindow *bool
+	// RetentionAge specifies the age after which orphaned Kubernetes resources are removed
+	RetentionAge *time.Duration
+	// KeepHookJobs specifies the number of completed jobs to keep for each hook
+	KeepHookJobs *int
+	// PruneReleaseResources enables removal of resources of uninstalled releases
+	PruneReleaseResources *bool
 }
 
 // GarbageCollectPlanCmd displays the plan of the garbage collection operation
@@ -1616,6 +1810,8 @@ type ResourceCreateCmd struct {
 	Manual *bool
 	// Confirmed suppresses confirmation prompt
 	Confirmed *bool
+	// IgnoreMaintenanceWindow starts the operation outside of the cluster maintenance window
+	IgnoreMaintenanceWindow *bool
 }
 
 // ResourceRemoveCmd removes specified resource
@@ -1635,6 +1831,8 @@ type ResourceRemoveCmd struct {
 	Manual *bool
 	// Confirmed suppresses confirmation prompt
 	Confirmed *bool
+	// IgnoreMaintenanceWindow starts the operation outside of the cluster maintenance window
+	IgnoreMaintenanceWindow *bool
 }
 
 // ResourceGetCmd shows specified resource
@@ -472,25 +472,52 @@ type JoinConfig struct {
 	Phase string
 	// OperationID is ID of existing join operation
 	OperationID string
+	// IgnoreMaintenanceWindow starts the join operation outside of the maintenance window
+	IgnoreMaintenanceWindow bool
+	// Nodes is the number of nodes joining in the new operation
+	Nodes int
+	// ReplaceServer is the failed master node the joining node replaces
+	ReplaceServer string
 }
 
 // NewJoinConfig populates join configuration from the provided CLI application
 func NewJoinConfig(g *Application) JoinConfig {
 	return JoinConfig{
-		SystemLogFile: *g.SystemLogFile,
-		UserLogFile:   *g.UserLogFile,
-		PeerAddrs:     *g.JoinCmd.PeerAddr,
-		AdvertiseAddr: *g.JoinCmd.AdvertiseAddr,
-		ServerAddr:    *g.JoinCmd.ServerAddr,
-		Token:         *g.JoinCmd.Token,
-		Role:          *g.JoinCmd.Role,
-		SystemDevice:  *g.JoinCmd.SystemDevice,
-		DockerDevice:  *g.JoinCmd.DockerDevice,
-		Mounts:        *g.JoinCmd.Mounts,
-		CloudProvider: *g.JoinCmd.CloudProvider,
-		Manual:        *g.JoinCmd.Manual,
-		Phase:         *g.JoinCmd.Phase,
-		OperationID:   *g.JoinCmd.OperationID,
+		SystemLogFile:           *g.SystemLogFile,
+		UserLogFile:             *g.UserLogFile,
+		PeerAddrs:               *g.JoinCmd.PeerAddr,
+		AdvertiseAddr:           *g.JoinCmd.AdvertiseAddr,
+		ServerAddr:              *g.JoinCmd.ServerAddr,
+		Token:                   *g.JoinCmd.Token,
+		Role:                    *g.JoinCmd.Role,
+		SystemDevice:            *g.JoinCmd.SystemDevice,
+		DockerDevice:            *g.JoinCmd.DockerDevice,
+		Mounts:                  *g.JoinCmd.Mounts,
+		CloudProvider:           *g.JoinCmd.CloudProvider,
+		Manual:                  *g.JoinCmd.Manual,
+		Phase:                   *g.JoinCmd.Phase,
+		OperationID:             *g.JoinCmd.OperationID,
+		IgnoreMaintenanceWindow: *g.JoinCmd.IgnoreMaintenanceWindow,
+		Nodes:                   *g.JoinCmd.Nodes,
+	}
+}
+
+// NewReplaceConfig populates configuration of the node replacing
+// a failed master from the provided CLI application
+func NewReplaceConfig(g *Application) JoinConfig {
+	return JoinConfig{
+		SystemLogFile:           *g.SystemLogFile,
+		UserLogFile:             *g.UserLogFile,
+		PeerAddrs:               *g.ReplaceCmd.PeerAddr,
+		AdvertiseAddr:           *g.ReplaceCmd.AdvertiseAddr,
+		ServerAddr:              *g.ReplaceCmd.ServerAddr,
+		Token:                   *g.ReplaceCmd.Token,
+		SystemDevice:            *g.ReplaceCmd.SystemDevice,
+		DockerDevice:            *g.ReplaceCmd.DockerDevice,
+		Mounts:                  *g.ReplaceCmd.Mounts,
+		CloudProvider:           *g.ReplaceCmd.CloudProvider,
+		IgnoreMaintenanceWindow: *g.ReplaceCmd.IgnoreMaintenanceWindow,
+		ReplaceServer:           *g.ReplaceCmd.Node,
 	}
 }
 
@@ -500,6 +527,18 @@ func (j *JoinConfig) CheckAndSetDefaults() (err error) {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if j.Nodes < 0 {
+		return trace.BadParameter("number of nodes can't be negative")
+	}
+	if j.Nodes == 0 {
+		j.Nodes = 1
+	}
+	if j.Nodes > 1 && j.OperationID != "" {
+		return trace.BadParameter("number of nodes can only be set for a new operation")
+	}
+	if j.ReplaceServer != "" && (j.Nodes > 1 || j.OperationID != "") {
+		return trace.BadParameter("a failed master can only be replaced by a single node in a new operation")
+	}
 	return nil
 }
 
@@ -564,24 +603,27 @@ func (j *JoinConfig) ToPeerConfig(env, joinEnv *localenv.LocalEnvironment) (*exp
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	return &expand.PeerConfig{
-		Context:       ctx,
-		Cancel:        cancel,
-		Peers:         peers,
-		AdvertiseAddr: advertiseAddr,
-		ServerAddr:    j.ServerAddr,
-		CloudProvider: j.CloudProvider,
-		EventsC:       make(chan install.Event, 100),
-		WatchCh:       make(chan rpcserver.WatchEvent, 1),
-		RuntimeConfig: *runtimeConfig,
-		Silent:        env.Silent,
-		DebugMode:     env.Debug,
-		Insecure:      env.Insecure,
-		LocalBackend:  env.Backend,
-		LocalApps:     env.Apps,
-		LocalPackages: env.Packages,
-		JoinBackend:   joinEnv.Backend,
-		Manual:        j.Manual,
-		OperationID:   j.OperationID,
+		Context:                 ctx,
+		Cancel:                  cancel,
+		Peers:                   peers,
+		AdvertiseAddr:           advertiseAddr,
+		ServerAddr:              j.ServerAddr,
+		CloudProvider:           j.CloudProvider,
+		EventsC:                 make(chan install.Event, 100),
+		WatchCh:                 make(chan rpcserver.WatchEvent, 1),
+		RuntimeConfig:           *runtimeConfig,
+		Silent:                  env.Silent,
+		DebugMode:               env.Debug,
+		Insecure:                env.Insecure,
+		LocalBackend:            env.Backend,
+		LocalApps:               env.Apps,
+		LocalPackages:           env.Packages,
+		JoinBackend:             joinEnv.Backend,
+		Manual:                  j.Manual,
+		OperationID:             j.OperationID,
+		IgnoreMaintenanceWindow: j.IgnoreMaintenanceWindow,
+		ReplaceServer:           j.ReplaceServer,
+		Nodes:                   j.Nodes,
 	}, nil
 }
 
@@ -0,0 +1,75 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// addDrainFlags registers the node drain policy flags on the specified command
+func addDrainFlags(cmd *kingpin.CmdClause) DrainFlags {
+	return DrainFlags{
+		GracePeriod:              cmd.Flag("drain-grace-period", "Override the termination grace period of pods evicted from drained nodes").Duration(),
+		NamespaceGracePeriods:    cmd.Flag("drain-namespace-grace-period", "Override the termination grace period of pods in the namespace as namespace=duration. Can be specified multiple times").StringMap(),
+		DisruptionBudgetTimeout:  cmd.Flag("drain-disruption-budget-timeout", "How long to retry evictions blocked by pod disruption budgets before the drain fails").Duration(),
+		ForceDeleteTimeout:       cmd.Flag("drain-force-delete-timeout", "How long to wait for pods to be evicted before deleting them without grace period. Pods are never force deleted if unspecified").Duration(),
+		SkipSelector:             cmd.Flag("drain-skip-selector", "Label selector of pods that should not be evicted from drained nodes").String(),
+		SkipLocalStorageSelector: cmd.Flag("drain-skip-local-storage-selector", "Label selector of pods with local storage (emptyDir volumes) that should not be evicted from drained nodes").String(),
+	}
+}
+
+// policy returns the drain policy for these flags
+// or nil if the default policy should be used
+func (r DrainFlags) policy() (*storage.DrainPolicy, error) {
+	if r.isDefault() {
+		return nil, nil
+	}
+	policy := storage.DrainPolicy{
+		GracePeriod:              *r.GracePeriod,
+		DisruptionBudgetTimeout:  *r.DisruptionBudgetTimeout,
+		ForceDeleteTimeout:       *r.ForceDeleteTimeout,
+		SkipSelector:             *r.SkipSelector,
+		SkipLocalStorageSelector: *r.SkipLocalStorageSelector,
+	}
+	for namespace, value := range *r.NamespaceGracePeriods {
+		period, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, trace.BadParameter("invalid grace period %q for namespace %q: %v",
+				value, namespace, err)
+		}
+		if policy.NamespaceGracePeriods == nil {
+			policy.NamespaceGracePeriods = make(map[string]time.Duration)
+		}
+		policy.NamespaceGracePeriods[namespace] = period
+	}
+	if err := policy.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &policy, nil
+}
+
+// isDefault returns true if no drain policy flags have been specified
+func (r DrainFlags) isDefault() bool {
+	return *r.GracePeriod == 0 && len(*r.NamespaceGracePeriods) == 0 &&
+		*r.DisruptionBudgetTimeout == 0 && *r.ForceDeleteTimeout == 0 &&
+		*r.SkipSelector == "" && *r.SkipLocalStorageSelector == ""
+}
@@ -18,6 +18,7 @@ package cli
 
 import (
 	"context"
+	"time"
 
 	"github.com/gravitational/gravity/lib/fsm"
 	libfsm "github.com/gravitational/gravity/lib/fsm"
@@ -92,6 +93,15 @@ func rollbackEnvironPhase(env, updateEnv *localenv.LocalEnvironment, params Phas
 	return trace.Wrap(err)
 }
 
+func rollbackEnvironPlan(env, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation, timeout time.Duration, force bool) error {
+	updater, err := getEnvironUpdater(env, updateEnv, operation)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer updater.Close()
+	return trace.Wrap(updater.RollbackPlan(context.TODO(), timeout, force))
+}
+
 func completeEnvironPlan(env, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation) error {
 	updater, err := getEnvironUpdater(env, updateEnv, operation)
 	if err != nil {
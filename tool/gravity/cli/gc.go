@@ -18,26 +18,31 @@ package cli
 
 import (
 	"context"
+	"time"
 
 	"github.com/gravitational/gravity/lib/app/docker"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	libfsm "github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/httplib"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/state"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
 	"github.com/gravitational/gravity/lib/vacuum"
 	"github.com/gravitational/gravity/lib/vacuum/prune"
 	"github.com/gravitational/gravity/lib/vacuum/prune/journal"
+	"github.com/gravitational/gravity/lib/vacuum/prune/kubernetes"
 	"github.com/gravitational/gravity/lib/vacuum/prune/pack"
 	"github.com/gravitational/gravity/lib/vacuum/prune/registry"
 
 	"github.com/gravitational/trace"
 	"github.com/sirupsen/logrus"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 )
 
-func garbageCollect(env *localenv.LocalEnvironment, manual, confirmed bool) error {
+func garbageCollect(env *localenv.LocalEnvironment, manual, confirmed, ignoreWindow bool, retention storage.RetentionPolicy) error {
 	if !confirmed {
 		env.Println("This operation will also remove docker images that " +
 			"you manually pushed to the docker registry. Are you sure?")
@@ -51,7 +56,7 @@ func garbageCollect(env *localenv.LocalEnvironment, manual, confirmed bool) erro
 		}
 	}
 
-	collector, err := newCollector(env)
+	collector, err := newCollector(env, ignoreWindow, retention)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -84,7 +89,7 @@ $ gravity plan resume`)
 	return nil
 }
 
-func newCollector(env *localenv.LocalEnvironment) (*vacuum.Collector, error) {
+func newCollector(env *localenv.LocalEnvironment, ignoreWindow bool, retention storage.RetentionPolicy) (*vacuum.Collector, error) {
 	clusterPackages, err := env.ClusterPackages()
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -117,8 +122,9 @@ func newCollector(env *localenv.LocalEnvironment) (*vacuum.Collector, error) {
 
 	key, err := operator.CreateClusterGarbageCollectOperation(context.TODO(),
 		ops.CreateClusterGarbageCollectOperationRequest{
-			AccountID:   cluster.AccountID,
-			ClusterName: cluster.Domain,
+			AccountID:               cluster.AccountID,
+			ClusterName:             cluster.Domain,
+			IgnoreMaintenanceWindow: ignoreWindow,
 		},
 	)
 	if err != nil {
@@ -195,6 +201,7 @@ func newCollector(env *localenv.LocalEnvironment) (*vacuum.Collector, error) {
 		Servers:       cluster.ClusterState.Servers,
 		ClusterKey:    cluster.Key(),
 		RuntimePath:   runtimePath,
+		Retention:     retention,
 		Silent:        env.Silent,
 		Runner:        runner,
 	})
@@ -267,7 +274,7 @@ func executeGarbageCollectPhase(env *localenv.LocalEnvironment, params PhasePara
 	return trace.Wrap(err)
 }
 
-func removeUnusedImages(env *localenv.LocalEnvironment, dryRun, confirmed bool) error {
+func removeUnusedImages(env *localenv.LocalEnvironment, dryRun, confirmed bool, retention *storage.QuotaPolicy) error {
 	if !dryRun && !confirmed {
 		env.Println("This operation will also remove docker images that " +
 			"you manually pushed to the docker registry on this node. Are you sure?")
@@ -312,6 +319,7 @@ func removeUnusedImages(env *localenv.LocalEnvironment, dryRun, confirmed bool)
 		Apps:         clusterEnv.Apps,
 		Packages:     clusterEnv.Packages,
 		ImageService: imageService,
+		Retention:    retention,
 		Config: prune.Config{
 			DryRun:      dryRun,
 			FieldLogger: logrus.WithField(trace.Component, "gc/registry"),
@@ -327,7 +335,7 @@ func removeUnusedImages(env *localenv.LocalEnvironment, dryRun, confirmed bool)
 	return trace.Wrap(err)
 }
 
-func removeUnusedPackages(env *localenv.LocalEnvironment, dryRun, pruneClusterPackages bool) error {
+func removeUnusedPackages(env *localenv.LocalEnvironment, dryRun, pruneClusterPackages bool, retention *storage.QuotaPolicy) error {
 	operator, err := env.SiteOperator()
 	if err != nil {
 		return trace.Wrap(err)
@@ -348,8 +356,9 @@ func removeUnusedPackages(env *localenv.LocalEnvironment, dryRun, pruneClusterPa
 			Locator:  cluster.App.Package,
 			Manifest: cluster.App.Manifest,
 		},
-		Apps:     remoteApps,
-		Packages: env.Packages,
+		Apps:      remoteApps,
+		Packages:  env.Packages,
+		Retention: retention,
 		Config: prune.Config{
 			DryRun:      dryRun,
 			FieldLogger: logrus.WithField(trace.Component, "gc:registry"),
@@ -390,6 +399,65 @@ func removeUnusedPackages(env *localenv.LocalEnvironment, dryRun, pruneClusterPa
 	return nil
 }
 
+func removeOrphanedKubernetesResources(env *localenv.LocalEnvironment, dryRun bool, retention storage.RetentionPolicy) error {
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	releases, err := operator.ListReleases(cluster.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	client, config, err := httplib.GetClusterKubeClient(cluster.DNSConfig.Addr())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	extensionsClient, err := apiextensions.NewForConfig(config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	pruner, err := kubernetes.New(kubernetes.Config{
+		Client:           client,
+		ExtensionsClient: extensionsClient,
+		Releases:         kubernetes.ReleaseNames(releases),
+		Retention:        retention,
+		Config: prune.Config{
+			DryRun:      dryRun,
+			FieldLogger: logrus.WithField(trace.Component, "gc:kubernetes"),
+			Silent:      env.Silent,
+		},
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = pruner.Prune(context.TODO())
+	return trace.Wrap(err)
+}
+
+// quotaPolicy returns the quota policy for the specified parameters
+// or nil if none has been specified
+func quotaPolicy(keepLast int, keepFor time.Duration, maxSize utils.Capacity) *storage.QuotaPolicy {
+	policy := storage.QuotaPolicy{
+		KeepLast: keepLast,
+		KeepFor:  keepFor,
+		MaxSize:  maxSize,
+	}
+	if policy.IsEmpty() {
+		return nil
+	}
+	return &policy
+}
+
 func collectRemoteApplications(operator ops.Operator, clusterKey ops.SiteKey) (remoteApps []storage.Application, err error) {
 	accounts, err := operator.GetAccounts()
 	if err != nil {
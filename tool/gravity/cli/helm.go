@@ -22,23 +22,32 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/app/docker"
 	"github.com/gravitational/gravity/lib/catalog"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/helm"
+	"github.com/gravitational/gravity/lib/httplib"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/ops/events"
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
 	helmutils "github.com/gravitational/gravity/lib/utils/helm"
 
+	"github.com/fatih/color"
 	"github.com/ghodss/yaml"
+	"github.com/gravitational/rigging"
 	"github.com/gravitational/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/helm/pkg/provenance"
 	"k8s.io/helm/pkg/repo"
 )
 
@@ -53,6 +62,8 @@ type releaseInstallConfig struct {
 	valuesConfig
 	// registryConfig is registry configuration.
 	registryConfig
+	// DryRun renders and validates the release without installing it.
+	DryRun bool
 }
 
 func (c *releaseInstallConfig) setDefaults(env *localenv.LocalEnvironment) error {
@@ -72,6 +83,8 @@ type releaseUpgradeConfig struct {
 	valuesConfig
 	// registryConfig is registry configuration.
 	registryConfig
+	// DryRun renders the upgrade and displays its diff without upgrading.
+	DryRun bool
 }
 
 func (c *releaseUpgradeConfig) setDefaults(env *localenv.LocalEnvironment) error {
@@ -146,6 +159,14 @@ func releaseInstall(env *localenv.LocalEnvironment, conf releaseInstallConfig) e
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if conf.DryRun {
+		return releaseDryRun(env, imageEnv, helm.DryRunParameters{
+			Values:    conf.Files,
+			Set:       conf.Values,
+			Name:      conf.Name,
+			Namespace: conf.Namespace,
+		}, conf.registryConfig)
+	}
 	err = appSyncEnv(env, imageEnv, appSyncConfig{
 		Image:          conf.Image,
 		registryConfig: conf.registryConfig,
@@ -165,6 +186,10 @@ func releaseInstall(env *localenv.LocalEnvironment, conf releaseInstallConfig) e
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	err = verifyChart(env, tmp)
+	if err != nil {
+		return trace.Wrap(err)
+	}
 	helmClient, err := helm.NewClient(helm.ClientConfig{
 		DNSAddress: env.DNS.Addr(),
 	})
@@ -187,6 +212,103 @@ func releaseInstall(env *localenv.LocalEnvironment, conf releaseInstallConfig) e
 	return nil
 }
 
+// verifyChart verifies the signature of the application chart unpacked
+// into the specified directory according to the cluster chart signing policy.
+//
+// Verification is skipped if the cluster has no chart signing policy.
+func verifyChart(env *localenv.LocalEnvironment, dir string) error {
+	policy, err := getChartSigningPolicy(env)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	verification, err := verifyChartSignature(dir, policy)
+	if err != nil {
+		if policy.IsEnforced() {
+			return trace.Wrap(err)
+		}
+		env.PrintStep("%v", color.YellowString("WARNING: %v", trace.UserMessage(err)))
+		return nil
+	}
+	var identities []string
+	for name := range verification.SignedBy.Identities {
+		identities = append(identities, name)
+	}
+	sort.Strings(identities)
+	env.PrintStep("Verified chart signature of %v", strings.Join(identities, ", "))
+	return nil
+}
+
+// getChartSigningPolicy returns the chart signing policy of the cluster.
+//
+// Returns NotFound if the cluster has no policy or is not a Gravity cluster.
+// If the cluster runs Gravity but its controller cannot be reached, the
+// verification is not skipped and an error is returned instead.
+func getChartSigningPolicy(env *localenv.LocalEnvironment) (storage.ChartSigningPolicy, error) {
+	if err := httplib.InGravity(env.DNS.Addr()); err != nil {
+		gravity, errCheck := runsGravity()
+		if errCheck != nil {
+			return nil, trace.Wrap(errCheck)
+		}
+		if gravity {
+			return nil, trace.ConnectionProblem(err, "failed to fetch the chart signing policy")
+		}
+		return nil, trace.NotFound("not a Gravity cluster")
+	}
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return nil, trace.ConnectionProblem(err, "failed to fetch the chart signing policy")
+	}
+	policy, err := operator.GetChartSigningPolicy(cluster.Key())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// runsGravity returns true if the current Kubernetes cluster is a Gravity cluster
+func runsGravity() (bool, error) {
+	client, _, err := utils.GetLocalKubeClient()
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	_, err = client.CoreV1().Services(constants.KubeSystemNamespace).Get(
+		constants.GravityServiceName, metav1.GetOptions{})
+	err = rigging.ConvertError(err)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return false, nil
+		}
+		return false, trace.Wrap(err)
+	}
+	return true, nil
+}
+
+func verifyChartSignature(dir string, policy storage.ChartSigningPolicy) (*provenance.Verification, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, defaults.ChartProvenanceFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, trace.NotFound("application image is not signed")
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	keyring, err := policy.GetKeyRing()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	verification, err := helm.VerifyChart(filepath.Join(dir, defaults.ResourcesDir), data, keyring)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return verification, nil
+}
+
 func releaseList(env *localenv.LocalEnvironment, all bool) error {
 	helmClient, err := helm.NewClient(helm.ClientConfig{
 		DNSAddress: env.DNS.Addr(),
@@ -250,6 +372,13 @@ func releaseUpgrade(env *localenv.LocalEnvironment, conf releaseUpgradeConfig) e
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if conf.DryRun {
+		return releaseDryRun(env, imageEnv, helm.DryRunParameters{
+			Values:  conf.Files,
+			Set:     conf.Values,
+			Release: release,
+		}, conf.registryConfig)
+	}
 	err = appSyncEnv(env, imageEnv, appSyncConfig{
 		Image:          conf.Image,
 		registryConfig: conf.registryConfig,
@@ -269,6 +398,10 @@ func releaseUpgrade(env *localenv.LocalEnvironment, conf releaseUpgradeConfig) e
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	err = verifyChart(env, tmp)
+	if err != nil {
+		return trace.Wrap(err)
+	}
 	release, err = helmClient.Upgrade(helm.UpgradeParameters{
 		Release: release.GetName(),
 		Path:    filepath.Join(tmp, "resources"),
@@ -284,6 +417,80 @@ func releaseUpgrade(env *localenv.LocalEnvironment, conf releaseUpgradeConfig) e
 	return nil
 }
 
+// releaseDryRun renders the chart of the provided application image without
+// deploying it.
+//
+// On install the rendered manifest is displayed, on upgrade the diff against
+// the manifest of the deployed release. Images referenced by the rendered
+// manifest that are missing from the registry are reported.
+func releaseDryRun(env *localenv.LocalEnvironment, imageEnv *localenv.ImageEnvironment, params helm.DryRunParameters, conf registryConfig) error {
+	tmp, err := ioutil.TempDir("", "")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer os.RemoveAll(tmp)
+	err = pack.Unpack(imageEnv.Packages, imageEnv.Manifest.Locator(), tmp, nil)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = verifyChart(env, tmp)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	params.Path = filepath.Join(tmp, "resources")
+	result, err := helm.DryRun(params)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	switch {
+	case params.Release == nil:
+		fmt.Print(result.Manifest)
+	case result.Diff == "":
+		env.PrintStep("No changes to release %v", params.Release.GetName())
+	default:
+		fmt.Print(result.Diff)
+	}
+	imageService, err := dryRunImageService(env, conf)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if imageService == nil {
+		env.PrintStep(color.YellowString("WARNING: no registry specified, skipping image check"))
+		return nil
+	}
+	var missing []string
+	for _, image := range result.Images {
+		exists, err := imageService.HasImage(context.TODO(), image)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if !exists {
+			missing = append(missing, image)
+		}
+	}
+	if len(missing) == 0 {
+		env.PrintStep("All %v images are present in the registry", len(result.Images))
+		return nil
+	}
+	env.PrintStep("Images missing from the registry (pushed on install if included in the application image):")
+	for _, image := range missing {
+		fmt.Printf("  %v\n", image)
+	}
+	return nil
+}
+
+// dryRunImageService returns the registry client to check release images with.
+// Returns nil if the registry is not known.
+func dryRunImageService(env *localenv.LocalEnvironment, conf registryConfig) (docker.ImageService, error) {
+	if env.InGravity() {
+		return docker.NewClusterImageService(constants.DockerRegistry)
+	}
+	if conf.Registry == "" {
+		return nil, nil
+	}
+	return conf.imageService()
+}
+
 func releaseRollback(env *localenv.LocalEnvironment, conf releaseRollbackConfig) error {
 	helmClient, err := helm.NewClient(helm.ClientConfig{
 		DNSAddress: env.DNS.Addr(),
@@ -349,6 +556,31 @@ func releaseHistory(env *localenv.LocalEnvironment, conf releaseHistoryConfig) e
 	return nil
 }
 
+func releaseMigrate(env *localenv.LocalEnvironment, dryRun, cleanup bool) error {
+	releases, err := helm.MigrateReleases(helm.MigrateParameters{
+		DNSAddress: env.DNS.Addr(),
+		DryRun:     dryRun,
+		Cleanup:    cleanup,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(releases) == 0 {
+		env.Println("No Tiller releases to migrate.")
+		return nil
+	}
+	for _, r := range releases {
+		if dryRun {
+			env.PrintStep("Would migrate release %v revision %v (%v)",
+				r.GetName(), r.GetRevision(), r.GetChart())
+		} else {
+			env.PrintStep("Migrated release %v revision %v (%v)",
+				r.GetName(), r.GetRevision(), r.GetChart())
+		}
+	}
+	return nil
+}
+
 func appSearch(env *localenv.LocalEnvironment, pattern string, remoteOnly, all bool) error {
 	result, err := catalog.Search(catalog.SearchRequest{
 		Pattern: pattern,
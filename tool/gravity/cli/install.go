@@ -25,6 +25,7 @@ import (
 
 	autoscaleaws "github.com/gravitational/gravity/lib/autoscale/aws"
 	cloudaws "github.com/gravitational/gravity/lib/cloudprovider/aws"
+	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/expand"
 	"github.com/gravitational/gravity/lib/fsm"
@@ -210,9 +211,12 @@ func (r *removeConfig) checkAndSetDefaults() error {
 }
 
 type removeConfig struct {
-	server    string
-	force     bool
-	confirmed bool
+	server       string
+	force        bool
+	confirmed    bool
+	ignoreWindow bool
+	// drainPolicy optionally defines how the node is drained before removal
+	drainPolicy *storage.DrainPolicy
 }
 
 func remove(env *localenv.LocalEnvironment, c removeConfig) error {
@@ -249,15 +253,28 @@ func remove(env *localenv.LocalEnvironment, c removeConfig) error {
 
 	key, err := operator.CreateSiteShrinkOperation(context.TODO(),
 		ops.CreateSiteShrinkOperationRequest{
-			AccountID:  site.AccountID,
-			SiteDomain: site.Domain,
-			Servers:    []string{server.Hostname},
-			Force:      c.force,
+			AccountID:               site.AccountID,
+			SiteDomain:              site.Domain,
+			Servers:                 []string{server.Hostname},
+			Force:                   c.force,
+			IgnoreMaintenanceWindow: c.ignoreWindow,
+			DrainPolicy:             c.drainPolicy,
 		})
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
+	operation, err := operator.GetSiteOperation(*key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if operation.IsScheduled() && operation.Schedule != nil {
+		fmt.Printf("maintenance window is closed, operation %q is scheduled to start after %v, "+
+			"use 'gravity status' to poll its progress\n", key.OperationID,
+			operation.Schedule.StartAfter.Format(constants.HumanDateFormat))
+		return nil
+	}
+
 	fmt.Printf("launched operation %q, use 'gravity status' to poll its progress\n", key.OperationID)
 	return nil
 }
@@ -529,6 +546,14 @@ func executeJoinPhase(localEnv, joinEnv *localenv.LocalEnvironment, p PhaseParam
 		// determine the ongoing expand operation, it should be the only
 		// operation present in the local join-specific backend
 		operation, err = ops.GetExpandOperation(joinEnv.Backend)
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		if p.OperationID != "" && (operation == nil || operation.ID != p.OperationID) {
+			// this node is joining in the operation executed by another
+			// node and has not synchronized it yet
+			operation, err = syncExpandOperation(operator, joinEnv.Backend, p.OperationID)
+		}
 		if err != nil {
 			return trace.Wrap(err)
 		}
@@ -562,6 +587,25 @@ func executeJoinPhase(localEnv, joinEnv *localenv.LocalEnvironment, p PhaseParam
 	})
 }
 
+// syncExpandOperation synchronizes the expand operation with the specified ID
+// from the cluster to the provided local join backend
+func syncExpandOperation(operator ops.Operator, backend storage.Backend, operationID string) (*ops.SiteOperation, error) {
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	key := ops.SiteOperationKey{
+		AccountID:   cluster.AccountID,
+		SiteDomain:  cluster.Domain,
+		OperationID: operationID,
+	}
+	err = expand.SyncOperation(operator, backend, *cluster, key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return ops.GetExpandOperation(backend)
+}
+
 func rollbackJoinPhase(localEnv, joinEnv *localenv.LocalEnvironment, p PhaseParams, operation *ops.SiteOperation) error {
 	operator, err := joinEnv.CurrentOperator(httplib.WithInsecure(), httplib.WithTimeout(5*time.Second))
 	if err != nil {
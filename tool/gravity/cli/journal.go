@@ -28,6 +28,7 @@ import (
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/report"
 	"github.com/gravitational/gravity/lib/state"
 	"github.com/gravitational/gravity/lib/system"
 	"github.com/gravitational/gravity/lib/system/mount"
@@ -37,7 +38,7 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func exportRuntimeJournal(env *localenv.LocalEnvironment, outputFile string) error {
+func exportRuntimeJournal(env *localenv.LocalEnvironment, outputFile string, timeRange report.TimeRange) error {
 	stateDir, err := state.GetStateDir()
 	if err != nil {
 		return trace.Wrap(err)
@@ -90,7 +91,8 @@ func exportRuntimeJournal(env *localenv.LocalEnvironment, outputFile string) err
 
 	zip := gzip.NewWriter(w)
 	defer zip.Close()
-	cmd := exec.CommandContext(ctx, utils.Exe.Path, "system", "stream-runtime-journal")
+	args := append([]string{"system", "stream-runtime-journal"}, timeRange.Args()...)
+	cmd := exec.CommandContext(ctx, utils.Exe.Path, args...)
 	cmd.Stdout = zip
 	cmd.Stderr = zip
 	if err = cmd.Run(); err != nil {
@@ -100,7 +102,7 @@ func exportRuntimeJournal(env *localenv.LocalEnvironment, outputFile string) err
 	return nil
 }
 
-func streamRuntimeJournal(env *localenv.LocalEnvironment) error {
+func streamRuntimeJournal(env *localenv.LocalEnvironment, timeRange report.TimeRange) error {
 	runtimePackage, err := pack.FindRuntimePackage(env.Packages)
 	if err != nil {
 		return trace.Wrap(err)
@@ -127,6 +129,7 @@ func streamRuntimeJournal(env *localenv.LocalEnvironment) error {
 		"--output", "export",
 		"-D", journalDir,
 	}
+	args = append(args, timeRange.JournalArgs()...)
 	if err := syscall.Exec(cmd, args, nil); err != nil {
 		return trace.Wrap(trace.ConvertSystemError(err),
 			"failed to execve(%q, %q)", cmd, args)
@@ -89,6 +89,34 @@ func rollbackPhase(localEnv, updateEnv, joinEnv *localenv.LocalEnvironment, para
 	}
 }
 
+// rollbackOperation rolls back all phases of the specified failed operation,
+// verifies the cluster health and marks the operation as rolled back
+func rollbackOperation(localEnv, updateEnv, joinEnv *localenv.LocalEnvironment, operationID string, timeout time.Duration, force bool) error {
+	op, err := getActiveOperation(localEnv, updateEnv, joinEnv, operationID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if op.IsRolledBack() {
+		return trace.BadParameter("operation %v has already been rolled back", op.ID)
+	}
+	localEnv.Printf("Rolling back operation %v.\n", op)
+	switch op.Type {
+	case ops.OperationUpdate:
+		err = rollbackUpdatePlan(localEnv, updateEnv, *op, timeout, force)
+	case ops.OperationUpdateRuntimeEnviron:
+		err = rollbackEnvironPlan(localEnv, updateEnv, *op, timeout, force)
+	case ops.OperationUpdateConfig:
+		err = rollbackConfigPlan(localEnv, updateEnv, *op, timeout, force)
+	default:
+		return trace.BadParameter("operation type %q does not support automatic rollback", op.Type)
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	localEnv.Println("Operation has been rolled back.")
+	return nil
+}
+
 func completeOperationPlan(localEnv, updateEnv, joinEnv *localenv.LocalEnvironment, operationID string) error {
 	op, err := getActiveOperation(localEnv, updateEnv, joinEnv, operationID)
 	if err != nil {
@@ -23,11 +23,13 @@ import (
 	_ "net/http/pprof"
 	"strings"
 
+	"github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/app/docker"
 	appservice "github.com/gravitational/gravity/lib/app/service"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/install"
+	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/pack"
@@ -168,6 +170,12 @@ func uploadUpdate(env *localenv.LocalEnvironment, opsURL string) error {
 		env.PrintStep("Application already exists in local cluster")
 	}
 
+	intermediateRuntimes, err := uploadIntermediateRuntimes(env, tarballPackages, tarballApps,
+		clusterPackages, clusterApps, *appPackage)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
 	var registries []string
 	err = utils.Retry(defaults.RetryInterval, defaults.RetryLessAttempts, func() error {
 		registries, err = getRegistries(context.TODO(), defaultEnv, cluster.ClusterState.Servers)
@@ -196,14 +204,16 @@ func uploadUpdate(env *localenv.LocalEnvironment, opsURL string) error {
 		if err != nil {
 			return trace.Wrap(err)
 		}
-		err = appservice.SyncApp(context.TODO(), appservice.SyncRequest{
-			PackService:  clusterPackages,
-			AppService:   clusterApps,
-			ImageService: imageService,
-			Package:      *appPackage,
-		})
-		if err != nil {
-			return trace.Wrap(err)
+		for _, locator := range append([]loc.Locator{*appPackage}, intermediateRuntimes...) {
+			err = appservice.SyncApp(context.TODO(), appservice.SyncRequest{
+				PackService:  clusterPackages,
+				AppService:   clusterApps,
+				ImageService: imageService,
+				Package:      locator,
+			})
+			if err != nil {
+				return trace.Wrap(err)
+			}
 		}
 	}
 
@@ -211,6 +221,49 @@ func uploadUpdate(env *localenv.LocalEnvironment, opsURL string) error {
 	return nil
 }
 
+// uploadIntermediateRuntimes imports the intermediate runtimes packaged with
+// the upgrade tarball into the cluster and returns their locators.
+// Intermediate runtimes are packaged by "tele build --upgrade-from" for multi-hop upgrades
+func uploadIntermediateRuntimes(
+	env *localenv.LocalEnvironment,
+	tarballPackages pack.PackageService,
+	tarballApps app.Applications,
+	clusterPackages pack.PackageService,
+	clusterApps app.Applications,
+	appPackage loc.Locator,
+) (runtimes []loc.Locator, err error) {
+	application, err := tarballApps.GetApp(appPackage)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	base := application.Manifest.Base()
+	items, err := tarballApps.ListApps(app.ListAppsRequest{
+		Repository: defaults.SystemAccountOrg,
+		Type:       storage.AppRuntime,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, item := range items {
+		if item.Package.Name != defaults.Runtime || (base != nil && item.Package.IsEqualTo(*base)) {
+			continue
+		}
+		env.PrintStep("Importing intermediate runtime %v", item.Package.Version)
+		_, err = appservice.PullApp(appservice.AppPullRequest{
+			SrcPack: tarballPackages,
+			SrcApp:  tarballApps,
+			DstPack: clusterPackages,
+			DstApp:  clusterApps,
+			Package: item.Package,
+		})
+		if err != nil && !trace.IsAlreadyExists(err) {
+			return nil, trace.Wrap(err)
+		}
+		runtimes = append(runtimes, item.Package)
+	}
+	return runtimes, nil
+}
+
 // getRegistries returns a list of registry addresses in the cluster
 func getRegistries(ctx context.Context, env *localenv.LocalEnvironment, servers []storage.Server) ([]string, error) {
 	// in planets before certain version registry was running only on active master
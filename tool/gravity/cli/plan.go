@@ -18,6 +18,7 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"time"
@@ -32,6 +33,7 @@ import (
 	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/fatih/color"
+	"github.com/ghodss/yaml"
 	"github.com/gravitational/trace"
 	"github.com/sirupsen/logrus"
 )
@@ -158,6 +160,78 @@ func displayExpandOperationPlan(joinEnv *localenv.LocalEnvironment, opKey ops.Si
 	return outputPlan(*plan, format)
 }
 
+// displayHookAttempts shows the application hook attempts of the operation
+func displayHookAttempts(localEnv, updateEnv, joinEnv *localenv.LocalEnvironment, operationID string, format constants.Format) error {
+	op, err := getLastOperation(localEnv, updateEnv, joinEnv, operationID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var operator ops.Operator
+	if op.Type == ops.OperationInstall && !op.IsCompleted() {
+		wizardEnv, err := localenv.NewRemoteEnvironment()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if wizardEnv.Operator == nil {
+			return trace.NotFound("could not connect to the installer process, " +
+				`make sure you're invoking "gravity plan" command from the same ` +
+				`directory where "gravity install" was run`)
+		}
+		operator = wizardEnv.Operator
+	} else {
+		clusterEnv, err := localEnv.NewClusterEnvironment()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		operator = clusterEnv.Operator
+	}
+	attempts, err := operator.GetHookAttempts(op.Key())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return outputHookAttempts(attempts, format)
+}
+
+func outputHookAttempts(attempts []storage.HookAttempt, format constants.Format) error {
+	var data []byte
+	var err error
+	switch format {
+	case constants.EncodingYAML:
+		data, err = yaml.Marshal(attempts)
+	case constants.EncodingJSON:
+		data, err = json.MarshalIndent(attempts, "", "  ")
+	case constants.EncodingText:
+	default:
+		return trace.BadParameter("unknown output format %q", format)
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if data != nil {
+		fmt.Println(string(data))
+		return nil
+	}
+	if len(attempts) == 0 {
+		fmt.Println("The operation has not run any application hooks.")
+		return nil
+	}
+	for _, attempt := range attempts {
+		status := color.GreenString("completed")
+		if attempt.Error != "" {
+			status = color.RedString("failed: %v", attempt.Error)
+		}
+		hook := attempt.Hook
+		if attempt.Server != "" {
+			hook = fmt.Sprintf("%v on %v", attempt.Hook, attempt.Server)
+		}
+		fmt.Printf("%v hook of %v, attempt %v (phase %v, %v), %v\n",
+			hook, attempt.Application, attempt.Attempt, attempt.PhaseID,
+			attempt.Completed.Sub(attempt.Started).Round(time.Second), status)
+		fmt.Println(attempt.Output)
+	}
+	return nil
+}
+
 func outputPlan(plan storage.OperationPlan, format constants.Format) (err error) {
 	switch format {
 	case constants.EncodingYAML:
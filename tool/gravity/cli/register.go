@@ -26,6 +26,7 @@ import (
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/modules"
+	"github.com/gravitational/gravity/lib/report"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/utils"
 	"github.com/gravitational/gravity/tool/common"
@@ -114,7 +115,21 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.JoinCmd.PhaseTimeout = g.JoinCmd.Flag("timeout", "Phase execution timeout").Default(defaults.PhaseTimeout).Hidden().Duration()
 	g.JoinCmd.Resume = g.JoinCmd.Flag("resume", "Resume joining from last failed step").Bool()
 	g.JoinCmd.Force = g.JoinCmd.Flag("force", "Force phase execution").Bool()
-	g.JoinCmd.OperationID = g.JoinCmd.Flag("operation-id", "ID of the operation that was created via UI").Hidden().String()
+	g.JoinCmd.OperationID = g.JoinCmd.Flag("operation-id", "ID of the existing join operation to join the node in").String()
+	g.JoinCmd.IgnoreMaintenanceWindow = g.JoinCmd.Flag("ignore-maintenance-window", "Start the operation right away even if the cluster maintenance window is closed").Bool()
+	g.JoinCmd.Nodes = g.JoinCmd.Flag("nodes", "Total number of nodes joining in the new operation, other nodes join it with --operation-id").Default("1").Int()
+
+	g.ReplaceCmd.CmdClause = g.Command("replace", "Join this node to the cluster in place of a failed master node")
+	g.ReplaceCmd.Node = g.ReplaceCmd.Arg("node", "Hostname or IP address of the failed master node to replace").Required().String()
+	g.ReplaceCmd.PeerAddr = g.ReplaceCmd.Arg("peer-addrs", "One or several IP addresses of cluster node to join, as comma-separated values").Required().String()
+	g.ReplaceCmd.Token = g.ReplaceCmd.Flag("with", "Unique install token to authorize this node to join the cluster").Required().String()
+	g.ReplaceCmd.AdvertiseAddr = g.ReplaceCmd.Flag("advertise-addr", "IP address to advertise").String()
+	g.ReplaceCmd.DockerDevice = g.ReplaceCmd.Flag("docker-device", "Docker device to use").Hidden().String()
+	g.ReplaceCmd.SystemDevice = g.ReplaceCmd.Flag("system-device", "Device to use for system data directory").Hidden().String()
+	g.ReplaceCmd.ServerAddr = g.ReplaceCmd.Flag("server-addr", "Address of the agent server").Hidden().String()
+	g.ReplaceCmd.Mounts = configure.KeyValParam(g.ReplaceCmd.Flag("mount", "One or several mounts in form <mount-name>:<path>, e.g. data:/var/lib/data"))
+	g.ReplaceCmd.CloudProvider = g.ReplaceCmd.Flag("cloud-provider", "Cloud provider integration e.g. 'generic', 'aws'. If not set, autodetect environment").String()
+	g.ReplaceCmd.IgnoreMaintenanceWindow = g.ReplaceCmd.Flag("ignore-maintenance-window", "Start the operation right away even if the cluster maintenance window is closed").Bool()
 
 	g.AutoJoinCmd.CmdClause = g.Command("autojoin", "Use cloud provider data to join a node to existing cluster")
 	g.AutoJoinCmd.ClusterName = g.AutoJoinCmd.Arg("cluster-name", "Cluster name used for discovery").Required().String()
@@ -132,6 +147,8 @@ func RegisterCommands(app *kingpin.Application) *Application {
 		Required().String()
 	g.RemoveCmd.Force = g.RemoveCmd.Flag("force", "Force removal of offline node").Bool()
 	g.RemoveCmd.Confirm = g.RemoveCmd.Flag("confirm", "Do not ask for confirmation").Bool()
+	g.RemoveCmd.IgnoreMaintenanceWindow = g.RemoveCmd.Flag("ignore-maintenance-window", "Start the operation right away even if the cluster maintenance window is closed").Bool()
+	g.RemoveCmd.Drain = addDrainFlags(g.RemoveCmd.CmdClause)
 
 	g.PlanCmd.CmdClause = g.Command("plan", "Manage operation plan")
 	g.PlanCmd.OperationID = g.PlanCmd.Flag("operation-id", "ID of the active operation. It not specified, the last operation will be used").Hidden().String()
@@ -139,6 +156,7 @@ func RegisterCommands(app *kingpin.Application) *Application {
 
 	g.PlanDisplayCmd.CmdClause = g.PlanCmd.Command("display", "Display a plan for an ongoing operation").Default()
 	g.PlanDisplayCmd.Output = common.Format(g.PlanDisplayCmd.Flag("output", "Output format for the plan, text, json or yaml").Short('o').Default(string(constants.EncodingText)))
+	g.PlanDisplayCmd.Hooks = g.PlanDisplayCmd.Flag("hooks", "Display the output of application hooks run by the operation instead of the plan").Bool()
 
 	g.PlanExecuteCmd.CmdClause = g.PlanCmd.Command("execute", "Execute specified operation phase")
 	g.PlanExecuteCmd.Phase = g.PlanExecuteCmd.Flag("phase", "Phase ID to execute").String()
@@ -169,6 +187,18 @@ func RegisterCommands(app *kingpin.Application) *Application {
 		Default("true").
 		Bool()
 	g.UpdateTriggerCmd.SkipVersionCheck = g.UpdateTriggerCmd.Flag("skip-version-check", "Bypass version compatibility check").Hidden().Bool()
+	g.UpdateTriggerCmd.CanarySelector = g.UpdateTriggerCmd.Flag("canary-selector", "Label selector of the regular nodes to upgrade first, e.g. 'upgrade=canary'").String()
+	g.UpdateTriggerCmd.CanaryPause = g.UpdateTriggerCmd.Flag("canary-pause", "How long canary nodes should stay healthy before the remaining nodes are upgraded").Duration()
+	g.UpdateTriggerCmd.BatchSize = g.UpdateTriggerCmd.Flag("batch-size", "Number of regular nodes to upgrade at a time").Int()
+	g.UpdateTriggerCmd.MaxUnavailable = g.UpdateTriggerCmd.Flag("max-unavailable", "Maximum number of regular nodes that can be unavailable at the same time").Int()
+	g.UpdateTriggerCmd.HealthGates = g.UpdateTriggerCmd.Flag("health-gates", "Verify cluster health after each node is upgraded").Bool()
+	g.UpdateTriggerCmd.HealthTimeout = g.UpdateTriggerCmd.Flag("health-timeout", "How long health gates can fail before the operation is paused").Default(defaults.HealthGateTimeout.String()).Duration()
+	g.UpdateTriggerCmd.HealthChecks = g.UpdateTriggerCmd.Flag("health-check", "URL of a custom HTTP health check to verify after each node is upgraded. Can be specified multiple times").Strings()
+	g.UpdateTriggerCmd.PrometheusURL = g.UpdateTriggerCmd.Flag("prometheus-url", "Address of the Prometheus server to run health queries against").String()
+	g.UpdateTriggerCmd.PrometheusQueries = g.UpdateTriggerCmd.Flag("prometheus-query", "Prometheus query that should return no results after each node is upgraded, e.g. firing alerts. Can be specified multiple times").Strings()
+	g.UpdateTriggerCmd.AutoRollback = g.UpdateTriggerCmd.Flag("auto-rollback", "Roll back the operation automatically if any of its phases fails").Bool()
+	g.UpdateTriggerCmd.IgnoreMaintenanceWindow = g.UpdateTriggerCmd.Flag("ignore-maintenance-window", "Start the operation right away even if the cluster maintenance window is closed").Bool()
+	g.UpdateTriggerCmd.Drain = addDrainFlags(g.UpdateTriggerCmd.CmdClause)
 
 	g.UpdatePlanInitCmd.CmdClause = g.UpdateCmd.Command("init-plan", "Initialize operation plan").Hidden()
 
@@ -185,6 +215,23 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.UpgradeCmd.Force = g.UpgradeCmd.Flag("force", "Force phase execution even if pre-conditions are not satisfied").Bool()
 	g.UpgradeCmd.Resume = g.UpgradeCmd.Flag("resume", "Resume upgrade from the last failed step").Bool()
 	g.UpgradeCmd.SkipVersionCheck = g.UpgradeCmd.Flag("skip-version-check", "Bypass version compatibility check").Hidden().Bool()
+	g.UpgradeCmd.CanarySelector = g.UpgradeCmd.Flag("canary-selector", "Label selector of the regular nodes to upgrade first, e.g. 'upgrade=canary'").String()
+	g.UpgradeCmd.CanaryPause = g.UpgradeCmd.Flag("canary-pause", "How long canary nodes should stay healthy before the remaining nodes are upgraded").Duration()
+	g.UpgradeCmd.BatchSize = g.UpgradeCmd.Flag("batch-size", "Number of regular nodes to upgrade at a time").Int()
+	g.UpgradeCmd.MaxUnavailable = g.UpgradeCmd.Flag("max-unavailable", "Maximum number of regular nodes that can be unavailable at the same time").Int()
+	g.UpgradeCmd.HealthGates = g.UpgradeCmd.Flag("health-gates", "Verify cluster health after each node is upgraded").Bool()
+	g.UpgradeCmd.HealthTimeout = g.UpgradeCmd.Flag("health-timeout", "How long health gates can fail before the operation is paused").Default(defaults.HealthGateTimeout.String()).Duration()
+	g.UpgradeCmd.HealthChecks = g.UpgradeCmd.Flag("health-check", "URL of a custom HTTP health check to verify after each node is upgraded. Can be specified multiple times").Strings()
+	g.UpgradeCmd.PrometheusURL = g.UpgradeCmd.Flag("prometheus-url", "Address of the Prometheus server to run health queries against").String()
+	g.UpgradeCmd.PrometheusQueries = g.UpgradeCmd.Flag("prometheus-query", "Prometheus query that should return no results after each node is upgraded, e.g. firing alerts. Can be specified multiple times").Strings()
+	g.UpgradeCmd.AutoRollback = g.UpgradeCmd.Flag("auto-rollback", "Roll back the operation automatically if any of its phases fails").Bool()
+	g.UpgradeCmd.IgnoreMaintenanceWindow = g.UpgradeCmd.Flag("ignore-maintenance-window", "Start the operation right away even if the cluster maintenance window is closed").Bool()
+	g.UpgradeCmd.Drain = addDrainFlags(g.UpgradeCmd.CmdClause)
+
+	g.RollbackCmd.CmdClause = g.Command("rollback", "Roll back a failed operation")
+	g.RollbackCmd.OperationID = g.RollbackCmd.Flag("operation-id", "ID of the operation to roll back. Defaults to the last failed operation").String()
+	g.RollbackCmd.Force = g.RollbackCmd.Flag("force", "Force rollback of phases that cannot be rolled back otherwise").Bool()
+	g.RollbackCmd.Timeout = g.RollbackCmd.Flag("timeout", "Rollback timeout").Default(defaults.PhaseTimeout).Duration()
 
 	g.UpdateUploadCmd.CmdClause = g.UpdateCmd.Command("upload", "Upload update package to locally running site").Hidden()
 	g.UpdateUploadCmd.OpsCenterURL = g.UpdateUploadCmd.Flag("ops-url", "Optional OpsCenter URL to upload new packages to (defaults to local gravity site)").Default(defaults.GravityServiceURL).String()
@@ -241,6 +288,7 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.AppInstallCmd.RegistryCA = g.AppInstallCmd.Flag("registry-ca", "Docker registry CA certificate path.").String()
 	g.AppInstallCmd.RegistryCert = g.AppInstallCmd.Flag("registry-cert", "Docker registry client certificate path.").String()
 	g.AppInstallCmd.RegistryKey = g.AppInstallCmd.Flag("registry-key", "Docker registry client private key path.").String()
+	g.AppInstallCmd.DryRun = g.AppInstallCmd.Flag("dry-run", "Render and validate the release and check its images without installing it.").Bool()
 
 	g.AppListCmd.CmdClause = g.AppCmd.Command("ls", "Show all application releases.").Alias("list")
 	g.AppListCmd.All = g.AppListCmd.Flag("all", "Do not filter releases by status.").Short('a').Bool()
@@ -254,6 +302,7 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.AppUpgradeCmd.RegistryCA = g.AppUpgradeCmd.Flag("registry-ca", "Docker registry CA certificate path.").String()
 	g.AppUpgradeCmd.RegistryCert = g.AppUpgradeCmd.Flag("registry-cert", "Docker registry client certificate path.").String()
 	g.AppUpgradeCmd.RegistryKey = g.AppUpgradeCmd.Flag("registry-key", "Docker registry client private key path.").String()
+	g.AppUpgradeCmd.DryRun = g.AppUpgradeCmd.Flag("dry-run", "Render and validate the upgrade, display the diff against the deployed release and check its images without upgrading.").Bool()
 
 	g.AppRollbackCmd.CmdClause = g.AppCmd.Command("rollback", "Rollback a release.")
 	g.AppRollbackCmd.Release = g.AppRollbackCmd.Arg("release", "Release name to rollback.").Required().String()
@@ -265,6 +314,10 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.AppHistoryCmd.CmdClause = g.AppCmd.Command("history", "Display revision history for a release.")
 	g.AppHistoryCmd.Release = g.AppHistoryCmd.Arg("release", "Release name to display revisions for.").Required().String()
 
+	g.AppMigrateReleasesCmd.CmdClause = g.AppCmd.Command("migrate-releases", "Convert releases managed by Tiller to Helm 3 format.")
+	g.AppMigrateReleasesCmd.DryRun = g.AppMigrateReleasesCmd.Flag("dry-run", "Only list releases to migrate w/o migrating them.").Bool()
+	g.AppMigrateReleasesCmd.Cleanup = g.AppMigrateReleasesCmd.Flag("cleanup", "Remove Tiller release records after migration.").Bool()
+
 	g.AppSyncCmd.CmdClause = g.AppCmd.Command("sync", "Synchronize an application image with a cluster.")
 	g.AppSyncCmd.Image = g.AppSyncCmd.Arg("image", "Specifies application image to install. Can be an image tarball, an unpacked image tarball, or an image name in the form of <name>:<version>.").Required().String()
 	g.AppSyncCmd.Registry = g.AppSyncCmd.Flag("registry", "Address of Docker registry to push application images to.").String()
@@ -510,6 +563,13 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	// get cluster diagnostics report
 	g.ReportCmd.CmdClause = g.Command("report", "Generate cluster diagnostics report")
 	g.ReportCmd.FilePath = g.ReportCmd.Flag("file", "target report file name").Default("report.tar.gz").String()
+	g.ReportCmd.RedactIPs = g.ReportCmd.Flag("redact-ips", "redact IP addresses in the collected diagnostics").Bool()
+	g.ReportCmd.RedactionRules = g.ReportCmd.Flag("redaction-rules", "path to the YAML file with additional redaction rules").String()
+	g.ReportCmd.Since = g.ReportCmd.Flag("since", "only collect logs newer than the specified duration (e.g. 2h) or RFC3339 timestamp").String()
+	g.ReportCmd.Until = g.ReportCmd.Flag("until", "only collect logs older than the specified duration (e.g. 30m) or RFC3339 timestamp").String()
+	g.ReportCmd.Collectors = g.ReportCmd.Flag("collectors", fmt.Sprintf("collector groups to run, one of %v. Can be repeated. Runs all collectors if unspecified", report.AllFilters)).Enums(report.AllFilters...)
+	g.ReportCmd.Nodes = g.ReportCmd.Flag("nodes", "hostname or IP address of the node to collect diagnostics from. Can be repeated. Collects from all nodes if unspecified").Strings()
+	g.ReportCmd.DryRun = g.ReportCmd.Flag("dry-run", "list what would be collected without collecting").Bool()
 
 	// operations on sites
 	g.SiteCmd.CmdClause = g.Command("site", "operations on gravity sites")
@@ -631,8 +691,10 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.SystemServiceListCmd.CmdClause = g.SystemServiceCmd.Command("list", "list running services").Hidden()
 
 	g.SystemReportCmd.CmdClause = g.SystemCmd.Command("report", "collect system diagnostics and output as gzipped tarball to terminal").Hidden()
-	g.SystemReportCmd.Filter = g.SystemReportCmd.Flag("filter", "collect only specific diagnostics ('system', 'kubernetes'). Collect everything if unspecified").Strings()
+	g.SystemReportCmd.Filter = g.SystemReportCmd.Flag("filter", fmt.Sprintf("collect only specific diagnostics (%v). Collect everything if unspecified", report.AllFilters)).Strings()
 	g.SystemReportCmd.Compressed = g.SystemReportCmd.Flag("compressed", "whether to compress the tarball").Default("true").Bool()
+	g.SystemReportCmd.Since = g.SystemReportCmd.Flag("since", "only collect logs newer than the specified duration or RFC3339 timestamp").String()
+	g.SystemReportCmd.Until = g.SystemReportCmd.Flag("until", "only collect logs older than the specified duration or RFC3339 timestamp").String()
 
 	g.SystemStateDirCmd.CmdClause = g.SystemCmd.Command("state-dir", "show where all gravity data is stored on the node").Hidden()
 
@@ -652,13 +714,21 @@ func RegisterCommands(app *kingpin.Application) *Application {
 
 	g.SystemExportRuntimeJournalCmd.CmdClause = g.SystemCmd.Command("export-runtime-journal", "Export runtime journal logs to a file").Hidden()
 	g.SystemExportRuntimeJournalCmd.OutputFile = g.SystemExportRuntimeJournalCmd.Flag("output", "Name of resulting tarball. Output to stdout if unspecified").String()
+	g.SystemExportRuntimeJournalCmd.Since = g.SystemExportRuntimeJournalCmd.Flag("since", "Only export entries newer than the specified duration or RFC3339 timestamp").String()
+	g.SystemExportRuntimeJournalCmd.Until = g.SystemExportRuntimeJournalCmd.Flag("until", "Only export entries older than the specified duration or RFC3339 timestamp").String()
 
 	g.SystemStreamRuntimeJournalCmd.CmdClause = g.SystemCmd.Command("stream-runtime-journal", "Stream runtime journal to stdout").Hidden()
+	g.SystemStreamRuntimeJournalCmd.Since = g.SystemStreamRuntimeJournalCmd.Flag("since", "Only stream entries newer than the specified duration or RFC3339 timestamp").String()
+	g.SystemStreamRuntimeJournalCmd.Until = g.SystemStreamRuntimeJournalCmd.Flag("until", "Only stream entries older than the specified duration or RFC3339 timestamp").String()
 
 	// pruning cluster resources
 	g.GarbageCollectCmd.CmdClause = g.Command("gc", "Prune cluster resources")
 	g.GarbageCollectCmd.Manual = g.GarbageCollectCmd.Flag("manual", "Do not start the operation automatically").Short('m').Bool()
 	g.GarbageCollectCmd.Confirmed = g.GarbageCollectCmd.Flag("confirm", "Confirm to remove unrelated docker images").Short('c').Bool()
+	g.GarbageCollectCmd.IgnoreMaintenanceWindow = g.GarbageCollectCmd.Flag("ignore-maintenance-window", "Start the operation right away even if the cluster maintenance window is closed").Bool()
+	g.GarbageCollectCmd.RetentionAge = g.GarbageCollectCmd.Flag("retention", "Remove orphaned Kubernetes resources older than the specified duration").Default(defaults.GarbageCollectRetentionAge.String()).Duration()
+	g.GarbageCollectCmd.KeepHookJobs = g.GarbageCollectCmd.Flag("keep-hook-jobs", "Number of most recent completed jobs to keep for each application hook").Default(strconv.Itoa(defaults.GarbageCollectHookJobs)).Int()
+	g.GarbageCollectCmd.PruneReleaseResources = g.GarbageCollectCmd.Flag("prune-release-resources", "Remove Kubernetes resources left behind by uninstalled Helm releases").Bool()
 
 	// system clean up tasks
 	systemGCCmd := g.SystemCmd.Command("gc", "Run system clean up tasks")
@@ -676,10 +746,24 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.SystemGCPackageCmd.CmdClause = systemGCCmd.Command("package", "Prune unused packages.")
 	g.SystemGCPackageCmd.DryRun = g.SystemGCPackageCmd.Flag("dry-run", "Only list packages to remove w/o removing them").Bool()
 	g.SystemGCPackageCmd.Cluster = g.SystemGCPackageCmd.Flag("cluster", "Whether to prune cluster packages").Bool()
+	g.SystemGCPackageCmd.KeepLast = g.SystemGCPackageCmd.Flag("keep-last", "Number of most recent versions to keep for each application").Int()
+	g.SystemGCPackageCmd.KeepFor = g.SystemGCPackageCmd.Flag("keep-for", "Keep application versions created within the specified duration").Duration()
+	g.SystemGCPackageCmd.MaxSize = new(utils.Capacity)
+	g.SystemGCPackageCmd.Flag("max-size", "Maximum total size of retained application versions, e.g. 10GB").SetValue(g.SystemGCPackageCmd.MaxSize)
 
 	g.SystemGCRegistryCmd.CmdClause = systemGCCmd.Command("registry", "Prune unused docker images on this node.")
 	g.SystemGCRegistryCmd.Confirm = g.SystemGCRegistryCmd.Flag("confirm", "Confirm to remove unrelated docker").Bool()
 	g.SystemGCRegistryCmd.DryRun = g.SystemGCRegistryCmd.Flag("dry-run", "Only list docker images to remove w/o removing them").Bool()
+	g.SystemGCRegistryCmd.KeepLast = g.SystemGCRegistryCmd.Flag("keep-last", "Number of most recent application versions to keep images for").Int()
+	g.SystemGCRegistryCmd.KeepFor = g.SystemGCRegistryCmd.Flag("keep-for", "Keep images of application versions created within the specified duration").Duration()
+	g.SystemGCRegistryCmd.MaxSize = new(utils.Capacity)
+	g.SystemGCRegistryCmd.Flag("max-size", "Maximum total size of application versions to keep images for, e.g. 10GB").SetValue(g.SystemGCRegistryCmd.MaxSize)
+
+	g.SystemGCKubernetesCmd.CmdClause = systemGCCmd.Command("kubernetes", "Prune completed hook jobs and resources of uninstalled applications.")
+	g.SystemGCKubernetesCmd.DryRun = g.SystemGCKubernetesCmd.Flag("dry-run", "Only list Kubernetes resources to remove w/o removing them").Bool()
+	g.SystemGCKubernetesCmd.RetentionAge = g.SystemGCKubernetesCmd.Flag("retention", "Remove orphaned resources older than the specified duration").Default(defaults.GarbageCollectRetentionAge.String()).Duration()
+	g.SystemGCKubernetesCmd.KeepHookJobs = g.SystemGCKubernetesCmd.Flag("keep-hook-jobs", "Number of most recent completed jobs to keep for each application hook").Default(strconv.Itoa(defaults.GarbageCollectHookJobs)).Int()
+	g.SystemGCKubernetesCmd.PruneReleaseResources = g.SystemGCKubernetesCmd.Flag("prune-release-resources", "Remove Kubernetes resources left behind by uninstalled Helm releases").Bool()
 
 	// operations on planet (planet plugin)
 	g.PlanetCmd.CmdClause = g.Command("planet", "operations with planet").Hidden()
@@ -709,6 +793,7 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.ResourceCreateCmd.User = g.ResourceCreateCmd.Flag("user", "user to create resource for, defaults to currently logged in user").String()
 	g.ResourceCreateCmd.Manual = g.ResourceCreateCmd.Flag("manual", "manually execute operation phases").Short('m').Bool()
 	g.ResourceCreateCmd.Confirmed = g.ResourceCreateCmd.Flag("confirm", "do not ask for confirmation").Bool()
+	g.ResourceCreateCmd.IgnoreMaintenanceWindow = g.ResourceCreateCmd.Flag("ignore-maintenance-window", "Start the operation right away even if the cluster maintenance window is closed").Bool()
 
 	// remove one or many resources
 	g.ResourceRemoveCmd.CmdClause = g.ResourceCmd.Command("rm", fmt.Sprintf("Remove a configuration resource, e.g. gravity resource rm oidc google. Supported resources are: %v", modules.GetResources().SupportedResourcesToRemove()))
@@ -719,6 +804,7 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.ResourceRemoveCmd.User = g.ResourceRemoveCmd.Flag("user", "user to remove resource for, defaults to currently logged in user").String()
 	g.ResourceRemoveCmd.Manual = g.ResourceRemoveCmd.Flag("manual", "manually execute operation phases").Short('m').Bool()
 	g.ResourceRemoveCmd.Confirmed = g.ResourceRemoveCmd.Flag("confirm", "do not ask for confirmation").Bool()
+	g.ResourceRemoveCmd.IgnoreMaintenanceWindow = g.ResourceRemoveCmd.Flag("ignore-maintenance-window", "Start the operation right away even if the cluster maintenance window is closed").Bool()
 
 	// get resources returns resources
 	g.ResourceGetCmd.CmdClause = g.ResourceCmd.Command("get", fmt.Sprintf("Get configuration resources, e.g. gravity get oidc. Supported resources are: %v",
@@ -42,23 +42,20 @@ import (
 
 // systemReport collects system diagnostics and outputs them as a (optionally compressed) tarball
 // to the stdout.
-// filters define the specific diagnostics to collect ('system', 'kubernetes'),
+// filters define the specific diagnostics to collect ('system', 'kubernetes', 'etcd', 'planet'),
 // if empty all diagnostics are collected.
-func systemReport(env *localenv.LocalEnvironment, filters []string, compressed bool) error {
-	var collectors report.Collectors
+// Collected logs are limited to the specified time range
+func systemReport(env *localenv.LocalEnvironment, filters []string, compressed bool, timeRange report.TimeRange) error {
+	if err := report.CheckFilters(filters); err != nil {
+		return trace.Wrap(err)
+	}
+	filters = teleutils.Deduplicate(filters)
 	ctx := context.Background()
-	for _, filter := range teleutils.Deduplicate(filters) {
-		switch filter {
-		case constants.ReportFilterSystem:
-			collectors = append(collectors, report.SystemInfo()...)
-			collectors = append(collectors, packageCollector{env})
-		case constants.ReportFilterKubernetes:
-			collectors = append(collectors, report.KubernetesInfo(ctx, utils.Runner)...)
-		}
+	collectors := report.NodeCollectors(filters, timeRange)
+	if report.HasFilter(filters, constants.ReportFilterKubernetes) {
+		collectors = append(collectors, report.KubernetesInfo(ctx, utils.Runner, timeRange)...)
 	}
-	if len(filters) == 0 {
-		collectors = append(collectors, report.SystemInfo()...)
-		collectors = append(collectors, report.KubernetesInfo(ctx, utils.Runner)...)
+	if report.HasFilter(filters, constants.ReportFilterSystem) {
 		collectors = append(collectors, packageCollector{env})
 	}
 
@@ -93,6 +90,24 @@ func systemReport(env *localenv.LocalEnvironment, filters []string, compressed b
 	return trace.ConvertSystemError(err)
 }
 
+// parseTimeRange parses the time range bounds given either as durations
+// relative to the current time or as RFC3339 timestamps
+func parseTimeRange(since, until string) (timeRange report.TimeRange, err error) {
+	now := time.Now()
+	timeRange.Since, err = report.ParseTime(since, now)
+	if err != nil {
+		return timeRange, trace.Wrap(err)
+	}
+	timeRange.Until, err = report.ParseTime(until, now)
+	if err != nil {
+		return timeRange, trace.Wrap(err)
+	}
+	if err := timeRange.Check(); err != nil {
+		return timeRange, trace.Wrap(err)
+	}
+	return timeRange, nil
+}
+
 // Collect iterates through the system packages and outputs them
 // using the specified reportWriter.
 func (r packageCollector) Collect(ctx context.Context, reportWriter report.Writer, runner utils.CommandRunner) error {
@@ -47,7 +47,7 @@ type LocalEnvironmentFactory interface {
 // manual controls whether the operation is created in manual mode if resource creation is implemented
 // as a cluster operation.
 // confirmed specifies if the user has explicitly approved the operation
-func createResource(env *localenv.LocalEnvironment, factory LocalEnvironmentFactory, filename string, upsert bool, user string, manual, confirmed bool) error {
+func createResource(env *localenv.LocalEnvironment, factory LocalEnvironmentFactory, filename string, upsert bool, user string, manual, confirmed, ignoreWindow bool) error {
 	operator, err := env.SiteOperator()
 	if err != nil {
 		return trace.Wrap(err)
@@ -70,10 +70,11 @@ func createResource(env *localenv.LocalEnvironment, factory LocalEnvironmentFact
 	control := resources.NewControl(gravityResources)
 	err = resources.ForEach(reader, func(resource storage.UnknownResource) error {
 		req := resources.CreateRequest{
-			Upsert:    upsert,
-			Owner:     user,
-			Manual:    manual,
-			Confirmed: confirmed,
+			Upsert:                  upsert,
+			Owner:                   user,
+			Manual:                  manual,
+			Confirmed:               confirmed,
+			IgnoreMaintenanceWindow: ignoreWindow,
 		}
 		return trace.Wrap(control.Create(context.TODO(), bytes.NewReader(resource.Raw), req))
 	})
@@ -87,7 +88,7 @@ func removeResource(
 	kind, name string,
 	force bool,
 	user string,
-	manual, confirmed bool,
+	manual, confirmed, ignoreWindow bool,
 ) error {
 	operator, err := env.SiteOperator()
 	if err != nil {
@@ -104,12 +105,13 @@ func removeResource(
 		return trace.Wrap(err)
 	}
 	req := resources.RemoveRequest{
-		Kind:      kind,
-		Name:      name,
-		Force:     force,
-		Owner:     user,
-		Manual:    manual,
-		Confirmed: confirmed,
+		Kind:                    kind,
+		Name:                    name,
+		Force:                   force,
+		Owner:                   user,
+		Manual:                  manual,
+		Confirmed:               confirmed,
+		IgnoreMaintenanceWindow: ignoreWindow,
 	}
 	err = resources.NewControl(gravityResources).Remove(context.TODO(), req)
 	return trace.Wrap(err)
@@ -165,7 +167,8 @@ func (r clusterOperationHandler) RemoveResource(req resources.RemoveRequest) err
 		env := storage.NewEnvironment(nil)
 		return trace.Wrap(updateEnviron(context.TODO(), localEnv, updateEnv, env, req.Manual, req.Confirmed))
 	case storage.KindClusterConfiguration:
-		return trace.Wrap(resetConfig(context.TODO(), localEnv, updateEnv, req.Manual, req.Confirmed))
+		return trace.Wrap(resetConfig(context.TODO(), localEnv, updateEnv,
+			req.Manual, req.Confirmed, req.IgnoreMaintenanceWindow))
 	}
 	// unreachable
 	return trace.BadParameter("unknown resource kind %q", req.Kind)
@@ -201,7 +204,7 @@ func (r clusterOperationHandler) UpdateResource(req resources.CreateRequest) err
 			return trace.Wrap(err)
 		}
 		return trace.Wrap(updateConfig(context.TODO(), localEnv, updateEnv,
-			config, req.Manual, req.Confirmed))
+			config, req.Manual, req.Confirmed, req.IgnoreMaintenanceWindow))
 	}
 	// unreachable
 	return trace.BadParameter("unknown resource kind %q", req.Resource.Kind)
@@ -36,6 +36,7 @@ import (
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/process"
 	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/systemservice"
 	"github.com/gravitational/gravity/lib/utils"
 
@@ -100,6 +101,7 @@ func InitAndCheck(g *Application, cmd string) error {
 		g.PlanRollbackCmd.FullCommand(),
 		g.PlanResumeCmd.FullCommand(),
 		g.UpgradeCmd.FullCommand(),
+		g.RollbackCmd.FullCommand(),
 		g.ResourceCreateCmd.FullCommand():
 		if *g.Debug {
 			teleutils.InitLogger(teleutils.LoggingForDaemon, level)
@@ -116,9 +118,11 @@ func InitAndCheck(g *Application, cmd string) error {
 		g.WizardCmd.FullCommand(),
 		g.JoinCmd.FullCommand(),
 		g.AutoJoinCmd.FullCommand(),
+		g.ReplaceCmd.FullCommand(),
 		g.UpdateTriggerCmd.FullCommand(),
 		g.UpdatePlanInitCmd.FullCommand(),
 		g.UpgradeCmd.FullCommand(),
+		g.RollbackCmd.FullCommand(),
 		g.RPCAgentRunCmd.FullCommand(),
 		g.LeaveCmd.FullCommand(),
 		g.RemoveCmd.FullCommand(),
@@ -128,7 +132,7 @@ func InitAndCheck(g *Application, cmd string) error {
 		// the current directory for convenience, unless the user set their
 		// own location
 		switch cmd {
-		case g.InstallCmd.FullCommand(), g.JoinCmd.FullCommand():
+		case g.InstallCmd.FullCommand(), g.JoinCmd.FullCommand(), g.ReplaceCmd.FullCommand():
 			if *g.SystemLogFile == defaults.GravitySystemLog {
 				install.InitLogging(defaults.GravitySystemLogFile)
 			}
@@ -181,15 +185,18 @@ func InitAndCheck(g *Application, cmd string) error {
 		g.PlanRollbackCmd.FullCommand(),
 		g.PlanResumeCmd.FullCommand(),
 		g.PlanCompleteCmd.FullCommand(),
+		g.RollbackCmd.FullCommand(),
 		g.InstallCmd.FullCommand(),
 		g.JoinCmd.FullCommand(),
 		g.AutoJoinCmd.FullCommand(),
+		g.ReplaceCmd.FullCommand(),
 		g.SystemDevicemapperMountCmd.FullCommand(),
 		g.SystemDevicemapperUnmountCmd.FullCommand(),
 		g.BackupCmd.FullCommand(),
 		g.RestoreCmd.FullCommand(),
 		g.GarbageCollectCmd.FullCommand(),
 		g.SystemGCRegistryCmd.FullCommand(),
+		g.SystemGCKubernetesCmd.FullCommand(),
 		g.CheckCmd.FullCommand():
 		if err := checkRunningAsRoot(); err != nil {
 			return trace.Wrap(err)
@@ -249,7 +256,8 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 		g.AppUpgradeCmd.FullCommand(),
 		g.AppRollbackCmd.FullCommand(),
 		g.AppUninstallCmd.FullCommand(),
-		g.AppHistoryCmd.FullCommand():
+		g.AppHistoryCmd.FullCommand(),
+		g.AppMigrateReleasesCmd.FullCommand():
 		if err := httplib.InGravity(localEnv.DNS.Addr()); err != nil {
 			if !httplib.InKubernetes() {
 				return trace.BadParameter("this command must be executed " +
@@ -327,6 +335,8 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			}, nil)
 		}
 		return Join(localEnv, joinEnv, NewJoinConfig(g))
+	case g.ReplaceCmd.FullCommand():
+		return Join(localEnv, joinEnv, NewReplaceConfig(g))
 	case g.AutoJoinCmd.FullCommand():
 		return autojoin(localEnv, joinEnv, autojoinConfig{
 			systemLogFile: *g.SystemLogFile,
@@ -340,12 +350,30 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 	case g.UpdateCheckCmd.FullCommand():
 		return updateCheck(localEnv, *g.UpdateCheckCmd.App)
 	case g.UpdateTriggerCmd.FullCommand():
+		drainPolicy, err := g.UpdateTriggerCmd.Drain.policy()
+		if err != nil {
+			return trace.Wrap(err)
+		}
 		return updateTrigger(localEnv,
 			updateEnv,
 			*g.UpdateTriggerCmd.App,
 			*g.UpdateTriggerCmd.Manual,
 			*g.UpdateTriggerCmd.Block,
 			*g.UpdateTriggerCmd.SkipVersionCheck,
+			updateStrategyConfig{
+				canarySelector:    *g.UpdateTriggerCmd.CanarySelector,
+				canaryPause:       *g.UpdateTriggerCmd.CanaryPause,
+				batchSize:         *g.UpdateTriggerCmd.BatchSize,
+				maxUnavailable:    *g.UpdateTriggerCmd.MaxUnavailable,
+				healthGates:       *g.UpdateTriggerCmd.HealthGates,
+				healthTimeout:     *g.UpdateTriggerCmd.HealthTimeout,
+				healthChecks:      *g.UpdateTriggerCmd.HealthChecks,
+				prometheusURL:     *g.UpdateTriggerCmd.PrometheusURL,
+				prometheusQueries: *g.UpdateTriggerCmd.PrometheusQueries,
+				autoRollback:      *g.UpdateTriggerCmd.AutoRollback,
+			}.strategy(),
+			drainPolicy,
+			*g.UpdateTriggerCmd.IgnoreMaintenanceWindow,
 		)
 	case g.UpdatePlanInitCmd.FullCommand():
 		return initUpdateOperationPlan(localEnv, updateEnv)
@@ -362,12 +390,30 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 					SkipVersionCheck: *g.UpgradeCmd.SkipVersionCheck,
 				})
 		}
+		drainPolicy, err := g.UpgradeCmd.Drain.policy()
+		if err != nil {
+			return trace.Wrap(err)
+		}
 		return updateTrigger(localEnv,
 			updateEnv,
 			*g.UpgradeCmd.App,
 			*g.UpgradeCmd.Manual,
 			*g.UpgradeCmd.Block,
 			*g.UpgradeCmd.SkipVersionCheck,
+			updateStrategyConfig{
+				canarySelector:    *g.UpgradeCmd.CanarySelector,
+				canaryPause:       *g.UpgradeCmd.CanaryPause,
+				batchSize:         *g.UpgradeCmd.BatchSize,
+				maxUnavailable:    *g.UpgradeCmd.MaxUnavailable,
+				healthGates:       *g.UpgradeCmd.HealthGates,
+				healthTimeout:     *g.UpgradeCmd.HealthTimeout,
+				healthChecks:      *g.UpgradeCmd.HealthChecks,
+				prometheusURL:     *g.UpgradeCmd.PrometheusURL,
+				prometheusQueries: *g.UpgradeCmd.PrometheusQueries,
+				autoRollback:      *g.UpgradeCmd.AutoRollback,
+			}.strategy(),
+			drainPolicy,
+			*g.UpgradeCmd.IgnoreMaintenanceWindow,
 		)
 	case g.PlanExecuteCmd.FullCommand():
 		return executePhase(localEnv, updateEnv, joinEnv,
@@ -396,7 +442,14 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 				SkipVersionCheck: *g.PlanCmd.SkipVersionCheck,
 				OperationID:      *g.PlanCmd.OperationID,
 			})
+	case g.RollbackCmd.FullCommand():
+		return rollbackOperation(localEnv, updateEnv, joinEnv,
+			*g.RollbackCmd.OperationID, *g.RollbackCmd.Timeout, *g.RollbackCmd.Force)
 	case g.PlanDisplayCmd.FullCommand():
+		if *g.PlanDisplayCmd.Hooks {
+			return displayHookAttempts(localEnv, updateEnv, joinEnv,
+				*g.PlanCmd.OperationID, *g.PlanDisplayCmd.Output)
+		}
 		return displayOperationPlan(localEnv, updateEnv, joinEnv,
 			*g.PlanCmd.OperationID, *g.PlanDisplayCmd.Output)
 	case g.PlanCompleteCmd.FullCommand():
@@ -407,10 +460,16 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			confirmed: *g.LeaveCmd.Confirm,
 		})
 	case g.RemoveCmd.FullCommand():
+		drainPolicy, err := g.RemoveCmd.Drain.policy()
+		if err != nil {
+			return trace.Wrap(err)
+		}
 		return remove(localEnv, removeConfig{
-			server:    *g.RemoveCmd.Node,
-			force:     *g.RemoveCmd.Force,
-			confirmed: *g.RemoveCmd.Confirm,
+			server:       *g.RemoveCmd.Node,
+			force:        *g.RemoveCmd.Force,
+			confirmed:    *g.RemoveCmd.Confirm,
+			ignoreWindow: *g.RemoveCmd.IgnoreMaintenanceWindow,
+			drainPolicy:  drainPolicy,
 		})
 	case g.StatusCmd.FullCommand():
 		printOptions := printOptions{
@@ -447,6 +506,7 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 				CertPath: *g.AppInstallCmd.RegistryCert,
 				KeyPath:  *g.AppInstallCmd.RegistryKey,
 			},
+			DryRun: *g.AppInstallCmd.DryRun,
 		})
 	case g.AppListCmd.FullCommand():
 		return releaseList(localEnv,
@@ -465,6 +525,7 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 				CertPath: *g.AppUpgradeCmd.RegistryCert,
 				KeyPath:  *g.AppUpgradeCmd.RegistryKey,
 			},
+			DryRun: *g.AppUpgradeCmd.DryRun,
 		})
 	case g.AppRollbackCmd.FullCommand():
 		return releaseRollback(localEnv, releaseRollbackConfig{
@@ -479,6 +540,10 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 		return releaseHistory(localEnv, releaseHistoryConfig{
 			Release: *g.AppHistoryCmd.Release,
 		})
+	case g.AppMigrateReleasesCmd.FullCommand():
+		return releaseMigrate(localEnv,
+			*g.AppMigrateReleasesCmd.DryRun,
+			*g.AppMigrateReleasesCmd.Cleanup)
 	case g.AppSyncCmd.FullCommand():
 		return appSync(localEnv, appSyncConfig{
 			Image: *g.AppSyncCmd.Image,
@@ -672,7 +737,16 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			*g.APIKeyDeleteCmd.Email,
 			*g.APIKeyDeleteCmd.Token)
 	case g.ReportCmd.FullCommand():
-		return getClusterReport(localEnv, *g.ReportCmd.FilePath)
+		return getClusterReport(localEnv, clusterReportConfig{
+			targetFile:     *g.ReportCmd.FilePath,
+			redactIPs:      *g.ReportCmd.RedactIPs,
+			redactionRules: *g.ReportCmd.RedactionRules,
+			since:          *g.ReportCmd.Since,
+			until:          *g.ReportCmd.Until,
+			collectors:     *g.ReportCmd.Collectors,
+			nodes:          *g.ReportCmd.Nodes,
+			dryRun:         *g.ReportCmd.DryRun,
+		})
 	// cluster commands
 	case g.SiteListCmd.FullCommand():
 		return listSites(localEnv, *g.SiteListCmd.OpsCenterURL)
@@ -772,9 +846,14 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 	case g.SystemUninstallCmd.FullCommand():
 		return systemUninstall(localEnv, *g.SystemUninstallCmd.Confirmed)
 	case g.SystemReportCmd.FullCommand():
+		timeRange, err := parseTimeRange(*g.SystemReportCmd.Since, *g.SystemReportCmd.Until)
+		if err != nil {
+			return trace.Wrap(err)
+		}
 		return systemReport(localEnv,
 			*g.SystemReportCmd.Filter,
-			*g.SystemReportCmd.Compressed)
+			*g.SystemReportCmd.Compressed,
+			timeRange)
 	case g.SystemStateDirCmd.FullCommand():
 		return printStateDir()
 	case g.SystemEnablePromiscModeCmd.FullCommand():
@@ -782,11 +861,25 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 	case g.SystemDisablePromiscModeCmd.FullCommand():
 		return disablePromiscMode(localEnv, *g.SystemDisablePromiscModeCmd.Iface)
 	case g.SystemExportRuntimeJournalCmd.FullCommand():
-		return exportRuntimeJournal(localEnv, *g.SystemExportRuntimeJournalCmd.OutputFile)
+		timeRange, err := parseTimeRange(*g.SystemExportRuntimeJournalCmd.Since, *g.SystemExportRuntimeJournalCmd.Until)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		return exportRuntimeJournal(localEnv, *g.SystemExportRuntimeJournalCmd.OutputFile, timeRange)
 	case g.SystemStreamRuntimeJournalCmd.FullCommand():
-		return streamRuntimeJournal(localEnv)
+		timeRange, err := parseTimeRange(*g.SystemStreamRuntimeJournalCmd.Since, *g.SystemStreamRuntimeJournalCmd.Until)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		return streamRuntimeJournal(localEnv, timeRange)
 	case g.GarbageCollectCmd.FullCommand():
-		return garbageCollect(localEnv, *g.GarbageCollectCmd.Manual, *g.GarbageCollectCmd.Confirmed)
+		return garbageCollect(localEnv, *g.GarbageCollectCmd.Manual, *g.GarbageCollectCmd.Confirmed,
+			*g.GarbageCollectCmd.IgnoreMaintenanceWindow,
+			storage.RetentionPolicy{
+				MaxAge:                *g.GarbageCollectCmd.RetentionAge,
+				HookJobs:              *g.GarbageCollectCmd.KeepHookJobs,
+				PruneReleaseResources: *g.GarbageCollectCmd.PruneReleaseResources,
+			})
 	case g.SystemGCJournalCmd.FullCommand():
 		return removeUnusedJournalFiles(localEnv,
 			*g.SystemGCJournalCmd.MachineIDFile,
@@ -794,11 +887,25 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 	case g.SystemGCPackageCmd.FullCommand():
 		return removeUnusedPackages(localEnv,
 			*g.SystemGCPackageCmd.DryRun,
-			*g.SystemGCPackageCmd.Cluster)
+			*g.SystemGCPackageCmd.Cluster,
+			quotaPolicy(*g.SystemGCPackageCmd.KeepLast,
+				*g.SystemGCPackageCmd.KeepFor,
+				*g.SystemGCPackageCmd.MaxSize))
+	case g.SystemGCKubernetesCmd.FullCommand():
+		return removeOrphanedKubernetesResources(localEnv,
+			*g.SystemGCKubernetesCmd.DryRun,
+			storage.RetentionPolicy{
+				MaxAge:                *g.SystemGCKubernetesCmd.RetentionAge,
+				HookJobs:              *g.SystemGCKubernetesCmd.KeepHookJobs,
+				PruneReleaseResources: *g.SystemGCKubernetesCmd.PruneReleaseResources,
+			})
 	case g.SystemGCRegistryCmd.FullCommand():
 		return removeUnusedImages(localEnv,
 			*g.SystemGCRegistryCmd.DryRun,
-			*g.SystemGCRegistryCmd.Confirm)
+			*g.SystemGCRegistryCmd.Confirm,
+			quotaPolicy(*g.SystemGCRegistryCmd.KeepLast,
+				*g.SystemGCRegistryCmd.KeepFor,
+				*g.SystemGCRegistryCmd.MaxSize))
 	case g.PlanetEnterCmd.FullCommand(), g.EnterCmd.FullCommand():
 		return planetEnter(localEnv, extraArgs)
 	case g.ExecCmd.FullCommand():
@@ -832,7 +939,8 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			*g.ResourceCreateCmd.Upsert,
 			*g.ResourceCreateCmd.User,
 			*g.ResourceCreateCmd.Manual,
-			*g.ResourceCreateCmd.Confirmed)
+			*g.ResourceCreateCmd.Confirmed,
+			*g.ResourceCreateCmd.IgnoreMaintenanceWindow)
 	case g.ResourceRemoveCmd.FullCommand():
 		return removeResource(localEnv, g,
 			*g.ResourceRemoveCmd.Kind,
@@ -840,7 +948,8 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			*g.ResourceRemoveCmd.Force,
 			*g.ResourceRemoveCmd.User,
 			*g.ResourceRemoveCmd.Manual,
-			*g.ResourceRemoveCmd.Confirmed)
+			*g.ResourceRemoveCmd.Confirmed,
+			*g.ResourceRemoveCmd.IgnoreMaintenanceWindow)
 	case g.ResourceGetCmd.FullCommand():
 		return getResources(localEnv,
 			*g.ResourceGetCmd.Kind,
@@ -34,6 +34,7 @@ import (
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/process"
 	gcfg "github.com/gravitational/gravity/lib/processconfig"
+	"github.com/gravitational/gravity/lib/report"
 
 	yaml "github.com/ghodss/yaml"
 	"github.com/gravitational/trace"
@@ -122,12 +123,11 @@ func listSites(env *localenv.LocalEnvironment, opsCenterURL string) error {
 	return nil
 }
 
-func getClusterReport(env *localenv.LocalEnvironment, targetFile string) error {
-	f, err := os.Create(targetFile)
+func getClusterReport(env *localenv.LocalEnvironment, config clusterReportConfig) error {
+	req, err := config.request()
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	defer f.Close()
 
 	operator, err := env.SiteOperator()
 	if err != nil {
@@ -139,23 +139,84 @@ func getClusterReport(env *localenv.LocalEnvironment, targetFile string) error {
 		return trace.Wrap(err)
 	}
 
-	report, err := operator.GetSiteReport(ops.SiteKey{
+	req.SiteKey = ops.SiteKey{
 		AccountID:  site.AccountID,
 		SiteDomain: site.Domain,
-	})
+	}
+	reader, err := operator.GetSiteReport(*req)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	defer report.Close()
+	defer reader.Close()
 
-	if _, err := io.Copy(f, report); err != nil {
+	if config.dryRun {
+		_, err = io.Copy(os.Stdout, reader)
 		return trace.Wrap(err)
 	}
 
-	fmt.Printf("report for %v exported to %v\n", site, targetFile)
+	f, err := os.Create(config.targetFile)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, reader); err != nil {
+		return trace.Wrap(err)
+	}
+
+	fmt.Printf("report for %v exported to %v\n", site, config.targetFile)
 	return nil
 }
 
+// request returns the cluster report request for this configuration
+func (r clusterReportConfig) request() (*ops.SiteReportRequest, error) {
+	req := ops.SiteReportRequest{
+		Redaction:  report.RedactionConfig{RedactIPs: r.redactIPs},
+		Collectors: r.collectors,
+		Nodes:      r.nodes,
+		DryRun:     r.dryRun,
+	}
+	if r.redactionRules != "" {
+		data, err := ioutil.ReadFile(r.redactionRules)
+		if err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+		req.Redaction.Rules, err = report.ParseRedactionRules(data)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	var err error
+	req.TimeRange, err = parseTimeRange(r.since, r.until)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := req.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &req, nil
+}
+
+// clusterReportConfig defines the cluster report to collect
+type clusterReportConfig struct {
+	// targetFile is the path to the resulting report tarball
+	targetFile string
+	// redactIPs specifies whether to redact IP addresses
+	redactIPs bool
+	// redactionRules is the path to the file with additional redaction rules
+	redactionRules string
+	// since is the lower bound of the collected logs
+	since string
+	// until is the upper bound of the collected logs
+	until string
+	// collectors lists the collector groups to run
+	collectors []string
+	// nodes lists the nodes to collect diagnostics from
+	nodes []string
+	// dryRun specifies whether to only list what would be collected
+	dryRun bool
+}
+
 // ClusterInfo collects information about the local cluster
 type ClusterInfo struct {
 	// App contains the information about the application running in cluster
@@ -137,7 +137,7 @@ func (g *Application) isInstallCommand(cmd string) bool {
 // a "gravity join" command
 func (g *Application) isJoinCommand(cmd string) bool {
 	switch cmd {
-	case g.JoinCmd.FullCommand():
+	case g.JoinCmd.FullCommand(), g.ReplaceCmd.FullCommand():
 		return true
 	}
 	return false
@@ -171,6 +171,7 @@ func (g *Application) isUpdateCommand(cmd string) bool {
 func (g *Application) isExpandCommand(cmd string) bool {
 	switch cmd {
 	case g.JoinCmd.FullCommand(), g.AutoJoinCmd.FullCommand(),
+		g.ReplaceCmd.FullCommand(),
 		g.PlanCmd.FullCommand(),
 		g.PlanDisplayCmd.FullCommand(),
 		g.PlanExecuteCmd.FullCommand(),
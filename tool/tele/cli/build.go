@@ -19,11 +19,15 @@ package cli
 import (
 	"context"
 
+	"github.com/gravitational/gravity/lib/app/docker"
 	"github.com/gravitational/gravity/lib/app/service"
 	"github.com/gravitational/gravity/lib/builder"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/helm"
 	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/trace"
+	"k8s.io/helm/pkg/provenance"
 )
 
 // BuildParameters represents the arguments provided for building an application
@@ -44,10 +48,107 @@ type BuildParameters struct {
 	Silent bool
 	// Insecure turns on insecure verify mode
 	Insecure bool
+	// UpgradeFrom lists the installed runtime versions the image should be able to upgrade from
+	UpgradeFrom []string
+	// SignKey is the name of the key to sign the application chart with
+	SignKey string
+	// Keyring is the path to the secret keyring with the signing key
+	Keyring string
+	// PassphraseFile is the path to the file with the signing key passphrase
+	PassphraseFile string
+	// Registry configures pulling images directly from registries
+	Registry RegistryParameters
+	// Policy is the path to the image policy file
+	Policy string
+	// ScanReports lists the vulnerability scanner reports to check images against
+	ScanReports []string
+}
+
+// imagePolicy returns the image policy for the parameters or nil
+// if the images are not restricted
+func (r BuildParameters) imagePolicy() (*docker.ImagePolicy, error) {
+	if r.Policy == "" && len(r.ScanReports) == 0 {
+		return nil, nil
+	}
+	policy := &docker.ImagePolicy{}
+	if r.Policy != "" {
+		var err error
+		policy, err = docker.ReadImagePolicy(r.Policy)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	if err := policy.AddReports(r.ScanReports...); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// RegistryParameters configures pulling images directly from registries
+type RegistryParameters struct {
+	// Pull enables pulling images directly from registries
+	Pull bool
+	// DockerConfig is the path to the docker config file with registry credentials
+	DockerConfig string
+	// Mirrors lists registry mirrors in <registry>=<mirror> format
+	Mirrors []string
+	// Rewrites lists image rewrite rules in <prefix>=<replacement> format
+	Rewrites []string
+	// Insecure lists registries accessed without TLS verification
+	Insecure []string
+}
+
+// puller returns the registry puller for the parameters or nil
+// if the images should be pulled with the docker daemon
+func (r RegistryParameters) puller() (*docker.RegistryPuller, error) {
+	if !r.Pull {
+		if r.DockerConfig != "" || len(r.Mirrors) != 0 || len(r.Rewrites) != 0 || len(r.Insecure) != 0 {
+			return nil, trace.BadParameter("registry flags require --pull-from-registry")
+		}
+		return nil, nil
+	}
+	config := docker.RegistryPullerConfig{
+		DockerConfig:       r.DockerConfig,
+		InsecureRegistries: r.Insecure,
+	}
+	for _, spec := range r.Mirrors {
+		mirror, err := docker.ParseRegistryMirror(spec)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		config.Mirrors = append(config.Mirrors, *mirror)
+	}
+	for _, spec := range r.Rewrites {
+		rewrite, err := docker.ParseImageRewrite(spec)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		config.Rewrites = append(config.Rewrites, *rewrite)
+	}
+	return docker.NewRegistryPuller(config)
 }
 
 // build builds an installer tarball according to the provided parameters
 func build(ctx context.Context, params BuildParameters, req service.VendorRequest) (err error) {
+	var signatory *provenance.Signatory
+	if params.SignKey != "" {
+		keyring, err := utils.EnsureLocalPath(params.Keyring, defaults.GnuPGDir, defaults.SecretKeyringFile)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		signatory, err = helm.NewSignatory(keyring, params.SignKey, params.PassphraseFile)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	puller, err := params.Registry.puller()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req.Policy, err = params.imagePolicy()
+	if err != nil {
+		return trace.Wrap(err)
+	}
 	installerBuilder, err := builder.New(builder.Config{
 		Context:          ctx,
 		StateDir:         params.StateDir,
@@ -58,6 +159,9 @@ func build(ctx context.Context, params BuildParameters, req service.VendorReques
 		Repository:       params.Repository,
 		SkipVersionCheck: params.SkipVersionCheck,
 		VendorReq:        req,
+		UpgradeFrom:      params.UpgradeFrom,
+		Signatory:        signatory,
+		RegistryPuller:   puller,
 		Progress:         utils.NewProgress(ctx, "Build", 6, params.Silent),
 	})
 	if err != nil {
@@ -79,6 +79,30 @@ type BuildCmd struct {
 	Parallel *int
 	// Quiet allows to suppress console output
 	Quiet *bool
+	// UpgradeFrom lists the installed runtime versions the image should be able to upgrade from
+	UpgradeFrom *[]string
+	// SignKey is the name of the key to sign the application chart with
+	SignKey *string
+	// Keyring is the path to the secret keyring with the signing key
+	Keyring *string
+	// PassphraseFile is the path to the file with the signing key passphrase
+	PassphraseFile *string
+	// PullFromRegistry pulls images directly from registries instead of the docker daemon
+	PullFromRegistry *bool
+	// DockerConfig is the path to the docker config file with registry credentials
+	DockerConfig *string
+	// RegistryMirrors lists registry mirrors in <registry>=<mirror> format
+	RegistryMirrors *[]string
+	// RegistryRewrites lists image rewrite rules in <prefix>=<replacement> format
+	RegistryRewrites *[]string
+	// InsecureRegistries lists registries accessed without TLS verification
+	InsecureRegistries *[]string
+	// Policy is the path to the image policy file
+	Policy *string
+	// ScanReports lists the vulnerability scanner reports to check images against
+	ScanReports *[]string
+	// PinDigests rewrites the vendored images to reference their manifest digests
+	PinDigests *bool
 }
 
 type ListCmd struct {
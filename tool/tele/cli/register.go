@@ -54,6 +54,18 @@ func RegisterCommands(app *kingpin.Application) Application {
 	tele.BuildCmd.SkipVersionCheck = tele.BuildCmd.Flag("skip-version-check", "Skip version compatibility check").Hidden().Bool()
 	tele.BuildCmd.Parallel = tele.BuildCmd.Flag("parallel", "Specifies the number of concurrent tasks. If < 0, the number of tasks is not restricted, if unspecified, then tasks are capped at the number of logical CPU cores").Int()
 	tele.BuildCmd.Quiet = tele.BuildCmd.Flag("quiet", "Suppress any extra output to stdout").Short('q').Bool()
+	tele.BuildCmd.UpgradeFrom = tele.BuildCmd.Flag("upgrade-from", "Version of the installed base image the cluster image should be able to upgrade from, bundles intermediate base images if necessary. Can be specified multiple times").Strings()
+	tele.BuildCmd.SignKey = tele.BuildCmd.Flag("sign", "Name of the key to sign the application chart with, produces a provenance file the cluster verifies on install and upgrade").String()
+	tele.BuildCmd.Keyring = tele.BuildCmd.Flag("keyring", "Path to the secret keyring with the signing key, defaults to ~/.gnupg/secring.gpg").String()
+	tele.BuildCmd.PassphraseFile = tele.BuildCmd.Flag("passphrase-file", "Path to the file with the passphrase of the signing key").String()
+	tele.BuildCmd.PullFromRegistry = tele.BuildCmd.Flag("pull-from-registry", "Pull container images directly from their registries instead of using the local docker daemon").Bool()
+	tele.BuildCmd.DockerConfig = tele.BuildCmd.Flag("docker-config", "Path to the docker config file with registry credentials, defaults to ~/.docker/config.json").String()
+	tele.BuildCmd.RegistryMirrors = tele.BuildCmd.Flag("registry-mirror", "Registry mirror to try first when pulling from registries, in <registry>=<mirror> format, e.g. docker.io=mirror.example.com. Can be specified multiple times").Strings()
+	tele.BuildCmd.RegistryRewrites = tele.BuildCmd.Flag("registry-rewrite", "Rewrite image references starting with the prefix when pulling from registries, in <prefix>=<replacement> format, e.g. quay.io/=registry.example.com/quay/. Can be specified multiple times").Strings()
+	tele.BuildCmd.Policy = tele.BuildCmd.Flag("policy", "Path to the image policy file restricting the vendored images").String()
+	tele.BuildCmd.ScanReports = tele.BuildCmd.Flag("scan-report", "Path to the vulnerability scanner report (Trivy JSON) to check the vendored images against. Can be specified multiple times").Strings()
+	tele.BuildCmd.PinDigests = tele.BuildCmd.Flag("pin-digests", "Rewrite the vendored images to reference their manifest digests and verify the digests when the images are pushed into the cluster registry").Bool()
+	tele.BuildCmd.InsecureRegistries = tele.BuildCmd.Flag("insecure-registry", "Registry that can be accessed over plain HTTP or without certificate verification when pulling from registries. Can be specified multiple times").Strings()
 
 	tele.ListCmd.CmdClause = app.Command("ls", "Display a list of user applications published in remote Ops Center")
 	tele.ListCmd.Runtimes = tele.ListCmd.Flag("runtimes", "Show only runtimes").Short('r').Hidden().Bool()
@@ -59,6 +59,19 @@ func Run(tele Application) error {
 			SkipVersionCheck: *tele.BuildCmd.SkipVersionCheck,
 			Silent:           *tele.BuildCmd.Quiet,
 			Insecure:         *tele.Insecure,
+			UpgradeFrom:      *tele.BuildCmd.UpgradeFrom,
+			SignKey:          *tele.BuildCmd.SignKey,
+			Keyring:          *tele.BuildCmd.Keyring,
+			PassphraseFile:   *tele.BuildCmd.PassphraseFile,
+			Policy:           *tele.BuildCmd.Policy,
+			ScanReports:      *tele.BuildCmd.ScanReports,
+			Registry: RegistryParameters{
+				Pull:         *tele.BuildCmd.PullFromRegistry,
+				DockerConfig: *tele.BuildCmd.DockerConfig,
+				Mirrors:      *tele.BuildCmd.RegistryMirrors,
+				Rewrites:     *tele.BuildCmd.RegistryRewrites,
+				Insecure:     *tele.BuildCmd.InsecureRegistries,
+			},
 		}, service.VendorRequest{
 			PackageName:            *tele.BuildCmd.Name,
 			PackageVersion:         *tele.BuildCmd.Version,
@@ -67,6 +80,7 @@ func Run(tele Application) error {
 			SetImages:              *tele.BuildCmd.SetImages,
 			SetDeps:                *tele.BuildCmd.SetDeps,
 			Parallel:               *tele.BuildCmd.Parallel,
+			PinDigests:             *tele.BuildCmd.PinDigests,
 			VendorRuntime:          true,
 		})
 	}